@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/internal/service"
+	"blueberry/pkg/logger"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	accountsCheckNames    []string
+	accountsCheckJSON     bool
+	accountsCheckWarnDays int
+	accountsCheckOffline  bool
+
+	accountsReleaseAll bool
+)
+
+var accountsCmd = &cobra.Command{
+	Use:   "accounts",
+	Short: "管理 B站上传账号",
+}
+
+var accountsCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "检查各账号的 cookies、登录状态与今日额度",
+	Long: `检查 bilibili_accounts 中的每个账号：cookies 文件能否读取、是否包含 CSRF（csrf / bili_jct）、
+SESSDATA 的剩余有效期、是否仍处于登录状态（请求创作中心稿件列表），以及今日剩余的上传额度；
+同时检查 youtube.cookies_file 中登录 cookie 的有效期。
+
+上传因 cookies 失效、风控限流或封禁失败后，账号按 bilibili.quarantine 被隔离，隔离期内显示为不可用。
+
+任一账号不可用（cookies 缺失 / 已过期、缺少 CSRF、未登录、处于隔离期）时以非 0 状态码退出，可用于定时监控。
+cookies 即将过期（--warn-days 天内）或今日额度已用尽只给出警告。
+
+示例：
+  blueberry accounts check
+  blueberry accounts check --account account1 --json
+  blueberry accounts check --offline --warn-days 3`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.Get()
+		if cfg == nil {
+			fmt.Fprintf(os.Stderr, "配置未加载\n")
+			exit(1)
+		}
+		// 检查过程中加载 cookies 的日志会干扰表格输出
+		logger.SetLevel(zerolog.WarnLevel)
+
+		accountService := service.NewAccountService(file.NewRepository(cfg.Output.Directory), cfg)
+		results, err := accountService.Check(cmd.Context(), service.AccountCheckOptions{
+			Accounts:   accountsCheckNames,
+			WarnWithin: time.Duration(accountsCheckWarnDays) * 24 * time.Hour,
+			Offline:    accountsCheckOffline,
+		})
+		if err != nil {
+			logger.Error().Err(err).Msg("检查账号失败")
+			exit(1)
+		}
+
+		unusable := 0
+		for i := range results {
+			if !results[i].Usable() {
+				unusable++
+			}
+		}
+
+		if accountsCheckJSON {
+			data, err := json.MarshalIndent(results, "", "  ")
+			if err != nil {
+				logger.Error().Err(err).Msg("序列化检查结果失败")
+				exit(1)
+			}
+			fmt.Println(string(data))
+		} else {
+			printAccountHealth(results)
+		}
+		if unusable > 0 {
+			if !accountsCheckJSON {
+				fmt.Printf("\n%d 个账号不可用\n", unusable)
+				for i := range results {
+					if results[i].Quarantine != nil {
+						fmt.Println("更新 cookies 或确认账号恢复后，可用 blueberry accounts release <账号> 解除隔离")
+						break
+					}
+				}
+			}
+			exit(1)
+		}
+	},
+}
+
+var accountsReleaseCmd = &cobra.Command{
+	Use:   "release [account...]",
+	Short: "解除账号的隔离与失败冷却",
+	Long: `上传因 cookies 失效、风控限流或封禁失败的账号会按 bilibili.quarantine 隔离一段时间，期间不参与自动选择。
+更新 cookies 或确认账号已恢复后，用该命令提前解除隔离（同时清除失败冷却）。
+
+示例：
+  blueberry accounts release account1
+  blueberry accounts release --all`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.Get()
+		if cfg == nil {
+			fmt.Fprintf(os.Stderr, "配置未加载\n")
+			exit(1)
+		}
+		if len(args) == 0 && !accountsReleaseAll {
+			fmt.Fprintf(os.Stderr, "请指定账号，或使用 --all 解除所有账号的隔离\n")
+			exit(1)
+		}
+
+		selector := service.NewAccountSelector(file.NewRepository(cfg.Output.Directory), cfg)
+		names := args
+		if accountsReleaseAll {
+			quarantines, err := selector.Quarantines()
+			if err != nil {
+				logger.Error().Err(err).Msg("读取账号隔离状态失败")
+				exit(1)
+			}
+			names = names[:0]
+			for name := range quarantines {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+		}
+		for _, name := range names {
+			if _, ok := cfg.BilibiliAccounts[name]; !ok {
+				logger.Error().Str("account", name).Msg("bilibili_accounts 中不存在该账号")
+				exit(1)
+			}
+			released, err := selector.Release(name)
+			if err != nil {
+				logger.Error().Err(err).Str("account", name).Msg("解除隔离失败")
+				exit(1)
+			}
+			if released {
+				fmt.Printf("%s: 已解除隔离\n", name)
+			} else {
+				fmt.Printf("%s: 未处于隔离期（已清除失败冷却）\n", name)
+			}
+		}
+		if len(names) == 0 {
+			fmt.Println("没有处于隔离期的账号")
+		}
+	},
+}
+
+// printAccountHealth 以表格输出账号检查结果，问题逐行列在账号下方
+func printAccountHealth(results []service.AccountHealth) {
+	fmt.Printf("%-16s %-9s %-8s %-6s %-5s %-22s %s\n", "账号", "平台", "状态", "登录", "CSRF", "cookies 过期时间", "今日额度")
+	for i := range results {
+		h := &results[i]
+		login := "-"
+		if h.LoggedIn != nil {
+			login = map[bool]string{true: "是", false: "否"}[*h.LoggedIn]
+		}
+		csrf := "-"
+		if h.Platform == "bilibili" && h.Cookies > 0 {
+			csrf = map[bool]string{true: "有", false: "无"}[h.HasCSRF]
+		}
+		expires := "-"
+		if h.ExpiresAt != nil {
+			left := "已过期"
+			if d := time.Until(*h.ExpiresAt); d > 0 {
+				left = service.FormatDuration(d)
+			}
+			expires = h.ExpiresAt.Format("2006-01-02") + "（" + left + "）"
+		}
+		quota := "-"
+		if h.Platform == "bilibili" {
+			quota = fmt.Sprintf("%d/%d（剩余 %d）", h.UploadsToday, h.DailyLimit, h.Remaining())
+		}
+		fmt.Printf("%-16s %-9s %-8s %-6s %-5s %-22s %s\n", h.Name, h.Platform, strings.ToUpper(h.Status), login, csrf, expires, quota)
+		for _, problem := range h.Problems {
+			fmt.Printf("    - %s\n", problem)
+		}
+	}
+}
+
+func init() {
+	accountsCheckCmd.Flags().StringSliceVar(&accountsCheckNames, "account", nil, "只检查这些账号（bilibili_accounts 中的名称，逗号分隔）")
+	accountsCheckCmd.Flags().BoolVar(&accountsCheckJSON, "json", false, "以 JSON 格式输出检查结果")
+	accountsCheckCmd.Flags().IntVar(&accountsCheckWarnDays, "warn-days", 7, "cookies 在该天数内过期时给出警告")
+	accountsCheckCmd.Flags().BoolVar(&accountsCheckOffline, "offline", false, "只检查 cookies 文件与额度，不请求 B站确认登录状态")
+	accountsReleaseCmd.Flags().BoolVar(&accountsReleaseAll, "all", false, "解除所有处于隔离期的账号")
+	accountsCmd.AddCommand(accountsCheckCmd, accountsReleaseCmd)
+	rootCmd.AddCommand(accountsCmd)
+}
@@ -0,0 +1,500 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/bilibili"
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	biliAccount string
+	biliCookies string
+	biliSince   string
+	biliUntil   string
+	biliTitle   string
+	biliStates  []string
+
+	biliListJSON bool
+
+	biliExportFormat string
+	biliExportOutput string
+	biliExportCopyTo string
+
+	biliDeleteAIDs   []string
+	biliDeleteAll    bool
+	biliDeleteYes    bool
+	biliDeleteDryRun bool
+)
+
+var biliCmd = &cobra.Command{
+	Use:   "bili",
+	Short: "管理 B站创作中心中已投稿的视频（列出 / 删除 / 导出）",
+	Long: `直接读取 B站创作中心的稿件库（翻页获取全部稿件），支持按投稿时间、标题正则与稿件状态过滤。
+
+账号通过 --account（bilibili_accounts 中的名称）或 --cookies（Netscape 格式 cookies 文件）指定，
+都未指定时使用 bilibili.cookies_file。稿件状态：published、reviewing、transcoding、transcode_failed、rejected、deleted、unknown。
+
+示例：
+  blueberry bili list --account account1
+  blueberry bili list --cookies cookies/blbl_1.txt --state rejected --json
+  blueberry bili export --account account1 --format csv --output library.csv
+  blueberry bili export --account account1 --state published --copy-to dist -o library.json
+  blueberry bili delete --account account1 --title '^\[测试\]' --since 2025-01-01`,
+}
+
+var biliListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出稿件",
+	Run: func(cmd *cobra.Command, args []string) {
+		archives := listBiliArchives(cmd)
+		if biliListJSON {
+			data, _ := json.MarshalIndent(archives, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+		for _, a := range archives {
+			line := fmt.Sprintf("%-18s %-16s %s  %s", a.AID, a.State, formatArchiveTime(a.CreatedAt), a.Title)
+			if a.RejectReason != "" {
+				line += "  （" + a.RejectReason + "）"
+			}
+			fmt.Println(line)
+		}
+		fmt.Printf("\n共 %d 个稿件\n", len(archives))
+	},
+}
+
+var biliExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "导出稿件库（JSON / CSV），包含本地对应的视频目录",
+	Long: `导出满足过滤条件的稿件（JSON / CSV），local_dir 为本地上传状态中记录了该 aid 的视频目录。
+--copy-to 将有本地视频目录的稿件复制到指定目录，保持相对于输出目录的路径；目标已存在时跳过。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := strings.ToLower(biliExportFormat)
+		if format != "json" && format != "csv" {
+			logger.Error().Str("format", biliExportFormat).Msg("--format 仅支持 json / csv")
+			exit(1)
+		}
+		archives := listBiliArchives(cmd)
+
+		var out io.Writer = os.Stdout
+		if biliExportOutput != "" && biliExportOutput != "-" {
+			f, err := os.Create(biliExportOutput)
+			if err != nil {
+				logger.Error().Err(err).Str("output", biliExportOutput).Msg("创建导出文件失败")
+				exit(1)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		var err error
+		if format == "csv" {
+			err = writeArchivesCSV(out, archives)
+		} else {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(archives)
+		}
+		if err != nil {
+			logger.Error().Err(err).Msg("导出稿件库失败")
+			exit(1)
+		}
+		if biliExportOutput != "" && biliExportOutput != "-" {
+			logger.Info().Int("count", len(archives)).Str("output", biliExportOutput).Msg("稿件库已导出")
+		}
+		if biliExportCopyTo != "" && !copyArchiveDirs(cmd, archives, biliExportCopyTo) {
+			exit(1)
+		}
+	},
+}
+
+// copyArchiveDirs 将稿件对应的本地视频目录复制到 destRoot，返回是否全部成功
+// 没有上传状态记录的稿件按 {output}/{aid} 目录匹配（旧版按 aid 命名的目录）
+func copyArchiveDirs(cmd *cobra.Command, archives []bilibili.Archive, destRoot string) bool {
+	ctx := cmd.Context()
+	outputDir, err := filepath.Abs(config.Get().Output.Directory)
+	if err != nil {
+		outputDir = config.Get().Output.Directory
+	}
+
+	copied, skipped, notFound, failed := 0, 0, 0, 0
+	for _, a := range archives {
+		if ctx.Err() != nil {
+			break
+		}
+		src := a.LocalDir
+		if src == "" {
+			if dir := filepath.Join(outputDir, a.AID); isDir(dir) {
+				src = dir
+			}
+		}
+		if src == "" || !isDir(src) {
+			notFound++
+			logger.Debug().Str("aid", a.AID).Str("title", a.Title).Msg("未找到本地视频目录")
+			continue
+		}
+		if absSrc, err := filepath.Abs(src); err == nil {
+			src = absSrc
+		}
+
+		rel, err := filepath.Rel(outputDir, src)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			rel = filepath.Base(src)
+		}
+		dest := filepath.Join(destRoot, rel)
+		if _, err := os.Stat(dest); err == nil {
+			skipped++
+			logger.Warn().Str("aid", a.AID).Str("dest", dest).Msg("目标目录已存在，跳过")
+			continue
+		}
+		if err := copyVideoDir(src, dest); err != nil {
+			failed++
+			logger.Error().Err(err).Str("aid", a.AID).Str("video_dir", src).Msg("复制视频目录失败")
+			continue
+		}
+		copied++
+		logger.Info().Str("aid", a.AID).Str("dest", dest).Msg("已复制视频目录")
+	}
+
+	logger.Info().
+		Int("copied", copied).
+		Int("skipped", skipped).
+		Int("not_found", notFound).
+		Int("failed", failed).
+		Str("copy_to", destRoot).
+		Msg("视频目录复制完成")
+	return failed == 0 && ctx.Err() == nil
+}
+
+// copyVideoDir 递归复制视频目录；失败时删除已复制的部分，避免留下不完整的目录
+func copyVideoDir(src, dest string) error {
+	err := filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return copyRegularFile(path, target)
+	})
+	if err != nil {
+		os.RemoveAll(dest)
+		return err
+	}
+	return nil
+}
+
+func copyRegularFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+var biliDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "删除稿件（需确认，或指定 --yes）",
+	Long: `删除满足过滤条件（或 --aid 指定）的稿件。未指定任何过滤条件时必须使用 --all 才会删除账号下的全部稿件。
+删除前列出将要删除的稿件并要求输入 yes 确认，--yes 跳过确认；--dry-run 只列出不删除。
+本地上传状态中记录了该 aid 的视频会标记为 deleted。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(biliDeleteAIDs) == 0 && !biliDeleteAll && !hasBiliFilter() {
+			logger.Error().Msg("请指定 --aid、过滤条件（--since / --until / --title / --state），或使用 --all 删除全部稿件")
+			exit(1)
+		}
+
+		archives := listBiliArchives(cmd)
+		if len(biliDeleteAIDs) > 0 {
+			wanted := make(map[string]bool, len(biliDeleteAIDs))
+			for _, aid := range biliDeleteAIDs {
+				wanted[strings.TrimSpace(aid)] = true
+			}
+			selected := archives[:0]
+			for _, a := range archives {
+				if wanted[a.AID] {
+					selected = append(selected, a)
+					delete(wanted, a.AID)
+				}
+			}
+			archives = selected
+			for aid := range wanted {
+				logger.Warn().Str("aid", aid).Msg("稿件库中未找到该 aid（或不满足过滤条件），跳过")
+			}
+		}
+		if len(archives) == 0 {
+			fmt.Println("没有需要删除的稿件")
+			return
+		}
+
+		for i, a := range archives {
+			if i == 10 && !biliDeleteDryRun {
+				fmt.Printf("  ... 还有 %d 个稿件\n", len(archives)-10)
+				break
+			}
+			fmt.Printf("  - [%s] %s  %s  %s\n", a.AID, a.State, formatArchiveTime(a.CreatedAt), a.Title)
+		}
+		if biliDeleteDryRun {
+			fmt.Printf("\n将删除 %d 个稿件（dry-run，未删除）\n", len(archives))
+			return
+		}
+		if !biliDeleteYes && !confirm(fmt.Sprintf("\n确认删除以上 %d 个稿件？(输入 'yes' 确认): ", len(archives))) {
+			fmt.Println("已取消删除操作")
+			return
+		}
+
+		ctx := cmd.Context()
+		library := bilibili.NewLibrary(biliCookiesFile())
+		account := biliAccountConfig()
+		repo := file.NewRepository(config.Get().Output.Directory)
+		deleted, failed := 0, 0
+		for i, a := range archives {
+			if ctx.Err() != nil {
+				break
+			}
+			if i > 0 {
+				select {
+				case <-ctx.Done():
+				case <-time.After(500 * time.Millisecond):
+				}
+			}
+			if err := library.DeleteArchive(ctx, a.AID, account); err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				failed++
+				logger.Error().Err(err).Str("aid", a.AID).Str("title", a.Title).Msg("删除稿件失败")
+				continue
+			}
+			deleted++
+			fmt.Printf("[%d/%d] 已删除: [%s] %s\n", deleted, len(archives), a.AID, a.Title)
+			if a.LocalDir != "" {
+				if err := repo.MarkVideoReviewStatus(a.LocalDir, file.ReviewStatus{State: file.ReviewDeleted, Desc: "已通过 bili delete 删除"}); err != nil {
+					logger.Warn().Err(err).Str("video_dir", a.LocalDir).Msg("记录本地稿件状态失败")
+				}
+			}
+		}
+
+		fmt.Printf("\n删除完成：成功 %d 个，失败 %d 个\n", deleted, failed)
+		if failed > 0 || ctx.Err() != nil {
+			exit(1)
+		}
+	},
+}
+
+// listBiliArchives 按命令行过滤条件获取稿件，并关联本地上传状态中的视频目录
+func listBiliArchives(cmd *cobra.Command) []bilibili.Archive {
+	cfg := config.Get()
+	if cfg == nil {
+		fmt.Fprintf(os.Stderr, "配置未加载\n")
+		exit(1)
+	}
+	logger.SetLevel(zerolog.InfoLevel)
+
+	filter, err := parseBiliFilter()
+	if err != nil {
+		logger.Error().Err(err).Msg("过滤条件无效")
+		exit(1)
+	}
+
+	library := bilibili.NewLibrary(biliCookiesFile())
+	archives, err := library.ListArchives(cmd.Context(), biliAccountConfig(), filter)
+	if err != nil {
+		logger.Error().Err(err).Msg("获取稿件列表失败")
+		exit(1)
+	}
+
+	localDirs := localDirsByAID(file.NewRepository(cfg.Output.Directory))
+	for i := range archives {
+		archives[i].LocalDir = localDirs[archives[i].AID]
+	}
+	return archives
+}
+
+// biliAccountConfig 返回 --account 指定的账号；未指定时使用空账号（由 cookies 文件决定）
+func biliAccountConfig() config.Account {
+	if biliAccount == "" {
+		return config.Account{}
+	}
+	account, ok := config.Get().BilibiliAccounts[biliAccount]
+	if !ok {
+		logger.Error().Str("account", biliAccount).Msg("bilibili_accounts 中不存在该账号")
+		exit(1)
+	}
+	return account
+}
+
+// biliCookiesFile 账号未配置 cookies_file 时使用的 cookies 文件：--cookies 优先，否则 bilibili.cookies_file
+func biliCookiesFile() string {
+	if biliCookies != "" {
+		return biliCookies
+	}
+	return config.Get().Bilibili.CookiesFile
+}
+
+func hasBiliFilter() bool {
+	return biliSince != "" || biliUntil != "" || biliTitle != "" || len(biliStates) > 0
+}
+
+// parseBiliFilter 解析 --since / --until（YYYY-MM-DD 或 RFC3339，--until 的日期包含当天）、--title 与 --state
+func parseBiliFilter() (bilibili.ArchiveFilter, error) {
+	var filter bilibili.ArchiveFilter
+	var err error
+	if biliSince != "" {
+		if filter.Since, _, err = parseBiliDate(biliSince); err != nil {
+			return filter, fmt.Errorf("--since: %w", err)
+		}
+	}
+	if biliUntil != "" {
+		until, dateOnly, err := parseBiliDate(biliUntil)
+		if err != nil {
+			return filter, fmt.Errorf("--until: %w", err)
+		}
+		if dateOnly {
+			until = until.AddDate(0, 0, 1)
+		}
+		filter.Until = until
+	}
+	if biliTitle != "" {
+		if filter.Title, err = regexp.Compile(biliTitle); err != nil {
+			return filter, fmt.Errorf("--title 正则无效: %w", err)
+		}
+	}
+	for _, s := range biliStates {
+		state := file.ReviewState(strings.ToLower(strings.TrimSpace(s)))
+		if !state.Valid() {
+			return filter, fmt.Errorf("未知的稿件状态: %s", s)
+		}
+		filter.States = append(filter.States, state)
+	}
+	return filter, nil
+}
+
+func parseBiliDate(value string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("无法解析时间 %q（使用 YYYY-MM-DD 或 RFC3339）", value)
+	}
+	return t, false, nil
+}
+
+// localDirsByAID 从本地上传状态建立 aid → 视频目录的映射
+func localDirsByAID(repo file.Repository) map[string]string {
+	dirs, err := repo.ListVideoDirsByUploadStatus(string(file.UploadCompleted))
+	if err != nil {
+		logger.Warn().Err(err).Msg("读取本地上传状态失败，不关联本地视频目录")
+		return nil
+	}
+	result := make(map[string]string, len(dirs))
+	for _, dir := range dirs {
+		if status, err := repo.LoadUploadStatus(dir); err == nil && status.BilibiliAID != "" {
+			result[status.BilibiliAID] = dir
+		}
+	}
+	return result
+}
+
+func writeArchivesCSV(out io.Writer, archives []bilibili.Archive) error {
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"aid", "title", "state", "state_code", "state_desc", "reject_reason", "created_at", "cover", "local_dir"}); err != nil {
+		return err
+	}
+	for _, a := range archives {
+		created := ""
+		if !a.CreatedAt.IsZero() {
+			created = a.CreatedAt.Format(time.RFC3339)
+		}
+		record := []string{a.AID, a.Title, string(a.State), strconv.Itoa(a.StateCode), a.StateDesc, a.RejectReason, created, a.Cover, a.LocalDir}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func formatArchiveTime(t time.Time) string {
+	if t.IsZero() {
+		return "----------------"
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// confirm 输出提示并读取一行输入，输入 yes 时返回 true
+func confirm(prompt string) bool {
+	fmt.Print(prompt)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return false
+	}
+	return strings.TrimSpace(line) == "yes"
+}
+
+func init() {
+	biliCmd.PersistentFlags().StringVar(&biliAccount, "account", "", "bilibili_accounts 中的账号名称")
+	biliCmd.PersistentFlags().StringVar(&biliCookies, "cookies", "", "cookies 文件路径（Netscape 格式，账号未配置 cookies_file 时使用）")
+	biliCmd.PersistentFlags().StringVar(&biliSince, "since", "", "只处理该时间之后投稿的稿件（YYYY-MM-DD 或 RFC3339）")
+	biliCmd.PersistentFlags().StringVar(&biliUntil, "until", "", "只处理该时间之前投稿的稿件（YYYY-MM-DD 包含当天）")
+	biliCmd.PersistentFlags().StringVar(&biliTitle, "title", "", "标题正则")
+	biliCmd.PersistentFlags().StringSliceVar(&biliStates, "state", nil, "稿件状态（可多个，逗号分隔）")
+
+	biliListCmd.Flags().BoolVar(&biliListJSON, "json", false, "以 JSON 格式输出")
+
+	biliExportCmd.Flags().StringVar(&biliExportFormat, "format", "json", "导出格式：json / csv")
+	biliExportCmd.Flags().StringVarP(&biliExportOutput, "output", "o", "", "导出文件路径，默认输出到标准输出")
+	biliExportCmd.Flags().StringVar(&biliExportCopyTo, "copy-to", "", "将稿件对应的本地视频目录复制到该目录")
+
+	biliDeleteCmd.Flags().StringSliceVar(&biliDeleteAIDs, "aid", nil, "只删除这些 aid（可多个，逗号分隔）")
+	biliDeleteCmd.Flags().BoolVar(&biliDeleteAll, "all", false, "未指定过滤条件时删除账号下的全部稿件")
+	biliDeleteCmd.Flags().BoolVarP(&biliDeleteYes, "yes", "y", false, "跳过删除确认")
+	biliDeleteCmd.Flags().BoolVar(&biliDeleteDryRun, "dry-run", false, "只列出将要删除的稿件，不删除")
+
+	biliCmd.AddCommand(biliListCmd, biliExportCmd, biliDeleteCmd)
+	rootCmd.AddCommand(biliCmd)
+}
@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"os"
 
@@ -23,7 +22,7 @@ var channelCmd = &cobra.Command{
 		cfg := config.Get()
 		if cfg == nil {
 			fmt.Fprintf(os.Stderr, "配置未加载\n")
-			os.Exit(1)
+			exit(1)
 		}
 
 		// 允许通过命令行跳过 pending 生成（覆盖配置）
@@ -34,18 +33,18 @@ var channelCmd = &cobra.Command{
 		application, err := app.NewApp(cfg)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "初始化应用失败: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 
 		logger.SetLevel(zerolog.DebugLevel)
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		downloadService := application.DownloadService
 
 		// 同步所有频道信息
 		if err := downloadService.ParseChannels(ctx); err != nil {
 			fmt.Fprintf(os.Stderr, "同步频道信息失败: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 	},
 }
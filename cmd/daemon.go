@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"blueberry/internal/app"
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/internal/service"
+	"blueberry/pkg/logger"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+var daemonStatusJSON bool
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "常驻运行，按 cron 表达式定时执行频道解析、下载、上传等任务",
+	Long: `以常驻进程运行内置调度器，按 daemon.tasks 中配置的 cron 表达式定时执行任务：
+  channel        解析频道信息（同 channel 命令）
+  download       下载所有频道（同 download 命令）
+  upload         上传所有频道（同 upload --all）
+  fix_subtitles  补充缺失的字幕（同 subtitle 命令）
+  organize       整理 output 目录（同 organize 命令）
+  pipeline       下载与上传解耦运行（同 pipeline --all；不能与 download / upload 同时启用）
+  verify_uploads 查询已上传稿件的审核 / 转码状态（同 verify-uploads；启用 pipeline 任务时退回的视频同时加入上传队列）
+
+daemon.quiet_hours 内不启动新的任务（ignore_quiet_hours 的任务除外）；处于下载限制 / bot detection
+休息期时 download 任务顺延到休息结束，账号额度用尽时 upload 任务顺延到第二天，而不是在任务内阻塞休眠。
+
+下次运行时间与上次运行结果保存在 daemon.state_file（默认 {output.directory}/.global/daemon_state.json），
+重启后按保存的时间继续调度；停机期间错过的运行会在启动时补跑一次。
+
+示例：
+  blueberry daemon
+  blueberry daemon status`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.Get()
+		if cfg == nil {
+			fmt.Fprintf(os.Stderr, "配置未加载\n")
+			exit(1)
+		}
+
+		application, err := app.NewApp(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "初始化应用失败: %v\n", err)
+			exit(1)
+		}
+
+		logger.SetLevel(zerolog.InfoLevel)
+
+		ctx := cmd.Context()
+
+		fileRepo := file.NewRepository(cfg.Output.Directory)
+		tasks, err := buildDaemonTasks(cfg, application, fileRepo)
+		if err != nil {
+			logger.Error().Err(err).Msg("创建定时任务失败")
+			exit(1)
+		}
+
+		scheduler, err := service.NewScheduler(tasks, cfg.Daemon.QuietHours, daemonStatePath(cfg))
+		if err != nil {
+			logger.Error().Err(err).Msg("创建调度器失败")
+			exit(1)
+		}
+
+		logger.Info().Int("tasks", len(tasks)).Strs("quiet_hours", cfg.Daemon.QuietHours).Msg("daemon 启动")
+		if err := scheduler.Run(ctx); err != nil {
+			logger.Error().Err(err).Msg("daemon 运行失败")
+			exit(1)
+		}
+		logger.Info().Msg("daemon 已退出")
+	},
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "查看定时任务的下次运行时间与上次运行结果",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.Get()
+		if cfg == nil {
+			fmt.Fprintf(os.Stderr, "配置未加载\n")
+			exit(1)
+		}
+
+		path := daemonStatePath(cfg)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				fmt.Printf("尚无调度状态（%s），daemon 还未运行过\n", path)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "读取调度状态失败: %v\n", err)
+			exit(1)
+		}
+		if daemonStatusJSON {
+			fmt.Println(string(data))
+			return
+		}
+
+		var state service.SchedulerState
+		if err := json.Unmarshal(data, &state); err != nil {
+			fmt.Fprintf(os.Stderr, "解析调度状态失败: %v\n", err)
+			exit(1)
+		}
+		names := make([]string, 0, len(state.Tasks))
+		for name := range state.Tasks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Printf("状态文件: %s（更新于 %s）\n\n", path, state.UpdatedAt.Local().Format("2006-01-02 15:04:05"))
+		for _, name := range names {
+			ts := state.Tasks[name]
+			line := fmt.Sprintf("%-14s %-16s 下次运行 %s", name, ts.Schedule, ts.NextRun.Local().Format("2006-01-02 15:04:05"))
+			if ts.Deferred != "" {
+				line += "（顺延: " + ts.Deferred + "）"
+			}
+			if !ts.LastStart.IsZero() {
+				line += fmt.Sprintf("  上次 %s %s", ts.LastStart.Local().Format("01-02 15:04"), ts.LastStatus)
+				if ts.LastDuration != "" && ts.LastStatus != service.TaskStatusRunning {
+					line += " " + ts.LastDuration
+				}
+			}
+			if ts.LastError != "" {
+				line += "  错误: " + ts.LastError
+			}
+			fmt.Println(line)
+		}
+	},
+}
+
+// daemonStatePath 调度状态文件路径
+func daemonStatePath(cfg *config.Config) string {
+	if cfg.Daemon.StateFile != "" {
+		return cfg.Daemon.StateFile
+	}
+	return filepath.Join(cfg.Output.Directory, ".global", "daemon_state.json")
+}
+
+// buildDaemonTasks 根据 daemon.tasks 配置创建定时任务（schedule 为空的任务不启用）
+func buildDaemonTasks(cfg *config.Config, application *app.App, fileRepo file.Repository) ([]service.ScheduledTask, error) {
+	runners := map[string]func(ctx context.Context) error{
+		"channel": func(ctx context.Context) error {
+			return application.DownloadService.ParseChannels(ctx)
+		},
+		"download": func(ctx context.Context) error {
+			return application.DownloadService.DownloadChannels(ctx)
+		},
+		"upload": func(ctx context.Context) error {
+			return application.UploadService.UploadAllChannels(ctx)
+		},
+		"fix_subtitles": func(ctx context.Context) error {
+			return application.DownloadService.FixSubtitles(ctx, false)
+		},
+		"organize": func(ctx context.Context) error {
+			return runOrganize(ctx, cfg, false, "")
+		},
+		"pipeline": func(ctx context.Context) error {
+			queue, err := openUploadQueue(cfg)
+			if err != nil {
+				return err
+			}
+			pipelineService := service.NewPipelineService(application.DownloadService, application.UploadService, fileRepo, queue, cfg)
+			_, err = pipelineService.Run(ctx, nil, service.PipelineStageAll)
+			return err
+		},
+		"verify_uploads": func(ctx context.Context) error {
+			opts := service.VerifyOptions{}
+			if cfg.Daemon.Tasks["pipeline"].Schedule != "" {
+				queue, err := openUploadQueue(cfg)
+				if err != nil {
+					return err
+				}
+				opts.Queue = queue
+			}
+			_, err := application.UploadService.VerifyUploads(ctx, opts)
+			return err
+		},
+	}
+	notBefore := map[string]func() (time.Time, bool){
+		"download": func() (time.Time, bool) { return service.DownloadRestUntil(cfg, fileRepo) },
+		"pipeline": func() (time.Time, bool) { return service.DownloadRestUntil(cfg, fileRepo) },
+		"upload":   func() (time.Time, bool) { return uploadQuotaResetAt(cfg, fileRepo) },
+	}
+
+	names := make([]string, 0, len(cfg.Daemon.Tasks))
+	for name := range cfg.Daemon.Tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var tasks []service.ScheduledTask
+	for _, name := range names {
+		taskCfg := cfg.Daemon.Tasks[name]
+		run, ok := runners[name]
+		if !ok {
+			return nil, fmt.Errorf("未知的定时任务: %s（可选: channel, download, upload, fix_subtitles, organize, pipeline, verify_uploads）", name)
+		}
+		if taskCfg.Schedule == "" {
+			continue
+		}
+		tasks = append(tasks, service.ScheduledTask{
+			Name:             name,
+			Schedule:         taskCfg.Schedule,
+			IgnoreQuietHours: taskCfg.IgnoreQuietHours,
+			NotBefore:        notBefore[name],
+			Run:              run,
+		})
+	}
+	return tasks, nil
+}
+
+// uploadQuotaResetAt 所有账号都不可选（当日额度用尽、达到每小时上限或处于失败冷却期）时返回最早恢复的时间
+func uploadQuotaResetAt(cfg *config.Config, fileRepo file.Repository) (time.Time, bool) {
+	if len(cfg.BilibiliAccounts) == 0 {
+		return time.Time{}, false
+	}
+	if ok, retryAt := service.NewAccountSelector(fileRepo, cfg).Available(nil); !ok {
+		return retryAt, true
+	}
+	return time.Time{}, false
+}
+
+func init() {
+	daemonStatusCmd.Flags().BoolVar(&daemonStatusJSON, "json", false, "以 JSON 格式输出")
+	daemonCmd.AddCommand(daemonStatusCmd)
+	rootCmd.AddCommand(daemonCmd)
+}
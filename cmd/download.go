@@ -1,13 +1,14 @@
 package cmd
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"blueberry/internal/app"
 	"blueberry/internal/config"
+	"blueberry/internal/service"
 	"blueberry/pkg/logger"
 
 	"github.com/rs/zerolog"
@@ -32,17 +33,17 @@ var downloadCmd = &cobra.Command{
 		cfg := config.Get()
 		if cfg == nil {
 			fmt.Fprintf(os.Stderr, "配置未加载\n")
-			os.Exit(1)
+			exit(1)
 		}
 
 		application, err := app.NewApp(cfg)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "初始化应用失败: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 
 		logger.SetLevel(zerolog.DebugLevel)
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		downloadService := application.DownloadService
 
@@ -60,7 +61,7 @@ var downloadCmd = &cobra.Command{
 			absVideoDir, err := filepath.Abs(videoDir)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "解析视频目录路径失败: %v\n", err)
-				os.Exit(1)
+				exit(1)
 			}
 			errExecute = downloadService.DownloadVideoDir(ctx, absVideoDir)
 		} else if channelDir != "" {
@@ -68,7 +69,7 @@ var downloadCmd = &cobra.Command{
 			absChannelDir, err := filepath.Abs(channelDir)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "解析频道目录路径失败: %v\n", err)
-				os.Exit(1)
+				exit(1)
 			}
 			errExecute = downloadService.DownloadChannel(ctx, absChannelDir)
 		} else {
@@ -76,13 +77,13 @@ var downloadCmd = &cobra.Command{
 			outputDir := cfg.Output.Directory
 			if outputDir == "" {
 				fmt.Fprintf(os.Stderr, "配置文件中未设置输出目录\n")
-				os.Exit(1)
+				exit(1)
 			}
 
 			absOutputDir, err := filepath.Abs(outputDir)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "解析输出目录路径失败: %v\n", err)
-				os.Exit(1)
+				exit(1)
 			}
 
 			logger.Info().Str("output_dir", absOutputDir).Msg("扫描频道目录")
@@ -91,7 +92,7 @@ var downloadCmd = &cobra.Command{
 			entries, err := os.ReadDir(absOutputDir)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "读取输出目录失败: %v\n", err)
-				os.Exit(1)
+				exit(1)
 			}
 
 			var channelDirs []string
@@ -117,12 +118,22 @@ var downloadCmd = &cobra.Command{
 
 			// 下载每个频道
 			for i, dir := range channelDirs {
+				if ctx.Err() != nil {
+					break
+				}
 				logger.Info().
 					Int("current", i+1).
 					Int("total", len(channelDirs)).
 					Str("channel_dir", dir).
 					Msg("开始下载频道")
 				if err := downloadService.DownloadChannel(ctx, dir); err != nil {
+					if ctx.Err() != nil {
+						break
+					}
+					if errors.Is(err, service.ErrDownloadResting) {
+						logger.Info().Err(err).Msg("进入休息，剩余频道请在休息结束后重新运行")
+						break
+					}
 					logger.Error().
 						Str("channel_dir", dir).
 						Err(err).
@@ -132,9 +143,13 @@ var downloadCmd = &cobra.Command{
 			}
 		}
 
-		if errExecute != nil {
+		if errors.Is(errExecute, service.ErrDownloadResting) {
+			logger.Info().Err(errExecute).Msg("进入休息，请在休息结束后重新运行")
+			return
+		}
+		if errExecute != nil && ctx.Err() == nil {
 			fmt.Fprintf(os.Stderr, "下载失败: %v\n", errExecute)
-			os.Exit(1)
+			exit(1)
 		}
 	},
 }
@@ -25,7 +25,7 @@ var fixDownloadStatusCmd = &cobra.Command{
 		cfg := config.Get()
 		if cfg == nil {
 			fmt.Fprintf(os.Stderr, "配置未加载\n")
-			os.Exit(1)
+			exit(1)
 		}
 
 		logger.SetLevel(zerolog.InfoLevel)
@@ -39,7 +39,7 @@ var fixDownloadStatusCmd = &cobra.Command{
 		absDownloadsDir, err := filepath.Abs(downloadsDir)
 		if err != nil {
 			logger.Error().Err(err).Str("dir", downloadsDir).Msg("解析 downloads 目录路径失败")
-			os.Exit(1)
+			exit(1)
 		}
 
 		fileRepo := file.NewRepository(downloadsDir)
@@ -50,73 +50,41 @@ var fixDownloadStatusCmd = &cobra.Command{
 		skippedCount := 0
 		errorCount := 0
 
-		// 遍历所有频道目录
-		channelEntries, err := os.ReadDir(absDownloadsDir)
+		// 按上传状态查找所有已上传的视频目录（bolt 后端直接走索引）
+		uploadedDirs, err := fileRepo.ListVideoDirsByUploadStatus("completed")
 		if err != nil {
-			logger.Error().Err(err).Str("dir", absDownloadsDir).Msg("读取 downloads 目录失败")
-			os.Exit(1)
+			logger.Error().Err(err).Str("dir", absDownloadsDir).Msg("查找已上传视频失败")
+			exit(1)
 		}
 
-		for _, channelEntry := range channelEntries {
-			if !channelEntry.IsDir() {
+		for _, videoDir := range uploadedDirs {
+			// 检查下载状态
+			if fileRepo.IsVideoDownloaded(videoDir) {
+				skippedCount++
 				continue
 			}
 
-			channelID := channelEntry.Name()
-			channelDir := filepath.Join(absDownloadsDir, channelID)
-
-			// 跳过特殊目录
-			if channelID == ".global" {
-				continue
+			// 需要修复：已上传但下载状态未标记
+			// 尝试找到视频文件路径
+			videoPath, err := fileRepo.FindVideoFile(videoDir)
+			if err != nil {
+				// 即使找不到视频文件，也标记为已下载（因为已经上传了）
+				videoPath = ""
 			}
 
-			// 遍历频道下的所有视频目录
-			videoEntries, err := os.ReadDir(channelDir)
-			if err != nil {
-				logger.Debug().Err(err).Str("channel_dir", channelDir).Msg("读取频道目录失败，跳过")
+			// 更新下载状态
+			if err := fileRepo.MarkVideoDownloadedWithPath(videoDir, videoPath); err != nil {
+				logger.Warn().Err(err).Str("video_dir", videoDir).Msg("更新下载状态失败")
+				errorCount++
 				continue
 			}
 
-			for _, videoEntry := range videoEntries {
-				if !videoEntry.IsDir() {
-					continue
-				}
-
-				videoDir := filepath.Join(channelDir, videoEntry.Name())
-
-				// 检查是否已上传
-				if !fileRepo.IsVideoUploaded(videoDir) {
-					continue
-				}
-
-				// 检查下载状态
-				if fileRepo.IsVideoDownloaded(videoDir) {
-					skippedCount++
-					continue
-				}
-
-				// 需要修复：已上传但下载状态未标记
-				// 尝试找到视频文件路径
-				videoPath, err := fileRepo.FindVideoFile(videoDir)
-				if err != nil {
-					// 即使找不到视频文件，也标记为已下载（因为已经上传了）
-					videoPath = ""
-				}
-
-				// 更新下载状态
-				if err := fileRepo.MarkVideoDownloadedWithPath(videoDir, videoPath); err != nil {
-					logger.Warn().Err(err).Str("video_dir", videoDir).Msg("更新下载状态失败")
-					errorCount++
-					continue
-				}
-
-				fixedCount++
-				logger.Info().
-					Str("channel_id", channelID).
-					Str("video_id", videoEntry.Name()).
-					Str("video_path", videoPath).
-					Msg("已修复下载状态")
-			}
+			fixedCount++
+			logger.Info().
+				Str("channel_id", filepath.Base(filepath.Dir(videoDir))).
+				Str("video_id", filepath.Base(videoDir)).
+				Str("video_path", videoPath).
+				Msg("已修复下载状态")
 		}
 
 		logger.Info().
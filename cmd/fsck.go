@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/internal/service"
+	"blueberry/pkg/logger"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fsckFix       string
+	fsckDryRun    bool
+	fsckJSON      bool
+	fsckStale     time.Duration
+	fsckListCodes bool
+)
+
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "检查并修复下载目录与状态之间的不一致",
+	Long: `遍历 output.directory，交叉核对 channel_info.json、video_info.json、下载状态、上传状态
+与实际的视频/字幕/封面文件以及 .part/.temp 残留文件，每个问题都带有机器可读的代码。
+
+默认只检查不修改；使用 --fix 选择要修复的问题代码（逗号分隔，或 all 表示全部可修复的问题），
+配合 --dry-run 只展示修复前后的状态差异与将要删除的文件，不做任何修改。
+
+示例：
+  # 只检查
+  blueberry fsck
+
+  # 查看所有问题代码
+  blueberry fsck --list-codes
+
+  # 预览修复
+  blueberry fsck --fix=video_file_untracked,temp_files --dry-run
+
+  # 修复全部可修复的问题，输出 JSON 报告
+  blueberry fsck --fix=all --json
+
+存在未修复的问题时退出码为 1。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if fsckListCodes {
+			for _, info := range service.FsckCodes {
+				fixable := " "
+				if info.Fixable {
+					fixable = "*"
+				}
+				fmt.Printf("%s %-26s %s\n", fixable, info.Code, info.Description)
+			}
+			fmt.Println("\n（* 表示可通过 --fix 自动修复）")
+			return
+		}
+
+		cfg := config.Get()
+		if cfg == nil {
+			fmt.Fprintf(os.Stderr, "配置未加载\n")
+			exit(1)
+		}
+
+		logger.SetLevel(zerolog.InfoLevel)
+
+		codes, err := parseFsckCodes(fsckFix)
+		if err != nil {
+			logger.Error().Err(err).Msg("--fix 参数无效")
+			exit(1)
+		}
+		if fsckDryRun && len(codes) == 0 {
+			logger.Error().Msg("--dry-run 需要与 --fix 一起使用")
+			exit(1)
+		}
+
+		downloadsDir := cfg.Output.Directory
+		if downloadsDir == "" {
+			downloadsDir = "./downloads"
+		}
+		store, err := file.NewStateStore(cfg.Output.StateBackend, downloadsDir, cfg.Output.StateFile)
+		if err != nil {
+			logger.Error().Err(err).Msg("创建状态存储失败")
+			exit(1)
+		}
+
+		fsckService := service.NewFsckService(downloadsDir, store, fsckStale)
+		ctx := cmd.Context()
+
+		var report *service.FsckReport
+		if len(codes) > 0 {
+			report, err = fsckService.Repair(ctx, codes, fsckDryRun)
+		} else {
+			report, err = fsckService.Check(ctx)
+		}
+		if err != nil {
+			logger.Error().Err(err).Str("dir", downloadsDir).Msg("fsck 失败")
+			exit(1)
+		}
+
+		if fsckJSON {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				logger.Error().Err(err).Msg("序列化报告失败")
+				exit(1)
+			}
+			fmt.Println(string(data))
+		} else {
+			printFsckReport(report)
+		}
+
+		if report.Remaining() > 0 {
+			exit(1)
+		}
+	},
+}
+
+// parseFsckCodes 解析 --fix 参数，all 表示全部可修复的问题代码
+func parseFsckCodes(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	known := make(map[string]service.FsckCodeInfo, len(service.FsckCodes))
+	for _, info := range service.FsckCodes {
+		known[info.Code] = info
+	}
+
+	var codes []string
+	for _, code := range strings.Split(value, ",") {
+		code = strings.TrimSpace(code)
+		if code == "" {
+			continue
+		}
+		if code == "all" {
+			codes = codes[:0]
+			for _, info := range service.FsckCodes {
+				if info.Fixable {
+					codes = append(codes, info.Code)
+				}
+			}
+			return codes, nil
+		}
+		info, ok := known[code]
+		if !ok {
+			return nil, fmt.Errorf("未知的问题代码: %s（使用 --list-codes 查看）", code)
+		}
+		if !info.Fixable {
+			return nil, fmt.Errorf("问题代码 %s 不支持自动修复", code)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+func printFsckReport(report *service.FsckReport) {
+	for _, issue := range report.Issues {
+		target := issue.VideoDir
+		if target == "" || issue.Code == service.FsckTempFiles {
+			target = issue.Path
+		}
+		if target == "" {
+			target = issue.Channel
+		}
+		fmt.Printf("%-7s %-26s %s: %s\n", issue.Severity, issue.Code, target, issue.Message)
+	}
+
+	if len(report.Fixes) > 0 {
+		fmt.Println()
+		action := "已修复"
+		if report.DryRun {
+			action = "将修复"
+		}
+		for _, fix := range report.Fixes {
+			target := fix.Issue.VideoDir
+			if target == "" || fix.Issue.Code == service.FsckTempFiles {
+				target = fix.Issue.Path
+			}
+			if fix.Applied {
+				fmt.Printf("%s %-26s %s\n", action, fix.Issue.Code, target)
+			} else {
+				fmt.Printf("修复失败 %-26s %s: %s\n", fix.Issue.Code, target, fix.Error)
+			}
+		}
+	}
+
+	if report.DryRun {
+		for _, path := range report.Removed {
+			fmt.Printf("\n--- %s\n将删除\n", path)
+		}
+		for _, diff := range report.Diffs {
+			fmt.Printf("\n--- %s\n+++ %s (修复后)\n", diff.Target, diff.Target)
+			for _, line := range diff.Lines {
+				fmt.Println(line)
+			}
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, issue := range report.Issues {
+		counts[issue.Code]++
+	}
+	codes := make([]string, 0, len(counts))
+	for code := range counts {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	fmt.Printf("\n检查了 %d 个频道、%d 个视频目录（状态后端: %s），发现 %d 个问题",
+		report.ChannelCount, report.VideoDirCount, report.StateBackend, len(report.Issues))
+	if len(report.Fixes) > 0 && !report.DryRun {
+		fmt.Printf("，剩余 %d 个", report.Remaining())
+	}
+	fmt.Println()
+	for _, code := range codes {
+		fmt.Printf("  %-26s %d\n", code, counts[code])
+	}
+}
+
+func init() {
+	fsckCmd.Flags().StringVar(&fsckFix, "fix", "", "要修复的问题代码，逗号分隔；all 表示全部可修复的问题")
+	fsckCmd.Flags().BoolVar(&fsckDryRun, "dry-run", false, "只展示修复前后的差异，不做任何修改")
+	fsckCmd.Flags().BoolVar(&fsckJSON, "json", false, "以 JSON 格式输出报告")
+	fsckCmd.Flags().DurationVar(&fsckStale, "stale", 6*time.Hour, "临时文件、downloading/uploading 状态超过该时长未更新才视为残留")
+	fsckCmd.Flags().BoolVar(&fsckListCodes, "list-codes", false, "列出所有问题代码")
+	rootCmd.AddCommand(fsckCmd)
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+var ledgerExportOutput string
+
+var ledgerCmd = &cobra.Command{
+	Use:   "ledger",
+	Short: "管理全局上传账本（跨账号、跨服务器防止重复上传）",
+	Long: `上传账本（{output.directory}/.global/upload_ledger）按 YouTube 视频 ID 记录已发布到 B站的稿件（aid、账号、服务器）。
+upload、sync、pipeline、retry 在开始上传前都会查询账本，视频已在任意账号或服务器上发布过时拒绝上传，
+需要重复上传时显式指定 --allow-duplicate。
+
+多台服务器共用同一批频道时，定期导出账本并导入到其他服务器即可互相感知已上传的视频：
+
+  blueberry ledger export -o ledger-a.json          # 服务器 A
+  blueberry ledger import ledger-a.json             # 服务器 B
+
+首次启用时用 ledger rebuild 从本地已完成的上传记录生成账本。`,
+}
+
+var ledgerExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "导出上传账本（JSON）",
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := openLedgerRepository()
+		entries, err := repo.LoadUploadLedger()
+		if err != nil {
+			logger.Error().Err(err).Msg("读取上传账本失败")
+			exit(1)
+		}
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			logger.Error().Err(err).Msg("序列化上传账本失败")
+			exit(1)
+		}
+		if ledgerExportOutput == "" {
+			fmt.Println(string(data))
+			return
+		}
+		if err := os.WriteFile(ledgerExportOutput, append(data, '\n'), 0644); err != nil {
+			logger.Error().Err(err).Str("file", ledgerExportOutput).Msg("写入导出文件失败")
+			exit(1)
+		}
+		fmt.Printf("已导出 %d 条记录到 %s\n", len(entries), ledgerExportOutput)
+	},
+}
+
+var ledgerImportCmd = &cobra.Command{
+	Use:   "import <file>...",
+	Short: "导入并合并其他服务器导出的上传账本",
+	Long: `合并规则：新视频直接加入；同一视频 ID、同一 aid 时补全缺失的账号 / 服务器信息；
+同一视频 ID 对应不同 aid 时保留较早上传的记录，并计为冲突（可用 reconcile 核对后删除多余稿件）。`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := openLedgerRepository()
+		failed := false
+		for _, path := range args {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				logger.Error().Err(err).Str("file", path).Msg("读取账本文件失败")
+				failed = true
+				continue
+			}
+			var entries []file.LedgerEntry
+			if err := json.Unmarshal(data, &entries); err != nil {
+				logger.Error().Err(err).Str("file", path).Msg("解析账本文件失败")
+				failed = true
+				continue
+			}
+			result, err := repo.MergeUploadLedger(entries)
+			if err != nil {
+				logger.Error().Err(err).Str("file", path).Msg("合并上传账本失败")
+				failed = true
+				continue
+			}
+			fmt.Printf("%s: %d 条记录，新增 %d，更新 %d，冲突 %d\n", path, len(entries), result.Added, result.Updated, result.Conflicts)
+		}
+		if failed {
+			exit(1)
+		}
+	},
+}
+
+var ledgerRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "从本地已完成的上传记录补全上传账本",
+	Long:  `扫描 upload_status 为 completed 的视频目录，按 video_info.json 中的视频 ID 将 aid 合并进账本（已有记录不会被覆盖）。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := openLedgerRepository()
+		dirs, err := repo.ListVideoDirsByUploadStatus(string(file.UploadCompleted))
+		if err != nil {
+			logger.Error().Err(err).Msg("读取本地上传状态失败")
+			exit(1)
+		}
+
+		host, _ := os.Hostname()
+		var entries []file.LedgerEntry
+		skipped := 0
+		for _, dir := range dirs {
+			status, err := repo.LoadUploadStatus(dir)
+			if err != nil || status.BilibiliAID == "" {
+				skipped++
+				continue
+			}
+			info, err := repo.LoadVideoInfo(dir)
+			if err != nil || info == nil || info.ID == "" {
+				logger.Warn().Str("video_dir", dir).Msg("缺少 video_info.json 中的视频 ID，跳过")
+				skipped++
+				continue
+			}
+			uploadedAt := status.CompletedAt
+			if uploadedAt == 0 {
+				uploadedAt = time.Now().Unix()
+			}
+			entries = append(entries, file.LedgerEntry{
+				VideoID:    info.ID,
+				AID:        status.BilibiliAID,
+				Account:    status.BilibiliAccount,
+				Host:       host,
+				VideoDir:   dir,
+				UploadedAt: uploadedAt,
+			})
+		}
+
+		result, err := repo.MergeUploadLedger(entries)
+		if err != nil {
+			logger.Error().Err(err).Msg("写入上传账本失败")
+			exit(1)
+		}
+		fmt.Printf("本地 %d 个已上传视频（跳过 %d 个），新增 %d，更新 %d，冲突 %d\n", len(dirs), skipped, result.Added, result.Updated, result.Conflicts)
+	},
+}
+
+var ledgerShowCmd = &cobra.Command{
+	Use:   "show <video_id>...",
+	Short: "查询视频是否已在账本中",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := openLedgerRepository()
+		for _, videoID := range args {
+			entry, err := repo.LookupUploadLedger(videoID)
+			if err != nil {
+				logger.Error().Err(err).Msg("读取上传账本失败")
+				exit(1)
+			}
+			if entry == nil {
+				fmt.Printf("%s: 未上传\n", videoID)
+				continue
+			}
+			fmt.Printf("%s: aid=%s account=%s host=%s uploaded_at=%s dir=%s\n", videoID, entry.AID, entry.Account, entry.Host,
+				time.Unix(entry.UploadedAt, 0).Format("2006-01-02 15:04:05"), filepath.Base(entry.VideoDir))
+		}
+	},
+}
+
+func openLedgerRepository() file.Repository {
+	cfg := config.Get()
+	if cfg == nil {
+		fmt.Fprintf(os.Stderr, "配置未加载\n")
+		exit(1)
+	}
+	logger.SetLevel(zerolog.InfoLevel)
+	return file.NewRepository(cfg.Output.Directory)
+}
+
+func init() {
+	ledgerExportCmd.Flags().StringVarP(&ledgerExportOutput, "output", "o", "", "导出到文件（默认输出到标准输出）")
+	ledgerCmd.AddCommand(ledgerExportCmd, ledgerImportCmd, ledgerRebuildCmd, ledgerShowCmd)
+	rootCmd.AddCommand(ledgerCmd)
+}
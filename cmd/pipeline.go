@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"blueberry/internal/app"
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/internal/service"
+	"blueberry/pkg/logger"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pipelineChannelURL string
+	pipelineAll        bool
+	pipelineStage      string
+	pipelineQueueState string
+	pipelineQueueJSON  bool
+	pipelineRequeue    bool
+	pipelineAllowDup   bool
+)
+
+var pipelineCmd = &cobra.Command{
+	Use:   "pipeline",
+	Short: "下载与上传解耦：下载完成的视频写入持久化队列，由独立的上传 worker 消费",
+	Long: `下载端按频道下载视频，每个视频就绪后写入磁盘上的上传队列（默认 {output.directory}/.global/upload_queue）；
+上传端由 pipeline.upload_workers 个 worker 并行消费队列，B站上传慢不会阻塞 YouTube 下载，反之亦然。
+
+背压：队列积压达到 pipeline.max_queued、磁盘剩余空间低于 pipeline.min_free_disk_mb，
+或积压已达到所有账号当日剩余上传额度时，下载端暂停，等待上传端消化。
+
+队列在进程重启后保留：上次中断时正在上传的视频会重新放回队列，失败的视频按退避时间重试，
+超过 pipeline.max_attempts 次后移入 failed（可用 pipeline queue --requeue 重新入队）。
+
+示例：
+  # 下载与上传同时进行
+  blueberry pipeline --all
+
+  # 下载与上传分别运行在两个进程中
+  blueberry pipeline --all --stage download
+  blueberry pipeline --stage upload
+
+  # 查看队列
+  blueberry pipeline queue`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.Get()
+		if cfg == nil {
+			fmt.Fprintf(os.Stderr, "配置未加载\n")
+			exit(1)
+		}
+
+		var channelURLs []string
+		switch {
+		case pipelineStage == service.PipelineStageUpload:
+		case pipelineAll:
+		case pipelineChannelURL != "":
+			channelURLs = []string{pipelineChannelURL}
+		default:
+			fmt.Fprintf(os.Stderr, "请指定频道（--channel）或使用 --all 处理所有频道（--stage upload 时可省略）\n")
+			exit(1)
+		}
+		if pipelineAllowDup {
+			cfg.Bilibili.AllowDuplicateUpload = true
+		}
+
+		application, err := app.NewApp(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "初始化应用失败: %v\n", err)
+			exit(1)
+		}
+
+		logger.SetLevel(zerolog.InfoLevel)
+		ctx := cmd.Context()
+
+		queue, err := openUploadQueue(cfg)
+		if err != nil {
+			logger.Error().Err(err).Msg("打开上传队列失败")
+			exit(1)
+		}
+		fileRepo := file.NewRepository(cfg.Output.Directory)
+		pipelineService := service.NewPipelineService(
+			application.DownloadService,
+			application.UploadService,
+			fileRepo,
+			queue,
+			cfg,
+		)
+
+		summary, err := pipelineService.Run(ctx, channelURLs, pipelineStage)
+		if summary != nil {
+			fmt.Printf("入队 %d，上传成功 %d，重试 %d，失败 %d；队列剩余 pending %d / failed %d\n",
+				summary.Enqueued, summary.Uploaded, summary.Retried, summary.Failed,
+				summary.Remaining.Pending, summary.Remaining.Failed)
+		}
+		if err != nil && ctx.Err() == nil {
+			logger.Error().Err(err).Msg("pipeline 运行失败")
+			exit(1)
+		}
+	},
+}
+
+var pipelineQueueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "查看或处理上传队列",
+	Long: `列出上传队列中的视频。
+
+示例：
+  blueberry pipeline queue                    # 各状态数量与 pending 列表
+  blueberry pipeline queue --state failed     # 查看失败的视频
+  blueberry pipeline queue --requeue          # 将 failed 中的视频重新放回队列`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.Get()
+		if cfg == nil {
+			fmt.Fprintf(os.Stderr, "配置未加载\n")
+			exit(1)
+		}
+
+		logger.SetLevel(zerolog.InfoLevel)
+
+		queue, err := openUploadQueue(cfg)
+		if err != nil {
+			logger.Error().Err(err).Msg("打开上传队列失败")
+			exit(1)
+		}
+
+		if pipelineRequeue {
+			n, err := queue.Requeue()
+			if err != nil {
+				logger.Error().Err(err).Msg("重新入队失败")
+				exit(1)
+			}
+			logger.Info().Int("count", n).Msg("已将 failed 中的视频重新放回队列")
+		}
+
+		items, err := queue.List(pipelineQueueState)
+		if err != nil {
+			logger.Error().Err(err).Msg("读取上传队列失败")
+			exit(1)
+		}
+		stats, err := queue.Stats()
+		if err != nil {
+			logger.Error().Err(err).Msg("读取上传队列失败")
+			exit(1)
+		}
+
+		if pipelineQueueJSON {
+			data, err := json.MarshalIndent(map[string]interface{}{
+				"dir":   queue.Dir(),
+				"stats": stats,
+				"state": pipelineQueueState,
+				"items": items,
+			}, "", "  ")
+			if err != nil {
+				logger.Error().Err(err).Msg("序列化队列失败")
+				exit(1)
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		fmt.Printf("队列目录: %s\npending %d，inflight %d，failed %d\n\n", queue.Dir(), stats.Pending, stats.Inflight, stats.Failed)
+		for _, item := range items {
+			line := fmt.Sprintf("%-14s %s  入队 %s  尝试 %d", item.VideoID, item.VideoDir,
+				time.Unix(item.EnqueuedAt, 0).Format("2006-01-02 15:04:05"), item.Attempts)
+			if item.NotBefore > time.Now().Unix() {
+				line += "  重试于 " + time.Unix(item.NotBefore, 0).Format("15:04:05")
+			}
+			if item.LastError != "" {
+				line += "  错误: " + item.LastError
+			}
+			fmt.Println(line)
+		}
+	},
+}
+
+// openUploadQueue 打开配置的上传队列（pipeline.queue_dir，默认 {output.directory}/.global/upload_queue）
+func openUploadQueue(cfg *config.Config) (file.UploadQueue, error) {
+	dir := cfg.Pipeline.QueueDir
+	if dir == "" {
+		dir = file.DefaultUploadQueueDir(cfg.Output.Directory)
+	}
+	return file.NewUploadQueue(dir)
+}
+
+func init() {
+	pipelineCmd.Flags().StringVar(&pipelineChannelURL, "channel", "", "要处理的频道URL")
+	pipelineCmd.Flags().BoolVar(&pipelineAll, "all", false, "处理配置中所有频道")
+	pipelineCmd.Flags().StringVar(&pipelineStage, "stage", service.PipelineStageAll, "运行阶段：all（下载+上传）、download（只下载入队）、upload（只消费队列）")
+	pipelineCmd.Flags().BoolVar(&pipelineAllowDup, "allow-duplicate", false, "允许上传已在其他账号/服务器发布过的视频（忽略上传账本）")
+	pipelineQueueCmd.Flags().StringVar(&pipelineQueueState, "state", file.QueueStatePending, "要列出的状态：pending、inflight、failed")
+	pipelineQueueCmd.Flags().BoolVar(&pipelineQueueJSON, "json", false, "以 JSON 格式输出")
+	pipelineQueueCmd.Flags().BoolVar(&pipelineRequeue, "requeue", false, "将 failed 中的视频重新放回队列")
+	pipelineCmd.AddCommand(pipelineQueueCmd)
+	rootCmd.AddCommand(pipelineCmd)
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"blueberry/internal/app"
+	"blueberry/internal/config"
+	"blueberry/internal/repository/bilibili"
+	"blueberry/internal/service"
+	"blueberry/pkg/logger"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	playlistAccount string
+	playlistJSON    bool
+
+	playlistBackfillDir    string
+	playlistBackfillDryRun bool
+)
+
+var playlistCmd = &cobra.Command{
+	Use:   "playlist",
+	Short: "管理频道对应的 bilibili.tv 播放列表",
+	Long: `在 youtube_channels[].playlist 中为频道配置播放列表后，该频道的视频发布时自动加入播放列表，
+并按 YouTube 原始的 playlist_index（缺失时按上传日期）排列。
+
+各账号优先使用 playlist.ids 中的播放列表 ID，否则按 playlist.title 查找，
+找不到且 auto_create 为 true 时自动创建；解析结果记录在 .global/playlists。`,
+}
+
+var playlistListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出账号下的播放列表（用于配置 playlist.ids）",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.Get()
+		if cfg == nil {
+			fmt.Fprintf(os.Stderr, "配置未加载\n")
+			exit(1)
+		}
+		logger.SetLevel(zerolog.InfoLevel)
+
+		var account config.Account
+		if playlistAccount != "" {
+			var ok bool
+			if account, ok = cfg.BilibiliAccounts[playlistAccount]; !ok {
+				logger.Error().Str("account", playlistAccount).Msg("bilibili_accounts 中不存在该账号")
+				exit(1)
+			}
+		}
+		playlists, err := bilibili.NewPlaylists(cfg.Bilibili.CookiesFile).ListPlaylists(cmd.Context(), account)
+		if err != nil {
+			logger.Error().Err(err).Msg("获取播放列表失败")
+			exit(1)
+		}
+		if playlistJSON {
+			data, _ := json.MarshalIndent(playlists, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+		for _, p := range playlists {
+			fmt.Printf("%-18s %4d  %s\n", p.ID, p.Count, p.Title)
+		}
+		fmt.Printf("\n共 %d 个播放列表\n", len(playlists))
+	},
+}
+
+var playlistBackfillCmd = &cobra.Command{
+	Use:   "backfill",
+	Short: "将已发布的视频补加到频道的播放列表",
+	Long: `将已上传到 B站、upload_status.json 中没有播放列表记录的视频加入所属频道配置的播放列表，
+完成后按原始顺序重新排列涉及的播放列表。频道未配置 playlist 的视频会被跳过。
+
+示例：
+  blueberry playlist backfill --dry-run
+  blueberry playlist backfill --dir downloads/Comic-likerhythm`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.Get()
+		if cfg == nil {
+			fmt.Fprintf(os.Stderr, "配置未加载\n")
+			exit(1)
+		}
+
+		application, err := app.NewApp(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "初始化应用失败: %v\n", err)
+			exit(1)
+		}
+
+		logger.SetLevel(zerolog.InfoLevel)
+		ctx := cmd.Context()
+
+		items, err := application.UploadService.BackfillPlaylists(ctx, service.PlaylistBackfillOptions{
+			Dir:    playlistBackfillDir,
+			DryRun: playlistBackfillDryRun,
+		})
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "补加播放列表失败: %v\n", err)
+			exit(1)
+		}
+
+		succeeded, failed := 0, 0
+		for _, item := range items {
+			switch {
+			case item.Error != "":
+				failed++
+				fmt.Printf("失败  %s  aid=%s  account=%s: %s\n", item.VideoDir, item.AID, item.Account, item.Error)
+			case playlistBackfillDryRun:
+				playlistID := item.PlaylistID
+				if playlistID == "" {
+					playlistID = "（将自动创建）"
+				}
+				fmt.Printf("%s  aid=%s  account=%s  playlist=%s\n", item.VideoDir, item.AID, item.Account, playlistID)
+			default:
+				succeeded++
+			}
+		}
+		if playlistBackfillDryRun {
+			fmt.Printf("\n共 %d 个视频待加入播放列表（dry-run，未修改）\n", len(items)-failed)
+			return
+		}
+		fmt.Printf("\n补加完成：成功 %d，失败 %d\n", succeeded, failed)
+		if failed > 0 {
+			exit(1)
+		}
+	},
+}
+
+func init() {
+	playlistListCmd.Flags().StringVar(&playlistAccount, "account", "", "账号（bilibili_accounts 中的名称），未指定时使用 bilibili.cookies_file")
+	playlistListCmd.Flags().BoolVar(&playlistJSON, "json", false, "以 JSON 格式输出")
+	playlistBackfillCmd.Flags().StringVar(&playlistBackfillDir, "dir", "", "只处理该频道目录或视频目录下已上传的视频")
+	playlistBackfillCmd.Flags().BoolVar(&playlistBackfillDryRun, "dry-run", false, "只列出待加入播放列表的视频，不实际修改")
+	playlistCmd.AddCommand(playlistListCmd, playlistBackfillCmd)
+	rootCmd.AddCommand(playlistCmd)
+}
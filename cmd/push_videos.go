@@ -4,12 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"blueberry/internal/config"
 	"blueberry/pkg/logger"
+	"blueberry/pkg/utils"
 
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
@@ -40,27 +40,27 @@ var pushVideosCmd = &cobra.Command{
 		cfg := config.Get()
 		if cfg == nil {
 			fmt.Fprintf(os.Stderr, "配置未加载\n")
-			os.Exit(1)
+			exit(1)
 		}
 
 		if pushVideosChannelDir == "" {
 			fmt.Fprintf(os.Stderr, "请指定频道目录（--channel-dir）\n")
-			os.Exit(1)
+			exit(1)
 		}
 
 		if pushVideosIndexStart <= 0 || pushVideosIndexEnd <= 0 {
 			fmt.Fprintf(os.Stderr, "请指定有效的索引范围（--index-start 和 --index-end 必须大于 0）\n")
-			os.Exit(1)
+			exit(1)
 		}
 
 		if pushVideosIndexStart > pushVideosIndexEnd {
 			fmt.Fprintf(os.Stderr, "索引范围无效：--index-start (%d) 不能大于 --index-end (%d)\n", pushVideosIndexStart, pushVideosIndexEnd)
-			os.Exit(1)
+			exit(1)
 		}
 
 		if pushVideosRemoteHost == "" {
 			fmt.Fprintf(os.Stderr, "请指定远程服务器地址（--remote-host）\n")
-			os.Exit(1)
+			exit(1)
 		}
 
 		logger.SetLevel(zerolog.InfoLevel)
@@ -69,26 +69,26 @@ var pushVideosCmd = &cobra.Command{
 		absChannelDir, err := filepath.Abs(pushVideosChannelDir)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "解析频道目录路径失败: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 
 		// 读取 channel_info.json
 		channelInfoPath := filepath.Join(absChannelDir, "channel_info.json")
 		if _, err := os.Stat(channelInfoPath); os.IsNotExist(err) {
 			fmt.Fprintf(os.Stderr, "未找到 channel_info.json: %s\n", channelInfoPath)
-			os.Exit(1)
+			exit(1)
 		}
 
 		data, err := os.ReadFile(channelInfoPath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "读取 channel_info.json 失败: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 
 		var videos []map[string]interface{}
 		if err := json.Unmarshal(data, &videos); err != nil {
 			fmt.Fprintf(os.Stderr, "解析 channel_info.json 失败: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 
 		logger.Info().
@@ -123,7 +123,7 @@ var pushVideosCmd = &cobra.Command{
 
 		if len(selectedVideos) == 0 {
 			fmt.Fprintf(os.Stderr, "未找到 playlist_index 在 [%d, %d] 范围内的视频\n", pushVideosIndexStart, pushVideosIndexEnd)
-			os.Exit(1)
+			exit(1)
 		}
 
 		logger.Info().
@@ -148,7 +148,8 @@ var pushVideosCmd = &cobra.Command{
 			Str("remote_dir", remoteChannelDir).
 			Msg("在远程服务器上创建频道目录")
 
-		sshCmd := exec.Command("ssh", "-o", "StrictHostKeyChecking=no",
+		ctx := cmd.Context()
+		sshCmd := utils.CommandContext(ctx, "ssh", "-o", "StrictHostKeyChecking=no",
 			fmt.Sprintf("%s@%s", pushVideosRemoteUser, pushVideosRemoteHost),
 			fmt.Sprintf("mkdir -p %s", remoteChannelDir))
 		if err := sshCmd.Run(); err != nil {
@@ -163,6 +164,10 @@ var pushVideosCmd = &cobra.Command{
 		failCount := 0
 
 		for _, video := range selectedVideos {
+			if ctx.Err() != nil {
+				logger.Warn().Msg("收到退出信号，停止推送（rsync --partial 已保留未完成的文件，可重新运行续传）")
+				break
+			}
 			videoID, _ := video["id"].(string)
 			if videoID == "" {
 				logger.Warn().Msg("视频 ID 为空，跳过")
@@ -215,7 +220,7 @@ var pushVideosCmd = &cobra.Command{
 				remotePath,
 			}
 
-			cmd := exec.Command("rsync", rsyncArgs...)
+			cmd := utils.CommandContext(ctx, "rsync", rsyncArgs...)
 			cmd.Stdout = os.Stdout
 			cmd.Stderr = os.Stderr
 
@@ -247,7 +252,7 @@ var pushVideosCmd = &cobra.Command{
 
 		if failCount > 0 {
 			fmt.Fprintf(os.Stderr, "部分视频推送失败（%d/%d）\n", failCount, len(selectedVideos))
-			os.Exit(1)
+			exit(1)
 		}
 	},
 }
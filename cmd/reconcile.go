@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/bilibili"
+	"blueberry/internal/repository/file"
+	"blueberry/internal/service"
+	"blueberry/pkg/logger"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reconcileAccounts  []string
+	reconcileFix       string
+	reconcileDryRun    bool
+	reconcileYes       bool
+	reconcileJSON      bool
+	reconcileListCodes bool
+)
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "核对各 B站账号的稿件库与本地上传记录",
+	Long: `拉取 bilibili_accounts 中每个账号的全部稿件，按 aid、描述中的 YouTube 链接与归一化标题（视频 ID / 原标题）
+匹配本地视频目录，报告以下问题：
+
+  aid_missing        本地记录的 aid 在稿件库中不存在或已删除
+  upload_unrecorded  稿件已在 B站，但本地未标记为已上传（或记录了其他 aid）
+  account_mismatch   本地记录的账号与稿件实际所在账号不一致
+  duplicate          同一个视频在 B站有多个稿件
+  remote_unmatched   稿件无法匹配到本地视频（仅提示）
+
+默认只报告；--fix 选择要修复的问题代码（逗号分隔，all 表示 aid_missing、upload_unrecorded、account_mismatch），
+修复结果写入 upload_status.json。duplicate 的修复会删除 B站上多余的稿件，必须显式指定并确认（或 --yes）。
+
+示例：
+  blueberry reconcile
+  blueberry reconcile --account account1 --json
+  blueberry reconcile --fix=all --dry-run
+  blueberry reconcile --fix=duplicate
+
+存在未修复的问题时退出码为 1。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if reconcileListCodes {
+			for _, info := range service.ReconcileCodes {
+				fixable := " "
+				if info.Fixable {
+					fixable = "*"
+				}
+				fmt.Printf("%s %-18s %s\n", fixable, info.Code, info.Description)
+			}
+			fmt.Println("\n（* 表示可通过 --fix 自动修复）")
+			return
+		}
+
+		cfg := config.Get()
+		if cfg == nil {
+			fmt.Fprintf(os.Stderr, "配置未加载\n")
+			exit(1)
+		}
+		logger.SetLevel(zerolog.InfoLevel)
+
+		codes, err := parseReconcileCodes(reconcileFix)
+		if err != nil {
+			logger.Error().Err(err).Msg("--fix 参数无效")
+			exit(1)
+		}
+		if reconcileDryRun && len(codes) == 0 {
+			logger.Error().Msg("--dry-run 需要与 --fix 一起使用")
+			exit(1)
+		}
+
+		outputDir := cfg.Output.Directory
+		if outputDir == "" {
+			outputDir = "./downloads"
+		}
+		store, err := file.NewStateStore(cfg.Output.StateBackend, outputDir, cfg.Output.StateFile)
+		if err != nil {
+			logger.Error().Err(err).Msg("创建状态存储失败")
+			exit(1)
+		}
+		reconcileService := service.NewReconcileService(outputDir, store, bilibili.NewLibrary(cfg.Bilibili.CookiesFile), cfg)
+
+		opts := service.ReconcileOptions{Accounts: reconcileAccounts, Fix: codes, DryRun: reconcileDryRun}
+		if !reconcileYes {
+			opts.ConfirmDelete = confirmDuplicateDeletion
+		}
+		report, err := reconcileService.Reconcile(cmd.Context(), opts)
+		if err != nil {
+			logger.Error().Err(err).Msg("reconcile 失败")
+			exit(1)
+		}
+
+		if reconcileJSON {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				logger.Error().Err(err).Msg("序列化报告失败")
+				exit(1)
+			}
+			fmt.Println(string(data))
+		} else {
+			printReconcileReport(report)
+		}
+
+		if report.Remaining() > 0 {
+			exit(1)
+		}
+	},
+}
+
+// parseReconcileCodes 解析 --fix 参数，all 表示除 duplicate（删除远端稿件）以外的全部可修复问题
+func parseReconcileCodes(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	var codes []string
+	for _, code := range strings.Split(value, ",") {
+		code = strings.TrimSpace(code)
+		switch {
+		case code == "":
+			continue
+		case code == "all":
+			codes = append(codes, service.ReconcileAIDMissing, service.ReconcileUnrecorded, service.ReconcileAccountMismatch)
+			continue
+		}
+		found := false
+		for _, info := range service.ReconcileCodes {
+			if info.Code != code {
+				continue
+			}
+			if !info.Fixable {
+				return nil, fmt.Errorf("问题代码 %s 不支持自动修复", code)
+			}
+			found = true
+		}
+		if !found {
+			return nil, fmt.Errorf("未知的问题代码: %s（使用 --list-codes 查看）", code)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// confirmDuplicateDeletion 列出将要删除的多余稿件并要求确认
+func confirmDuplicateDeletion(issues []service.ReconcileIssue) bool {
+	total := 0
+	for _, issue := range issues {
+		fmt.Printf("  - %s（保留 %s）: 删除 %s\n", issue.VideoDir, issue.AID, strings.Join(issue.Extra, ", "))
+		total += len(issue.Extra)
+	}
+	return confirm(fmt.Sprintf("\n确认删除以上 %d 个多余稿件？(输入 'yes' 确认): ", total))
+}
+
+func printReconcileReport(report *service.ReconcileReport) {
+	for _, issue := range report.Issues {
+		target := issue.VideoDir
+		if target == "" {
+			target = issue.Account + ":" + issue.AID + " " + issue.Title
+		}
+		fmt.Printf("%-18s %s: %s\n", issue.Code, target, issue.Message)
+	}
+
+	if len(report.Fixes) > 0 {
+		fmt.Println()
+		action := "已修复"
+		if report.DryRun {
+			action = "将修复"
+		}
+		for _, fix := range report.Fixes {
+			if fix.Applied {
+				fmt.Printf("%s %-18s %s\n", action, fix.Issue.Code, fix.Issue.VideoDir)
+			} else {
+				fmt.Printf("修复失败 %-18s %s: %s\n", fix.Issue.Code, fix.Issue.VideoDir, fix.Error)
+			}
+		}
+	}
+
+	fmt.Println()
+	for _, account := range report.Accounts {
+		if account.Error != "" {
+			fmt.Printf("账号 %s: 获取稿件失败: %s\n", account.Name, account.Error)
+		} else {
+			fmt.Printf("账号 %s: %d 个稿件\n", account.Name, account.Archives)
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, issue := range report.Issues {
+		counts[issue.Code]++
+	}
+	codes := make([]string, 0, len(counts))
+	for code := range counts {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	fmt.Printf("本地 %d 个视频，%d 个匹配到稿件，发现 %d 个问题", report.LocalVideos, report.Matched, len(report.Issues))
+	if len(report.Fixes) > 0 && !report.DryRun {
+		fmt.Printf("，剩余 %d 个", report.Remaining())
+	}
+	fmt.Println()
+	for _, code := range codes {
+		fmt.Printf("  %-18s %d\n", code, counts[code])
+	}
+}
+
+func init() {
+	reconcileCmd.Flags().StringSliceVar(&reconcileAccounts, "account", nil, "只拉取这些账号的稿件（不拉取全部账号时跳过 aid_missing 检查）")
+	reconcileCmd.Flags().StringVar(&reconcileFix, "fix", "", "要修复的问题代码，逗号分隔；all 表示 aid_missing、upload_unrecorded、account_mismatch")
+	reconcileCmd.Flags().BoolVar(&reconcileDryRun, "dry-run", false, "只展示将要进行的修复，不做任何修改")
+	reconcileCmd.Flags().BoolVarP(&reconcileYes, "yes", "y", false, "删除多余稿件（--fix=duplicate）时跳过确认")
+	reconcileCmd.Flags().BoolVar(&reconcileJSON, "json", false, "以 JSON 格式输出报告")
+	reconcileCmd.Flags().BoolVar(&reconcileListCodes, "list-codes", false, "列出所有问题代码")
+	rootCmd.AddCommand(reconcileCmd)
+}
@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -22,6 +21,7 @@ var (
 	retryUploadOnly   bool     // 只重新上传，不下载
 	retryAccount      string   // 指定上传时使用的账号名称
 	retryFromConfig   bool     // 从配置文件读取 video_ids
+	retryAllowDup     bool     // 忽略上传账本，允许重复上传
 )
 
 var retryCmd = &cobra.Command{
@@ -59,7 +59,7 @@ var retryCmd = &cobra.Command{
 		cfg := config.Get()
 		if cfg == nil {
 			fmt.Fprintf(os.Stderr, "配置未加载\n")
-			os.Exit(1)
+			exit(1)
 		}
 
 		// 如果指定了 --from-config，从配置文件中读取 video_ids
@@ -88,7 +88,7 @@ var retryCmd = &cobra.Command{
 						Msg("从频道配置读取 video_ids")
 				} else {
 					fmt.Fprintf(os.Stderr, "错误：配置文件中未找到 video_ids（请检查 youtube.video_ids 或 youtube_channels[].video_ids）\n")
-					os.Exit(1)
+					exit(1)
 				}
 			}
 		} else {
@@ -96,24 +96,28 @@ var retryCmd = &cobra.Command{
 			if len(args) == 0 {
 				fmt.Fprintf(os.Stderr, "错误：请至少指定一个 video_id，或使用 --from-config 从配置文件读取\n")
 				cmd.Help()
-				os.Exit(1)
+				exit(1)
 			}
 			retryVideoIDs = args
 		}
 
 		if len(retryVideoIDs) == 0 {
 			fmt.Fprintf(os.Stderr, "错误：未找到要处理的 video_id\n")
-			os.Exit(1)
+			exit(1)
+		}
+
+		if retryAllowDup {
+			cfg.Bilibili.AllowDuplicateUpload = true
 		}
 
 		application, err := app.NewApp(cfg)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "初始化应用失败: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 
 		logger.SetLevel(zerolog.InfoLevel)
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// 如果从配置读取，需要先初始化 logger（因为之前可能还没有初始化）
 		if retryFromConfig {
@@ -128,7 +132,7 @@ var retryCmd = &cobra.Command{
 		absDownloadsDir, err := filepath.Abs(downloadsDir)
 		if err != nil {
 			logger.Error().Err(err).Str("dir", downloadsDir).Msg("解析 downloads 目录路径失败")
-			os.Exit(1)
+			exit(1)
 		}
 
 		fileRepo := file.NewRepository(downloadsDir)
@@ -144,7 +148,7 @@ var retryCmd = &cobra.Command{
 		channelEntries, err := os.ReadDir(absDownloadsDir)
 		if err != nil {
 			logger.Error().Err(err).Str("dir", absDownloadsDir).Msg("读取 downloads 目录失败")
-			os.Exit(1)
+			exit(1)
 		}
 
 		foundCount := 0
@@ -328,7 +332,7 @@ var retryCmd = &cobra.Command{
 		}
 
 		if errorCount > 0 {
-			os.Exit(1)
+			exit(1)
 		}
 	},
 }
@@ -354,13 +358,10 @@ func cleanupVideoStatus(videoDir string, fileRepo file.Repository, downloadOnly,
 
 	// 清理上传状态
 	if !downloadOnly {
-		uploadStatusFile := filepath.Join(videoDir, "upload_status.json")
-		if _, err := os.Stat(uploadStatusFile); err == nil {
-			if err := os.Remove(uploadStatusFile); err != nil {
-				return fmt.Errorf("删除上传状态文件失败: %w", err)
-			}
-			logger.Info().Str("video_dir", videoDir).Msg("已删除上传状态文件")
+		if err := fileRepo.ClearUploadStatus(videoDir); err != nil {
+			return err
 		}
+		logger.Info().Str("video_dir", videoDir).Msg("已清除上传状态")
 	}
 
 	return nil
@@ -371,5 +372,6 @@ func init() {
 	retryCmd.Flags().BoolVar(&retryUploadOnly, "upload-only", false, "只重新上传，不下载")
 	retryCmd.Flags().StringVar(&retryAccount, "account", "", "指定上传时使用的B站账号名称（如果不指定，使用配置中的第一个账号）")
 	retryCmd.Flags().BoolVar(&retryFromConfig, "from-config", false, "从配置文件的 video_ids 读取要处理的视频（优先级：全局配置 > 频道配置）")
+	retryCmd.Flags().BoolVar(&retryAllowDup, "allow-duplicate", false, "允许上传已在其他账号/服务器发布过的视频（忽略上传账本）")
 	rootCmd.AddCommand(retryCmd)
 }
@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
 
 	"blueberry/internal/config"
+	"blueberry/internal/service"
 	"blueberry/pkg/logger"
 
 	"github.com/spf13/cobra"
@@ -20,10 +25,63 @@ var rootCmd = &cobra.Command{
 支持批量处理多个频道，自动下载多语言字幕，并上传到指定的B站账号。`,
 }
 
+// interrupted 是否收到过 Ctrl+C / SIGTERM
+var interrupted atomic.Bool
+
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watchSignals(cancel)
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Fprintf(os.Stderr, "执行命令时出错: %v\n", err)
-		os.Exit(1)
+		exit(1)
+	}
+	if interrupted.Load() {
+		exit(130)
+	}
+}
+
+// watchSignals 第一次收到 SIGINT / SIGTERM 时取消根 context，让命令停止领取新任务、
+// 中断子进程与上传并把进行中的视频回滚为可恢复状态；第二次收到时立即强制退出
+func watchSignals(cancel context.CancelFunc) {
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigCh
+		interrupted.Store(true)
+		logger.Warn().Str("signal", sig.String()).Msg("收到退出信号，正在停止并回滚进行中的任务（再次 Ctrl+C 强制退出）")
+		cancel()
+
+		<-sigCh
+		fmt.Fprintln(os.Stderr, "再次收到退出信号，强制退出（进行中的视频可能停留在 downloading / uploading，可用 fsck --fix 修复）")
+		os.Exit(130)
+	}()
+}
+
+// exit 打印中断汇总后退出；被信号中断时退出码固定为 130
+func exit(code int) {
+	if interrupted.Load() {
+		printInterruptSummary()
+		code = 130
+	}
+	os.Exit(code)
+}
+
+// printInterruptSummary 输出被中断并回滚的视频
+func printInterruptSummary() {
+	items := service.InterruptedItems()
+	if len(items) == 0 {
+		fmt.Fprintln(os.Stderr, "已中断：没有正在处理的视频需要回滚")
+		return
+	}
+	fmt.Fprintf(os.Stderr, "已中断：%d 个进行中的视频已回滚，下次运行会继续处理\n", len(items))
+	for _, item := range items {
+		kind := "下载"
+		if item.Kind == service.InterruptedUpload {
+			kind = "上传"
+		}
+		fmt.Fprintf(os.Stderr, "  [%s] %s  %s\n", kind, item.VideoDir, item.Note)
 	}
 }
 
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/internal/service"
+	"blueberry/pkg/logger"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scheduleAccount string
+	scheduleJSON    bool
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "查看各账号的定时发布排期",
+	Long: `列出已上传、等待定时发布的视频（.global/publish_schedule），以及每个账号下一个空闲的发布时段。
+
+开启 bilibili.schedule.enabled 后，每个视频上传时预约账号下一个空闲时段（每个时段最多 per_slot 个），
+上传失败时释放；upload --publish-at 可为本次上传直接指定发布时间。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.Get()
+		if cfg == nil {
+			fmt.Fprintf(os.Stderr, "配置未加载\n")
+			exit(1)
+		}
+		logger.SetLevel(zerolog.InfoLevel)
+
+		repo := file.NewRepository(cfg.Output.Directory)
+		schedule, err := repo.LoadPublishSchedule()
+		if err != nil {
+			logger.Error().Err(err).Msg("读取发布排期失败")
+			exit(1)
+		}
+		if scheduleAccount != "" {
+			schedule = map[string][]file.ScheduledPublish{scheduleAccount: schedule[scheduleAccount]}
+		}
+
+		if scheduleJSON {
+			data, err := json.MarshalIndent(schedule, "", "  ")
+			if err != nil {
+				logger.Error().Err(err).Msg("序列化发布排期失败")
+				exit(1)
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		accounts := make([]string, 0, len(cfg.BilibiliAccounts))
+		for name := range cfg.BilibiliAccounts {
+			if scheduleAccount == "" || name == scheduleAccount {
+				accounts = append(accounts, name)
+			}
+		}
+		sort.Strings(accounts)
+		for _, name := range accounts {
+			entries := schedule[name]
+			fmt.Printf("账号 %s: %d 个待发布\n", name, len(entries))
+			for _, e := range entries {
+				fmt.Printf("  %s  %s\n", time.Unix(e.At, 0).Format("2006-01-02 15:04"), e.VideoDir)
+			}
+			if !cfg.Bilibili.Schedule.Enabled {
+				continue
+			}
+			booked := make([]int64, 0, len(entries))
+			for _, e := range entries {
+				booked = append(booked, e.At)
+			}
+			next := "-"
+			if loc, slots, err := service.AccountPublishSlots(cfg.Bilibili.Schedule, cfg.BilibiliAccounts[name]); err != nil {
+				next = err.Error()
+			} else if at, err := service.NextPublishSlot(time.Now(), loc, slots, cfg.Bilibili.Schedule, booked); err != nil {
+				next = err.Error()
+			} else {
+				next = at.Format("2006-01-02 15:04 MST")
+			}
+			fmt.Printf("  下一个空闲时段: %s\n", next)
+		}
+	},
+}
+
+func init() {
+	scheduleCmd.Flags().StringVar(&scheduleAccount, "account", "", "只查看该账号")
+	scheduleCmd.Flags().BoolVar(&scheduleJSON, "json", false, "以 JSON 格式输出排期")
+	rootCmd.AddCommand(scheduleCmd)
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stateFrom string
+	stateTo   string
+)
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "状态存储管理",
+	Long:  `管理下载/上传状态与全局计数的存储后端（json / bolt）。`,
+}
+
+var stateMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "在状态存储后端之间迁移数据",
+	Long: `将下载状态、上传状态以及 .global 计数从一个后端复制到另一个后端。
+
+示例：
+  # JSON 文件 -> bolt 数据库（首次启用 bolt 时也会自动导入，这里用于手动重新导入）
+  blueberry state migrate --from json --to bolt
+
+  # bolt 数据库 -> JSON 文件（切回 json 后端前执行）
+  blueberry state migrate --from bolt --to json
+
+迁移完成后请将配置中的 output.state_backend 改为目标后端。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.Get()
+		if cfg == nil {
+			fmt.Fprintf(os.Stderr, "配置未加载\n")
+			exit(1)
+		}
+
+		logger.SetLevel(zerolog.InfoLevel)
+
+		if stateFrom == stateTo {
+			logger.Error().Str("from", stateFrom).Str("to", stateTo).Msg("源后端与目标后端相同")
+			exit(1)
+		}
+
+		src, err := file.NewStateStore(stateFrom, cfg.Output.Directory, cfg.Output.StateFile)
+		if err != nil {
+			logger.Error().Err(err).Msg("创建源状态存储失败")
+			exit(1)
+		}
+		dst, err := file.NewStateStore(stateTo, cfg.Output.Directory, cfg.Output.StateFile)
+		if err != nil {
+			logger.Error().Err(err).Msg("创建目标状态存储失败")
+			exit(1)
+		}
+
+		logger.Info().
+			Str("from", src.Backend()).
+			Str("to", dst.Backend()).
+			Str("output_dir", cfg.Output.Directory).
+			Msg("开始迁移状态")
+
+		count, err := file.CopyState(dst, src)
+		if err != nil {
+			logger.Error().Err(err).Int("copied", count).Msg("迁移状态失败")
+			exit(1)
+		}
+
+		logger.Info().
+			Int("video_count", count).
+			Str("to", dst.Backend()).
+			Msg("状态迁移完成")
+	},
+}
+
+func init() {
+	stateMigrateCmd.Flags().StringVar(&stateFrom, "from", file.StateBackendJSON, "源后端（json / bolt）")
+	stateMigrateCmd.Flags().StringVar(&stateTo, "to", file.StateBackendBolt, "目标后端（json / bolt）")
+	stateCmd.AddCommand(stateMigrateCmd)
+	rootCmd.AddCommand(stateCmd)
+}
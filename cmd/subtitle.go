@@ -1,13 +1,13 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"blueberry/internal/app"
 	"blueberry/internal/config"
+	"blueberry/internal/service"
 	"blueberry/pkg/logger"
 
 	"github.com/rs/zerolog"
@@ -18,6 +18,10 @@ var (
 	subtitleChannelDir string
 	subtitleVideoDir   string
 	subtitleForce      bool
+
+	backfillDir    string
+	backfillLangs  []string
+	backfillDryRun bool
 )
 
 var subtitleCmd = &cobra.Command{
@@ -31,17 +35,17 @@ var subtitleCmd = &cobra.Command{
 		cfg := config.Get()
 		if cfg == nil {
 			fmt.Fprintf(os.Stderr, "配置未加载\n")
-			os.Exit(1)
+			exit(1)
 		}
 
 		application, err := app.NewApp(cfg)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "初始化应用失败: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 
 		logger.SetLevel(zerolog.InfoLevel)
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		downloadService := application.DownloadService
 
@@ -52,7 +56,7 @@ var subtitleCmd = &cobra.Command{
 			absVideoDir, err := filepath.Abs(subtitleVideoDir)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "解析视频目录路径失败: %v\n", err)
-				os.Exit(1)
+				exit(1)
 			}
 			errExecute = downloadService.FixSubtitlesForVideoDir(ctx, absVideoDir, subtitleForce)
 		} else if subtitleChannelDir != "" {
@@ -60,7 +64,7 @@ var subtitleCmd = &cobra.Command{
 			absChannelDir, err := filepath.Abs(subtitleChannelDir)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "解析频道目录路径失败: %v\n", err)
-				os.Exit(1)
+				exit(1)
 			}
 			errExecute = downloadService.FixSubtitlesForChannelDir(ctx, absChannelDir, subtitleForce)
 		} else {
@@ -68,9 +72,78 @@ var subtitleCmd = &cobra.Command{
 			errExecute = downloadService.FixSubtitles(ctx, subtitleForce)
 		}
 
-		if errExecute != nil {
+		if errExecute != nil && ctx.Err() == nil {
 			fmt.Fprintf(os.Stderr, "补充字幕失败: %v\n", errExecute)
-			os.Exit(1)
+			exit(1)
+		}
+	},
+}
+
+var subtitleBackfillCmd = &cobra.Command{
+	Use:   "backfill",
+	Short: "为已发布的视频补传缺失语言的字幕",
+	Long: `为已上传到 B站的视频补传字幕：本地已下载、但 upload_status.json 中没有成功记录的语言，
+会按 bilibili.subtitle_languages 映射的语言 ID 上传并追加到对应的 aid。
+未配置语言 ID 的语言会记为失败，补充映射后重新运行即可。
+
+补传前会查询稿件上已有的字幕语言，已有的语言（如发布时随稿件提交的英语字幕）不会重复追加，
+直接记为已上传。字幕只用上传该稿件的账号补传，账号已不在 bilibili_accounts 中的视频记为失败。
+
+示例：
+  blueberry subtitle backfill --dry-run
+  blueberry subtitle backfill --lang zh-Hans,id,th
+  blueberry subtitle backfill --dir downloads/Comic-likerhythm`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.Get()
+		if cfg == nil {
+			fmt.Fprintf(os.Stderr, "配置未加载\n")
+			exit(1)
+		}
+
+		application, err := app.NewApp(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "初始化应用失败: %v\n", err)
+			exit(1)
+		}
+
+		logger.SetLevel(zerolog.InfoLevel)
+		ctx := cmd.Context()
+
+		items, err := application.UploadService.BackfillSubtitles(ctx, service.SubtitleBackfillOptions{
+			Dir:       backfillDir,
+			Languages: backfillLangs,
+			DryRun:    backfillDryRun,
+		})
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "补传字幕失败: %v\n", err)
+			exit(1)
+		}
+
+		succeeded, failed := 0, 0
+		for _, item := range items {
+			switch {
+			case backfillDryRun:
+				line := fmt.Sprintf("%s  aid=%s  %s", item.VideoDir, item.AID, item.Lang)
+				if item.Error != "" {
+					line += "  （" + item.Error + "）"
+				} else {
+					line += fmt.Sprintf("  lang_id=%d", item.LangID)
+				}
+				fmt.Println(line)
+			case item.Error != "":
+				failed++
+				fmt.Printf("失败  %s  aid=%s  %s: %s\n", item.VideoDir, item.AID, item.Lang, item.Error)
+			default:
+				succeeded++
+			}
+		}
+		if backfillDryRun {
+			fmt.Printf("\n共 %d 个字幕待补传（dry-run，未上传）\n", len(items))
+			return
+		}
+		fmt.Printf("\n补传完成：成功 %d，失败 %d\n", succeeded, failed)
+		if failed > 0 {
+			exit(1)
 		}
 	},
 }
@@ -79,5 +152,9 @@ func init() {
 	subtitleCmd.Flags().StringVar(&subtitleChannelDir, "channel-dir", "", "指定要处理的频道目录（例如：downloads/Comic-likerhythm）")
 	subtitleCmd.Flags().StringVar(&subtitleVideoDir, "video-dir", "", "指定要处理的视频目录（例如：downloads/Comic-likerhythm/videoTitle）")
 	subtitleCmd.Flags().BoolVar(&subtitleForce, "force", false, "强制模式：忽略状态文件，对所有缺失的字幕进行下载，并确保新旧格式都存在")
+	subtitleBackfillCmd.Flags().StringVar(&backfillDir, "dir", "", "只处理该频道目录或视频目录下已上传的视频")
+	subtitleBackfillCmd.Flags().StringSliceVar(&backfillLangs, "lang", nil, "只补传这些语言（逗号分隔，例如：zh-Hans,id,th）")
+	subtitleBackfillCmd.Flags().BoolVar(&backfillDryRun, "dry-run", false, "只列出待补传的字幕，不实际上传")
+	subtitleCmd.AddCommand(subtitleBackfillCmd)
 	rootCmd.AddCommand(subtitleCmd)
 }
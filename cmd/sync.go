@@ -1,19 +1,16 @@
 package cmd
 
 import (
-	"context"
 	"errors"
 	"fmt"
-	"math/rand"
 	"os"
-	"path/filepath"
 	"strings"
-	"time"
 
 	"blueberry/internal/app"
 	"blueberry/internal/config"
 	"blueberry/internal/repository/file"
 	"blueberry/internal/repository/youtube"
+	"blueberry/internal/service"
 	"blueberry/pkg/logger"
 
 	"github.com/rs/zerolog"
@@ -25,6 +22,7 @@ var (
 	serialAll        bool
 	syncLimit        int
 	syncOffset       int
+	syncAllowDup     bool
 )
 
 // sync: 按视频顺序逐个下载完再上传（下载→上传为一个原子单元）
@@ -36,136 +34,49 @@ var syncCmd = &cobra.Command{
 		cfg := config.Get()
 		if cfg == nil {
 			fmt.Fprintf(os.Stderr, "配置未加载\n")
-			os.Exit(1)
+			exit(1)
 		}
 
 		if !serialAll && serialChannelURL == "" {
 			fmt.Fprintf(os.Stderr, "请指定频道（--channel）或使用 --all 处理所有频道\n")
-			os.Exit(1)
+			exit(1)
+		}
+
+		if syncAllowDup {
+			cfg.Bilibili.AllowDuplicateUpload = true
 		}
 
 		application, err := app.NewApp(cfg)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "初始化应用失败: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 
 		logger.SetLevel(zerolog.InfoLevel)
-		ctx := context.Background()
-
-		fileRepo := file.NewRepository(cfg.Output.Directory)
-
-		processChannel := func(ch config.YouTubeChannel) error {
-			// 命令行覆盖 offset/limit（优先于配置）
-			if syncLimit != 0 || syncOffset != 0 {
-				cfg.YouTube.LimitOverride = syncLimit
-				cfg.YouTube.OffsetOverride = syncOffset
-				logger.Info().Int("limit", syncLimit).Int("offset", syncOffset).Msg("应用命令行覆盖（sync）")
-			}
-			channelID := fileRepo.ExtractChannelID(ch.URL)
-			channelDir := filepath.Join(cfg.Output.Directory, channelID)
-
-			// 确保有频道信息
-			if !fileRepo.ChannelInfoExists(channelID) {
-				logger.Info().Str("channel_url", ch.URL).Msg("未找到频道信息，先解析频道")
-				if err := application.DownloadService.ParseChannels(ctx); err != nil {
-					return fmt.Errorf("解析频道失败: %w", err)
-				}
-			}
-
-			videos, err := fileRepo.LoadChannelInfo(channelID)
-			if err != nil || len(videos) == 0 {
-				return fmt.Errorf("加载频道视频列表失败或为空: %w", err)
-			}
-
-			// 计算有效 offset/limit（命令行 > 配置）
-			offset := 0
-			limit := 0
-			if cfg.YouTube.OffsetOverride != 0 || cfg.YouTube.LimitOverride != 0 {
-				offset = cfg.YouTube.OffsetOverride
-				limit = cfg.YouTube.LimitOverride
-			} else {
-				offset = ch.Offset
-				limit = ch.Limit
-			}
-			if offset < 0 {
-				offset = 0
-			}
-			start := offset
-			end := len(videos)
-			if limit > 0 && start+limit < end {
-				end = start + limit
-			}
-			if start > len(videos) {
-				start = len(videos)
-			}
-			if start < end {
-				videos = videos[start:end]
-			} else {
-				videos = []map[string]interface{}{}
-			}
-
-			logger.Info().
-				Str("channel_id", channelID).
-				Int("count", len(videos)).
-				Int("offset", offset).
-				Int("limit", limit).
-				Msg("开始顺序处理频道视频（已应用 offset/limit）")
-
-			for i, v := range videos {
-				videoID, _ := v["id"].(string)
-				title, _ := v["title"].(string)
-				if videoID == "" {
-					continue
-				}
+		ctx := cmd.Context()
 
-				logger.Info().
-					Int("current", i+1).
-					Int("total", len(videos)).
-					Str("video_id", videoID).
-					Str("title", title).
-					Msg("处理视频")
-
-				// 目录使用 videoID（与下载服务一致）
-				videoDir := filepath.Join(channelDir, videoID)
-
-				// 如果已上传，直接跳过（避免不必要的下载与上传）
-				if fileRepo.IsVideoUploaded(videoDir) {
-					logger.Info().
-						Str("video_dir", videoDir).
-						Msg("该视频已上传（upload_status.json=completed），跳过下载与上传")
-					continue
-				}
-
-				// 先下载该视频（包含字幕/缩略图等按需步骤）
-				if err := application.DownloadService.DownloadVideoDir(ctx, videoDir); err != nil {
-					if errors.Is(err, youtube.ErrBotDetection) || strings.Contains(err.Error(), "bot detection") {
-						logger.Error().Err(err).Msg("检测到 bot detection，立即退出程序")
-						os.Exit(1)
-					}
-					logger.Error().Err(err).Str("video_dir", videoDir).Msg("下载该视频失败，继续下一个")
-					continue
-				}
-
-				// 选择可用账号（随机），按每日上限过滤
-				accountName, ok := selectAvailableAccount(cfg, fileRepo)
-				if !ok {
-					logger.Error().Msg("没有可用的B站账号（当日额度已用尽），终止后续处理")
-					return fmt.Errorf("no available bilibili account today")
-				}
-				logger.Info().Str("account", accountName).Msg("选择上传账号")
-				// 立即上传该视频
-				if err := application.UploadService.UploadSingleVideo(ctx, videoDir, accountName); err != nil {
-					logger.Error().Err(err).Str("video_dir", videoDir).Msg("上传该视频失败，继续下一个")
-					continue
-				}
-			}
-			return nil
+		// 命令行覆盖 offset/limit（优先于配置）
+		if syncLimit != 0 || syncOffset != 0 {
+			cfg.YouTube.LimitOverride = syncLimit
+			cfg.YouTube.OffsetOverride = syncOffset
+			logger.Info().Int("limit", syncLimit).Int("offset", syncOffset).Msg("应用命令行覆盖（sync）")
 		}
-
+		syncService := service.NewSyncService(
+			application.DownloadService,
+			application.UploadService,
+			file.NewRepository(cfg.Output.Directory),
+			cfg,
+		)
 		if serialAll {
 			for _, ch := range cfg.YouTubeChannels {
-				if err := processChannel(ch); err != nil {
+				if err := syncService.SyncChannel(ctx, ch); err != nil {
+					if ctx.Err() != nil {
+						break
+					}
+					if errors.Is(err, youtube.ErrBotDetection) || strings.Contains(err.Error(), "bot detection") {
+						logger.Error().Err(err).Str("channel_url", ch.URL).Msg("检测到 bot detection，停止处理后续频道")
+						exit(1)
+					}
 					logger.Error().Err(err).Str("channel_url", ch.URL).Msg("顺序同步失败（继续下一个频道）")
 				}
 			}
@@ -182,11 +93,14 @@ var syncCmd = &cobra.Command{
 		}
 		if target == nil {
 			fmt.Fprintf(os.Stderr, "未找到该频道配置：%s\n", serialChannelURL)
-			os.Exit(1)
+			exit(1)
 		}
-		if err := processChannel(*target); err != nil {
+		if err := syncService.SyncChannel(ctx, *target); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
 			logger.Error().Err(err).Str("channel_url", target.URL).Msg("顺序同步失败")
-			os.Exit(1)
+			exit(1)
 		}
 	},
 }
@@ -196,39 +110,6 @@ func init() {
 	syncCmd.Flags().BoolVar(&serialAll, "all", false, "顺序同步配置中所有频道")
 	syncCmd.Flags().IntVar(&syncLimit, "limit", 0, "限制下载的视频数量（>0 生效）")
 	syncCmd.Flags().IntVar(&syncOffset, "offset", 0, "下载起始偏移（从 0 开始）")
+	syncCmd.Flags().BoolVar(&syncAllowDup, "allow-duplicate", false, "允许上传已在其他账号/服务器发布过的视频（忽略上传账本）")
 	rootCmd.AddCommand(syncCmd)
 }
-
-// selectAvailableAccount 从配置中随机选择当日未达上限的账号
-func selectAvailableAccount(cfg *config.Config, repo file.Repository) (string, bool) {
-	// 收集账号名
-	names := make([]string, 0, len(cfg.BilibiliAccounts))
-	for name := range cfg.BilibiliAccounts {
-		names = append(names, name)
-	}
-	if len(names) == 0 {
-		return "", false
-	}
-	// 读取当日计数
-	counts, err := repo.LoadTodayUploadCounts()
-	if err != nil {
-		counts = map[string]int{}
-	}
-	limit := cfg.Bilibili.DailyUploadLimit
-	if limit <= 0 {
-		limit = 160
-	}
-	// 过滤可用列表
-	available := make([]string, 0, len(names))
-	for _, n := range names {
-		if counts[n] < limit {
-			available = append(available, n)
-		}
-	}
-	if len(available) == 0 {
-		return "", false
-	}
-	// 随机选择一个
-	rand.Seed(time.Now().UnixNano())
-	return available[rand.Intn(len(available))], true
-}
@@ -22,6 +22,8 @@ var (
 	uploadAll        bool
 	uploadWatch      bool
 	uploadIntervalM  int
+	uploadAllowDup   bool
+	uploadPublishAt  string
 )
 
 var uploadCmd = &cobra.Command{
@@ -32,18 +34,29 @@ var uploadCmd = &cobra.Command{
 		cfg := config.Get()
 		if cfg == nil {
 			fmt.Fprintf(os.Stderr, "配置未加载\n")
-			os.Exit(1)
+			exit(1)
+		}
+		if uploadAllowDup {
+			cfg.Bilibili.AllowDuplicateUpload = true
+		}
+		if uploadPublishAt != "" {
+			at, err := parsePublishAt(uploadPublishAt)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "--publish-at 无效: %v\n", err)
+				exit(1)
+			}
+			cfg.Bilibili.PublishAtOverride = at
 		}
 
 		application, err := app.NewApp(cfg)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "初始化应用失败: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 
 		logger.SetLevel(zerolog.InfoLevel)
 
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// 如果指定了 --all，上传所有频道
 		if uploadAll {
@@ -52,15 +65,12 @@ var uploadCmd = &cobra.Command{
 				if err := application.UploadService.UploadAllChannels(ctx); err != nil {
 					logger.Error().Err(err).Msg("上传所有频道失败")
 					if !uploadWatch {
-						os.Exit(1)
+						exit(1)
 					}
 				}
-				if !uploadWatch {
+				if !uploadWatch || !waitNextUploadRound(ctx) {
 					return
 				}
-				interval := time.Duration(uploadIntervalM) * time.Minute
-				logger.Info().Dur("sleep", interval).Msg("本轮上传完成，进入休眠等待下一轮")
-				time.Sleep(interval)
 			}
 		}
 
@@ -71,15 +81,12 @@ var uploadCmd = &cobra.Command{
 				if err := application.UploadService.UploadChannel(ctx, uploadChannel); err != nil {
 					logger.Error().Err(err).Str("channel", uploadChannel).Msg("上传频道失败")
 					if !uploadWatch {
-						os.Exit(1)
+						exit(1)
 					}
 				}
-				if !uploadWatch {
+				if !uploadWatch || !waitNextUploadRound(ctx) {
 					return
 				}
-				interval := time.Duration(uploadIntervalM) * time.Minute
-				logger.Info().Dur("sleep", interval).Msg("本轮上传完成，进入休眠等待下一轮")
-				time.Sleep(interval)
 			}
 		}
 
@@ -90,52 +97,72 @@ var uploadCmd = &cobra.Command{
 				if err := application.UploadService.UploadChannelDir(ctx, uploadChannelDir); err != nil {
 					logger.Error().Err(err).Str("channel_dir", uploadChannelDir).Msg("上传频道目录失败")
 					if !uploadWatch {
-						os.Exit(1)
+						exit(1)
 					}
 				}
-				if !uploadWatch {
+				if !uploadWatch || !waitNextUploadRound(ctx) {
 					return
 				}
-				interval := time.Duration(uploadIntervalM) * time.Minute
-				logger.Info().Dur("sleep", interval).Msg("本轮上传完成，进入休眠等待下一轮")
-				time.Sleep(interval)
 			}
 		}
 
 		// 否则，上传单个视频
 		if uploadVideoPath == "" {
 			fmt.Fprintf(os.Stderr, "请指定：--video-dir（单视频）或 --channel（频道URL）或 --channel-dir（频道目录）或 --all（全部频道）\n")
-			os.Exit(1)
+			exit(1)
 		}
 
 		accountName := uploadAccount
 		if accountName == "" {
 			fmt.Fprintf(os.Stderr, "请指定B站账号名称（--account）\n")
-			os.Exit(1)
+			exit(1)
 		}
 
 		if _, exists := cfg.BilibiliAccounts[accountName]; !exists {
 			fmt.Fprintf(os.Stderr, "账号 %s 不存在\n", accountName)
-			os.Exit(1)
+			exit(1)
 		}
 
 		for {
 			if err := application.UploadService.UploadSingleVideo(ctx, uploadVideoPath, accountName); err != nil {
 				if !uploadWatch {
-					os.Exit(1)
+					exit(1)
 				}
 				logger.Error().Err(err).Str("video_dir", uploadVideoPath).Msg("单视频上传失败")
 			}
-			if !uploadWatch {
+			if !uploadWatch || !waitNextUploadRound(ctx) {
 				return
 			}
-			interval := time.Duration(uploadIntervalM) * time.Minute
-			logger.Info().Dur("sleep", interval).Msg("本轮上传完成，进入休眠等待下一轮")
-			time.Sleep(interval)
 		}
 	},
 }
 
+// waitNextUploadRound --watch 模式下休眠到下一轮；收到退出信号时返回 false
+func waitNextUploadRound(ctx context.Context) bool {
+	interval := time.Duration(uploadIntervalM) * time.Minute
+	logger.Info().Dur("sleep", interval).Msg("本轮上传完成，进入休眠等待下一轮")
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// parsePublishAt 解析 --publish-at："2006-01-02 15:04"（本地时间）或 RFC3339
+func parsePublishAt(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无法解析时间 %q（使用 \"YYYY-MM-DD HH:MM\" 或 RFC3339）", value)
+	}
+	return t, nil
+}
+
 func init() {
 	uploadCmd.Flags().StringVar(&uploadVideoPath, "video-dir", "", "要上传的视频目录路径（单个视频模式）")
 	uploadCmd.Flags().StringVar(&uploadAccount, "account", "", "B站账号名称（单个视频模式）")
@@ -144,4 +171,6 @@ func init() {
 	uploadCmd.Flags().BoolVar(&uploadAll, "all", false, "上传配置文件中所有频道（全部频道模式）")
 	uploadCmd.Flags().BoolVar(&uploadWatch, "watch", false, "持续循环上传；每轮结束后休眠并再次扫描上传")
 	uploadCmd.Flags().IntVar(&uploadIntervalM, "interval-minutes", 5, "watch 模式的每轮间隔（分钟）")
+	uploadCmd.Flags().StringVar(&uploadPublishAt, "publish-at", "", "立即上传、在指定时间发布（\"2006-01-02 15:04\" 本地时间或 RFC3339），优先于 bilibili.schedule 排期")
+	uploadCmd.Flags().BoolVar(&uploadAllowDup, "allow-duplicate", false, "允许上传已在其他账号/服务器发布过的视频（忽略上传账本）")
 }
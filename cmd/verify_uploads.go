@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"blueberry/internal/app"
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/internal/service"
+	"blueberry/pkg/logger"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyDir     string
+	verifyAll     bool
+	verifyRequeue bool
+	verifyEnqueue bool
+	verifyJSON    bool
+)
+
+var verifyUploadsCmd = &cobra.Command{
+	Use:   "verify-uploads",
+	Short: "查询已上传视频的审核 / 转码状态，可将退回的视频重新排队上传",
+	Long: `查询每个已记录 aid 的稿件在创作中心的状态（开放浏览、审核中、转码中、转码失败、退回、已删除），
+并将状态码、描述与退回原因写入上传状态（upload_status.json 的 review 字段）。
+已开放浏览或已删除的稿件默认不再查询（--all 重新查询）。
+
+退回或转码失败的视频在 verify.auto_requeue_rejected=true 或指定 --requeue 时重新排队上传：
+原 aid 记入 rejected_aids，上传状态回到 pending，重新上传时标题追加序号、封面改用从视频中截取的另一帧。
+本地视频文件已删除或重新上传次数达到 verify.max_resubmissions 时不会重新排队。
+
+示例：
+  blueberry verify-uploads
+  blueberry verify-uploads --requeue --enqueue
+  blueberry verify-uploads --dir downloads/Comic-likerhythm --all --json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := config.Get()
+		if cfg == nil {
+			fmt.Fprintf(os.Stderr, "配置未加载\n")
+			exit(1)
+		}
+
+		application, err := app.NewApp(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "初始化应用失败: %v\n", err)
+			exit(1)
+		}
+
+		logger.SetLevel(zerolog.InfoLevel)
+		ctx := cmd.Context()
+
+		opts := service.VerifyOptions{Dir: verifyDir, All: verifyAll, Requeue: verifyRequeue}
+		if verifyEnqueue {
+			queue, err := openUploadQueue(cfg)
+			if err != nil {
+				logger.Error().Err(err).Msg("打开上传队列失败")
+				exit(1)
+			}
+			opts.Queue = queue
+		}
+
+		items, err := application.UploadService.VerifyUploads(ctx, opts)
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "检查稿件状态失败: %v\n", err)
+			exit(1)
+		}
+
+		if verifyJSON {
+			data, _ := json.MarshalIndent(items, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+		counts := make(map[file.ReviewState]int)
+		failed, requeued := 0, 0
+		for _, item := range items {
+			if item.Error != "" && item.State == "" {
+				failed++
+				fmt.Printf("查询失败  %s  aid=%s: %s\n", item.VideoDir, item.AID, item.Error)
+				continue
+			}
+			counts[item.State]++
+			if !item.State.NeedsResubmit() && !item.Changed {
+				continue
+			}
+			line := fmt.Sprintf("%-16s %s  aid=%s  code=%d", item.State, item.VideoDir, item.AID, item.Code)
+			if item.RejectReason != "" {
+				line += "  原因: " + item.RejectReason
+			}
+			if item.Requeued {
+				requeued++
+				line += "  → 已重新排队"
+			} else if item.Error != "" {
+				line += "  （未重新排队: " + item.Error + "）"
+			}
+			fmt.Println(line)
+		}
+		fmt.Printf("\n共检查 %d 个稿件：开放 %d，审核中 %d，转码中 %d，转码失败 %d，退回 %d，已删除 %d，未知 %d，查询失败 %d；重新排队 %d\n",
+			len(items), counts[file.ReviewPublished], counts[file.ReviewReviewing], counts[file.ReviewTranscoding],
+			counts[file.ReviewTranscodeFailed], counts[file.ReviewRejected], counts[file.ReviewDeleted], counts[file.ReviewUnknown],
+			failed, requeued)
+	},
+}
+
+func init() {
+	verifyUploadsCmd.Flags().StringVar(&verifyDir, "dir", "", "只检查该频道目录或视频目录下已上传的视频")
+	verifyUploadsCmd.Flags().BoolVar(&verifyAll, "all", false, "重新检查已开放浏览 / 已删除的稿件")
+	verifyUploadsCmd.Flags().BoolVar(&verifyRequeue, "requeue", false, "将退回 / 转码失败的视频重新排队上传（忽略 verify.auto_requeue_rejected）")
+	verifyUploadsCmd.Flags().BoolVar(&verifyEnqueue, "enqueue", false, "重新排队的视频同时加入 pipeline 上传队列")
+	verifyUploadsCmd.Flags().BoolVar(&verifyJSON, "json", false, "以 JSON 格式输出每个稿件的检查结果")
+	rootCmd.AddCommand(verifyUploadsCmd)
+}
@@ -31,19 +31,34 @@ func NewApp(cfg *config.Config) (*App, error) {
 	}
 	// 根据配置选择上传方式
 	var bilibiliUploader bilibili.Uploader
-	uploadMethod := cfg.Bilibili.UploadMethod
-	if uploadMethod == "" {
-		uploadMethod = "http" // 默认使用 HTTP 方式
-	}
-
-	if uploadMethod == "http" {
-		bilibiliUploader = bilibili.NewHTTPUploader(
+	switch cfg.Bilibili.UploadMethod {
+	case config.UploadMethodChromedp:
+		bilibiliUploader = bilibili.NewUploader(
 			cfg.Bilibili.BaseURL,
 			cfg.Bilibili.CookiesFromBrowser,
 			cfg.Bilibili.CookiesFile,
+			false,
 		)
-	} else {
-		bilibiliUploader = bilibili.NewUploader(
+	case config.UploadMethodAuto:
+		// HTTP 接口变化时改用无头浏览器重新上传
+		bilibiliUploader = bilibili.NewFallbackUploader(
+			bilibili.NewHTTPUploader(
+				fileRepo,
+				cfg.Bilibili.BaseURL,
+				cfg.Bilibili.CookiesFromBrowser,
+				cfg.Bilibili.CookiesFile,
+			),
+			bilibili.NewUploader(
+				cfg.Bilibili.BaseURL,
+				cfg.Bilibili.CookiesFromBrowser,
+				cfg.Bilibili.CookiesFile,
+				true,
+			),
+		)
+	default:
+		// 默认使用 HTTP 方式
+		bilibiliUploader = bilibili.NewHTTPUploader(
+			fileRepo,
 			cfg.Bilibili.BaseURL,
 			cfg.Bilibili.CookiesFromBrowser,
 			cfg.Bilibili.CookiesFile,
@@ -63,6 +78,7 @@ func NewApp(cfg *config.Config) (*App, error) {
 	)
 	uploadService := service.NewUploadService(
 		bilibiliUploader,
+		bilibili.NewPlaylists(cfg.Bilibili.CookiesFile),
 		ytParser,
 		subtitleManager,
 		fileRepo,
@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
+
+	"blueberry/pkg/metadata"
 
 	"github.com/spf13/viper"
 )
@@ -16,13 +19,16 @@ type Config struct {
 	YouTube          YouTubeConfig      `mapstructure:"youtube"`
 	Logging          LoggingConfig      `mapstructure:"logging"`
 	Channel          ChannelConfig      `mapstructure:"channel"`
+	Pipeline         PipelineConfig     `mapstructure:"pipeline"`
+	Daemon           DaemonConfig       `mapstructure:"daemon"`
+	Verify           VerifyConfig       `mapstructure:"verify"`
 }
 
 type BilibiliConfig struct {
 	BaseURL            string `mapstructure:"base_url"`
 	CookiesFromBrowser string `mapstructure:"cookies_from_browser"` // 从浏览器导入 cookies（仅本地开发环境使用）
 	CookiesFile        string `mapstructure:"cookies_file"`         // Cookies 文件路径（Netscape 格式或 JSON 格式，推荐用于服务器环境）
-	UploadMethod       string `mapstructure:"upload_method"`        // 上传方式：http（纯HTTP，推荐）或 chromedp（浏览器自动化，需要浏览器）
+	UploadMethod       string `mapstructure:"upload_method"`        // 上传方式：http（纯HTTP，推荐）、chromedp（浏览器自动化，需要浏览器）或 auto（HTTP 接口变化时改用无头浏览器）
 	// UploadSubtitles 控制是否上传字幕文件，默认 false（不上传）
 	UploadSubtitles bool `mapstructure:"upload_subtitles"`
 	// 上传成功后是否删除本地原视频文件（仅删除视频，不删除字幕/元数据）
@@ -33,6 +39,119 @@ type BilibiliConfig struct {
 	ChunkUploadRetries int `mapstructure:"chunk_upload_retries"`
 	// 分块上传重试退避（秒），第 n 次重试等待 n*该值 秒，默认 1
 	ChunkRetryBackoffSeconds int `mapstructure:"chunk_retry_backoff_seconds"`
+	// 分块上传会话有效期（小时），中断后在有效期内重新上传会从第一个缺失的分块续传，默认 24；<=0 不续传
+	UploadSessionTTLHours int `mapstructure:"upload_session_ttl_hours"`
+	// 同时上传的分块数量，默认 1（逐个上传）
+	UploadConcurrency int `mapstructure:"upload_concurrency"`
+	// 单个视频所有分块共享的重试次数预算，用尽后本次上传失败；默认 20，0 表示不限制（仅受 chunk_upload_retries 约束）
+	UploadRetryBudget int `mapstructure:"upload_retry_budget"`
+	// SubtitleLanguages YouTube 字幕语言代码（en、zh-Hans、id、th…）到 bilibili.tv 字幕语言 ID 的映射，
+	// 未配置映射或 ID 为 0 的语言不上传；未配置该项时默认仅 en: 3（注意 viper 会把键转为小写，匹配时不区分大小写）
+	SubtitleLanguages map[string]int `mapstructure:"subtitle_languages"`
+	// Metadata 投稿标题 / 简介 / 标签模板（频道可在 youtube_channels[].metadata 中覆盖）
+	Metadata MetadataTemplates `mapstructure:"metadata"`
+	// Sanitize 发布前对标题 / 简介 / 标签的清洗规则
+	Sanitize SanitizeConfig `mapstructure:"sanitize"`
+	// Schedule 定时发布：上传后按账号的发布时段排期，而不是立即发布
+	Schedule ScheduleConfig `mapstructure:"schedule"`
+	// AccountSelection 自动选择上传账号的策略（频道可在 youtube_channels[].account_selection 中覆盖）
+	AccountSelection AccountSelectionConfig `mapstructure:"account_selection"`
+	// Quarantine 上传因账号问题失败（cookies 失效、风控限流、封禁）时暂停使用该账号的时长
+	Quarantine QuarantineConfig `mapstructure:"quarantine"`
+	// 运行期覆盖（--allow-duplicate），不从配置文件读取：忽略全局上传账本，允许重复上传已发布过的视频
+	AllowDuplicateUpload bool `mapstructure:"-"`
+	// 运行期覆盖（upload --publish-at），不从配置文件读取：本次上传的视频在该时间发布，优先于排期
+	PublishAtOverride time.Time `mapstructure:"-"`
+}
+
+// 上传方式
+const (
+	UploadMethodHTTP     = "http"     // 纯 HTTP 接口上传（默认）
+	UploadMethodChromedp = "chromedp" // 浏览器自动化上传，打开浏览器窗口，可手动登录
+	UploadMethodAuto     = "auto"     // HTTP 上传，接口变化（404、响应无法解析）时改用无头浏览器重新上传该视频
+)
+
+// 账号选择策略
+const (
+	SelectRandom     = "random"      // 在可用账号中随机选择（默认）
+	SelectRoundRobin = "round_robin" // 按账号名顺序轮流使用（每个频道各自轮转）
+	SelectLeastUsed  = "least_used"  // 选择今日上传数最少的账号
+	SelectWeighted   = "weighted"    // 按 bilibili_accounts.<name>.weight 加权随机
+	SelectSticky     = "sticky"      // 同一频道始终使用上次选择的账号，不可用时按 fallback 重新选择
+)
+
+// AccountSelectionConfig 自动选择上传账号的策略；超过每日 / 每小时上限或处于失败冷却期的账号不参与选择
+type AccountSelectionConfig struct {
+	// Strategy 选择策略，默认 random
+	Strategy string `mapstructure:"strategy"`
+	// Fallback sticky 没有可沿用的账号时使用的策略，默认 least_used
+	Fallback string `mapstructure:"fallback"`
+	// Accounts 只从这些账号中选择（bilibili_accounts 中的名称），为空表示全部账号
+	Accounts []string `mapstructure:"accounts"`
+	// HourlyLimit 每个账号每小时最多上传数（账号可用 hourly_limit 覆盖），0 表示不限制
+	HourlyLimit int `mapstructure:"hourly_limit"`
+	// CooldownMinutes 账号上传失败后暂停选择的分钟数，0 表示不暂停
+	CooldownMinutes int `mapstructure:"cooldown_minutes"`
+}
+
+// Merge 用 override 中非零的字段覆盖当前配置
+func (a AccountSelectionConfig) Merge(override AccountSelectionConfig) AccountSelectionConfig {
+	if override.Strategy != "" {
+		a.Strategy = override.Strategy
+	}
+	if override.Fallback != "" {
+		a.Fallback = override.Fallback
+	}
+	if len(override.Accounts) > 0 {
+		a.Accounts = override.Accounts
+	}
+	if override.HourlyLimit > 0 {
+		a.HourlyLimit = override.HourlyLimit
+	}
+	if override.CooldownMinutes > 0 {
+		a.CooldownMinutes = override.CooldownMinutes
+	}
+	return a
+}
+
+// QuarantineConfig 按失败类别隔离账号的时长（分钟），0 表示该类失败不隔离；隔离期内账号不参与自动选择
+type QuarantineConfig struct {
+	AuthExpiredMinutes     int `mapstructure:"auth_expired_minutes"`
+	RateLimitedMinutes     int `mapstructure:"rate_limited_minutes"`
+	BannedMinutes          int `mapstructure:"banned_minutes"`
+	ContentRejectedMinutes int `mapstructure:"content_rejected_minutes"`
+}
+
+// Duration 返回该类失败（auth_expired / rate_limited / banned / content_rejected）的隔离时长
+func (q QuarantineConfig) Duration(kind string) time.Duration {
+	minutes := 0
+	switch kind {
+	case "auth_expired":
+		minutes = q.AuthExpiredMinutes
+	case "rate_limited":
+		minutes = q.RateLimitedMinutes
+	case "banned":
+		minutes = q.BannedMinutes
+	case "content_rejected":
+		minutes = q.ContentRejectedMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// ScheduleConfig 定时发布排期
+type ScheduleConfig struct {
+	// Enabled 开启后每个视频上传完成时不立即发布，而是预约账号下一个空闲的发布时段
+	Enabled bool `mapstructure:"enabled"`
+	// Timezone 发布时段所在时区（如 Asia/Bangkok），为空使用本地时区；账号可单独配置
+	Timezone string `mapstructure:"timezone"`
+	// Slots 每日发布时段（HH:MM），账号可单独配置 publish_slots
+	Slots []string `mapstructure:"slots"`
+	// PerSlot 每个账号每个时段最多发布的视频数，默认 1
+	PerSlot int `mapstructure:"per_slot"`
+	// MinLeadMinutes 发布时间距上传至少间隔的分钟数（B站要求定时发布时间晚于当前时间），默认 120
+	MinLeadMinutes int `mapstructure:"min_lead_minutes"`
+	// MaxDays 最多预约到多少天之后，超出时上传失败等待排期空出，默认 15
+	MaxDays int `mapstructure:"max_days"`
 }
 
 type YouTubeChannel struct {
@@ -44,12 +163,73 @@ type YouTubeChannel struct {
 	Offset int `mapstructure:"offset"`
 	// VideoIDs: 指定要处理的 video_id 列表（如果配置了，将只保留匹配的视频，忽略 limit 和 offset）
 	VideoIDs []string `mapstructure:"video_ids"`
+	// Metadata 该频道的投稿模板，未配置的字段使用 bilibili.metadata
+	Metadata MetadataTemplates `mapstructure:"metadata"`
+	// Playlist 该频道视频发布后加入的 bilibili.tv 播放列表，未配置时不加入
+	Playlist PlaylistConfig `mapstructure:"playlist"`
+	// AccountSelection 该频道的账号选择策略，未配置的字段使用 bilibili.account_selection
+	AccountSelection AccountSelectionConfig `mapstructure:"account_selection"`
+}
+
+// PlaylistConfig 频道对应的 bilibili.tv 播放列表。各账号优先使用 ids 中的播放列表，
+// 否则按标题查找，找不到且 auto_create 为 true 时自动创建
+type PlaylistConfig struct {
+	Title       string `mapstructure:"title"`
+	Description string `mapstructure:"description"` // 自动创建时使用的简介
+	// IDs 各账号下已有的播放列表 ID，key 为 bilibili_accounts 中的账号名
+	IDs        map[string]string `mapstructure:"ids"`
+	AutoCreate bool              `mapstructure:"auto_create"`
+}
+
+// Enabled 是否配置了播放列表
+func (p PlaylistConfig) Enabled() bool {
+	return p.Title != "" || len(p.IDs) > 0
+}
+
+// SanitizeConfig 投稿信息清洗规则（Unicode 归一化、控制字符与长度限制始终生效）
+type SanitizeConfig struct {
+	// BannedPhrases 从标题、简介与标签中去除的禁用词（不区分大小写）
+	BannedPhrases []string `mapstructure:"banned_phrases"`
+	// BannedDomains 从标题与简介中去除指向这些域名（含子域名）的链接
+	BannedDomains []string `mapstructure:"banned_domains"`
+	// StripLinks 去除简介中的所有链接，默认 false
+	StripLinks bool `mapstructure:"strip_links"`
+	// StripTitleEmoji 去除标题与标签中的 emoji，默认 true
+	StripTitleEmoji bool `mapstructure:"strip_title_emoji"`
+}
+
+// MetadataTemplates 投稿信息的 Go 模板（text/template），为空时使用默认值：
+// 标题为视频 ID，简介为 YouTube 描述，不设置标签。tags 渲染结果按逗号或换行拆分
+type MetadataTemplates struct {
+	Title       string `mapstructure:"title"`
+	Description string `mapstructure:"description"`
+	Tags        string `mapstructure:"tags"`
+}
+
+// Merge 用 override 中非空的字段覆盖当前模板
+func (m MetadataTemplates) Merge(override MetadataTemplates) MetadataTemplates {
+	if override.Title != "" {
+		m.Title = override.Title
+	}
+	if override.Description != "" {
+		m.Description = override.Description
+	}
+	if override.Tags != "" {
+		m.Tags = override.Tags
+	}
+	return m
 }
 
 type Account struct {
 	Username    string `mapstructure:"username"`
 	UserID      string `mapstructure:"userid"`       // B站用户ID
 	CookiesFile string `mapstructure:"cookies_file"` // 账号级别的 cookies 文件路径（优先于全局配置）
+	// 定时发布：该账号的时区与每日发布时段，为空时使用 bilibili.schedule
+	Timezone     string   `mapstructure:"timezone"`
+	PublishSlots []string `mapstructure:"publish_slots"`
+	// 账号选择：weighted 策略的权重（默认 1）与每小时上传上限（0 使用 account_selection.hourly_limit）
+	Weight      int `mapstructure:"weight"`
+	HourlyLimit int `mapstructure:"hourly_limit"`
 }
 
 type SubtitlesConfig struct {
@@ -62,9 +242,18 @@ type OutputConfig struct {
 	Directory string `mapstructure:"directory"`
 	// SubtitleArchive 字幕归档根目录（上传完成后将字幕复制到 {SubtitleArchive}/{aid}/ 下）
 	SubtitleArchive string `mapstructure:"subtitle_archive"`
+	// StateBackend 下载/上传状态与全局计数的存储后端：
+	// json（默认）：每个视频目录下的 download_status.json / upload_status.json 以及 .global/*.json
+	// bolt：单文件嵌入式数据库（事务 + 状态索引），首次启用时自动导入已有的 JSON 状态
+	StateBackend string `mapstructure:"state_backend"`
+	// StateFile bolt 后端的数据库文件路径，默认 {directory}/.global/state.db
+	StateFile string `mapstructure:"state_file"`
 }
 
 type YouTubeConfig struct {
+	// YtDlpPath yt-dlp 可执行文件路径，默认 "yt-dlp"（从 PATH 中查找）；
+	// 可指定固定版本的 yt-dlp，或离线测试用的替身程序
+	YtDlpPath string `mapstructure:"ytdlp_path"`
 	// CookiesFromBrowser 从浏览器导入 cookies，例如 "chrome", "firefox", "safari", "edge" 等
 	// 如果设置了此选项，会使用 --cookies-from-browser 参数
 	CookiesFromBrowser string `mapstructure:"cookies_from_browser"`
@@ -114,6 +303,16 @@ type YouTubeConfig struct {
 	BotDetectionRestDuration int `mapstructure:"bot_detection_rest_duration"`
 	// CleanupPartialFilesOnFailure 下载失败时是否清理部分下载的文件（.part, .ytdl 等），默认 false（不清理）
 	CleanupPartialFilesOnFailure bool `mapstructure:"cleanup_partial_files_on_failure"`
+	// DownloadWorkers 同时处理的视频数量（download / sync），默认 1（逐个处理）
+	// 所有 worker 共享 video_limit_before_rest 计数与 bot detection 休息窗口
+	DownloadWorkers int `mapstructure:"download_workers"`
+	// VideoDownloadConcurrency 同时进行的视频抓取（yt-dlp 下载视频 / 获取视频信息）数量上限，默认等于 download_workers
+	// limit_rate 为所有视频抓取的总限速，会均分到每个 yt-dlp 进程
+	VideoDownloadConcurrency int `mapstructure:"video_download_concurrency"`
+	// SubtitleDownloadConcurrency 同时进行的字幕抓取数量上限，默认等于 download_workers
+	SubtitleDownloadConcurrency int `mapstructure:"subtitle_download_concurrency"`
+	// ThumbnailDownloadConcurrency 同时进行的缩略图抓取数量上限，默认等于 download_workers
+	ThumbnailDownloadConcurrency int `mapstructure:"thumbnail_download_concurrency"`
 	// 运行期覆盖（命令行优先于配置），不从配置文件读取
 	LimitOverride  int `mapstructure:"-"`
 	OffsetOverride int `mapstructure:"-"`
@@ -151,6 +350,52 @@ type ChannelConfig struct {
 	GeneratePendingDownloads bool `mapstructure:"generate_pending_downloads"`
 }
 
+// PipelineConfig 控制 pipeline 模式（下载与上传解耦，通过持久化队列连接）
+type PipelineConfig struct {
+	// QueueDir 上传队列目录，默认 {output.directory}/.global/upload_queue
+	QueueDir string `mapstructure:"queue_dir"`
+	// UploadWorkers 同时上传的视频数量，默认 1
+	UploadWorkers int `mapstructure:"upload_workers"`
+	// MaxQueued 队列中待上传视频达到该数量时暂停下载，默认 20；0 表示不限制
+	MaxQueued int `mapstructure:"max_queued"`
+	// MinFreeDiskMB 下载目录所在磁盘剩余空间低于该值（MB）时暂停下载，默认 10240；0 表示不检查
+	MinFreeDiskMB int `mapstructure:"min_free_disk_mb"`
+	// PollIntervalSeconds 队列为空或触发背压时的轮询间隔（秒），默认 30
+	PollIntervalSeconds int `mapstructure:"poll_interval_seconds"`
+	// MaxAttempts 单个视频最多上传尝试次数，超过后移入 failed，默认 5
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// RetryDelayMinutes 上传失败后重新入队的基础退避时间（分钟），第 n 次失败等待 n*该值，默认 10
+	RetryDelayMinutes int `mapstructure:"retry_delay_minutes"`
+}
+
+// VerifyConfig 控制发布后的审核 / 转码状态检查（verify-uploads）
+type VerifyConfig struct {
+	// AutoRequeueRejected 审核退回或转码失败的视频自动重新排队上传（使用修改后的标题与封面），默认 false
+	AutoRequeueRejected bool `mapstructure:"auto_requeue_rejected"`
+	// MaxResubmissions 单个视频最多重新上传的次数，默认 2
+	MaxResubmissions int `mapstructure:"max_resubmissions"`
+	// RequestIntervalMs 相邻两次查询稿件状态的间隔（毫秒），默认 1000
+	RequestIntervalMs int `mapstructure:"request_interval_ms"`
+}
+
+// DaemonConfig 控制 daemon 模式的定时任务
+type DaemonConfig struct {
+	// StateFile 调度状态文件（下次运行时间、上次运行结果），默认 {output.directory}/.global/daemon_state.json
+	StateFile string `mapstructure:"state_file"`
+	// QuietHours 静默时段（本地时间，格式 HH:MM-HH:MM，可跨午夜），期间不启动新的任务
+	QuietHours []string `mapstructure:"quiet_hours"`
+	// Tasks 各任务的调度配置，任务名：channel、download、upload、fix_subtitles、organize、pipeline、verify_uploads
+	Tasks map[string]DaemonTask `mapstructure:"tasks"`
+}
+
+// DaemonTask 单个定时任务的配置
+type DaemonTask struct {
+	// Schedule cron 表达式（分 时 日 月 周），也支持 @every 30m、@daily 等；为空表示不运行该任务
+	Schedule string `mapstructure:"schedule"`
+	// IgnoreQuietHours 为 true 时静默时段内也照常运行
+	IgnoreQuietHours bool `mapstructure:"ignore_quiet_hours"`
+}
+
 var globalConfig *Config
 
 func Load(configPath string) (*Config, error) {
@@ -161,8 +406,21 @@ func Load(configPath string) (*Config, error) {
 	viper.SetDefault("bilibili.upload_subtitles", false)
 	viper.SetDefault("bilibili.chunk_upload_retries", 5)
 	viper.SetDefault("bilibili.chunk_retry_backoff_seconds", 1)
+	viper.SetDefault("bilibili.upload_session_ttl_hours", 24)
+	viper.SetDefault("bilibili.upload_concurrency", 1)
+	viper.SetDefault("bilibili.upload_retry_budget", 20)
+	viper.SetDefault("bilibili.sanitize.strip_title_emoji", true)
+	viper.SetDefault("bilibili.schedule.per_slot", 1)
+	viper.SetDefault("bilibili.schedule.min_lead_minutes", 120)
+	viper.SetDefault("bilibili.schedule.max_days", 15)
+	viper.SetDefault("bilibili.account_selection.strategy", SelectRandom)
+	viper.SetDefault("bilibili.account_selection.fallback", SelectLeastUsed)
+	viper.SetDefault("bilibili.quarantine.auth_expired_minutes", 24*60)
+	viper.SetDefault("bilibili.quarantine.rate_limited_minutes", 120)
+	viper.SetDefault("bilibili.quarantine.banned_minutes", 7*24*60)
 	viper.SetDefault("bilibili.delete_original_after_upload", true)
 	viper.SetDefault("subtitles.auto_fix_overlap", false)
+	viper.SetDefault("youtube.ytdlp_path", "yt-dlp")
 	viper.SetDefault("youtube.force_download_undownloadable", true)
 	viper.SetDefault("youtube.min_height", 1080)
 	viper.SetDefault("youtube.disable_android_fallback", true)
@@ -181,8 +439,21 @@ func Load(configPath string) (*Config, error) {
 	viper.SetDefault("youtube.video_limit_rest_duration", 60)    // 1小时 = 60分钟，实际休息时间会在此基础上随机增加 0-10%
 	viper.SetDefault("youtube.bot_detection_threshold", 3)       // 机器人检测累计3次后触发休息
 	viper.SetDefault("youtube.bot_detection_rest_duration", 360) // 6小时 = 360分钟，实际休息时间会在此基础上随机增加 0-10%
+	viper.SetDefault("youtube.download_workers", 1)
 	viper.SetDefault("output.directory", "./downloads")
 	viper.SetDefault("output.subtitle_archive", "./output")
+	viper.SetDefault("output.state_backend", "json")
+	viper.SetDefault("pipeline.upload_workers", 1)
+	viper.SetDefault("pipeline.max_queued", 20)
+	viper.SetDefault("pipeline.min_free_disk_mb", 10240)
+	viper.SetDefault("pipeline.poll_interval_seconds", 30)
+	viper.SetDefault("pipeline.max_attempts", 5)
+	viper.SetDefault("pipeline.retry_delay_minutes", 10)
+	viper.SetDefault("verify.max_resubmissions", 2)
+	viper.SetDefault("verify.request_interval_ms", 1000)
+	viper.SetDefault("daemon.tasks.channel.schedule", "0 */6 * * *")
+	viper.SetDefault("daemon.tasks.download.schedule", "@every 30m")
+	viper.SetDefault("daemon.tasks.upload.schedule", "@every 15m")
 
 	if configPath != "" {
 		viper.SetConfigFile(configPath)
@@ -221,12 +492,25 @@ func Load(configPath string) (*Config, error) {
 	if config.YouTube.BotDetectionRestDuration == 0 {
 		config.YouTube.BotDetectionRestDuration = 360 // 使用默认值
 	}
+	// subtitle_languages 的默认值不能用 viper.SetDefault：map 默认值会与配置合并，配置中无法去掉 en
+	// 未配置时默认只上传英语；配置了（包括空映射 {}）则只使用配置中的语言，ID 为 0 表示不上传该语言
+	if !viper.IsSet("bilibili.subtitle_languages") {
+		config.Bilibili.SubtitleLanguages = map[string]int{"en": 3}
+	}
+	for lang, id := range config.Bilibili.SubtitleLanguages {
+		if id == 0 {
+			delete(config.Bilibili.SubtitleLanguages, lang)
+		}
+	}
 	if config.YouTube.SleepIntervalSeconds == 0 {
 		config.YouTube.SleepIntervalSeconds = 30 // 使用默认值
 	}
 	if config.YouTube.ConcurrentFragments == 0 {
 		config.YouTube.ConcurrentFragments = 1 // 使用默认值
 	}
+	if config.YouTube.DownloadWorkers == 0 {
+		config.YouTube.DownloadWorkers = 1 // 使用默认值
+	}
 
 	if err := validate(&config); err != nil {
 		return nil, fmt.Errorf("配置验证失败: %w", err)
@@ -245,20 +529,102 @@ func validate(cfg *Config) error {
 		return fmt.Errorf("输出目录不能为空")
 	}
 
+	switch cfg.Output.StateBackend {
+	case "", "json", "bolt":
+	default:
+		return fmt.Errorf("不支持的状态存储后端: %s（可选: json, bolt）", cfg.Output.StateBackend)
+	}
+
+	if cfg.YouTube.DownloadWorkers < 0 || cfg.YouTube.VideoDownloadConcurrency < 0 ||
+		cfg.YouTube.SubtitleDownloadConcurrency < 0 || cfg.YouTube.ThumbnailDownloadConcurrency < 0 {
+		return fmt.Errorf("download_workers 与各资源并发数不能为负数")
+	}
+
+	if cfg.Pipeline.UploadWorkers < 0 || cfg.Pipeline.MaxQueued < 0 || cfg.Pipeline.MinFreeDiskMB < 0 ||
+		cfg.Pipeline.PollIntervalSeconds < 0 || cfg.Pipeline.MaxAttempts < 0 || cfg.Pipeline.RetryDelayMinutes < 0 {
+		return fmt.Errorf("pipeline 配置项不能为负数")
+	}
+
+	if cfg.Bilibili.UploadConcurrency < 0 || cfg.Bilibili.UploadRetryBudget < 0 {
+		return fmt.Errorf("bilibili.upload_concurrency 与 upload_retry_budget 不能为负数")
+	}
+
+	if cfg.Verify.MaxResubmissions < 0 || cfg.Verify.RequestIntervalMs < 0 {
+		return fmt.Errorf("verify 配置项不能为负数")
+	}
+
+	// pipeline 任务已包含下载与上传，与 download / upload 同时启用会有两个下载端与两条上传路径处理同一批视频目录
+	if cfg.Daemon.Tasks["pipeline"].Schedule != "" {
+		for _, name := range []string{"download", "upload"} {
+			if cfg.Daemon.Tasks[name].Schedule != "" {
+				return fmt.Errorf("daemon.tasks.pipeline 不能与 daemon.tasks.%s 同时启用，请将 daemon.tasks.%s.schedule 设为空", name, name)
+			}
+		}
+	}
+
+	for lang, id := range cfg.Bilibili.SubtitleLanguages {
+		if id < 0 {
+			return fmt.Errorf("bilibili.subtitle_languages 中 %s 的语言 ID 不能为负数（0 表示不上传该语言）", lang)
+		}
+	}
+
 	if cfg.Bilibili.BaseURL == "" {
 		return fmt.Errorf("B站基础URL不能为空")
 	}
 
+	switch cfg.Bilibili.UploadMethod {
+	case "", UploadMethodHTTP, UploadMethodChromedp, UploadMethodAuto:
+	default:
+		return fmt.Errorf("bilibili.upload_method 无效: %s（可选 http、chromedp、auto）", cfg.Bilibili.UploadMethod)
+	}
+
+	if _, err := metadata.Parse(cfg.Bilibili.Metadata.Title, cfg.Bilibili.Metadata.Description, cfg.Bilibili.Metadata.Tags); err != nil {
+		return fmt.Errorf("bilibili.metadata 无效: %w", err)
+	}
+
 	for _, channel := range cfg.YouTubeChannels {
 		if channel.URL == "" {
 			return fmt.Errorf("YouTube频道URL不能为空")
 		}
+		m := cfg.Bilibili.Metadata.Merge(channel.Metadata)
+		if _, err := metadata.Parse(m.Title, m.Description, m.Tags); err != nil {
+			return fmt.Errorf("频道 %s 的 metadata 无效: %w", channel.URL, err)
+		}
+		if channel.Playlist.AutoCreate && channel.Playlist.Title == "" {
+			return fmt.Errorf("频道 %s 的 playlist 开启了 auto_create，但未配置 title", channel.URL)
+		}
+		if err := validateAccountSelection(cfg.Bilibili.AccountSelection.Merge(channel.AccountSelection), cfg.BilibiliAccounts); err != nil {
+			return fmt.Errorf("频道 %s 的 account_selection 无效: %w", channel.URL, err)
+		}
+	}
+
+	if err := validateSchedule(cfg.Bilibili.Schedule.Timezone, cfg.Bilibili.Schedule.Slots); err != nil {
+		return fmt.Errorf("bilibili.schedule 无效: %w", err)
+	}
+	if s := cfg.Bilibili.Schedule; s.PerSlot < 0 || s.MinLeadMinutes < 0 || s.MaxDays < 0 {
+		return fmt.Errorf("bilibili.schedule 配置项不能为负数")
+	}
+
+	if err := validateAccountSelection(cfg.Bilibili.AccountSelection, cfg.BilibiliAccounts); err != nil {
+		return fmt.Errorf("bilibili.account_selection 无效: %w", err)
+	}
+	if q := cfg.Bilibili.Quarantine; q.AuthExpiredMinutes < 0 || q.RateLimitedMinutes < 0 || q.BannedMinutes < 0 || q.ContentRejectedMinutes < 0 {
+		return fmt.Errorf("bilibili.quarantine 的时长不能为负数")
 	}
 
 	for accountName, account := range cfg.BilibiliAccounts {
 		if account.Username == "" {
 			return fmt.Errorf("账号 %s 的用户名不能为空", accountName)
 		}
+		if account.Weight < 0 || account.HourlyLimit < 0 {
+			return fmt.Errorf("账号 %s 的 weight 与 hourly_limit 不能为负数", accountName)
+		}
+		if err := validateSchedule(account.Timezone, account.PublishSlots); err != nil {
+			return fmt.Errorf("账号 %s 的定时发布配置无效: %w", accountName, err)
+		}
+		if cfg.Bilibili.Schedule.Enabled && len(cfg.Bilibili.Schedule.Slots) == 0 && len(account.PublishSlots) == 0 {
+			return fmt.Errorf("已开启定时发布，账号 %s 未配置发布时段（bilibili.schedule.slots 或 publish_slots）", accountName)
+		}
 		// 必须配置 cookies 文件（账号级别或全局）
 		hasCookies := account.CookiesFile != "" || cfg.Bilibili.CookiesFile != ""
 		if !hasCookies {
@@ -272,3 +638,41 @@ func validate(cfg *Config) error {
 
 	return nil
 }
+
+// validateAccountSelection 校验选择策略名称、限额与账号名
+func validateAccountSelection(sel AccountSelectionConfig, accounts map[string]Account) error {
+	switch sel.Strategy {
+	case "", SelectRandom, SelectRoundRobin, SelectLeastUsed, SelectWeighted, SelectSticky:
+	default:
+		return fmt.Errorf("不支持的策略: %s（可选: random, round_robin, least_used, weighted, sticky）", sel.Strategy)
+	}
+	switch sel.Fallback {
+	case "", SelectRandom, SelectRoundRobin, SelectLeastUsed, SelectWeighted:
+	default:
+		return fmt.Errorf("不支持的 fallback 策略: %s（可选: random, round_robin, least_used, weighted）", sel.Fallback)
+	}
+	if sel.HourlyLimit < 0 || sel.CooldownMinutes < 0 {
+		return fmt.Errorf("hourly_limit 与 cooldown_minutes 不能为负数")
+	}
+	for _, name := range sel.Accounts {
+		if _, ok := accounts[name]; !ok {
+			return fmt.Errorf("accounts 中的账号 %s 不在 bilibili_accounts 中", name)
+		}
+	}
+	return nil
+}
+
+// validateSchedule 校验时区名称与 HH:MM 格式的发布时段
+func validateSchedule(timezone string, slots []string) error {
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return fmt.Errorf("未知时区 %s: %w", timezone, err)
+		}
+	}
+	for _, slot := range slots {
+		if _, err := time.Parse("15:04", slot); err != nil {
+			return fmt.Errorf("发布时段 %q 格式应为 HH:MM", slot)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package bilibili
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+)
+
+// GetArchiveStatus 查询稿件在创作中心的审核 / 转码状态（HTTP 实现）
+func (u *httpUploader) GetArchiveStatus(ctx context.Context, aid string, account config.Account) (*file.ReviewStatus, error) {
+	view, err := u.viewArchive(ctx, aid, account)
+	if err != nil {
+		return nil, err
+	}
+	if view.deleted {
+		return &file.ReviewStatus{State: file.ReviewDeleted, Code: view.code, Desc: view.message}, nil
+	}
+
+	review := parseArchiveStatus(view.data)
+	logger.Debug().
+		Str("aid", aid).
+		Int("state_code", review.Code).
+		Str("state", string(review.State)).
+		Str("desc", review.Desc).
+		Str("reject_reason", review.RejectReason).
+		Msg("稿件状态")
+	return review, nil
+}
+
+// ArchiveSubtitles 查询已发布稿件上已有字幕的语言 ID（HTTP 实现）
+func (u *httpUploader) ArchiveSubtitles(ctx context.Context, aid string, account config.Account) ([]int, error) {
+	view, err := u.viewArchive(ctx, aid, account)
+	if err != nil {
+		return nil, err
+	}
+	if view.deleted {
+		return nil, fmt.Errorf("稿件 %s 不存在: %s", aid, view.message)
+	}
+	return parseArchiveSubtitleLangs(view.data), nil
+}
+
+// archiveView 创作中心稿件信息接口的结果；deleted 为 true 时 code / message 为稿件不存在的原因
+type archiveView struct {
+	data    map[string]any
+	deleted bool
+	code    int
+	message string
+}
+
+// viewArchive 请求创作中心的稿件信息（archive/view）
+func (u *httpUploader) viewArchive(ctx context.Context, aid string, account config.Account) (*archiveView, error) {
+	u = u.forJob()
+	if err := u.loadAccountCookies(account); err != nil {
+		return nil, err
+	}
+
+	apiURL := u.buildAPIURL("/intl/videoup/web2/archive/view?aid=" + url.QueryEscape(aid))
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建稿件状态请求失败: %w", err)
+	}
+	u.setCookies(req)
+	u.setHeaders(req)
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("查询稿件状态失败: %w", err)
+	}
+	defer resp.Body.Close()
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取稿件状态响应失败: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return &archiveView{deleted: true, message: "稿件不存在"}, nil
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("查询稿件状态失败: HTTP %d, 响应: %s", resp.StatusCode, previewForLog(string(bodyBytes), 300))
+	}
+
+	var result struct {
+		Code    int            `json:"code"`
+		Message string         `json:"message"`
+		Data    map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return nil, fmt.Errorf("解析稿件状态响应失败: %w, 响应: %s", err, previewForLog(string(bodyBytes), 300))
+	}
+	// -404 / 10003：稿件不存在
+	if result.Code == -404 || result.Code == 10003 {
+		return &archiveView{deleted: true, code: result.Code, message: result.Message}, nil
+	}
+	if result.Code != 0 {
+		return nil, fmt.Errorf("查询稿件状态失败: code=%d, message=%s", result.Code, result.Message)
+	}
+	return &archiveView{data: result.Data}, nil
+}
+
+// parseArchiveSubtitleLangs 从稿件信息的 subtitles 列表（位于 data 或 data.archive 下）中提取字幕语言 ID
+func parseArchiveSubtitleLangs(data map[string]any) []int {
+	list, ok := data["subtitles"].([]any)
+	if !ok {
+		if archive, isMap := data["archive"].(map[string]any); isMap {
+			list, _ = archive["subtitles"].([]any)
+		}
+	}
+	var ids []int
+	for _, item := range list {
+		sub, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, k := range []string{"lang_id", "subtitle_lang_id"} {
+			var id int
+			switch v := sub[k].(type) {
+			case float64:
+				id = int(v)
+			case string:
+				id, _ = strconv.Atoi(v)
+			}
+			if id > 0 {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// parseArchiveStatus 从创作中心返回的稿件信息中提取状态码、描述与退回原因（字段可能位于 data 或 data.archive 下）
+func parseArchiveStatus(data map[string]any) *file.ReviewStatus {
+	if archive, ok := data["archive"].(map[string]any); ok {
+		data = archive
+	}
+	extractStr := func(keys ...string) string {
+		for _, k := range keys {
+			if s, ok := data[k].(string); ok && s != "" {
+				return s
+			}
+		}
+		return ""
+	}
+	code, found := 0, false
+	for _, k := range []string{"state", "status"} {
+		if v, ok := data[k].(float64); ok {
+			code, found = int(v), true
+			break
+		}
+		if v, ok := data[k].(string); ok {
+			if n, err := strconv.Atoi(v); err == nil {
+				code, found = n, true
+				break
+			}
+		}
+	}
+	state := classifyArchiveState(code)
+	if !found {
+		state = file.ReviewUnknown
+	}
+	return &file.ReviewStatus{
+		State:        state,
+		Code:         code,
+		Desc:         extractStr("state_desc", "status_desc", "state_name"),
+		RejectReason: extractStr("reject_reason", "reason", "audit_reason", "xcode_fail_msg"),
+	}
+}
+
+// classifyArchiveState 按 B站稿件状态码归类：>=0 开放浏览，-2/-3/-4 退回或锁定，-9 转码中，-16 转码失败，-100 删除，
+// -1/-6/-7/-10/-30/-40 审核中（含定时发布），其余记为 unknown
+func classifyArchiveState(code int) file.ReviewState {
+	switch {
+	case code >= 0:
+		return file.ReviewPublished
+	case code == -2 || code == -3 || code == -4:
+		return file.ReviewRejected
+	case code == -9:
+		return file.ReviewTranscoding
+	case code == -16:
+		return file.ReviewTranscodeFailed
+	case code == -100:
+		return file.ReviewDeleted
+	case code == -1 || code == -6 || code == -7 || code == -10 || code == -30 || code == -40:
+		return file.ReviewReviewing
+	}
+	return file.ReviewUnknown
+}
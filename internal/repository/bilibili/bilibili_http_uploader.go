@@ -6,6 +6,7 @@ import (
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -14,31 +15,38 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
 	"blueberry/pkg/logger"
 	"blueberry/pkg/subtitle"
+	"blueberry/pkg/utils"
 )
 
 // httpUploader 基于 HTTP 请求的 B站上传器实现
+// cookies 与 csrfToken 属于单个账号：每次上传或账号操作都在 forJob 返回的副本上加载，
+// 多个 worker 共用同一个上传器并发使用不同账号时互不覆盖
 type httpUploader struct {
+	fileRepo           file.Repository // 持久化分块上传会话；为 nil 时不续传
 	baseURL            string
 	cookiesFromBrowser string
 	cookiesFile        string
 	httpClient         *http.Client
 	cookies            []Cookie
 	csrfToken          string
-	// Derived from preupload
-	uposEndpointHost string
-	uposChunkSize    int64
-	uposPutQuery     string
 }
 
 // NewHTTPUploader 创建基于 HTTP 的上传器
-func NewHTTPUploader(baseURL, cookiesFromBrowser, cookiesFile string) Uploader {
+func NewHTTPUploader(fileRepo file.Repository, baseURL, cookiesFromBrowser, cookiesFile string) Uploader {
+	return newHTTPUploader(fileRepo, baseURL, cookiesFromBrowser, cookiesFile)
+}
+
+func newHTTPUploader(fileRepo file.Repository, baseURL, cookiesFromBrowser, cookiesFile string) *httpUploader {
 	return &httpUploader{
+		fileRepo:           fileRepo,
 		baseURL:            baseURL,
 		cookiesFromBrowser: cookiesFromBrowser,
 		cookiesFile:        cookiesFile,
@@ -55,188 +63,188 @@ func NewHTTPUploader(baseURL, cookiesFromBrowser, cookiesFile string) Uploader {
 	}
 }
 
-// UploadVideo 上传视频（HTTP 实现）
-func (u *httpUploader) UploadVideo(ctx context.Context, videoPath, videoTitle, videoDesc string, subtitlePaths []string, account config.Account) (*UploadResult, error) {
-	result := &UploadResult{}
-
-	// 确定使用的 cookies 配置（优先账号级别，否则全局）
-	cookiesFile := account.CookiesFile
-	if cookiesFile == "" {
-		cookiesFile = u.cookiesFile
-	}
-
-	// 加载 cookies
-	if err := u.loadCookies(cookiesFile); err != nil {
-		return nil, fmt.Errorf("加载 cookies 失败: %w", err)
-	}
-
-	// 提取 CSRF token
-	if err := u.extractCSRFToken(); err != nil {
-		return nil, fmt.Errorf("提取 CSRF token 失败: %w", err)
+// forJob 返回共享配置与 HTTP 客户端、但尚未加载账号的上传器副本
+func (u *httpUploader) forJob() *httpUploader {
+	return &httpUploader{
+		fileRepo:           u.fileRepo,
+		baseURL:            u.baseURL,
+		cookiesFromBrowser: u.cookiesFromBrowser,
+		cookiesFile:        u.cookiesFile,
+		httpClient:         u.httpClient,
 	}
+}
 
-	// 先访问上传页面，确保会话有效并获取上传权限
-	// 这一步很重要，因为 B站可能需要先访问页面才能获得上传权限
-	if err := u.visitUploadPage(ctx); err != nil {
-		logger.Warn().Err(err).Msg("访问上传页面失败，继续尝试上传")
-		// 不返回错误，继续尝试上传
+// UploadVideo 上传视频（HTTP 实现）
+func (u *httpUploader) UploadVideo(ctx context.Context, videoPath string, meta VideoMeta, subtitlePaths []string, account config.Account) (*UploadResult, error) {
+	result, err := runUploadSteps(ctx, "http", u.forJob(), videoPath, meta, subtitlePaths, account)
+	if err != nil {
+		return nil, err
 	}
+	logger.Info().
+		Str("bilibili_aid", result.AID).
+		Str("title", meta.Title).
+		Msg("视频发布成功，上传流程完成")
+	return result, nil
+}
 
-	// 0. 清理路径中的转义字符（处理 shell 转义）
-	videoPath = u.cleanPath(videoPath)
-	for i := range subtitlePaths {
-		subtitlePaths[i] = u.cleanPath(subtitlePaths[i])
-	}
+func (u *httpUploader) stepPrepare(ctx context.Context, job *uploadJob) error {
+	return u.prepareAccount(ctx, job.account)
+}
 
-	// 1. 在开始上传前检查所需文件是否存在
-	videoDir := filepath.Dir(videoPath)
-	actualVideoPath, err := u.validateRequiredFiles(videoPath, subtitlePaths, videoDir)
+// stepCover 先上传封面图；封面失败则直接跳过该视频
+func (u *httpUploader) stepCover(ctx context.Context, job *uploadJob) error {
+	coverURL, err := u.uploadCover(ctx, job.coverPath, job.videoPath)
 	if err != nil {
-		return nil, fmt.Errorf("文件检查失败: %w", err)
-	}
-
-	// 1.1 先上传封面图；封面失败则直接跳过该视频
-	var coverURL string
-	{
-		// 查找封面文件路径（优先：与视频同名的 .jpg → cover.{jpg|jpeg|png|webp|gif} → thumbnail.jpg）
-		dir := filepath.Dir(videoPath)
-		coverPath := ""
-		// 1) 与视频同名的 jpg（来自 yt-dlp --convert-thumbnails jpg）
-		base := strings.TrimSuffix(actualVideoPath, filepath.Ext(actualVideoPath))
-		candidate := base + ".jpg"
-		if _, statErr := os.Stat(candidate); statErr == nil {
-			coverPath = candidate
-			logger.Debug().Str("path", candidate).Msg("使用与视频同名的 JPG 缩略图作为封面图（优先）")
-		}
-		// 2) cover.{jpg|jpeg|png|webp|gif}
-		if coverPath == "" {
-			for _, ext := range []string{".jpg", ".jpeg", ".png", ".webp", ".gif"} {
-				p := filepath.Join(dir, "cover"+ext)
-				if _, statErr := os.Stat(p); statErr == nil {
-					coverPath = p
-					break
-				}
-			}
-		}
-		// 3) thumbnail.jpg
-		if coverPath == "" {
-			thumb := filepath.Join(dir, "thumbnail.jpg")
-			if _, statErr := os.Stat(thumb); statErr == nil {
-				coverPath = thumb
-				logger.Debug().Str("path", thumb).Msg("使用 thumbnail.jpg 作为封面图（回退）")
-			}
-		}
-		// 4) 如果还是找不到，使用默认封面图（assets/default_cover.jpg）
-		if coverPath == "" {
-			defaultCoverPath := u.findDefaultCoverPath(dir)
-			if defaultCoverPath != "" {
-				coverPath = defaultCoverPath
-				logger.Info().Str("path", coverPath).Msg("使用默认封面图（assets/default_cover.jpg）")
-			} else {
-				return nil, fmt.Errorf("未找到封面图文件（需要与视频同名的 .jpg，或 cover.{jpg|jpeg|png|webp|gif}，或 thumbnail.jpg，或 assets/default_cover.jpg）")
-			}
-		}
-		coverURL, err = u.uploadCover(ctx, coverPath, actualVideoPath)
-		if err != nil {
-			// 明确日志并跳过该视频
-			logger.Error().Err(err).Str("video_path", actualVideoPath).Msg("封面图上传失败，跳过该视频")
-			return nil, fmt.Errorf("封面图上传失败，跳过该视频: %w", err)
-		}
-		logger.Info().Str("cover_url", coverURL).Msg("封面图上传完成（优先）")
+		return err
 	}
+	job.coverURL = coverURL
+	logger.Info().Str("cover_url", coverURL).Msg("封面图上传完成（优先）")
+	return nil
+}
 
-	// 2. 先上传字幕（如失败仅警告继续）
-	var subtitleURL string
-	if len(subtitlePaths) > 0 {
-		const maxSubtitleRetries = 3
-		var lastSubErr error
-		for attempt := 1; attempt <= maxSubtitleRetries; attempt++ {
-			subtitleURL, lastSubErr = u.uploadSubtitles(ctx, subtitlePaths)
-			if lastSubErr == nil {
-				logger.Info().Int("attempt", attempt).Str("subtitle_url", subtitleURL).Msg("字幕上传完成")
-				break
-			}
-			logger.Warn().
-				Int("attempt", attempt).
-				Int("max_retries", maxSubtitleRetries).
-				Err(lastSubErr).
-				Strs("subtitle_paths", subtitlePaths).
-				Msg("上传字幕失败，将重试")
-			time.Sleep(time.Duration(attempt) * time.Second)
-		}
-		if lastSubErr != nil {
-			logger.Warn().
-				Err(lastSubErr).
-				Strs("subtitle_paths", subtitlePaths).
-				Msg("上传字幕失败，已用尽重试。继续上传其他内容（视频和封面图）。如需字幕，请手动检查并修复后重新上传")
+// stepSubtitles 按语言逐个上传字幕（单个语言失败仅警告继续）
+func (u *httpUploader) stepSubtitles(ctx context.Context, job *uploadJob) error {
+	subtitles, skipped := planSubtitles(config.Get(), job.subtitlePaths)
+	job.subtitles = u.uploadSubtitleFiles(ctx, subtitles)
+	job.skippedSubtitles = skipped
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	// 第一个上传成功的字幕随发布请求提交（优先英语），其余语言发布后追加
+	for i := range job.subtitles {
+		if job.subtitles[i].Err == nil {
+			job.primarySubtitle = &job.subtitles[i]
+			break
 		}
 	}
+	return nil
+}
 
-	// 3. 上传视频（使用实际找到的文件路径）
-	filename, err := u.uploadVideo(ctx, actualVideoPath)
+func (u *httpUploader) stepVideo(ctx context.Context, job *uploadJob) error {
+	filename, err := u.uploadVideo(ctx, job.videoPath, u.sessionAccount(job.account))
 	if err != nil {
-		return nil, fmt.Errorf("上传视频失败: %w", err)
+		return err
 	}
 	logger.Info().Str("filename", filename).Msg("视频上传完成")
 
-	// 5. 发布视频
 	// 注意：发布时 filename 不应该包含视频文件后缀（.mp4, .mkv 等）
-	publishFilename := filename
-	// 移除常见的视频文件后缀
+	job.filename = filename
 	videoExts := []string{".mp4", ".mkv", ".avi", ".mov", ".flv", ".webm", ".m4v", ".3gp"}
 	for _, ext := range videoExts {
-		if strings.HasSuffix(publishFilename, ext) {
-			publishFilename = strings.TrimSuffix(publishFilename, ext)
+		if strings.HasSuffix(filename, ext) {
+			job.filename = strings.TrimSuffix(filename, ext)
 			logger.Debug().
 				Str("original", filename).
-				Str("publish", publishFilename).
+				Str("publish", job.filename).
 				Str("removed_ext", ext).
 				Msg("移除视频文件后缀用于发布")
 			break
 		}
 	}
-	aid, err := u.publishVideo(ctx, publishFilename, videoTitle, coverURL, subtitleURL, videoDesc)
+	return nil
+}
+
+// stepPublish 发布稿件，随后追加其余语言的字幕
+func (u *httpUploader) stepPublish(ctx context.Context, job *uploadJob) error {
+	aid, err := u.publishVideo(ctx, job.filename, job.coverURL, job.primarySubtitle, job.meta)
 	if err != nil {
-		return nil, fmt.Errorf("发布视频失败: %w", err)
+		return err
 	}
+	job.aid = aid
+	job.playlistID = job.meta.PlaylistID
 
-	result.Success = true
-	result.AID = aid
-	result.VideoID = aid
+	for i := range job.subtitles {
+		sub := &job.subtitles[i]
+		if sub.Err != nil || sub == job.primarySubtitle {
+			continue
+		}
+		if err := u.addSubtitle(ctx, aid, sub); err != nil {
+			sub.Err = err
+			logger.Warn().Err(err).Str("aid", aid).Str("lang", sub.Lang).Msg("追加字幕失败，可稍后用 subtitle backfill 补传")
+		}
+	}
+	return nil
+}
 
-	logger.Info().
-		Str("bilibili_aid", aid).
-		Str("filename", filename).
-		Str("title", videoTitle).
-		Msg("视频发布成功，上传流程完成")
+// AddSubtitles 为已发布的稿件补传字幕（HTTP 实现）
+func (u *httpUploader) AddSubtitles(ctx context.Context, aid string, subtitlePaths []string, account config.Account) ([]SubtitleResult, error) {
+	u = u.forJob()
+	if err := u.prepareAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	for i := range subtitlePaths {
+		subtitlePaths[i] = cleanPath(subtitlePaths[i])
+	}
 
-	return result, nil
+	subtitles, skipped := planSubtitles(config.Get(), subtitlePaths)
+	results := u.uploadSubtitleFiles(ctx, subtitles)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+		if err := u.addSubtitle(ctx, aid, &results[i]); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			results[i].Err = err
+			logger.Warn().Err(err).Str("aid", aid).Str("lang", results[i].Lang).Msg("追加字幕失败")
+		}
+	}
+	return append(skipped, results...), nil
+}
+
+// prepareAccount 加载账号 cookies、提取 CSRF token 并访问上传页面
+func (u *httpUploader) prepareAccount(ctx context.Context, account config.Account) error {
+	if err := u.loadAccountCookies(account); err != nil {
+		return err
+	}
+
+	// 先访问上传页面，确保会话有效并获取上传权限
+	// 这一步很重要，因为 B站可能需要先访问页面才能获得上传权限
+	if err := u.visitUploadPage(ctx); err != nil {
+		logger.Warn().Err(err).Msg("访问上传页面失败，继续尝试上传")
+		// 不返回错误，继续尝试上传
+	}
+	return nil
+}
+
+// loadAccountCookies 加载账号的 cookies（优先账号级别，否则全局）并提取 CSRF token
+func (u *httpUploader) loadAccountCookies(account config.Account) error {
+	cookiesFile := account.CookiesFile
+	if cookiesFile == "" {
+		cookiesFile = u.cookiesFile
+	}
+	if err := u.loadCookies(cookiesFile); err != nil {
+		return fmt.Errorf("加载 cookies 失败: %w", err)
+	}
+	if err := u.extractCSRFToken(); err != nil {
+		return fmt.Errorf("提取 CSRF token 失败: %w", err)
+	}
+	return nil
 }
 
 // CheckLoginStatus 检查登录状态（HTTP 实现）
 func (u *httpUploader) CheckLoginStatus(ctx context.Context) (bool, error) {
+	u = u.forJob()
 	// 加载 cookies
 	if err := u.loadCookies(u.cookiesFile); err != nil {
 		return false, err
 	}
-
-	// 尝试访问需要登录的页面
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/archive/new", u.baseURL), nil)
-	if err != nil {
+	if err := u.extractCSRFToken(); err != nil {
 		return false, err
 	}
 
-	u.setCookies(req)
-	u.setHeaders(req)
-
-	resp, err := u.httpClient.Do(req)
-	if err != nil {
+	// 请求需要登录的稿件列表接口：code=-101 表示 cookies 已失效
+	if _, _, err := u.fetchArchivePage(ctx, 1); err != nil {
+		if errors.Is(err, ErrNotLoggedIn) {
+			return false, nil
+		}
 		return false, err
 	}
-	defer resp.Body.Close()
-
-	// 如果返回 200 且能访问上传页面，说明已登录
-	return resp.StatusCode == 200, nil
+	return true, nil
 }
 
 // visitUploadPage 访问上传页面，确保会话有效并获取上传权限
@@ -404,19 +412,167 @@ func (u *httpUploader) buildAPIURL(endpoint string) string {
 	return parsedURL.String()
 }
 
-// uploadVideo 上传视频（分块上传）
-func (u *httpUploader) uploadVideo(ctx context.Context, videoPath string) (string, error) {
-	file, err := os.Open(videoPath)
+// errUploadSessionExpired 续传时服务端不再认可 upload_id（过期或已被清理）
+var errUploadSessionExpired = errors.New("上传会话已失效")
+
+// uploadVideo 以 account 账号上传视频（分块上传）
+// 上传会话（账号、upos 主机、upload_id、auth、已完成的分块）持久化在视频的上传状态中，
+// 中断后同一账号重新上传时校验会话并从第一个缺失的分块继续；会话失效或换了账号则重新开始
+func (u *httpUploader) uploadVideo(ctx context.Context, videoPath, account string) (string, error) {
+	videoFile, err := os.Open(videoPath)
 	if err != nil {
 		return "", fmt.Errorf("打开视频文件失败: %w", err)
 	}
-	defer file.Close()
+	defer videoFile.Close()
 
-	fileInfo, err := file.Stat()
+	fileInfo, err := videoFile.Stat()
 	if err != nil {
 		return "", fmt.Errorf("获取文件信息失败: %w", err)
 	}
+	videoDir := filepath.Dir(videoPath)
+
+	session := u.resumeUploadSession(videoDir, videoPath, account, fileInfo)
+	resumed := session != nil
+	if session == nil {
+		if session, err = u.initUploadSession(ctx, videoPath, account, fileInfo); err != nil {
+			return "", err
+		}
+		u.saveUploadSession(videoDir, session)
+	}
+
+	if !session.Uploaded {
+		err = u.uploadChunks(ctx, videoFile, videoDir, session)
+		if errors.Is(err, errUploadSessionExpired) && resumed {
+			logger.Warn().
+				Str("upload_id", session.UploadID).
+				Int("completed_parts", len(session.Parts)).
+				Msg("上传会话已失效，重新开始上传")
+			u.clearUploadSession(videoDir)
+			if session, err = u.initUploadSession(ctx, videoPath, account, fileInfo); err != nil {
+				return "", err
+			}
+			u.saveUploadSession(videoDir, session)
+			err = u.uploadChunks(ctx, videoFile, videoDir, session)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		u.finalizeUpload(ctx, videoPath, session)
+		if err := u.completeUpload(ctx, session.Filename); err != nil {
+			return "", err
+		}
+		session.Uploaded = true
+		u.saveUploadSession(videoDir, session)
+	} else {
+		logger.Info().
+			Str("filename", session.Filename).
+			Str("upload_id", session.UploadID).
+			Msg("视频分块已在上次运行中上传完成，跳过上传直接发布")
+	}
+
+	logger.Info().Str("filename", session.Filename).Msg("视频上传完成")
+	return session.Filename, nil
+}
+
+// sessionAccount 上传会话所属账号的标识：username，未配置时为实际使用的 cookies 文件
+func (u *httpUploader) sessionAccount(account config.Account) string {
+	switch {
+	case account.Username != "":
+		return account.Username
+	case account.CookiesFile != "":
+		return account.CookiesFile
+	default:
+		return u.cookiesFile
+	}
+}
 
+// resumeUploadSession 读取并校验上次未完成的上传会话：同一账号创建、文件指纹一致且未超过有效期才可续传
+// upload_id 与服务端文件名属于创建会话的账号，换账号续传会用新账号的 cookies 发布旧账号上传的文件
+func (u *httpUploader) resumeUploadSession(videoDir, videoPath, account string, fileInfo os.FileInfo) *file.UploadSession {
+	if u.fileRepo == nil {
+		return nil
+	}
+	session, err := u.fileRepo.LoadUploadSession(videoDir)
+	if err != nil {
+		logger.Warn().Err(err).Str("video_dir", videoDir).Msg("读取上传会话失败，重新开始上传")
+		return nil
+	}
+	if session == nil {
+		return nil
+	}
+
+	reason := ""
+	ttl := uploadSessionTTL()
+	switch {
+	case ttl <= 0:
+		reason = "未启用续传（bilibili.upload_session_ttl_hours<=0）"
+	case session.Account != account:
+		reason = fmt.Sprintf("会话由其他账号创建（%s）", session.Account)
+	case session.VideoPath != videoPath:
+		reason = "视频文件路径已变化"
+	case session.FileSize != fileInfo.Size() || session.FileModTime != fileInfo.ModTime().Unix():
+		reason = "视频文件大小或修改时间已变化"
+	case session.UploadID == "" || session.Endpoint == "" || session.Filename == "" || session.ChunkSize <= 0:
+		reason = "会话信息不完整"
+	case session.Chunks != int((session.FileSize+session.ChunkSize-1)/session.ChunkSize):
+		reason = "分块数量与文件大小不一致"
+	case time.Since(time.Unix(session.CreatedAt, 0)) > ttl:
+		reason = "会话已超过有效期"
+	}
+	if reason != "" {
+		logger.Info().
+			Str("video_dir", videoDir).
+			Str("upload_id", session.UploadID).
+			Str("account", account).
+			Str("reason", reason).
+			Msg("丢弃上次的上传会话，重新开始上传")
+		u.clearUploadSession(videoDir)
+		return nil
+	}
+
+	// 续传沿用会话中的上传参数（endpoint、chunk_size 与 finalize 需要的 put_query 都保存在会话中）
+	logger.Info().
+		Str("video_dir", videoDir).
+		Str("upload_id", session.UploadID).
+		Int("completed_parts", len(session.Parts)).
+		Int("chunks", session.Chunks).
+		Bool("uploaded", session.Uploaded).
+		Msg("发现未完成的上传会话，继续上传")
+	return session
+}
+
+// uploadSessionTTL 上传会话有效期（bilibili.upload_session_ttl_hours，默认 24 小时）
+func uploadSessionTTL() time.Duration {
+	hours := 24
+	if cfg := config.Get(); cfg != nil {
+		hours = cfg.Bilibili.UploadSessionTTLHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// saveUploadSession 持久化上传会话；失败只影响续传，不中断上传
+func (u *httpUploader) saveUploadSession(videoDir string, session *file.UploadSession) {
+	if u.fileRepo == nil {
+		return
+	}
+	if err := u.fileRepo.SaveUploadSession(videoDir, session); err != nil {
+		logger.Warn().Err(err).Str("video_dir", videoDir).Msg("保存上传会话失败（中断后将无法续传）")
+	}
+}
+
+// clearUploadSession 丢弃已失效的上传会话
+func (u *httpUploader) clearUploadSession(videoDir string) {
+	if u.fileRepo == nil {
+		return
+	}
+	if err := u.fileRepo.ClearUploadSession(videoDir); err != nil {
+		logger.Warn().Err(err).Str("video_dir", videoDir).Msg("清除上传会话失败")
+	}
+}
+
+// initUploadSession 调用 preupload 与初始化上传接口，创建新的上传会话
+func (u *httpUploader) initUploadSession(ctx context.Context, videoPath, account string, fileInfo os.FileInfo) (*file.UploadSession, error) {
 	fileSize := fileInfo.Size()
 	filename := generateFilename(filepath.Base(videoPath))
 
@@ -426,23 +582,28 @@ func (u *httpUploader) uploadVideo(ctx context.Context, videoPath string) (strin
 		Msg("开始上传视频")
 
 	// 0. 调用 preupload API 获取上传配置和认证信息
-	uposAuth, actualFilename, err := u.getUposAuth(ctx, filename, fileSize)
+	pre, err := u.getUposAuth(ctx, filename, fileSize)
+	if kind := ClassifyError(err); err != nil && (kind.AccountFailure() || kind == FailureAPIChanged) {
+		// 账号未登录、被限流、封禁或 preupload 接口已变化时继续上传也会失败
+		return nil, fmt.Errorf("获取上传认证信息失败: %w", err)
+	}
 	if err != nil {
 		logger.Warn().Err(err).Msg("获取上传认证信息失败，尝试不使用 X-Upos-Auth")
 		// 不返回错误，继续尝试上传，使用原始文件名
-		actualFilename = filename
-	} else if actualFilename != "" {
+		pre = &preuploadResult{}
+	} else if pre.filename != "" {
 		// 使用 preupload 返回的实际文件名
-		filename = actualFilename
+		filename = pre.filename
 		logger.Debug().Str("actual_filename", filename).Msg("使用 preupload 返回的文件名")
 	}
+	uposAuth := pre.auth
 
 	// 选择上传主机与分块大小（来自 preupload 返回的 endpoint 与 chunk_size）
-	baseUposHost := u.uposEndpointHost
+	baseUposHost := pre.endpoint
 	if baseUposHost == "" {
 		baseUposHost = "https://upos-cs-upcdntxa.bilivideo.com"
 	}
-	chunkSize := u.uposChunkSize
+	chunkSize := pre.chunkSize
 	if chunkSize <= 0 {
 		// 默认使用 22,020,096 字节（与 preupload 常见返回一致）
 		chunkSize = 22020096
@@ -458,7 +619,7 @@ func (u *httpUploader) uploadVideo(ctx context.Context, videoPath string) (strin
 	uploadURL := fmt.Sprintf("%s/iupever/%s?uploads&output=json", baseUposHost, filename)
 	req, err := http.NewRequestWithContext(ctx, "POST", uploadURL, nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// 设置 cookies 和 headers（初始化上传也需要认证）
@@ -494,14 +655,14 @@ func (u *httpUploader) uploadVideo(ctx context.Context, videoPath string) (strin
 
 	resp, err := u.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("初始化上传失败: %w", err)
+		return nil, fmt.Errorf("初始化上传失败: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// 读取响应体以便调试
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("读取初始化响应失败: %w", err)
+		return nil, fmt.Errorf("读取初始化响应失败: %w", err)
 	}
 
 	// 检查状态码
@@ -510,7 +671,7 @@ func (u *httpUploader) uploadVideo(ctx context.Context, videoPath string) (strin
 			Int("status_code", resp.StatusCode).
 			Str("response", string(bodyBytes)).
 			Msg("初始化上传返回非200状态码")
-		return "", fmt.Errorf("初始化上传失败: HTTP %d, 响应: %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("初始化上传失败: HTTP %d, 响应: %s", resp.StatusCode, string(bodyBytes))
 	}
 
 	// 解析响应
@@ -521,7 +682,7 @@ func (u *httpUploader) uploadVideo(ctx context.Context, videoPath string) (strin
 			Err(err).
 			Str("response", string(bodyBytes)).
 			Msg("解析初始化响应失败")
-		return "", fmt.Errorf("解析初始化响应失败: %w, 响应: %s", err, string(bodyBytes))
+		return nil, fmt.Errorf("解析初始化响应失败: %w, 响应: %s", err, string(bodyBytes))
 	}
 
 	// 检查 OK 字段
@@ -529,7 +690,7 @@ func (u *httpUploader) uploadVideo(ctx context.Context, videoPath string) (strin
 		logger.Error().
 			Str("response", string(bodyBytes)).
 			Msg("初始化上传返回 OK != 1")
-		return "", fmt.Errorf("初始化上传失败: OK=%v, 响应: %s", ok, string(bodyBytes))
+		return nil, fmt.Errorf("初始化上传失败: OK=%v, 响应: %s", ok, string(bodyBytes))
 	}
 
 	// 获取 upload_id（注意：是下划线，不是驼峰）
@@ -545,7 +706,7 @@ func (u *httpUploader) uploadVideo(ctx context.Context, videoPath string) (strin
 		logger.Error().
 			Str("response", string(bodyBytes)).
 			Msg("初始化响应中 upload_id 为空")
-		return "", fmt.Errorf("初始化响应中 upload_id 为空，响应: %s", string(bodyBytes))
+		return nil, fmt.Errorf("初始化响应中 upload_id 为空，响应: %s", string(bodyBytes))
 	}
 
 	// 可选字段：bucket/key（若返回则记录，便于追踪）
@@ -564,161 +725,92 @@ func (u *httpUploader) uploadVideo(ctx context.Context, videoPath string) (strin
 		Str("key", key).
 		Msg("已初始化上传")
 
-	// 2. 分块上传
-	chunks := int((fileSize + chunkSize - 1) / chunkSize)
-
-	for chunk := 0; chunk < chunks; chunk++ {
-		start := int64(chunk) * chunkSize
-		end := start + chunkSize
-		if end > fileSize {
-			end = fileSize
-		}
-
-		partNumber := chunk + 1
-		chunkData := make([]byte, end-start)
-		if _, err := file.ReadAt(chunkData, start); err != nil {
-			return "", fmt.Errorf("读取分块 %d 失败: %w", partNumber, err)
-		}
-
-		chunkURL := fmt.Sprintf("%s/iupever/%s?partNumber=%d&uploadId=%s&chunk=%d&chunks=%d&size=%d&start=%d&end=%d&total=%d",
-			baseUposHost, filename, partNumber, uploadID, chunk, chunks, end-start, start, end, fileSize)
-
-		req, err := http.NewRequestWithContext(ctx, "PUT", chunkURL, bytes.NewReader(chunkData))
-		if err != nil {
-			return "", fmt.Errorf("创建分块上传请求失败: %w", err)
-		}
-
-		// 设置 headers（分块上传需要 X-Upos-Auth 和正确的 headers）
-		u.setHeaders(req)
-		req.Header.Set("Content-Type", "application/octet-stream")
-		req.Header.Set("Content-Length", fmt.Sprintf("%d", len(chunkData)))
-
-		// 设置 X-Upos-Auth header（每个分块上传都需要）
-		if uposAuth != "" {
-			req.Header.Set("X-Upos-Auth", uposAuth)
-		} else {
-			logger.Warn().Int("chunk", partNumber).Msg("分块上传时未设置 X-Upos-Auth header")
-		}
-
-		// 添加重试机制（网络错误与非200/非204响应均重试）
-		var resp *http.Response
-		maxRetries := 3
-		backoffBase := 1
-		if cfg := config.Get(); cfg != nil {
-			if cfg.Bilibili.ChunkUploadRetries > 0 {
-				maxRetries = cfg.Bilibili.ChunkUploadRetries
-			}
-			if cfg.Bilibili.ChunkRetryBackoffSeconds > 0 {
-				backoffBase = cfg.Bilibili.ChunkRetryBackoffSeconds
-			}
-		}
-		var lastErr error
-		for attempt := 1; attempt <= maxRetries; attempt++ {
-			resp, err = u.httpClient.Do(req)
-			if err == nil && resp != nil && (resp.StatusCode == 200 || resp.StatusCode == 204) {
-				resp.Body.Close()
-				lastErr = nil
-				break
-			}
-			// 生成错误信息
-			var statusCode int
-			var bodyPreview string
-			if resp != nil {
-				statusCode = resp.StatusCode
-				bodyBytes, _ := io.ReadAll(resp.Body)
-				resp.Body.Close()
-				bodyPreview = string(bodyBytes)
-			}
-			if err != nil {
-				lastErr = err
-			} else {
-				lastErr = fmt.Errorf("HTTP %d, 响应: %s", statusCode, bodyPreview)
-			}
-			// 重试或失败退出
-			if attempt < maxRetries {
-				waitTime := time.Duration(attempt*backoffBase) * time.Second
-				logger.Warn().
-					Int("chunk", partNumber).
-					Int("attempt", attempt).
-					Int("max", maxRetries).
-					Dur("wait", waitTime).
-					Err(lastErr).
-					Msg("分块上传失败，准备重试")
-				time.Sleep(waitTime)
-				// 重新创建请求（因为 body 已经被读取）
-				req, _ = http.NewRequestWithContext(ctx, "PUT", chunkURL, bytes.NewReader(chunkData))
-				u.setHeaders(req)
-				req.Header.Set("Content-Type", "application/octet-stream")
-				req.Header.Set("Content-Length", fmt.Sprintf("%d", len(chunkData)))
-				if uposAuth != "" {
-					req.Header.Set("X-Upos-Auth", uposAuth)
-				}
-				continue
-			}
-			// 已用尽重试
-			return "", fmt.Errorf("上传分块 %d 失败（已重试 %d 次）: %w", partNumber, maxRetries, lastErr)
-		}
-
-		logger.Info().
-			Int("chunk", partNumber).
-			Int("total", chunks).
-			Int64("size", end-start).
-			Msg("分块上传完成")
-
-		// 在每个分块上传之间添加短暂延迟，避免连接被关闭
-		if chunk < chunks-1 {
-			time.Sleep(500 * time.Millisecond)
-		}
-	}
+	return &file.UploadSession{
+		VideoPath:   videoPath,
+		Account:     account,
+		FileSize:    fileSize,
+		FileModTime: fileInfo.ModTime().Unix(),
+		Endpoint:    baseUposHost,
+		Filename:    filename,
+		UploadID:    uploadID,
+		Auth:        uposAuth,
+		PutQuery:    pre.putQuery,
+		ChunkSize:   chunkSize,
+		Chunks:      int((fileSize + chunkSize - 1) / chunkSize),
+		CreatedAt:   time.Now().Unix(),
+	}, nil
+}
 
-	// 2.5 可选：调用 UPOS 完成接口，获取 bucket/key/location（仅用于日志校验）
+// finalizeUpload 调用 UPOS 完成接口合并分块（携带各分块的 ETag），获取 bucket/key/location（仅用于日志校验）
+func (u *httpUploader) finalizeUpload(ctx context.Context, videoPath string, session *file.UploadSession) {
 	// 解析 profile 值（来自 preupload put_query）
 	profileVal := "iup/bup"
-	if u.uposPutQuery != "" {
-		if vals, err := url.ParseQuery(u.uposPutQuery); err == nil {
+	if session.PutQuery != "" {
+		if vals, err := url.ParseQuery(session.PutQuery); err == nil {
 			if pv := vals.Get("profile"); pv != "" {
 				profileVal = pv
 			}
 		}
 	}
-	finalizeURL := fmt.Sprintf("%s/iupever/%s?output=json&name=%s&profile=%s&uploadId=%s&biz_id=&biz=UGC",
-		baseUposHost, filename, url.QueryEscape(filepath.Base(videoPath)), url.QueryEscape(profileVal), uploadID)
-	if req, err := http.NewRequestWithContext(ctx, "POST", finalizeURL, nil); err == nil {
-		u.setHeaders(req)
-		if uposAuth != "" {
-			req.Header.Set("X-Upos-Auth", uposAuth)
-		}
-		if resp, err := u.httpClient.Do(req); err == nil {
-			body, _ := io.ReadAll(resp.Body)
-			resp.Body.Close()
-			if resp.StatusCode == 200 {
-				var fin map[string]any
-				if json.Unmarshal(body, &fin) == nil {
-					logger.Info().
-						Str("finalize_url", finalizeURL).
-						Str("bucket", fmt.Sprint(fin["bucket"])).
-						Str("key", fmt.Sprint(fin["key"])).
-						Str("location", fmt.Sprint(fin["location"])).
-						Msg("UPOS 完成上传（bucket/key/location）")
-				} else {
-					logger.Info().
-						Str("finalize_url", finalizeURL).
-						Str("response", previewForLog(string(body), 300)).
-						Msg("UPOS 完成上传返回（解析失败，原样预览）")
-				}
-			} else {
-				logger.Warn().
-					Int("status", resp.StatusCode).
-					Str("finalize_url", finalizeURL).
-					Str("response", previewForLog(string(body), 300)).
-					Msg("UPOS 完成上传返回非200（忽略继续）")
-			}
-		} else {
-			logger.Warn().Err(err).Str("finalize_url", finalizeURL).Msg("UPOS 完成上传请求失败（忽略继续）")
+	type partBody struct {
+		PartNumber int    `json:"partNumber"`
+		ETag       string `json:"eTag"`
+	}
+	parts := make([]partBody, 0, len(session.Parts))
+	for _, p := range session.Parts {
+		etag := p.ETag
+		if etag == "" {
+			etag = "etag"
 		}
+		parts = append(parts, partBody{PartNumber: p.PartNumber, ETag: etag})
 	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	partsJSON, _ := json.Marshal(map[string]any{"parts": parts})
 
-	// 3. 完成上传
+	finalizeURL := fmt.Sprintf("%s/iupever/%s?output=json&name=%s&profile=%s&uploadId=%s&biz_id=&biz=UGC",
+		session.Endpoint, session.Filename, url.QueryEscape(filepath.Base(videoPath)), url.QueryEscape(profileVal), session.UploadID)
+	req, err := http.NewRequestWithContext(ctx, "POST", finalizeURL, bytes.NewReader(partsJSON))
+	if err != nil {
+		return
+	}
+	u.setHeaders(req)
+	req.Header.Set("Content-Type", "application/json")
+	if session.Auth != "" {
+		req.Header.Set("X-Upos-Auth", session.Auth)
+	}
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		logger.Warn().Err(err).Str("finalize_url", finalizeURL).Msg("UPOS 完成上传请求失败（忽略继续）")
+		return
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		logger.Warn().
+			Int("status", resp.StatusCode).
+			Str("finalize_url", finalizeURL).
+			Str("response", previewForLog(string(body), 300)).
+			Msg("UPOS 完成上传返回非200（忽略继续）")
+		return
+	}
+	var fin map[string]any
+	if json.Unmarshal(body, &fin) == nil {
+		logger.Info().
+			Str("finalize_url", finalizeURL).
+			Str("bucket", fmt.Sprint(fin["bucket"])).
+			Str("key", fmt.Sprint(fin["key"])).
+			Str("location", fmt.Sprint(fin["location"])).
+			Msg("UPOS 完成上传（bucket/key/location）")
+	} else {
+		logger.Info().
+			Str("finalize_url", finalizeURL).
+			Str("response", previewForLog(string(body), 300)).
+			Msg("UPOS 完成上传返回（解析失败，原样预览）")
+	}
+}
+
+// completeUpload 通知 B站 视频文件已上传完成
+func (u *httpUploader) completeUpload(ctx context.Context, filename string) error {
 	completeURL := u.buildAPIURL("/intl/videoup/web2/uploading")
 	formData := url.Values{}
 	formData.Set("filename", filename)
@@ -727,19 +819,19 @@ func (u *httpUploader) uploadVideo(ctx context.Context, videoPath string) (strin
 	maxRetries := 3
 	var completeErr error
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		req, err = http.NewRequestWithContext(ctx, "POST", completeURL, strings.NewReader(formData.Encode()))
+		req, err := http.NewRequestWithContext(ctx, "POST", completeURL, strings.NewReader(formData.Encode()))
 		if err != nil {
-			return "", err
+			return err
 		}
 		u.setCookies(req)
 		u.setHeaders(req)
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-		resp, err = u.httpClient.Do(req)
+		resp, err := u.httpClient.Do(req)
 		if err == nil && resp != nil && resp.StatusCode == 200 {
-			defer resp.Body.Close()
+			resp.Body.Close()
 			logger.Info().Int("attempt", attempt).Msg("完成上传成功")
-			goto COMPLETE_OK
+			return nil
 		}
 
 		// 记录错误详情
@@ -761,25 +853,32 @@ func (u *httpUploader) uploadVideo(ctx context.Context, videoPath string) (strin
 
 		completeErr = err
 		// 指数退避
-		time.Sleep(time.Duration(attempt) * time.Second)
+		if err := sleepContext(ctx, time.Duration(attempt)*time.Second); err != nil {
+			return err
+		}
 	}
 	if completeErr != nil {
-		return "", fmt.Errorf("完成上传失败: %w", completeErr)
+		return fmt.Errorf("完成上传失败: %w", completeErr)
 	}
-	return "", fmt.Errorf("完成上传失败")
-COMPLETE_OK:
+	return fmt.Errorf("完成上传失败")
+}
 
-	logger.Info().Str("filename", filename).Msg("视频上传完成")
-	return filename, nil
+// preuploadResult preupload 返回的上传认证信息与上传参数，只属于本次上传
+type preuploadResult struct {
+	auth      string // X-Upos-Auth
+	filename  string // upos_uri 指定的服务端文件名
+	endpoint  string // UPOS 上传主机
+	chunkSize int64
+	putQuery  string
 }
 
 // getUposAuth 调用 preupload API 获取上传认证信息
-func (u *httpUploader) getUposAuth(ctx context.Context, filename string, fileSize int64) (string, string, error) {
+func (u *httpUploader) getUposAuth(ctx context.Context, filename string, fileSize int64) (*preuploadResult, error) {
 	// 构建 preupload API URL
 	preuploadURL := u.buildAPIURL("/preupload")
 	parsedURL, err := url.Parse(preuploadURL)
 	if err != nil {
-		return "", "", fmt.Errorf("解析 preupload URL 失败: %w", err)
+		return nil, fmt.Errorf("解析 preupload URL 失败: %w", err)
 	}
 
 	query := parsedURL.Query()
@@ -795,7 +894,7 @@ func (u *httpUploader) getUposAuth(ctx context.Context, filename string, fileSiz
 
 	req, err := http.NewRequestWithContext(ctx, "GET", parsedURL.String(), nil)
 	if err != nil {
-		return "", "", fmt.Errorf("创建 preupload 请求失败: %w", err)
+		return nil, fmt.Errorf("创建 preupload 请求失败: %w", err)
 	}
 
 	u.setCookies(req)
@@ -805,7 +904,7 @@ func (u *httpUploader) getUposAuth(ctx context.Context, filename string, fileSiz
 
 	resp, err := u.httpClient.Do(req)
 	if err != nil {
-		return "", "", fmt.Errorf("调用 preupload API 失败: %w", err)
+		return nil, fmt.Errorf("调用 preupload API 失败: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -815,12 +914,13 @@ func (u *httpUploader) getUposAuth(ctx context.Context, filename string, fileSiz
 			Int("status_code", resp.StatusCode).
 			Str("response", string(bodyBytes)).
 			Msg("preupload API 返回非200状态码")
-		return "", "", fmt.Errorf("preupload API 返回 HTTP %d", resp.StatusCode)
+		return nil, newAPIError("preupload", resp.StatusCode, 0, string(bodyBytes),
+			fmt.Sprintf("preupload API 返回 HTTP %d", resp.StatusCode))
 	}
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", "", fmt.Errorf("读取 preupload 响应失败: %w", err)
+		return nil, fmt.Errorf("读取 preupload 响应失败: %w", err)
 	}
 
 	// 解析响应（preupload API 直接返回数据，没有 code/data 包装）
@@ -830,7 +930,7 @@ func (u *httpUploader) getUposAuth(ctx context.Context, filename string, fileSiz
 			Err(err).
 			Str("response", string(bodyBytes)).
 			Msg("解析 preupload 响应失败")
-		return "", "", fmt.Errorf("解析 preupload 响应失败: %w", err)
+		return nil, newAPIChangedError("preupload", resp.StatusCode, fmt.Sprintf("解析 preupload 响应失败: %v", err))
 	}
 
 	// 检查 OK 字段
@@ -838,7 +938,13 @@ func (u *httpUploader) getUposAuth(ctx context.Context, filename string, fileSiz
 		logger.Warn().
 			Str("response", string(bodyBytes)).
 			Msg("preupload API 返回 OK != 1")
-		return "", "", fmt.Errorf("preupload API 返回失败: OK=%v", ok)
+		code, _ := preuploadResp["code"].(float64)
+		message := anyString(preuploadResp["message"])
+		if message == "" {
+			message = anyString(preuploadResp["msg"])
+		}
+		return nil, newAPIError("preupload", resp.StatusCode, int(code), message,
+			fmt.Sprintf("preupload API 返回失败: OK=%v, code=%d, message=%s", ok, int(code), message))
 	}
 
 	// 直接从根级别获取 auth 字段
@@ -852,40 +958,40 @@ func (u *httpUploader) getUposAuth(ctx context.Context, filename string, fileSiz
 		logger.Warn().
 			Str("response", string(bodyBytes)).
 			Msg("preupload 响应中没有 auth 字段")
-		return "", "", fmt.Errorf("preupload 响应中没有 auth 字段")
+		return nil, newAPIChangedError("preupload", resp.StatusCode, "preupload 响应中没有 auth 字段")
 	}
 
-	// 记录并保存 endpoint 和 chunk_size、put_query
+	// 记录 endpoint 和 chunk_size、put_query
+	result := &preuploadResult{auth: auth, filename: filename}
 	if ep, ok := preuploadResp["endpoint"].(string); ok && ep != "" {
 		if strings.HasPrefix(ep, "//") {
 			ep = "https:" + ep
 		} else if !strings.HasPrefix(ep, "http") {
 			ep = "https://" + ep
 		}
-		u.uposEndpointHost = strings.TrimRight(ep, "/")
+		result.endpoint = strings.TrimRight(ep, "/")
 	}
 	if cs, ok := preuploadResp["chunk_size"].(float64); ok && cs > 0 {
-		u.uposChunkSize = int64(cs)
+		result.chunkSize = int64(cs)
 	}
 	if pq, ok := preuploadResp["put_query"].(string); ok {
-		u.uposPutQuery = pq
+		result.putQuery = pq
 	}
 	logger.Info().
-		Str("endpoint", u.uposEndpointHost).
-		Int64("chunk_size", u.uposChunkSize).
-		Str("put_query", u.uposPutQuery).
+		Str("endpoint", result.endpoint).
+		Int64("chunk_size", result.chunkSize).
+		Str("put_query", result.putQuery).
 		Msg("preupload 返回的上传参数")
 
 	// 从 upos_uri 中提取实际文件名
-	actualFilename := filename
 	if uposURI, ok := preuploadResp["upos_uri"].(string); ok && uposURI != "" {
 		// upos_uri 格式：upos://iupever/n251209ad16g3917krfoey36fea2g002.mp4
 		// 提取文件名部分
 		if strings.HasPrefix(uposURI, "upos://iupever/") {
-			actualFilename = strings.TrimPrefix(uposURI, "upos://iupever/")
+			result.filename = strings.TrimPrefix(uposURI, "upos://iupever/")
 			logger.Info().
 				Str("upos_uri", uposURI).
-				Str("server_filename", actualFilename).
+				Str("server_filename", result.filename).
 				Msg("preupload 指定的服务端文件名")
 		}
 	}
@@ -903,27 +1009,14 @@ func (u *httpUploader) getUposAuth(ctx context.Context, filename string, fileSiz
 		}
 	}
 
-	return auth, actualFilename, nil
+	return result, nil
 }
 
-// uploadSubtitles 上传字幕
-func (u *httpUploader) uploadSubtitles(ctx context.Context, subtitlePaths []string) (string, error) {
-	if len(subtitlePaths) == 0 {
-		return "", nil
-	}
-
-	// 使用第一个字幕文件（通常是最主要的语言）
-	srtPath := subtitlePaths[0]
-	for _, path := range subtitlePaths {
-		if strings.HasSuffix(strings.ToLower(path), ".srt") {
-			srtPath = path
-			break
-		}
-	}
-
+// uploadSubtitle 将单个 SRT 字幕转换为 B站 JSON 并直传 OSS，返回字幕 key
+// allowStyled 为 true 时同目录的样式字幕 JSON（与语言无关）优先于 SRT 转换结果，仅用于主语言
+func (u *httpUploader) uploadSubtitle(ctx context.Context, srtPath string, allowStyled bool) (string, error) {
 	logger.Info().
 		Str("srt_path", srtPath).
-		Strs("all_subtitle_paths", subtitlePaths).
 		Msg("开始上传字幕")
 
 	// 1. 将 SRT 转换为 B站 JSON 格式
@@ -1052,6 +1145,9 @@ func (u *httpUploader) uploadSubtitles(ctx context.Context, subtitlePaths []stri
 	entryCount := 0
 	usedStyled := false
 	for _, name := range []string{"styled_subtitles.json", "subtitles_styled.json", "subtitles_rich.json"} {
+		if !allowStyled {
+			break
+		}
 		p := filepath.Join(dir, name)
 		if _, statErr := os.Stat(p); statErr == nil {
 			// 直接读取并使用用户提供的带样式 JSON（保持原格式：含 font_* 等顶层字段与 body[from/to/content]）
@@ -1192,6 +1288,87 @@ func (u *httpUploader) uploadSubtitles(ctx context.Context, subtitlePaths []stri
 	return subtitleURL, nil
 }
 
+// uploadSubtitleFiles 逐个语言上传字幕（每种语言最多重试 3 次），返回各语言结果，顺序与 subtitles 一致
+func (u *httpUploader) uploadSubtitleFiles(ctx context.Context, subtitles []subtitleFile) []SubtitleResult {
+	const maxSubtitleRetries = 3
+	results := make([]SubtitleResult, 0, len(subtitles))
+	for i, sub := range subtitles {
+		result := SubtitleResult{Lang: sub.Lang, LangID: sub.LangID}
+		for attempt := 1; attempt <= maxSubtitleRetries; attempt++ {
+			result.URL, result.Err = u.uploadSubtitle(ctx, sub.Path, i == 0)
+			if result.Err == nil {
+				logger.Info().Int("attempt", attempt).Str("lang", sub.Lang).Int("lang_id", sub.LangID).Str("subtitle_url", result.URL).Msg("字幕上传完成")
+				break
+			}
+			logger.Warn().
+				Int("attempt", attempt).
+				Int("max_retries", maxSubtitleRetries).
+				Err(result.Err).
+				Str("lang", sub.Lang).
+				Str("subtitle_path", sub.Path).
+				Msg("上传字幕失败，将重试")
+			if err := sleepContext(ctx, time.Duration(attempt)*time.Second); err != nil {
+				result.Err = err
+				return append(results, result)
+			}
+		}
+		if result.Err != nil {
+			logger.Warn().
+				Err(result.Err).
+				Str("lang", sub.Lang).
+				Str("subtitle_path", sub.Path).
+				Msg("上传字幕失败，已用尽重试。继续上传其他语言与视频")
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// addSubtitle 为已发布的稿件追加一个语言的字幕（字幕需已直传 OSS）
+func (u *httpUploader) addSubtitle(ctx context.Context, aid string, sub *SubtitleResult) error {
+	apiURL := u.buildAPIURL("/intl/videoup/web2/subtitle/add")
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"aid":              aid,
+		"subtitle_url":     sub.URL,
+		"subtitle_lang_id": sub.LangID,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化追加字幕请求失败: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("创建追加字幕请求失败: %w", err)
+	}
+	u.setCookies(req)
+	u.setHeaders(req)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("追加字幕失败: %w", err)
+	}
+	defer resp.Body.Close()
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取追加字幕响应失败: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("追加字幕失败: HTTP %d, 响应: %s", resp.StatusCode, previewForLog(string(bodyBytes), 300))
+	}
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return fmt.Errorf("解析追加字幕响应失败: %w, 响应: %s", err, previewForLog(string(bodyBytes), 300))
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("追加字幕失败: code=%d, message=%s", result.Code, result.Message)
+	}
+	logger.Info().Str("aid", aid).Str("lang", sub.Lang).Int("lang_id", sub.LangID).Msg("字幕已追加到稿件")
+	return nil
+}
+
 // checkSubtitleBatch 检查一批字幕条目的合法性
 func (u *httpUploader) checkSubtitleBatch(ctx context.Context, checkURL string, batch []subtitle.BilibiliSubtitleEntry) ([]string, error) {
 	checkData := map[string]interface{}{
@@ -1324,33 +1501,6 @@ func generateHash(input string) string {
 	return fmt.Sprintf("%x", hash)[:16] // 取前16位
 }
 
-// findDefaultCoverPath 查找默认封面图路径（assets/default_cover.jpg）
-// 尝试多个可能的位置：相对于视频目录、相对于工作目录等
-func (u *httpUploader) findDefaultCoverPath(videoDir string) string {
-	// 尝试多个可能的 assets 目录位置
-	possiblePaths := []string{
-		"./assets/default_cover.jpg",                    // 当前工作目录
-		"assets/default_cover.jpg",                      // 相对路径
-		filepath.Join(videoDir, "..", "..", "assets", "default_cover.jpg"), // 从视频目录向上查找
-		filepath.Join("/opt/blueberry/assets/default_cover.jpg"),            // 默认安装路径
-		filepath.Join("/usr/local/blueberry/assets/default_cover.jpg"),       // 备用安装路径
-	}
-
-	for _, path := range possiblePaths {
-		if absPath, err := filepath.Abs(path); err == nil {
-			if _, err := os.Stat(absPath); err == nil {
-				return absPath
-			}
-		}
-		// 也尝试直接使用路径（可能是绝对路径）
-		if _, err := os.Stat(path); err == nil {
-			return path
-		}
-	}
-
-	return ""
-}
-
 // uploadCover 上传封面图
 func (u *httpUploader) uploadCover(ctx context.Context, coverPath string, videoPath string) (string, error) {
 	// 读取图片文件
@@ -1426,7 +1576,8 @@ func (u *httpUploader) uploadCover(ctx context.Context, coverPath string, videoP
 			Int("status_code", resp.StatusCode).
 			Str("response", string(bodyBytes)).
 			Msg("封面图上传返回非200状态码")
-		return "", fmt.Errorf("上传封面图失败: HTTP %d, 响应: %s", resp.StatusCode, string(bodyBytes))
+		return "", newAPIError("cover", resp.StatusCode, 0, string(bodyBytes),
+			fmt.Sprintf("上传封面图失败: HTTP %d, 响应: %s", resp.StatusCode, string(bodyBytes)))
 	}
 
 	var result struct {
@@ -1442,7 +1593,7 @@ func (u *httpUploader) uploadCover(ctx context.Context, coverPath string, videoP
 			Err(err).
 			Str("response", string(bodyBytes)).
 			Msg("解析封面图上传响应失败")
-		return "", fmt.Errorf("解析封面图上传响应失败: %w, 响应: %s", err, string(bodyBytes))
+		return "", newAPIChangedError("cover", resp.StatusCode, fmt.Sprintf("解析封面图上传响应失败: %v, 响应: %s", err, string(bodyBytes)))
 	}
 
 	if result.Code != 0 {
@@ -1555,7 +1706,8 @@ func (u *httpUploader) uploadCover(ctx context.Context, coverPath string, videoP
 			return result2.Data.URL, nil
 		}
 
-		return "", fmt.Errorf("上传封面图失败: code=%d, message=%s, response=%s", result.Code, result.Message, string(bodyBytes))
+		return "", newAPIError("cover", resp.StatusCode, result.Code, result.Message,
+			fmt.Sprintf("上传封面图失败: code=%d, message=%s, response=%s", result.Code, result.Message, string(bodyBytes)))
 	}
 
 	return result.Data.URL, nil
@@ -1605,7 +1757,7 @@ func upscaleCoverTo1280x720(ctx context.Context, inPath string) (string, string,
 		// 质量参数，若不支持会被 ffmpeg 忽略
 		args = append([]string{"-y", "-i", inPath, "-vf", vf, "-frames:v", "1", "-quality", "85"}, outPath)[0:]
 	}
-	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd := utils.CommandContext(ctx, "ffmpeg", args...)
 	if out, err := cmd.CombinedOutput(); err != nil {
 		// 若保持原格式失败，回退到 jpg 再试一次
 		if outExt != ".jpg" {
@@ -1613,7 +1765,7 @@ func upscaleCoverTo1280x720(ctx context.Context, inPath string) (string, string,
 			contentType = "image/jpeg"
 			outPath = filepath.Join(dir, "cover_1280x720.jpg")
 			args = []string{"-y", "-i", inPath, "-vf", vf, "-frames:v", "1", "-q:v", "2", outPath}
-			if out2, err2 := exec.CommandContext(ctx, "ffmpeg", args...).CombinedOutput(); err2 != nil {
+			if out2, err2 := utils.CommandContext(ctx, "ffmpeg", args...).CombinedOutput(); err2 != nil {
 				return "", "", fmt.Errorf("ffmpeg 调整封面失败: %w, output=%s; fallback=%v, out2=%s", err, string(out), err2, string(out2))
 			}
 		} else {
@@ -1640,7 +1792,7 @@ func extractCoverFromVideo(ctx context.Context, videoPath string) (string, strin
 		"-q:v", "2",
 		outPath,
 	}
-	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd := utils.CommandContext(ctx, "ffmpeg", args...)
 	if out, err := cmd.CombinedOutput(); err != nil {
 		return "", "", fmt.Errorf("ffmpeg 截帧失败: %w, output=%s", err, string(out))
 	}
@@ -1648,37 +1800,49 @@ func extractCoverFromVideo(ctx context.Context, videoPath string) (string, strin
 }
 
 // publishVideo 发布视频
-func (u *httpUploader) publishVideo(ctx context.Context, filename, title, coverURL, subtitleURL, desc string) (string, error) {
+func (u *httpUploader) publishVideo(ctx context.Context, filename, coverURL string, sub *SubtitleResult, meta VideoMeta) (string, error) {
 	apiURL := u.buildAPIURL("/intl/videoup/web2/add")
+	subtitleURL := ""
+	if sub != nil {
+		subtitleURL = sub.URL
+	}
 
 	// 构建发布数据
 	publishData := map[string]interface{}{
-		"title":            title,
+		"title":            meta.Title,
 		"cover":            coverURL,
-		"desc":             desc,
+		"desc":             meta.Desc,
 		"no_reprint":       true,
 		"filename":         filename,
-		"playlist_id":      "",
+		"playlist_id":      meta.PlaylistID,
 		"from_spmid":       "333.1011",
 		"copyright":        1,
-		"tag":              "",
+		"tag":              strings.Join(meta.Tags, ","),
 		"subtitle_id":      nil, // 即使没有字幕，也需要设置为 null
 		"subtitle_lang_id": nil, // 即使没有字幕，也需要设置为 null
 	}
 
+	// 定时发布：dtime 为发布时间（Unix 秒），稿件审核通过后在该时间开放浏览
+	if !meta.PublishAt.IsZero() {
+		publishData["dtime"] = meta.PublishAt.Unix()
+	}
+
 	// 只有当 subtitleURL 不为空时才添加字幕相关字段
 	if subtitleURL != "" {
 		publishData["subtitle_url"] = subtitleURL
-		publishData["subtitle_lang_id"] = 3 // 有字幕时设置为 3（英语）
+		publishData["subtitle_lang_id"] = sub.LangID
 	}
 
 	// 记录发布参数（用于调试）
 	logger.Info().
 		Str("filename", filename).
-		Str("title", previewForLog(title, 50)).
+		Str("title", previewForLog(meta.Title, 50)).
+		Strs("tags", meta.Tags).
+		Time("publish_at", meta.PublishAt).
 		Str("cover", coverURL).
 		Str("subtitle_url", subtitleURL).
 		Bool("has_subtitle", subtitleURL != "").
+		Interface("subtitle_lang_id", publishData["subtitle_lang_id"]).
 		Str("api_url", apiURL).
 		Msg("准备发布视频")
 
@@ -1737,23 +1901,27 @@ func (u *httpUploader) publishVideo(ctx context.Context, filename, title, coverU
 			Str("status", resp.Status).
 			Str("response", string(bodyBytes)).
 			Msg("发布视频返回非200状态码")
-		return "", fmt.Errorf("发布视频失败: HTTP %d (%s), 响应: %s", resp.StatusCode, resp.Status, string(bodyBytes))
+		return "", newAPIError("publish", resp.StatusCode, 0, string(bodyBytes),
+			fmt.Sprintf("发布视频失败: HTTP %d (%s), 响应: %s", resp.StatusCode, resp.Status, string(bodyBytes)))
 	}
 
+	// aid 可能以字符串或数字返回
 	var result struct {
 		Code    int    `json:"code"`
 		Message string `json:"message"`
 		Data    struct {
-			AID string `json:"aid"`
+			AID any `json:"aid"`
 		} `json:"data"`
 	}
 
-	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+	decoder := json.NewDecoder(bytes.NewReader(bodyBytes))
+	decoder.UseNumber()
+	if err := decoder.Decode(&result); err != nil {
 		logger.Error().
 			Err(err).
 			Str("response", string(bodyBytes)).
 			Msg("解析发布响应失败")
-		return "", fmt.Errorf("解析发布响应失败: %w, 响应: %s", err, string(bodyBytes))
+		return "", newAPIChangedError("publish", resp.StatusCode, fmt.Sprintf("解析发布响应失败: %v, 响应: %s", err, string(bodyBytes)))
 	}
 
 	if result.Code != 0 {
@@ -1774,22 +1942,23 @@ func (u *httpUploader) publishVideo(ctx context.Context, filename, title, coverU
 			Str("api_url", apiURL).
 			Msg("发布视频返回错误，完整 HTTP 响应和请求体已记录")
 
-		return "", fmt.Errorf(
+		return "", newAPIError("publish", resp.StatusCode, result.Code, result.Message, fmt.Sprintf(
 			"发布视频失败: HTTP %d (%s), API code=%d, message=%s, api_url=%s, response_preview=%s, request_preview=%s, filename=%s, cover=%s, subtitle_url=%s",
 			resp.StatusCode, resp.Status, result.Code, errorMsg, apiURL,
 			previewForLog(string(bodyBytes), 1000),
 			previewForLog(string(jsonData), 1000),
 			filename, coverURL, subtitleURL,
-		)
+		))
 	}
 
-	logger.Info().Str("aid", result.Data.AID).Msg("视频发布成功")
-	return result.Data.AID, nil
+	aid := anyString(result.Data.AID)
+	logger.Info().Str("aid", aid).Msg("视频发布成功")
+	return aid, nil
 }
 
 // validateRequiredFiles 在开始上传前检查所需文件是否存在
 // 返回实际找到的视频文件路径
-func (u *httpUploader) validateRequiredFiles(videoPath string, subtitlePaths []string, videoDir string) (string, error) {
+func validateRequiredFiles(videoPath string, subtitlePaths []string, videoDir string) (string, error) {
 	// 1. 检查视频文件（必需）
 	// 先尝试直接使用提供的路径
 	actualVideoPath := videoPath
@@ -1824,7 +1993,7 @@ func (u *httpUploader) validateRequiredFiles(videoPath string, subtitlePaths []s
 
 	// 2. 检查字幕文件（如果提供了路径）
 	for i, subtitlePath := range subtitlePaths {
-		subtitlePaths[i] = u.cleanPath(subtitlePath)
+		subtitlePaths[i] = cleanPath(subtitlePath)
 		if _, err := os.Stat(subtitlePaths[i]); err != nil {
 			return "", fmt.Errorf("字幕文件不存在: %s, 错误: %w", subtitlePaths[i], err)
 		}
@@ -1885,7 +2054,7 @@ func generateFilename(originalName string) string {
 
 // cleanPath 清理路径中的转义字符
 // 处理 shell 转义，例如：\  -> 空格，\# -> #，\\ -> \
-func (u *httpUploader) cleanPath(path string) string {
+func cleanPath(path string) string {
 	// 替换常见的转义字符
 	// \  -> 空格
 	// \# -> #
@@ -1898,3 +2067,15 @@ func (u *httpUploader) cleanPath(path string) string {
 
 	return cleaned
 }
+
+// sleepContext 等待 d，ctx 取消时提前返回 ctx.Err()
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,739 @@
+package bilibili
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/bilibili/bilibilitest"
+	"blueberry/internal/repository/file"
+)
+
+const testSRT = `1
+00:00:01,000 --> 00:00:02,500
+Hello world
+
+2
+00:00:03,000 --> 00:00:04,000
+Second line
+`
+
+// testEnv 端到端上传测试环境：测试配置、替身服务器、测试视频与输出目录下的仓库
+type testEnv struct {
+	cfg   *config.Config // config.Load 后的全局配置，测试可以直接修改（如 upload_concurrency）
+	srv   *bilibilitest.Server
+	video testVideo
+	repo  file.Repository // 需要保存上传会话的测试传给 newTestUploader
+}
+
+// newTestEnv 加载测试配置（3 次分块重试、上传英语（3）与简体中文（2）字幕），
+// 启动替身服务器（测试结束时关闭）并创建测试视频
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := fmt.Sprintf(`output:
+  directory: %q
+bilibili:
+  chunk_upload_retries: 3
+  chunk_retry_backoff_seconds: 1
+  upload_subtitles: true
+  subtitle_languages:
+    en: 3
+    zh-Hans: 2
+`, filepath.Join(dir, "downloads"))
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("加载测试配置失败: %v", err)
+	}
+	srv := bilibilitest.NewServer()
+	t.Cleanup(srv.Close)
+	return &testEnv{
+		cfg:   cfg,
+		srv:   srv,
+		video: newTestVideo(t),
+		repo:  file.NewRepository(cfg.Output.Directory),
+	}
+}
+
+// testVideo 视频目录：2500 字节的视频（替身服务器分块大小 1024，共 3 块）、同名封面与两种语言的字幕
+type testVideo struct {
+	dir       string
+	path      string
+	subtitles []string
+	content   []byte
+}
+
+func newTestVideo(t *testing.T) testVideo {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "abc123")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	v := testVideo{dir: dir, path: filepath.Join(dir, "Example Video [abc123].mp4")}
+	v.content = make([]byte, 2500)
+	for i := range v.content {
+		v.content[i] = byte(i % 251)
+	}
+	files := map[string][]byte{
+		v.path: v.content,
+		filepath.Join(dir, "Example Video [abc123].jpg"): {0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F'},
+	}
+	for _, lang := range []string{"en", "zh-Hans"} {
+		p := filepath.Join(dir, "Example Video [abc123]."+lang+".srt")
+		files[p] = []byte(testSRT)
+		v.subtitles = append(v.subtitles, p)
+	}
+	for p, data := range files {
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return v
+}
+
+func writeTestCookies(t *testing.T, withSession bool) string {
+	t.Helper()
+	if withSession {
+		return writeAccountCookies(t, bilibilitest.SessData, bilibilitest.CSRFToken)
+	}
+	return writeAccountCookies(t, "", bilibilitest.CSRFToken)
+}
+
+// writeAccountCookies 写出账号的 cookies 文件，sessData 为空表示未登录
+func writeAccountCookies(t *testing.T, sessData, csrf string) string {
+	t.Helper()
+	lines := []string{
+		"# Netscape HTTP Cookie File",
+		".bilibili.tv\tTRUE\t/\tTRUE\t0\tbili_jct\t" + csrf,
+	}
+	if sessData != "" {
+		lines = append(lines, ".bilibili.tv\tTRUE\t/\tTRUE\t0\tSESSDATA\t"+sessData)
+	}
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newTestUploader(t *testing.T, srv *bilibilitest.Server, repo file.Repository) *httpUploader {
+	t.Helper()
+	u := newHTTPUploader(repo, "https://www.bilibili.tv/en/", "", writeTestCookies(t, true))
+	u.httpClient = srv.Client()
+	return u
+}
+
+func testMeta() VideoMeta {
+	return VideoMeta{Title: "Example Video", Desc: "desc", Tags: []string{"music", "live"}}
+}
+
+func upload(t *testing.T, u *httpUploader, v testVideo, meta VideoMeta) (*UploadResult, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	return u.UploadVideo(ctx, v.path, meta, append([]string(nil), v.subtitles...), config.Account{Username: "tester"})
+}
+
+// compactEndpoints 合并连续重复的接口（分块、重试），便于断言调用顺序
+func compactEndpoints(endpoints []bilibilitest.Endpoint) []bilibilitest.Endpoint {
+	var out []bilibilitest.Endpoint
+	for _, e := range endpoints {
+		if len(out) == 0 || out[len(out)-1] != e {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func assertUploadedIntact(t *testing.T, srv *bilibilitest.Server, v testVideo) bilibilitest.Archive {
+	t.Helper()
+	archives := srv.Archives()
+	if len(archives) != 1 {
+		t.Fatalf("稿件数 = %d，期望 1", len(archives))
+	}
+	data, ok := srv.File(archives[0].Filename)
+	if !ok {
+		t.Fatalf("服务端没有合并后的视频 %s", archives[0].Filename)
+	}
+	if !bytes.Equal(data, v.content) {
+		t.Fatalf("合并后的视频与原文件不一致：%d 字节，期望 %d 字节", len(data), len(v.content))
+	}
+	return archives[0]
+}
+
+func TestUploadVideoPublishesThroughAllSteps(t *testing.T) {
+	env := newTestEnv(t)
+	srv, v := env.srv, env.video
+
+	meta := testMeta()
+	meta.PlaylistID = "pl-1"
+	meta.PublishAt = time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	result, err := upload(t, newTestUploader(t, srv, nil), v, meta)
+	if err != nil {
+		t.Fatalf("UploadVideo 失败: %v", err)
+	}
+
+	archive := assertUploadedIntact(t, srv, v)
+	if !result.Success || result.AID != archive.AID || result.VideoID != archive.AID || result.PlaylistID != "pl-1" {
+		t.Fatalf("结果 = %+v，稿件 aid = %s", result, archive.AID)
+	}
+	if archive.Title != meta.Title || archive.Desc != meta.Desc || archive.Tags != "music,live" || archive.PlaylistID != "pl-1" {
+		t.Fatalf("稿件信息不正确: %+v", archive)
+	}
+	if archive.DTime != meta.PublishAt.Unix() {
+		t.Fatalf("dtime = %d，期望 %d", archive.DTime, meta.PublishAt.Unix())
+	}
+	if strings.Contains(archive.Filename, ".") {
+		t.Fatalf("发布请求的 filename 应去掉后缀: %s", archive.Filename)
+	}
+
+	want := []bilibilitest.Endpoint{
+		bilibilitest.EndpointUploadPage,
+		bilibilitest.EndpointCover,
+		bilibilitest.EndpointSubtitleToken, bilibilitest.EndpointSubtitleOSS,
+		bilibilitest.EndpointSubtitleToken, bilibilitest.EndpointSubtitleOSS,
+		bilibilitest.EndpointPreupload,
+		bilibilitest.EndpointUploadInit,
+		bilibilitest.EndpointChunk,
+		bilibilitest.EndpointFinalize,
+		bilibilitest.EndpointComplete,
+		bilibilitest.EndpointPublish,
+		bilibilitest.EndpointSubtitleAdd,
+	}
+	if got := compactEndpoints(srv.Endpoints()); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("接口调用顺序 = %v\n期望 %v", got, want)
+	}
+
+	chunks := srv.Requests(bilibilitest.EndpointChunk)
+	if len(chunks) != 3 {
+		t.Fatalf("分块请求 = %d，期望 3", len(chunks))
+	}
+	for i, r := range chunks {
+		if r.Header.Get("X-Upos-Auth") != srv.Auth {
+			t.Fatalf("分块 %d 缺少 X-Upos-Auth", i+1)
+		}
+		if r.Query.Get("partNumber") != fmt.Sprint(i+1) || r.Query.Get("chunks") != "3" || r.Query.Get("total") != "2500" {
+			t.Fatalf("分块 %d 参数不正确: %v", i+1, r.Query)
+		}
+	}
+	publish := srv.Requests(bilibilitest.EndpointPublish)[0]
+	if publish.Query.Get("csrf") != bilibilitest.CSRFToken {
+		t.Fatalf("发布请求 csrf = %q", publish.Query.Get("csrf"))
+	}
+
+	// 英语随发布请求提交，简体中文发布后追加
+	if archive.SubtitleLangID != 3 || archive.SubtitleURL == "" {
+		t.Fatalf("发布时的字幕 = %q (lang %d)，期望英语", archive.SubtitleURL, archive.SubtitleLangID)
+	}
+	if _, ok := archive.Subtitles[2]; !ok || len(archive.Subtitles) != 1 {
+		t.Fatalf("追加的字幕 = %v，期望只有简体中文（2）", archive.Subtitles)
+	}
+	if len(result.Subtitles) != 2 {
+		t.Fatalf("字幕结果 = %+v", result.Subtitles)
+	}
+	for _, sub := range result.Subtitles {
+		if sub.Err != nil || sub.URL == "" {
+			t.Fatalf("字幕 %s 上传失败: %+v", sub.Lang, sub)
+		}
+		if _, ok := srv.Object(sub.URL); !ok {
+			t.Fatalf("OSS 中没有字幕 %s", sub.URL)
+		}
+	}
+}
+
+// 多个 worker 共用一个上传器、同时使用不同账号上传：每个账号的 cookies、CSRF 与上传会话互不覆盖
+func TestUploadVideoConcurrentAccounts(t *testing.T) {
+	env := newTestEnv(t)
+	srv := env.srv
+	u := newTestUploader(t, srv, nil)
+
+	names := []string{"alice", "bob", "carol"}
+	results := make([]*UploadResult, len(names))
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		v := newTestVideo(t)
+		account := config.Account{Username: name, CookiesFile: writeAccountCookies(t, "sess-"+name, "csrf-"+name)}
+		meta := testMeta()
+		meta.Title = name
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			results[i], errs[i] = u.UploadVideo(ctx, v.path, meta, append([]string(nil), v.subtitles...), account)
+		}()
+	}
+	wg.Wait()
+
+	archives := make(map[string]bilibilitest.Archive)
+	for _, a := range srv.Archives() {
+		archives[a.AID] = a
+	}
+	for i, name := range names {
+		if errs[i] != nil {
+			t.Fatalf("%s 上传失败: %v", name, errs[i])
+		}
+		a, ok := archives[results[i].AID]
+		if !ok || a.Title != name || a.Owner != "sess-"+name {
+			t.Fatalf("%s 的稿件 = %+v，期望标题与所属账号都是 %s", name, a, name)
+		}
+	}
+	for _, r := range srv.Requests("") {
+		csrf := r.Query.Get("csrf")
+		if csrf != "" && strings.TrimPrefix(csrf, "csrf-") != strings.TrimPrefix(r.Cookie("SESSDATA"), "sess-") {
+			t.Fatalf("%s 请求的 csrf=%s 与 SESSDATA=%s 不属于同一账号", r.Endpoint, csrf, r.Cookie("SESSDATA"))
+		}
+	}
+}
+
+// 分块逐个上传与并发上传（upload_concurrency=3）时，临时失败都会重试到成功
+func TestUploadVideoRetriesTransientFailures(t *testing.T) {
+	for _, concurrency := range []int{1, 3} {
+		t.Run(fmt.Sprintf("concurrency=%d", concurrency), func(t *testing.T) {
+			env := newTestEnv(t)
+			env.cfg.Bilibili.UploadConcurrency = concurrency
+			srv, v := env.srv, env.video
+
+			secondPart := func(r bilibilitest.Request) bool { return r.Query.Get("partNumber") == "2" }
+			srv.Inject(bilibilitest.EndpointChunk, bilibilitest.Fault{Status: 503, Body: "busy", When: secondPart})
+			srv.Inject(bilibilitest.EndpointChunk, bilibilitest.Fault{CloseConn: true, When: secondPart})
+			srv.Inject(bilibilitest.EndpointSubtitleOSS, bilibilitest.Fault{Status: 500, Body: "<Error/>"})
+			srv.Inject(bilibilitest.EndpointComplete, bilibilitest.Fault{Status: 502, Body: "bad gateway"})
+
+			if _, err := upload(t, newTestUploader(t, srv, nil), v, testMeta()); err != nil {
+				t.Fatalf("UploadVideo 失败: %v", err)
+			}
+			assertUploadedIntact(t, srv, v)
+
+			var part2 int
+			for _, r := range srv.Requests(bilibilitest.EndpointChunk) {
+				if secondPart(r) {
+					part2++
+				}
+			}
+			if part2 != 3 {
+				t.Fatalf("分块 2 请求 %d 次，期望 3 次（两次失败后成功）", part2)
+			}
+			if n := len(srv.Requests(bilibilitest.EndpointSubtitleOSS)); n != 3 {
+				t.Fatalf("字幕直传请求 %d 次，期望 3 次（两种语言，其中一次重试）", n)
+			}
+			if n := len(srv.Requests(bilibilitest.EndpointComplete)); n != 2 {
+				t.Fatalf("完成上传请求 %d 次，期望 2 次", n)
+			}
+		})
+	}
+}
+
+func TestUploadVideoGivesUpAfterChunkRetries(t *testing.T) {
+	env := newTestEnv(t)
+	srv, v := env.srv, env.video
+
+	srv.Inject(bilibilitest.EndpointChunk, bilibilitest.Fault{
+		Status: 500,
+		Body:   "internal error",
+		Times:  -1,
+		When:   func(r bilibilitest.Request) bool { return r.Query.Get("partNumber") == "3" },
+	})
+	_, err := upload(t, newTestUploader(t, srv, nil), v, testMeta())
+	var stepErr *UploadStepError
+	if !errors.As(err, &stepErr) || stepErr.Step != StepVideo {
+		t.Fatalf("错误 = %v，期望视频步骤失败", err)
+	}
+	if n := len(srv.Requests(bilibilitest.EndpointChunk)); n != 2+3 {
+		t.Fatalf("分块请求 %d 次，期望 5 次（分块 3 尝试 chunk_upload_retries=3 次）", n)
+	}
+	if n := len(srv.Requests(bilibilitest.EndpointPublish)); n != 0 {
+		t.Fatalf("分块失败后不应发布，发布请求 %d 次", n)
+	}
+}
+
+// upload_concurrency > 1：分块并发上传、乱序完成，合并请求中的分块仍按序号排列
+func TestUploadVideoConcurrentChunks(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Bilibili.UploadConcurrency = 3
+	srv, v := env.srv, env.video
+	srv.ChunkSize = 256 // 2500 字节共 10 块
+	// 分块 1 最慢，其余分块先完成
+	srv.ChunkDelay = func(part int) time.Duration {
+		if part == 1 {
+			return 300 * time.Millisecond
+		}
+		return 50 * time.Millisecond
+	}
+
+	if _, err := upload(t, newTestUploader(t, srv, nil), v, testMeta()); err != nil {
+		t.Fatalf("UploadVideo 失败: %v", err)
+	}
+	assertUploadedIntact(t, srv, v)
+	if n := srv.MaxConcurrentChunks(); n < 2 || n > 3 {
+		t.Fatalf("同时上传的分块数最大为 %d，期望 2~3（upload_concurrency=3）", n)
+	}
+
+	chunks := srv.Requests(bilibilitest.EndpointChunk)
+	if len(chunks) != 10 {
+		t.Fatalf("分块请求 = %d，期望 10", len(chunks))
+	}
+	finalize := srv.Requests(bilibilitest.EndpointFinalize)
+	if len(finalize) != 1 {
+		t.Fatalf("合并请求 %d 次，期望 1 次", len(finalize))
+	}
+	parts, _ := finalize[0].JSON()["parts"].([]any)
+	if len(parts) != 10 {
+		t.Fatalf("合并请求中的分块 = %v，期望 10 个", parts)
+	}
+	for i, p := range parts {
+		part, _ := p.(map[string]any)
+		if part["partNumber"] != float64(i+1) || part["eTag"] != fmt.Sprintf("etag-%d", i+1) {
+			t.Fatalf("合并请求中第 %d 个分块 = %v，期望按序号排列", i+1, part)
+		}
+	}
+}
+
+func TestUploadVideoResumesSession(t *testing.T) {
+	env := newTestEnv(t)
+	srv, v, repo := env.srv, env.video, env.repo
+
+	// 第一次上传：分块 3 返回 404（upload_id 失效），新会话不重试，上传失败但已完成的分块保存在会话中
+	srv.Inject(bilibilitest.EndpointChunk, bilibilitest.Fault{
+		Status: 404,
+		When:   func(r bilibilitest.Request) bool { return r.Query.Get("partNumber") == "3" },
+	})
+	if _, err := upload(t, newTestUploader(t, srv, repo), v, testMeta()); !errors.Is(err, errUploadSessionExpired) {
+		t.Fatalf("第一次上传错误 = %v，期望上传会话失效", err)
+	}
+	session, err := repo.LoadUploadSession(v.dir)
+	if err != nil || session == nil || len(session.Parts) != 2 {
+		t.Fatalf("上传会话 = %+v, err = %v，期望保存 2 个已完成分块", session, err)
+	}
+
+	// 第二次上传：续传，只上传分块 3，不重新初始化
+	result, err := upload(t, newTestUploader(t, srv, repo), v, testMeta())
+	if err != nil {
+		t.Fatalf("续传失败: %v", err)
+	}
+	assertUploadedIntact(t, srv, v)
+	if n := len(srv.Requests(bilibilitest.EndpointUploadInit)); n != 1 {
+		t.Fatalf("初始化上传 %d 次，续传不应重新初始化", n)
+	}
+	if n := len(srv.Requests(bilibilitest.EndpointChunk)); n != 4 {
+		t.Fatalf("分块请求 %d 次，期望 4 次（1、2、3 失败、续传 3）", n)
+	}
+	if result.AID == "" {
+		t.Fatal("续传后没有 aid")
+	}
+}
+
+func TestUploadVideoDiscardsSessionOfOtherAccount(t *testing.T) {
+	env := newTestEnv(t)
+	srv, v, repo := env.srv, env.video, env.repo
+	u := newTestUploader(t, srv, repo)
+
+	// alice 上传到一半失败，会话中保存了 alice 的 upload_id 与 2 个已完成分块
+	srv.Inject(bilibilitest.EndpointChunk, bilibilitest.Fault{
+		Status: 404,
+		When:   func(r bilibilitest.Request) bool { return r.Query.Get("partNumber") == "3" },
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	alice := config.Account{Username: "alice", CookiesFile: writeAccountCookies(t, "sess-alice", "csrf-alice")}
+	if _, err := u.UploadVideo(ctx, v.path, testMeta(), nil, alice); err == nil {
+		t.Fatal("alice 的上传应失败")
+	}
+	if session, _ := repo.LoadUploadSession(v.dir); session == nil || session.Account != "alice" {
+		t.Fatalf("上传会话 = %+v，期望属于 alice", session)
+	}
+
+	// 换成 bob 重试：不能续传 alice 的会话，重新初始化并上传全部分块后以 bob 发布
+	bob := config.Account{Username: "bob", CookiesFile: writeAccountCookies(t, "sess-bob", "csrf-bob")}
+	result, err := u.UploadVideo(ctx, v.path, testMeta(), nil, bob)
+	if err != nil {
+		t.Fatalf("bob 上传失败: %v", err)
+	}
+	archive := assertUploadedIntact(t, srv, v)
+	if archive.AID != result.AID || archive.Owner != "sess-bob" {
+		t.Fatalf("稿件 = %+v，期望属于 bob", archive)
+	}
+	if n := len(srv.Requests(bilibilitest.EndpointUploadInit)); n != 2 {
+		t.Fatalf("初始化上传 %d 次，换账号后应重新初始化", n)
+	}
+	if n := len(srv.Requests(bilibilitest.EndpointChunk)); n != 6 {
+		t.Fatalf("分块请求 %d 次，期望 6 次（alice 3 次、bob 重新上传 3 次）", n)
+	}
+}
+
+func TestUploadVideoRestartsExpiredSession(t *testing.T) {
+	env := newTestEnv(t)
+	srv, v, repo := env.srv, env.video, env.repo
+
+	thirdPart := func(r bilibilitest.Request) bool { return r.Query.Get("partNumber") == "3" }
+	srv.Inject(bilibilitest.EndpointChunk, bilibilitest.Fault{Status: 404, When: thirdPart})
+	if _, err := upload(t, newTestUploader(t, srv, repo), v, testMeta()); err == nil {
+		t.Fatal("第一次上传应失败")
+	}
+
+	// 续传时服务端已不认可 upload_id：丢弃会话，重新初始化并上传全部分块
+	srv.Inject(bilibilitest.EndpointChunk, bilibilitest.Fault{Status: 404, When: thirdPart})
+	if _, err := upload(t, newTestUploader(t, srv, repo), v, testMeta()); err != nil {
+		t.Fatalf("重新上传失败: %v", err)
+	}
+	assertUploadedIntact(t, srv, v)
+	if n := len(srv.Requests(bilibilitest.EndpointUploadInit)); n != 2 {
+		t.Fatalf("初始化上传 %d 次，期望 2 次", n)
+	}
+}
+
+func TestUploadVideoMalformedResponses(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint bilibilitest.Endpoint
+		fault    bilibilitest.Fault
+		step     UploadStep
+		kind     FailureKind
+		notLogin bool
+	}{
+		{
+			name:     "preupload 返回 HTML",
+			endpoint: bilibilitest.EndpointPreupload,
+			fault:    bilibilitest.Fault{Body: "<html>maintenance</html>"},
+			step:     StepVideo,
+			kind:     FailureAPIChanged,
+		},
+		{
+			name:     "preupload 接口不存在",
+			endpoint: bilibilitest.EndpointPreupload,
+			fault:    bilibilitest.Fault{Status: 404, Body: "not found"},
+			step:     StepVideo,
+			kind:     FailureAPIChanged,
+		},
+		{
+			name:     "preupload 缺少 auth",
+			endpoint: bilibilitest.EndpointPreupload,
+			fault:    bilibilitest.Fault{Body: `{"OK":1,"chunk_size":1024}`},
+			step:     StepVideo,
+			kind:     FailureAPIChanged,
+		},
+		{
+			name:     "preupload 未登录",
+			endpoint: bilibilitest.EndpointPreupload,
+			fault:    bilibilitest.Fault{Body: `{"OK":0,"code":-101,"message":"账号未登录"}`},
+			step:     StepVideo,
+			kind:     FailureAuthExpired,
+			notLogin: true,
+		},
+		{
+			name:     "封面响应无法解析",
+			endpoint: bilibilitest.EndpointCover,
+			fault:    bilibilitest.Fault{Body: `{"code":0,"data":`},
+			step:     StepCover,
+			kind:     FailureAPIChanged,
+		},
+		{
+			name:     "初始化上传 OK=0",
+			endpoint: bilibilitest.EndpointUploadInit,
+			fault:    bilibilitest.Fault{Body: `{"OK":0}`},
+			step:     StepVideo,
+			kind:     FailureUnknown,
+		},
+		{
+			name:     "发布响应无法解析",
+			endpoint: bilibilitest.EndpointPublish,
+			fault:    bilibilitest.Fault{Body: "<!DOCTYPE html><title>502</title>"},
+			step:     StepPublish,
+			kind:     FailureAPIChanged,
+		},
+		{
+			name:     "发布返回未登录",
+			endpoint: bilibilitest.EndpointPublish,
+			fault:    bilibilitest.Fault{Body: `{"code":-101,"message":"账号未登录"}`},
+			step:     StepPublish,
+			kind:     FailureAuthExpired,
+			notLogin: true,
+		},
+		{
+			name:     "发布被风控",
+			endpoint: bilibilitest.EndpointPublish,
+			fault:    bilibilitest.Fault{Body: `{"code":-352,"message":"risk control"}`},
+			step:     StepPublish,
+			kind:     FailureRateLimited,
+		},
+		{
+			name:     "发布内容被拒绝",
+			endpoint: bilibilitest.EndpointPublish,
+			fault:    bilibilitest.Fault{Body: `{"code":21012,"message":"标题包含敏感词"}`},
+			step:     StepPublish,
+			kind:     FailureContentRejected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			srv, v := env.srv, env.video
+			srv.Inject(tt.endpoint, tt.fault)
+
+			result, err := upload(t, newTestUploader(t, srv, nil), v, testMeta())
+			if err == nil {
+				t.Fatalf("期望失败，结果 = %+v", result)
+			}
+			var stepErr *UploadStepError
+			if !errors.As(err, &stepErr) || stepErr.Step != tt.step {
+				t.Fatalf("错误 = %v，期望 %s 步骤失败", err, tt.step)
+			}
+			if kind := ClassifyError(err); kind != tt.kind {
+				t.Fatalf("ClassifyError = %q，期望 %q（错误: %v）", kind, tt.kind, err)
+			}
+			if errors.Is(err, ErrNotLoggedIn) != tt.notLogin {
+				t.Fatalf("errors.Is(err, ErrNotLoggedIn) = %v，期望 %v", !tt.notLogin, tt.notLogin)
+			}
+			if n := len(srv.Archives()); n != 0 {
+				t.Fatalf("失败后不应创建稿件，稿件数 = %d", n)
+			}
+		})
+	}
+}
+
+func TestUploadVideoRequiresLogin(t *testing.T) {
+	env := newTestEnv(t)
+	srv, v := env.srv, env.video
+
+	u := newHTTPUploader(nil, "https://www.bilibili.tv/en/", "", writeTestCookies(t, false))
+	u.httpClient = srv.Client()
+	_, err := upload(t, u, v, testMeta())
+	if !errors.Is(err, ErrNotLoggedIn) {
+		t.Fatalf("错误 = %v，期望未登录", err)
+	}
+	if n := len(srv.Requests(bilibilitest.EndpointChunk)); n != 0 {
+		t.Fatalf("未登录时不应上传分块，分块请求 %d 次", n)
+	}
+
+	loggedIn, err := u.CheckLoginStatus(context.Background())
+	if err != nil || loggedIn {
+		t.Fatalf("CheckLoginStatus = %v, %v，期望未登录", loggedIn, err)
+	}
+}
+
+func TestArchivesAfterUpload(t *testing.T) {
+	env := newTestEnv(t)
+	srv, v := env.srv, env.video
+	u := newTestUploader(t, srv, nil)
+
+	result, err := upload(t, u, v, testMeta())
+	if err != nil {
+		t.Fatalf("UploadVideo 失败: %v", err)
+	}
+
+	ctx := context.Background()
+	archives, err := u.ListArchives(ctx, config.Account{}, ArchiveFilter{})
+	if err != nil || len(archives) != 1 || archives[0].AID != result.AID || archives[0].Title != "Example Video" {
+		t.Fatalf("ListArchives = %+v, %v", archives, err)
+	}
+
+	srv.SetArchiveState(result.AID, -2, "封面不合规")
+	status, err := u.GetArchiveStatus(ctx, result.AID, config.Account{})
+	if err != nil || status.State != file.ReviewRejected || status.RejectReason != "封面不合规" {
+		t.Fatalf("GetArchiveStatus = %+v, %v", status, err)
+	}
+	status, err = u.GetArchiveStatus(ctx, "999", config.Account{})
+	if err != nil || status.State != file.ReviewDeleted {
+		t.Fatalf("不存在的稿件状态 = %+v, %v，期望 deleted", status, err)
+	}
+}
+
+func TestUploadVideoAcceptsNumericAID(t *testing.T) {
+	env := newTestEnv(t)
+	srv, v := env.srv, env.video
+	srv.NumericAID = true
+
+	result, err := upload(t, newTestUploader(t, srv, nil), v, testMeta())
+	if err != nil {
+		t.Fatalf("UploadVideo 失败: %v", err)
+	}
+	archive := assertUploadedIntact(t, srv, v)
+	if result.AID != archive.AID {
+		t.Fatalf("aid = %q, want %q", result.AID, archive.AID)
+	}
+}
+
+// countingUploader 只记录上传次数的 Uploader
+type countingUploader struct {
+	Uploader
+	uploads int
+}
+
+func (c *countingUploader) UploadVideo(ctx context.Context, videoPath string, meta VideoMeta, subtitlePaths []string, account config.Account) (*UploadResult, error) {
+	c.uploads++
+	return &UploadResult{Success: true, AID: "browser"}, nil
+}
+
+func TestFallbackUploaderDoesNotRepublish(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoint  bilibilitest.Endpoint
+		fault     bilibilitest.Fault
+		wantAID   bool // 在稿件库中找回已发布的稿件
+		wantErr   bool
+		fallbacks int
+	}{
+		{
+			name:     "发布已生效但响应无法解析",
+			endpoint: bilibilitest.EndpointPublish,
+			fault:    bilibilitest.Fault{Body: "<!DOCTYPE html><title>502</title>", Apply: true},
+			wantAID:  true,
+		},
+		{
+			name:     "发布未生效且响应无法解析",
+			endpoint: bilibilitest.EndpointPublish,
+			fault:    bilibilitest.Fault{Body: "<!DOCTYPE html><title>502</title>"},
+			wantErr:  true,
+		},
+		{
+			name:      "preupload 接口变化时回退到浏览器",
+			endpoint:  bilibilitest.EndpointPreupload,
+			fault:     bilibilitest.Fault{Status: 404, Body: "not found"},
+			fallbacks: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			srv, v := env.srv, env.video
+			srv.Inject(tt.endpoint, tt.fault)
+
+			browser := &countingUploader{}
+			f := NewFallbackUploader(newTestUploader(t, srv, nil), browser)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			result, err := f.UploadVideo(ctx, v.path, testMeta(), append([]string(nil), v.subtitles...), config.Account{Username: "tester"})
+
+			if browser.uploads != tt.fallbacks {
+				t.Fatalf("浏览器上传 %d 次，期望 %d 次", browser.uploads, tt.fallbacks)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && ClassifyError(err) != FailureAPIChanged {
+				t.Fatalf("ClassifyError = %q，期望 api_changed", ClassifyError(err))
+			}
+			if tt.wantAID {
+				archives := srv.Archives()
+				if len(archives) != 1 || result.AID != archives[0].AID {
+					t.Fatalf("result = %+v, archives = %+v", result, archives)
+				}
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
 	"blueberry/pkg/logger"
 
 	"github.com/chromedp/cdproto/network"
@@ -19,81 +20,101 @@ import (
 )
 
 type Uploader interface {
-	UploadVideo(ctx context.Context, videoPath, videoTitle, videoDesc string, subtitlePaths []string, account config.Account) (*UploadResult, error)
+	UploadVideo(ctx context.Context, videoPath string, meta VideoMeta, subtitlePaths []string, account config.Account) (*UploadResult, error)
+	// AddSubtitles 为已发布的稿件补传字幕，返回各语言的结果
+	AddSubtitles(ctx context.Context, aid string, subtitlePaths []string, account config.Account) ([]SubtitleResult, error)
+	// GetArchiveStatus 查询已发布稿件的审核 / 转码状态
+	GetArchiveStatus(ctx context.Context, aid string, account config.Account) (*file.ReviewStatus, error)
+	// ArchiveSubtitles 查询已发布稿件上已有字幕的语言 ID
+	ArchiveSubtitles(ctx context.Context, aid string, account config.Account) ([]int, error)
 	CheckLoginStatus(ctx context.Context) (bool, error)
 }
 
+// VideoMeta 投稿的标题、简介与标签（由上传服务按模板渲染并校验长度）
+type VideoMeta struct {
+	Title string
+	Desc  string
+	Tags  []string
+	// PublishAt 定时发布时间，零值表示上传后立即发布
+	PublishAt time.Time
+	// PlaylistID 发布时加入的播放列表，为空表示不加入
+	PlaylistID string
+}
+
 type UploadResult struct {
 	Success bool
 	VideoID string
 	AID     string
 	Error   error
+	// Subtitles 各语言字幕的上传结果
+	Subtitles []SubtitleResult
+	// PlaylistID 发布时已加入的播放列表（仅 HTTP 上传器填写）
+	PlaylistID string
 }
 
 type uploader struct {
 	baseURL            string
 	cookiesFromBrowser string
 	cookiesFile        string
+	// headless 无头模式下 cookies 无效时直接返回 ErrNotLoggedIn，页面元素找不到时返回错误，不等待手动操作
+	headless bool
 }
 
-func NewUploader(baseURL, cookiesFromBrowser, cookiesFile string) Uploader {
+// NewUploader 创建基于浏览器自动化（chromedp）的上传器；headless 为 false 时打开浏览器窗口，允许手动登录与补充操作
+func NewUploader(baseURL, cookiesFromBrowser, cookiesFile string, headless bool) Uploader {
 	return &uploader{
 		baseURL:            baseURL,
 		cookiesFromBrowser: cookiesFromBrowser,
 		cookiesFile:        cookiesFile,
+		headless:           headless,
 	}
 }
 
-func (u *uploader) UploadVideo(ctx context.Context, videoPath, videoTitle, videoDesc string, subtitlePaths []string, account config.Account) (*UploadResult, error) {
-	result := &UploadResult{}
-
+func (u *uploader) UploadVideo(ctx context.Context, videoPath string, meta VideoMeta, subtitlePaths []string, account config.Account) (*UploadResult, error) {
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Flag("headless", false),
-		chromedp.Flag("disable-gpu", false),
+		chromedp.Flag("headless", u.headless),
+		chromedp.Flag("disable-gpu", u.headless),
 		chromedp.UserAgent("Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
 	)
 
 	allocCtx, cancel := chromedp.NewExecAllocator(ctx, opts...)
 	defer cancel()
 
-	ctx, cancel = chromedp.NewContext(allocCtx, chromedp.WithLogf(logger.Printf))
+	browserCtx, cancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(logger.Printf))
 	defer cancel()
 
-	ctx, cancel = context.WithTimeout(ctx, 15*time.Minute)
+	browserCtx, cancel = context.WithTimeout(browserCtx, 15*time.Minute)
 	defer cancel()
 
-	// 加载 cookies（优先使用账号级别的配置，否则使用全局配置）
-	cookiesFile := account.CookiesFile
-	if cookiesFile == "" {
-		cookiesFile = u.cookiesFile
-	}
-
-	// 如果账号有独立的 cookies 配置，使用账号的配置
-	if cookiesFile != "" {
-		if err := u.loadCookiesWithConfig(ctx, cookiesFile, ""); err != nil {
-			logger.Warn().Err(err).Msg("加载 cookies 失败，将尝试正常登录")
-		}
-	} else if u.cookiesFile != "" {
-		// 否则使用全局配置
-		if err := u.loadCookies(ctx); err != nil {
-			logger.Warn().Err(err).Msg("加载 cookies 失败，将尝试正常登录")
+	result, err := runUploadSteps(browserCtx, "chromedp", &browserSteps{u: u}, videoPath, meta, subtitlePaths, account)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
+		return nil, err
 	}
-
-	if err := u.login(ctx, account); err != nil {
-		result.Error = fmt.Errorf("登录失败: %w", err)
-		return result, result.Error
+	if result.AID == "" {
+		logger.Warn().Msg("未能获取视频ID，上传可能未完成或需要手动操作")
+		logger.Info().Msg("如果上传已完成，请手动获取视频ID（aid），然后使用 rename 命令重命名字幕文件")
+	} else {
+		logger.Info().Str("video_id", result.AID).Msg("上传流程完成，已获取视频ID")
 	}
+	return result, nil
+}
 
-	videoID, err := u.uploadVideoFile(ctx, videoPath, videoTitle, videoDesc, subtitlePaths)
-	if err != nil {
-		result.Error = fmt.Errorf("上传失败: %w", err)
-		return result, result.Error
-	}
+// AddSubtitles 浏览器自动化上传器不支持为已发布稿件补传字幕
+func (u *uploader) AddSubtitles(ctx context.Context, aid string, subtitlePaths []string, account config.Account) ([]SubtitleResult, error) {
+	return nil, fmt.Errorf("chromedp 上传方式不支持补传字幕，请使用 bilibili.upload_method: http")
+}
 
-	result.Success = true
-	result.VideoID = videoID
-	return result, nil
+// GetArchiveStatus 浏览器自动化上传器不支持查询稿件状态
+func (u *uploader) GetArchiveStatus(ctx context.Context, aid string, account config.Account) (*file.ReviewStatus, error) {
+	return nil, fmt.Errorf("chromedp 上传方式不支持查询稿件状态，请使用 bilibili.upload_method: http")
+}
+
+// ArchiveSubtitles 浏览器自动化上传器不支持查询稿件字幕
+func (u *uploader) ArchiveSubtitles(ctx context.Context, aid string, account config.Account) ([]int, error) {
+	return nil, fmt.Errorf("chromedp 上传方式不支持查询稿件字幕，请使用 bilibili.upload_method: http")
 }
 
 func (u *uploader) login(ctx context.Context, account config.Account) error {
@@ -110,6 +131,10 @@ func (u *uploader) login(ctx context.Context, account config.Account) error {
 		logger.Info().Msg("已经登录（可能通过 cookies），跳过登录步骤")
 		return nil
 	}
+	if u.headless {
+		// 无头模式无法手动登录
+		return ErrNotLoggedIn
+	}
 
 	// 如果配置了 cookies 文件，且已经加载，可能不需要登录
 	if cookiesFile != "" {
@@ -203,209 +228,330 @@ func (u *uploader) login(ctx context.Context, account config.Account) error {
 	return nil
 }
 
-func (u *uploader) uploadVideoFile(ctx context.Context, videoPath, videoTitle, videoDesc string, subtitlePaths []string) (string, error) {
-	logger.Info().Str("video_path", videoPath).Str("title", videoTitle).Msg("开始上传视频")
+// browserSteps 浏览器上传器对投稿步骤的实现
+// 上传页面选择视频文件后才显示封面、字幕与稿件信息表单，因此 cover / subtitles 步骤只记录文件，在 publish 步骤中填写
+type browserSteps struct {
+	u         *uploader
+	coverPath string
+	subtitles []subtitleFile
+}
 
-	uploadURL := fmt.Sprintf("%s/upload", u.baseURL)
-	absVideoPath, err := filepath.Abs(videoPath)
-	if err != nil {
-		return "", fmt.Errorf("获取视频绝对路径失败: %w", err)
+// 视频文件输入框
+var browserVideoSelectors = []string{
+	`input[type="file"]`,
+	`input[accept*="video"]`,
+	`input[accept*="mp4"]`,
+	`input.file-input`,
+	`#file-input`,
+	`.upload-input`,
+}
+
+// 封面文件输入框
+var browserCoverSelectors = []string{
+	`input[type="file"][accept*="image"]`,
+	`.cover-upload input[type="file"]`,
+	`input.cover-input`,
+	`#cover-input`,
+}
+
+// 字幕文件输入框
+var browserSubtitleSelectors = []string{
+	`input[type="file"][accept*="srt"]`,
+	`input[type="file"][accept*="subtitle"]`,
+	`input.subtitle-input`,
+	`#subtitle-input`,
+	`input[accept*=".srt"]`,
+}
+
+// 标题输入框
+var browserTitleSelectors = []string{
+	`input[name="title"]`,
+	`input[placeholder*="title" i]`,
+	`input[placeholder*="标题" i]`,
+	`#title`,
+	`.title-input`,
+	`textarea[name="title"]`,
+}
+
+// 简介输入框
+var browserDescSelectors = []string{
+	`textarea[name="desc"]`,
+	`textarea[placeholder*="description" i]`,
+	`textarea[placeholder*="简介" i]`,
+	`#desc`,
+	`.desc-input textarea`,
+}
+
+// 标签输入框（逐个输入并回车）
+var browserTagSelectors = []string{
+	`input[placeholder*="tag" i]`,
+	`input[placeholder*="标签" i]`,
+	`.tag-input input`,
+}
+
+// 提交按钮
+var browserSubmitSelectors = []string{
+	`button[type="submit"]`,
+	`.submit-btn`,
+	`button.publish`,
+	`.publish-button`,
+}
+
+// stepPrepare 加载 cookies、确认登录并打开上传页面
+func (s *browserSteps) stepPrepare(ctx context.Context, job *uploadJob) error {
+	u := s.u
+	// 加载 cookies（优先使用账号级别的配置，否则使用全局配置）
+	cookiesFile := job.account.CookiesFile
+	if cookiesFile == "" {
+		cookiesFile = u.cookiesFile
 	}
 
-	// 准备字幕文件的绝对路径
-	absSubtitlePaths := make([]string, 0, len(subtitlePaths))
-	for _, subPath := range subtitlePaths {
-		absPath, err := filepath.Abs(subPath)
-		if err == nil {
-			absSubtitlePaths = append(absSubtitlePaths, absPath)
-		} else {
-			logger.Warn().Str("path", subPath).Err(err).Msg("获取字幕文件绝对路径失败")
+	// 如果账号有独立的 cookies 配置，使用账号的配置
+	if cookiesFile != "" {
+		if err := u.loadCookiesWithConfig(ctx, cookiesFile, ""); err != nil {
+			logger.Warn().Err(err).Msg("加载 cookies 失败，将尝试正常登录")
+		}
+	} else if u.cookiesFile != "" {
+		// 否则使用全局配置
+		if err := u.loadCookies(ctx); err != nil {
+			logger.Warn().Err(err).Msg("加载 cookies 失败，将尝试正常登录")
 		}
 	}
 
-	var videoID string
-	err = chromedp.Run(ctx,
-		chromedp.Navigate(uploadURL),
+	if err := u.login(ctx, job.account); err != nil {
+		return fmt.Errorf("登录失败: %w", err)
+	}
+	return s.openUploadPage(ctx)
+}
+
+func (s *browserSteps) openUploadPage(ctx context.Context) error {
+	err := chromedp.Run(ctx,
+		chromedp.Navigate(fmt.Sprintf("%s/upload", s.u.baseURL)),
 		chromedp.WaitVisible("body", chromedp.ByQuery),
 		chromedp.Sleep(3*time.Second),
-		// 步骤1: 上传视频文件
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			logger.Info().Msg("查找视频上传输入框...")
-
-			// 尝试多种可能的选择器
-			uploadSelectors := []string{
-				`input[type="file"]`,
-				`input[accept*="video"]`,
-				`input[accept*="mp4"]`,
-				`input.file-input`,
-				`#file-input`,
-				`.upload-input`,
-			}
-
-			var uploaded bool
-			for _, selector := range uploadSelectors {
-				if err := chromedp.SetUploadFiles(selector, []string{absVideoPath}, chromedp.ByQuery).Do(ctx); err == nil {
-					logger.Info().Str("selector", selector).Msg("视频文件已选择")
-					uploaded = true
-					chromedp.Sleep(2 * time.Second)
-					break
-				}
-			}
-
-			if !uploaded {
-				logger.Warn().Msg("自动选择视频文件失败，请在浏览器中手动选择视频文件")
-				logger.Info().Str("path", absVideoPath).Msg("视频文件路径")
-			}
+	)
+	if err != nil {
+		return fmt.Errorf("打开上传页面失败: %w", err)
+	}
+	return nil
+}
 
-			return nil
-		}),
-		// 等待文件上传和处理
-		chromedp.Sleep(5*time.Second),
-		// 步骤2: 填写视频标题
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			logger.Info().Str("title", videoTitle).Msg("尝试填写视频标题...")
-
-			titleSelectors := []string{
-				`input[name="title"]`,
-				`input[placeholder*="title" i]`,
-				`input[placeholder*="标题" i]`,
-				`#title`,
-				`.title-input`,
-				`textarea[name="title"]`,
-			}
+func (s *browserSteps) stepCover(ctx context.Context, job *uploadJob) error {
+	absPath, err := filepath.Abs(job.coverPath)
+	if err != nil {
+		return fmt.Errorf("获取封面绝对路径失败: %w", err)
+	}
+	s.coverPath = absPath
+	return nil
+}
 
-			var titleFilled bool
-			for _, selector := range titleSelectors {
-				// 先清空输入框
-				chromedp.Clear(selector, chromedp.ByQuery).Do(ctx)
-				if err := chromedp.SendKeys(selector, videoTitle, chromedp.ByQuery).Do(ctx); err == nil {
-					logger.Info().Str("selector", selector).Msg("已填写视频标题")
-					titleFilled = true
-					chromedp.Sleep(1 * time.Second)
-					break
-				}
-			}
+// stepSubtitles 与 HTTP 上传器使用相同的语言规划（每种语言一个 SRT，未配置映射的语言跳过）
+func (s *browserSteps) stepSubtitles(ctx context.Context, job *uploadJob) error {
+	subtitles, skipped := planSubtitles(config.Get(), job.subtitlePaths)
+	for _, sub := range subtitles {
+		absPath, err := filepath.Abs(sub.Path)
+		if err != nil {
+			logger.Warn().Str("path", sub.Path).Err(err).Msg("获取字幕文件绝对路径失败")
+			skipped = append(skipped, SubtitleResult{Lang: sub.Lang, LangID: sub.LangID, Err: err})
+			continue
+		}
+		sub.Path = absPath
+		s.subtitles = append(s.subtitles, sub)
+	}
+	job.skippedSubtitles = skipped
+	return nil
+}
 
-			if !titleFilled {
-				logger.Warn().Msg("未找到标题输入框，可能需要手动填写")
-				logger.Info().Str("title", videoTitle).Msg("请使用此标题")
-			}
+// stepVideo 选择视频文件；找不到输入框时重新加载上传页面重试，次数与退避沿用分块上传的配置
+func (s *browserSteps) stepVideo(ctx context.Context, job *uploadJob) error {
+	absVideoPath, err := filepath.Abs(job.videoPath)
+	if err != nil {
+		return fmt.Errorf("获取视频绝对路径失败: %w", err)
+	}
+	maxRetries, backoff := 3, 1
+	if cfg := config.Get(); cfg != nil {
+		if cfg.Bilibili.ChunkUploadRetries > 0 {
+			maxRetries = cfg.Bilibili.ChunkUploadRetries
+		}
+		if cfg.Bilibili.ChunkRetryBackoffSeconds > 0 {
+			backoff = cfg.Bilibili.ChunkRetryBackoffSeconds
+		}
+	}
 
-			return nil
-		}),
-		// 步骤3: 上传字幕文件（如果有）
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			if len(absSubtitlePaths) == 0 {
-				return nil
-			}
+	logger.Info().Str("video_path", absVideoPath).Str("title", job.meta.Title).Msg("开始上传视频")
+	for attempt := 1; ; attempt++ {
+		_, err = setUploadFiles(ctx, browserVideoSelectors, absVideoPath)
+		if err == nil || attempt >= maxRetries {
+			break
+		}
+		logger.Warn().Err(err).Int("attempt", attempt).Int("max", maxRetries).Msg("选择视频文件失败，重新加载上传页面后重试")
+		if err := sleepContext(ctx, time.Duration(attempt*backoff)*time.Second); err != nil {
+			return err
+		}
+		if err := s.openUploadPage(ctx); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		if s.u.headless {
+			return err
+		}
+		logger.Warn().Msg("自动选择视频文件失败，请在浏览器中手动选择视频文件")
+		logger.Info().Str("path", absVideoPath).Msg("视频文件路径")
+	}
 
-			logger.Info().Int("count", len(absSubtitlePaths)).Msg("尝试上传字幕文件...")
+	// 等待文件上传和处理
+	return sleepContext(ctx, 5*time.Second)
+}
 
-			// 查找字幕上传输入框
-			subtitleSelectors := []string{
-				`input[type="file"][accept*="srt"]`,
-				`input[type="file"][accept*="subtitle"]`,
-				`input.subtitle-input`,
-				`#subtitle-input`,
-				`input[accept*=".srt"]`,
-			}
+// stepPublish 设置封面与字幕、填写稿件信息并提交，最后尝试获取视频ID
+func (s *browserSteps) stepPublish(ctx context.Context, job *uploadJob) error {
+	if _, err := setUploadFiles(ctx, browserCoverSelectors, s.coverPath); err != nil {
+		// 与 HTTP 上传器一致：封面失败则跳过该视频
+		if s.u.headless {
+			return err
+		}
+		logger.Warn().Str("cover_path", s.coverPath).Msg("未找到封面上传输入框，请在浏览器中手动设置封面")
+	}
 
-			// 尝试上传每个字幕文件
-			for i, subPath := range absSubtitlePaths {
-				var uploaded bool
-				for _, selector := range subtitleSelectors {
-					if err := chromedp.SetUploadFiles(selector, []string{subPath}, chromedp.ByQuery).Do(ctx); err == nil {
-						logger.Info().Str("selector", selector).Str("file", subPath).Msg("字幕文件已上传")
-						uploaded = true
-						chromedp.Sleep(1 * time.Second)
-						break
-					}
-				}
+	for _, sub := range s.subtitles {
+		result := SubtitleResult{Lang: sub.Lang, LangID: sub.LangID}
+		if selector, err := setUploadFiles(ctx, browserSubtitleSelectors, sub.Path); err != nil {
+			result.Err = err
+			logger.Warn().Str("file", sub.Path).Str("lang", sub.Lang).Msg("字幕文件上传失败，可能需要手动上传")
+		} else {
+			logger.Info().Str("selector", selector).Str("file", sub.Path).Msg("字幕文件已上传")
+		}
+		job.subtitles = append(job.subtitles, result)
+	}
 
-				if !uploaded {
-					logger.Warn().Str("file", subPath).Int("index", i+1).Msg("字幕文件上传失败，可能需要手动上传")
-				}
-			}
+	meta := job.meta
+	if !meta.PublishAt.IsZero() {
+		logger.Warn().Time("publish_at", meta.PublishAt).Msg("chromedp 上传方式不支持定时发布，将立即发布")
+	}
+	if _, err := fillInput(ctx, browserTitleSelectors, meta.Title, true); err != nil {
+		if s.u.headless {
+			return err
+		}
+		logger.Warn().Msg("未找到标题输入框，可能需要手动填写")
+		logger.Info().Str("title", meta.Title).Msg("请使用此标题")
+	}
+	if meta.Desc != "" {
+		if _, err := fillInput(ctx, browserDescSelectors, meta.Desc, true); err != nil {
+			logger.Warn().Msg("未找到简介输入框，可能需要手动填写")
+		}
+	}
+	for _, tag := range meta.Tags {
+		if _, err := fillInput(ctx, browserTagSelectors, tag+"\n", false); err != nil {
+			logger.Warn().Strs("tags", meta.Tags).Msg("未找到标签输入框，可能需要手动填写")
+			break
+		}
+	}
 
-			return nil
-		}),
-		// 等待处理完成
-		chromedp.Sleep(10*time.Second),
-		// 步骤4: 尝试获取视频ID
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			logger.Info().Msg("尝试获取视频ID...")
-
-			// 尝试从URL中获取
-			var currentURL string
-			if err := chromedp.Evaluate(`window.location.href`, &currentURL).Do(ctx); err == nil {
-				logger.Debug().Str("url", currentURL).Msg("当前页面URL")
-				// 从URL中提取视频ID（如果在上传成功页面）
-				// 例如: https://www.bilibili.tv/en/video/av1234567890
-				if id := extractVideoIDFromURL(currentURL); id != "" {
-					videoID = id
-					logger.Info().Str("video_id", videoID).Msg("从URL获取到视频ID")
-					return nil
-				}
-			}
+	submitted := false
+	for _, selector := range browserSubmitSelectors {
+		if err := chromedp.Run(ctx, chromedp.Click(selector, chromedp.ByQuery)); err == nil {
+			logger.Info().Str("selector", selector).Msg("已点击提交按钮")
+			submitted = true
+			break
+		}
+	}
+	if !submitted {
+		if s.u.headless {
+			return fmt.Errorf("未找到提交按钮")
+		}
+		logger.Warn().Msg("未找到提交按钮，请在浏览器中手动提交")
+	}
 
-			// 尝试从页面元素中获取
-			idSelectors := []string{
-				`[data-video-id]`,
-				`[data-aid]`,
-				`.video-id`,
-				`#video-id`,
-			}
+	// 等待处理完成
+	if err := sleepContext(ctx, 10*time.Second); err != nil {
+		return err
+	}
+	job.aid = extractVideoID(ctx)
+	return nil
+}
 
-			for _, selector := range idSelectors {
-				var id string
-				if err := chromedp.TextContent(selector, &id, chromedp.ByQuery).Do(ctx); err == nil && id != "" {
-					videoID = id
-					logger.Info().Str("video_id", videoID).Str("selector", selector).Msg("从页面元素获取到视频ID")
-					return nil
-				}
-			}
+// setUploadFiles 依次尝试选择器，为第一个可用的文件输入框设置文件，返回使用的选择器
+func setUploadFiles(ctx context.Context, selectors []string, path string) (string, error) {
+	for _, selector := range selectors {
+		if err := chromedp.Run(ctx, chromedp.SetUploadFiles(selector, []string{path}, chromedp.ByQuery)); err == nil {
+			logger.Info().Str("selector", selector).Str("file", path).Msg("文件已选择")
+			sleepContext(ctx, 2*time.Second)
+			return selector, nil
+		}
+	}
+	return "", fmt.Errorf("未找到文件上传输入框: %s", filepath.Base(path))
+}
 
-			// 尝试从JavaScript变量中获取
-			var jsID string
-			jsCode := `
-				(function() {
-					if (window.__INITIAL_STATE__ && window.__INITIAL_STATE__.videoData && window.__INITIAL_STATE__.videoData.aid) {
-						return window.__INITIAL_STATE__.videoData.aid.toString();
-					}
-					if (window.aid) {
-						return window.aid.toString();
-					}
-					return '';
-				})()
-			`
-			if err := chromedp.Evaluate(jsCode, &jsID).Do(ctx); err == nil && jsID != "" {
-				videoID = jsID
-				logger.Info().Str("video_id", videoID).Msg("从JavaScript变量获取到视频ID")
-				return nil
-			}
+// fillInput 依次尝试选择器，在第一个可用的输入框中输入文本；clear 为 true 时先清空
+func fillInput(ctx context.Context, selectors []string, text string, clear bool) (string, error) {
+	for _, selector := range selectors {
+		if clear {
+			chromedp.Run(ctx, chromedp.Clear(selector, chromedp.ByQuery))
+		}
+		if err := chromedp.Run(ctx, chromedp.SendKeys(selector, text, chromedp.ByQuery)); err == nil {
+			logger.Debug().Str("selector", selector).Msg("已填写输入框")
+			sleepContext(ctx, 500*time.Millisecond)
+			return selector, nil
+		}
+	}
+	return "", fmt.Errorf("未找到输入框: %s", selectors[0])
+}
 
-			logger.Warn().Msg("未能自动获取视频ID，可能需要手动获取")
-			logger.Info().Msg("上传完成后，请在上传成功页面或视频页面查看视频ID（aid）")
-			logger.Info().Msg("然后可以使用 rename 命令重命名字幕文件")
+// extractVideoID 提交后从页面 URL、页面元素或 JavaScript 变量中获取视频ID，获取不到返回空
+func extractVideoID(ctx context.Context) string {
+	logger.Info().Msg("尝试获取视频ID...")
+
+	// 尝试从URL中获取
+	var currentURL string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(`window.location.href`, &currentURL)); err == nil {
+		logger.Debug().Str("url", currentURL).Msg("当前页面URL")
+		// 从URL中提取视频ID（如果在上传成功页面）
+		// 例如: https://www.bilibili.tv/en/video/av1234567890
+		if id := extractVideoIDFromURL(currentURL); id != "" {
+			logger.Info().Str("video_id", id).Msg("从URL获取到视频ID")
+			return id
+		}
+	}
 
-			return nil
-		}),
-		// 等待用户完成剩余操作（如果有）
-		chromedp.Sleep(5*time.Second),
-	)
+	// 尝试从页面元素中获取
+	idSelectors := []string{
+		`[data-video-id]`,
+		`[data-aid]`,
+		`.video-id`,
+		`#video-id`,
+	}
 
-	if err != nil {
-		return "", fmt.Errorf("上传过程出错: %w", err)
+	for _, selector := range idSelectors {
+		var id string
+		if err := chromedp.Run(ctx, chromedp.TextContent(selector, &id, chromedp.ByQuery)); err == nil && id != "" {
+			logger.Info().Str("video_id", id).Str("selector", selector).Msg("从页面元素获取到视频ID")
+			return id
+		}
 	}
 
-	if videoID == "" {
-		logger.Warn().Msg("未能获取视频ID，上传可能未完成或需要手动操作")
-		logger.Info().Msg("如果上传已完成，请手动获取视频ID（aid），然后使用 rename 命令重命名字幕文件")
-	} else {
-		logger.Info().Str("video_id", videoID).Msg("上传流程完成，已获取视频ID")
+	// 尝试从JavaScript变量中获取
+	var jsID string
+	jsCode := `
+		(function() {
+			if (window.__INITIAL_STATE__ && window.__INITIAL_STATE__.videoData && window.__INITIAL_STATE__.videoData.aid) {
+				return window.__INITIAL_STATE__.videoData.aid.toString();
+			}
+			if (window.aid) {
+				return window.aid.toString();
+			}
+			return '';
+		})()
+	`
+	if err := chromedp.Run(ctx, chromedp.Evaluate(jsCode, &jsID)); err == nil && jsID != "" {
+		logger.Info().Str("video_id", jsID).Msg("从JavaScript变量获取到视频ID")
+		return jsID
 	}
 
-	return videoID, nil
+	logger.Warn().Msg("未能自动获取视频ID，可能需要手动获取")
+	logger.Info().Msg("上传完成后，请在上传成功页面或视频页面查看视频ID（aid）")
+	logger.Info().Msg("然后可以使用 rename 命令重命名字幕文件")
+	return ""
 }
 
 // extractVideoIDFromURL 从URL中提取视频ID
@@ -0,0 +1,787 @@
+// Package bilibilitest 提供 bilibili.tv 投稿接口的本地替身服务器，用于不访问网络的上传测试。
+//
+// 服务器实现 HTTP 上传器用到的接口：创作中心上传页、preupload、UPOS 分块上传（初始化 / PUT / 合并）、
+// 完成上传、封面、字幕直传凭证与 OSS、发布、追加字幕、稿件列表与稿件状态。
+// 所有请求都会被记录，可按接口注入失败响应（HTTP 状态码、错误码、无法解析的响应体或断开连接）。
+package bilibilitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 上传器访问的固定域名，Transport 会把它们全部转发到本地服务器
+const (
+	UposHost = "upos-cs-upcdntxa.bilivideo.com"
+	OSSHost  = "bstar-subtitle.oss-ap-southeast-1.aliyuncs.com"
+)
+
+// 测试 cookies 中的值（bili_jct 即 CSRF token）
+const (
+	CSRFToken = "test-csrf-token"
+	SessData  = "test-sessdata"
+)
+
+// Endpoint 请求所属的接口
+type Endpoint string
+
+const (
+	EndpointUploadPage    Endpoint = "upload_page"    // GET studio.bilibili.tv/archive/new
+	EndpointPreupload     Endpoint = "preupload"      // GET /preupload
+	EndpointUploadInit    Endpoint = "upload_init"    // POST /iupever/{filename}?uploads
+	EndpointChunk         Endpoint = "chunk"          // PUT /iupever/{filename}?partNumber=
+	EndpointFinalize      Endpoint = "finalize"       // POST /iupever/{filename}?uploadId=
+	EndpointComplete      Endpoint = "complete"       // POST /intl/videoup/web2/uploading
+	EndpointCover         Endpoint = "cover"          // POST /intl/videoup/web2/cover
+	EndpointSubtitleToken Endpoint = "subtitle_token" // GET /intl/videoup/web2/upload/token
+	EndpointSubtitleOSS   Endpoint = "subtitle_oss"   // POST https://{OSSHost}/
+	EndpointPublish       Endpoint = "publish"        // POST /intl/videoup/web2/add
+	EndpointSubtitleAdd   Endpoint = "subtitle_add"   // POST /intl/videoup/web2/subtitle/add
+	EndpointArchives      Endpoint = "archives"       // GET /intl/videoup/web2/archives
+	EndpointArchiveView   Endpoint = "archive_view"   // GET /intl/videoup/web2/archive/view
+)
+
+// Request 服务器收到的一个请求
+type Request struct {
+	Endpoint Endpoint
+	Method   string
+	Host     string // 上传器请求的原始域名
+	Path     string
+	Query    url.Values
+	Header   http.Header
+	Body     []byte
+	Faulted  bool // 是否返回了注入的失败响应
+}
+
+// Cookie 返回请求 Cookie 头中指定名称的值
+func (r Request) Cookie(name string) string {
+	req := http.Request{Header: r.Header}
+	if c, err := req.Cookie(name); err == nil {
+		return c.Value
+	}
+	return ""
+}
+
+// JSON 将请求体解析为 JSON 对象
+func (r Request) JSON() map[string]any {
+	var m map[string]any
+	_ = json.Unmarshal(r.Body, &m)
+	return m
+}
+
+// Fault 注入的失败响应
+type Fault struct {
+	Status    int                // HTTP 状态码，0 表示 200
+	Body      string             // 响应体（原样返回，可以是无法解析的内容）
+	Times     int                // 生效次数，0 表示 1 次，<0 表示一直生效
+	CloseConn bool               // 不返回响应，直接断开连接
+	Apply     bool               // 请求照常处理（如创建稿件），只是返回注入的响应
+	When      func(Request) bool // 只对满足条件的请求生效，nil 表示该接口的所有请求
+}
+
+// Archive 通过发布接口创建的稿件
+type Archive struct {
+	AID            string
+	Title          string
+	Desc           string
+	Tags           string
+	Cover          string
+	Filename       string // 发布请求中的 filename（不含后缀）
+	PlaylistID     string
+	Owner          string         // 发布请求的 SESSDATA，即稿件所属账号
+	DTime          int64          // 定时发布时间，0 表示立即发布
+	SubtitleURL    string         // 随发布请求提交的字幕
+	SubtitleLangID int            // 随发布请求提交的字幕语言
+	Subtitles      map[int]string // 发布后追加的字幕：语言 ID → 字幕 key
+	State          int            // 稿件状态码（archive/view 与稿件列表返回），默认 -1 审核中
+	RejectReason   string
+	CreatedAt      time.Time
+}
+
+type upload struct {
+	filename string
+	owner    string // 初始化上传请求的 SESSDATA
+	parts    map[int][]byte
+}
+
+// Server bilibili.tv 替身服务器
+type Server struct {
+	// URL 本地服务器地址
+	URL string
+	// Auth preupload 返回的 auth，分块请求的 X-Upos-Auth 必须与之一致
+	Auth string
+	// ChunkSize preupload 返回的分块大小
+	ChunkSize int64
+	// NumericAID 发布接口以数字（而不是字符串）返回 aid
+	NumericAID bool
+	// ChunkDelay 分块请求处理前的等待时间（按分块序号），用于让并发上传的分块乱序完成；nil 表示不等待
+	ChunkDelay func(partNumber int) time.Duration
+
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	requests  []Request
+	faults    map[Endpoint][]*Fault
+	uploads   map[string]*upload // upload_id → 分块
+	files     map[string][]byte  // 合并后的视频：服务端文件名 → 内容
+	owners    map[string]string  // 合并后的视频：服务端文件名 → 上传账号的 SESSDATA
+	completed map[string]bool    // 已调用完成上传接口的文件名
+	objects   map[string][]byte  // OSS 中的字幕：key → 内容
+	archives  []*Archive
+	nextID    int
+
+	chunksInFlight int // 正在处理的分块请求数
+	maxChunks      int // 同时处理的分块请求数的最大值
+}
+
+// NewServer 启动替身服务器，使用完毕后调用 Close
+func NewServer() *Server {
+	s := &Server{
+		Auth:      "upos-auth-signature",
+		ChunkSize: 1024,
+		faults:    make(map[Endpoint][]*Fault),
+		uploads:   make(map[string]*upload),
+		files:     make(map[string][]byte),
+		owners:    make(map[string]string),
+		completed: make(map[string]bool),
+		objects:   make(map[string][]byte),
+		nextID:    1,
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close 关闭服务器
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Transport 把任意域名的请求转发到本地服务器（保留原始 Host），用于替换上传器的 http.Client
+func (s *Server) Transport() http.RoundTripper {
+	target, _ := url.Parse(s.srv.URL)
+	return &routingTransport{target: target, base: s.srv.Client().Transport}
+}
+
+// Client 使用 Transport 的 http.Client
+func (s *Server) Client() *http.Client {
+	return &http.Client{Transport: s.Transport()}
+}
+
+type routingTransport struct {
+	target *url.URL
+	base   http.RoundTripper
+}
+
+func (t *routingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.Host = req.URL.Host
+	out.URL.Scheme = t.target.Scheme
+	out.URL.Host = t.target.Host
+	return t.base.RoundTrip(out)
+}
+
+// Inject 为接口注入失败响应，按注入顺序依次生效
+func (s *Server) Inject(endpoint Endpoint, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if fault.Times == 0 {
+		fault.Times = 1
+	}
+	s.faults[endpoint] = append(s.faults[endpoint], &fault)
+}
+
+// Requests 返回接口收到的请求（endpoint 为空返回全部），按到达顺序排列
+func (s *Server) Requests(endpoint Endpoint) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Request
+	for _, r := range s.requests {
+		if endpoint == "" || r.Endpoint == endpoint {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Endpoints 返回所有请求的接口序列，便于断言调用顺序
+func (s *Server) Endpoints() []Endpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Endpoint, 0, len(s.requests))
+	for _, r := range s.requests {
+		out = append(out, r.Endpoint)
+	}
+	return out
+}
+
+// Archives 返回已发布的稿件
+func (s *Server) Archives() []Archive {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Archive, 0, len(s.archives))
+	for _, a := range s.archives {
+		out = append(out, *a)
+	}
+	return out
+}
+
+// SetArchiveState 修改稿件状态码与退回原因
+func (s *Server) SetArchiveState(aid string, state int, rejectReason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a := s.findArchive(aid); a != nil {
+		a.State = state
+		a.RejectReason = rejectReason
+	}
+}
+
+// File 返回合并后的视频内容（按服务端文件名，不含后缀也可）
+func (s *Server) File(filename string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name, ok := s.lookupFile(filename)
+	if !ok {
+		return nil, false
+	}
+	return s.files[name], true
+}
+
+// Object 返回直传到 OSS 的字幕内容
+func (s *Server) Object(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[key]
+	return data, ok
+}
+
+func endpointOf(r *http.Request) Endpoint {
+	urlPath := r.URL.Path
+	switch {
+	case r.Host == OSSHost:
+		return EndpointSubtitleOSS
+	case urlPath == "/archive/new":
+		return EndpointUploadPage
+	case urlPath == "/preupload":
+		return EndpointPreupload
+	case strings.HasPrefix(urlPath, "/iupever/"):
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodPut:
+			return EndpointChunk
+		case query.Has("uploads"):
+			return EndpointUploadInit
+		case query.Get("uploadId") != "":
+			return EndpointFinalize
+		}
+	case urlPath == "/intl/videoup/web2/uploading":
+		return EndpointComplete
+	case urlPath == "/intl/videoup/web2/cover":
+		return EndpointCover
+	case urlPath == "/intl/videoup/web2/upload/token":
+		return EndpointSubtitleToken
+	case urlPath == "/intl/videoup/web2/add":
+		return EndpointPublish
+	case urlPath == "/intl/videoup/web2/subtitle/add":
+		return EndpointSubtitleAdd
+	case urlPath == "/intl/videoup/web2/archives":
+		return EndpointArchives
+	case urlPath == "/intl/videoup/web2/archive/view":
+		return EndpointArchiveView
+	}
+	return ""
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := Request{
+		Endpoint: endpointOf(r),
+		Method:   r.Method,
+		Host:     r.Host,
+		Path:     r.URL.Path,
+		Query:    r.URL.Query(),
+		Header:   r.Header.Clone(),
+		Body:     body,
+	}
+
+	s.mu.Lock()
+	fault := s.takeFault(req)
+	req.Faulted = fault != nil
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	if req.Endpoint == EndpointChunk {
+		defer s.trackChunk(req)()
+	}
+	if fault != nil && !fault.Apply {
+		serveFault(w, fault)
+		return
+	}
+	if fault != nil {
+		defer serveFault(w, fault)
+		w = httptest.NewRecorder()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch req.Endpoint {
+	case EndpointUploadPage:
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = io.WriteString(w, "<html><body>upload</body></html>")
+	case EndpointPreupload:
+		s.preupload(w, req)
+	case EndpointUploadInit:
+		s.uploadInit(w, req)
+	case EndpointChunk:
+		s.chunk(w, req)
+	case EndpointFinalize:
+		s.finalize(w, req)
+	case EndpointComplete:
+		s.complete(w, req)
+	case EndpointCover:
+		s.cover(w, r, req)
+	case EndpointSubtitleToken:
+		s.subtitleToken(w, req)
+	case EndpointSubtitleOSS:
+		s.subtitleOSS(w, r, req)
+	case EndpointPublish:
+		s.publish(w, req)
+	case EndpointSubtitleAdd:
+		s.subtitleAdd(w, req)
+	case EndpointArchives:
+		s.listArchives(w, req)
+	case EndpointArchiveView:
+		s.viewArchive(w, req)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// trackChunk 记录同时处理的分块请求数，并按 ChunkDelay 等待；返回结束时调用的函数
+func (s *Server) trackChunk(req Request) func() {
+	s.mu.Lock()
+	s.chunksInFlight++
+	if s.chunksInFlight > s.maxChunks {
+		s.maxChunks = s.chunksInFlight
+	}
+	s.mu.Unlock()
+	if s.ChunkDelay != nil {
+		part, _ := strconv.Atoi(req.Query.Get("partNumber"))
+		time.Sleep(s.ChunkDelay(part))
+	}
+	return func() {
+		s.mu.Lock()
+		s.chunksInFlight--
+		s.mu.Unlock()
+	}
+}
+
+// MaxConcurrentChunks 同时处理的分块请求数的最大值
+func (s *Server) MaxConcurrentChunks() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.maxChunks
+}
+
+// takeFault 取出第一个匹配该请求的注入失败（调用方持有锁）
+func (s *Server) takeFault(req Request) *Fault {
+	faults := s.faults[req.Endpoint]
+	for i, f := range faults {
+		if f.When != nil && !f.When(req) {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults[req.Endpoint] = append(faults[:i:i], faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+func serveFault(w http.ResponseWriter, f *Fault) {
+	if f.CloseConn {
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+	status := f.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, f.Body)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeCode 返回 B站风格的 {"code","message","data"} 响应
+func writeCode(w http.ResponseWriter, code int, message string, data any) {
+	writeJSON(w, map[string]any{"code": code, "message": message, "data": data})
+}
+
+// loggedIn API 请求需要 SESSDATA cookie，写操作还需要 csrf 参数与 bili_jct 一致
+func loggedIn(w http.ResponseWriter, req Request, checkCSRF bool) bool {
+	if req.Cookie("SESSDATA") == "" {
+		writeCode(w, -101, "账号未登录", nil)
+		return false
+	}
+	if checkCSRF && (req.Query.Get("csrf") == "" || req.Query.Get("csrf") != req.Cookie("bili_jct")) {
+		writeCode(w, -111, "csrf 校验失败", nil)
+		return false
+	}
+	return true
+}
+
+func (s *Server) preupload(w http.ResponseWriter, req Request) {
+	if req.Cookie("SESSDATA") == "" {
+		writeJSON(w, map[string]any{"OK": 0, "code": -101, "message": "账号未登录"})
+		return
+	}
+	name := req.Query.Get("name")
+	writeJSON(w, map[string]any{
+		"OK":         1,
+		"auth":       s.Auth,
+		"endpoint":   "//" + UposHost,
+		"endpoints":  []string{"//" + UposHost},
+		"chunk_size": s.ChunkSize,
+		"put_query":  "os=upos&profile=iup%2Fbup",
+		"upos_uri":   "upos://iupever/" + name,
+		"biz_id":     0,
+	})
+}
+
+func (s *Server) uploadInit(w http.ResponseWriter, req Request) {
+	if req.Header.Get("X-Upos-Auth") != s.Auth {
+		http.Error(w, `{"OK":0,"message":"invalid auth"}`, http.StatusForbidden)
+		return
+	}
+	id := fmt.Sprintf("upload-%d", s.nextID)
+	s.nextID++
+	filename := strings.TrimPrefix(req.Path, "/iupever/")
+	s.uploads[id] = &upload{filename: filename, owner: req.Cookie("SESSDATA"), parts: make(map[int][]byte)}
+	writeJSON(w, map[string]any{"OK": 1, "bucket": "iupever", "key": "/" + filename, "upload_id": id})
+}
+
+func (s *Server) chunk(w http.ResponseWriter, req Request) {
+	up, ok := s.uploads[req.Query.Get("uploadId")]
+	if !ok {
+		http.Error(w, `{"OK":0,"message":"no such upload"}`, http.StatusNotFound)
+		return
+	}
+	if req.Header.Get("X-Upos-Auth") != s.Auth {
+		http.Error(w, `{"OK":0,"message":"invalid auth"}`, http.StatusForbidden)
+		return
+	}
+	part, err := strconv.Atoi(req.Query.Get("partNumber"))
+	if err != nil || part < 1 {
+		http.Error(w, `{"OK":0,"message":"invalid partNumber"}`, http.StatusBadRequest)
+		return
+	}
+	up.parts[part] = req.Body
+	w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, part))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) finalize(w http.ResponseWriter, req Request) {
+	up, ok := s.uploads[req.Query.Get("uploadId")]
+	if !ok {
+		http.Error(w, `{"OK":0,"message":"no such upload"}`, http.StatusNotFound)
+		return
+	}
+	var body struct {
+		Parts []struct {
+			PartNumber int    `json:"partNumber"`
+			ETag       string `json:"eTag"`
+		} `json:"parts"`
+	}
+	if err := json.Unmarshal(req.Body, &body); err != nil || len(body.Parts) == 0 {
+		http.Error(w, `{"OK":0,"message":"invalid parts"}`, http.StatusBadRequest)
+		return
+	}
+	sort.Slice(body.Parts, func(i, j int) bool { return body.Parts[i].PartNumber < body.Parts[j].PartNumber })
+	var data bytes.Buffer
+	for i, p := range body.Parts {
+		chunk, ok := up.parts[p.PartNumber]
+		if !ok || p.PartNumber != i+1 || p.ETag != fmt.Sprintf("etag-%d", p.PartNumber) {
+			http.Error(w, fmt.Sprintf(`{"OK":0,"message":"part %d missing"}`, p.PartNumber), http.StatusBadRequest)
+			return
+		}
+		data.Write(chunk)
+	}
+	if len(body.Parts) != len(up.parts) {
+		http.Error(w, `{"OK":0,"message":"part count mismatch"}`, http.StatusBadRequest)
+		return
+	}
+	s.files[up.filename] = data.Bytes()
+	s.owners[up.filename] = up.owner
+	writeJSON(w, map[string]any{"OK": 1, "bucket": "iupever", "key": "/" + up.filename, "location": "upos://iupever/" + up.filename})
+}
+
+func (s *Server) complete(w http.ResponseWriter, req Request) {
+	if !loggedIn(w, req, false) {
+		return
+	}
+	form, _ := url.ParseQuery(string(req.Body))
+	s.completed[form.Get("filename")] = true
+	writeCode(w, 0, "0", nil)
+}
+
+func (s *Server) cover(w http.ResponseWriter, r *http.Request, req Request) {
+	if !loggedIn(w, req, false) {
+		return
+	}
+	fields, _ := parseMultipart(r.Header.Get("Content-Type"), req.Body)
+	if !strings.HasPrefix(string(fields["cover"]), "data:image/") {
+		writeCode(w, -400, "封面格式错误", nil)
+		return
+	}
+	id := s.nextID
+	s.nextID++
+	writeCode(w, 0, "0", map[string]any{"url": fmt.Sprintf("https://pic.bstarstatic.com/ugc/cover-%d.jpg", id)})
+}
+
+func (s *Server) subtitleToken(w http.ResponseWriter, req Request) {
+	if !loggedIn(w, req, false) {
+		return
+	}
+	id := s.nextID
+	s.nextID++
+	writeCode(w, 0, "0", map[string]any{
+		"host":                  "https://" + OSSHost,
+		"key":                   fmt.Sprintf("ugc/subtitle/%d_subtitle-%d.json", id, id),
+		"OSSAccessKeyId":        "test-access-key",
+		"policy":                "eyJjb25kaXRpb25zIjpbXX0=",
+		"signature":             "test-signature",
+		"success_action_status": "200",
+	})
+}
+
+func (s *Server) subtitleOSS(w http.ResponseWriter, r *http.Request, req Request) {
+	fields, err := parseMultipart(r.Header.Get("Content-Type"), req.Body)
+	if err != nil || fields["key"] == nil || fields["file"] == nil || string(fields["OSSAccessKeyId"]) != "test-access-key" {
+		http.Error(w, "<Error><Code>InvalidArgument</Code></Error>", http.StatusBadRequest)
+		return
+	}
+	s.objects[string(fields["key"])] = fields["file"]
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) publish(w http.ResponseWriter, req Request) {
+	if !loggedIn(w, req, true) {
+		return
+	}
+	var body map[string]any
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		writeCode(w, -400, "请求错误", nil)
+		return
+	}
+	str := func(key string) string {
+		v, _ := body[key].(string)
+		return v
+	}
+	filename := str("filename")
+	name, ok := s.lookupFile(filename)
+	if !ok || !s.completed[name] {
+		writeCode(w, 21001, "视频文件不存在或未完成上传", nil)
+		return
+	}
+	// 只能发布本账号上传的视频文件
+	if s.owners[name] != req.Cookie("SESSDATA") {
+		writeCode(w, 21004, "视频文件不属于当前账号", nil)
+		return
+	}
+	if str("title") == "" || str("cover") == "" {
+		writeCode(w, 21002, "标题与封面不能为空", nil)
+		return
+	}
+	subtitleURL := str("subtitle_url")
+	if subtitleURL != "" {
+		if _, ok := s.objects[subtitleURL]; !ok {
+			writeCode(w, 21003, "字幕不存在", nil)
+			return
+		}
+	}
+
+	a := &Archive{
+		AID:         strconv.Itoa(100000 + len(s.archives) + 1),
+		Title:       str("title"),
+		Desc:        str("desc"),
+		Tags:        str("tag"),
+		Cover:       str("cover"),
+		Filename:    filename,
+		PlaylistID:  str("playlist_id"),
+		Owner:       req.Cookie("SESSDATA"),
+		SubtitleURL: subtitleURL,
+		Subtitles:   make(map[int]string),
+		State:       -1,
+		CreatedAt:   time.Now(),
+	}
+	if id, ok := body["subtitle_lang_id"].(float64); ok {
+		a.SubtitleLangID = int(id)
+	}
+	if dtime, ok := body["dtime"].(float64); ok {
+		a.DTime = int64(dtime)
+	}
+	s.archives = append(s.archives, a)
+	if s.NumericAID {
+		aid, _ := strconv.ParseInt(a.AID, 10, 64)
+		writeCode(w, 0, "0", map[string]any{"aid": aid})
+		return
+	}
+	writeCode(w, 0, "0", map[string]any{"aid": a.AID})
+}
+
+func (s *Server) subtitleAdd(w http.ResponseWriter, req Request) {
+	if !loggedIn(w, req, true) {
+		return
+	}
+	body := req.JSON()
+	aid, _ := body["aid"].(string)
+	key, _ := body["subtitle_url"].(string)
+	langID, _ := body["subtitle_lang_id"].(float64)
+	a := s.findArchive(aid)
+	if a == nil {
+		writeCode(w, -404, "稿件不存在", nil)
+		return
+	}
+	if _, ok := s.objects[key]; !ok || langID <= 0 {
+		writeCode(w, 21003, "字幕不存在", nil)
+		return
+	}
+	a.Subtitles[int(langID)] = key
+	writeCode(w, 0, "0", nil)
+}
+
+func (s *Server) listArchives(w http.ResponseWriter, req Request) {
+	if !loggedIn(w, req, false) {
+		return
+	}
+	pn, _ := strconv.Atoi(req.Query.Get("pn"))
+	ps, _ := strconv.Atoi(req.Query.Get("ps"))
+	if pn < 1 {
+		pn = 1
+	}
+	if ps < 1 {
+		ps = 20
+	}
+	items := make([]map[string]any, 0, ps)
+	// 与创作中心一致：最新的稿件在前
+	for i := len(s.archives) - 1 - (pn-1)*ps; i >= 0 && len(items) < ps; i-- {
+		items = append(items, archiveJSON(s.archives[i]))
+	}
+	writeCode(w, 0, "0", map[string]any{
+		"archives": items,
+		"page":     map[string]any{"pn": pn, "ps": ps, "total": len(s.archives)},
+	})
+}
+
+func (s *Server) viewArchive(w http.ResponseWriter, req Request) {
+	if !loggedIn(w, req, false) {
+		return
+	}
+	a := s.findArchive(req.Query.Get("aid"))
+	if a == nil {
+		http.Error(w, `{"code":-404,"message":"稿件不存在"}`, http.StatusNotFound)
+		return
+	}
+	writeCode(w, 0, "0", map[string]any{"archive": archiveJSON(a)})
+}
+
+func archiveJSON(a *Archive) map[string]any {
+	return map[string]any{
+		"aid":           a.AID,
+		"title":         a.Title,
+		"desc":          a.Desc,
+		"cover":         a.Cover,
+		"state":         a.State,
+		"reject_reason": a.RejectReason,
+		"ctime":         a.CreatedAt.Unix(),
+		"subtitles":     archiveSubtitles(a),
+	}
+}
+
+// archiveSubtitles 稿件上的字幕（发布时提交的与发布后追加的），按语言 ID 排序
+func archiveSubtitles(a *Archive) []map[string]any {
+	subtitles := make(map[int]string, len(a.Subtitles)+1)
+	if a.SubtitleLangID > 0 {
+		subtitles[a.SubtitleLangID] = a.SubtitleURL
+	}
+	for id, key := range a.Subtitles {
+		subtitles[id] = key
+	}
+	ids := make([]int, 0, len(subtitles))
+	for id := range subtitles {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	list := make([]map[string]any, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, map[string]any{"lang_id": id, "subtitle_url": subtitles[id]})
+	}
+	return list
+}
+
+func (s *Server) findArchive(aid string) *Archive {
+	for _, a := range s.archives {
+		if a.AID == aid {
+			return a
+		}
+	}
+	return nil
+}
+
+// lookupFile 按服务端文件名查找合并后的视频，发布请求中的 filename 不含后缀
+func (s *Server) lookupFile(filename string) (string, bool) {
+	if _, ok := s.files[filename]; ok {
+		return filename, true
+	}
+	for name := range s.files {
+		if ext := path.Ext(name); ext != "" && strings.TrimSuffix(name, ext) == filename {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// parseMultipart 解析 multipart/form-data 请求体，返回字段名 → 内容
+func parseMultipart(contentType string, body []byte) (map[string][]byte, error) {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, err
+	}
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	fields := make(map[string][]byte)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return fields, nil
+		}
+		if err != nil {
+			return fields, err
+		}
+		data, _ := io.ReadAll(part)
+		fields[part.FormName()] = data
+	}
+}
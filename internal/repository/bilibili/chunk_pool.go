@@ -0,0 +1,305 @@
+package bilibili
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+	"blueberry/pkg/utils"
+
+	"github.com/rs/zerolog"
+)
+
+// 自适应退避：5xx / 429 / 超时后所有 worker 在领取下一个分块前额外等待 penalty，
+// 每次失败翻倍（最长 maxChunkPenalty），每次成功减半
+const (
+	minChunkPenalty = time.Second
+	maxChunkPenalty = 60 * time.Second
+)
+
+// chunkPool 并发上传一个视频的分块，协调共享的会话进度、重试预算与自适应退避
+type chunkPool struct {
+	u           *httpUploader
+	videoFile   *os.File
+	videoDir    string
+	session     *file.UploadSession
+	maxRetries  int // 单个分块最多尝试次数（bilibili.chunk_upload_retries）
+	backoffBase int // 第 n 次重试等待 n*backoffBase 秒（bilibili.chunk_retry_backoff_seconds）
+
+	mu          sync.Mutex
+	budget      int // 剩余的共享重试次数，<0 表示不限制
+	penalty     time.Duration
+	uploaded    int64
+	uploadStart time.Time
+}
+
+// uploadConcurrency 同时上传的分块数量（bilibili.upload_concurrency，默认 1）
+func uploadConcurrency(cfg *config.Config) int {
+	if cfg == nil || cfg.Bilibili.UploadConcurrency < 1 {
+		return 1
+	}
+	return cfg.Bilibili.UploadConcurrency
+}
+
+// uploadChunks 上传会话中尚未完成的分块，每完成一个分块持久化一次进度
+// bilibili.upload_concurrency > 1 时多个分块并行上传；任一分块最终失败会取消其余分块
+func (u *httpUploader) uploadChunks(ctx context.Context, videoFile *os.File, videoDir string, session *file.UploadSession) error {
+	cfg := config.Get()
+	p := &chunkPool{
+		u:           u,
+		videoFile:   videoFile,
+		videoDir:    videoDir,
+		session:     session,
+		maxRetries:  3,
+		backoffBase: 1,
+		budget:      -1,
+		uploadStart: time.Now(),
+	}
+	if cfg != nil {
+		if cfg.Bilibili.ChunkUploadRetries > 0 {
+			p.maxRetries = cfg.Bilibili.ChunkUploadRetries
+		}
+		if cfg.Bilibili.ChunkRetryBackoffSeconds > 0 {
+			p.backoffBase = cfg.Bilibili.ChunkRetryBackoffSeconds
+		}
+		if cfg.Bilibili.UploadRetryBudget > 0 {
+			p.budget = cfg.Bilibili.UploadRetryBudget
+		}
+	}
+
+	var pending []int
+	for partNumber := 1; partNumber <= session.Chunks; partNumber++ {
+		if !session.HasPart(partNumber) {
+			pending = append(pending, partNumber)
+		}
+	}
+	if len(session.Parts) > 0 {
+		logger.Info().
+			Int("completed_parts", len(session.Parts)).
+			Int("chunks", session.Chunks).
+			Msg("跳过已上传的分块，从第一个缺失的分块继续")
+	}
+
+	workers := uploadConcurrency(cfg)
+	logger.Info().
+		Int("pending_chunks", len(pending)).
+		Int("concurrency", workers).
+		Int("retry_budget", p.budget).
+		Msg("开始分块上传")
+
+	poolCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	utils.RunWorkers(poolCtx, workers, len(pending), func(ctx context.Context, worker, i int) {
+		if err := p.uploadPart(ctx, worker, pending[i]); err != nil {
+			cancel(err)
+		}
+	})
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err := context.Cause(poolCtx); err != nil {
+		return err
+	}
+	elapsed := time.Since(p.uploadStart)
+	logger.Info().
+		Int("chunks", len(pending)).
+		Int64("bytes", p.uploaded).
+		Dur("elapsed", elapsed).
+		Float64("mb_per_sec", throughputMBps(p.uploaded, elapsed)).
+		Msg("所有分块上传完成")
+	return nil
+}
+
+// uploadPart 上传单个分块（带重试），成功后记录进度
+func (p *chunkPool) uploadPart(ctx context.Context, worker, partNumber int) error {
+	session := p.session
+	chunk := partNumber - 1
+	start := int64(chunk) * session.ChunkSize
+	end := start + session.ChunkSize
+	if end > session.FileSize {
+		end = session.FileSize
+	}
+
+	chunkData := make([]byte, end-start)
+	if _, err := p.videoFile.ReadAt(chunkData, start); err != nil {
+		return fmt.Errorf("读取分块 %d 失败: %w", partNumber, err)
+	}
+
+	chunkURL := fmt.Sprintf("%s/iupever/%s?partNumber=%d&uploadId=%s&chunk=%d&chunks=%d&size=%d&start=%d&end=%d&total=%d",
+		session.Endpoint, session.Filename, partNumber, session.UploadID, chunk, session.Chunks, end-start, start, end, session.FileSize)
+
+	log := logger.Logger().With().Int("worker", worker).Int("chunk", partNumber).Int("total", session.Chunks).Logger()
+	if session.Auth == "" {
+		log.Warn().Msg("分块上传时未设置 X-Upos-Auth header")
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= p.maxRetries; attempt++ {
+		if err := p.waitPenalty(ctx); err != nil {
+			return err
+		}
+
+		// 每次尝试重新创建请求（body 读取后不可复用）
+		req, err := http.NewRequestWithContext(ctx, "PUT", chunkURL, bytes.NewReader(chunkData))
+		if err != nil {
+			return fmt.Errorf("创建分块上传请求失败: %w", err)
+		}
+		p.u.setHeaders(req)
+		req.Header.Set("Content-Type", "application/octet-stream")
+		req.Header.Set("Content-Length", fmt.Sprintf("%d", len(chunkData)))
+		if session.Auth != "" {
+			req.Header.Set("X-Upos-Auth", session.Auth)
+		}
+
+		began := time.Now()
+		resp, err := p.u.httpClient.Do(req)
+		if err == nil && (resp.StatusCode == 200 || resp.StatusCode == 204) {
+			etag := strings.Trim(resp.Header.Get("ETag"), `"`)
+			resp.Body.Close()
+			elapsed := time.Since(began)
+			p.onSuccess(partNumber, etag, end-start)
+			log.Info().
+				Int("attempt", attempt).
+				Int64("size", end-start).
+				Dur("elapsed", elapsed).
+				Float64("mb_per_sec", throughputMBps(end-start, elapsed)).
+				Msg("分块上传完成")
+			// 同一连接上的分块之间添加短暂延迟，避免连接被关闭
+			if partNumber < session.Chunks {
+				return sleepContext(ctx, 500*time.Millisecond)
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return ctx.Err()
+		}
+
+		// 生成错误信息
+		statusCode := 0
+		if resp != nil {
+			statusCode = resp.StatusCode
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			lastErr = fmt.Errorf("HTTP %d, 响应: %s", statusCode, previewForLog(string(bodyBytes), 300))
+		} else {
+			lastErr = err
+		}
+		// upload_id 过期或被清理时 UPOS 返回 404 / 403，重试无意义
+		if statusCode == http.StatusNotFound || statusCode == http.StatusForbidden {
+			return fmt.Errorf("上传分块 %d 失败: %v: %w", partNumber, lastErr, errUploadSessionExpired)
+		}
+		if isThrottleError(statusCode, err) {
+			p.onThrottle(log)
+		}
+		if attempt == p.maxRetries {
+			break
+		}
+		if !p.takeRetry() {
+			return fmt.Errorf("上传分块 %d 失败，分块上传重试预算（bilibili.upload_retry_budget）已用尽: %w", partNumber, lastErr)
+		}
+
+		waitTime := time.Duration(attempt*p.backoffBase) * time.Second
+		log.Warn().
+			Int("attempt", attempt).
+			Int("max", p.maxRetries).
+			Dur("wait", waitTime).
+			Err(lastErr).
+			Msg("分块上传失败，准备重试")
+		if err := sleepContext(ctx, waitTime); err != nil {
+			return err
+		}
+	}
+	// 已用尽重试
+	return fmt.Errorf("上传分块 %d 失败（已重试 %d 次）: %w", partNumber, p.maxRetries, lastErr)
+}
+
+// onSuccess 记录完成的分块并持久化（按分块序号排序，供合并请求使用）；自适应退避减半
+func (p *chunkPool) onSuccess(partNumber int, etag string, size int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.session.Parts = append(p.session.Parts, file.UploadPart{PartNumber: partNumber, ETag: etag})
+	sort.Slice(p.session.Parts, func(i, j int) bool {
+		return p.session.Parts[i].PartNumber < p.session.Parts[j].PartNumber
+	})
+	p.u.saveUploadSession(p.videoDir, p.session)
+	p.uploaded += size
+
+	p.penalty /= 2
+	if p.penalty < minChunkPenalty {
+		p.penalty = 0
+	}
+}
+
+// onThrottle 服务端过载（5xx / 429）或超时：加大所有 worker 共享的退避时间
+func (p *chunkPool) onThrottle(log zerolog.Logger) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case p.penalty == 0:
+		p.penalty = minChunkPenalty
+	case p.penalty < maxChunkPenalty:
+		p.penalty *= 2
+		if p.penalty > maxChunkPenalty {
+			p.penalty = maxChunkPenalty
+		}
+	}
+	log.Warn().Dur("penalty", p.penalty).Msg("上传服务端过载或超时，所有分块放慢上传")
+}
+
+// waitPenalty 在发起请求前等待当前的自适应退避时间
+func (p *chunkPool) waitPenalty(ctx context.Context) error {
+	p.mu.Lock()
+	penalty := p.penalty
+	p.mu.Unlock()
+	if penalty <= 0 {
+		return nil
+	}
+	return sleepContext(ctx, penalty)
+}
+
+// takeRetry 从共享重试预算中扣除一次，预算用尽返回 false
+func (p *chunkPool) takeRetry() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.budget < 0 {
+		return true
+	}
+	if p.budget == 0 {
+		return false
+	}
+	p.budget--
+	return true
+}
+
+// isThrottleError 是否为需要整体放慢的错误：5xx、429 或网络超时
+func isThrottleError(statusCode int, err error) bool {
+	if statusCode >= 500 || statusCode == http.StatusTooManyRequests {
+		return true
+	}
+	var netErr net.Error
+	return err != nil && (errors.As(err, &netErr) && netErr.Timeout() || errors.Is(err, context.DeadlineExceeded))
+}
+
+// throughputMBps 计算吞吐量（MB/s）
+func throughputMBps(size int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(size) / (1024 * 1024) / elapsed.Seconds()
+}
@@ -0,0 +1,114 @@
+package bilibili
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"blueberry/internal/repository/bilibili/bilibilitest"
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+)
+
+// 过载 / 超时时共享的退避时间翻倍（最长 maxChunkPenalty），分块成功时减半，低于 minChunkPenalty 时归零
+func TestChunkPoolPenaltyDoublesAndHalves(t *testing.T) {
+	p := &chunkPool{u: &httpUploader{}, session: &file.UploadSession{}}
+	log := logger.Logger()
+
+	var got []time.Duration
+	for i := 0; i < 8; i++ {
+		p.onThrottle(log)
+		got = append(got, p.penalty)
+	}
+	want := []time.Duration{1, 2, 4, 8, 16, 32, 60, 60}
+	for i := range want {
+		if got[i] != want[i]*time.Second {
+			t.Fatalf("第 %d 次过载后 penalty = %s，期望 %s（依次为 %v）", i+1, got[i], want[i]*time.Second, got)
+		}
+	}
+
+	for i, w := range []time.Duration{30 * time.Second, 15 * time.Second, 7500 * time.Millisecond} {
+		p.onSuccess(i+1, "etag", 1)
+		if p.penalty != w {
+			t.Fatalf("第 %d 次成功后 penalty = %s，期望 %s", i+1, p.penalty, w)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		p.onSuccess(i+4, "etag", 1)
+	}
+	if p.penalty != 0 {
+		t.Fatalf("penalty = %s，低于 %s 时应归零", p.penalty, minChunkPenalty)
+	}
+	for i, part := range p.session.Parts {
+		if part.PartNumber != i+1 {
+			t.Fatalf("会话中的分块 = %+v，期望按序号排列", p.session.Parts)
+		}
+	}
+}
+
+// 并发上传时所有分块共享 upload_retry_budget：分块 2、3 各失败一次（400，不触发自适应退避），
+// 单个分块的重试次数（chunk_upload_retries=3）足够，能否完成取决于共享预算是否够两个分块各重试一次
+func TestUploadVideoConcurrentChunksShareRetryBudget(t *testing.T) {
+	for _, tc := range []struct {
+		budget int
+		ok     bool
+	}{
+		{budget: 1, ok: false},
+		{budget: 2, ok: true},
+	} {
+		t.Run(fmt.Sprintf("budget=%d", tc.budget), func(t *testing.T) {
+			env := newTestEnv(t)
+			env.cfg.Bilibili.UploadConcurrency = 3
+			env.cfg.Bilibili.UploadRetryBudget = tc.budget
+			srv, v := env.srv, env.video
+
+			for _, part := range []string{"2", "3"} {
+				srv.Inject(bilibilitest.EndpointChunk, bilibilitest.Fault{
+					Status: 400,
+					Body:   "bad request",
+					When:   func(r bilibilitest.Request) bool { return r.Query.Get("partNumber") == part },
+				})
+			}
+			_, err := upload(t, newTestUploader(t, srv, nil), v, testMeta())
+			if tc.ok {
+				if err != nil {
+					t.Fatalf("UploadVideo 失败: %v", err)
+				}
+				assertUploadedIntact(t, srv, v)
+				if n := len(srv.Requests(bilibilitest.EndpointChunk)); n != 5 {
+					t.Fatalf("分块请求 %d 次，期望 5 次（分块 2、3 各重试 1 次）", n)
+				}
+				return
+			}
+			var stepErr *UploadStepError
+			if !errors.As(err, &stepErr) || stepErr.Step != StepVideo || !strings.Contains(err.Error(), "upload_retry_budget") {
+				t.Fatalf("错误 = %v，期望视频步骤因重试预算用尽失败", err)
+			}
+			if n := len(srv.Requests(bilibilitest.EndpointPublish)); n != 0 {
+				t.Fatalf("分块失败后不应发布，发布请求 %d 次", n)
+			}
+		})
+	}
+}
+
+// 并发上传时一个分块返回 5xx 后其余分块一起放慢，重试成功后上传完整
+func TestUploadVideoConcurrentChunksRecoverFromThrottle(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.Bilibili.UploadConcurrency = 3
+	srv, v := env.srv, env.video
+
+	srv.Inject(bilibilitest.EndpointChunk, bilibilitest.Fault{
+		Status: 503,
+		Body:   "busy",
+		When:   func(r bilibilitest.Request) bool { return r.Query.Get("partNumber") == "2" },
+	})
+	if _, err := upload(t, newTestUploader(t, srv, nil), v, testMeta()); err != nil {
+		t.Fatalf("UploadVideo 失败: %v", err)
+	}
+	assertUploadedIntact(t, srv, v)
+	if n := len(srv.Requests(bilibilitest.EndpointChunk)); n != 4 {
+		t.Fatalf("分块请求 %d 次，期望 4 次（分块 2 重试 1 次）", n)
+	}
+}
@@ -0,0 +1,52 @@
+package bilibili
+
+import (
+	"time"
+)
+
+// SessionCookieNames bilibili.tv 登录态依赖的 cookie（CSRF 另外检查），过期后需要重新导出 cookies
+var SessionCookieNames = []string{"SESSDATA"}
+
+// CookieReport cookies 的静态检查结果（不发起请求）
+type CookieReport struct {
+	Count   int
+	HasCSRF bool // 存在 csrf 或 bili_jct
+	// ExpiresAt 关键 cookie 中最早的过期时间，零值表示都是会话 cookie 或都不存在
+	ExpiresAt time.Time
+	// Missing 不存在的关键 cookie
+	Missing []string
+}
+
+// ParseCookiesFile 解析 cookies 文件（Netscape 格式或 JSON 格式）
+func ParseCookiesFile(path string) ([]Cookie, error) {
+	return (&uploader{}).parseCookiesFile(path)
+}
+
+// InspectCookies 统计 cookies 数量、CSRF 是否存在，以及 keyNames 中各 cookie 的最早过期时间
+func InspectCookies(cookies []Cookie, keyNames []string) CookieReport {
+	report := CookieReport{Count: len(cookies)}
+	found := make(map[string]bool)
+	for _, c := range cookies {
+		if c.Name == "csrf" || c.Name == "bili_jct" {
+			report.HasCSRF = report.HasCSRF || c.Value != ""
+		}
+		for _, name := range keyNames {
+			if c.Name != name {
+				continue
+			}
+			found[name] = true
+			if c.Expires <= 0 {
+				continue
+			}
+			if expires := time.Unix(c.Expires, 0); report.ExpiresAt.IsZero() || expires.Before(report.ExpiresAt) {
+				report.ExpiresAt = expires
+			}
+		}
+	}
+	for _, name := range keyNames {
+		if !found[name] {
+			report.Missing = append(report.Missing, name)
+		}
+	}
+	return report
+}
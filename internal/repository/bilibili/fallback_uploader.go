@@ -0,0 +1,98 @@
+package bilibili
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+)
+
+// fallbackUploader 先用 primary 上传，失败原因为接口变化（FailureAPIChanged）时用 fallback 重新上传同一个视频。
+// 发布步骤的失败不回退：发布请求可能已经生效，重新上传会产生重复稿件。
+type fallbackUploader struct {
+	primary  Uploader
+	fallback Uploader
+}
+
+// NewFallbackUploader 组合两个上传器（upload_method: auto 使用 HTTP + 无头浏览器）；
+// 补传字幕、查询稿件状态与登录检查只使用 primary
+func NewFallbackUploader(primary, fallback Uploader) Uploader {
+	return &fallbackUploader{primary: primary, fallback: fallback}
+}
+
+func (f *fallbackUploader) UploadVideo(ctx context.Context, videoPath string, meta VideoMeta, subtitlePaths []string, account config.Account) (*UploadResult, error) {
+	// primary 会就地清理字幕路径，保留原始参数给 fallback
+	paths := append([]string(nil), subtitlePaths...)
+	started := time.Now()
+	result, err := f.primary.UploadVideo(ctx, videoPath, meta, subtitlePaths, account)
+	if err == nil || ctx.Err() != nil || ClassifyError(err) != FailureAPIChanged {
+		return result, err
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Stage == "publish" {
+		return f.recoverPublished(ctx, videoPath, meta, account, started, err)
+	}
+
+	logger.Warn().
+		Err(err).
+		Str("video_path", videoPath).
+		Str("account", account.Username).
+		Msg("HTTP 上传接口可能已变化，改用无头浏览器重新上传")
+	result, fallbackErr := f.fallback.UploadVideo(ctx, videoPath, meta, paths, account)
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("浏览器回退上传失败: %w（HTTP 上传错误: %v）", fallbackErr, err)
+	}
+	logger.Info().Str("video_path", videoPath).Str("aid", result.AID).Msg("浏览器回退上传完成")
+	return result, nil
+}
+
+// recoverPublished 发布响应异常时在稿件库中查找本次发布的稿件（标题相同且在上传开始后创建），
+// 找到时视为发布成功；找不到时返回原错误，不改用浏览器重新上传
+func (f *fallbackUploader) recoverPublished(ctx context.Context, videoPath string, meta VideoMeta, account config.Account, started time.Time, publishErr error) (*UploadResult, error) {
+	library, ok := f.primary.(Library)
+	if !ok {
+		return nil, publishErr
+	}
+	archives, err := library.ListArchives(ctx, account, ArchiveFilter{
+		Since: started.Add(-time.Minute), // 容忍本机与服务器的时钟偏差
+		Title: regexp.MustCompile("^" + regexp.QuoteMeta(meta.Title) + "$"),
+	})
+	if err != nil {
+		logger.Warn().Err(err).Str("video_path", videoPath).Msg("发布响应异常，查询稿件库失败")
+		return nil, fmt.Errorf("%w（发布请求可能已生效，未改用浏览器重新上传；查询稿件库失败: %v）", publishErr, err)
+	}
+	if len(archives) == 0 {
+		return nil, fmt.Errorf("%w（稿件库中未找到该稿件，未改用浏览器重新上传）", publishErr)
+	}
+
+	// 稿件列表最新的在前
+	aid := archives[0].AID
+	logger.Warn().
+		Err(publishErr).
+		Str("video_path", videoPath).
+		Str("aid", aid).
+		Int("matched", len(archives)).
+		Msg("发布响应无法解析，已在稿件库中找到本次发布的稿件")
+	return &UploadResult{Success: true, AID: aid}, nil
+}
+
+func (f *fallbackUploader) AddSubtitles(ctx context.Context, aid string, subtitlePaths []string, account config.Account) ([]SubtitleResult, error) {
+	return f.primary.AddSubtitles(ctx, aid, subtitlePaths, account)
+}
+
+func (f *fallbackUploader) GetArchiveStatus(ctx context.Context, aid string, account config.Account) (*file.ReviewStatus, error) {
+	return f.primary.GetArchiveStatus(ctx, aid, account)
+}
+
+func (f *fallbackUploader) ArchiveSubtitles(ctx context.Context, aid string, account config.Account) ([]int, error) {
+	return f.primary.ArchiveSubtitles(ctx, aid, account)
+}
+
+func (f *fallbackUploader) CheckLoginStatus(ctx context.Context) (bool, error) {
+	return f.primary.CheckLoginStatus(ctx)
+}
@@ -0,0 +1,280 @@
+package bilibili
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+)
+
+// ErrNotLoggedIn 创作中心接口返回未登录（code=-101），cookies 已失效
+var ErrNotLoggedIn = errors.New("账号未登录，请检查 cookies 是否有效")
+
+// archivePageSize 创作中心稿件列表每页数量
+const archivePageSize = 20
+
+// archivePageInterval 翻页之间的间隔，避免请求过快
+var archivePageInterval = time.Second
+
+// Library 创作中心稿件库管理（列出 / 删除已投稿的视频），基于 HTTP 上传器的 cookies 与 API 参数
+type Library interface {
+	// ListArchives 翻页获取账号的全部稿件，返回满足 filter 的稿件
+	ListArchives(ctx context.Context, account config.Account, filter ArchiveFilter) ([]Archive, error)
+	// DeleteArchive 删除稿件
+	DeleteArchive(ctx context.Context, aid string, account config.Account) error
+}
+
+// Archive 创作中心中的一个稿件
+type Archive struct {
+	AID          string           `json:"aid"`
+	Title        string           `json:"title"`
+	Desc         string           `json:"desc,omitempty"`
+	State        file.ReviewState `json:"state"`
+	StateCode    int              `json:"state_code"`
+	StateDesc    string           `json:"state_desc,omitempty"`
+	RejectReason string           `json:"reject_reason,omitempty"`
+	Cover        string           `json:"cover,omitempty"`
+	CreatedAt    time.Time        `json:"created_at"`
+	// Account 稿件所在的账号（bilibili_accounts 中的名称，由调用方填写）
+	Account string `json:"account,omitempty"`
+	// LocalDir 本地上传状态中记录该 aid 的视频目录（由调用方填写）
+	LocalDir string `json:"local_dir,omitempty"`
+}
+
+// ArchiveFilter 稿件过滤条件，零值表示不过滤
+type ArchiveFilter struct {
+	Since  time.Time          // 投稿时间不早于
+	Until  time.Time          // 投稿时间早于
+	Title  *regexp.Regexp     // 标题匹配
+	States []file.ReviewState // 稿件状态之一
+}
+
+// Match 判断稿件是否满足过滤条件
+func (f ArchiveFilter) Match(a Archive) bool {
+	if !f.Since.IsZero() && a.CreatedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !a.CreatedAt.Before(f.Until) {
+		return false
+	}
+	if f.Title != nil && !f.Title.MatchString(a.Title) {
+		return false
+	}
+	if len(f.States) > 0 {
+		for _, state := range f.States {
+			if a.State == state {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// NewLibrary 创建稿件库管理器；账号未配置 cookies_file 时使用 cookiesFile
+func NewLibrary(cookiesFile string) Library {
+	return newHTTPUploader(nil, "", "", cookiesFile)
+}
+
+// ListArchives 翻页获取稿件列表（GET /intl/videoup/web2/archives）
+func (u *httpUploader) ListArchives(ctx context.Context, account config.Account, filter ArchiveFilter) ([]Archive, error) {
+	u = u.forJob()
+	if err := u.loadAccountCookies(account); err != nil {
+		return nil, err
+	}
+
+	var archives []Archive
+	fetched := 0
+	for page := 1; ; page++ {
+		items, total, err := u.fetchArchivePage(ctx, page)
+		if err != nil {
+			return archives, fmt.Errorf("获取第 %d 页稿件失败: %w", page, err)
+		}
+		fetched += len(items)
+		for _, a := range items {
+			if filter.Match(a) {
+				archives = append(archives, a)
+			}
+		}
+		logger.Debug().Int("page", page).Int("count", len(items)).Int("fetched", fetched).Int("total", total).Msg("已获取稿件列表")
+		if len(items) == 0 || (total > 0 && fetched >= total) || (total == 0 && len(items) < archivePageSize) {
+			break
+		}
+		if err := sleepContext(ctx, archivePageInterval); err != nil {
+			return archives, err
+		}
+	}
+	logger.Info().Int("total", fetched).Int("matched", len(archives)).Msg("稿件列表获取完成")
+	return archives, nil
+}
+
+// fetchArchivePage 获取一页稿件，返回稿件与稿件总数（data.page.total，缺失时为 0）
+func (u *httpUploader) fetchArchivePage(ctx context.Context, page int) ([]Archive, int, error) {
+	apiURL := u.buildAPIURL(fmt.Sprintf("/intl/videoup/web2/archives?state=&pn=%d&ps=%d", page, archivePageSize))
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("创建稿件列表请求失败: %w", err)
+	}
+	u.setCookies(req)
+	u.setHeaders(req)
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("请求稿件列表失败: %w", err)
+	}
+	defer resp.Body.Close()
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("读取稿件列表响应失败: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		return nil, 0, fmt.Errorf("HTTP %d, 响应: %s", resp.StatusCode, previewForLog(string(bodyBytes), 300))
+	}
+
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    struct {
+			Archives []map[string]any `json:"archives"`
+			Page     struct {
+				Total int `json:"total"`
+			} `json:"page"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return nil, 0, fmt.Errorf("解析稿件列表响应失败: %w, 响应: %s", err, previewForLog(string(bodyBytes), 300))
+	}
+	if result.Code == -101 {
+		return nil, 0, fmt.Errorf("%w: code=%d, message=%s", ErrNotLoggedIn, result.Code, result.Message)
+	}
+	if result.Code != 0 {
+		return nil, 0, fmt.Errorf("code=%d, message=%s", result.Code, result.Message)
+	}
+
+	archives := make([]Archive, 0, len(result.Data.Archives))
+	for _, data := range result.Data.Archives {
+		if a, ok := parseArchive(data); ok {
+			archives = append(archives, a)
+		}
+	}
+	return archives, result.Data.Page.Total, nil
+}
+
+// parseArchive 解析稿件列表中的一项（字段可能位于顶层或 archive 下），缺少 aid 时返回 false
+func parseArchive(data map[string]any) (Archive, bool) {
+	review := parseArchiveStatus(data)
+	if inner, ok := data["archive"].(map[string]any); ok {
+		data = inner
+	}
+	a := Archive{
+		AID:          anyString(data["aid"]),
+		Title:        anyString(data["title"]),
+		Desc:         anyString(data["desc"]),
+		State:        review.State,
+		StateCode:    review.Code,
+		StateDesc:    review.Desc,
+		RejectReason: review.RejectReason,
+		Cover:        anyString(data["cover"]),
+	}
+	for _, key := range []string{"ctime", "create_time", "ptime", "pubdate"} {
+		if t, ok := anyTime(data[key]); ok {
+			a.CreatedAt = t
+			break
+		}
+	}
+	return a, a.AID != ""
+}
+
+// anyString 将 JSON 中的字符串或数字转换为字符串（aid 可能以字符串或数字返回）
+func anyString(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case json.Number:
+		return val.String()
+	}
+	return ""
+}
+
+// anyTime 解析 Unix 秒时间戳（数字或数字字符串）或 "2006-01-02 15:04:05" 格式的时间
+func anyTime(v any) (time.Time, bool) {
+	switch val := v.(type) {
+	case float64:
+		if val > 0 {
+			return time.Unix(int64(val), 0), true
+		}
+	case string:
+		if n, err := strconv.ParseInt(val, 10, 64); err == nil && n > 0 {
+			return time.Unix(n, 0), true
+		}
+		for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05"} {
+			if t, err := time.ParseInLocation(layout, val, time.Local); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// DeleteArchive 删除稿件（POST /intl/videoup/web2/del，multipart/form-data 提交 aid）
+func (u *httpUploader) DeleteArchive(ctx context.Context, aid string, account config.Account) error {
+	u = u.forJob()
+	if err := u.loadAccountCookies(account); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	if err := writer.WriteField("aid", aid); err != nil {
+		return fmt.Errorf("构建删除请求失败: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("构建删除请求失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", u.buildAPIURL("/intl/videoup/web2/del"), &buf)
+	if err != nil {
+		return fmt.Errorf("创建删除请求失败: %w", err)
+	}
+	u.setCookies(req)
+	u.setHeaders(req)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("删除稿件失败: %w", err)
+	}
+	defer resp.Body.Close()
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取删除响应失败: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("删除稿件失败: HTTP %d, 响应: %s", resp.StatusCode, previewForLog(string(bodyBytes), 300))
+	}
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return fmt.Errorf("解析删除响应失败: %w, 响应: %s", err, previewForLog(string(bodyBytes), 300))
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("删除稿件失败: code=%d, message=%s", result.Code, result.Message)
+	}
+	logger.Info().Str("aid", aid).Msg("稿件已删除")
+	return nil
+}
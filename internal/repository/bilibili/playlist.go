@@ -0,0 +1,181 @@
+package bilibili
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"blueberry/internal/config"
+	"blueberry/pkg/logger"
+)
+
+// Playlists 创作中心播放列表管理（查找 / 创建播放列表、添加稿件、调整顺序），基于 HTTP 上传器的 cookies 与 API 参数
+type Playlists interface {
+	// ListPlaylists 获取账号下的全部播放列表
+	ListPlaylists(ctx context.Context, account config.Account) ([]Playlist, error)
+	// CreatePlaylist 创建播放列表，返回播放列表 ID
+	CreatePlaylist(ctx context.Context, title, desc string, account config.Account) (string, error)
+	// AddToPlaylist 将已发布的稿件加入播放列表
+	AddToPlaylist(ctx context.Context, playlistID string, aids []string, account config.Account) error
+	// SortPlaylist 按 aids 的顺序排列播放列表中的稿件（未列出的稿件排在最后）
+	SortPlaylist(ctx context.Context, playlistID string, aids []string, account config.Account) error
+}
+
+// Playlist 账号下的一个播放列表
+type Playlist struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Count int    `json:"count"`
+}
+
+// NewPlaylists 创建播放列表管理器；账号未配置 cookies_file 时使用 cookiesFile
+func NewPlaylists(cookiesFile string) Playlists {
+	return newHTTPUploader(nil, "", "", cookiesFile)
+}
+
+// FindPlaylist 按标题（忽略首尾空白与大小写）查找播放列表
+func FindPlaylist(playlists []Playlist, title string) (Playlist, bool) {
+	title = strings.TrimSpace(title)
+	for _, p := range playlists {
+		if strings.EqualFold(strings.TrimSpace(p.Title), title) {
+			return p, true
+		}
+	}
+	return Playlist{}, false
+}
+
+// ListPlaylists 获取播放列表（GET /intl/videoup/web2/playlist/list）
+func (u *httpUploader) ListPlaylists(ctx context.Context, account config.Account) ([]Playlist, error) {
+	u = u.forJob()
+	if err := u.loadAccountCookies(account); err != nil {
+		return nil, err
+	}
+	var data struct {
+		List []map[string]any `json:"list"`
+	}
+	if err := u.playlistRequest(ctx, "GET", "/intl/videoup/web2/playlist/list", nil, &data); err != nil {
+		return nil, fmt.Errorf("获取播放列表失败: %w", err)
+	}
+	playlists := make([]Playlist, 0, len(data.List))
+	for _, item := range data.List {
+		p := Playlist{ID: anyString(item["id"]), Title: anyString(item["title"])}
+		if p.ID == "" {
+			p.ID = anyString(item["playlist_id"])
+		}
+		if count, ok := item["count"].(float64); ok {
+			p.Count = int(count)
+		}
+		if p.ID != "" {
+			playlists = append(playlists, p)
+		}
+	}
+	return playlists, nil
+}
+
+// CreatePlaylist 创建播放列表（POST /intl/videoup/web2/playlist/add）
+func (u *httpUploader) CreatePlaylist(ctx context.Context, title, desc string, account config.Account) (string, error) {
+	u = u.forJob()
+	if err := u.loadAccountCookies(account); err != nil {
+		return "", err
+	}
+	var data map[string]any
+	body := map[string]any{"title": title, "desc": desc}
+	if err := u.playlistRequest(ctx, "POST", "/intl/videoup/web2/playlist/add", body, &data); err != nil {
+		return "", fmt.Errorf("创建播放列表失败: %w", err)
+	}
+	id := anyString(data["id"])
+	if id == "" {
+		id = anyString(data["playlist_id"])
+	}
+	if id == "" {
+		return "", fmt.Errorf("创建播放列表失败: 响应中没有播放列表 ID")
+	}
+	logger.Info().Str("playlist_id", id).Str("title", title).Msg("播放列表已创建")
+	return id, nil
+}
+
+// AddToPlaylist 将稿件加入播放列表（POST /intl/videoup/web2/playlist/archive/add）
+func (u *httpUploader) AddToPlaylist(ctx context.Context, playlistID string, aids []string, account config.Account) error {
+	u = u.forJob()
+	if err := u.loadAccountCookies(account); err != nil {
+		return err
+	}
+	body := map[string]any{"playlist_id": playlistID, "aids": aids}
+	if err := u.playlistRequest(ctx, "POST", "/intl/videoup/web2/playlist/archive/add", body, nil); err != nil {
+		return fmt.Errorf("加入播放列表失败: %w", err)
+	}
+	logger.Info().Str("playlist_id", playlistID).Strs("aids", aids).Msg("稿件已加入播放列表")
+	return nil
+}
+
+// SortPlaylist 调整播放列表中稿件的顺序（POST /intl/videoup/web2/playlist/archive/sort）
+func (u *httpUploader) SortPlaylist(ctx context.Context, playlistID string, aids []string, account config.Account) error {
+	u = u.forJob()
+	if err := u.loadAccountCookies(account); err != nil {
+		return err
+	}
+	body := map[string]any{"playlist_id": playlistID, "aids": aids}
+	if err := u.playlistRequest(ctx, "POST", "/intl/videoup/web2/playlist/archive/sort", body, nil); err != nil {
+		return fmt.Errorf("调整播放列表顺序失败: %w", err)
+	}
+	logger.Debug().Str("playlist_id", playlistID).Int("count", len(aids)).Msg("播放列表顺序已更新")
+	return nil
+}
+
+// playlistRequest 发送播放列表请求（body 非 nil 时以 JSON 提交），code 为 0 时将 data 解析到 out（可为 nil）
+func (u *httpUploader) playlistRequest(ctx context.Context, method, endpoint string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %w", err)
+		}
+		reader = bytes.NewReader(jsonData)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.buildAPIURL(endpoint), reader)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	u.setCookies(req)
+	u.setHeaders(req)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("HTTP %d, 响应: %s", resp.StatusCode, previewForLog(string(bodyBytes), 300))
+	}
+	var result struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return fmt.Errorf("解析响应失败: %w, 响应: %s", err, previewForLog(string(bodyBytes), 300))
+	}
+	if result.Code == -101 {
+		return fmt.Errorf("%w: code=%d, message=%s", ErrNotLoggedIn, result.Code, result.Message)
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("code=%d, message=%s", result.Code, result.Message)
+	}
+	if out != nil && len(result.Data) > 0 && string(result.Data) != "null" {
+		if err := json.Unmarshal(result.Data, out); err != nil {
+			return fmt.Errorf("解析响应数据失败: %w, 响应: %s", err, previewForLog(string(bodyBytes), 300))
+		}
+	}
+	return nil
+}
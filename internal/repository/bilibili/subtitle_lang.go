@@ -0,0 +1,117 @@
+package bilibili
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"blueberry/internal/config"
+	"blueberry/pkg/logger"
+)
+
+// SubtitleResult 单个语言字幕的上传结果
+type SubtitleResult struct {
+	Lang   string // YouTube 语言代码（en、zh-Hans…）
+	LangID int    // bilibili.tv 字幕语言 ID，未配置映射时为 0
+	URL    string // 字幕直传 OSS 后的 key
+	Err    error
+}
+
+// subtitleFile 待上传的单个语言字幕
+type subtitleFile struct {
+	Lang   string
+	LangID int
+	Path   string
+}
+
+// primarySubtitleLang 发布稿件时随 add 接口一起提交的字幕语言，其余语言发布后逐个追加
+const primarySubtitleLang = "en"
+
+var subtitleLangPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// SubtitleLanguage 从字幕文件名中提取 YouTube 语言代码，无法识别时返回空字符串
+// 支持 {title}[{id}].{lang}.srt、{id}.{lang}.srt 以及上传后复制的 {aid}_{lang}.srt，.frame.srt 视为同一语言
+func SubtitleLanguage(path string) string {
+	base := filepath.Base(path)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+	name = strings.TrimSuffix(name, ".frame")
+
+	candidate := ""
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		candidate = name[idx+1:]
+	} else if idx := strings.LastIndex(name, "_"); idx >= 0 {
+		candidate = name[idx+1:]
+	}
+	if !subtitleLangPattern.MatchString(candidate) {
+		return ""
+	}
+	return candidate
+}
+
+// SubtitleLangID 返回语言对应的 bilibili.tv 字幕语言 ID（bilibili.subtitle_languages，不区分大小写）
+func SubtitleLangID(cfg *config.Config, lang string) (int, bool) {
+	if cfg == nil || lang == "" {
+		return 0, false
+	}
+	if id, ok := cfg.Bilibili.SubtitleLanguages[lang]; ok {
+		return id, true
+	}
+	for key, id := range cfg.Bilibili.SubtitleLanguages {
+		if strings.EqualFold(key, lang) {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// planSubtitles 按语言归类字幕文件：每种语言取一个 .srt（优先非 .frame 版本），主语言排在最前，其余按语言代码排序
+// 只有 .vtt / .ass 或未配置语言 ID 的字幕不上传，直接作为失败结果返回；无法识别语言的文件仅记录日志
+func planSubtitles(cfg *config.Config, subtitlePaths []string) ([]subtitleFile, []SubtitleResult) {
+	byLang := make(map[string]string)
+	var skipped []SubtitleResult
+	nonSRT := make(map[string]string)
+	for _, path := range subtitlePaths {
+		lang := SubtitleLanguage(path)
+		if lang == "" {
+			logger.Warn().Str("subtitle_path", path).Msg("无法从文件名识别字幕语言，跳过该字幕")
+			continue
+		}
+		if !strings.HasSuffix(strings.ToLower(path), ".srt") {
+			nonSRT[lang] = path
+			continue
+		}
+		if prev, ok := byLang[lang]; !ok || strings.Contains(filepath.Base(prev), ".frame.") {
+			byLang[lang] = path
+		}
+	}
+	for lang, path := range nonSRT {
+		if _, ok := byLang[lang]; !ok {
+			skipped = append(skipped, SubtitleResult{Lang: lang, Err: fmt.Errorf("仅支持上传 SRT 字幕: %s", filepath.Base(path))})
+		}
+	}
+
+	langs := make([]string, 0, len(byLang))
+	for lang := range byLang {
+		langs = append(langs, lang)
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		pi, pj := strings.EqualFold(langs[i], primarySubtitleLang), strings.EqualFold(langs[j], primarySubtitleLang)
+		if pi != pj {
+			return pi
+		}
+		return langs[i] < langs[j]
+	})
+
+	files := make([]subtitleFile, 0, len(langs))
+	for _, lang := range langs {
+		id, ok := SubtitleLangID(cfg, lang)
+		if !ok {
+			skipped = append(skipped, SubtitleResult{Lang: lang, Err: fmt.Errorf("未在 bilibili.subtitle_languages 中配置 %s 的语言 ID", lang)})
+			continue
+		}
+		files = append(files, subtitleFile{Lang: lang, LangID: id, Path: byLang[lang]})
+	}
+	return files, skipped
+}
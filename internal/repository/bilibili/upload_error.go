@@ -0,0 +1,140 @@
+package bilibili
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// FailureKind 上传失败的类别，决定是否需要暂停使用该账号
+type FailureKind string
+
+const (
+	FailureUnknown         FailureKind = ""
+	FailureAuthExpired     FailureKind = "auth_expired"     // cookies 失效 / 未登录 / CSRF 校验失败
+	FailureRateLimited     FailureKind = "rate_limited"     // 请求过于频繁、触发风控
+	FailureBanned          FailureKind = "banned"           // 账号被封禁或禁止投稿
+	FailureContentRejected FailureKind = "content_rejected" // 稿件内容（标题、简介、版权等）被拒绝，与账号无关
+	FailureAPIChanged      FailureKind = "api_changed"      // 接口不存在或响应格式无法解析，HTTP 上传方式可能已失效
+)
+
+// APIError preupload / 封面 / 发布接口返回的错误（非 200 状态码、非 0 的业务 code 或无法解析的响应）
+type APIError struct {
+	Stage      string // preupload / cover / publish
+	HTTPStatus int
+	Code       int
+	Message    string
+	Kind       FailureKind
+	detail     string
+}
+
+func newAPIError(stage string, httpStatus, code int, message, detail string) *APIError {
+	return &APIError{
+		Stage:      stage,
+		HTTPStatus: httpStatus,
+		Code:       code,
+		Message:    message,
+		Kind:       classifyAPIFailure(httpStatus, code, message),
+		detail:     detail,
+	}
+}
+
+// newAPIChangedError 接口返回了无法解析或缺少必要字段的响应
+func newAPIChangedError(stage string, httpStatus int, detail string) *APIError {
+	return &APIError{
+		Stage:      stage,
+		HTTPStatus: httpStatus,
+		Kind:       FailureAPIChanged,
+		detail:     detail,
+	}
+}
+
+func (e *APIError) Error() string {
+	return e.detail
+}
+
+// Unwrap 未登录类错误同时满足 errors.Is(err, ErrNotLoggedIn)
+func (e *APIError) Unwrap() error {
+	if e.Kind == FailureAuthExpired {
+		return ErrNotLoggedIn
+	}
+	return nil
+}
+
+// 已知的业务错误码
+var apiCodeKinds = map[int]FailureKind{
+	-101:  FailureAuthExpired, // 账号未登录
+	-111:  FailureAuthExpired, // csrf 校验失败
+	-2:    FailureAuthExpired, // access key 错误
+	-102:  FailureBanned,      // 账号被封停
+	-352:  FailureRateLimited, // 风控校验失败
+	-412:  FailureRateLimited, // 请求被拦截
+	-509:  FailureRateLimited, // 请求过于频繁
+	-799:  FailureRateLimited, // 请求过于频繁，请稍后再试
+	21020: FailureRateLimited, // 投稿过于频繁
+}
+
+// 接口返回的 message 中的关键词（按顺序匹配，不区分大小写），只用于业务响应中未知的错误码；
+// 非 200 响应的响应体与其他错误（UPOS 分块、网络、chromedp）的文本可能包含任意内容，不按关键词分类
+var failureKeywords = []struct {
+	kind     FailureKind
+	keywords []string
+}{
+	{FailureBanned, []string{"封禁", "封停", "banned", "suspended", "account is blocked", "禁止投稿"}},
+	{FailureAuthExpired, []string{"未登录", "登录失效", "not logged in", "login required", "csrf", "session expired"}},
+	{FailureRateLimited, []string{"频繁", "稍后再试", "too frequent", "too many requests", "rate limit", "风控", "risk control"}},
+	{FailureContentRejected, []string{"敏感", "违规", "版权", "sensitive", "violat", "copyright", "inappropriate"}},
+}
+
+func classifyAPIFailure(httpStatus, code int, message string) FailureKind {
+	if kind, ok := apiCodeKinds[code]; ok {
+		return kind
+	}
+	switch httpStatus {
+	case http.StatusUnauthorized:
+		return FailureAuthExpired
+	case http.StatusTooManyRequests, http.StatusPreconditionFailed:
+		return FailureRateLimited
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusGone, http.StatusNotImplemented:
+		return FailureAPIChanged
+	}
+	if code == 0 {
+		// 非 200 响应：message 是原始响应体（如网关错误页）
+		return FailureUnknown
+	}
+	return classifyMessage(message)
+}
+
+func classifyMessage(message string) FailureKind {
+	message = strings.ToLower(message)
+	for _, group := range failureKeywords {
+		for _, keyword := range group.keywords {
+			if strings.Contains(message, keyword) {
+				return group.kind
+			}
+		}
+	}
+	return FailureUnknown
+}
+
+// ClassifyError 判断上传错误的类别：只使用 APIError 的分类与 ErrNotLoggedIn，
+// 其他错误视为临时错误（FailureUnknown），不会导致账号被隔离
+func ClassifyError(err error) FailureKind {
+	if err == nil {
+		return FailureUnknown
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		// APIError 的错误文本包含请求体（如 csrf 字段），不再按关键词匹配
+		return apiErr.Kind
+	}
+	if errors.Is(err, ErrNotLoggedIn) {
+		return FailureAuthExpired
+	}
+	return FailureUnknown
+}
+
+// AccountFailure 该类错误是否由账号状态引起（换账号或等待后可能恢复），内容被拒绝与未知错误不算
+func (k FailureKind) AccountFailure() bool {
+	return k == FailureAuthExpired || k == FailureRateLimited || k == FailureBanned
+}
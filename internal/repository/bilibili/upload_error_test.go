@@ -0,0 +1,39 @@
+package bilibili
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind FailureKind
+	}{
+		// 文本中带有关键词，但不是接口返回的业务错误：视为临时错误
+		{"UPOS 分块 5xx 响应体", fmt.Errorf("上传分块 3 失败: HTTP 503, 响应: 服务繁忙，请稍后再试"), FailureUnknown},
+		{"chromedp 错误", errors.New("chromedp: rate limit exceeded while waiting for node"), FailureUnknown},
+		{"表单中的 csrf 字段", fmt.Errorf("上传封面失败: 找不到 csrf 输入框"), FailureUnknown},
+		{"页面文本中的封禁字样", errors.New("发布按钮不可用: 页面提示 该视频涉及封禁内容"), FailureUnknown},
+		{"网关错误页", newAPIError("publish", 502, 0, "<html>请求过于频繁，请稍后再试</html>", "发布视频失败: HTTP 502"), FailureUnknown},
+		{"网络错误", fmt.Errorf("发布视频失败: %w", errors.New("connection reset by peer")), FailureUnknown},
+
+		// 类型化的错误
+		{"未登录", fmt.Errorf("准备账号失败: %w", ErrNotLoggedIn), FailureAuthExpired},
+		{"风控错误码", newAPIError("publish", 200, -352, "risk control", "发布视频失败"), FailureRateLimited},
+		{"HTTP 429", newAPIError("preupload", 429, 0, "Too Many Requests", "preupload 失败"), FailureRateLimited},
+		{"未知错误码的限流信息", newAPIError("publish", 200, 21999, "投稿过于频繁", "发布视频失败"), FailureRateLimited},
+		{"内容被拒绝", newAPIError("publish", 200, 21012, "标题包含敏感词", "发布视频失败"), FailureContentRejected},
+		{"接口不存在", newAPIError("publish", 404, 0, "not found", "发布视频失败"), FailureAPIChanged},
+		{"步骤错误包装", &UploadStepError{Step: StepPublish, Err: newAPIError("publish", 200, -101, "账号未登录", "发布视频失败")}, FailureAuthExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if kind := ClassifyError(tt.err); kind != tt.kind {
+				t.Fatalf("ClassifyError(%v) = %q，期望 %q", tt.err, kind, tt.kind)
+			}
+		})
+	}
+}
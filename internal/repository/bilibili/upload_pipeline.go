@@ -0,0 +1,202 @@
+package bilibili
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+)
+
+// UploadStep 投稿流程中的步骤，HTTP 与浏览器上传器按相同顺序执行
+type UploadStep string
+
+const (
+	StepPrepare   UploadStep = "prepare"   // 加载 cookies、确认登录状态
+	StepCover     UploadStep = "cover"     // 上传封面
+	StepSubtitles UploadStep = "subtitles" // 上传字幕
+	StepVideo     UploadStep = "video"     // 上传视频文件
+	StepPublish   UploadStep = "publish"   // 提交稿件
+)
+
+var uploadStepLabels = map[UploadStep]string{
+	StepPrepare:   "准备账号",
+	StepCover:     "封面图上传",
+	StepSubtitles: "字幕上传",
+	StepVideo:     "上传视频",
+	StepPublish:   "发布视频",
+}
+
+// UploadStepError 投稿流程中某个步骤失败；Unwrap 返回原始错误，ClassifyError 与 errors.Is 仍然可用
+type UploadStepError struct {
+	Method string // http / chromedp
+	Step   UploadStep
+	Err    error
+}
+
+func (e *UploadStepError) Error() string {
+	return fmt.Sprintf("%s失败: %v", uploadStepLabels[e.Step], e.Err)
+}
+
+func (e *UploadStepError) Unwrap() error {
+	return e.Err
+}
+
+// uploadJob 一次投稿在各步骤之间传递的状态
+type uploadJob struct {
+	videoPath     string // 文件检查后实际上传的视频文件
+	meta          VideoMeta
+	subtitlePaths []string
+	account       config.Account
+
+	coverPath        string           // 本地封面，cover 步骤开始前已选好
+	coverURL         string           // 封面上传后的地址（HTTP）
+	filename         string           // 视频在上传服务器上的文件名，不含后缀（HTTP）
+	subtitles        []SubtitleResult // 各语言字幕的上传结果
+	skippedSubtitles []SubtitleResult // 未上传的语言（未配置映射、缺少 SRT 等）
+	primarySubtitle  *SubtitleResult  // 随发布请求提交的字幕，指向 subtitles 中的元素
+	aid              string
+	playlistID       string // 发布时已加入的播放列表
+}
+
+// uploadSteps 上传器对各步骤的实现；步骤顺序、文件检查、封面选择与结果组装由 runUploadSteps 统一处理
+type uploadSteps interface {
+	stepPrepare(ctx context.Context, job *uploadJob) error
+	stepCover(ctx context.Context, job *uploadJob) error
+	stepSubtitles(ctx context.Context, job *uploadJob) error
+	stepVideo(ctx context.Context, job *uploadJob) error
+	stepPublish(ctx context.Context, job *uploadJob) error
+}
+
+// runUploadSteps 检查文件后依次执行 prepare → cover → subtitles → video → publish，任一步骤失败即停止
+func runUploadSteps(ctx context.Context, method string, steps uploadSteps, videoPath string, meta VideoMeta, subtitlePaths []string, account config.Account) (*UploadResult, error) {
+	// 清理路径中的转义字符（处理 shell 转义）
+	videoPath = cleanPath(videoPath)
+	for i := range subtitlePaths {
+		subtitlePaths[i] = cleanPath(subtitlePaths[i])
+	}
+	actualVideoPath, err := validateRequiredFiles(videoPath, subtitlePaths, filepath.Dir(videoPath))
+	if err != nil {
+		return nil, fmt.Errorf("文件检查失败: %w", err)
+	}
+
+	job := &uploadJob{
+		videoPath:     actualVideoPath,
+		meta:          meta,
+		subtitlePaths: subtitlePaths,
+		account:       account,
+	}
+	sequence := []struct {
+		step UploadStep
+		run  func(context.Context, *uploadJob) error
+	}{
+		{StepPrepare, steps.stepPrepare},
+		{StepCover, func(ctx context.Context, job *uploadJob) error {
+			coverPath, err := findCoverPath(job.videoPath)
+			if err != nil {
+				return err
+			}
+			job.coverPath = coverPath
+			return steps.stepCover(ctx, job)
+		}},
+		{StepSubtitles, steps.stepSubtitles},
+		{StepVideo, steps.stepVideo},
+		{StepPublish, steps.stepPublish},
+	}
+	for _, s := range sequence {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		start := time.Now()
+		if err := s.run(ctx, job); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			logger.Error().
+				Err(err).
+				Str("upload_method", method).
+				Str("step", string(s.step)).
+				Str("video_path", job.videoPath).
+				Msg("上传步骤失败，跳过该视频")
+			return nil, &UploadStepError{Method: method, Step: s.step, Err: err}
+		}
+		logger.Debug().
+			Str("upload_method", method).
+			Str("step", string(s.step)).
+			Dur("elapsed", time.Since(start)).
+			Msg("上传步骤完成")
+	}
+
+	return &UploadResult{
+		Success:    true,
+		VideoID:    job.aid,
+		AID:        job.aid,
+		Subtitles:  append(job.skippedSubtitles, job.subtitles...),
+		PlaylistID: job.playlistID,
+	}, nil
+}
+
+// findCoverPath 选择封面文件：重新上传封面 → 与视频同名的 .jpg → cover.{jpg|jpeg|png|webp|gif} → thumbnail.jpg → 默认封面
+func findCoverPath(videoPath string) (string, error) {
+	dir := filepath.Dir(videoPath)
+	// 审核退回后重新上传时生成的封面（verify-uploads）
+	resubmitCover := filepath.Join(dir, file.ResubmitCoverFile)
+	if _, err := os.Stat(resubmitCover); err == nil {
+		logger.Info().Str("path", resubmitCover).Msg("使用重新上传封面（审核退回后重新上传）")
+		return resubmitCover, nil
+	}
+	// 与视频同名的 jpg（来自 yt-dlp --convert-thumbnails jpg）
+	candidate := strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + ".jpg"
+	if _, err := os.Stat(candidate); err == nil {
+		logger.Debug().Str("path", candidate).Msg("使用与视频同名的 JPG 缩略图作为封面图（优先）")
+		return candidate, nil
+	}
+	for _, ext := range []string{".jpg", ".jpeg", ".png", ".webp", ".gif"} {
+		p := filepath.Join(dir, "cover"+ext)
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	thumb := filepath.Join(dir, "thumbnail.jpg")
+	if _, err := os.Stat(thumb); err == nil {
+		logger.Debug().Str("path", thumb).Msg("使用 thumbnail.jpg 作为封面图（回退）")
+		return thumb, nil
+	}
+	if defaultCoverPath := findDefaultCoverPath(dir); defaultCoverPath != "" {
+		logger.Info().Str("path", defaultCoverPath).Msg("使用默认封面图（assets/default_cover.jpg）")
+		return defaultCoverPath, nil
+	}
+	return "", fmt.Errorf("未找到封面图文件（需要与视频同名的 .jpg，或 cover.{jpg|jpeg|png|webp|gif}，或 thumbnail.jpg，或 assets/default_cover.jpg）")
+}
+
+// findDefaultCoverPath 查找默认封面图路径（assets/default_cover.jpg）
+// 尝试多个可能的位置：相对于视频目录、相对于工作目录等
+func findDefaultCoverPath(videoDir string) string {
+	// 尝试多个可能的 assets 目录位置
+	possiblePaths := []string{
+		"./assets/default_cover.jpg",                                       // 当前工作目录
+		"assets/default_cover.jpg",                                         // 相对路径
+		filepath.Join(videoDir, "..", "..", "assets", "default_cover.jpg"), // 从视频目录向上查找
+		filepath.Join("/opt/blueberry/assets/default_cover.jpg"),           // 默认安装路径
+		filepath.Join("/usr/local/blueberry/assets/default_cover.jpg"),     // 备用安装路径
+	}
+
+	for _, path := range possiblePaths {
+		if absPath, err := filepath.Abs(path); err == nil {
+			if _, err := os.Stat(absPath); err == nil {
+				return absPath
+			}
+		}
+		// 也尝试直接使用路径（可能是绝对路径）
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return ""
+}
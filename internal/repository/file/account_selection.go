@@ -0,0 +1,106 @@
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// accountSelectionName 账号选择策略的状态（.global 下）：各账号近期的上传时间、失败冷却与隔离、轮转位置、频道固定账号
+const accountSelectionName = "account_selection"
+
+// AccountSelectionState 账号选择策略的全局状态
+type AccountSelectionState struct {
+	// Usage key 为账号名
+	Usage map[string]*AccountUsage `json:"usage"`
+	// Cursors round_robin 策略各频道上次使用的账号序号，key 为频道目录名
+	Cursors map[string]int `json:"cursors"`
+	// Sticky sticky 策略各频道固定使用的账号，key 为频道目录名
+	Sticky map[string]string `json:"sticky"`
+}
+
+// AccountUsage 账号近期的上传与失败记录
+type AccountUsage struct {
+	// RecentUploads 最近一小时内上传成功的时间（Unix 秒），用于每小时上限
+	RecentUploads []int64 `json:"recent_uploads,omitempty"`
+	// LastFailureAt 最近一次上传失败的时间（Unix 秒），用于失败冷却
+	LastFailureAt int64  `json:"last_failure_at,omitempty"`
+	LastFailure   string `json:"last_failure,omitempty"`
+	// Quarantine 账号因 cookies 失效、风控或封禁被隔离，到期前不参与自动选择
+	Quarantine *AccountQuarantine `json:"quarantine,omitempty"`
+}
+
+// AccountQuarantine 账号的隔离记录
+type AccountQuarantine struct {
+	Kind   string `json:"kind"` // auth_expired / rate_limited / banned / content_rejected
+	Reason string `json:"reason,omitempty"`
+	Since  int64  `json:"since"`
+	Until  int64  `json:"until"`
+}
+
+// Active 隔离是否仍在有效期内
+func (q *AccountQuarantine) Active(now time.Time) bool {
+	return q != nil && q.Until > now.Unix()
+}
+
+// AccountSelectionRecord 上传前选择账号的决定与原因，记录在 upload_status.json 中
+type AccountSelectionRecord struct {
+	Account    string `json:"account"`
+	Strategy   string `json:"strategy"`
+	Reason     string `json:"reason,omitempty"`
+	SelectedAt int64  `json:"selected_at"`
+}
+
+func parseAccountSelectionState(data []byte) (*AccountSelectionState, error) {
+	state := &AccountSelectionState{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, state); err != nil {
+			return nil, fmt.Errorf("解析账号选择状态失败: %w", err)
+		}
+	}
+	if state.Usage == nil {
+		state.Usage = make(map[string]*AccountUsage)
+	}
+	if state.Cursors == nil {
+		state.Cursors = make(map[string]int)
+	}
+	if state.Sticky == nil {
+		state.Sticky = make(map[string]string)
+	}
+	return state, nil
+}
+
+// LoadAccountSelection 读取账号选择状态，不存在时返回空状态
+func (r *repository) LoadAccountSelection() (*AccountSelectionState, error) {
+	data, err := r.store.LoadGlobal(accountSelectionName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return parseAccountSelectionState(nil)
+		}
+		return nil, fmt.Errorf("读取账号选择状态失败: %w", err)
+	}
+	return parseAccountSelectionState(data)
+}
+
+// UpdateAccountSelection 在同一事务内读取、修改并写回账号选择状态；updateFunc 返回错误时放弃写入
+func (r *repository) UpdateAccountSelection(updateFunc func(*AccountSelectionState) error) error {
+	return r.store.UpdateGlobal(accountSelectionName, func(data []byte) ([]byte, error) {
+		state, err := parseAccountSelectionState(data)
+		if err != nil {
+			return nil, err
+		}
+		if err := updateFunc(state); err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(state, "", "  ")
+	})
+}
+
+// RecordAccountSelection 记录为视频选择的上传账号及原因
+func (r *repository) RecordAccountSelection(videoDir string, record AccountSelectionRecord) error {
+	return r.updateUploadStatus(videoDir, func(status *UploadStatus) error {
+		status.AccountSelection = &record
+		return nil
+	})
+}
@@ -10,6 +10,9 @@ import (
 	"unicode"
 	"unicode/utf8"
 
+	"blueberry/internal/config"
+	"blueberry/pkg/utils"
+
 	"github.com/rs/zerolog/log"
 )
 
@@ -67,6 +70,8 @@ type Repository interface {
 
 	MarkVideoDownloading(videoDir string, videoURL string) error
 	MarkVideoFailed(videoDir string, errorMsg string) error
+	// 下载被中断（Ctrl+C / SIGTERM）时将视频回滚为 pending，保留 .part 以便下次续传
+	MarkVideoInterrupted(videoDir string) error
 	InitializeDownloadStatus(videoDir string, videoURL string, subtitleURLs map[string]string, subtitleLanguages []string, thumbnailURL string) error
 	MarkSubtitlesDownloaded(videoDir string, languages []string) error
 	MarkSubtitlesDownloadedWithPaths(videoDir string, languages []string, subtitlePaths map[string]string, subtitleURLs map[string]string) error
@@ -87,6 +92,34 @@ type Repository interface {
 	MarkVideoUploading(videoDir string) error
 	MarkVideoUploaded(videoDir string, bilibiliAID string, bilibiliAccount string, bilibiliUserID string, fileSize int64) error
 	MarkVideoUploadFailed(videoDir string, errorMsg string) error
+	// 上传被中断时将视频回滚为 pending，不计为失败
+	MarkVideoUploadInterrupted(videoDir string) error
+	// 分块上传会话（进程重启后续传）
+	LoadUploadSession(videoDir string) (*UploadSession, error)
+	SaveUploadSession(videoDir string, session *UploadSession) error
+	ClearUploadSession(videoDir string) error
+	// 记录各语言字幕的上传结果（与已有记录合并，key 为 YouTube 语言代码）
+	MarkSubtitlesUploaded(videoDir string, results map[string]SubtitleUpload) error
+	// 记录稿件的审核 / 转码状态
+	MarkVideoReviewStatus(videoDir string, review ReviewStatus) error
+	// 记录提交发布的投稿信息与清洗修改记录
+	RecordPublishedMetadata(videoDir string, meta PublishedMetadata) error
+	// 记录稿件的定时发布时间（0 表示立即发布）
+	MarkVideoScheduledPublish(videoDir string, at int64) error
+	// 记录稿件已加入的播放列表
+	MarkVideoPlaylist(videoDir, playlistID string) error
+	// 审核退回的视频重新排队上传：当前 aid 记入 rejected_aids，状态回到 pending
+	MarkVideoResubmit(videoDir string) error
+	// 将视频关联到 B站已存在的稿件（reconcile：补记漏记的 aid 或修正账号）
+	LinkUploadedArchive(videoDir, bilibiliAID, bilibiliAccount, bilibiliUserID string) error
+	// 本地记录的稿件在 B站已不存在：清除 aid 并回到 pending 以便重新上传
+	MarkVideoUploadLost(videoDir string, reason string) error
+	// 全局已上传视频账本（按 YouTube 视频 ID，跨账号 / 服务器防止重复上传）
+	LookupUploadLedger(videoID string) (*LedgerEntry, error)
+	RecordUploadLedger(entry LedgerEntry) error
+	ForgetUploadLedger(videoID, aid string) error
+	LoadUploadLedger() ([]LedgerEntry, error)
+	MergeUploadLedger(entries []LedgerEntry) (LedgerMergeResult, error)
 	FindCoverFile(videoDir string) (string, error)
 	// 从 download_status.json 中提取字幕语言列表
 	GetSubtitleLanguagesFromStatus(videoDir string) ([]string, error)
@@ -98,6 +131,17 @@ type Repository interface {
 	GetTodayUploadCount(account string) (int, error)
 	IncrementTodayUploadCount(account string) error
 	LoadTodayUploadCounts() (map[string]int, error)
+	// 定时发布排期（.global/publish_schedule）
+	ReservePublishSlot(account, videoDir string, pick func(booked []int64) (int64, error)) (int64, error)
+	ReleasePublishSlot(account, videoDir string) error
+	LoadPublishSchedule() (map[string][]ScheduledPublish, error)
+	// 各账号解析出的播放列表 ID（.global/playlists，key 为播放列表标题）
+	LookupPlaylistID(account, title string) (string, error)
+	SavePlaylistID(account, title, playlistID string) (string, error)
+	// 账号选择策略的状态（.global/account_selection）与每个视频的选择记录
+	LoadAccountSelection() (*AccountSelectionState, error)
+	UpdateAccountSelection(updateFunc func(*AccountSelectionState) error) error
+	RecordAccountSelection(videoDir string, record AccountSelectionRecord) error
 	// 下载计数（每N个视频后休息）
 	GetTodayDownloadCount() (int, error)
 	IncrementTodayDownloadCount() error
@@ -115,6 +159,15 @@ type Repository interface {
 	GetDownloadVideoStatus(videoDir string) (string, bool, string, error)
 	// 标记是否存在（或已获得）1080p（或更高）的视频
 	SetVideoHas1080p(videoDir string, has1080p bool) error
+	// 清除上传状态（用于重新上传）
+	ClearUploadStatus(videoDir string) error
+	// 按上传状态列出视频目录（bolt 后端走索引）
+	ListVideoDirsByUploadStatus(status string) ([]string, error)
+	// 当前使用的状态存储后端（json / bolt）
+	StateBackend() string
+	// 读取类型化的下载/上传状态（旧格式自动升级）
+	LoadDownloadStatus(videoDir string) (*DownloadStatus, error)
+	LoadUploadStatus(videoDir string) (*UploadStatus, error)
 }
 
 // VideoInfo 视频信息结构，用于保存到JSON文件
@@ -218,11 +271,30 @@ type PendingResourceStatus struct {
 
 type repository struct {
 	outputDir string
+	store     StateStore
 }
 
+// NewRepository 创建文件仓库，状态后端由配置 output.state_backend 决定（未加载配置时使用 json）
 func NewRepository(outputDir string) Repository {
+	backend, stateFile := StateBackendJSON, ""
+	if cfg := config.Get(); cfg != nil {
+		backend = cfg.Output.StateBackend
+		stateFile = cfg.Output.StateFile
+	}
+	store, err := NewStateStore(backend, outputDir, stateFile)
+	if err != nil {
+		// 配置校验阶段已拦截非法后端，这里兜底回退到 json
+		log.Warn().Err(err).Msg("创建状态存储失败，回退到 json 后端")
+		store = newJSONStateStore(outputDir)
+	}
+	return NewRepositoryWithStore(outputDir, store)
+}
+
+// NewRepositoryWithStore 使用指定的状态后端创建文件仓库
+func NewRepositoryWithStore(outputDir string, store StateStore) Repository {
 	return &repository{
 		outputDir: outputDir,
+		store:     store,
 	}
 }
 
@@ -407,7 +479,7 @@ func (r *repository) SaveVideoInfo(videoDir string, videoInfo *VideoInfo) error
 		return fmt.Errorf("序列化视频信息失败: %w", err)
 	}
 
-	if err := os.WriteFile(infoPath, data, 0644); err != nil {
+	if err := utils.WriteFileAtomic(infoPath, data, 0644); err != nil {
 		return fmt.Errorf("保存视频信息失败: %w", err)
 	}
 
@@ -433,7 +505,7 @@ func (r *repository) SaveChannelInfo(channelID string, videos []map[string]inter
 		return fmt.Errorf("序列化频道信息失败: %w", err)
 	}
 
-	if err := os.WriteFile(infoPath, data, 0644); err != nil {
+	if err := utils.WriteFileAtomic(infoPath, data, 0644); err != nil {
 		return fmt.Errorf("保存频道信息失败: %w", err)
 	}
 
@@ -603,34 +675,15 @@ func (r *repository) SanitizeTitle(title string) string {
 
 // IsVideoDownloaded 检查视频是否已下载完成
 func (r *repository) IsVideoDownloaded(videoDir string) bool {
-	// 检查下载状态文件
-	statusFile := filepath.Join(videoDir, "download_status.json")
-	data, err := os.ReadFile(statusFile)
+	// 读取下载状态
+	status, err := r.store.LoadDownloadStatus(videoDir)
 	if err != nil {
-		// 文件不存在，说明未下载
+		// 状态不存在，说明未下载
 		return false
 	}
 
-	var status map[string]interface{}
-	if err := json.Unmarshal(data, &status); err != nil {
-		return false
-	}
-
-	// 检查视频下载状态
-	var downloaded bool
-	if video, ok := status["video"].(map[string]interface{}); ok {
-		if d, ok := video["downloaded"].(bool); ok {
-			downloaded = d
-		}
-	} else if video, ok := status["video"].(bool); ok {
-		// 兼容旧格式（直接是 bool）
-		downloaded = video
-	} else {
-		return false
-	}
-
-	// 如果状态文件标记为已下载，还需要验证实际文件是否存在且完整
-	if downloaded {
+	// 如果状态标记为已下载，还需要验证实际文件是否存在且完整
+	if status.Video.Downloaded {
 		// 检查目录中是否存在临时文件（.part, .temp.mp4, .temp 等），如果存在说明还在下载中
 		hasTempFiles, tempFiles := r.HasTemporaryFiles(videoDir)
 		if hasTempFiles {
@@ -651,166 +704,89 @@ func (r *repository) IsVideoDownloaded(videoDir string) bool {
 		}
 	}
 
-	return downloaded
+	return status.Video.Downloaded
 }
 
 // IsSubtitlesDownloaded 检查字幕是否已下载完成
 func (r *repository) IsSubtitlesDownloaded(videoDir string, languages []string) bool {
-	// 检查下载状态文件
-	statusFile := filepath.Join(videoDir, "download_status.json")
-	data, err := os.ReadFile(statusFile)
+	// 读取下载状态
+	status, err := r.store.LoadDownloadStatus(videoDir)
 	if err != nil {
 		return false
 	}
 
-	var status map[string]interface{}
-	if err := json.Unmarshal(data, &status); err != nil {
-		return false
-	}
-
 	// 如果 languages 为空，检查是否有任何字幕
 	if len(languages) == 0 {
-		if subtitles, ok := status["subtitles"].(map[string]interface{}); ok {
-			for _, subData := range subtitles {
-				// 新格式：map[string]interface{}
-				if subMap, ok := subData.(map[string]interface{}); ok {
-					// 检查 status 字段，如果状态是 "failed" 或 "pending"，不算已下载
-					if statusStr, ok := subMap["status"].(string); ok {
-						if statusStr == "failed" || statusStr == "pending" {
-							continue
-						}
-					}
-					if downloaded, ok := subMap["downloaded"].(bool); ok && downloaded {
-						return true
-					}
-				}
-				// 兼容旧格式：直接是 bool
-				if downloadedBool, ok := subData.(bool); ok && downloadedBool {
-					return true
-				}
+		for _, sub := range status.Subtitles {
+			if sub.Downloaded {
+				return true
 			}
 		}
 		return false
 	}
 
 	// 检查所有需要的语言是否都已下载
-	if subtitles, ok := status["subtitles"].(map[string]interface{}); ok {
-		for _, lang := range languages {
-			subData, exists := subtitles[lang]
-			if !exists {
-				return false
-			}
-			// 新格式：map[string]interface{}
-			if subMap, ok := subData.(map[string]interface{}); ok {
-				// 检查 status 字段，如果状态是 "failed" 或 "pending"，需要重新下载
-				if statusStr, ok := subMap["status"].(string); ok {
-					if statusStr == "failed" || statusStr == "pending" {
-						return false
-					}
-				}
-				// 检查 downloaded 字段
-				if downloaded, ok := subMap["downloaded"].(bool); !ok || !downloaded {
-					return false
-				}
-			} else if downloadedBool, ok := subData.(bool); ok {
-				// 兼容旧格式：直接是 bool
-				if !downloadedBool {
-					return false
-				}
-			} else {
-				return false
-			}
+	for _, lang := range languages {
+		sub, exists := status.Subtitles[lang]
+		if !exists || !sub.Downloaded {
+			return false
 		}
-		return true
 	}
-
-	return false
+	return true
 }
 
 // IsThumbnailDownloaded 检查缩略图是否已下载完成
 func (r *repository) IsThumbnailDownloaded(videoDir string) bool {
-	// 检查下载状态文件
-	statusFile := filepath.Join(videoDir, "download_status.json")
-	data, err := os.ReadFile(statusFile)
+	// 读取下载状态
+	status, err := r.store.LoadDownloadStatus(videoDir)
 	if err != nil {
 		return false
 	}
-
-	var status map[string]interface{}
-	if err := json.Unmarshal(data, &status); err != nil {
-		return false
-	}
-
-	// 检查缩略图下载状态
-	if thumbnail, ok := status["thumbnail"].(map[string]interface{}); ok {
-		if downloaded, ok := thumbnail["downloaded"].(bool); ok {
-			return downloaded
-		}
-	}
-	// 兼容旧格式（直接是 bool）
-	if thumbnail, ok := status["thumbnail"].(bool); ok {
-		return thumbnail
-	}
-	return false
+	return status.Thumbnail != nil && status.Thumbnail.Downloaded
 }
 
 // GetDownloadVideoStatus 返回视频下载状态、downloaded 标志与错误信息
 // 如果目录中存在临时文件（.part, .temp.mp4, .temp 等），即使状态文件标记为已下载，也会返回 downloaded=false
 func (r *repository) GetDownloadVideoStatus(videoDir string) (string, bool, string, error) {
-	statusFile := filepath.Join(videoDir, "download_status.json")
-	data, err := os.ReadFile(statusFile)
+	status, err := r.store.LoadDownloadStatus(videoDir)
 	if err != nil {
 		return "", false, "", err
 	}
-	var status map[string]interface{}
-	if err := json.Unmarshal(data, &status); err != nil {
-		return "", false, "", err
-	}
-	if video, ok := status["video"].(map[string]interface{}); ok {
-		st := ""
-		if s, ok := video["status"].(string); ok {
-			st = s
-		}
-		dl := false
-		if d, ok := video["downloaded"].(bool); ok {
-			dl = d
-		}
-		errMsg := ""
-		if e, ok := video["error"].(string); ok {
-			errMsg = e
-		}
+	video := status.Video
+	dl := video.Downloaded
 
-		// 如果状态文件标记为已下载，需要检查目录中是否存在临时文件
-		// 如果存在临时文件，说明还在下载中，不应该视为下载完成
-		if dl {
-			hasTempFiles, tempFiles := r.HasTemporaryFiles(videoDir)
-			if hasTempFiles {
-				log.Debug().
-					Str("video_dir", videoDir).
-					Strs("temp_files", tempFiles).
-					Msg("状态文件标记为已下载，但检测到临时文件，认为下载未完成")
-				dl = false
-			}
+	// 如果状态文件标记为已下载，需要检查目录中是否存在临时文件
+	// 如果存在临时文件，说明还在下载中，不应该视为下载完成
+	if dl {
+		hasTempFiles, tempFiles := r.HasTemporaryFiles(videoDir)
+		if hasTempFiles {
+			log.Debug().
+				Str("video_dir", videoDir).
+				Strs("temp_files", tempFiles).
+				Msg("状态文件标记为已下载，但检测到临时文件，认为下载未完成")
+			dl = false
 		}
-
-		return st, dl, errMsg, nil
 	}
-	return "", false, "", nil
+
+	return string(video.Status), dl, video.Error, nil
+}
+
+// LoadDownloadStatus 读取视频目录的下载状态（旧格式会自动升级为当前结构）
+func (r *repository) LoadDownloadStatus(videoDir string) (*DownloadStatus, error) {
+	return r.store.LoadDownloadStatus(videoDir)
+}
+
+// LoadUploadStatus 读取视频目录的上传状态（旧格式会自动升级为当前结构）
+func (r *repository) LoadUploadStatus(videoDir string) (*UploadStatus, error) {
+	return r.store.LoadUploadStatus(videoDir)
 }
 
 // SetVideoHas1080p 在 download_status.json 中记录视频是否至少达到 1080p
 func (r *repository) SetVideoHas1080p(videoDir string, has1080p bool) error {
-	return r.updateDownloadStatus(videoDir, func(status map[string]interface{}) {
-		if status["video"] == nil {
-			status["video"] = make(map[string]interface{})
-		}
-		video, ok := status["video"].(map[string]interface{})
-		if !ok {
-			video = make(map[string]interface{})
-			status["video"] = video
-		}
-		video["has_1080p"] = has1080p
-		video["has_1080p_marked_at"] = time.Now().Unix()
+	return r.updateDownloadStatus(videoDir, func(status *DownloadStatus) error {
+		status.Video.Has1080p = &has1080p
+		status.Video.Has1080pMarkedAt = time.Now().Unix()
+		return nil
 	})
 }
 
@@ -821,172 +797,124 @@ func (r *repository) MarkVideoDownloaded(videoDir string) error {
 
 // MarkVideoDownloadedWithPath 标记视频已下载完成，并记录文件路径
 func (r *repository) MarkVideoDownloadedWithPath(videoDir, videoPath string) error {
-	return r.updateDownloadStatus(videoDir, func(status map[string]interface{}) {
-		if status["video"] == nil {
-			status["video"] = make(map[string]interface{})
-		}
-		video, ok := status["video"].(map[string]interface{})
-		if !ok {
-			// 兼容旧格式，如果是 bool，转换为 map
-			video = make(map[string]interface{})
-			status["video"] = video
-		}
-		video["status"] = "completed"
-		video["resource_type"] = "video"
-		video["downloaded"] = true
-		video["downloaded_at"] = time.Now().Unix()
-		if videoPath != "" {
-			video["file_path"] = videoPath
-		}
+	return r.updateDownloadStatus(videoDir, func(status *DownloadStatus) error {
+		markResourceCompleted(&status.Video.ResourceStatus, ResourceTypeVideo, videoPath, "")
+		return nil
 	})
 }
 
 // MarkVideoDownloading 标记视频开始下载（重置失败状态，允许重新下载）
 func (r *repository) MarkVideoDownloading(videoDir string, videoURL string) error {
-	return r.updateDownloadStatus(videoDir, func(status map[string]interface{}) {
-		if status["video"] == nil {
-			status["video"] = make(map[string]interface{})
-		}
-		video, ok := status["video"].(map[string]interface{})
-		if !ok {
-			video = make(map[string]interface{})
-			status["video"] = video
-		}
+	return r.updateDownloadStatus(videoDir, func(status *DownloadStatus) error {
+		video := &status.Video.ResourceStatus
 		// 重置状态，允许重新下载（即使之前失败过）
-		video["status"] = "downloading"
-		video["downloaded"] = false
-		video["resource_type"] = "video"
+		video.Status = ResourceDownloading
+		video.Downloaded = false
+		video.ResourceType = ResourceTypeVideo
 		// 清除之前的错误信息
-		delete(video, "error")
-		delete(video, "failed_at")
+		video.Error = ""
+		video.FailedAt = 0
 		if videoURL != "" {
-			video["url"] = videoURL
+			video.URL = videoURL
 		}
+		return nil
 	})
 }
 
 // InitializeDownloadStatus 初始化下载状态文件，包含所有资源的 URL（从 video_info.json 或 rawData 中读取）
 // subtitleLanguages: 需要下载的字幕语言列表（即使没有URL，也会保存语言列表）
 func (r *repository) InitializeDownloadStatus(videoDir string, videoURL string, subtitleURLs map[string]string, subtitleLanguages []string, thumbnailURL string) error {
-	return r.updateDownloadStatus(videoDir, func(status map[string]interface{}) {
+	return r.updateDownloadStatus(videoDir, func(status *DownloadStatus) error {
 		// 初始化视频状态
-		if status["video"] == nil {
-			status["video"] = make(map[string]interface{})
-		}
-		video, ok := status["video"].(map[string]interface{})
-		if !ok {
-			video = make(map[string]interface{})
-			status["video"] = video
-		}
-		// 如果还没有状态，设置为 pending
-		// 注意：如果之前失败过（status == "failed"），不覆盖，保持失败状态以便重新下载
-		if _, hasStatus := video["status"]; !hasStatus {
-			video["status"] = "pending"
-			video["downloaded"] = false
-			video["resource_type"] = "video"
-		} else if status, ok := video["status"].(string); ok && status == "failed" {
-			// 如果之前失败过，确保 downloaded 为 false，允许重新下载
-			video["downloaded"] = false
-		}
+		// 注意：如果之前失败过（status == failed），不覆盖，保持失败状态以便重新下载
+		video := &status.Video.ResourceStatus
+		initResourcePending(video, ResourceTypeVideo)
 		if videoURL != "" {
-			video["url"] = videoURL
-		}
-
-		// 初始化字幕状态
-		if status["subtitles"] == nil {
-			status["subtitles"] = make(map[string]interface{})
-		}
-		subtitles, ok := status["subtitles"].(map[string]interface{})
-		if !ok {
-			subtitles = make(map[string]interface{})
-			status["subtitles"] = subtitles
+			video.URL = videoURL
 		}
 
 		// 首先，为所有有URL的字幕设置状态
 		for lang, url := range subtitleURLs {
-			if subtitles[lang] == nil {
-				subtitles[lang] = make(map[string]interface{})
-			}
-			sub, ok := subtitles[lang].(map[string]interface{})
-			if !ok {
-				sub = make(map[string]interface{})
-				subtitles[lang] = sub
-			}
-			// 如果还没有状态，设置为 pending
-			if _, hasStatus := sub["status"]; !hasStatus {
-				sub["status"] = "pending"
-				sub["downloaded"] = false
-				sub["resource_type"] = "subtitle"
-			}
+			sub := status.Subtitle(lang)
+			initResourcePending(sub, ResourceTypeSubtitle)
 			if url != "" {
-				sub["url"] = url
+				sub.URL = url
 			}
 		}
 
 		// 然后，为所有配置的语言（即使没有URL）也设置状态
-		// 这样后续下载时可以根据语言列表来下载
+		// 这样后续下载时可以根据语言列表来下载；没有 url 表示需要后续下载时获取
 		for _, lang := range subtitleLanguages {
-			// 如果已经有URL了，跳过（上面已经处理了）
 			if _, hasURL := subtitleURLs[lang]; hasURL {
 				continue
 			}
-			// 如果没有URL，也创建一个条目，标记为 pending，但没有 url
-			if subtitles[lang] == nil {
-				subtitles[lang] = make(map[string]interface{})
-			}
-			sub, ok := subtitles[lang].(map[string]interface{})
-			if !ok {
-				sub = make(map[string]interface{})
-				subtitles[lang] = sub
-			}
-			// 如果还没有状态，设置为 pending
-			if _, hasStatus := sub["status"]; !hasStatus {
-				sub["status"] = "pending"
-				sub["downloaded"] = false
-				sub["resource_type"] = "subtitle"
-				// 不设置 url，表示需要后续下载时获取
-			}
+			initResourcePending(status.Subtitle(lang), ResourceTypeSubtitle)
 		}
 
 		// 初始化缩略图状态
 		if thumbnailURL != "" {
-			if status["thumbnail"] == nil {
-				status["thumbnail"] = make(map[string]interface{})
-			}
-			thumbnail, ok := status["thumbnail"].(map[string]interface{})
-			if !ok {
-				thumbnail = make(map[string]interface{})
-				status["thumbnail"] = thumbnail
-			}
-			// 如果还没有状态，设置为 pending
-			if _, hasStatus := thumbnail["status"]; !hasStatus {
-				thumbnail["status"] = "pending"
-				thumbnail["downloaded"] = false
-				thumbnail["resource_type"] = "thumbnail"
-			}
-			thumbnail["url"] = thumbnailURL
+			thumbnail := status.EnsureThumbnail()
+			initResourcePending(thumbnail, ResourceTypeThumbnail)
+			thumbnail.URL = thumbnailURL
 		}
+		return nil
 	})
 }
 
+// initResourcePending 资源还没有状态时设置为 pending
+func initResourcePending(res *ResourceStatus, resourceType string) {
+	if res.Status == "" {
+		res.Status = ResourcePending
+		res.Downloaded = false
+		res.ResourceType = resourceType
+	}
+}
+
+// markResourceCompleted 将资源标记为下载完成，并清除之前失败的痕迹
+func markResourceCompleted(res *ResourceStatus, resourceType, filePath, url string) {
+	res.Status = ResourceCompleted
+	res.ResourceType = resourceType
+	res.Downloaded = true
+	res.DownloadedAt = time.Now().Unix()
+	res.Error = ""
+	res.FailedAt = 0
+	if filePath != "" {
+		res.FilePath = filePath
+	}
+	if url != "" {
+		res.URL = url
+	}
+}
+
+// markResourceFailed 将资源标记为下载失败
+func markResourceFailed(res *ResourceStatus, resourceType, errorMsg string) {
+	res.Status = ResourceFailed
+	res.Downloaded = false
+	res.ResourceType = resourceType
+	if errorMsg != "" {
+		res.Error = shortenErrorMessage(errorMsg)
+	}
+	res.FailedAt = time.Now().Unix()
+}
+
 // MarkVideoFailed 标记视频下载失败
 func (r *repository) MarkVideoFailed(videoDir string, errorMsg string) error {
-	return r.updateDownloadStatus(videoDir, func(status map[string]interface{}) {
-		if status["video"] == nil {
-			status["video"] = make(map[string]interface{})
-		}
-		video, ok := status["video"].(map[string]interface{})
-		if !ok {
-			video = make(map[string]interface{})
-			status["video"] = video
-		}
-		video["status"] = "failed"
-		video["downloaded"] = false
-		video["resource_type"] = "video"
-		if errorMsg != "" {
-			video["error"] = shortenErrorMessage(errorMsg)
+	return r.updateDownloadStatus(videoDir, func(status *DownloadStatus) error {
+		markResourceFailed(&status.Video.ResourceStatus, ResourceTypeVideo, errorMsg)
+		return nil
+	})
+}
+
+// MarkVideoInterrupted 下载被中断，回滚为 pending（不记录错误，下次运行重新下载）
+func (r *repository) MarkVideoInterrupted(videoDir string) error {
+	return r.updateDownloadStatus(videoDir, func(status *DownloadStatus) error {
+		video := &status.Video.ResourceStatus
+		if video.Status != ResourceDownloading {
+			return nil
 		}
-		video["failed_at"] = time.Now().Unix()
+		video.Status = ResourcePending
+		video.Downloaded = false
+		return nil
 	})
 }
 
@@ -999,43 +927,11 @@ func (r *repository) MarkSubtitlesDownloaded(videoDir string, languages []string
 
 // MarkSubtitlesDownloadedWithPaths 标记字幕已下载完成，并记录文件路径和URL
 func (r *repository) MarkSubtitlesDownloadedWithPaths(videoDir string, languages []string, subtitlePaths map[string]string, subtitleURLs map[string]string) error {
-	return r.updateDownloadStatus(videoDir, func(status map[string]interface{}) {
-		if status["subtitles"] == nil {
-			status["subtitles"] = make(map[string]interface{})
-		}
-		subtitles, ok := status["subtitles"].(map[string]interface{})
-		if !ok {
-			subtitles = make(map[string]interface{})
-			status["subtitles"] = subtitles
-		}
+	return r.updateDownloadStatus(videoDir, func(status *DownloadStatus) error {
 		for _, lang := range languages {
-			subData := subtitles[lang]
-			var sub map[string]interface{}
-			if subMap, ok := subData.(map[string]interface{}); ok {
-				sub = subMap
-			} else {
-				// 兼容旧格式，如果是 bool，转换为 map
-				sub = make(map[string]interface{})
-				subtitles[lang] = sub
-			}
-			sub["status"] = "completed"
-			sub["resource_type"] = "subtitle"
-			sub["downloaded"] = true
-			sub["downloaded_at"] = time.Now().Unix()
-			// 清除之前失败的痕迹
-			delete(sub, "error")
-			delete(sub, "failed_at")
-			if subtitlePaths != nil {
-				if path, ok := subtitlePaths[lang]; ok {
-					sub["file_path"] = path
-				}
-			}
-			if subtitleURLs != nil {
-				if url, ok := subtitleURLs[lang]; ok {
-					sub["url"] = url
-				}
-			}
+			markResourceCompleted(status.Subtitle(lang), ResourceTypeSubtitle, subtitlePaths[lang], subtitleURLs[lang])
 		}
+		return nil
 	})
 }
 
@@ -1047,91 +943,28 @@ func (r *repository) MarkThumbnailDownloaded(videoDir string) error {
 
 // MarkThumbnailDownloadedWithPath 标记缩略图已下载完成，并记录文件路径和URL
 func (r *repository) MarkThumbnailDownloadedWithPath(videoDir, thumbnailPath string, thumbnailURL string) error {
-	return r.updateDownloadStatus(videoDir, func(status map[string]interface{}) {
-		if status["thumbnail"] == nil {
-			status["thumbnail"] = make(map[string]interface{})
-		}
-		thumbnail, ok := status["thumbnail"].(map[string]interface{})
-		if !ok {
-			// 兼容旧格式，如果是 bool，转换为 map
-			thumbnail = make(map[string]interface{})
-			status["thumbnail"] = thumbnail
-		}
-		thumbnail["status"] = "completed"
-		thumbnail["resource_type"] = "thumbnail"
-		thumbnail["downloaded"] = true
-		thumbnail["downloaded_at"] = time.Now().Unix()
-		if thumbnailPath != "" {
-			thumbnail["file_path"] = thumbnailPath
-		}
-		if thumbnailURL != "" {
-			thumbnail["url"] = thumbnailURL
-		}
+	return r.updateDownloadStatus(videoDir, func(status *DownloadStatus) error {
+		markResourceCompleted(status.EnsureThumbnail(), ResourceTypeThumbnail, thumbnailPath, thumbnailURL)
+		return nil
 	})
 }
 
 // MarkSubtitleFailed 标记字幕下载失败
 func (r *repository) MarkSubtitleFailed(videoDir string, lang string, errorMsg string) error {
-	return r.updateDownloadStatus(videoDir, func(status map[string]interface{}) {
-		if status["subtitles"] == nil {
-			status["subtitles"] = make(map[string]interface{})
-		}
-		subtitles, ok := status["subtitles"].(map[string]interface{})
-		if !ok {
-			subtitles = make(map[string]interface{})
-			status["subtitles"] = subtitles
-		}
-
-		subData := subtitles[lang]
-		var sub map[string]interface{}
-		if subMap, ok := subData.(map[string]interface{}); ok {
-			sub = subMap
-		} else {
-			// 兼容旧格式，如果是 bool，转换为 map
-			sub = make(map[string]interface{})
-			subtitles[lang] = sub
-		}
-
-		sub["status"] = "failed"
-		sub["downloaded"] = false
-		sub["resource_type"] = "subtitle"
-		if errorMsg != "" {
-			sub["error"] = shortenErrorMessage(errorMsg)
-		}
-		sub["failed_at"] = time.Now().Unix()
+	return r.updateDownloadStatus(videoDir, func(status *DownloadStatus) error {
+		markResourceFailed(status.Subtitle(lang), ResourceTypeSubtitle, errorMsg)
+		return nil
 	})
 }
 
-// updateDownloadStatus 更新下载状态文件
-func (r *repository) updateDownloadStatus(videoDir string, updateFunc func(map[string]interface{})) error {
-	statusFile := filepath.Join(videoDir, "download_status.json")
-
+// updateDownloadStatus 更新下载状态（读-改-写由状态后端在同一事务内完成，写入前校验状态合法性）
+func (r *repository) updateDownloadStatus(videoDir string, updateFunc func(*DownloadStatus) error) error {
 	// 确保视频目录存在
 	if err := os.MkdirAll(videoDir, 0755); err != nil {
 		return fmt.Errorf("创建视频目录失败: %w", err)
 	}
 
-	// 读取现有状态
-	status := make(map[string]interface{})
-	if data, err := os.ReadFile(statusFile); err == nil {
-		json.Unmarshal(data, &status)
-	}
-
-	// 确保 subtitles 字段存在
-	if status["subtitles"] == nil {
-		status["subtitles"] = make(map[string]interface{})
-	}
-
-	// 更新状态
-	updateFunc(status)
-
-	// 保存状态
-	data, err := json.MarshalIndent(status, "", "  ")
-	if err != nil {
-		return fmt.Errorf("序列化下载状态失败: %w", err)
-	}
-
-	if err := os.WriteFile(statusFile, data, 0644); err != nil {
+	if err := r.store.UpdateDownloadStatus(videoDir, updateFunc); err != nil {
 		return fmt.Errorf("保存下载状态失败: %w", err)
 	}
 
@@ -1150,6 +983,21 @@ func (r *repository) EnsureVideoDirByTitle(channelID, title string) (string, err
 
 // SavePendingDownloads 保存待下载资源状态
 func (r *repository) SavePendingDownloads(channelID string, pending *PendingDownloads) error {
+	lock, err := r.lockPendingDownloads(channelID)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	return r.savePendingDownloads(channelID, pending)
+}
+
+// lockPendingDownloads 获取频道 pending_downloads.json 的跨进程锁
+func (r *repository) lockPendingDownloads(channelID string) (*utils.FileLock, error) {
+	return utils.LockFile(filepath.Join(r.outputDir, channelID, ".pending_downloads.lock"))
+}
+
+// savePendingDownloads 保存待下载资源状态（调用方需持有锁）
+func (r *repository) savePendingDownloads(channelID string, pending *PendingDownloads) error {
 	channelDir := filepath.Join(r.outputDir, channelID)
 	statusFile := filepath.Join(channelDir, "pending_downloads.json")
 
@@ -1158,7 +1006,7 @@ func (r *repository) SavePendingDownloads(channelID string, pending *PendingDown
 		return fmt.Errorf("序列化待下载状态失败: %w", err)
 	}
 
-	if err := os.WriteFile(statusFile, data, 0644); err != nil {
+	if err := utils.WriteFileAtomic(statusFile, data, 0644); err != nil {
 		return fmt.Errorf("保存待下载状态失败: %w", err)
 	}
 
@@ -1185,6 +1033,12 @@ func (r *repository) LoadPendingDownloads(channelID string) (*PendingDownloads,
 
 // UpdatePendingDownloadStatus 更新待下载资源状态
 func (r *repository) UpdatePendingDownloadStatus(channelID, videoID, resourceType, status, filePath string) error {
+	lock, err := r.lockPendingDownloads(channelID)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
 	pending, err := r.LoadPendingDownloads(channelID)
 	if err != nil {
 		// 如果文件不存在，不更新
@@ -1230,124 +1084,307 @@ func (r *repository) UpdatePendingDownloadStatus(channelID, videoID, resourceTyp
 		}
 	}
 
-	return r.SavePendingDownloads(channelID, pending)
+	return r.savePendingDownloads(channelID, pending)
 }
 
 // IsVideoUploaded 检查视频是否已上传到B站
 func (r *repository) IsVideoUploaded(videoDir string) bool {
-	statusFile := filepath.Join(videoDir, "upload_status.json")
-	data, err := os.ReadFile(statusFile)
+	status, err := r.store.LoadUploadStatus(videoDir)
 	if err != nil {
-		// 文件不存在，说明未上传
+		// 状态不存在，说明未上传
 		return false
 	}
-
-	var status map[string]interface{}
-	if err := json.Unmarshal(data, &status); err != nil {
-		return false
-	}
-
-	// 检查上传状态
-	if uploadStatus, ok := status["status"].(string); ok {
-		return uploadStatus == "completed"
-	}
-
-	// 兼容旧格式（直接是 bool）
-	if uploaded, ok := status["uploaded"].(bool); ok {
-		return uploaded
-	}
-
-	return false
+	return status.Status == UploadCompleted
 }
 
 // MarkVideoUploading 标记视频开始上传
 func (r *repository) MarkVideoUploading(videoDir string) error {
-	return r.updateUploadStatus(videoDir, func(status map[string]interface{}) {
-		status["status"] = "uploading"
-		status["uploaded"] = false
-		status["started_at"] = time.Now().Unix()
+	return r.updateUploadStatus(videoDir, func(status *UploadStatus) error {
+		status.Status = UploadUploading
+		status.Uploaded = false
+		status.StartedAt = time.Now().Unix()
 		// 清除之前的错误信息
-		delete(status, "error")
-		delete(status, "failed_at")
-		delete(status, "bilibili_aid")
+		status.Error = ""
+		status.FailedAt = 0
+		status.BilibiliAID = ""
+		status.ScheduledPublishAt = 0
+		status.PlaylistID = ""
+		return nil
 	})
 }
 
 // MarkVideoUploaded 标记视频上传完成
 func (r *repository) MarkVideoUploaded(videoDir string, bilibiliAID string, bilibiliAccount string, bilibiliUserID string, fileSize int64) error {
-	return r.updateUploadStatus(videoDir, func(status map[string]interface{}) {
-		status["status"] = "completed"
-		status["uploaded"] = true
-		status["bilibili_aid"] = bilibiliAID
+	err := r.updateUploadStatus(videoDir, func(status *UploadStatus) error {
+		status.Status = UploadCompleted
+		status.Uploaded = true
+		status.BilibiliAID = bilibiliAID
 		if bilibiliAccount != "" {
-			status["bilibili_account"] = bilibiliAccount
+			status.BilibiliAccount = bilibiliAccount
 		}
 		if bilibiliUserID != "" {
-			status["bilibili_userid"] = bilibiliUserID
+			status.BilibiliUserID = bilibiliUserID
 		}
 		if fileSize > 0 {
-			status["file_size"] = fileSize
-		}
-		status["completed_at"] = time.Now().Unix()
-		// 清除错误信息
-		delete(status, "error")
-		delete(status, "failed_at")
+			status.FileSize = fileSize
+		}
+		status.CompletedAt = time.Now().Unix()
+		// 清除错误信息、已完成的上传会话与旧稿件的审核状态
+		status.Error = ""
+		status.FailedAt = 0
+		status.Session = nil
+		status.Review = nil
+		return nil
 	})
+	if err != nil {
+		return err
+	}
+	r.recordLedger(videoDir, bilibiliAID, bilibiliAccount)
+	return nil
 }
 
 // MarkVideoUploadFailed 标记视频上传失败
 func (r *repository) MarkVideoUploadFailed(videoDir string, errorMsg string) error {
-	return r.updateUploadStatus(videoDir, func(status map[string]interface{}) {
-		status["status"] = "failed"
-		status["uploaded"] = false
+	return r.updateUploadStatus(videoDir, func(status *UploadStatus) error {
+		status.Status = UploadFailed
+		status.Uploaded = false
 		if errorMsg != "" {
-			status["error"] = shortenErrorMessage(errorMsg)
+			status.Error = shortenErrorMessage(errorMsg)
 		}
-		status["failed_at"] = time.Now().Unix()
+		status.FailedAt = time.Now().Unix()
+		return nil
 	})
 }
 
-// updateUploadStatus 更新上传状态文件
-func (r *repository) updateUploadStatus(videoDir string, updateFunc func(map[string]interface{})) error {
-	// 确保视频目录存在
-	if err := os.MkdirAll(videoDir, 0755); err != nil {
-		return fmt.Errorf("创建视频目录失败: %w", err)
+// MarkVideoUploadInterrupted 上传被中断，回滚为 pending（不记录错误，下次运行重新上传）
+func (r *repository) MarkVideoUploadInterrupted(videoDir string) error {
+	return r.updateUploadStatus(videoDir, func(status *UploadStatus) error {
+		if status.Status != UploadUploading {
+			return nil
+		}
+		status.Status = UploadPending
+		status.Uploaded = false
+		status.StartedAt = 0
+		return nil
+	})
+}
+
+// LoadUploadSession 读取未完成的分块上传会话，没有时返回 nil
+func (r *repository) LoadUploadSession(videoDir string) (*UploadSession, error) {
+	status, err := r.store.LoadUploadStatus(videoDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
 	}
+	return status.Session, nil
+}
 
-	statusFile := filepath.Join(videoDir, "upload_status.json")
+// SaveUploadSession 保存分块上传会话（每完成一个分块调用一次）
+func (r *repository) SaveUploadSession(videoDir string, session *UploadSession) error {
+	return r.updateUploadStatus(videoDir, func(status *UploadStatus) error {
+		session.UpdatedAt = time.Now().Unix()
+		status.Session = session
+		return nil
+	})
+}
 
-	// 读取现有状态（如果存在）
-	var status map[string]interface{}
-	data, err := os.ReadFile(statusFile)
-	if err == nil {
-		if err := json.Unmarshal(data, &status); err != nil {
-			// 如果解析失败，创建新状态
-			status = make(map[string]interface{})
-		}
-	} else {
-		// 文件不存在，创建新状态
-		status = make(map[string]interface{})
+// ClearUploadSession 丢弃分块上传会话（会话过期或文件已变化）
+func (r *repository) ClearUploadSession(videoDir string) error {
+	return r.updateUploadStatus(videoDir, func(status *UploadStatus) error {
+		status.Session = nil
+		return nil
+	})
+}
+
+// MarkSubtitlesUploaded 记录各语言字幕的上传结果；已成功的语言不会被之后的失败覆盖
+func (r *repository) MarkSubtitlesUploaded(videoDir string, results map[string]SubtitleUpload) error {
+	if len(results) == 0 {
+		return nil
 	}
+	return r.updateUploadStatus(videoDir, func(status *UploadStatus) error {
+		if status.Subtitles == nil {
+			status.Subtitles = make(map[string]*SubtitleUpload)
+		}
+		now := time.Now().Unix()
+		for lang, result := range results {
+			if prev := status.Subtitles[lang]; prev != nil && prev.Status == UploadCompleted && result.Status != UploadCompleted {
+				continue
+			}
+			result := result
+			result.Error = shortenErrorMessage(result.Error)
+			result.UpdatedAt = now
+			status.Subtitles[lang] = &result
+		}
+		return nil
+	})
+}
+
+// MarkVideoReviewStatus 记录稿件的审核 / 转码状态，状态变化时更新 changed_at
+func (r *repository) MarkVideoReviewStatus(videoDir string, review ReviewStatus) error {
+	return r.updateUploadStatus(videoDir, func(status *UploadStatus) error {
+		now := time.Now().Unix()
+		review.CheckedAt = now
+		review.RejectReason = shortenErrorMessage(review.RejectReason)
+		if status.Review == nil || status.Review.State != review.State {
+			review.ChangedAt = now
+		} else {
+			review.ChangedAt = status.Review.ChangedAt
+		}
+		status.Review = &review
+		return nil
+	})
+}
+
+// RecordPublishedMetadata 记录提交发布的投稿信息与清洗修改记录（覆盖上一次的记录）
+func (r *repository) RecordPublishedMetadata(videoDir string, meta PublishedMetadata) error {
+	return r.updateUploadStatus(videoDir, func(status *UploadStatus) error {
+		meta.UpdatedAt = time.Now().Unix()
+		status.Metadata = &meta
+		return nil
+	})
+}
 
-	// 更新时间戳
-	status["updated_at"] = time.Now().Unix()
+// MarkVideoResubmit 审核退回的视频重新排队上传：当前 aid 记入 rejected_aids，清除发布结果并回到 pending
+func (r *repository) MarkVideoResubmit(videoDir string) error {
+	rejectedAID := ""
+	err := r.updateUploadStatus(videoDir, func(status *UploadStatus) error {
+		if status.Status != UploadCompleted {
+			return fmt.Errorf("视频未处于已上传状态: %s", status.Status)
+		}
+		rejectedAID = status.BilibiliAID
+		if status.BilibiliAID != "" {
+			status.RejectedAIDs = append(status.RejectedAIDs, status.BilibiliAID)
+		}
+		status.Resubmissions++
+		status.Status = UploadPending
+		status.Uploaded = false
+		status.BilibiliAID = ""
+		status.CompletedAt = 0
+		status.StartedAt = 0
+		status.Subtitles = nil
+		status.PlaylistID = ""
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	r.forgetLedger(videoDir, rejectedAID)
+	return nil
+}
 
-	// 调用更新函数
-	updateFunc(status)
+// LinkUploadedArchive 将视频关联到 B站已存在的稿件，已完成的视频保留原完成时间
+func (r *repository) LinkUploadedArchive(videoDir, bilibiliAID, bilibiliAccount, bilibiliUserID string) error {
+	err := r.updateUploadStatus(videoDir, func(status *UploadStatus) error {
+		if status.Status != UploadCompleted || status.CompletedAt == 0 {
+			status.CompletedAt = time.Now().Unix()
+		}
+		if status.BilibiliAID != bilibiliAID {
+			status.Review = nil
+			status.Subtitles = nil
+			status.PlaylistID = ""
+		}
+		status.Status = UploadCompleted
+		status.Uploaded = true
+		status.BilibiliAID = bilibiliAID
+		status.BilibiliAccount = bilibiliAccount
+		status.BilibiliUserID = bilibiliUserID
+		status.Error = ""
+		status.FailedAt = 0
+		status.Session = nil
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	r.recordLedger(videoDir, bilibiliAID, bilibiliAccount)
+	return nil
+}
 
-	// 保存状态
-	data, err = json.MarshalIndent(status, "", "  ")
+// MarkVideoUploadLost 本地记录的稿件在 B站已不存在：清除发布结果并回到 pending，原因记录在 error 中
+func (r *repository) MarkVideoUploadLost(videoDir string, reason string) error {
+	lostAID := ""
+	err := r.updateUploadStatus(videoDir, func(status *UploadStatus) error {
+		lostAID = status.BilibiliAID
+		status.Status = UploadPending
+		status.Uploaded = false
+		status.BilibiliAID = ""
+		status.CompletedAt = 0
+		status.StartedAt = 0
+		status.Review = nil
+		status.Subtitles = nil
+		status.PlaylistID = ""
+		status.Error = shortenErrorMessage(reason)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("序列化上传状态失败: %w", err)
+		return err
 	}
+	r.forgetLedger(videoDir, lostAID)
+	return nil
+}
 
-	if err := os.WriteFile(statusFile, data, 0644); err != nil {
+// recordLedger 视频发布后写入全局上传账本（失败只记录日志，不影响上传状态）
+func (r *repository) recordLedger(videoDir, aid, account string) {
+	videoID := r.ledgerVideoID(videoDir)
+	if videoID == "" {
+		return
+	}
+	entry := LedgerEntry{VideoID: videoID, AID: aid, Account: account, VideoDir: videoDir}
+	if err := r.RecordUploadLedger(entry); err != nil {
+		log.Warn().Err(err).Str("video_dir", videoDir).Msg("写入上传账本失败")
+	}
+}
+
+// forgetLedger 稿件失效（退回重新上传 / 已不存在）时从全局上传账本中移除
+func (r *repository) forgetLedger(videoDir, aid string) {
+	if aid == "" {
+		return
+	}
+	if err := r.ForgetUploadLedger(r.ledgerVideoID(videoDir), aid); err != nil {
+		log.Warn().Err(err).Str("video_dir", videoDir).Msg("更新上传账本失败")
+	}
+}
+
+// updateUploadStatus 更新上传状态（读-改-写由状态后端在同一事务内完成，写入前校验状态合法性）
+func (r *repository) updateUploadStatus(videoDir string, updateFunc func(*UploadStatus) error) error {
+	// 确保视频目录存在
+	if err := os.MkdirAll(videoDir, 0755); err != nil {
+		return fmt.Errorf("创建视频目录失败: %w", err)
+	}
+
+	err := r.store.UpdateUploadStatus(videoDir, func(status *UploadStatus) error {
+		// 更新时间戳
+		status.UpdatedAt = time.Now().Unix()
+		return updateFunc(status)
+	})
+	if err != nil {
 		return fmt.Errorf("保存上传状态失败: %w", err)
 	}
 
 	return nil
 }
 
+// ClearUploadStatus 清除上传状态（用于重新上传）
+func (r *repository) ClearUploadStatus(videoDir string) error {
+	if err := r.store.DeleteUploadStatus(videoDir); err != nil {
+		return fmt.Errorf("清除上传状态失败: %w", err)
+	}
+	return nil
+}
+
+// ListVideoDirsByUploadStatus 返回上传状态为 status 的所有视频目录
+// bolt 后端直接使用状态索引，json 后端需要遍历输出目录
+func (r *repository) ListVideoDirsByUploadStatus(status string) ([]string, error) {
+	return r.store.ListVideoDirsByUploadStatus(status)
+}
+
+// StateBackend 返回当前使用的状态存储后端名称
+func (r *repository) StateBackend() string {
+	return r.store.Backend()
+}
+
 // FindCoverFile 查找封面图文件（cover.{ext}，支持多种图片格式）
 func (r *repository) FindCoverFile(videoDir string) (string, error) {
 	// 尝试查找 cover.{ext} 格式的文件（支持多种扩展名）
@@ -1446,72 +1483,48 @@ func (r *repository) HasTemporaryFiles(videoDir string) (bool, []string) {
 	return len(tempFiles) > 0, tempFiles
 }
 
-// GetSubtitleLanguagesFromStatus 从 download_status.json 中提取字幕语言列表
+// GetSubtitleLanguagesFromStatus 从下载状态中提取字幕语言列表
 func (r *repository) GetSubtitleLanguagesFromStatus(videoDir string) ([]string, error) {
-	statusFile := filepath.Join(videoDir, "download_status.json")
-	data, err := os.ReadFile(statusFile)
+	status, err := r.store.LoadDownloadStatus(videoDir)
 	if err != nil {
-		return nil, fmt.Errorf("读取下载状态文件失败: %w", err)
+		return nil, fmt.Errorf("读取下载状态失败: %w", err)
 	}
-
-	var status map[string]interface{}
-	if err := json.Unmarshal(data, &status); err != nil {
-		return nil, fmt.Errorf("解析下载状态文件失败: %w", err)
-	}
-
-	var languages []string
-	if subtitles, ok := status["subtitles"].(map[string]interface{}); ok {
-		for lang := range subtitles {
-			languages = append(languages, lang)
-		}
-	}
-
-	return languages, nil
+	return status.SubtitleLanguages(), nil
 }
 
 // ---------- 账号上传计数（按天） ----------
 
-func (r *repository) countersFile() string {
-	globalDir := filepath.Join(r.outputDir, ".global")
-	_ = os.MkdirAll(globalDir, 0755)
-	return filepath.Join(globalDir, "upload_counters.json")
-}
-
-func (r *repository) loadCountersRaw() (*uploadCounters, error) {
-	path := r.countersFile()
-	data, err := os.ReadFile(path)
-	if err != nil {
-		// 不存在则初始化
-		return &uploadCounters{
-			Date:   time.Now().Format("2006-01-02"),
-			Counts: map[string]int{},
-		}, nil
-	}
+// parseUploadCounters 解析上传计数，不存在、损坏或跨天时返回今天的空计数
+func parseUploadCounters(data []byte) *uploadCounters {
+	today := time.Now().Format("2006-01-02")
 	var uc uploadCounters
-	if err := json.Unmarshal(data, &uc); err != nil {
+	if len(data) == 0 || json.Unmarshal(data, &uc) != nil || uc.Date != today {
 		return &uploadCounters{
-			Date:   time.Now().Format("2006-01-02"),
-			Counts: map[string]int{},
-		}, nil
-	}
-	// 跨天则重置
-	today := time.Now().Format("2006-01-02")
-	if uc.Date != today {
-		uc = uploadCounters{
 			Date:   today,
 			Counts: map[string]int{},
 		}
 	}
-	return &uc, nil
+	if uc.Counts == nil {
+		uc.Counts = map[string]int{}
+	}
+	return &uc
 }
 
-func (r *repository) saveCountersRaw(uc *uploadCounters) error {
-	path := r.countersFile()
-	data, err := json.MarshalIndent(uc, "", "  ")
-	if err != nil {
-		return err
+func (r *repository) loadCountersRaw() (*uploadCounters, error) {
+	data, err := r.store.LoadGlobal(uploadCountersName)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
 	}
-	return os.WriteFile(path, data, 0644)
+	return parseUploadCounters(data), nil
+}
+
+// updateCountersRaw 在同一事务内读取、修改并保存上传计数
+func (r *repository) updateCountersRaw(updateFunc func(uc *uploadCounters)) error {
+	return r.store.UpdateGlobal(uploadCountersName, func(data []byte) ([]byte, error) {
+		uc := parseUploadCounters(data)
+		updateFunc(uc)
+		return json.MarshalIndent(uc, "", "  ")
+	})
 }
 
 func (r *repository) LoadTodayUploadCounts() (map[string]int, error) {
@@ -1536,75 +1549,64 @@ func (r *repository) GetTodayUploadCount(account string) (int, error) {
 }
 
 func (r *repository) IncrementTodayUploadCount(account string) error {
-	uc, err := r.loadCountersRaw()
-	if err != nil {
-		return err
-	}
-	uc.Counts[account] = uc.Counts[account] + 1
-	return r.saveCountersRaw(uc)
+	return r.updateCountersRaw(func(uc *uploadCounters) {
+		uc.Counts[account] = uc.Counts[account] + 1
+	})
 }
 
 // ---------- 下载计数（每N个视频后休息） ----------
 
-func (r *repository) downloadCountersFile() string {
-	globalDir := filepath.Join(r.outputDir, ".global")
-	_ = os.MkdirAll(globalDir, 0755)
-	return filepath.Join(globalDir, "download_counters.json")
-}
-
-func (r *repository) loadDownloadCountersRaw() (*downloadCounters, error) {
-	path := r.downloadCountersFile()
-	data, err := os.ReadFile(path)
-	if err != nil {
-		// 不存在则初始化
+// parseDownloadCounters 解析下载计数；休息时间已过时重置下载计数（保留 bot detection 计数）
+// 返回值 expired 表示是否发生了重置
+func parseDownloadCounters(data []byte) (dc *downloadCounters, expired bool) {
+	dc = &downloadCounters{}
+	if len(data) == 0 || json.Unmarshal(data, dc) != nil {
+		// 不存在或损坏则初始化
 		return &downloadCounters{
 			Date:                  time.Now().Format("2006-01-02 15:04:05"),
 			Count:                 0,
 			BotDetectionCount:     0,
 			BotDetectionRestStart: "",
-		}, nil
-	}
-	var dc downloadCounters
-	if err := json.Unmarshal(data, &dc); err != nil {
-		return &downloadCounters{
-			Date:                  time.Now().Format("2006-01-02 15:04:05"),
-			Count:                 0,
-			BotDetectionCount:     0,
-			BotDetectionRestStart: "",
-		}, nil
+		}, false
 	}
 
 	// 检查是否在休息期间
-	now := time.Now()
 	if dc.RestUntil != "" {
 		restUntil, err := time.Parse("2006-01-02 15:04:05", dc.RestUntil)
-		if err == nil {
-			if now.Before(restUntil) {
-				// 还在休息期间，保持计数
-				return &dc, nil
-			} else {
-				// 休息时间已过，重置下载计数（但保留 bot detection 计数）
-				dc.Count = 0
-				dc.RestUntil = ""
-				dc.RestDuration = 0
-				dc.Date = time.Now().Format("2006-01-02 15:04:05")
-				// 保存重置后的状态
-				_ = r.saveDownloadCountersRaw(&dc)
-			}
+		if err == nil && !time.Now().Before(restUntil) {
+			// 休息时间已过，重置下载计数（但保留 bot detection 计数）
+			dc.Count = 0
+			dc.RestUntil = ""
+			dc.RestDuration = 0
+			dc.Date = time.Now().Format("2006-01-02 15:04:05")
+			return dc, true
 		}
 	}
 
-	return &dc, nil
+	return dc, false
 }
 
-func (r *repository) saveDownloadCountersRaw(dc *downloadCounters) error {
-	path := r.downloadCountersFile()
-	dc.Date = time.Now().Format("2006-01-02 15:04:05")
-	data, err := json.MarshalIndent(dc, "", "  ")
-	if err != nil {
-		return err
+func (r *repository) loadDownloadCountersRaw() (*downloadCounters, error) {
+	data, err := r.store.LoadGlobal(downloadCountersName)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
 	}
-	return os.WriteFile(path, data, 0644)
+	dc, expired := parseDownloadCounters(data)
+	if expired {
+		// 保存重置后的状态
+		_ = r.updateDownloadCountersRaw(func(*downloadCounters) {})
+	}
+	return dc, nil
+}
+
+// updateDownloadCountersRaw 在同一事务内读取、修改并保存下载计数
+func (r *repository) updateDownloadCountersRaw(updateFunc func(dc *downloadCounters)) error {
+	return r.store.UpdateGlobal(downloadCountersName, func(data []byte) ([]byte, error) {
+		dc, _ := parseDownloadCounters(data)
+		updateFunc(dc)
+		dc.Date = time.Now().Format("2006-01-02 15:04:05")
+		return json.MarshalIndent(dc, "", "  ")
+	})
 }
 
 func (r *repository) GetTodayDownloadCount() (int, error) {
@@ -1616,12 +1618,9 @@ func (r *repository) GetTodayDownloadCount() (int, error) {
 }
 
 func (r *repository) IncrementTodayDownloadCount() error {
-	dc, err := r.loadDownloadCountersRaw()
-	if err != nil {
-		return err
-	}
-	dc.Count = dc.Count + 1
-	if err := r.saveDownloadCountersRaw(dc); err != nil {
+	if err := r.updateDownloadCountersRaw(func(dc *downloadCounters) {
+		dc.Count = dc.Count + 1
+	}); err != nil {
 		return err
 	}
 	// 使用标准库的 log 包，因为这里没有 logger 依赖
@@ -1631,14 +1630,11 @@ func (r *repository) IncrementTodayDownloadCount() error {
 
 // SetDownloadRestUntil 设置休息结束时间
 func (r *repository) SetDownloadRestUntil(restUntil time.Time, restDurationMinutes int) error {
-	dc, err := r.loadDownloadCountersRaw()
-	if err != nil {
-		return err
-	}
-	dc.RestUntil = restUntil.Format("2006-01-02 15:04:05")
-	dc.RestDuration = restDurationMinutes
-	dc.Count = 0 // 重置计数
-	if err := r.saveDownloadCountersRaw(dc); err != nil {
+	if err := r.updateDownloadCountersRaw(func(dc *downloadCounters) {
+		dc.RestUntil = restUntil.Format("2006-01-02 15:04:05")
+		dc.RestDuration = restDurationMinutes
+		dc.Count = 0 // 重置计数
+	}); err != nil {
 		return err
 	}
 	// 日志在 service 层记录，这里只负责数据持久化
@@ -1647,15 +1643,11 @@ func (r *repository) SetDownloadRestUntil(restUntil time.Time, restDurationMinut
 
 // ResetTodayDownloadCount 重置今天的下载计数
 func (r *repository) ResetTodayDownloadCount() error {
-	dc, err := r.loadDownloadCountersRaw()
-	if err != nil {
-		return err
-	}
-	dc.Count = 0
-	dc.Date = time.Now().Format("2006-01-02 15:04:05")
-	dc.RestUntil = "" // 清除休息时间
-	dc.RestDuration = 0
-	return r.saveDownloadCountersRaw(dc)
+	return r.updateDownloadCountersRaw(func(dc *downloadCounters) {
+		dc.Count = 0
+		dc.RestUntil = "" // 清除休息时间
+		dc.RestDuration = 0
+	})
 }
 
 // GetBotDetectionCount 获取机器人检测计数
@@ -1669,33 +1661,24 @@ func (r *repository) GetBotDetectionCount() (int, error) {
 
 // IncrementBotDetectionCount 增加机器人检测计数
 func (r *repository) IncrementBotDetectionCount() error {
-	dc, err := r.loadDownloadCountersRaw()
-	if err != nil {
-		return err
-	}
-	dc.BotDetectionCount++
-	return r.saveDownloadCountersRaw(dc)
+	return r.updateDownloadCountersRaw(func(dc *downloadCounters) {
+		dc.BotDetectionCount++
+	})
 }
 
 // ResetBotDetectionCount 重置机器人检测计数
 func (r *repository) ResetBotDetectionCount() error {
-	dc, err := r.loadDownloadCountersRaw()
-	if err != nil {
-		return err
-	}
-	dc.BotDetectionCount = 0
-	dc.BotDetectionRestStart = "" // 清除休息开始时间
-	return r.saveDownloadCountersRaw(dc)
+	return r.updateDownloadCountersRaw(func(dc *downloadCounters) {
+		dc.BotDetectionCount = 0
+		dc.BotDetectionRestStart = "" // 清除休息开始时间
+	})
 }
 
 // SetBotDetectionRestStart 设置机器人检测休息开始时间（只记录开始时间）
 func (r *repository) SetBotDetectionRestStart(restStart time.Time) error {
-	dc, err := r.loadDownloadCountersRaw()
-	if err != nil {
-		return err
-	}
-	dc.BotDetectionRestStart = restStart.Format("2006-01-02 15:04:05")
-	return r.saveDownloadCountersRaw(dc)
+	return r.updateDownloadCountersRaw(func(dc *downloadCounters) {
+		dc.BotDetectionRestStart = restStart.Format("2006-01-02 15:04:05")
+	})
 }
 
 // IsInBotDetectionRestPeriod 检查是否在机器人检测休息期间
@@ -1724,9 +1707,11 @@ func (r *repository) IsInBotDetectionRestPeriod(restDurationMinutes int) (bool,
 		// 还在休息期间
 		return true, restUntil, nil
 	} else {
-		// 休息时间已过，清除休息开始时间
-		dc.BotDetectionRestStart = ""
-		_ = r.saveDownloadCountersRaw(dc)
+		// 休息时间已过，清除休息开始时间并重置计数
+		_ = r.updateDownloadCountersRaw(func(dc *downloadCounters) {
+			dc.BotDetectionCount = 0
+			dc.BotDetectionRestStart = ""
+		})
 		return false, time.Time{}, nil
 	}
 }
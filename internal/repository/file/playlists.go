@@ -0,0 +1,80 @@
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// playlistsName 各账号按标题解析出的播放列表 ID（.global 下），避免每次上传都查询或重复创建
+const playlistsName = "playlists"
+
+type playlistIndex struct {
+	// Accounts key 为账号名，value 为 标题（小写）→ 播放列表 ID
+	Accounts map[string]map[string]string `json:"accounts"`
+}
+
+func parsePlaylistIndex(data []byte) (*playlistIndex, error) {
+	index := &playlistIndex{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, index); err != nil {
+			return nil, fmt.Errorf("解析播放列表记录失败: %w", err)
+		}
+	}
+	if index.Accounts == nil {
+		index.Accounts = make(map[string]map[string]string)
+	}
+	return index, nil
+}
+
+func playlistKey(title string) string {
+	return strings.ToLower(strings.TrimSpace(title))
+}
+
+// LookupPlaylistID 返回账号下该标题已记录的播放列表 ID，未记录时返回空字符串
+func (r *repository) LookupPlaylistID(account, title string) (string, error) {
+	data, err := r.store.LoadGlobal(playlistsName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("读取播放列表记录失败: %w", err)
+	}
+	index, err := parsePlaylistIndex(data)
+	if err != nil {
+		return "", err
+	}
+	return index.Accounts[account][playlistKey(title)], nil
+}
+
+// SavePlaylistID 记录账号下该标题对应的播放列表 ID；其他进程已先记录时保留已有记录，返回最终生效的 ID
+func (r *repository) SavePlaylistID(account, title, playlistID string) (string, error) {
+	saved := playlistID
+	err := r.store.UpdateGlobal(playlistsName, func(data []byte) ([]byte, error) {
+		index, err := parsePlaylistIndex(data)
+		if err != nil {
+			return nil, err
+		}
+		ids := index.Accounts[account]
+		if ids == nil {
+			ids = make(map[string]string)
+			index.Accounts[account] = ids
+		}
+		if existing := ids[playlistKey(title)]; existing != "" {
+			saved = existing
+		} else {
+			ids[playlistKey(title)] = playlistID
+		}
+		return json.MarshalIndent(index, "", "  ")
+	})
+	return saved, err
+}
+
+// MarkVideoPlaylist 记录稿件已加入的播放列表
+func (r *repository) MarkVideoPlaylist(videoDir, playlistID string) error {
+	return r.updateUploadStatus(videoDir, func(status *UploadStatus) error {
+		status.PlaylistID = playlistID
+		return nil
+	})
+}
@@ -0,0 +1,125 @@
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// publishScheduleName 定时发布排期（.global 下），记录每个账号已占用的发布时间
+const publishScheduleName = "publish_schedule"
+
+// ScheduledPublish 一个已占用的定时发布时间
+type ScheduledPublish struct {
+	At       int64  `json:"at"` // Unix 秒
+	VideoDir string `json:"video_dir"`
+}
+
+type publishSchedule struct {
+	Accounts map[string][]ScheduledPublish `json:"accounts"`
+}
+
+func parsePublishSchedule(data []byte) (*publishSchedule, error) {
+	schedule := &publishSchedule{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, schedule); err != nil {
+			return nil, fmt.Errorf("解析发布排期失败: %w", err)
+		}
+	}
+	if schedule.Accounts == nil {
+		schedule.Accounts = make(map[string][]ScheduledPublish)
+	}
+	return schedule, nil
+}
+
+// prune 移除已到发布时间的排期
+func (s *publishSchedule) prune(now int64) {
+	for account, entries := range s.Accounts {
+		kept := entries[:0]
+		for _, e := range entries {
+			if e.At > now {
+				kept = append(kept, e)
+			}
+		}
+		if len(kept) == 0 {
+			delete(s.Accounts, account)
+		} else {
+			s.Accounts[account] = kept
+		}
+	}
+}
+
+// ReservePublishSlot 为视频预约账号的发布时间：该视频已有未到期的预约时直接返回；
+// 否则以该账号已占用的时间调用 pick 选择时间并记录（同一事务内完成，多进程安全）
+func (r *repository) ReservePublishSlot(account, videoDir string, pick func(booked []int64) (int64, error)) (int64, error) {
+	var at int64
+	err := r.store.UpdateGlobal(publishScheduleName, func(data []byte) ([]byte, error) {
+		schedule, err := parsePublishSchedule(data)
+		if err != nil {
+			return nil, err
+		}
+		schedule.prune(time.Now().Unix())
+
+		entries := schedule.Accounts[account]
+		booked := make([]int64, 0, len(entries))
+		for _, e := range entries {
+			if e.VideoDir == videoDir {
+				at = e.At
+				return json.MarshalIndent(schedule, "", "  ")
+			}
+			booked = append(booked, e.At)
+		}
+		if at, err = pick(booked); err != nil {
+			return nil, err
+		}
+		entries = append(entries, ScheduledPublish{At: at, VideoDir: videoDir})
+		sort.Slice(entries, func(i, j int) bool { return entries[i].At < entries[j].At })
+		schedule.Accounts[account] = entries
+		return json.MarshalIndent(schedule, "", "  ")
+	})
+	return at, err
+}
+
+// ReleasePublishSlot 上传失败时释放视频在账号下预约的发布时间
+func (r *repository) ReleasePublishSlot(account, videoDir string) error {
+	return r.store.UpdateGlobal(publishScheduleName, func(data []byte) ([]byte, error) {
+		schedule, err := parsePublishSchedule(data)
+		if err != nil {
+			return nil, err
+		}
+		entries := schedule.Accounts[account]
+		kept := entries[:0]
+		for _, e := range entries {
+			if e.VideoDir != videoDir {
+				kept = append(kept, e)
+			}
+		}
+		schedule.Accounts[account] = kept
+		schedule.prune(time.Now().Unix())
+		return json.MarshalIndent(schedule, "", "  ")
+	})
+}
+
+// LoadPublishSchedule 返回各账号尚未到期的发布排期（按时间排序）
+func (r *repository) LoadPublishSchedule() (map[string][]ScheduledPublish, error) {
+	data, err := r.store.LoadGlobal(publishScheduleName)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取发布排期失败: %w", err)
+	}
+	schedule, err := parsePublishSchedule(data)
+	if err != nil {
+		return nil, err
+	}
+	schedule.prune(time.Now().Unix())
+	return schedule.Accounts, nil
+}
+
+// MarkVideoScheduledPublish 记录稿件的定时发布时间（0 表示立即发布）
+func (r *repository) MarkVideoScheduledPublish(videoDir string, at int64) error {
+	return r.updateUploadStatus(videoDir, func(status *UploadStatus) error {
+		status.ScheduledPublishAt = at
+		return nil
+	})
+}
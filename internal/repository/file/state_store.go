@@ -0,0 +1,295 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"blueberry/pkg/utils"
+)
+
+const (
+	// StateBackendJSON 状态保存在每个视频目录下的 download_status.json / upload_status.json 中（默认）
+	StateBackendJSON = "json"
+	// StateBackendBolt 状态保存在单文件嵌入式数据库（bbolt）中
+	StateBackendBolt = "bolt"
+
+	downloadStatusFileName = "download_status.json"
+	uploadStatusFileName   = "upload_status.json"
+	globalDirName          = ".global"
+	videoDirLockName       = ".status.lock"
+
+	uploadCountersName   = "upload_counters"
+	downloadCountersName = "download_counters"
+)
+
+// globalStateNames 需要在后端之间迁移的 .global 状态名称
+var globalStateNames = []string{uploadCountersName, downloadCountersName, uploadLedgerName, publishScheduleName, playlistsName, accountSelectionName}
+
+// StateStore 状态存储后端
+// 负责视频的下载状态、上传状态以及 .global 下的全局计数，Repository 的所有状态读写都经过它。
+// Load* 在状态不存在时返回满足 os.IsNotExist 的错误；Update* 在同一事务内完成读-改-写。
+type StateStore interface {
+	// Backend 返回后端名称（json / bolt）
+	Backend() string
+	LoadDownloadStatus(videoDir string) (*DownloadStatus, error)
+	// UpdateDownloadStatus 读取（不存在或损坏时为空状态）、修改、校验并写回下载状态；updateFunc 返回错误时放弃写入
+	UpdateDownloadStatus(videoDir string, updateFunc func(*DownloadStatus) error) error
+	LoadUploadStatus(videoDir string) (*UploadStatus, error)
+	UpdateUploadStatus(videoDir string, updateFunc func(*UploadStatus) error) error
+	DeleteUploadStatus(videoDir string) error
+	// ListVideoDirs 返回所有存在下载或上传状态的视频目录（已排序）
+	ListVideoDirs() ([]string, error)
+	// ListVideoDirsByUploadStatus 返回上传状态为 status 的视频目录（已排序）
+	ListVideoDirsByUploadStatus(status string) ([]string, error)
+	// LoadGlobal 读取 .global 下名为 name 的原始 JSON
+	LoadGlobal(name string) ([]byte, error)
+	// UpdateGlobal 在同一事务内读取并写回 .global 下名为 name 的原始 JSON（不存在时 data 为 nil）
+	UpdateGlobal(name string, updateFunc func(data []byte) ([]byte, error)) error
+}
+
+// NewStateStore 根据后端名称创建状态存储
+// stateFile 仅对 bolt 后端有效，为空时使用 {outputDir}/.global/state.db
+func NewStateStore(backend, outputDir, stateFile string) (StateStore, error) {
+	switch strings.ToLower(strings.TrimSpace(backend)) {
+	case "", StateBackendJSON:
+		return newJSONStateStore(outputDir), nil
+	case StateBackendBolt:
+		if stateFile == "" {
+			stateFile = filepath.Join(outputDir, globalDirName, "state.db")
+		}
+		return newBoltStateStore(outputDir, stateFile), nil
+	default:
+		return nil, fmt.Errorf("不支持的状态存储后端: %s（可选: %s, %s）", backend, StateBackendJSON, StateBackendBolt)
+	}
+}
+
+// CopyState 将 src 中的全部状态复制到 dst（用于切换状态后端），返回复制的视频目录数量
+func CopyState(dst, src StateStore) (int, error) {
+	dirs, err := src.ListVideoDirs()
+	if err != nil {
+		return 0, fmt.Errorf("列出视频状态失败: %w", err)
+	}
+
+	copied := 0
+	for _, videoDir := range dirs {
+		if status, err := src.LoadDownloadStatus(videoDir); err == nil {
+			if err := dst.UpdateDownloadStatus(videoDir, func(to *DownloadStatus) error {
+				*to = *status
+				return nil
+			}); err != nil {
+				return copied, fmt.Errorf("写入下载状态失败 (%s): %w", videoDir, err)
+			}
+		} else if !os.IsNotExist(err) {
+			return copied, fmt.Errorf("读取下载状态失败 (%s): %w", videoDir, err)
+		}
+		if status, err := src.LoadUploadStatus(videoDir); err == nil {
+			if err := dst.UpdateUploadStatus(videoDir, func(to *UploadStatus) error {
+				*to = *status
+				return nil
+			}); err != nil {
+				return copied, fmt.Errorf("写入上传状态失败 (%s): %w", videoDir, err)
+			}
+		} else if !os.IsNotExist(err) {
+			return copied, fmt.Errorf("读取上传状态失败 (%s): %w", videoDir, err)
+		}
+		copied++
+	}
+
+	for _, name := range globalStateNames {
+		data, err := src.LoadGlobal(name)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return copied, fmt.Errorf("读取全局状态 %s 失败: %w", name, err)
+		}
+		if err := dst.UpdateGlobal(name, func([]byte) ([]byte, error) { return data, nil }); err != nil {
+			return copied, fmt.Errorf("写入全局状态 %s 失败: %w", name, err)
+		}
+	}
+
+	return copied, nil
+}
+
+// ---------- JSON 文件后端 ----------
+
+// jsonStateStore 每个视频目录下一个 JSON 文件的状态后端（历史格式）
+type jsonStateStore struct {
+	outputDir string
+}
+
+func newJSONStateStore(outputDir string) *jsonStateStore {
+	return &jsonStateStore{outputDir: outputDir}
+}
+
+func (s *jsonStateStore) Backend() string {
+	return StateBackendJSON
+}
+
+func (s *jsonStateStore) LoadDownloadStatus(videoDir string) (*DownloadStatus, error) {
+	data, err := os.ReadFile(filepath.Join(videoDir, downloadStatusFileName))
+	if err != nil {
+		return nil, err
+	}
+	return decodeDownloadStatus(data)
+}
+
+func (s *jsonStateStore) UpdateDownloadStatus(videoDir string, updateFunc func(*DownloadStatus) error) error {
+	lock, err := lockVideoDir(videoDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	path := filepath.Join(videoDir, downloadStatusFileName)
+	status := NewDownloadStatus()
+	if data, err := os.ReadFile(path); err == nil {
+		if decoded, err := decodeDownloadStatus(data); err == nil {
+			status = decoded
+		}
+	}
+	if err := updateFunc(status); err != nil {
+		return err
+	}
+	data, err := encodeStatus(status, "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(path, data, 0644)
+}
+
+func (s *jsonStateStore) LoadUploadStatus(videoDir string) (*UploadStatus, error) {
+	data, err := os.ReadFile(filepath.Join(videoDir, uploadStatusFileName))
+	if err != nil {
+		return nil, err
+	}
+	return decodeUploadStatus(data)
+}
+
+func (s *jsonStateStore) UpdateUploadStatus(videoDir string, updateFunc func(*UploadStatus) error) error {
+	lock, err := lockVideoDir(videoDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	path := filepath.Join(videoDir, uploadStatusFileName)
+	status := NewUploadStatus()
+	if data, err := os.ReadFile(path); err == nil {
+		if decoded, err := decodeUploadStatus(data); err == nil {
+			status = decoded
+		}
+	}
+	if err := updateFunc(status); err != nil {
+		return err
+	}
+	data, err := encodeStatus(status, "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(path, data, 0644)
+}
+
+func (s *jsonStateStore) DeleteUploadStatus(videoDir string) error {
+	if _, err := os.Stat(videoDir); os.IsNotExist(err) {
+		// 目录不存在时无需删除，也不要为了加锁而创建目录
+		return nil
+	}
+	lock, err := lockVideoDir(videoDir)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := os.Remove(filepath.Join(videoDir, uploadStatusFileName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *jsonStateStore) ListVideoDirs() ([]string, error) {
+	return s.scanVideoDirs(func(videoDir string) bool {
+		for _, name := range []string{downloadStatusFileName, uploadStatusFileName} {
+			if _, err := os.Stat(filepath.Join(videoDir, name)); err == nil {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (s *jsonStateStore) ListVideoDirsByUploadStatus(status string) ([]string, error) {
+	return s.scanVideoDirs(func(videoDir string) bool {
+		st, err := s.LoadUploadStatus(videoDir)
+		return err == nil && string(st.Status) == status
+	})
+}
+
+// scanVideoDirs 遍历 {outputDir}/{channel}/{video} 两级目录
+func (s *jsonStateStore) scanVideoDirs(match func(videoDir string) bool) ([]string, error) {
+	channelEntries, err := os.ReadDir(s.outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("读取输出目录失败: %w", err)
+	}
+	var dirs []string
+	for _, channelEntry := range channelEntries {
+		if !channelEntry.IsDir() || channelEntry.Name() == globalDirName {
+			continue
+		}
+		channelDir := filepath.Join(s.outputDir, channelEntry.Name())
+		videoEntries, err := os.ReadDir(channelDir)
+		if err != nil {
+			continue
+		}
+		for _, videoEntry := range videoEntries {
+			if !videoEntry.IsDir() {
+				continue
+			}
+			videoDir := filepath.Join(channelDir, videoEntry.Name())
+			if match(videoDir) {
+				dirs = append(dirs, videoDir)
+			}
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func (s *jsonStateStore) globalFile(name string) string {
+	return filepath.Join(s.outputDir, globalDirName, name+".json")
+}
+
+func (s *jsonStateStore) LoadGlobal(name string) ([]byte, error) {
+	return os.ReadFile(s.globalFile(name))
+}
+
+func (s *jsonStateStore) UpdateGlobal(name string, updateFunc func(data []byte) ([]byte, error)) error {
+	path := s.globalFile(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建全局状态目录失败: %w", err)
+	}
+	// 计数文件是多个进程共享的，读-改-写全程持有锁，避免互相覆盖或重复计数
+	lock, err := utils.LockFile(filepath.Join(s.outputDir, globalDirName, name+".lock"))
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	newData, err := updateFunc(data)
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(path, newData, 0644)
+}
+
+// lockVideoDir 获取视频目录状态文件的跨进程锁（download_status.json 与 upload_status.json 共用一把锁）
+func lockVideoDir(videoDir string) (*utils.FileLock, error) {
+	return utils.LockFile(filepath.Join(videoDir, videoDirLockName))
+}
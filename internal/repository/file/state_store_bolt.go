@@ -0,0 +1,637 @@
+package file
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	bolt "go.etcd.io/bbolt"
+	bolterrors "go.etcd.io/bbolt/errors"
+)
+
+var (
+	bucketMeta           = []byte("meta")
+	bucketDownloadStatus = []byte("download_status")
+	bucketUploadStatus   = []byte("upload_status")
+	bucketGlobals        = []byte("globals")
+	// 索引：<状态>\x00<视频目录键> -> 空值
+	bucketDownloadIndex = []byte("idx_download_status")
+	bucketUploadIndex   = []byte("idx_upload_status")
+
+	metaSchemaVersion = []byte("schema_version")
+	metaJSONImported  = []byte("json_imported_at")
+)
+
+const (
+	// boltSchemaVersion 数据库值格式的版本
+	// 1: 值为状态 JSON；2: 值为 <状态>\x00<状态 JSON>（typed 状态模型）
+	boltSchemaVersion = "2"
+	// boltLockTimeout 等待数据库文件锁的最长时间
+	boltLockTimeout = 30 * time.Second
+	// boltIdleClose 最后一次操作结束后保持数据库打开的时间
+	boltIdleClose = 200 * time.Millisecond
+	// boltMaxHold 连续持有文件锁的最长时间，超过后关闭数据库让出文件锁
+	boltMaxHold = time.Second
+	// boltYield 让出文件锁后暂停打开的时间，长于 bbolt 等待文件锁时的重试间隔（50ms）
+	boltYield = 100 * time.Millisecond
+)
+
+// boltStateStore 基于 bbolt 单文件数据库的状态后端
+type boltStateStore struct {
+	outputDir string
+	path      string
+	legacy    *jsonStateStore
+	handle    *boltHandle
+}
+
+func newBoltStateStore(outputDir, path string) *boltStateStore {
+	return &boltStateStore{
+		outputDir: outputDir,
+		path:      path,
+		legacy:    newJSONStateStore(outputDir),
+		handle:    sharedBoltHandle(path),
+	}
+}
+
+func (s *boltStateStore) Backend() string {
+	return StateBackendBolt
+}
+
+// withDB 在打开的数据库上执行 fn；首次打开时创建 bucket 并导入已有的 JSON 状态文件
+func (s *boltStateStore) withDB(fn func(db *bolt.DB) error) error {
+	db, err := s.handle.acquire(s.prepare)
+	if err != nil {
+		return err
+	}
+	defer s.handle.release()
+	return fn(db)
+}
+
+// boltHandle 一个数据库文件在本进程内的共享句柄
+// 下载服务和上传服务通常是两个独立进程，而 bbolt 在打开期间持有排他文件锁：
+// 连续的操作（扫描、批量更新）共用一个打开的数据库，空闲 boltIdleClose 后关闭并释放文件锁；
+// 连续使用超过 boltMaxHold 时等当前操作结束后关闭，并在 boltYield 内不再打开，
+// 让等待文件锁的其他进程有机会获得锁，繁忙的进程不会一直独占数据库。
+// 文件锁对同一进程的多次打开同样互斥，因此同一文件的所有 boltStateStore 共用一个句柄。
+type boltHandle struct {
+	path string
+
+	mu         sync.Mutex
+	cond       *sync.Cond // users 归零或打开结束时通知等待者
+	db         *bolt.DB
+	openedAt   time.Time   // db 打开（获得文件锁）的时间
+	opening    bool        // 正在等待文件锁（不持有 mu）
+	yieldUntil time.Time   // 让出文件锁后在该时间之前不再打开
+	users      int         // 正在使用 db 的操作数
+	idle       *time.Timer // 空闲后关闭 db
+	prepared   bool
+}
+
+var (
+	boltHandlesMu sync.Mutex
+	boltHandles   = make(map[string]*boltHandle)
+)
+
+func newBoltHandle(path string) *boltHandle {
+	h := &boltHandle{path: path}
+	h.cond = sync.NewCond(&h.mu)
+	return h
+}
+
+// sharedBoltHandle 返回数据库文件的共享句柄（按绝对路径）
+func sharedBoltHandle(path string) *boltHandle {
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
+	boltHandlesMu.Lock()
+	defer boltHandlesMu.Unlock()
+	h, ok := boltHandles[path]
+	if !ok {
+		h = newBoltHandle(path)
+		boltHandles[path] = h
+	}
+	return h
+}
+
+// acquire 返回打开的数据库并登记一次使用，需与 release 成对调用；首次打开时调用 prepare
+func (h *boltHandle) acquire(prepare func(db *bolt.DB) error) (*bolt.DB, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for {
+		if h.idle != nil {
+			h.idle.Stop()
+		}
+		switch {
+		case h.opening:
+			h.cond.Wait()
+		case h.db != nil && time.Since(h.openedAt) >= boltMaxHold:
+			// 持有文件锁过久：不再接纳新操作，等当前操作结束后关闭并让出文件锁
+			if h.users > 0 {
+				h.cond.Wait()
+				continue
+			}
+			h.closeLocked()
+			h.yieldUntil = time.Now().Add(boltYield)
+		case h.db != nil:
+			h.users++
+			return h.db, nil
+		default:
+			if err := h.open(prepare); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// open 等待文件锁并打开数据库（调用方持有 mu，等待期间释放），首次打开时调用 prepare
+func (h *boltHandle) open(prepare func(db *bolt.DB) error) error {
+	h.opening = true
+	wait := time.Until(h.yieldUntil)
+	needPrepare := !h.prepared
+	h.mu.Unlock()
+
+	db, err := h.openDB(wait)
+	if err == nil && needPrepare {
+		if err = prepare(db); err != nil {
+			db.Close()
+		}
+	}
+
+	h.mu.Lock()
+	h.opening = false
+	h.cond.Broadcast()
+	if err != nil {
+		return err
+	}
+	h.db = db
+	h.openedAt = time.Now()
+	h.prepared = true
+	return nil
+}
+
+func (h *boltHandle) openDB(wait time.Duration) (*bolt.DB, error) {
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return nil, fmt.Errorf("创建状态数据库目录失败: %w", err)
+	}
+	db, err := bolt.Open(h.path, 0644, &bolt.Options{Timeout: boltLockTimeout})
+	if errors.Is(err, bolterrors.ErrTimeout) {
+		return nil, fmt.Errorf("打开状态数据库失败 (%s): 等待文件锁超过 %s，可能有其他进程长时间占用: %w", h.path, boltLockTimeout, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开状态数据库失败 (%s): %w", h.path, err)
+	}
+	return db, nil
+}
+
+// release 结束一次使用；没有其他操作时在 boltIdleClose 后关闭数据库，释放文件锁
+func (h *boltHandle) release() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.users--
+	if h.users > 0 {
+		return
+	}
+	h.cond.Broadcast()
+	if h.idle == nil {
+		h.idle = time.AfterFunc(boltIdleClose, h.closeIdle)
+	} else {
+		h.idle.Reset(boltIdleClose)
+	}
+}
+
+// closeIdle 空闲计时到期时关闭数据库（期间又有操作开始则保持打开）
+func (h *boltHandle) closeIdle() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.users > 0 {
+		return
+	}
+	h.closeLocked()
+}
+
+// closeLocked 关闭数据库并释放文件锁（调用方持有 mu，且没有正在进行的操作）
+func (h *boltHandle) closeLocked() {
+	if h.db == nil {
+		return
+	}
+	if err := h.db.Close(); err != nil {
+		log.Warn().Err(err).Str("state_file", h.path).Msg("关闭状态数据库失败")
+	}
+	h.db = nil
+}
+
+// prepare 创建 bucket，并在数据库首次创建时导入输出目录中已有的 JSON 状态
+func (s *boltStateStore) prepare(db *bolt.DB) error {
+	imported := false
+	err := db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketMeta, bucketDownloadStatus, bucketUploadStatus, bucketGlobals, bucketDownloadIndex, bucketUploadIndex} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return fmt.Errorf("创建 bucket %s 失败: %w", name, err)
+			}
+		}
+		meta := tx.Bucket(bucketMeta)
+		switch version := string(meta.Get(metaSchemaVersion)); version {
+		case boltSchemaVersion:
+		case "":
+			if err := meta.Put(metaSchemaVersion, []byte(boltSchemaVersion)); err != nil {
+				return err
+			}
+		case "1":
+			if err := migrateBoltV1(tx); err != nil {
+				return fmt.Errorf("升级状态数据库失败: %w", err)
+			}
+			if err := meta.Put(metaSchemaVersion, []byte(boltSchemaVersion)); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("状态数据库版本 %s 不受支持（当前程序支持 %s），请升级程序", version, boltSchemaVersion)
+		}
+		imported = meta.Get(metaJSONImported) != nil
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("初始化状态数据库失败: %w", err)
+	}
+	if imported {
+		return nil
+	}
+
+	start := time.Now()
+	count, err := s.importJSON(db)
+	if err != nil {
+		return fmt.Errorf("导入 JSON 状态文件失败: %w", err)
+	}
+	log.Info().
+		Str("state_file", s.path).
+		Int("video_count", count).
+		Dur("elapsed", time.Since(start)).
+		Msg("已将现有 JSON 状态文件导入状态数据库")
+	return nil
+}
+
+// migrateBoltV1 将版本 1 的值（状态 JSON）升级为 <状态>\x00<状态 JSON>，并按新的状态重建索引
+func migrateBoltV1(tx *bolt.Tx) error {
+	buckets := []struct {
+		bucket, index []byte
+		convert       func(doc []byte) ([]byte, string, error)
+	}{
+		{bucketDownloadStatus, bucketDownloadIndex, func(doc []byte) ([]byte, string, error) {
+			status, err := decodeDownloadStatus(doc)
+			if err != nil {
+				return nil, "", err
+			}
+			data, err := encodeStatus(status, "")
+			return data, string(status.Video.Status), err
+		}},
+		{bucketUploadStatus, bucketUploadIndex, func(doc []byte) ([]byte, string, error) {
+			status, err := decodeUploadStatus(doc)
+			if err != nil {
+				return nil, "", err
+			}
+			data, err := encodeStatus(status, "")
+			return data, string(status.Status), err
+		}},
+	}
+	for _, b := range buckets {
+		type entry struct {
+			key, data []byte
+			state     string
+		}
+		var entries []entry
+		err := tx.Bucket(b.bucket).ForEach(func(k, v []byte) error {
+			// 升级前的新版本程序可能已按版本 2 的格式写入部分记录
+			data, state, err := b.convert(storedDoc(v))
+			if err != nil {
+				// 无法解析的文档原样保留，读取时会报告错误
+				log.Warn().Err(err).Str("key", string(k)).Msg("升级状态数据库时无法解析状态，保留原文档")
+				data, state = storedDoc(v), storedState(v)
+			}
+			entries = append(entries, entry{key: append([]byte(nil), k...), data: data, state: state})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := tx.DeleteBucket(b.index); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucket(b.index); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := tx.Bucket(b.bucket).Delete(e.key); err != nil {
+				return err
+			}
+			if err := putStatus(tx, b.bucket, b.index, e.key, e.data, e.state); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importJSON 在一个事务内导入所有 JSON 状态文件与全局计数
+func (s *boltStateStore) importJSON(db *bolt.DB) (int, error) {
+	dirs, err := s.legacy.ListVideoDirs()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	count := 0
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, videoDir := range dirs {
+			key := s.key(videoDir)
+			if status, err := s.legacy.LoadDownloadStatus(videoDir); err == nil {
+				data, err := encodeStatus(status, "")
+				if err != nil {
+					return fmt.Errorf("%s: %w", videoDir, err)
+				}
+				if err := putStatus(tx, bucketDownloadStatus, bucketDownloadIndex, key, data, string(status.Video.Status)); err != nil {
+					return err
+				}
+			}
+			if status, err := s.legacy.LoadUploadStatus(videoDir); err == nil {
+				data, err := encodeStatus(status, "")
+				if err != nil {
+					return fmt.Errorf("%s: %w", videoDir, err)
+				}
+				if err := putStatus(tx, bucketUploadStatus, bucketUploadIndex, key, data, string(status.Status)); err != nil {
+					return err
+				}
+			}
+			count++
+		}
+		for _, name := range globalStateNames {
+			if data, err := s.legacy.LoadGlobal(name); err == nil {
+				if err := tx.Bucket(bucketGlobals).Put([]byte(name), data); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Bucket(bucketMeta).Put(metaJSONImported, []byte(time.Now().Format(time.RFC3339)))
+	})
+	return count, err
+}
+
+// key 将视频目录转换为数据库键：输出目录内使用相对路径，否则使用绝对路径
+func (s *boltStateStore) key(videoDir string) []byte {
+	absDir, err := filepath.Abs(videoDir)
+	if err != nil {
+		return []byte(filepath.Clean(videoDir))
+	}
+	if absOut, err := filepath.Abs(s.outputDir); err == nil {
+		if rel, err := filepath.Rel(absOut, absDir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return []byte(filepath.ToSlash(rel))
+		}
+	}
+	return []byte(absDir)
+}
+
+// videoDir 将数据库键还原为视频目录
+func (s *boltStateStore) videoDir(key []byte) string {
+	k := filepath.FromSlash(string(key))
+	if filepath.IsAbs(k) {
+		return k
+	}
+	return filepath.Join(s.outputDir, k)
+}
+
+func indexKey(status string, key []byte) []byte {
+	return append(append([]byte(status), 0), key...)
+}
+
+// putStatus 写入状态并维护状态索引（索引键中的状态与文档一起保存在 <bucket>/<key> 的前缀字节中）
+func putStatus(tx *bolt.Tx, bucket, index []byte, key []byte, data []byte, state string) error {
+	b := tx.Bucket(bucket)
+	idx := tx.Bucket(index)
+	if old := b.Get(key); old != nil {
+		if err := idx.Delete(indexKey(storedState(old), key)); err != nil {
+			return err
+		}
+	}
+	if err := b.Put(key, append(indexKey(state, nil), data...)); err != nil {
+		return err
+	}
+	return idx.Put(indexKey(state, key), nil)
+}
+
+// storedState 返回存储值中的状态前缀
+func storedState(v []byte) string {
+	if i := bytes.IndexByte(v, 0); i >= 0 {
+		return string(v[:i])
+	}
+	return ""
+}
+
+// storedDoc 返回存储值中的 JSON 文档部分
+func storedDoc(v []byte) []byte {
+	if i := bytes.IndexByte(v, 0); i >= 0 {
+		return v[i+1:]
+	}
+	return v
+}
+
+// loadRaw 读取状态文档；数据库中没有记录时返回 nil
+func (s *boltStateStore) loadRaw(bucket []byte, videoDir string) ([]byte, error) {
+	var data []byte
+	err := s.withDB(func(db *bolt.DB) error {
+		return db.View(func(tx *bolt.Tx) error {
+			if v := tx.Bucket(bucket).Get(s.key(videoDir)); v != nil {
+				data = append([]byte(nil), storedDoc(v)...)
+			}
+			return nil
+		})
+	})
+	return data, err
+}
+
+// updateRaw 在一个事务内读取状态文档、调用 fn 生成新文档与状态，并维护索引
+// 数据库中没有记录时 fn 收到的 old 为 nil
+func (s *boltStateStore) updateRaw(bucket, index []byte, videoDir string, fn func(old []byte) (data []byte, state string, err error)) error {
+	return s.withDB(func(db *bolt.DB) error {
+		return db.Update(func(tx *bolt.Tx) error {
+			key := s.key(videoDir)
+			var old []byte
+			if v := tx.Bucket(bucket).Get(key); v != nil {
+				old = storedDoc(v)
+			}
+			data, state, err := fn(old)
+			if err != nil {
+				return err
+			}
+			return putStatus(tx, bucket, index, key, data, state)
+		})
+	})
+}
+
+func (s *boltStateStore) LoadDownloadStatus(videoDir string) (*DownloadStatus, error) {
+	data, err := s.loadRaw(bucketDownloadStatus, videoDir)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		// 数据库中没有记录时回退读取旧的 JSON 文件（例如切换后端后由旧版本写入的目录）
+		return s.legacy.LoadDownloadStatus(videoDir)
+	}
+	return decodeDownloadStatus(data)
+}
+
+func (s *boltStateStore) UpdateDownloadStatus(videoDir string, updateFunc func(*DownloadStatus) error) error {
+	return s.updateRaw(bucketDownloadStatus, bucketDownloadIndex, videoDir, func(old []byte) ([]byte, string, error) {
+		status := NewDownloadStatus()
+		if old != nil {
+			if decoded, err := decodeDownloadStatus(old); err == nil {
+				status = decoded
+			}
+		} else if legacy, err := s.legacy.LoadDownloadStatus(videoDir); err == nil {
+			status = legacy
+		}
+		if err := updateFunc(status); err != nil {
+			return nil, "", err
+		}
+		data, err := encodeStatus(status, "")
+		return data, string(status.Video.Status), err
+	})
+}
+
+func (s *boltStateStore) LoadUploadStatus(videoDir string) (*UploadStatus, error) {
+	data, err := s.loadRaw(bucketUploadStatus, videoDir)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return s.legacy.LoadUploadStatus(videoDir)
+	}
+	return decodeUploadStatus(data)
+}
+
+func (s *boltStateStore) UpdateUploadStatus(videoDir string, updateFunc func(*UploadStatus) error) error {
+	return s.updateRaw(bucketUploadStatus, bucketUploadIndex, videoDir, func(old []byte) ([]byte, string, error) {
+		status := NewUploadStatus()
+		if old != nil {
+			if decoded, err := decodeUploadStatus(old); err == nil {
+				status = decoded
+			}
+		} else if legacy, err := s.legacy.LoadUploadStatus(videoDir); err == nil {
+			status = legacy
+		}
+		if err := updateFunc(status); err != nil {
+			return nil, "", err
+		}
+		data, err := encodeStatus(status, "")
+		return data, string(status.Status), err
+	})
+}
+
+func (s *boltStateStore) DeleteUploadStatus(videoDir string) error {
+	err := s.withDB(func(db *bolt.DB) error {
+		return db.Update(func(tx *bolt.Tx) error {
+			key := s.key(videoDir)
+			b := tx.Bucket(bucketUploadStatus)
+			if old := b.Get(key); old != nil {
+				if err := tx.Bucket(bucketUploadIndex).Delete(indexKey(storedState(old), key)); err != nil {
+					return err
+				}
+			}
+			return b.Delete(key)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	// 同时删除可能残留的旧 JSON 文件，避免回退读取时“复活”
+	return s.legacy.DeleteUploadStatus(videoDir)
+}
+
+func (s *boltStateStore) ListVideoDirs() ([]string, error) {
+	seen := make(map[string]struct{})
+	err := s.withDB(func(db *bolt.DB) error {
+		return db.View(func(tx *bolt.Tx) error {
+			for _, name := range [][]byte{bucketDownloadStatus, bucketUploadStatus} {
+				if err := tx.Bucket(name).ForEach(func(k, _ []byte) error {
+					seen[s.videoDir(k)] = struct{}{}
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	dirs := make([]string, 0, len(seen))
+	for dir := range seen {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func (s *boltStateStore) ListVideoDirsByUploadStatus(status string) ([]string, error) {
+	var dirs []string
+	err := s.withDB(func(db *bolt.DB) error {
+		return db.View(func(tx *bolt.Tx) error {
+			prefix := indexKey(status, nil)
+			c := tx.Bucket(bucketUploadIndex).Cursor()
+			for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+				dirs = append(dirs, s.videoDir(k[len(prefix):]))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func (s *boltStateStore) LoadGlobal(name string) ([]byte, error) {
+	var data []byte
+	err := s.withDB(func(db *bolt.DB) error {
+		return db.View(func(tx *bolt.Tx) error {
+			if v := tx.Bucket(bucketGlobals).Get([]byte(name)); v != nil {
+				data = append([]byte(nil), v...)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, &os.PathError{Op: "load", Path: name, Err: os.ErrNotExist}
+	}
+	return data, nil
+}
+
+func (s *boltStateStore) UpdateGlobal(name string, updateFunc func(data []byte) ([]byte, error)) error {
+	return s.withDB(func(db *bolt.DB) error {
+		return db.Update(func(tx *bolt.Tx) error {
+			b := tx.Bucket(bucketGlobals)
+			var data []byte
+			if v := b.Get([]byte(name)); v != nil {
+				data = append([]byte(nil), v...)
+			}
+			newData, err := updateFunc(data)
+			if err != nil {
+				return err
+			}
+			return b.Put([]byte(name), newData)
+		})
+	})
+}
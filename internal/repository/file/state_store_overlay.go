@@ -0,0 +1,181 @@
+package file
+
+import (
+	"os"
+	"sort"
+	"sync"
+)
+
+// overlayStateStore 只在内存中记录写入的状态后端
+// 读取时优先返回内存中的修改，否则回落到 base；不会修改 base。用于 dry-run。
+type overlayStateStore struct {
+	base StateStore
+
+	mu            sync.Mutex
+	download      map[string][]byte
+	upload        map[string][]byte
+	uploadDeleted map[string]bool
+	globals       map[string][]byte
+}
+
+// NewOverlayStateStore 创建叠加在 base 之上的内存状态后端，所有写入只保存在内存中
+func NewOverlayStateStore(base StateStore) StateStore {
+	return &overlayStateStore{
+		base:          base,
+		download:      make(map[string][]byte),
+		upload:        make(map[string][]byte),
+		uploadDeleted: make(map[string]bool),
+		globals:       make(map[string][]byte),
+	}
+}
+
+func (s *overlayStateStore) Backend() string {
+	return s.base.Backend()
+}
+
+func (s *overlayStateStore) LoadDownloadStatus(videoDir string) (*DownloadStatus, error) {
+	s.mu.Lock()
+	data, ok := s.download[videoDir]
+	s.mu.Unlock()
+	if ok {
+		return decodeDownloadStatus(data)
+	}
+	return s.base.LoadDownloadStatus(videoDir)
+}
+
+func (s *overlayStateStore) UpdateDownloadStatus(videoDir string, updateFunc func(*DownloadStatus) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := NewDownloadStatus()
+	if data, ok := s.download[videoDir]; ok {
+		if decoded, err := decodeDownloadStatus(data); err == nil {
+			status = decoded
+		}
+	} else if loaded, err := s.base.LoadDownloadStatus(videoDir); err == nil {
+		status = loaded
+	}
+	if err := updateFunc(status); err != nil {
+		return err
+	}
+	data, err := encodeStatus(status, "")
+	if err != nil {
+		return err
+	}
+	s.download[videoDir] = data
+	return nil
+}
+
+func (s *overlayStateStore) LoadUploadStatus(videoDir string) (*UploadStatus, error) {
+	s.mu.Lock()
+	data, ok := s.upload[videoDir]
+	deleted := s.uploadDeleted[videoDir]
+	s.mu.Unlock()
+	if ok {
+		return decodeUploadStatus(data)
+	}
+	if deleted {
+		return nil, &os.PathError{Op: "load", Path: videoDir, Err: os.ErrNotExist}
+	}
+	return s.base.LoadUploadStatus(videoDir)
+}
+
+func (s *overlayStateStore) UpdateUploadStatus(videoDir string, updateFunc func(*UploadStatus) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := NewUploadStatus()
+	if data, ok := s.upload[videoDir]; ok {
+		if decoded, err := decodeUploadStatus(data); err == nil {
+			status = decoded
+		}
+	} else if !s.uploadDeleted[videoDir] {
+		if loaded, err := s.base.LoadUploadStatus(videoDir); err == nil {
+			status = loaded
+		}
+	}
+	if err := updateFunc(status); err != nil {
+		return err
+	}
+	data, err := encodeStatus(status, "")
+	if err != nil {
+		return err
+	}
+	s.upload[videoDir] = data
+	return nil
+}
+
+func (s *overlayStateStore) DeleteUploadStatus(videoDir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.upload, videoDir)
+	s.uploadDeleted[videoDir] = true
+	return nil
+}
+
+func (s *overlayStateStore) ListVideoDirs() ([]string, error) {
+	dirs, err := s.base.ListVideoDirs()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		seen[dir] = true
+	}
+	s.mu.Lock()
+	for _, m := range []map[string][]byte{s.download, s.upload} {
+		for dir := range m {
+			if !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	s.mu.Unlock()
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func (s *overlayStateStore) ListVideoDirsByUploadStatus(status string) ([]string, error) {
+	dirs, err := s.ListVideoDirs()
+	if err != nil {
+		return nil, err
+	}
+	var matched []string
+	for _, dir := range dirs {
+		if st, err := s.LoadUploadStatus(dir); err == nil && string(st.Status) == status {
+			matched = append(matched, dir)
+		}
+	}
+	return matched, nil
+}
+
+func (s *overlayStateStore) LoadGlobal(name string) ([]byte, error) {
+	s.mu.Lock()
+	data, ok := s.globals[name]
+	s.mu.Unlock()
+	if ok {
+		return data, nil
+	}
+	return s.base.LoadGlobal(name)
+}
+
+func (s *overlayStateStore) UpdateGlobal(name string, updateFunc func(data []byte) ([]byte, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.globals[name]
+	if !ok {
+		loaded, err := s.base.LoadGlobal(name)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		data = loaded
+	}
+	newData, err := updateFunc(data)
+	if err != nil {
+		return err
+	}
+	s.globals[name] = newData
+	return nil
+}
@@ -0,0 +1,186 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const benchVideoDirs = 500
+
+// newBenchStore 创建包含 benchVideoDirs 个视频目录（下载与上传状态）的状态存储
+func newBenchStore(b *testing.B, backend string) (StateStore, []string) {
+	b.Helper()
+	outputDir := b.TempDir()
+	store, err := NewStateStore(backend, outputDir, "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	dirs := make([]string, benchVideoDirs)
+	for i := range dirs {
+		dirs[i] = filepath.Join(outputDir, "channel", fmt.Sprintf("video%04d", i))
+		if err := os.MkdirAll(dirs[i], 0755); err != nil {
+			b.Fatal(err)
+		}
+		if err := store.UpdateDownloadStatus(dirs[i], func(s *DownloadStatus) error {
+			s.Video.Status = ResourceCompleted
+			s.Video.Downloaded = true
+			return nil
+		}); err != nil {
+			b.Fatal(err)
+		}
+		if err := store.UpdateUploadStatus(dirs[i], func(s *UploadStatus) error {
+			s.Status = UploadPending
+			return nil
+		}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return store, dirs
+}
+
+// 对比 json 与 bolt 后端的单次读写与按上传状态筛选的开销
+func BenchmarkStateStore(b *testing.B) {
+	for _, backend := range []string{StateBackendJSON, StateBackendBolt} {
+		b.Run(backend+"/LoadDownloadStatus", func(b *testing.B) {
+			store, dirs := newBenchStore(b, backend)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := store.LoadDownloadStatus(dirs[i%len(dirs)]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(backend+"/UpdateUploadStatus", func(b *testing.B) {
+			store, dirs := newBenchStore(b, backend)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := store.UpdateUploadStatus(dirs[i%len(dirs)], func(s *UploadStatus) error {
+					s.UpdatedAt++
+					return nil
+				}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(backend+"/ListVideoDirsByUploadStatus", func(b *testing.B) {
+			store, _ := newBenchStore(b, backend)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				dirs, err := store.ListVideoDirsByUploadStatus(string(UploadPending))
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(dirs) != benchVideoDirs {
+					b.Fatalf("dirs = %d, want %d", len(dirs), benchVideoDirs)
+				}
+			}
+		})
+	}
+}
+
+// 同一进程中另一个句柄的文件锁与另一个进程互斥，可以模拟另一个服务进程
+func TestBoltHandleYieldsLockToOtherProcess(t *testing.T) {
+	outputDir := t.TempDir()
+	path := filepath.Join(outputDir, "state.db")
+	store := newBoltStateStore(outputDir, path)
+	videoDir := filepath.Join(outputDir, "channel", "video")
+
+	// 繁忙的进程：多个 worker 不间断地更新状态
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if err := store.UpdateUploadStatus(videoDir, func(s *UploadStatus) error {
+					s.UpdatedAt++
+					return nil
+				}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	other := newBoltHandle(path)
+	start := time.Now()
+	if _, err := other.acquire(func(*bolt.DB) error { return nil }); err != nil {
+		t.Fatalf("另一个进程无法打开数据库: %v", err)
+	}
+	elapsed := time.Since(start)
+	other.release()
+	if limit := boltMaxHold + time.Second; elapsed > limit {
+		t.Fatalf("另一个进程等待文件锁 %s，超过 %s", elapsed, limit)
+	}
+}
+
+// 版本 1 的数据库（值为状态 JSON）打开时升级为版本 2 并重建索引
+func TestBoltStateStoreMigratesSchemaV1(t *testing.T) {
+	outputDir := t.TempDir()
+	path := filepath.Join(outputDir, "state.db")
+	db, err := bolt.Open(path, 0644, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketMeta, bucketDownloadStatus, bucketUploadStatus, bucketGlobals, bucketDownloadIndex, bucketUploadIndex} {
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		meta := tx.Bucket(bucketMeta)
+		_ = meta.Put(metaSchemaVersion, []byte("1"))
+		_ = meta.Put(metaJSONImported, []byte(time.Now().Format(time.RFC3339)))
+		_ = tx.Bucket(bucketUploadStatus).Put([]byte("channel/old"), []byte(`{"status":"completed","bilibili_aid":"123"}`))
+		_ = tx.Bucket(bucketUploadIndex).Put(indexKey("completed", []byte("channel/old")), nil)
+		return tx.Bucket(bucketDownloadStatus).Put([]byte("channel/old"), []byte(`{"video":{"downloaded":true}}`))
+	})
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := newBoltStateStore(outputDir, path)
+	videoDir := filepath.Join(outputDir, "channel", "old")
+	upload, err := store.LoadUploadStatus(videoDir)
+	if err != nil || upload.Status != UploadCompleted || upload.BilibiliAID != "123" {
+		t.Fatalf("LoadUploadStatus = %+v, %v", upload, err)
+	}
+	dirs, err := store.ListVideoDirsByUploadStatus(string(UploadCompleted))
+	if err != nil || len(dirs) != 1 || dirs[0] != videoDir {
+		t.Fatalf("ListVideoDirsByUploadStatus = %v, %v", dirs, err)
+	}
+	// 更新后旧的索引项被移除
+	if err := store.UpdateUploadStatus(videoDir, func(s *UploadStatus) error {
+		s.Status = UploadFailed
+		s.Uploaded = false
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if dirs, _ := store.ListVideoDirsByUploadStatus(string(UploadCompleted)); len(dirs) != 0 {
+		t.Fatalf("completed 索引未更新: %v", dirs)
+	}
+	download, err := store.LoadDownloadStatus(videoDir)
+	if err != nil || !download.Video.Downloaded {
+		t.Fatalf("LoadDownloadStatus = %+v, %v", download, err)
+	}
+}
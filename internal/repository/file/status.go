@@ -0,0 +1,453 @@
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// StatusSchemaVersion 当前 download_status / upload_status 的结构版本
+// 版本 1（或缺省）为历史的无类型格式，可能包含 bool 形式的旧字段；加载时会自动升级
+const StatusSchemaVersion = 2
+
+// ResourceState 单个资源（视频/字幕/缩略图）的下载状态
+type ResourceState string
+
+const (
+	ResourcePending     ResourceState = "pending"     // 等待下载
+	ResourceDownloading ResourceState = "downloading" // 下载中
+	ResourceCompleted   ResourceState = "completed"   // 已完成
+	ResourceFailed      ResourceState = "failed"      // 下载失败
+	ResourceSkipped     ResourceState = "skipped"     // 主动跳过
+	ResourceNotFound    ResourceState = "not_found"   // 源站不存在该资源
+)
+
+// Valid 检查状态值是否合法
+func (s ResourceState) Valid() bool {
+	switch s {
+	case ResourcePending, ResourceDownloading, ResourceCompleted, ResourceFailed, ResourceSkipped, ResourceNotFound:
+		return true
+	}
+	return false
+}
+
+// UploadState 视频上传状态
+type UploadState string
+
+const (
+	UploadPending   UploadState = "pending"   // 等待上传
+	UploadUploading UploadState = "uploading" // 上传中
+	UploadCompleted UploadState = "completed" // 已上传
+	UploadFailed    UploadState = "failed"    // 上传失败
+)
+
+// Valid 检查状态值是否合法
+func (s UploadState) Valid() bool {
+	switch s {
+	case UploadPending, UploadUploading, UploadCompleted, UploadFailed:
+		return true
+	}
+	return false
+}
+
+// ReviewState 稿件发布后的审核 / 转码状态
+type ReviewState string
+
+const (
+	ReviewPublished       ReviewState = "published"        // 已开放浏览
+	ReviewReviewing       ReviewState = "reviewing"        // 审核中（含定时发布、暂缓审核）
+	ReviewTranscoding     ReviewState = "transcoding"      // 转码中
+	ReviewTranscodeFailed ReviewState = "transcode_failed" // 转码失败
+	ReviewRejected        ReviewState = "rejected"         // 审核退回 / 锁定
+	ReviewDeleted         ReviewState = "deleted"          // 稿件已删除或不存在
+	ReviewUnknown         ReviewState = "unknown"          // 无法识别的状态码
+)
+
+// Valid 检查状态值是否合法
+func (s ReviewState) Valid() bool {
+	switch s {
+	case ReviewPublished, ReviewReviewing, ReviewTranscoding, ReviewTranscodeFailed, ReviewRejected, ReviewDeleted, ReviewUnknown:
+		return true
+	}
+	return false
+}
+
+// Final 是否为不会再变化的状态（之后的检查可以跳过）
+func (s ReviewState) Final() bool {
+	return s == ReviewPublished || s == ReviewDeleted
+}
+
+// NeedsResubmit 是否需要重新上传（审核退回或转码失败）
+func (s ReviewState) NeedsResubmit() bool {
+	return s == ReviewRejected || s == ReviewTranscodeFailed
+}
+
+// 资源类型
+const (
+	ResourceTypeVideo     = "video"
+	ResourceTypeSubtitle  = "subtitle"
+	ResourceTypeThumbnail = "thumbnail"
+)
+
+// ResourceStatus 单个资源的下载状态
+type ResourceStatus struct {
+	Status       ResourceState `json:"status,omitempty"`
+	Downloaded   bool          `json:"downloaded"`
+	ResourceType string        `json:"resource_type,omitempty"`
+	URL          string        `json:"url,omitempty"`
+	FilePath     string        `json:"file_path,omitempty"`
+	DownloadedAt int64         `json:"downloaded_at,omitempty"`
+	Error        string        `json:"error,omitempty"`
+	FailedAt     int64         `json:"failed_at,omitempty"`
+}
+
+// VideoResourceStatus 视频资源的下载状态（额外记录分辨率信息）
+type VideoResourceStatus struct {
+	ResourceStatus
+	Has1080p         *bool `json:"has_1080p,omitempty"`
+	Has1080pMarkedAt int64 `json:"has_1080p_marked_at,omitempty"`
+}
+
+// DownloadStatus 视频目录的下载状态（download_status.json）
+type DownloadStatus struct {
+	SchemaVersion int                        `json:"schema_version"`
+	Video         VideoResourceStatus        `json:"video"`
+	Subtitles     map[string]*ResourceStatus `json:"subtitles"`
+	Thumbnail     *ResourceStatus            `json:"thumbnail,omitempty"`
+}
+
+// UploadStatus 视频目录的上传状态（upload_status.json）
+type UploadStatus struct {
+	SchemaVersion   int         `json:"schema_version"`
+	Status          UploadState `json:"status,omitempty"`
+	Uploaded        bool        `json:"uploaded"`
+	StartedAt       int64       `json:"started_at,omitempty"`
+	BilibiliAID     string      `json:"bilibili_aid,omitempty"`
+	BilibiliAccount string      `json:"bilibili_account,omitempty"`
+	BilibiliUserID  string      `json:"bilibili_userid,omitempty"`
+	FileSize        int64       `json:"file_size,omitempty"`
+	CompletedAt     int64       `json:"completed_at,omitempty"`
+	Error           string      `json:"error,omitempty"`
+	FailedAt        int64       `json:"failed_at,omitempty"`
+	UpdatedAt       int64       `json:"updated_at,omitempty"`
+	// Session 未完成的分块上传会话，上传成功后清除
+	Session *UploadSession `json:"session,omitempty"`
+	// Subtitles 各语言字幕的上传结果，key 为 YouTube 语言代码（en、zh-Hans…）
+	Subtitles map[string]*SubtitleUpload `json:"subtitles,omitempty"`
+	// Review 发布后的审核 / 转码状态（verify-uploads 查询）
+	Review *ReviewStatus `json:"review,omitempty"`
+	// Resubmissions 审核退回后重新上传的次数；>0 时上传使用修改后的标题与封面
+	Resubmissions int `json:"resubmissions,omitempty"`
+	// RejectedAIDs 被退回、已重新上传替代的历史稿件
+	RejectedAIDs []string `json:"rejected_aids,omitempty"`
+	// ScheduledPublishAt 定时发布时间（Unix 秒），0 表示上传后立即发布
+	ScheduledPublishAt int64 `json:"scheduled_publish_at,omitempty"`
+	// PlaylistID 稿件已加入的 bilibili.tv 播放列表
+	PlaylistID string `json:"playlist_id,omitempty"`
+	// AccountSelection 自动选择上传账号时的决定与原因
+	AccountSelection *AccountSelectionRecord `json:"account_selection,omitempty"`
+	// Metadata 最近一次提交发布的投稿信息及清洗时做出的修改
+	Metadata *PublishedMetadata `json:"metadata,omitempty"`
+}
+
+// PublishedMetadata 提交发布的标题 / 标签（简介只记录长度）与清洗修改记录，用于审计
+type PublishedMetadata struct {
+	Title             string           `json:"title"`
+	Tags              []string         `json:"tags,omitempty"`
+	DescriptionLength int              `json:"description_length"` // 字符数
+	Changes           []MetadataChange `json:"changes,omitempty"`
+	UpdatedAt         int64            `json:"updated_at,omitempty"`
+}
+
+// MetadataChange 清洗投稿信息时的一次修改
+type MetadataChange struct {
+	Field  string `json:"field"` // title / description / tags
+	Rule   string `json:"rule"`  // normalize / control_chars / emoji / banned_phrase / link / truncate / dropped
+	Detail string `json:"detail,omitempty"`
+}
+
+// ResubmitCoverFile 审核退回后重新上传时使用的封面（从视频中截取的另一帧），上传器优先使用
+const ResubmitCoverFile = "cover_resubmit.jpg"
+
+// ReviewStatus 稿件的审核 / 转码状态
+type ReviewStatus struct {
+	State        ReviewState `json:"state"`
+	Code         int         `json:"code"`                    // 创作中心返回的原始状态码
+	Desc         string      `json:"desc,omitempty"`          // 状态描述
+	RejectReason string      `json:"reject_reason,omitempty"` // 退回 / 转码失败原因
+	CheckedAt    int64       `json:"checked_at"`
+	ChangedAt    int64       `json:"changed_at,omitempty"` // 状态最近一次变化的时间
+}
+
+// SubtitleUpload 单个语言字幕上传到 B站的结果
+type SubtitleUpload struct {
+	LangID      int         `json:"lang_id,omitempty"` // bilibili.tv 字幕语言 ID
+	Status      UploadState `json:"status"`            // completed / failed
+	SubtitleURL string      `json:"subtitle_url,omitempty"`
+	Error       string      `json:"error,omitempty"`
+	UpdatedAt   int64       `json:"updated_at,omitempty"`
+}
+
+// UploadSession B站 UPOS 分块上传会话，持久化后进程重启可从第一个缺失的分块续传
+type UploadSession struct {
+	VideoPath   string       `json:"video_path"`
+	Account     string       `json:"account,omitempty"` // 创建会话的账号，续传时账号不同则丢弃会话
+	FileSize    int64        `json:"file_size"`
+	FileModTime int64        `json:"file_mtime"`          // 与 FileSize 一起作为文件指纹，文件变化后会话作废
+	Endpoint    string       `json:"endpoint"`            // UPOS 上传主机
+	Filename    string       `json:"filename"`            // 服务端文件名（preupload 的 upos_uri）
+	UploadID    string       `json:"upload_id"`           // 初始化上传返回的 upload_id
+	Auth        string       `json:"auth,omitempty"`      // X-Upos-Auth
+	PutQuery    string       `json:"put_query,omitempty"` // preupload 返回的 put_query（含 profile）
+	ChunkSize   int64        `json:"chunk_size"`
+	Chunks      int          `json:"chunks"`
+	Parts       []UploadPart `json:"parts,omitempty"`    // 已完成的分块
+	Uploaded    bool         `json:"uploaded,omitempty"` // 所有分块已上传并完成合并，只差发布
+	CreatedAt   int64        `json:"created_at"`
+	UpdatedAt   int64        `json:"updated_at,omitempty"`
+}
+
+// UploadPart 已上传完成的分块
+type UploadPart struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag,omitempty"`
+}
+
+// HasPart 分块是否已上传
+func (s *UploadSession) HasPart(partNumber int) bool {
+	for _, p := range s.Parts {
+		if p.PartNumber == partNumber {
+			return true
+		}
+	}
+	return false
+}
+
+// NewDownloadStatus 创建空的下载状态
+func NewDownloadStatus() *DownloadStatus {
+	return &DownloadStatus{
+		SchemaVersion: StatusSchemaVersion,
+		Subtitles:     make(map[string]*ResourceStatus),
+	}
+}
+
+// NewUploadStatus 创建空的上传状态
+func NewUploadStatus() *UploadStatus {
+	return &UploadStatus{SchemaVersion: StatusSchemaVersion}
+}
+
+// Subtitle 返回指定语言的字幕状态，不存在时创建
+func (s *DownloadStatus) Subtitle(lang string) *ResourceStatus {
+	if s.Subtitles == nil {
+		s.Subtitles = make(map[string]*ResourceStatus)
+	}
+	sub, ok := s.Subtitles[lang]
+	if !ok || sub == nil {
+		sub = &ResourceStatus{}
+		s.Subtitles[lang] = sub
+	}
+	return sub
+}
+
+// SubtitleLanguages 返回状态中记录的字幕语言（已排序）
+func (s *DownloadStatus) SubtitleLanguages() []string {
+	langs := make([]string, 0, len(s.Subtitles))
+	for lang := range s.Subtitles {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// EnsureThumbnail 返回缩略图状态，不存在时创建
+func (s *DownloadStatus) EnsureThumbnail() *ResourceStatus {
+	if s.Thumbnail == nil {
+		s.Thumbnail = &ResourceStatus{}
+	}
+	return s.Thumbnail
+}
+
+// Validate 检查资源状态是否自洽
+func (r *ResourceStatus) Validate() error {
+	if r.Status == "" {
+		if r.Downloaded {
+			return fmt.Errorf("未设置状态但 downloaded=true")
+		}
+		return nil
+	}
+	if !r.Status.Valid() {
+		return fmt.Errorf("非法的下载状态: %q", r.Status)
+	}
+	if r.Downloaded != (r.Status == ResourceCompleted) {
+		return fmt.Errorf("状态 %s 与 downloaded=%v 不一致", r.Status, r.Downloaded)
+	}
+	return nil
+}
+
+// Validate 检查下载状态是否合法，写入前调用
+func (s *DownloadStatus) Validate() error {
+	if err := s.Video.Validate(); err != nil {
+		return fmt.Errorf("video: %w", err)
+	}
+	for lang, sub := range s.Subtitles {
+		if sub == nil {
+			return fmt.Errorf("subtitles[%s]: 状态为空", lang)
+		}
+		if err := sub.Validate(); err != nil {
+			return fmt.Errorf("subtitles[%s]: %w", lang, err)
+		}
+	}
+	if s.Thumbnail != nil {
+		if err := s.Thumbnail.Validate(); err != nil {
+			return fmt.Errorf("thumbnail: %w", err)
+		}
+	}
+	return nil
+}
+
+// Validate 检查上传状态是否合法，写入前调用
+func (s *UploadStatus) Validate() error {
+	if s.Status == "" {
+		if s.Uploaded {
+			return fmt.Errorf("未设置上传状态但 uploaded=true")
+		}
+		return nil
+	}
+	if !s.Status.Valid() {
+		return fmt.Errorf("非法的上传状态: %q", s.Status)
+	}
+	if s.Uploaded != (s.Status == UploadCompleted) {
+		return fmt.Errorf("上传状态 %s 与 uploaded=%v 不一致", s.Status, s.Uploaded)
+	}
+	for lang, sub := range s.Subtitles {
+		if sub != nil && !sub.Status.Valid() {
+			return fmt.Errorf("字幕 %s 的上传状态非法: %q", lang, sub.Status)
+		}
+	}
+	if s.Review != nil && !s.Review.State.Valid() {
+		return fmt.Errorf("非法的审核状态: %q", s.Review.State)
+	}
+	return nil
+}
+
+// ---------- 加载与旧格式升级 ----------
+
+// decodeDownloadStatus 解析下载状态；历史格式（无 schema_version、bool 字段、未知状态）会被升级为当前结构
+func decodeDownloadStatus(data []byte) (*DownloadStatus, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析下载状态失败: %w", err)
+	}
+
+	status := NewDownloadStatus()
+	if v, ok := raw["video"]; ok {
+		video, err := decodeVideoResource(v)
+		if err != nil {
+			return nil, fmt.Errorf("解析 video 状态失败: %w", err)
+		}
+		status.Video = video
+	}
+	if v, ok := raw["subtitles"]; ok && string(v) != "null" {
+		var subs map[string]json.RawMessage
+		if err := json.Unmarshal(v, &subs); err != nil {
+			return nil, fmt.Errorf("解析 subtitles 状态失败: %w", err)
+		}
+		for lang, subRaw := range subs {
+			sub, err := decodeResource(subRaw, ResourceTypeSubtitle)
+			if err != nil {
+				return nil, fmt.Errorf("解析字幕 %s 状态失败: %w", lang, err)
+			}
+			status.Subtitles[lang] = &sub
+		}
+	}
+	if v, ok := raw["thumbnail"]; ok && string(v) != "null" {
+		thumb, err := decodeResource(v, ResourceTypeThumbnail)
+		if err != nil {
+			return nil, fmt.Errorf("解析 thumbnail 状态失败: %w", err)
+		}
+		status.Thumbnail = &thumb
+	}
+	return status, nil
+}
+
+func decodeVideoResource(data json.RawMessage) (VideoResourceStatus, error) {
+	var legacy bool
+	if json.Unmarshal(data, &legacy) == nil {
+		// 旧格式：video 直接是 bool
+		return VideoResourceStatus{ResourceStatus: legacyResource(legacy, ResourceTypeVideo)}, nil
+	}
+	var video VideoResourceStatus
+	if err := json.Unmarshal(data, &video); err != nil {
+		return VideoResourceStatus{}, err
+	}
+	video.ResourceStatus = normalizeResource(video.ResourceStatus, ResourceTypeVideo)
+	return video, nil
+}
+
+func decodeResource(data json.RawMessage, resourceType string) (ResourceStatus, error) {
+	var legacy bool
+	if json.Unmarshal(data, &legacy) == nil {
+		// 旧格式：资源直接是 bool
+		return legacyResource(legacy, resourceType), nil
+	}
+	var res ResourceStatus
+	if err := json.Unmarshal(data, &res); err != nil {
+		return ResourceStatus{}, err
+	}
+	return normalizeResource(res, resourceType), nil
+}
+
+func legacyResource(downloaded bool, resourceType string) ResourceStatus {
+	if downloaded {
+		return ResourceStatus{Status: ResourceCompleted, Downloaded: true, ResourceType: resourceType}
+	}
+	return ResourceStatus{Status: ResourcePending, ResourceType: resourceType}
+}
+
+// normalizeResource 修正历史数据中不一致的状态：
+// 缺少 status 时按 downloaded 推断；未知状态视为 pending；downloaded 与 status 以 status 为准
+func normalizeResource(res ResourceStatus, resourceType string) ResourceStatus {
+	if res.ResourceType == "" && (res.Status != "" || res.Downloaded) {
+		res.ResourceType = resourceType
+	}
+	switch {
+	case res.Status == "" && res.Downloaded:
+		res.Status = ResourceCompleted
+	case res.Status != "" && !res.Status.Valid():
+		res.Status = ResourcePending
+	}
+	if res.Status != "" {
+		res.Downloaded = res.Status == ResourceCompleted
+	}
+	return res
+}
+
+// decodeUploadStatus 解析上传状态；历史格式（仅有 uploaded bool、未知状态）会被升级为当前结构
+func decodeUploadStatus(data []byte) (*UploadStatus, error) {
+	status := NewUploadStatus()
+	if err := json.Unmarshal(data, status); err != nil {
+		return nil, fmt.Errorf("解析上传状态失败: %w", err)
+	}
+	switch {
+	case status.Status == "" && status.Uploaded:
+		status.Status = UploadCompleted
+	case status.Status != "" && !status.Status.Valid():
+		status.Status = UploadPending
+	}
+	if status.Status != "" {
+		status.Uploaded = status.Status == UploadCompleted
+	}
+	status.SchemaVersion = StatusSchemaVersion
+	return status, nil
+}
+
+// encodeStatus 校验后序列化状态（indent 为空时输出紧凑格式）
+func encodeStatus(status interface{ Validate() error }, indent string) ([]byte, error) {
+	if err := status.Validate(); err != nil {
+		return nil, fmt.Errorf("状态校验失败: %w", err)
+	}
+	if indent == "" {
+		return json.Marshal(status)
+	}
+	return json.MarshalIndent(status, "", indent)
+}
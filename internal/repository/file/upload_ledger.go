@@ -0,0 +1,182 @@
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// uploadLedgerName 全局已上传视频账本（.global 下），按 YouTube 视频 ID 记录已发布的稿件，跨账号、跨服务器防止重复上传
+const uploadLedgerName = "upload_ledger"
+
+// LedgerEntry 账本中一个已上传的视频
+type LedgerEntry struct {
+	VideoID    string `json:"video_id"`
+	AID        string `json:"aid"`
+	Account    string `json:"account,omitempty"`
+	Host       string `json:"host,omitempty"` // 上传所在的服务器
+	VideoDir   string `json:"video_dir,omitempty"`
+	UploadedAt int64  `json:"uploaded_at"`
+}
+
+// LedgerMergeResult 合并账本的结果
+type LedgerMergeResult struct {
+	Added     int `json:"added"`
+	Updated   int `json:"updated"`
+	Conflicts int `json:"conflicts"` // 同一视频 ID 对应不同 aid（保留较早上传的记录）
+}
+
+type uploadLedger struct {
+	Entries map[string]*LedgerEntry `json:"entries"`
+}
+
+func parseUploadLedger(data []byte) (*uploadLedger, error) {
+	ledger := &uploadLedger{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, ledger); err != nil {
+			return nil, fmt.Errorf("解析上传账本失败: %w", err)
+		}
+	}
+	if ledger.Entries == nil {
+		ledger.Entries = make(map[string]*LedgerEntry)
+	}
+	return ledger, nil
+}
+
+func (r *repository) updateUploadLedger(updateFunc func(ledger *uploadLedger) error) error {
+	return r.store.UpdateGlobal(uploadLedgerName, func(data []byte) ([]byte, error) {
+		ledger, err := parseUploadLedger(data)
+		if err != nil {
+			return nil, err
+		}
+		if err := updateFunc(ledger); err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(ledger, "", "  ")
+	})
+}
+
+// LookupUploadLedger 查询视频是否已在任意账号 / 服务器上发布，未记录时返回 nil
+func (r *repository) LookupUploadLedger(videoID string) (*LedgerEntry, error) {
+	videoID = strings.TrimSpace(videoID)
+	if videoID == "" {
+		return nil, nil
+	}
+	data, err := r.store.LoadGlobal(uploadLedgerName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取上传账本失败: %w", err)
+	}
+	ledger, err := parseUploadLedger(data)
+	if err != nil {
+		return nil, err
+	}
+	return ledger.Entries[videoID], nil
+}
+
+// RecordUploadLedger 记录已发布的视频（同一视频 ID 以最新一次发布为准）
+func (r *repository) RecordUploadLedger(entry LedgerEntry) error {
+	entry.VideoID = strings.TrimSpace(entry.VideoID)
+	if entry.VideoID == "" || entry.AID == "" {
+		return nil
+	}
+	if entry.Host == "" {
+		entry.Host, _ = os.Hostname()
+	}
+	if entry.UploadedAt == 0 {
+		entry.UploadedAt = time.Now().Unix()
+	}
+	return r.updateUploadLedger(func(ledger *uploadLedger) error {
+		ledger.Entries[entry.VideoID] = &entry
+		return nil
+	})
+}
+
+// ForgetUploadLedger 稿件已不存在或被退回重新上传时移除记录（只移除 aid 相同的记录）
+func (r *repository) ForgetUploadLedger(videoID, aid string) error {
+	videoID = strings.TrimSpace(videoID)
+	if videoID == "" {
+		return nil
+	}
+	return r.updateUploadLedger(func(ledger *uploadLedger) error {
+		if entry := ledger.Entries[videoID]; entry != nil && (aid == "" || entry.AID == aid) {
+			delete(ledger.Entries, videoID)
+		}
+		return nil
+	})
+}
+
+// LoadUploadLedger 返回账本中的全部记录（按视频 ID 排序）
+func (r *repository) LoadUploadLedger() ([]LedgerEntry, error) {
+	data, err := r.store.LoadGlobal(uploadLedgerName)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取上传账本失败: %w", err)
+	}
+	ledger, err := parseUploadLedger(data)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]LedgerEntry, 0, len(ledger.Entries))
+	for _, entry := range ledger.Entries {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].VideoID < entries[j].VideoID })
+	return entries, nil
+}
+
+// MergeUploadLedger 合并其他服务器导出的账本：新视频直接加入；同一 aid 补全缺失字段；
+// 同一视频 ID 对应不同 aid 时保留较早上传的记录并计为冲突
+func (r *repository) MergeUploadLedger(entries []LedgerEntry) (LedgerMergeResult, error) {
+	var result LedgerMergeResult
+	err := r.updateUploadLedger(func(ledger *uploadLedger) error {
+		result = LedgerMergeResult{}
+		for _, entry := range entries {
+			entry := entry
+			entry.VideoID = strings.TrimSpace(entry.VideoID)
+			if entry.VideoID == "" || entry.AID == "" {
+				continue
+			}
+			existing := ledger.Entries[entry.VideoID]
+			switch {
+			case existing == nil:
+				ledger.Entries[entry.VideoID] = &entry
+				result.Added++
+			case existing.AID == entry.AID:
+				changed := false
+				if existing.Account == "" && entry.Account != "" {
+					existing.Account, changed = entry.Account, true
+				}
+				if existing.Host == "" && entry.Host != "" {
+					existing.Host, changed = entry.Host, true
+				}
+				if existing.UploadedAt == 0 || (entry.UploadedAt != 0 && entry.UploadedAt < existing.UploadedAt) {
+					existing.UploadedAt, changed = entry.UploadedAt, true
+				}
+				if changed {
+					result.Updated++
+				}
+			default:
+				result.Conflicts++
+				if entry.UploadedAt != 0 && (existing.UploadedAt == 0 || entry.UploadedAt < existing.UploadedAt) {
+					ledger.Entries[entry.VideoID] = &entry
+				}
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// ledgerVideoID 从 video_info.json 中读取视频 ID（账本只按真实的 YouTube 视频 ID 记录）
+func (r *repository) ledgerVideoID(videoDir string) string {
+	info, err := r.LoadVideoInfo(videoDir)
+	if err != nil || info == nil {
+		return ""
+	}
+	return strings.TrimSpace(info.ID)
+}
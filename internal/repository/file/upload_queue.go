@@ -0,0 +1,422 @@
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"blueberry/pkg/utils"
+)
+
+const (
+	uploadQueueDirName = "upload_queue"
+	queueLockName      = ".queue.lock"
+
+	// 队列条目状态（同时也是队列目录下的子目录名）
+	QueueStatePending  = "pending"
+	QueueStateInflight = "inflight"
+	QueueStateFailed   = "failed"
+)
+
+// QueueItem 上传队列中的一个待上传视频
+type QueueItem struct {
+	VideoID    string `json:"video_id"`
+	ChannelID  string `json:"channel_id"`
+	VideoDir   string `json:"video_dir"`
+	EnqueuedAt int64  `json:"enqueued_at"`
+	// Attempts 已尝试上传的次数（失败重新入队时递增）
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error,omitempty"`
+	// NotBefore 重试退避：在该时间（Unix 秒）之前不会被领取
+	NotBefore int64 `json:"not_before,omitempty"`
+	// 领取者信息，用于重启后判断 inflight 条目是否被遗弃
+	OwnerHost string `json:"owner_host,omitempty"`
+	OwnerPID  int    `json:"owner_pid,omitempty"`
+	ClaimedAt int64  `json:"claimed_at,omitempty"`
+
+	// name 队列文件名（{入队纳秒}_{videoID}.json），保证 FIFO 顺序且重新入队时不变
+	name string
+}
+
+// QueueStats 队列中各状态的条目数量
+type QueueStats struct {
+	Pending  int `json:"pending"`
+	Inflight int `json:"inflight"`
+	Failed   int `json:"failed"`
+}
+
+// Backlog 尚未完成的条目数量（待领取 + 上传中）
+func (s QueueStats) Backlog() int {
+	return s.Pending + s.Inflight
+}
+
+// UploadQueue 持久化的上传队列
+// 每个条目是 {dir}/{pending,inflight,failed}/ 下的一个 JSON 文件，状态转换通过 rename 完成，
+// 因此进程崩溃后队列内容不会丢失，多个进程/goroutine 同时领取时同一条目只会被领取一次。
+type UploadQueue interface {
+	// Dir 返回队列目录
+	Dir() string
+	// Enqueue 将视频加入队列；同一 videoID 已在 pending/inflight 中时忽略，返回是否新加入
+	Enqueue(item QueueItem) (bool, error)
+	// Claim 领取最早入队且已过退避时间的条目并移入 inflight；没有可领取的条目时返回 nil, nil
+	Claim() (*QueueItem, error)
+	// Ack 上传完成，从队列中移除
+	Ack(item *QueueItem) error
+	// Nack 上传失败，记录错误并在 retryAfter 之后重新可领取
+	Nack(item *QueueItem, errMsg string, retryAfter time.Duration) error
+	// Release 将条目原样放回 pending，不计入尝试次数（用于取消/退出）
+	Release(item *QueueItem) error
+	// Fail 放弃该条目，移入 failed（不再自动重试，可通过 Requeue 恢复）
+	Fail(item *QueueItem, errMsg string) error
+	// Recover 将领取者进程已退出的 inflight 条目放回 pending，返回恢复的数量（在开始消费前调用）
+	Recover() (int, error)
+	// Requeue 将 failed 中的条目重新放回 pending 并清零尝试次数，返回数量
+	Requeue() (int, error)
+	// List 返回 pending / inflight / failed 下的条目（按入队顺序）
+	List(state string) ([]QueueItem, error)
+	// Stats 返回各状态的条目数量
+	Stats() (QueueStats, error)
+}
+
+type uploadQueue struct {
+	dir string
+}
+
+// DefaultUploadQueueDir 返回默认的上传队列目录 {outputDir}/.global/upload_queue
+func DefaultUploadQueueDir(outputDir string) string {
+	return filepath.Join(outputDir, globalDirName, uploadQueueDirName)
+}
+
+// NewUploadQueue 创建（或打开已有的）上传队列
+func NewUploadQueue(dir string) (UploadQueue, error) {
+	for _, sub := range []string{QueueStatePending, QueueStateInflight, QueueStateFailed} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("创建上传队列目录失败: %w", err)
+		}
+	}
+	return &uploadQueue{dir: dir}, nil
+}
+
+func (q *uploadQueue) Dir() string {
+	return q.dir
+}
+
+func (q *uploadQueue) path(state, name string) string {
+	return filepath.Join(q.dir, state, name)
+}
+
+// names 返回 state 目录下的队列文件名（文件名以入队时间开头，排序即 FIFO 顺序）
+func (q *uploadQueue) names(state string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(q.dir, state))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// videoIDFromName 从队列文件名 {入队纳秒}_{videoID}.json 中解析 videoID
+func videoIDFromName(name string) string {
+	name = strings.TrimSuffix(name, ".json")
+	if i := strings.IndexByte(name, '_'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+func (q *uploadQueue) read(state, name string) (*QueueItem, error) {
+	data, err := os.ReadFile(q.path(state, name))
+	if err != nil {
+		return nil, err
+	}
+	var item QueueItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, fmt.Errorf("解析队列条目失败 (%s): %w", name, err)
+	}
+	item.name = name
+	return &item, nil
+}
+
+func (q *uploadQueue) write(state string, item *QueueItem) error {
+	data, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(q.path(state, item.name), data, 0644)
+}
+
+// lock 获取队列级别的跨进程锁，用于 Enqueue 去重、Recover 等多文件操作，
+// 以及 Claim / release 中条目在 inflight 而领取者信息尚未更新的窗口
+func (q *uploadQueue) lock() (*utils.FileLock, error) {
+	return utils.LockFile(filepath.Join(q.dir, queueLockName))
+}
+
+func (q *uploadQueue) Enqueue(item QueueItem) (bool, error) {
+	if item.VideoID == "" {
+		return false, fmt.Errorf("队列条目缺少 video_id")
+	}
+	lock, err := q.lock()
+	if err != nil {
+		return false, err
+	}
+	defer lock.Unlock()
+
+	for _, state := range []string{QueueStatePending, QueueStateInflight} {
+		names, err := q.names(state)
+		if err != nil {
+			return false, fmt.Errorf("读取上传队列失败: %w", err)
+		}
+		for _, name := range names {
+			if videoIDFromName(name) == item.VideoID {
+				return false, nil
+			}
+		}
+	}
+
+	now := time.Now()
+	item.EnqueuedAt = now.Unix()
+	item.Attempts = 0
+	item.LastError = ""
+	item.NotBefore = 0
+	item.OwnerHost, item.OwnerPID, item.ClaimedAt = "", 0, 0
+	item.name = fmt.Sprintf("%019d_%s.json", now.UnixNano(), item.VideoID)
+
+	// 同一 videoID 之前失败过的条目被新的入队取代
+	if names, err := q.names(QueueStateFailed); err == nil {
+		for _, name := range names {
+			if videoIDFromName(name) == item.VideoID {
+				_ = os.Remove(q.path(QueueStateFailed, name))
+			}
+		}
+	}
+
+	if err := q.write(QueueStatePending, &item); err != nil {
+		return false, fmt.Errorf("写入上传队列失败: %w", err)
+	}
+	return true, nil
+}
+
+func (q *uploadQueue) Claim() (*QueueItem, error) {
+	// 持有队列锁：Recover 不会看到尚未写入领取者信息的 inflight 条目
+	lock, err := q.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	names, err := q.names(QueueStatePending)
+	if err != nil {
+		return nil, fmt.Errorf("读取上传队列失败: %w", err)
+	}
+	now := time.Now()
+	host, _ := os.Hostname()
+	for _, name := range names {
+		item, err := q.read(QueueStatePending, name)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // 已被其他 worker 领取
+			}
+			return nil, err
+		}
+		if item.NotBefore > now.Unix() {
+			continue
+		}
+		// rename 是原子的：只有一个领取者能成功
+		if err := os.Rename(q.path(QueueStatePending, name), q.path(QueueStateInflight, name)); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("领取队列条目失败: %w", err)
+		}
+		item.OwnerHost = host
+		item.OwnerPID = os.Getpid()
+		item.ClaimedAt = now.Unix()
+		if err := q.write(QueueStateInflight, item); err != nil {
+			return nil, fmt.Errorf("更新队列条目失败: %w", err)
+		}
+		return item, nil
+	}
+	return nil, nil
+}
+
+func (q *uploadQueue) Ack(item *QueueItem) error {
+	if err := os.Remove(q.path(QueueStateInflight, item.name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("移除队列条目失败: %w", err)
+	}
+	return nil
+}
+
+// release 将 inflight 条目更新后移动到 state 目录
+func (q *uploadQueue) release(item *QueueItem, state string) error {
+	lock, err := q.lock()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	return q.releaseLocked(item, state)
+}
+
+// releaseLocked 同 release，调用方持有队列锁
+func (q *uploadQueue) releaseLocked(item *QueueItem, state string) error {
+	item.OwnerHost, item.OwnerPID, item.ClaimedAt = "", 0, 0
+	if err := q.write(QueueStateInflight, item); err != nil {
+		return fmt.Errorf("更新队列条目失败: %w", err)
+	}
+	if err := os.Rename(q.path(QueueStateInflight, item.name), q.path(state, item.name)); err != nil {
+		return fmt.Errorf("移动队列条目失败: %w", err)
+	}
+	return nil
+}
+
+func (q *uploadQueue) Nack(item *QueueItem, errMsg string, retryAfter time.Duration) error {
+	item.Attempts++
+	item.LastError = errMsg
+	item.NotBefore = 0
+	if retryAfter > 0 {
+		item.NotBefore = time.Now().Add(retryAfter).Unix()
+	}
+	return q.release(item, QueueStatePending)
+}
+
+func (q *uploadQueue) Release(item *QueueItem) error {
+	return q.release(item, QueueStatePending)
+}
+
+func (q *uploadQueue) Fail(item *QueueItem, errMsg string) error {
+	item.Attempts++
+	item.LastError = errMsg
+	item.NotBefore = 0
+	return q.release(item, QueueStateFailed)
+}
+
+func (q *uploadQueue) Recover() (int, error) {
+	lock, err := q.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer lock.Unlock()
+
+	names, err := q.names(QueueStateInflight)
+	if err != nil {
+		return 0, fmt.Errorf("读取上传队列失败: %w", err)
+	}
+	host, _ := os.Hostname()
+	recovered := 0
+	for _, name := range names {
+		item, err := q.read(QueueStateInflight, name)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			// 损坏的条目直接放回 pending，重新领取时会再次解析
+			if renameErr := os.Rename(q.path(QueueStateInflight, name), q.path(QueueStatePending, name)); renameErr == nil {
+				recovered++
+			}
+			continue
+		}
+		// 其他主机领取的条目（共享存储）无法判断进程是否存活，保持不动
+		if item.OwnerHost != "" && item.OwnerHost != host {
+			continue
+		}
+		if item.OwnerPID != os.Getpid() && utils.ProcessAlive(item.OwnerPID) {
+			continue
+		}
+		if err := q.releaseLocked(item, QueueStatePending); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return recovered, err
+		}
+		recovered++
+	}
+	return recovered, nil
+}
+
+func (q *uploadQueue) Requeue() (int, error) {
+	lock, err := q.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer lock.Unlock()
+
+	names, err := q.names(QueueStateFailed)
+	if err != nil {
+		return 0, fmt.Errorf("读取上传队列失败: %w", err)
+	}
+	requeued := 0
+	for _, name := range names {
+		item, err := q.read(QueueStateFailed, name)
+		if err != nil {
+			continue
+		}
+		item.Attempts = 0
+		item.NotBefore = 0
+		if err := q.write(QueueStateFailed, item); err != nil {
+			return requeued, fmt.Errorf("更新队列条目失败: %w", err)
+		}
+		if err := os.Rename(q.path(QueueStateFailed, name), q.path(QueueStatePending, name)); err != nil {
+			return requeued, fmt.Errorf("移动队列条目失败: %w", err)
+		}
+		requeued++
+	}
+	return requeued, nil
+}
+
+func (q *uploadQueue) List(state string) ([]QueueItem, error) {
+	switch state {
+	case QueueStatePending, QueueStateInflight, QueueStateFailed:
+	default:
+		return nil, fmt.Errorf("未知的队列状态: %s（可选: pending, inflight, failed）", state)
+	}
+	names, err := q.names(state)
+	if err != nil {
+		return nil, fmt.Errorf("读取上传队列失败: %w", err)
+	}
+	items := make([]QueueItem, 0, len(names))
+	for _, name := range names {
+		item, err := q.read(state, name)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, nil
+}
+
+func (q *uploadQueue) Stats() (QueueStats, error) {
+	var stats QueueStats
+	for _, c := range []struct {
+		state string
+		count *int
+	}{
+		{QueueStatePending, &stats.Pending},
+		{QueueStateInflight, &stats.Inflight},
+		{QueueStateFailed, &stats.Failed},
+	} {
+		names, err := q.names(c.state)
+		if err != nil {
+			return stats, fmt.Errorf("读取上传队列失败: %w", err)
+		}
+		*c.count = len(names)
+	}
+	return stats, nil
+}
@@ -0,0 +1,82 @@
+package file
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// 持有队列锁读取 inflight（与 Recover 相同）时，不应看到没有领取者信息的条目
+func TestUploadQueueClaimNeverExposesOwnerlessInflight(t *testing.T) {
+	queue, err := NewUploadQueue(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := queue.(*uploadQueue)
+	const items = 200
+	for i := 0; i < items; i++ {
+		if _, err := q.Enqueue(QueueItem{VideoID: fmt.Sprintf("vid%04d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				item, err := q.Claim()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if item == nil {
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		lock, err := q.lock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		names, err := q.names(QueueStateInflight)
+		if err != nil {
+			lock.Unlock()
+			t.Fatal(err)
+		}
+		for _, name := range names {
+			item, err := q.read(QueueStateInflight, name)
+			if err != nil {
+				lock.Unlock()
+				t.Fatal(err)
+			}
+			if item.OwnerPID == 0 {
+				lock.Unlock()
+				t.Fatalf("inflight 条目 %s 没有领取者信息", name)
+			}
+		}
+		lock.Unlock()
+	}
+
+	stats, err := q.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Inflight != items || stats.Pending != 0 {
+		t.Fatalf("stats = %+v，期望全部 %d 个条目被领取", stats, items)
+	}
+}
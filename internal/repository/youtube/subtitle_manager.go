@@ -5,12 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	"blueberry/internal/config"
 	"blueberry/pkg/logger"
+	"blueberry/pkg/utils"
 )
 
 type SubtitleManager interface {
@@ -63,7 +64,7 @@ func (s *subtitleManager) ListSubtitles(ctx context.Context, videoURL string, la
 
 	args = append(args, videoURL)
 
-	cmd := exec.CommandContext(ctx, "yt-dlp", args...)
+	cmd := utils.CommandContext(ctx, YtDlpPath(config.Get()), args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, fmt.Errorf("获取字幕信息失败: %w, 输出: %s", err, string(output))
@@ -20,11 +20,21 @@ import (
 	"blueberry/internal/repository/file"
 	"blueberry/pkg/logger"
 	"blueberry/pkg/subtitle"
+	"blueberry/pkg/utils"
 )
 
 var ErrBotDetection = errors.New("bot detection")
 var ErrFileStuck = errors.New("file download stuck (no size change)")
 
+// 下载过程中的等待与卡住检测时长（变量便于测试时缩短）
+var (
+	strategySwitchDelay   = 3 * time.Second  // 切换到下一种下载策略前的等待
+	progressCheckInterval = 60 * time.Second // 心跳日志与下载文件大小检查的间隔
+	stuckTimeout          = 2 * time.Minute  // 下载文件总大小无变化超过该时长视为卡住
+	partFileRetryDelay    = 15 * time.Second // 存在 .part 文件时等待下载/合并完成的基础时长（随重试次数递增）
+	partFileMaxRetryDelay = 60 * time.Second // 等待下载/合并完成的最大时长
+)
+
 type Downloader interface {
 	DownloadVideo(ctx context.Context, channelID, videoURL string, languages []string, title string) (*DownloadResult, error)
 }
@@ -51,6 +61,7 @@ func NewDownloader(fileRepo file.Repository, cookiesFromBrowser, cookiesFile str
 }
 
 func (d *downloader) DownloadVideo(ctx context.Context, channelID, videoURL string, languages []string, title string) (*DownloadResult, error) {
+	log := logger.Ctx(ctx)
 	videoID := d.fileRepo.ExtractVideoID(videoURL)
 
 	// 使用视频ID创建目录（不再使用标题）
@@ -63,7 +74,7 @@ func (d *downloader) DownloadVideo(ctx context.Context, channelID, videoURL stri
 	const maxDownloadRetries = 5
 	for retryCount := 0; retryCount < maxDownloadRetries; retryCount++ {
 		if retryCount > 0 {
-			logger.Info().
+			log.Info().
 				Str("video_id", videoID).
 				Int("retry_count", retryCount).
 				Int("max_retries", maxDownloadRetries).
@@ -80,15 +91,15 @@ func (d *downloader) DownloadVideo(ctx context.Context, channelID, videoURL stri
 					cfg := config.Get()
 					if cfg != nil && cfg.YouTube.CleanupPartialFilesOnFailure {
 						if cleanupErr := d.fileRepo.CleanupPartialFiles(videoDir); cleanupErr != nil {
-							logger.Warn().Err(cleanupErr).Str("video_dir", videoDir).Msg("清理部分下载文件失败")
+							log.Warn().Err(cleanupErr).Str("video_dir", videoDir).Msg("清理部分下载文件失败")
 						} else {
-							logger.Info().Str("video_dir", videoDir).Msg("已清理部分下载的文件，准备重新下载")
+							log.Info().Str("video_dir", videoDir).Msg("已清理部分下载的文件，准备重新下载")
 						}
 					} else {
-						logger.Debug().Str("video_dir", videoDir).Msg("检测到文件卡住，但配置为不清理部分下载文件（cleanup_partial_files_on_failure=false），直接重新下载")
+						log.Debug().Str("video_dir", videoDir).Msg("检测到文件卡住，但配置为不清理部分下载文件（cleanup_partial_files_on_failure=false），直接重新下载")
 					}
 
-					logger.Warn().
+					log.Warn().
 						Str("video_id", videoID).
 						Int("retry_count", retryCount+1).
 						Int("max_retries", maxDownloadRetries).
@@ -113,6 +124,7 @@ func (d *downloader) DownloadVideo(ctx context.Context, channelID, videoURL stri
 
 // downloadVideoOnce 执行一次下载尝试（内部方法）
 func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL string, languages []string, title string, videoID, videoDir string) (*DownloadResult, error) {
+	log := logger.Ctx(ctx)
 	result := &DownloadResult{
 		SubtitlePaths: make([]string, 0),
 	}
@@ -130,13 +142,13 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 		// 优先使用 cookies（更稳定，减少风控），统一使用 best 选择器
 		{client: "web", includeCookie: true, sleepBefore: 0, useBest: true},
 		// 然后再尝试无 cookies
-		{client: "web", includeCookie: false, sleepBefore: 3 * time.Second, useBest: true},
+		{client: "web", includeCookie: false, sleepBefore: strategySwitchDelay, useBest: true},
 	}
 	// 按配置决定是否添加 android 回退
 	if cfg := config.Get(); cfg == nil || !cfg.YouTube.DisableAndroidFallback {
-		tries = append(tries, tryConf{client: "android", includeCookie: false, sleepBefore: 3 * time.Second, useBest: true})
+		tries = append(tries, tryConf{client: "android", includeCookie: false, sleepBefore: strategySwitchDelay, useBest: true})
 	} else {
-		logger.Info().Msg("已启用 youtube.disable_android_fallback，跳过 android 回退策略")
+		log.Info().Msg("已启用 youtube.disable_android_fallback，跳过 android 回退策略")
 	}
 
 	var lastErr error
@@ -148,14 +160,14 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 		var args []string
 		// 统一使用 bestvideo+bestaudio/best，避免触发更深风控，由下载结果再判断是否达到 1080p
 		args = d.buildBestArgsWithClient(videoDir, videoURL, languages, t.client, t.includeCookie)
-		logger.Debug().
+		log.Debug().
 			Int("strategy_index", i+1).
 			Str("client", t.client).
 			Bool("with_cookies", t.includeCookie).
 			Str("command", "yt-dlp "+strings.Join(args, " ")).
 			Msg("执行下载命令（按策略）")
 
-		cmd := exec.CommandContext(ctx, "yt-dlp", args...)
+		cmd := utils.CommandContext(ctx, YtDlpPath(config.Get()), args...)
 
 		// 使用管道实时读取输出，避免长时间阻塞无日志
 		var outputStr string
@@ -163,14 +175,14 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 
 		stdoutPipe, pipeErr := cmd.StdoutPipe()
 		if pipeErr != nil {
-			logger.Error().Err(pipeErr).Msg("创建 stdout 管道失败，使用 CombinedOutput")
+			log.Error().Err(pipeErr).Msg("创建 stdout 管道失败，使用 CombinedOutput")
 			output, cmdErr := cmd.CombinedOutput()
 			outputStr = string(output)
 			err = cmdErr
 		} else {
 			stderrPipe, pipeErr := cmd.StderrPipe()
 			if pipeErr != nil {
-				logger.Error().Err(pipeErr).Msg("创建 stderr 管道失败，使用 CombinedOutput")
+				log.Error().Err(pipeErr).Msg("创建 stderr 管道失败，使用 CombinedOutput")
 				output, cmdErr := cmd.CombinedOutput()
 				outputStr = string(output)
 				err = cmdErr
@@ -182,12 +194,13 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 				} else {
 					// 实时读取输出
 					var outputBuilder strings.Builder
+					var outputMu sync.Mutex // stdout / stderr 两个 goroutine 同时写入
 					outputDone := make(chan bool, 2)
 
 					// 用于跟踪文件大小变化
-					lastFileSize := int64(-1)          // 初始化为 -1，表示还未检测到文件
-					lastFileSizeTime := time.Time{}    // 初始化为零值，表示还未开始计时
-					fileSizeTimeout := 2 * time.Minute // 2分钟文件大小无变化则认为卡住
+					lastFileSize := int64(-1)       // 初始化为 -1，表示还未检测到文件
+					lastFileSizeTime := time.Time{} // 初始化为零值，表示还未开始计时
+					fileSizeTimeout := stuckTimeout // 文件大小长时间无变化则认为卡住
 					var fileSizeMutex sync.Mutex
 
 					// 读取 stdout
@@ -195,11 +208,13 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 						scanner := bufio.NewScanner(stdoutPipe)
 						for scanner.Scan() {
 							line := scanner.Text()
+							outputMu.Lock()
 							outputBuilder.WriteString(line)
 							outputBuilder.WriteString("\n")
+							outputMu.Unlock()
 							// 如果输出中包含进度信息，立即记录
 							if strings.Contains(line, "[download]") || strings.Contains(line, "%") {
-								logger.Info().
+								log.Info().
 									Int("strategy_index", i+1).
 									Str("client", t.client).
 									Str("progress", line).
@@ -214,11 +229,13 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 						scanner := bufio.NewScanner(stderrPipe)
 						for scanner.Scan() {
 							line := scanner.Text()
+							outputMu.Lock()
 							outputBuilder.WriteString(line)
 							outputBuilder.WriteString("\n")
+							outputMu.Unlock()
 							// 错误信息立即记录
 							if strings.Contains(line, "ERROR:") {
-								logger.Warn().
+								log.Warn().
 									Int("strategy_index", i+1).
 									Str("client", t.client).
 									Str("error_line", line).
@@ -229,7 +246,7 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 								strings.Contains(line, "confirm you're not a bot") ||
 								strings.Contains(line, "bot detection") ||
 								strings.Contains(line, "authentication") {
-								logger.Error().
+								log.Error().
 									Int("strategy_index", i+1).
 									Str("client", t.client).
 									Str("error_line", line).
@@ -239,12 +256,16 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 						outputDone <- true
 					}()
 
-					// 定期输出心跳日志（每60秒）
-					ticker := time.NewTicker(60 * time.Second)
+					// 定期输出心跳日志（默认每60秒）
+					ticker := time.NewTicker(progressCheckInterval)
 					defer ticker.Stop()
 					cmdDone := make(chan error, 1)
 
+					// Wait 会关闭 stdout/stderr 管道，必须等两个读取 goroutine 读到 EOF 后再调用，
+					// 否则可能丢失 yt-dlp 退出前最后输出的错误信息（403、bot detection 等）
 					go func() {
+						<-outputDone
+						<-outputDone
 						cmdDone <- cmd.Wait()
 					}()
 
@@ -253,23 +274,10 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 						select {
 						case waitErr := <-cmdDone:
 							ticker.Stop()
-							// 等待输出读取完成（最多等待5秒）
-							timeout := time.After(5 * time.Second)
-							outputReadCount := 0
-						readLoop:
-							for outputReadCount < 2 {
-								select {
-								case <-outputDone:
-									outputReadCount++
-								case <-timeout:
-									logger.Warn().
-										Int("strategy_index", i+1).
-										Int("read_count", outputReadCount).
-										Msg("等待输出读取超时，继续处理")
-									break readLoop
-								}
-							}
+							// 输出已在 Wait 之前读取完成
+							outputMu.Lock()
 							outputStr = outputBuilder.String()
+							outputMu.Unlock()
 							err = waitErr
 
 							// 检查输出中是否有 "Sleeping" 字样，如果有，说明可能还在 sleep 等待中
@@ -288,7 +296,7 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 								partPattern := filepath.Join(videoDir, "*.part")
 								matches, _ := filepath.Glob(partPattern)
 								if len(matches) > 0 {
-									logger.Warn().
+									log.Warn().
 										Int("strategy_index", i+1).
 										Str("output_snippet", previewForLog(outputStr, 200)).
 										Strs("part_files", matches).
@@ -306,7 +314,7 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 									exitCode = -1
 								}
 							}
-							logger.Debug().
+							log.Debug().
 								Int("strategy_index", i+1).
 								Int("exit_code", exitCode).
 								Int("output_length", len(outputStr)).
@@ -325,8 +333,8 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 							var filePaths []string
 
 							// 读取目录中的所有文件
-							entries, err := os.ReadDir(videoDir)
-							if err == nil {
+							entries, readErr := os.ReadDir(videoDir)
+							if readErr == nil {
 								for _, entry := range entries {
 									if entry.IsDir() {
 										continue
@@ -378,7 +386,7 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 
 								// 检查是否超过2分钟总大小无变化
 								if !lastFileSizeTime.IsZero() && noSizeChangeDuration > fileSizeTimeout {
-									logger.Warn().
+									log.Warn().
 										Int("strategy_index", i+1).
 										Str("client", t.client).
 										Bool("with_cookies", t.includeCookie).
@@ -393,14 +401,22 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 									if cmd.Process != nil {
 										cmd.Process.Kill()
 									}
-									// 等待进程结束
-									<-cmdDone
+									// 等待进程结束（子进程如 ffmpeg 仍占用输出管道时最多等待5秒）
+									select {
+									case <-cmdDone:
+									case <-time.After(5 * time.Second):
+										log.Warn().
+											Int("strategy_index", i+1).
+											Msg("等待进程退出超时，继续处理")
+									}
 									// 设置错误并继续到下一个策略
 									err = fmt.Errorf("文件大小无变化超时（%v 文件大小未变化）", noSizeChangeDuration)
+									outputMu.Lock()
 									outputStr = outputBuilder.String()
+									outputMu.Unlock()
 									lastErr = err
 									lastOutput = outputStr
-									logger.Error().
+									log.Error().
 										Int("strategy_index", i+1).
 										Str("client", t.client).
 										Bool("with_cookies", t.includeCookie).
@@ -421,7 +437,7 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 							}
 							fileSizeMutex.Unlock()
 
-							logger.Info().
+							log.Info().
 								Int("strategy_index", i+1).
 								Str("client", t.client).
 								Bool("with_cookies", t.includeCookie).
@@ -453,7 +469,7 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 	processOutput:
 
 		// 记录处理输出的调试信息（输出完整内容）
-		logger.Debug().
+		log.Debug().
 			Int("strategy_index", i+1).
 			Int("output_length", len(outputStr)).
 			Bool("has_error", err != nil).
@@ -489,7 +505,7 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 
 		// 强制记录错误信息（无论是否有错误，只要有输出就记录）
 		// 使用 Error 级别确保可见
-		logger.Error().
+		log.Error().
 			Int("strategy_index", i+1).
 			Str("client", t.client).
 			Bool("with_cookies", t.includeCookie).
@@ -520,7 +536,7 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 				partPattern := filepath.Join(videoDir, "*.part")
 				matches, _ := filepath.Glob(partPattern)
 				if len(matches) > 0 {
-					logger.Warn().
+					log.Warn().
 						Str("video_dir", videoDir).
 						Str("video_id", videoID).
 						Strs("part_files", matches).
@@ -528,7 +544,7 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 				}
 			}
 
-			videoFile, err := d.findVideoFileWithRetry(videoDir, videoID)
+			videoFile, err := d.findVideoFileWithRetry(ctx, videoDir, videoID)
 			if err != nil {
 				// 如果是文件卡住的错误，直接返回（不要包装），以便外层能正确检测
 				if errors.Is(err, ErrFileStuck) {
@@ -542,32 +558,32 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 			if err == nil {
 				result.SubtitlePaths = subtitleFiles
 				// 清理旧的 .frame.srt 文件（不再需要帧格式转换）
-				d.cleanupFrameSrtFiles(videoDir)
+				d.cleanupFrameSrtFiles(ctx, videoDir)
 				// 如果下载的是 VTT 格式，尝试转换为 SRT
-				result.SubtitlePaths = d.convertVTTToSRTIfNeeded(videoDir, result.SubtitlePaths)
+				result.SubtitlePaths = d.convertVTTToSRTIfNeeded(ctx, videoDir, result.SubtitlePaths)
 				// 去除字幕文件名中的分辨率标识（例如: id_1080p.en.srt -> id.en.srt）
-				result.SubtitlePaths = d.stripResolutionFromSubtitleFilenames(videoDir, result.SubtitlePaths)
+				result.SubtitlePaths = d.stripResolutionFromSubtitleFilenames(ctx, videoDir, result.SubtitlePaths)
 				// 检查字幕时间轴重叠（受配置开关控制）
 				if cfg := config.Get(); cfg != nil && cfg.Subtitles.AutoFixOverlap {
 					for _, subPath := range result.SubtitlePaths {
-						if err := d.validateSubtitleOverlap(subPath); err != nil {
-							logger.Warn().
+						if err := d.validateSubtitleOverlap(ctx, subPath); err != nil {
+							log.Warn().
 								Str("subtitle_path", subPath).
 								Err(err).
 								Msg("字幕时间轴重叠检查失败")
 						}
 					}
 				} else {
-					logger.Debug().Msg("自动修复字幕时间轴重叠已禁用")
+					log.Debug().Msg("自动修复字幕时间轴重叠已禁用")
 				}
 				// 重命名字幕文件为 {title}[{video_id}].{lang}.{ext} 格式
-				result.SubtitlePaths = d.renameSubtitlesToTitleFormat(videoDir, videoID, title, result.SubtitlePaths)
+				result.SubtitlePaths = d.renameSubtitlesToTitleFormat(ctx, videoDir, videoID, title, result.SubtitlePaths)
 			}
 
 			result.VideoTitle = d.fileRepo.ExtractVideoTitleFromFile(videoFile)
 			// 从文件名解析高度，标记是否至少为 1080p
 			if err := d.markHas1080p(videoDir, videoFile); err != nil {
-				logger.Warn().Err(err).Msg("标记 has_1080p 失败（忽略）")
+				log.Warn().Err(err).Msg("标记 has_1080p 失败（忽略）")
 			}
 			return result, nil
 		}
@@ -593,7 +609,7 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 		if isBotDetection {
 			// 不立即中止，尝试下一种策略，但先打印详细错误信息
 			// 使用 Error 级别确保错误信息被记录
-			logger.Error().
+			log.Error().
 				Int("strategy_index", i+1).
 				Str("client", t.client).
 				Bool("with_cookies", t.includeCookie).
@@ -609,7 +625,7 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 
 		// 其他错误，打印详细错误信息
 		// 使用 Error 级别确保错误信息被记录
-		logger.Error().
+		log.Error().
 			Int("strategy_index", i+1).
 			Str("client", t.client).
 			Bool("with_cookies", t.includeCookie).
@@ -627,13 +643,13 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 			minHeight = cfg.YouTube.MinHeight
 		}
 		minArgs := d.buildMinimalArgs(videoDir, videoURL, languages, minHeight, true)
-		logger.Info().Msg("尝试使用最小化参数进行兜底下载")
-		cmd := exec.CommandContext(ctx, "yt-dlp", minArgs...)
+		log.Info().Msg("尝试使用最小化参数进行兜底下载")
+		cmd := utils.CommandContext(ctx, YtDlpPath(config.Get()), minArgs...)
 		output, err := cmd.CombinedOutput()
 		if err == nil {
 			// 成功，返回结果
 			// 注意：yt-dlp 可能返回成功，但文件还在合并中（HLS 下载），需要等待
-			videoFile, errFind := d.findVideoFileWithRetry(videoDir, videoID)
+			videoFile, errFind := d.findVideoFileWithRetry(ctx, videoDir, videoID)
 			if errFind != nil {
 				// 如果是文件卡住的错误，直接返回（不要包装），以便外层能正确检测
 				if errors.Is(errFind, ErrFileStuck) {
@@ -644,8 +660,8 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 			result.VideoPath = videoFile
 			if subtitleFiles, err := d.fileRepo.FindSubtitleFiles(videoDir); err == nil {
 				result.SubtitlePaths = subtitleFiles
-				d.cleanupFrameSrtFiles(videoDir)
-				result.SubtitlePaths = d.convertVTTToSRTIfNeeded(videoDir, result.SubtitlePaths)
+				d.cleanupFrameSrtFiles(ctx, videoDir)
+				result.SubtitlePaths = d.convertVTTToSRTIfNeeded(ctx, videoDir, result.SubtitlePaths)
 			}
 			result.VideoTitle = d.fileRepo.ExtractVideoTitleFromFile(videoFile)
 			return result, nil
@@ -658,10 +674,10 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 	// 所有尝试都失败了，但在返回错误前，检查视频文件是否已经成功下载
 	// 某些情况下（如缺少 ffprobe），yt-dlp 可能返回错误但实际已下载视频
 	// 注意：即使命令失败，文件可能还在合并中，需要等待
-	videoFile, errFind := d.findVideoFileWithRetry(videoDir, videoID)
+	videoFile, errFind := d.findVideoFileWithRetry(ctx, videoDir, videoID)
 	if errFind == nil && videoFile != "" {
 		// 视频文件存在，认为下载成功（即使 yt-dlp 返回了错误）
-		logger.Warn().
+		log.Warn().
 			Str("video_url", videoURL).
 			Str("video_dir", videoDir).
 			Str("video_file", videoFile).
@@ -670,13 +686,13 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 		result.VideoPath = videoFile
 		if subtitleFiles, err := d.fileRepo.FindSubtitleFiles(videoDir); err == nil {
 			result.SubtitlePaths = subtitleFiles
-			d.cleanupFrameSrtFiles(videoDir)
-			result.SubtitlePaths = d.convertVTTToSRTIfNeeded(videoDir, result.SubtitlePaths)
+			d.cleanupFrameSrtFiles(ctx, videoDir)
+			result.SubtitlePaths = d.convertVTTToSRTIfNeeded(ctx, videoDir, result.SubtitlePaths)
 		}
 		result.VideoTitle = d.fileRepo.ExtractVideoTitleFromFile(videoFile)
 		// 从文件名解析高度，标记是否至少为 1080p
 		if err := d.markHas1080p(videoDir, videoFile); err != nil {
-			logger.Warn().Err(err).Msg("标记 has_1080p 失败（忽略）")
+			log.Warn().Err(err).Msg("标记 has_1080p 失败（忽略）")
 		}
 		return result, nil
 	}
@@ -686,7 +702,7 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 	if strings.Contains(lastOutput, "Sign in to confirm you're not a bot") ||
 		strings.Contains(lastOutput, "confirm you're not a bot") ||
 		strings.Contains(lastOutput, "authentication") {
-		logger.Error().
+		log.Error().
 			Str("video_url", videoURL).
 			Str("video_dir", videoDir).
 			Str("output", lastOutput).
@@ -695,7 +711,17 @@ func (d *downloader) downloadVideoOnce(ctx context.Context, channelID, videoURL
 		return nil, fmt.Errorf("%w: %s", ErrBotDetection, lastOutput)
 	}
 
-	logger.Error().
+	// 各策略都因文件卡住被终止，且残留的 .part 文件仍无变化：返回 ErrFileStuck，由外层重新下载
+	if errors.Is(errFind, ErrFileStuck) {
+		log.Error().
+			Str("video_url", videoURL).
+			Str("video_dir", videoDir).
+			Err(lastErr).
+			Msg("下载失败，文件卡住")
+		return nil, errFind
+	}
+
+	log.Error().
 		Str("video_url", videoURL).
 		Str("video_dir", videoDir).
 		Str("output", lastOutput).
@@ -758,6 +784,7 @@ func (d *downloader) buildDownloadArgs(videoDir, videoURL string, languages []st
 // choosePlayerClient 通过 yt-dlp --list-formats 预探测可用的 player_client
 // 优先 android，若 android 不可用则回退 web；都不可用返回错误
 func (d *downloader) choosePlayerClient(ctx context.Context, videoURL string) (string, string, error) {
+	log := logger.Ctx(ctx)
 	candidates := []string{"android", "web"}
 	var lastOut string
 	cfg := config.Get()
@@ -784,12 +811,12 @@ func (d *downloader) choosePlayerClient(ctx context.Context, videoURL string) (s
 			args = append(args, "--cookies-from-browser", d.cookiesFromBrowser)
 		}
 		args = append(args, videoURL)
-		cmd := exec.CommandContext(ctx, "yt-dlp", args...)
+		cmd := utils.CommandContext(ctx, YtDlpPath(config.Get()), args...)
 		output, err := cmd.CombinedOutput()
 		outStr := string(output)
 		lastOut = outStr
 		if err != nil {
-			logger.Warn().
+			log.Warn().
 				Str("client", client).
 				Err(err).
 				Str("output_preview", previewForLog(outStr, 600)).
@@ -801,11 +828,11 @@ func (d *downloader) choosePlayerClient(ctx context.Context, videoURL string) (s
 			(strings.Contains(outStr, " mp4 ") || strings.Contains(outStr, "m3u8") || strings.Contains(outStr, "dash")) {
 			// 额外记录若出现 SABR 提示
 			if strings.Contains(outStr, "SABR") || strings.Contains(outStr, "nsig extraction failed") {
-				logger.Warn().Str("client", client).Msg("list-formats 提示 SABR 或 nsig 警告，仍尝试该 client")
+				log.Warn().Str("client", client).Msg("list-formats 提示 SABR 或 nsig 警告，仍尝试该 client")
 			}
 			return client, outStr, nil
 		}
-		logger.Warn().
+		log.Warn().
 			Str("client", client).
 			Str("output_preview", previewForLog(outStr, 600)).
 			Msg("list-formats 未发现可用格式，尝试下一个 client")
@@ -913,7 +940,8 @@ func (d *downloader) buildBestArgsWithClient(videoDir, videoURL string, language
 
 // convertVTTToSRTIfNeeded 如果需要，将 VTT 字幕转换为 SRT
 // 如果系统没有 ffmpeg，yt-dlp 会下载 VTT 格式，这里我们手动转换
-func (d *downloader) convertVTTToSRTIfNeeded(videoDir string, subtitlePaths []string) []string {
+func (d *downloader) convertVTTToSRTIfNeeded(ctx context.Context, videoDir string, subtitlePaths []string) []string {
+	log := logger.Ctx(ctx)
 	// 检查是否有 ffmpeg（如果有，yt-dlp 应该已经转换了）
 	if _, err := exec.LookPath("ffmpeg"); err == nil {
 		// 有 ffmpeg，yt-dlp 应该已经转换了，直接返回
@@ -930,20 +958,20 @@ func (d *downloader) convertVTTToSRTIfNeeded(videoDir string, subtitlePaths []st
 			// 转换为 SRT
 			srtPath, err := subtitle.ConvertVTTToSRT(path)
 			if err != nil {
-				logger.Warn().
+				log.Warn().
 					Str("vtt_path", path).
 					Err(err).
 					Msg("VTT 转 SRT 失败，保留原文件")
 				convertedPaths = append(convertedPaths, path)
 			} else {
-				logger.Info().
+				log.Info().
 					Str("vtt_path", path).
 					Str("srt_path", srtPath).
 					Msg("VTT 已转换为 SRT")
 				convertedPaths = append(convertedPaths, srtPath)
 				// 可选：删除原 VTT 文件
 				// if err := os.Remove(path); err != nil {
-				// 	logger.Warn().Str("path", path).Err(err).Msg("删除 VTT 文件失败")
+				// 	log.Warn().Str("path", path).Err(err).Msg("删除 VTT 文件失败")
 				// }
 			}
 		} else {
@@ -953,7 +981,7 @@ func (d *downloader) convertVTTToSRTIfNeeded(videoDir string, subtitlePaths []st
 	}
 
 	if hasVTT {
-		logger.Info().
+		log.Info().
 			Int("converted_count", len(convertedPaths)).
 			Msg("已使用纯 Go 实现将 VTT 转换为 SRT（无需 ffmpeg）")
 	}
@@ -1059,7 +1087,8 @@ func sanitizeSubtitleFilename(filename string) string {
 
 // renameSubtitlesToTitleFormat 将字幕文件重命名为 {title}[{video_id}].{lang}.{ext} 格式
 // 输入格式可能是：{video_id}.{lang}.{ext} 或 {video_id}.{lang}.frame.srt
-func (d *downloader) renameSubtitlesToTitleFormat(videoDir, videoID, title string, subtitlePaths []string) []string {
+func (d *downloader) renameSubtitlesToTitleFormat(ctx context.Context, videoDir, videoID, title string, subtitlePaths []string) []string {
+	log := logger.Ctx(ctx)
 	var renamedPaths []string
 
 	// 清理标题中的特殊字符，确保文件名安全
@@ -1077,7 +1106,7 @@ func (d *downloader) renameSubtitlesToTitleFormat(videoDir, videoID, title strin
 		// 检查是否已经是新格式（包含 [video_id] 的模式）
 		// 如果已经是新格式，跳过重命名
 		if strings.Contains(base, fmt.Sprintf("[%s]", videoID)) {
-			logger.Debug().
+			log.Debug().
 				Str("subtitle_path", subtitlePath).
 				Msg("字幕文件已经是新格式，跳过重命名")
 			renamedPaths = append(renamedPaths, subtitlePath)
@@ -1101,7 +1130,7 @@ func (d *downloader) renameSubtitlesToTitleFormat(videoDir, videoID, title strin
 		parts := strings.Split(nameWithoutExt, ".")
 		if len(parts) < 2 {
 			// 如果无法解析，保持原文件名
-			logger.Warn().Str("subtitle_path", subtitlePath).Msg("无法解析字幕文件名格式，保持原文件名")
+			log.Warn().Str("subtitle_path", subtitlePath).Msg("无法解析字幕文件名格式，保持原文件名")
 			renamedPaths = append(renamedPaths, subtitlePath)
 			continue
 		}
@@ -1120,7 +1149,7 @@ func (d *downloader) renameSubtitlesToTitleFormat(videoDir, videoID, title strin
 
 		if lang == "" || lang == videoID {
 			// 如果语言代码为空或等于视频ID，说明解析失败
-			logger.Warn().Str("subtitle_path", subtitlePath).Str("lang", lang).Msg("无法提取语言代码，保持原文件名")
+			log.Warn().Str("subtitle_path", subtitlePath).Str("lang", lang).Msg("无法提取语言代码，保持原文件名")
 			renamedPaths = append(renamedPaths, subtitlePath)
 			continue
 		}
@@ -1150,7 +1179,7 @@ func (d *downloader) renameSubtitlesToTitleFormat(videoDir, videoID, title strin
 					truncated = truncated[:len(truncated)-1]
 				}
 				finalTitle = string(truncated)
-				logger.Debug().
+				log.Debug().
 					Str("original_title", sanitizedTitle).
 					Str("truncated_title", finalTitle).
 					Int("max_title_length", maxTitleLength).
@@ -1167,20 +1196,20 @@ func (d *downloader) renameSubtitlesToTitleFormat(videoDir, videoID, title strin
 		// 如果新文件已存在，先删除
 		if _, err := os.Stat(newPath); err == nil {
 			if err := os.Remove(newPath); err != nil {
-				logger.Warn().Str("path", newPath).Err(err).Msg("删除已存在的字幕文件失败")
+				log.Warn().Str("path", newPath).Err(err).Msg("删除已存在的字幕文件失败")
 			}
 		}
 
 		// 复制文件为新格式（保留旧格式文件）
 		if err := copyFile(subtitlePath, newPath); err != nil {
-			logger.Warn().
+			log.Warn().
 				Str("old_path", originalPath).
 				Str("new_path", newPath).
 				Err(err).
 				Msg("复制字幕文件失败，保持原文件名")
 			renamedPaths = append(renamedPaths, subtitlePath)
 		} else {
-			logger.Info().
+			log.Info().
 				Str("old_path", originalPath).
 				Str("new_path", newPath).
 				Str("lang", lang).
@@ -1193,7 +1222,8 @@ func (d *downloader) renameSubtitlesToTitleFormat(videoDir, videoID, title strin
 }
 
 // validateSubtitleOverlap 检查字幕文件中的时间轴重叠，如果发现重叠则自动修复
-func (d *downloader) validateSubtitleOverlap(subtitlePath string) error {
+func (d *downloader) validateSubtitleOverlap(ctx context.Context, subtitlePath string) error {
+	log := logger.Ctx(ctx)
 	// 只检查 SRT 文件
 	if !strings.HasSuffix(strings.ToLower(subtitlePath), ".srt") {
 		return nil
@@ -1297,7 +1327,7 @@ func (d *downloader) validateSubtitleOverlap(subtitlePath string) error {
 			newTimeStr := formatTimeRangeForLine(current.startTime, current.endTime, current.isMillisecond)
 			lines[current.lineIndex] = timePattern.ReplaceAllString(line, newTimeStr)
 
-			logger.Info().
+			log.Info().
 				Str("subtitle_path", subtitlePath).
 				Int("line", current.lineIndex+1).
 				Str("old_time", extractTimeFromLine(line)).
@@ -1311,9 +1341,9 @@ func (d *downloader) validateSubtitleOverlap(subtitlePath string) error {
 		// 创建备份文件
 		backupPath := subtitlePath + ".backup"
 		if err := copyFile(subtitlePath, backupPath); err != nil {
-			logger.Warn().Err(err).Msg("创建备份文件失败")
+			log.Warn().Err(err).Msg("创建备份文件失败")
 		} else {
-			logger.Info().Str("backup_path", backupPath).Msg("已创建字幕文件备份")
+			log.Info().Str("backup_path", backupPath).Msg("已创建字幕文件备份")
 		}
 
 		// 写回修复后的内容
@@ -1333,7 +1363,7 @@ func (d *downloader) validateSubtitleOverlap(subtitlePath string) error {
 			return fmt.Errorf("刷新字幕文件失败: %w", err)
 		}
 
-		logger.Info().
+		log.Info().
 			Str("subtitle_path", subtitlePath).
 			Msg("已自动修复字幕时间轴重叠并保存")
 	}
@@ -1414,7 +1444,8 @@ func formatTimeRange(start, end float64) string {
 }
 
 // cleanupFrameSrtFiles 清理旧的 .frame.srt 文件（不再需要帧格式转换）
-func (d *downloader) cleanupFrameSrtFiles(videoDir string) {
+func (d *downloader) cleanupFrameSrtFiles(ctx context.Context, videoDir string) {
+	log := logger.Ctx(ctx)
 	entries, err := os.ReadDir(videoDir)
 	if err != nil {
 		return
@@ -1430,7 +1461,7 @@ func (d *downloader) cleanupFrameSrtFiles(videoDir string) {
 			// 如果对应的 .srt 文件存在，删除 .frame.srt 文件
 			if _, err := os.Stat(normalSrtPath); err == nil {
 				if err := os.Remove(frameSrtPath); err == nil {
-					logger.Info().
+					log.Info().
 						Str("frame_srt_path", frameSrtPath).
 						Msg("已清理旧的 .frame.srt 文件")
 				}
@@ -1440,7 +1471,8 @@ func (d *downloader) cleanupFrameSrtFiles(videoDir string) {
 }
 
 // stripResolutionFromSubtitleFilenames 去除字幕文件名中的分辨率标识（例如: id_1080p.en.srt -> id.en.srt）
-func (d *downloader) stripResolutionFromSubtitleFilenames(videoDir string, subtitlePaths []string) []string {
+func (d *downloader) stripResolutionFromSubtitleFilenames(ctx context.Context, videoDir string, subtitlePaths []string) []string {
+	log := logger.Ctx(ctx)
 	newPaths := make([]string, 0, len(subtitlePaths))
 	re := regexp.MustCompile(`_(\d{3,5})p(\.[A-Za-z-]+\.(srt|vtt))$`)
 	for _, p := range subtitlePaths {
@@ -1455,14 +1487,14 @@ func (d *downloader) stripResolutionFromSubtitleFilenames(videoDir string, subti
 			}
 			// 重命名文件
 			if err := os.Rename(p, newPath); err != nil {
-				logger.Warn().
+				log.Warn().
 					Str("old_path", p).
 					Str("new_path", newPath).
 					Err(err).
 					Msg("重命名字幕文件去除分辨率标识失败，保留原文件名")
 				newPaths = append(newPaths, p)
 			} else {
-				logger.Info().
+				log.Info().
 					Str("old_path", p).
 					Str("new_path", newPath).
 					Msg("已去除字幕文件名中的分辨率标识")
@@ -1479,11 +1511,12 @@ func (d *downloader) stripResolutionFromSubtitleFilenames(videoDir string, subti
 // 如果检测到文件大小还在变化，说明还在下载中，需要等待更长时间
 // 对于大文件，合并时间可能较长，因此增加重试次数和等待时间
 // 如果文件大小长时间无变化（超过30秒），且已重试6次，返回 ErrFileStuck 以便重新下载
-func (d *downloader) findVideoFileWithRetry(videoDir, videoID string) (string, error) {
-	const maxRetries = 12                   // 最大重试次数，大文件合并可能需要更长时间
-	const baseRetryDelay = 15 * time.Second // 基础等待时间
-	const maxRetryDelay = 60 * time.Second  // 最大等待时间（随重试次数递增）
-	const noChangeTimeout = 2 * time.Minute // 文件大小无变化的超时时间（2分钟无变化直接返回错误）
+func (d *downloader) findVideoFileWithRetry(ctx context.Context, videoDir, videoID string) (string, error) {
+	log := logger.Ctx(ctx)
+	const maxRetries = 12                  // 最大重试次数，大文件合并可能需要更长时间
+	baseRetryDelay := partFileRetryDelay   // 基础等待时间
+	maxRetryDelay := partFileMaxRetryDelay // 最大等待时间（随重试次数递增）
+	noChangeTimeout := stuckTimeout        // 文件大小无变化的超时时间（默认2分钟无变化直接返回错误）
 
 	var lastPartFileSize int64 = -1
 	var lastPartFileTime time.Time
@@ -1560,7 +1593,7 @@ func (d *downloader) findVideoFileWithRetry(videoDir, videoID string) (string, e
 				noChangeDuration := time.Since(lastPartFileTime)
 				if noChangeDuration > noChangeTimeout {
 					// 总大小超过2分钟没有变化，直接返回错误，进入重新下载逻辑
-					logger.Error().
+					log.Error().
 						Str("video_dir", videoDir).
 						Str("video_id", videoID).
 						Int64("total_size", currentTotalSize).
@@ -1572,7 +1605,7 @@ func (d *downloader) findVideoFileWithRetry(videoDir, videoID string) (string, e
 			} else {
 				// 总大小有变化，说明还在下载中，重置无变化计时
 				if lastPartFileSize >= 0 {
-					logger.Info().
+					log.Info().
 						Str("video_dir", videoDir).
 						Str("video_id", videoID).
 						Int64("old_total_size", lastPartFileSize).
@@ -1594,7 +1627,7 @@ func (d *downloader) findVideoFileWithRetry(videoDir, videoID string) (string, e
 				currentDelay = maxRetryDelay
 			}
 
-			logger.Info().
+			log.Info().
 				Str("video_dir", videoDir).
 				Str("video_id", videoID).
 				Int("retry", i+1).
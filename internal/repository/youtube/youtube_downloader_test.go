@@ -0,0 +1,306 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/internal/repository/youtube/ytdlptest"
+)
+
+const testChannelID = "testchannel"
+
+func TestMain(m *testing.M) {
+	// 由下载器启动的替身 yt-dlp 进程在这里执行后退出
+	ytdlptest.Main()
+
+	// 缩短策略切换、卡住检测与 .part 等待时间，让每次卡住在一秒左右被发现
+	strategySwitchDelay = 10 * time.Millisecond
+	progressCheckInterval = 50 * time.Millisecond
+	stuckTimeout = 400 * time.Millisecond
+	partFileRetryDelay = 50 * time.Millisecond
+	partFileMaxRetryDelay = 100 * time.Millisecond
+	os.Exit(m.Run())
+}
+
+// newTestDownloader 使用替身 yt-dlp 创建下载器：带 cookies 文件、PATH 中没有 ffmpeg（字幕以 VTT 下载后由 Go 转换）
+func newTestDownloader(t *testing.T, videos ...ytdlptest.Video) (*downloader, *ytdlptest.Fake, file.Repository) {
+	t.Helper()
+	fake := ytdlptest.New(t, ytdlptest.Fixture{Videos: videos})
+	t.Setenv("PATH", t.TempDir())
+
+	dir := t.TempDir()
+	cookiesFile := filepath.Join(dir, "cookies.txt")
+	if err := os.WriteFile(cookiesFile, []byte("# Netscape HTTP Cookie File\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outputDir := filepath.Join(dir, "downloads")
+	configPath := filepath.Join(dir, "config.yaml")
+	content := fmt.Sprintf(`output:
+  directory: %q
+youtube:
+  ytdlp_path: %q
+  cookies_file: %q
+`, outputDir, fake.Path, cookiesFile)
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Load(configPath); err != nil {
+		t.Fatalf("加载测试配置失败: %v", err)
+	}
+
+	repo := file.NewRepository(outputDir)
+	return NewDownloader(repo, "", cookiesFile).(*downloader), fake, repo
+}
+
+func download(t *testing.T, d *downloader, id, title string) (*DownloadResult, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	return d.DownloadVideo(ctx, testChannelID, "https://www.youtube.com/watch?v="+id, []string{"en", "zh-Hans"}, title)
+}
+
+func assertHas1080p(t *testing.T, repo file.Repository, videoDir string, want bool) {
+	t.Helper()
+	status, err := repo.LoadDownloadStatus(videoDir)
+	if err != nil {
+		t.Fatalf("读取下载状态失败: %v", err)
+	}
+	if status.Video.Has1080p == nil || *status.Video.Has1080p != want {
+		t.Fatalf("has_1080p = %v, want %v", status.Video.Has1080p, want)
+	}
+}
+
+func assertFileExists(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("文件不存在: %v", err)
+	}
+}
+
+func TestDownloadVideoSuccess(t *testing.T) {
+	d, fake, repo := newTestDownloader(t, ytdlptest.Video{
+		ID: "vid00000001", Title: "Hello World", Subtitles: []string{"en", "zh-Hans", "fr"},
+	})
+
+	result, err := download(t, d, "vid00000001", "Hello World")
+	if err != nil {
+		t.Fatalf("DownloadVideo: %v", err)
+	}
+	videoDir := filepath.Dir(result.VideoPath)
+	if filepath.Base(result.VideoPath) != "vid00000001_1080p.mp4" {
+		t.Fatalf("video path = %s", result.VideoPath)
+	}
+	// VTT 已转换为 SRT 并去掉分辨率后缀，同时复制为 {title}[{id}].{lang}.srt
+	for _, name := range []string{
+		"vid00000001.en.srt", "vid00000001.zh-Hans.srt",
+		"Hello World[vid00000001].en.srt", "vid00000001_1080p.jpg",
+	} {
+		assertFileExists(t, filepath.Join(videoDir, name))
+	}
+	if _, err := os.Stat(filepath.Join(videoDir, "vid00000001.fr.srt")); err == nil {
+		t.Fatal("未请求的字幕语言不应下载")
+	}
+	assertHas1080p(t, repo, videoDir, true)
+
+	downloads := fake.Invocations(ytdlptest.ActionDownload)
+	if len(downloads) != 1 {
+		t.Fatalf("download invocations = %d, want 1", len(downloads))
+	}
+	inv := downloads[0]
+	if inv.Value("--cookies") == "" {
+		t.Fatal("第一种策略应带 cookies")
+	}
+	if inv.Has("--convert-subs") {
+		t.Fatal("没有 ffmpeg 时不应传 --convert-subs")
+	}
+	if got := inv.Value("--sub-langs"); got != "en,zh-Hans" {
+		t.Fatalf("--sub-langs = %q", got)
+	}
+}
+
+func TestDownloadVideoHTTP403TriesAllStrategies(t *testing.T) {
+	d, fake, _ := newTestDownloader(t, ytdlptest.Video{
+		ID: "vid00000403", Title: "Forbidden", Scenario: ytdlptest.ScenarioHTTP403,
+	})
+
+	_, err := download(t, d, "vid00000403", "Forbidden")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if errors.Is(err, ErrBotDetection) {
+		t.Fatalf("403 不应识别为 bot detection: %v", err)
+	}
+	if !strings.Contains(err.Error(), "HTTP Error 403") {
+		t.Fatalf("错误中应包含 yt-dlp 输出: %v", err)
+	}
+
+	// 默认关闭 android 回退：先带 cookies，再不带 cookies
+	downloads := fake.Invocations(ytdlptest.ActionDownload)
+	if len(downloads) != 2 {
+		t.Fatalf("download invocations = %d, want 2", len(downloads))
+	}
+	if !downloads[0].Has("--cookies") || downloads[1].Has("--cookies") {
+		t.Fatalf("策略顺序错误: %v / %v", downloads[0].Args, downloads[1].Args)
+	}
+}
+
+func TestDownloadVideoBotCheck(t *testing.T) {
+	d, fake, _ := newTestDownloader(t, ytdlptest.Video{
+		ID: "vid00000bot", Title: "Bot", Scenario: ytdlptest.ScenarioBotCheck,
+	})
+
+	_, err := download(t, d, "vid00000bot", "Bot")
+	if !errors.Is(err, ErrBotDetection) {
+		t.Fatalf("err = %v, want ErrBotDetection", err)
+	}
+	if n := len(fake.Invocations(ytdlptest.ActionDownload)); n != 2 {
+		t.Fatalf("download invocations = %d, want 2", n)
+	}
+}
+
+func TestDownloadVideoBotCheckRecoversWithoutCookies(t *testing.T) {
+	d, fake, _ := newTestDownloader(t, ytdlptest.Video{
+		ID: "vid0000bot1", Title: "Bot once", Scenario: ytdlptest.ScenarioBotCheck, FailAttempts: 1,
+	})
+
+	result, err := download(t, d, "vid0000bot1", "Bot once")
+	if err != nil {
+		t.Fatalf("DownloadVideo: %v", err)
+	}
+	assertFileExists(t, result.VideoPath)
+	downloads := fake.Invocations(ytdlptest.ActionDownload)
+	if len(downloads) != 2 || downloads[1].Has("--cookies") {
+		t.Fatalf("应在不带 cookies 的策略成功: %d invocations", len(downloads))
+	}
+}
+
+func TestDownloadVideoStuckIsKilledAndNextStrategySucceeds(t *testing.T) {
+	d, fake, _ := newTestDownloader(t, ytdlptest.Video{
+		ID: "vid000stuck", Title: "Stuck once", Scenario: ytdlptest.ScenarioStuck, FailAttempts: 1,
+	})
+
+	result, err := download(t, d, "vid000stuck", "Stuck once")
+	if err != nil {
+		t.Fatalf("DownloadVideo: %v", err)
+	}
+	assertFileExists(t, result.VideoPath)
+	if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(result.VideoPath), "*.part")); len(matches) != 0 {
+		t.Fatalf("残留 .part 文件: %v", matches)
+	}
+	if n := len(fake.Invocations(ytdlptest.ActionDownload)); n != 2 {
+		t.Fatalf("download invocations = %d, want 2", n)
+	}
+}
+
+func TestDownloadVideoAlwaysStuckReturnsErrFileStuck(t *testing.T) {
+	d, fake, _ := newTestDownloader(t, ytdlptest.Video{
+		ID: "vid00stuck2", Title: "Stuck", Scenario: ytdlptest.ScenarioStuck,
+	})
+
+	_, err := download(t, d, "vid00stuck2", "Stuck")
+	if !errors.Is(err, ErrFileStuck) {
+		t.Fatalf("err = %v, want ErrFileStuck", err)
+	}
+	// 每轮两种策略都被终止，共重试 5 轮
+	if n := len(fake.Invocations(ytdlptest.ActionDownload)); n != 10 {
+		t.Fatalf("download invocations = %d, want 10", n)
+	}
+}
+
+func TestDownloadVideoSlowProgressIsNotKilled(t *testing.T) {
+	d, fake, _ := newTestDownloader(t, ytdlptest.Video{
+		ID: "vid0000slow", Title: "Slow", Scenario: ytdlptest.ScenarioSlow, SlowDuration: 2 * time.Second,
+	})
+
+	result, err := download(t, d, "vid0000slow", "Slow")
+	if err != nil {
+		t.Fatalf("DownloadVideo: %v", err)
+	}
+	assertFileExists(t, result.VideoPath)
+	// 总时长超过 stuckTimeout，但文件一直在增长，不应被当作卡住
+	if n := len(fake.Invocations(ytdlptest.ActionDownload)); n != 1 {
+		t.Fatalf("download invocations = %d, want 1", n)
+	}
+}
+
+func TestDownloadVideoNo1080p(t *testing.T) {
+	d, _, repo := newTestDownloader(t, ytdlptest.Video{
+		ID: "vid00000720", Title: "Low res", Scenario: ytdlptest.ScenarioNo1080p,
+	})
+
+	result, err := download(t, d, "vid00000720", "Low res")
+	if err != nil {
+		t.Fatalf("DownloadVideo: %v", err)
+	}
+	if filepath.Base(result.VideoPath) != "vid00000720_720p.mp4" {
+		t.Fatalf("video path = %s", result.VideoPath)
+	}
+	videoDir := filepath.Dir(result.VideoPath)
+	assertFileExists(t, filepath.Join(videoDir, "vid00000720.en.srt"))
+	assertHas1080p(t, repo, videoDir, false)
+}
+
+func TestDownloadVideoVTTOnlyIsConvertedToSRT(t *testing.T) {
+	d, _, _ := newTestDownloader(t, ytdlptest.Video{
+		ID: "vid00000vtt", Title: "VTT", Scenario: ytdlptest.ScenarioVTTOnly, Subtitles: []string{"en", "zh-Hans"},
+	})
+
+	result, err := download(t, d, "vid00000vtt", "VTT")
+	if err != nil {
+		t.Fatalf("DownloadVideo: %v", err)
+	}
+	for _, lang := range []string{"en", "zh-Hans"} {
+		var srt string
+		for _, p := range result.SubtitlePaths {
+			if strings.HasSuffix(p, "."+lang+".srt") {
+				srt = p
+			}
+		}
+		if srt == "" {
+			t.Fatalf("%s 字幕未转换为 SRT: %v", lang, result.SubtitlePaths)
+		}
+		data, err := os.ReadFile(srt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "00:00:01,000 --> 00:00:02,500") {
+			t.Fatalf("SRT 时间轴格式错误:\n%s", data)
+		}
+	}
+}
+
+func TestChoosePlayerClientFallsBackToWeb(t *testing.T) {
+	d, fake, _ := newTestDownloader(t, ytdlptest.Video{
+		ID: "vid00000web", Title: "Web only", Clients: []string{"web"},
+	})
+
+	client, output, err := d.choosePlayerClient(context.Background(), "https://www.youtube.com/watch?v=vid00000web")
+	if err != nil {
+		t.Fatalf("choosePlayerClient: %v", err)
+	}
+	if client != "web" || !strings.Contains(output, "Available formats") {
+		t.Fatalf("client = %q, output = %q", client, output)
+	}
+	probes := fake.Invocations(ytdlptest.ActionListFormats)
+	if len(probes) != 2 || probes[0].Value("--extractor-args") != "youtube:player_client=android" {
+		t.Fatalf("应先探测 android 再探测 web: %+v", probes)
+	}
+}
+
+func TestChoosePlayerClientNoUsableFormats(t *testing.T) {
+	d, _, _ := newTestDownloader(t, ytdlptest.Video{
+		ID: "vid000nofmt", Title: "No formats", Clients: []string{"ios"},
+	})
+
+	if _, _, err := d.choosePlayerClient(context.Background(), "https://www.youtube.com/watch?v=vid000nofmt"); err == nil {
+		t.Fatal("expected error")
+	}
+}
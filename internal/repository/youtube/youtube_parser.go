@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+
+	"blueberry/internal/config"
+	"blueberry/pkg/utils"
 )
 
 type Parser interface {
@@ -101,7 +104,7 @@ func (p *parser) ExtractVideosFromChannel(ctx context.Context, channelURL string
 
 	args = append(args, channelURL)
 
-	cmd := exec.CommandContext(ctx, "yt-dlp", args...)
+	cmd := utils.CommandContext(ctx, YtDlpPath(config.Get()), args...)
 
 	// 使用 CombinedOutput 以便在错误时拿到 stderr，方便排查网络/登录问题
 	output, err := cmd.CombinedOutput()
@@ -208,9 +211,9 @@ func (p *parser) ExtractVideosFromChannel(ctx context.Context, channelURL string
 }
 
 func (p *parser) CheckInstalled() error {
-	_, err := exec.LookPath("yt-dlp")
-	if err != nil {
-		return fmt.Errorf("yt-dlp未安装，请先安装yt-dlp: https://github.com/yt-dlp/yt-dlp")
+	path := YtDlpPath(config.Get())
+	if _, err := exec.LookPath(path); err != nil {
+		return fmt.Errorf("yt-dlp未安装（%s），请先安装yt-dlp或配置 youtube.ytdlp_path: https://github.com/yt-dlp/yt-dlp", path)
 	}
 	return nil
 }
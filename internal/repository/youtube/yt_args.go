@@ -10,6 +10,17 @@ import (
 	"blueberry/internal/config"
 )
 
+// YtDlpPath 返回 yt-dlp 可执行文件路径（youtube.ytdlp_path），未配置时使用 PATH 中的 yt-dlp
+func YtDlpPath(cfg *config.Config) string {
+	if cfg == nil {
+		cfg = config.Get()
+	}
+	if cfg != nil && cfg.YouTube.YtDlpPath != "" {
+		return cfg.YouTube.YtDlpPath
+	}
+	return "yt-dlp"
+}
+
 // BuildYtDlpStabilityArgs builds retry/fragment/sleep/concurrency args for yt-dlp from config.
 // Centralized here to avoid scattering the same flags across different code paths.
 func BuildYtDlpStabilityArgs(cfg *config.Config) []string {
@@ -81,11 +92,54 @@ func BuildYtDlpStabilityArgs(cfg *config.Config) []string {
 	)
 	// 添加限速参数（如果配置了）
 	if cfg != nil && cfg.YouTube.LimitRate != "" {
-		args = append(args, "--limit-rate", cfg.YouTube.LimitRate)
+		args = append(args, "--limit-rate", splitLimitRate(cfg.YouTube.LimitRate, videoConcurrency(cfg)))
 	}
 	return args
 }
 
+// videoConcurrency 同时运行的视频抓取数量（video_download_concurrency，未配置时为 download_workers）
+func videoConcurrency(cfg *config.Config) int {
+	if cfg.YouTube.VideoDownloadConcurrency > 0 {
+		return cfg.YouTube.VideoDownloadConcurrency
+	}
+	if cfg.YouTube.DownloadWorkers > 1 {
+		return cfg.YouTube.DownloadWorkers
+	}
+	return 1
+}
+
+// splitLimitRate 将总限速（如 "4M"、"500K"、"1.5M"）均分给 n 个并发的 yt-dlp 进程
+// 无法解析时原样返回，交给 yt-dlp 处理
+func splitLimitRate(rate string, n int) string {
+	if n <= 1 {
+		return rate
+	}
+	value := strings.TrimSpace(rate)
+	multiplier := 1.0
+	if value != "" {
+		switch strings.ToUpper(value[len(value)-1:]) {
+		case "K":
+			multiplier = 1 << 10
+		case "M":
+			multiplier = 1 << 20
+		case "G":
+			multiplier = 1 << 30
+		}
+		if multiplier != 1 {
+			value = value[:len(value)-1]
+		}
+	}
+	bytesPerSecond, err := strconv.ParseFloat(value, 64)
+	if err != nil || bytesPerSecond <= 0 {
+		return rate
+	}
+	perProcess := int64(bytesPerSecond * multiplier / float64(n) / 1024)
+	if perProcess < 1 {
+		perProcess = 1
+	}
+	return strconv.FormatInt(perProcess, 10) + "K"
+}
+
 // BuildYtDlpBaseArgs builds common, non-dynamic args (output template, ipv6, thumbnails, info json, description).
 func BuildYtDlpBaseArgs(videoDir string, cfg *config.Config) []string {
 	if cfg == nil {
@@ -0,0 +1,356 @@
+package ytdlptest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 替身程序输出的视频内容与 .part 文件每次增长的字节数
+const (
+	videoSize = 64 * 1024
+	partChunk = 4 * 1024
+)
+
+const vttContent = `WEBVTT
+Kind: captions
+Language: %[1]s
+
+00:00:01.000 --> 00:00:02.500
+[%[1]s] Hello world
+
+00:00:03.000 --> 00:00:04.000
+[%[1]s] Second line
+`
+
+const srtContent = `1
+00:00:01,000 --> 00:00:02,500
+[%[1]s] Hello world
+
+2
+00:00:03,000 --> 00:00:04,000
+[%[1]s] Second line
+`
+
+// process 替身程序的一次执行
+type process struct {
+	fixture Fixture
+	args    []string
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+// run 按 yt-dlp 的参数执行夹具中的场景，返回退出码
+func run(fixturePath, logPath string, args []string) int {
+	data, err := os.ReadFile(fixturePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: 读取夹具失败: %v\n", err)
+		return 2
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: 解析夹具失败: %v\n", err)
+		return 2
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "ERROR: You must provide at least one URL.")
+		return 2
+	}
+
+	p := &process{fixture: fixture, args: args, stdout: os.Stdout, stderr: os.Stderr}
+	url := args[len(args)-1]
+	inv := Invocation{Args: args, Action: classify(args), VideoID: videoIDFromURL(url)}
+	// 下载调用的失败次数依赖之前的调用记录，先读再追加
+	previous, _ := readInvocations(logPath)
+	if err := appendInvocation(logPath, inv); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: 写入调用记录失败: %v\n", err)
+		return 2
+	}
+
+	if inv.Action == ActionFlatPlaylist {
+		return p.flatPlaylist(url)
+	}
+	video, ok := p.video(inv.VideoID)
+	if !ok {
+		fmt.Fprintf(p.stderr, "ERROR: [youtube] %s: Video unavailable. This video is not available\n", inv.VideoID)
+		return 1
+	}
+	if video.Scenario == ScenarioBotCheck && !p.recovered(video, previous) {
+		return p.botCheck(video)
+	}
+
+	switch inv.Action {
+	case ActionListFormats:
+		return p.listFormats(video)
+	case ActionDumpJSON:
+		return p.dumpJSON(video)
+	case ActionSubtitles:
+		return p.subtitles(video)
+	default:
+		return p.download(video, p.recovered(video, previous))
+	}
+}
+
+// video 返回夹具中的视频，并补齐默认值
+func (p *process) video(id string) (Video, bool) {
+	for _, v := range p.fixture.Videos {
+		if v.ID != id {
+			continue
+		}
+		if v.Scenario == "" {
+			v.Scenario = ScenarioSuccess
+		}
+		if v.Height == 0 {
+			v.Height = 1080
+			if v.Scenario == ScenarioNo1080p {
+				v.Height = 720
+			}
+		}
+		if v.Subtitles == nil {
+			v.Subtitles = []string{"en"}
+		}
+		return v, true
+	}
+	return Video{}, false
+}
+
+// recovered 返回失败场景是否已用完 FailAttempts 次下载调用（之后按成功处理）
+func (p *process) recovered(video Video, previous []Invocation) bool {
+	if video.FailAttempts <= 0 {
+		return false
+	}
+	attempts := 0
+	for _, inv := range previous {
+		if inv.Action == ActionDownload && inv.VideoID == video.ID {
+			attempts++
+		}
+	}
+	return attempts >= video.FailAttempts
+}
+
+func (p *process) flatPlaylist(url string) int {
+	ids, ok := p.fixture.Channels[url]
+	if !ok {
+		fmt.Fprintf(p.stderr, "ERROR: [youtube:tab] %s: This channel does not exist.\n", url)
+		return 1
+	}
+	enc := json.NewEncoder(p.stdout)
+	for _, id := range ids {
+		video, ok := p.video(id)
+		if !ok {
+			continue
+		}
+		entry := map[string]any{
+			"_type":       "url",
+			"ie_key":      "Youtube",
+			"id":          video.ID,
+			"title":       video.Title,
+			"url":         "https://www.youtube.com/watch?v=" + video.ID,
+			"description": video.Description,
+		}
+		if err := enc.Encode(entry); err != nil {
+			return 1
+		}
+	}
+	return 0
+}
+
+func (p *process) botCheck(video Video) int {
+	fmt.Fprintf(p.stderr, "ERROR: [youtube] %s: Sign in to confirm you're not a bot. "+
+		"Use --cookies-from-browser or --cookies for the authentication. "+
+		"See  https://github.com/yt-dlp/yt-dlp/wiki/FAQ#how-do-i-pass-cookies-to-yt-dlp  for how to manually pass cookies. "+
+		"Also see  https://github.com/yt-dlp/yt-dlp/wiki/Extractors#exporting-youtube-cookies  for tips on effectively exporting YouTube cookies\n", video.ID)
+	return 1
+}
+
+func (p *process) listFormats(video Video) int {
+	client := strings.TrimPrefix(flagValue(p.args, "--extractor-args"), "youtube:player_client=")
+	fmt.Fprintf(p.stdout, "[youtube] Extracting URL: https://www.youtube.com/watch?v=%s\n", video.ID)
+	if len(video.Clients) > 0 && !slices.Contains(video.Clients, client) {
+		fmt.Fprintf(p.stderr, "WARNING: [youtube] %s: %s client did not return any formats\n", video.ID, client)
+		fmt.Fprintf(p.stdout, "[info] Available formats for %s:\nID EXT RESOLUTION\n", video.ID)
+		return 0
+	}
+	fmt.Fprintf(p.stdout, "[info] Available formats for %s:\n", video.ID)
+	fmt.Fprintln(p.stdout, "ID  EXT  RESOLUTION FPS | PROTO")
+	fmt.Fprintln(p.stdout, "140 m4a  audio only     | https")
+	fmt.Fprintf(p.stdout, "137 mp4  %dx%d  30  | https\n", video.Height*16/9, video.Height)
+	return 0
+}
+
+// info 视频信息（--dump-json 与 --write-info-json 的内容）
+func (p *process) info(video Video) map[string]any {
+	subtitles := make(map[string]any, len(video.Subtitles))
+	for _, lang := range video.Subtitles {
+		subtitles[lang] = []map[string]string{{"ext": "vtt", "name": lang}}
+	}
+	return map[string]any{
+		"id":          video.ID,
+		"title":       video.Title,
+		"description": video.Description,
+		"webpage_url": "https://www.youtube.com/watch?v=" + video.ID,
+		"height":      video.Height,
+		"ext":         "mp4",
+		"subtitles":   subtitles,
+	}
+}
+
+func (p *process) dumpJSON(video Video) int {
+	if err := json.NewEncoder(p.stdout).Encode(p.info(video)); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// outputPath 按 -o 模板生成文件路径，ext 可以是 "mp4"、"jpg"、"en.srt" 等
+func (p *process) outputPath(video Video, ext string) string {
+	tmpl := flagValue(p.args, "-o")
+	if tmpl == "" {
+		tmpl = "%(title)s [%(id)s].%(ext)s"
+	}
+	r := strings.NewReplacer(
+		"%(id)s", video.ID,
+		"%(title)s", video.Title,
+		"%(height)s", strconv.Itoa(video.Height),
+		"%(ext)s", ext,
+	)
+	path := r.Replace(tmpl)
+	// yt-dlp 会自动创建输出目录
+	_ = os.MkdirAll(filepath.Dir(path), 0755)
+	return path
+}
+
+// requestedSubtitles 返回 --sub-langs 请求且视频实际拥有的字幕语言
+func (p *process) requestedSubtitles(video Video) []string {
+	if !p.has("--write-sub") && !p.has("--write-auto-sub") {
+		return nil
+	}
+	langs := flagValue(p.args, "--sub-langs")
+	if langs == "" || langs == "all" {
+		return video.Subtitles
+	}
+	var result []string
+	for _, lang := range strings.Split(langs, ",") {
+		if slices.Contains(video.Subtitles, lang) {
+			result = append(result, lang)
+		}
+	}
+	return result
+}
+
+// writeSubtitles 写出字幕；传入 --convert-subs srt 时写 SRT，否则写 VTT（ScenarioVTTOnly 始终写 VTT）
+func (p *process) writeSubtitles(video Video) error {
+	format := "vtt"
+	if flagValue(p.args, "--convert-subs") == "srt" && video.Scenario != ScenarioVTTOnly {
+		format = "srt"
+	}
+	for _, lang := range p.requestedSubtitles(video) {
+		content := vttContent
+		if format == "srt" {
+			content = srtContent
+		}
+		path := p.outputPath(video, lang+"."+format)
+		if err := os.WriteFile(path, []byte(fmt.Sprintf(content, lang)), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(p.stdout, "[info] Writing video subtitles to: %s\n", path)
+	}
+	return nil
+}
+
+func (p *process) subtitles(video Video) int {
+	fmt.Fprintf(p.stdout, "[youtube] Extracting URL: https://www.youtube.com/watch?v=%s\n", video.ID)
+	if err := p.writeSubtitles(video); err != nil {
+		fmt.Fprintf(p.stderr, "ERROR: Unable to write subtitles: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// download 下载视频；recovered 为 true 时失败场景已用完 FailAttempts，按成功处理
+func (p *process) download(video Video, recovered bool) int {
+	fmt.Fprintf(p.stdout, "[youtube] Extracting URL: https://www.youtube.com/watch?v=%s\n", video.ID)
+	fmt.Fprintf(p.stdout, "[info] %s: Downloading 1 format(s): %dp\n", video.ID, video.Height)
+
+	videoPath := p.outputPath(video, "mp4")
+	partPath := videoPath + ".part"
+	if !recovered {
+		switch video.Scenario {
+		case ScenarioHTTP403:
+			fmt.Fprintln(p.stderr, "ERROR: unable to download video data: HTTP Error 403: Forbidden")
+			return 1
+		case ScenarioStuck:
+			if err := os.WriteFile(partPath, make([]byte, partChunk), 0644); err != nil {
+				return 1
+			}
+			fmt.Fprintf(p.stdout, "[download] Destination: %s\n", videoPath)
+			fmt.Fprintf(p.stdout, "[download]   6.3%% of 64.00KiB at 1.00KiB/s ETA 01:00\n")
+			// 不再有任何进展，直到被下载器终止
+			time.Sleep(time.Hour)
+			return 1
+		case ScenarioSlow:
+			if err := p.growPart(partPath, video.SlowDuration); err != nil {
+				return 1
+			}
+		}
+	}
+
+	if p.has("--write-info-json") {
+		data, _ := json.Marshal(p.info(video))
+		if err := os.WriteFile(p.outputPath(video, "info.json"), data, 0644); err != nil {
+			return 1
+		}
+	}
+	if p.has("--write-description") {
+		if err := os.WriteFile(p.outputPath(video, "description"), []byte(video.Description), 0644); err != nil {
+			return 1
+		}
+	}
+	if p.has("--write-thumbnail") {
+		// 内容不是真正的图片，只需非空
+		if err := os.WriteFile(p.outputPath(video, "jpg"), []byte("\xff\xd8\xff\xe0fake-jpeg"), 0644); err != nil {
+			return 1
+		}
+	}
+	if err := p.writeSubtitles(video); err != nil {
+		fmt.Fprintf(p.stderr, "ERROR: Unable to write subtitles: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(p.stdout, "[download] Destination: %s\n", videoPath)
+	if err := os.WriteFile(videoPath, make([]byte, videoSize), 0644); err != nil {
+		return 1
+	}
+	_ = os.Remove(partPath)
+	fmt.Fprintln(p.stdout, "[download] 100% of 64.00KiB in 00:00:01 at 64.00KiB/s")
+	return 0
+}
+
+// growPart 在 duration 内持续向 .part 文件追加数据
+func (p *process) growPart(partPath string, duration time.Duration) error {
+	f, err := os.OpenFile(partPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fmt.Fprintf(p.stdout, "[download] Destination: %s\n", strings.TrimSuffix(partPath, ".part"))
+	const steps = 20
+	for i := 1; i <= steps; i++ {
+		if _, err := f.Write(make([]byte, partChunk)); err != nil {
+			return err
+		}
+		fmt.Fprintf(p.stdout, "[download] %5.1f%% of 64.00KiB\n", float64(i)*100/(steps+1))
+		time.Sleep(duration / steps)
+	}
+	return nil
+}
+
+func (p *process) has(flag string) bool {
+	return slices.Contains(p.args, flag)
+}
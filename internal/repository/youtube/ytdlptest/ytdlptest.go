@@ -0,0 +1,228 @@
+// Package ytdlptest 提供用 Go 编写的 yt-dlp 替身程序，用于不访问 YouTube 的下载集成测试。
+//
+// 替身程序就是测试二进制本身：测试包在 TestMain 中调用 Main，当环境变量指向夹具文件时，
+// 进程按 yt-dlp 的命令行参数执行夹具中的场景（成功、403、bot 检测、卡住、缓慢下载、无 1080p、仅 VTT 字幕）后退出；
+// 测试把 youtube.ytdlp_path 配置为 Fake.Path 即可让下载器、解析器与字幕补充调用替身程序。
+// 每次调用都会追加记录到调用日志，便于断言下载策略（是否带 cookies、player_client 等）。
+package ytdlptest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 替身程序通过环境变量获得夹具与调用日志路径（子进程继承测试进程的环境变量）
+const (
+	fixtureEnv = "BLUEBERRY_FAKE_YTDLP_FIXTURE"
+	logEnv     = "BLUEBERRY_FAKE_YTDLP_LOG"
+)
+
+// Scenario 视频下载时替身程序模拟的场景
+type Scenario string
+
+const (
+	ScenarioSuccess  Scenario = "success"   // 正常下载视频、缩略图、信息与字幕
+	ScenarioHTTP403  Scenario = "http_403"  // 下载视频数据时 HTTP 403，退出码 1
+	ScenarioBotCheck Scenario = "bot_check" // 所有请求都要求登录确认不是机器人，退出码 1
+	ScenarioStuck    Scenario = "stuck"     // 写出 .part 文件后不再有任何进展，直到被终止
+	ScenarioSlow     Scenario = "slow"      // .part 文件在 SlowDuration 内持续增长后完成下载
+	ScenarioNo1080p  Scenario = "no_1080p"  // 只有低于 1080p 的格式（默认 720p）
+	ScenarioVTTOnly  Scenario = "vtt_only"  // 字幕只有 VTT，即使要求 --convert-subs srt 也不转换
+)
+
+// Action 替身程序根据参数判断出的调用类型
+type Action string
+
+const (
+	ActionFlatPlaylist Action = "flat_playlist" // --flat-playlist --dump-json 解析频道
+	ActionDumpJSON     Action = "dump_json"     // --dump-json 获取视频信息
+	ActionListFormats  Action = "list_formats"  // --list-formats 探测 player_client
+	ActionSubtitles    Action = "subtitles"     // --skip-download 只下载字幕
+	ActionDownload     Action = "download"      // 下载视频
+)
+
+// Video 夹具中的一个视频
+type Video struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Scenario    Scenario `json:"scenario,omitempty"` // 为空时等同于 ScenarioSuccess
+	Height      int      `json:"height,omitempty"`   // 视频高度，默认 1080（ScenarioNo1080p 默认 720）
+	Subtitles   []string `json:"subtitles,omitempty"`
+	// Clients 有可用格式的 player_client，为空表示全部可用（影响 --list-formats）
+	Clients []string `json:"clients,omitempty"`
+	// FailAttempts 前几次下载调用按 Scenario 失败，之后按成功处理；0 表示每次都按 Scenario 执行
+	FailAttempts int `json:"fail_attempts,omitempty"`
+	// SlowDuration ScenarioSlow 下 .part 文件持续增长的时长
+	SlowDuration time.Duration `json:"slow_duration,omitempty"`
+}
+
+// Fixture 替身程序的夹具：频道 URL 到视频 ID 列表的映射与所有视频
+type Fixture struct {
+	Channels map[string][]string `json:"channels,omitempty"`
+	Videos   []Video             `json:"videos"`
+}
+
+// Invocation 替身程序的一次调用
+type Invocation struct {
+	Args    []string `json:"args"`
+	Action  Action   `json:"action"`
+	VideoID string   `json:"video_id,omitempty"`
+}
+
+// Has 返回调用参数中是否包含 flag
+func (inv Invocation) Has(flag string) bool {
+	return slices.Contains(inv.Args, flag)
+}
+
+// Value 返回调用参数中 flag 后的值，没有时返回空字符串
+func (inv Invocation) Value(flag string) string {
+	return flagValue(inv.Args, flag)
+}
+
+// Fake 已安装到当前测试的替身程序
+type Fake struct {
+	// Path 替身程序路径（即当前测试二进制），用作 youtube.ytdlp_path
+	Path    string
+	t       testing.TB
+	logPath string
+}
+
+// Main 在测试包的 TestMain 中、m.Run 之前调用；
+// 当前进程是由下载代码启动的替身程序时执行 yt-dlp 逻辑并退出，否则直接返回。
+func Main() {
+	fixturePath := os.Getenv(fixtureEnv)
+	if fixturePath == "" {
+		return
+	}
+	os.Exit(run(fixturePath, os.Getenv(logEnv), os.Args[1:]))
+}
+
+// New 写出夹具并为当前测试设置替身程序的环境变量（测试结束后自动恢复）
+func New(t testing.TB, fixture Fixture) *Fake {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("获取测试二进制路径失败: %v", err)
+	}
+	dir := t.TempDir()
+	data, err := json.Marshal(fixture)
+	if err != nil {
+		t.Fatalf("序列化夹具失败: %v", err)
+	}
+	fixturePath := filepath.Join(dir, "fixture.json")
+	if err := os.WriteFile(fixturePath, data, 0644); err != nil {
+		t.Fatalf("写入夹具失败: %v", err)
+	}
+	logPath := filepath.Join(dir, "invocations.jsonl")
+	t.Setenv(fixtureEnv, fixturePath)
+	t.Setenv(logEnv, logPath)
+	// -race 编译的替身程序默认在退出前休眠 1 秒，下载器的卡住检测会把这段时间当作无进展
+	t.Setenv("GORACE", "atexit_sleep_ms=0")
+	return &Fake{Path: exe, t: t, logPath: logPath}
+}
+
+// Invocations 按调用顺序返回替身程序的调用记录；actions 非空时只返回这些类型的调用
+func (f *Fake) Invocations(actions ...Action) []Invocation {
+	f.t.Helper()
+	invocations, err := readInvocations(f.logPath)
+	if err != nil {
+		f.t.Fatalf("读取替身程序调用记录失败: %v", err)
+	}
+	if len(actions) == 0 {
+		return invocations
+	}
+	filtered := make([]Invocation, 0, len(invocations))
+	for _, inv := range invocations {
+		if slices.Contains(actions, inv.Action) {
+			filtered = append(filtered, inv)
+		}
+	}
+	return filtered
+}
+
+func readInvocations(logPath string) ([]Invocation, error) {
+	f, err := os.Open(logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var invocations []Invocation
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var inv Invocation
+		if err := json.Unmarshal(scanner.Bytes(), &inv); err != nil {
+			return nil, fmt.Errorf("解析调用记录失败: %w", err)
+		}
+		invocations = append(invocations, inv)
+	}
+	return invocations, scanner.Err()
+}
+
+func appendInvocation(logPath string, inv Invocation) error {
+	if logPath == "" {
+		return nil
+	}
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// flagValue 返回 args 中 flag 后的参数值
+func flagValue(args []string, flag string) string {
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == flag {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// classify 根据参数判断调用类型
+func classify(args []string) Action {
+	switch {
+	case slices.Contains(args, "--flat-playlist"):
+		return ActionFlatPlaylist
+	case slices.Contains(args, "--list-formats"):
+		return ActionListFormats
+	case slices.Contains(args, "--dump-json"):
+		return ActionDumpJSON
+	case slices.Contains(args, "--skip-download"):
+		return ActionSubtitles
+	default:
+		return ActionDownload
+	}
+}
+
+// videoIDFromURL 从 watch?v= 链接中提取视频 ID
+func videoIDFromURL(url string) string {
+	idx := strings.Index(url, "v=")
+	if idx < 0 {
+		return ""
+	}
+	id := url[idx+2:]
+	if end := strings.IndexAny(id, "&#"); end >= 0 {
+		id = id[:end]
+	}
+	return id
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/bilibili"
+	"blueberry/internal/repository/file"
+)
+
+// 账号检查结果
+const (
+	AccountOK      = "ok"      // 可用
+	AccountWarning = "warning" // 可用，但 cookies 即将过期、今日额度已用尽等
+	AccountError   = "error"   // 不可用：cookies 缺失 / 已过期、缺少 CSRF、未登录、处于隔离期
+)
+
+// youtubeSessionCookies YouTube 登录态依赖的 cookie（存在其一即可）
+var youtubeSessionCookies = []string{"SID", "__Secure-1PSID", "__Secure-3PSID", "LOGIN_INFO"}
+
+// AccountCheckOptions 账号检查的范围
+type AccountCheckOptions struct {
+	// Accounts 只检查这些账号（bilibili_accounts 中的名称）；为空检查全部账号与 YouTube cookies
+	Accounts []string
+	// WarnWithin cookies 在该时长内过期时给出警告
+	WarnWithin time.Duration
+	// Offline 只检查 cookies 文件与额度，不请求 B站确认登录状态
+	Offline bool
+}
+
+// AccountHealth 单个账号（或 YouTube cookies）的检查结果
+type AccountHealth struct {
+	Name         string     `json:"name"`
+	Platform     string     `json:"platform"` // bilibili / youtube
+	CookiesFile  string     `json:"cookies_file,omitempty"`
+	Cookies      int        `json:"cookies"`
+	HasCSRF      bool       `json:"has_csrf"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"` // 关键 cookie 中最早的过期时间
+	LoggedIn     *bool      `json:"logged_in,omitempty"`  // 未检查时为空
+	UploadsToday int        `json:"uploads_today"`
+	DailyLimit   int        `json:"daily_limit,omitempty"`
+	// Quarantine 仍在有效期内的隔离记录（上传因 cookies 失效、风控或封禁失败后暂停使用）
+	Quarantine *file.AccountQuarantine `json:"quarantine,omitempty"`
+	Status     string                  `json:"status"`
+	Problems   []string                `json:"problems,omitempty"`
+}
+
+// Usable 账号是否可用于上传 / 下载
+func (h *AccountHealth) Usable() bool {
+	return h.Status != AccountError
+}
+
+// Remaining 今日剩余的上传额度
+func (h *AccountHealth) Remaining() int {
+	if remaining := h.DailyLimit - h.UploadsToday; remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+func (h *AccountHealth) fail(format string, args ...any) {
+	h.Status = AccountError
+	h.Problems = append(h.Problems, fmt.Sprintf(format, args...))
+}
+
+func (h *AccountHealth) warn(format string, args ...any) {
+	if h.Status == AccountOK {
+		h.Status = AccountWarning
+	}
+	h.Problems = append(h.Problems, fmt.Sprintf(format, args...))
+}
+
+// AccountService 检查账号 cookies 与登录状态
+type AccountService interface {
+	Check(ctx context.Context, opts AccountCheckOptions) ([]AccountHealth, error)
+}
+
+type accountService struct {
+	fileManager file.Repository
+	accounts    AccountSelector
+	cfg         *config.Config
+}
+
+// NewAccountService 创建 AccountService
+func NewAccountService(fileManager file.Repository, cfg *config.Config) AccountService {
+	return &accountService{fileManager: fileManager, accounts: NewAccountSelector(fileManager, cfg), cfg: cfg}
+}
+
+// Check 检查 bilibili_accounts 中的每个账号（cookies 文件、CSRF、关键 cookie 剩余有效期、登录状态与今日额度），
+// 未指定账号时同时检查 YouTube cookies 文件
+func (s *accountService) Check(ctx context.Context, opts AccountCheckOptions) ([]AccountHealth, error) {
+	names := opts.Accounts
+	if len(names) == 0 {
+		for name := range s.cfg.BilibiliAccounts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	counts, err := s.fileManager.LoadTodayUploadCounts()
+	if err != nil {
+		return nil, fmt.Errorf("读取今日上传计数失败: %w", err)
+	}
+	quarantines, err := s.accounts.Quarantines()
+	if err != nil {
+		return nil, fmt.Errorf("读取账号隔离状态失败: %w", err)
+	}
+
+	results := make([]AccountHealth, 0, len(names)+1)
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+		account, ok := s.cfg.BilibiliAccounts[name]
+		if !ok {
+			return results, fmt.Errorf("bilibili_accounts 中不存在账号 %s", name)
+		}
+		h := s.checkBilibili(ctx, name, account, counts[name], opts)
+		if q, ok := quarantines[name]; ok {
+			h.Quarantine = &q
+			h.fail("已隔离（%s）至 %s: %s", q.Kind, time.Unix(q.Until, 0).Format("2006-01-02 15:04"), q.Reason)
+		}
+		results = append(results, h)
+	}
+	if len(opts.Accounts) == 0 {
+		results = append(results, s.checkYouTube(opts))
+	}
+	return results, nil
+}
+
+func (s *accountService) checkBilibili(ctx context.Context, name string, account config.Account, uploadsToday int, opts AccountCheckOptions) AccountHealth {
+	h := AccountHealth{
+		Name:         name,
+		Platform:     "bilibili",
+		CookiesFile:  account.CookiesFile,
+		UploadsToday: uploadsToday,
+		DailyLimit:   dailyUploadLimit(s.cfg),
+		Status:       AccountOK,
+	}
+	if h.CookiesFile == "" {
+		h.CookiesFile = s.cfg.Bilibili.CookiesFile
+	}
+
+	if !s.inspectCookies(&h, bilibili.SessionCookieNames, opts.WarnWithin, true) {
+		return h
+	}
+	if !h.HasCSRF {
+		h.fail("cookies 中缺少 CSRF（csrf 或 bili_jct）")
+	}
+
+	if !opts.Offline && h.Usable() {
+		loggedIn, err := bilibili.NewHTTPUploader(nil, s.cfg.Bilibili.BaseURL, "", h.CookiesFile).CheckLoginStatus(ctx)
+		switch {
+		case err != nil:
+			h.warn("无法确认登录状态: %v", err)
+		case !loggedIn:
+			h.LoggedIn = &loggedIn
+			h.fail("账号未登录，cookies 已失效")
+		default:
+			h.LoggedIn = &loggedIn
+		}
+	}
+
+	if h.Remaining() == 0 {
+		h.warn("今日上传额度已用尽（%d/%d）", h.UploadsToday, h.DailyLimit)
+	}
+	return h
+}
+
+func (s *accountService) checkYouTube(opts AccountCheckOptions) AccountHealth {
+	h := AccountHealth{Name: "youtube", Platform: "youtube", CookiesFile: s.cfg.YouTube.CookiesFile, Status: AccountOK}
+	if h.CookiesFile == "" {
+		if s.cfg.YouTube.CookiesFromBrowser != "" {
+			h.warn("使用浏览器 %s 的 cookies，未检查", s.cfg.YouTube.CookiesFromBrowser)
+		} else {
+			h.warn("未配置 youtube.cookies_file，可能触发 bot 检测")
+		}
+		return h
+	}
+	s.inspectCookies(&h, youtubeSessionCookies, opts.WarnWithin, false)
+	return h
+}
+
+// inspectCookies 读取 cookies 文件并检查关键 cookie：requireAll 为 true 时缺少任一关键 cookie 即不可用，
+// 否则至少需要其中一个。文件无法读取时返回 false
+func (s *accountService) inspectCookies(h *AccountHealth, keyNames []string, warnWithin time.Duration, requireAll bool) bool {
+	if h.CookiesFile == "" {
+		h.fail("未配置 cookies 文件")
+		return false
+	}
+	if _, err := os.Stat(h.CookiesFile); err != nil {
+		h.fail("cookies 文件不可读: %v", err)
+		return false
+	}
+	cookies, err := bilibili.ParseCookiesFile(h.CookiesFile)
+	if err != nil {
+		h.fail("解析 cookies 文件失败: %v", err)
+		return false
+	}
+	report := bilibili.InspectCookies(cookies, keyNames)
+	h.Cookies = report.Count
+	h.HasCSRF = report.HasCSRF
+	if report.Count == 0 {
+		h.fail("cookies 文件为空")
+		return false
+	}
+	switch {
+	case requireAll && len(report.Missing) > 0:
+		h.fail("缺少登录 cookie: %s", strings.Join(report.Missing, ", "))
+	case !requireAll && len(report.Missing) == len(keyNames):
+		h.warn("未找到登录 cookie（%s），可能是未登录时导出的", strings.Join(keyNames, " / "))
+	}
+	if !report.ExpiresAt.IsZero() {
+		expiresAt := report.ExpiresAt
+		h.ExpiresAt = &expiresAt
+		left := time.Until(expiresAt)
+		switch {
+		case left <= 0:
+			h.fail("cookies 已于 %s 过期", expiresAt.Format("2006-01-02 15:04"))
+		case warnWithin > 0 && left < warnWithin:
+			h.warn("cookies 将于 %s 过期（剩余 %s）", expiresAt.Format("2006-01-02 15:04"), FormatDuration(left))
+		}
+	}
+	return true
+}
+
+// dailyUploadLimit 每个账号每日的上传上限（bilibili.daily_upload_limit，未配置时为 160）
+func dailyUploadLimit(cfg *config.Config) int {
+	if cfg.Bilibili.DailyUploadLimit > 0 {
+		return cfg.Bilibili.DailyUploadLimit
+	}
+	return 160
+}
+
+// FormatDuration 将时长格式化为“N天N小时”或“N小时N分钟”
+func FormatDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	if days > 0 {
+		return fmt.Sprintf("%d天%d小时", days, hours)
+	}
+	return fmt.Sprintf("%d小时%d分钟", hours, int(d%time.Hour/time.Minute))
+}
@@ -0,0 +1,440 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/bilibili"
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+)
+
+// ErrNoAccountAvailable 没有可选择的上传账号（均已达每日 / 每小时上限、处于失败冷却期或被隔离）
+var ErrNoAccountAvailable = errors.New("没有可用的B站账号")
+
+// ErrAccountQuarantined 指定的账号处于隔离期（cookies 失效、风控或封禁后暂停使用）
+var ErrAccountQuarantined = errors.New("账号处于隔离期")
+
+// AccountSelection 一次账号选择的结果
+type AccountSelection struct {
+	Account  string
+	Strategy string
+	// Reason 选择该账号的原因（写入 upload_status.json，便于排查）
+	Reason string
+	// RetryAt 没有可用账号时最早可能恢复的时间
+	RetryAt time.Time
+}
+
+// AccountSelector 按 bilibili.account_selection 与频道的 account_selection 为视频选择上传账号
+type AccountSelector interface {
+	// Select 为视频目录选择上传账号，并将选择记录写入其上传状态（videoDir 为空时按全局配置选择、不记录）。
+	// reserved 为正在上传、尚未计入当日计数的各账号视频数，可为 nil。
+	// 没有可用账号时返回 ErrNoAccountAvailable，同时在结果的 RetryAt 中给出最早可能恢复的时间
+	Select(videoDir string, reserved map[string]int) (AccountSelection, error)
+	// Available 按全局配置判断是否至少有一个账号可被选择；没有时返回最早可能恢复的时间
+	// （失败冷却或每小时上限到期，均为每日上限时为次日 0 点）
+	Available(reserved map[string]int) (bool, time.Time)
+	// RecordResult 记录账号的上传结果：成功计入每小时上传数，失败（err 非 nil）开始冷却；
+	// 按 bilibili.quarantine 对 cookies 失效、风控限流、封禁等账号问题隔离账号
+	RecordResult(account string, err error)
+	// Quarantines 返回仍在隔离期内的账号，key 为账号名
+	Quarantines() (map[string]file.AccountQuarantine, error)
+	// Release 解除账号的隔离与失败冷却，返回账号此前是否处于隔离期
+	Release(account string) (bool, error)
+}
+
+type accountSelector struct {
+	fileManager file.Repository
+	cfg         *config.Config
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// NewAccountSelector 创建 AccountSelector；选择状态保存在 .global/account_selection，多个进程共享
+func NewAccountSelector(fileManager file.Repository, cfg *config.Config) AccountSelector {
+	return &accountSelector{
+		fileManager: fileManager,
+		cfg:         cfg,
+		rnd:         rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// accountCandidate 一个账号的可选状态
+type accountCandidate struct {
+	name     string
+	used     int       // 今日已上传（含正在上传）
+	blocked  string    // 不可选的原因，为空表示可选
+	retryAt  time.Time // 不可选时最早恢复的时间
+	weight   int
+	position int // 在候选列表中的序号（round_robin）
+}
+
+func (s *accountSelector) Select(videoDir string, reserved map[string]int) (AccountSelection, error) {
+	channelKey := ""
+	sel := s.cfg.Bilibili.AccountSelection
+	if videoDir != "" {
+		channelKey = filepath.Base(filepath.Dir(videoDir))
+		if ch, ok := findChannelByDir(s.cfg, s.fileManager, channelKey); ok {
+			sel = sel.Merge(ch.AccountSelection)
+		}
+	}
+
+	var selection AccountSelection
+	err := s.fileManager.UpdateAccountSelection(func(state *file.AccountSelectionState) error {
+		candidates, err := s.candidates(sel, state, reserved, time.Now())
+		if err != nil {
+			return err
+		}
+		selection, err = s.pick(sel, channelKey, candidates, state)
+		return err
+	})
+	if err != nil {
+		return selection, err
+	}
+
+	if videoDir != "" {
+		record := file.AccountSelectionRecord{
+			Account:    selection.Account,
+			Strategy:   selection.Strategy,
+			Reason:     selection.Reason,
+			SelectedAt: time.Now().Unix(),
+		}
+		if err := s.fileManager.RecordAccountSelection(videoDir, record); err != nil {
+			logger.Warn().Err(err).Str("video_dir", videoDir).Msg("记录账号选择失败")
+		}
+	}
+	return selection, nil
+}
+
+func (s *accountSelector) Available(reserved map[string]int) (bool, time.Time) {
+	state, err := s.fileManager.LoadAccountSelection()
+	if err != nil {
+		logger.Warn().Err(err).Msg("读取账号选择状态失败")
+		return len(s.cfg.BilibiliAccounts) > 0, time.Time{}
+	}
+	sel := s.cfg.Bilibili.AccountSelection
+	// 频道可能使用 bilibili.account_selection.accounts 之外的账号，这里按全部账号判断
+	sel.Accounts = nil
+	candidates, err := s.candidates(sel, state, reserved, time.Now())
+	if err != nil {
+		logger.Warn().Err(err).Msg("读取今日上传计数失败")
+		return len(s.cfg.BilibiliAccounts) > 0, time.Time{}
+	}
+	retryAt := earliestRetry(candidates)
+	return retryAt.IsZero() && len(candidates) > 0, retryAt
+}
+
+func (s *accountSelector) RecordResult(account string, uploadErr error) {
+	if account == "" {
+		return
+	}
+	now := time.Now()
+	err := s.fileManager.UpdateAccountSelection(func(state *file.AccountSelectionState) error {
+		usage := state.Usage[account]
+		if usage == nil {
+			usage = &file.AccountUsage{}
+			state.Usage[account] = usage
+		}
+		if uploadErr != nil {
+			usage.LastFailureAt = now.Unix()
+			usage.LastFailure = previewError(uploadErr.Error(), 200)
+			kind := bilibili.ClassifyError(uploadErr)
+			if d := s.cfg.Bilibili.Quarantine.Duration(string(kind)); d > 0 {
+				usage.Quarantine = &file.AccountQuarantine{
+					Kind:   string(kind),
+					Reason: usage.LastFailure,
+					Since:  now.Unix(),
+					Until:  now.Add(d).Unix(),
+				}
+				logger.Warn().
+					Str("account", account).
+					Str("kind", string(kind)).
+					Time("until", now.Add(d)).
+					Msg("账号上传失败（账号问题），已隔离，期间不参与自动选择；可用 accounts release 提前解除")
+			}
+			return nil
+		}
+		usage.RecentUploads = append(recentUploads(usage.RecentUploads, now), now.Unix())
+		return nil
+	})
+	if err != nil {
+		logger.Warn().Err(err).Str("account", account).Msg("记录账号上传结果失败")
+	}
+}
+
+func (s *accountSelector) Quarantines() (map[string]file.AccountQuarantine, error) {
+	state, err := s.fileManager.LoadAccountSelection()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	quarantines := make(map[string]file.AccountQuarantine)
+	for name, usage := range state.Usage {
+		if usage != nil && usage.Quarantine.Active(now) {
+			quarantines[name] = *usage.Quarantine
+		}
+	}
+	return quarantines, nil
+}
+
+func (s *accountSelector) Release(account string) (bool, error) {
+	released := false
+	err := s.fileManager.UpdateAccountSelection(func(state *file.AccountSelectionState) error {
+		usage := state.Usage[account]
+		if usage == nil {
+			return nil
+		}
+		released = usage.Quarantine.Active(time.Now())
+		usage.Quarantine = nil
+		usage.LastFailureAt = 0
+		return nil
+	})
+	return released, err
+}
+
+// candidates 按名称顺序列出可参与选择的账号，并标记每日 / 每小时上限与失败冷却
+func (s *accountSelector) candidates(sel config.AccountSelectionConfig, state *file.AccountSelectionState, reserved map[string]int, now time.Time) ([]accountCandidate, error) {
+	names := sel.Accounts
+	if len(names) == 0 {
+		for name := range s.cfg.BilibiliAccounts {
+			names = append(names, name)
+		}
+	}
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+
+	counts, err := s.fileManager.LoadTodayUploadCounts()
+	if err != nil {
+		return nil, fmt.Errorf("读取今日上传计数失败: %w", err)
+	}
+	dailyLimit := dailyUploadLimit(s.cfg)
+	tomorrow := nextDay(now)
+
+	candidates := make([]accountCandidate, 0, len(names))
+	for i, name := range names {
+		account, ok := s.cfg.BilibiliAccounts[name]
+		if !ok {
+			continue
+		}
+		c := accountCandidate{name: name, used: counts[name] + reserved[name], weight: account.Weight, position: i}
+		if c.weight <= 0 {
+			c.weight = 1
+		}
+		usage := state.Usage[name]
+		if usage == nil {
+			usage = &file.AccountUsage{}
+		}
+
+		hourlyLimit := sel.HourlyLimit
+		if account.HourlyLimit > 0 {
+			hourlyLimit = account.HourlyLimit
+		}
+		recent := recentUploads(usage.RecentUploads, now)
+		cooldown := time.Duration(sel.CooldownMinutes) * time.Minute
+		coolUntil := time.Unix(usage.LastFailureAt, 0).Add(cooldown)
+
+		switch {
+		case usage.Quarantine.Active(now):
+			until := time.Unix(usage.Quarantine.Until, 0)
+			c.blocked = fmt.Sprintf("已隔离（%s），至 %s", usage.Quarantine.Kind, until.Format("01-02 15:04"))
+			c.retryAt = until
+		case c.used >= dailyLimit:
+			c.blocked = fmt.Sprintf("今日已上传 %d/%d", c.used, dailyLimit)
+			c.retryAt = tomorrow
+		case cooldown > 0 && usage.LastFailureAt > 0 && coolUntil.After(now):
+			c.blocked = fmt.Sprintf("上传失败冷却中，至 %s", coolUntil.Format("15:04"))
+			c.retryAt = coolUntil
+		case hourlyLimit > 0 && len(recent)+reserved[name] >= hourlyLimit:
+			c.blocked = fmt.Sprintf("最近一小时已上传 %d/%d", len(recent)+reserved[name], hourlyLimit)
+			c.retryAt = now
+			if len(recent) >= hourlyLimit {
+				c.retryAt = time.Unix(recent[len(recent)-hourlyLimit], 0).Add(time.Hour)
+			}
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
+}
+
+// pick 按策略在可选账号中选择一个，并更新轮转位置 / 频道固定账号
+func (s *accountSelector) pick(sel config.AccountSelectionConfig, channelKey string, candidates []accountCandidate, state *file.AccountSelectionState) (AccountSelection, error) {
+	var eligible, blocked []string
+	for _, c := range candidates {
+		if c.blocked == "" {
+			eligible = append(eligible, c.name)
+		} else {
+			blocked = append(blocked, c.name+"（"+c.blocked+"）")
+		}
+	}
+	if len(eligible) == 0 {
+		if len(blocked) == 0 {
+			return AccountSelection{}, fmt.Errorf("%w: 未配置 bilibili_accounts", ErrNoAccountAvailable)
+		}
+		return AccountSelection{RetryAt: earliestRetry(candidates)}, fmt.Errorf("%w: %s", ErrNoAccountAvailable, strings.Join(blocked, "; "))
+	}
+
+	strategy := sel.Strategy
+	if strategy == "" {
+		strategy = config.SelectRandom
+	}
+	if strategy != config.SelectSticky {
+		return s.pickBy(strategy, channelKey, candidates, state), nil
+	}
+
+	fallback := sel.Fallback
+	if fallback == "" || fallback == config.SelectSticky {
+		fallback = config.SelectLeastUsed
+	}
+	if channelKey == "" {
+		selection := s.pickBy(fallback, channelKey, candidates, state)
+		selection.Strategy = config.SelectSticky
+		selection.Reason = "未关联频道，按 " + fallback + " 选择: " + selection.Reason
+		return selection, nil
+	}
+	previous := state.Sticky[channelKey]
+	for _, c := range candidates {
+		if c.name == previous && c.blocked == "" {
+			return AccountSelection{Account: previous, Strategy: config.SelectSticky, Reason: fmt.Sprintf("沿用频道 %s 固定的账号", channelKey)}, nil
+		}
+	}
+	selection := s.pickBy(fallback, channelKey, candidates, state)
+	state.Sticky[channelKey] = selection.Account
+	reason := fmt.Sprintf("频道 %s 尚无固定账号", channelKey)
+	if previous != "" {
+		reason = fmt.Sprintf("频道 %s 固定的账号 %s 不可用", channelKey, previous)
+		for _, c := range candidates {
+			if c.name == previous {
+				reason += "（" + c.blocked + "）"
+			}
+		}
+	}
+	selection.Strategy = config.SelectSticky
+	selection.Reason = reason + "，按 " + fallback + " 重新选择: " + selection.Reason
+	return selection, nil
+}
+
+// pickBy 按 random / round_robin / least_used / weighted 选择，candidates 中至少有一个可选账号
+func (s *accountSelector) pickBy(strategy, channelKey string, candidates []accountCandidate, state *file.AccountSelectionState) AccountSelection {
+	var eligible []accountCandidate
+	for _, c := range candidates {
+		if c.blocked == "" {
+			eligible = append(eligible, c)
+		}
+	}
+
+	switch strategy {
+	case config.SelectRoundRobin:
+		last, ok := state.Cursors[channelKey]
+		if !ok {
+			last = -1
+		}
+		chosen := eligible[0]
+		for _, c := range eligible {
+			if c.position > last {
+				chosen = c
+				break
+			}
+		}
+		state.Cursors[channelKey] = chosen.position
+		return AccountSelection{
+			Account:  chosen.name,
+			Strategy: strategy,
+			Reason:   fmt.Sprintf("轮转到第 %d/%d 个账号（%d 个可用）", chosen.position+1, len(candidates), len(eligible)),
+		}
+
+	case config.SelectLeastUsed:
+		chosen := eligible[0]
+		for _, c := range eligible[1:] {
+			if c.used < chosen.used {
+				chosen = c
+			}
+		}
+		return AccountSelection{
+			Account:  chosen.name,
+			Strategy: strategy,
+			Reason:   fmt.Sprintf("今日上传最少（%d 个，%d 个可用账号）", chosen.used, len(eligible)),
+		}
+
+	case config.SelectWeighted:
+		total := 0
+		for _, c := range eligible {
+			total += c.weight
+		}
+		n := s.intn(total)
+		chosen := eligible[len(eligible)-1]
+		for _, c := range eligible {
+			if n < c.weight {
+				chosen = c
+				break
+			}
+			n -= c.weight
+		}
+		return AccountSelection{
+			Account:  chosen.name,
+			Strategy: strategy,
+			Reason:   fmt.Sprintf("按权重随机选择（权重 %d/%d）", chosen.weight, total),
+		}
+
+	default:
+		chosen := eligible[s.intn(len(eligible))]
+		return AccountSelection{
+			Account:  chosen.name,
+			Strategy: config.SelectRandom,
+			Reason:   fmt.Sprintf("在 %d 个可用账号中随机选择", len(eligible)),
+		}
+	}
+}
+
+func (s *accountSelector) intn(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rnd.Intn(n)
+}
+
+// earliestRetry 所有账号都不可选时最早恢复的时间；有可选账号（或没有账号）时返回零值
+func earliestRetry(candidates []accountCandidate) time.Time {
+	var retryAt time.Time
+	for _, c := range candidates {
+		if c.blocked == "" {
+			return time.Time{}
+		}
+		if retryAt.IsZero() || c.retryAt.Before(retryAt) {
+			retryAt = c.retryAt
+		}
+	}
+	return retryAt
+}
+
+// nextDay 次日 0 点（当日上传计数重置的时间）
+func nextDay(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+}
+
+// recentUploads 返回最近一小时内的上传时间
+func recentUploads(uploads []int64, now time.Time) []int64 {
+	cutoff := now.Add(-time.Hour).Unix()
+	recent := make([]int64, 0, len(uploads))
+	for _, at := range uploads {
+		if at > cutoff {
+			recent = append(recent, at)
+		}
+	}
+	return recent
+}
+
+// previewError 截断过长的错误信息（按字符）
+func previewError(msg string, max int) string {
+	runes := []rune(msg)
+	if len(runes) <= max {
+		return msg
+	}
+	return string(runes[:max]) + "..."
+}
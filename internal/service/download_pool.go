@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/internal/repository/youtube"
+	"blueberry/pkg/logger"
+	"blueberry/pkg/utils"
+
+	"github.com/rs/zerolog"
+)
+
+// ErrDownloadResting 处于下载限制 / bot detection 休息期间：本次运行不再开始新的视频，
+// 剩余视频在休息结束后的下一次运行中处理（daemon 通过 NotBefore 顺延到休息结束）
+var ErrDownloadResting = errors.New("下载休息中")
+
+func restingError(until time.Time) error {
+	return fmt.Errorf("%w，休息至 %s", ErrDownloadResting, until.Format("2006-01-02 15:04:05"))
+}
+
+// downloadGate 协调多个 worker 共享的下载计数与休息窗口
+// mu 只在读写计数与休息记录时持有，不在持有期间休眠；
+// inFlight 为已开始但尚未计数的新下载，用于避免并发下载越过 video_limit_before_rest。
+type downloadGate struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	inFlight int
+}
+
+func newDownloadGate() *downloadGate {
+	g := &downloadGate{}
+	g.cond = sync.NewCond(&g.mu)
+	return g
+}
+
+// resourceLimiter 按资源类型（视频/字幕/缩略图）限制同时进行的抓取数量
+type resourceLimiter struct {
+	slots map[string]chan struct{}
+}
+
+func newResourceLimiter(limits map[string]int) *resourceLimiter {
+	l := &resourceLimiter{slots: make(map[string]chan struct{}, len(limits))}
+	for kind, n := range limits {
+		if n < 1 {
+			n = 1
+		}
+		l.slots[kind] = make(chan struct{}, n)
+	}
+	return l
+}
+
+// acquire 占用一个 kind 类型的抓取名额，返回释放函数；未配置的类型不限制
+func (l *resourceLimiter) acquire(ctx context.Context, kind string) (func(), error) {
+	slots, ok := l.slots[kind]
+	if !ok {
+		return func() {}, nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// downloadWorkers 返回视频 worker 数量（至少 1）
+func downloadWorkers(cfg *config.Config) int {
+	if cfg != nil && cfg.YouTube.DownloadWorkers > 1 {
+		return cfg.YouTube.DownloadWorkers
+	}
+	return 1
+}
+
+// newDownloadLimiter 根据配置创建视频/字幕/缩略图的并发预算（未配置时等于 worker 数量）
+func newDownloadLimiter(cfg *config.Config) *resourceLimiter {
+	workers := downloadWorkers(cfg)
+	limitOr := func(n int) int {
+		if n > 0 {
+			return n
+		}
+		return workers
+	}
+	var video, subtitle, thumbnail int
+	if cfg != nil {
+		video = cfg.YouTube.VideoDownloadConcurrency
+		subtitle = cfg.YouTube.SubtitleDownloadConcurrency
+		thumbnail = cfg.YouTube.ThumbnailDownloadConcurrency
+	}
+	return newResourceLimiter(map[string]int{
+		file.ResourceTypeVideo:     limitOr(video),
+		file.ResourceTypeSubtitle:  limitOr(subtitle),
+		file.ResourceTypeThumbnail: limitOr(thumbnail),
+	})
+}
+
+// workerLogger 返回带 worker 编号与任务序号的日志器，便于按 worker 过滤并发日志
+func workerLogger(worker, index, total int) zerolog.Logger {
+	return logger.Logger().With().
+		Int("worker", worker).
+		Str("seq", fmt.Sprintf("%d/%d", index+1, total)).
+		Logger()
+}
+
+// acquireDownloadTurn 在开始处理一个视频前调用
+// 处于休息窗口时返回 ErrDownloadResting；达到 video_limit_before_rest 时先等待进行中的下载完成，再记录休息窗口并返回 ErrDownloadResting。
+// newDownload 为 true 表示该视频尚未下载，会预留一个下载计数，处理完后必须调用 releaseDownloadTurn。
+func (s *downloadService) acquireDownloadTurn(ctx context.Context, newDownload bool) error {
+	log := logger.Ctx(ctx)
+	g := s.gate
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	// 取消时唤醒 cond.Wait，避免等待进行中的下载时看不到取消信号
+	stop := context.AfterFunc(ctx, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.cond.Broadcast()
+	})
+	defer stop()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if until, resting := s.downloadRestUntil(); resting {
+			return restingError(until)
+		}
+
+		limit := s.getDownloadLimit()
+		if limit <= 0 {
+			break
+		}
+		if count, err := s.fileManager.GetTodayDownloadCount(); err == nil {
+			s.dailyDownloadCount = count
+		}
+		if s.dailyDownloadCount+g.inFlight < limit {
+			break
+		}
+		if g.inFlight > 0 {
+			// 进行中的下载完成后才能确定是否真的达到限制
+			g.cond.Wait()
+			continue
+		}
+		log.Warn().
+			Int("current_count", s.dailyDownloadCount).
+			Int("limit", limit).
+			Msg("达到下载限制，准备休息")
+		return restingError(s.startLimitRest(ctx))
+	}
+
+	if newDownload {
+		g.inFlight++
+	}
+	return nil
+}
+
+// downloadRestUntil 返回下载限制 / bot detection 休息的结束时间（取较晚者）
+func (s *downloadService) downloadRestUntil() (time.Time, bool) {
+	return DownloadRestUntil(s.cfg, s.fileManager)
+}
+
+// DownloadRestUntil 返回下载限制 / bot detection 休息的结束时间（取较晚者），不在休息期间时返回 false
+func DownloadRestUntil(cfg *config.Config, fileManager file.Repository) (time.Time, bool) {
+	var until time.Time
+	if inRest, restUntil, err := fileManager.IsInRestPeriod(); err == nil && inRest {
+		until = restUntil
+	}
+	if inRest, restUntil, err := fileManager.IsInBotDetectionRestPeriod(botDetectionRestMinutes(cfg)); err == nil && inRest && restUntil.After(until) {
+		until = restUntil
+	}
+	return until, !until.IsZero()
+}
+
+// releaseDownloadTurn 归还 acquireDownloadTurn 预留的下载计数；downloaded 为 true 时增加今日下载计数
+func (s *downloadService) releaseDownloadTurn(ctx context.Context, newDownload, downloaded bool) {
+	if !newDownload {
+		return
+	}
+	g := s.gate
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.inFlight--
+	if downloaded {
+		s.incrementDownloadCounter(ctx)
+	}
+	g.cond.Broadcast()
+}
+
+// onBotDetection 累计 bot detection 并在达到阈值时记录休息窗口；之后各 worker 领取视频时返回 ErrDownloadResting
+func (s *downloadService) onBotDetection(ctx context.Context) {
+	s.gate.mu.Lock()
+	defer s.gate.mu.Unlock()
+	s.handleBotDetection(ctx)
+}
+
+// botDetectionRestMinutes bot detection 休息时长（分钟），默认值与 config.go 中的 youtube.bot_detection_rest_duration 一致
+func botDetectionRestMinutes(cfg *config.Config) int {
+	if cfg != nil && cfg.YouTube.BotDetectionRestDuration > 0 {
+		return cfg.YouTube.BotDetectionRestDuration
+	}
+	return 360 // 默认6小时 = 360分钟
+}
+
+// fetchVideo 在视频并发预算内调用 yt-dlp 下载视频
+func (s *downloadService) fetchVideo(ctx context.Context, channelID, videoURL string, languages []string, title string) (*youtube.DownloadResult, error) {
+	release, err := s.limiter.acquire(ctx, file.ResourceTypeVideo)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.downloader.DownloadVideo(ctx, channelID, videoURL, languages, title)
+}
+
+// fetchVideoMetadata 在视频并发预算内执行 yt-dlp --dump-json 获取视频信息
+func (s *downloadService) fetchVideoMetadata(ctx context.Context, args []string) ([]byte, error) {
+	release, err := s.limiter.acquire(ctx, file.ResourceTypeVideo)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return utils.CommandContext(ctx, youtube.YtDlpPath(s.cfg), args...).CombinedOutput()
+}
+
+// fetchThumbnail 在缩略图并发预算内下载缩略图
+func (s *downloadService) fetchThumbnail(ctx context.Context, videoDir string, rawData map[string]interface{}) (string, error) {
+	release, err := s.limiter.acquire(ctx, file.ResourceTypeThumbnail)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return s.downloadThumbnails(ctx, videoDir, rawData)
+}
+
+// videoJobResult 单个视频任务的处理结果，按任务顺序汇总
+type videoJobResult struct {
+	videoID     string
+	err         error
+	skipped     bool
+	interrupted bool // 处理中途收到取消信号，状态已回滚
+	deferred    bool // 处于休息期间未开始，留到休息结束后的下一次运行
+}
+
+// logVideoJobResults 按任务顺序输出汇总（与 worker 完成顺序无关）
+func logVideoJobResults(channelID string, results []videoJobResult) {
+	succeeded, failed, skipped, interrupted, deferred := 0, 0, 0, 0, 0
+	for i, result := range results {
+		switch {
+		case result.videoID == "":
+			// 未分发（被取消）或视频信息不完整
+		case result.skipped:
+			skipped++
+		case result.deferred:
+			deferred++
+		case result.interrupted:
+			interrupted++
+		case result.err != nil:
+			failed++
+			logger.Warn().
+				Str("channel_id", channelID).
+				Str("seq", fmt.Sprintf("%d/%d", i+1, len(results))).
+				Str("video_id", result.videoID).
+				Err(result.err).
+				Msg("视频处理失败")
+		default:
+			succeeded++
+		}
+	}
+	logger.Info().
+		Str("channel_id", channelID).
+		Int("total", len(results)).
+		Int("succeeded", succeeded).
+		Int("failed", failed).
+		Int("skipped", skipped).
+		Int("interrupted", interrupted).
+		Int("deferred", deferred).
+		Msg("频道视频处理完成")
+}
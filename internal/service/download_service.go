@@ -18,8 +18,17 @@ import (
 	"blueberry/internal/repository/file"
 	"blueberry/internal/repository/youtube"
 	"blueberry/pkg/logger"
+	"blueberry/pkg/utils"
 )
 
+// DownloadHooks 频道下载过程中的回调，字段为 nil 时忽略；并发下载时会被多个 worker 同时调用
+type DownloadHooks struct {
+	// BeforeVideo 在领取下载计数之前调用，可以阻塞等待（背压）；返回 false 时跳过该视频
+	BeforeVideo func(ctx context.Context, videoDir string) bool
+	// OnReady 视频文件已就绪（本次下载完成或此前已下载）时调用
+	OnReady func(ctx context.Context, videoDir string)
+}
+
 type DownloadService interface {
 	// ParseChannels 解析配置文件中所有频道并保存视频列表信息到目录下
 	// 遍历配置中的所有YouTube频道，为每个频道解析视频列表
@@ -42,6 +51,10 @@ type DownloadService interface {
 	// channelDir: 频道目录路径（例如：downloads/Comic-likerhythm）
 	DownloadChannel(ctx context.Context, channelDir string) error
 
+	// DownloadChannelWithHooks 与 DownloadChannel 相同，但在处理每个视频前后调用 hooks
+	// 用于 pipeline 模式：下载前做背压判断，下载完成后将视频目录加入上传队列
+	DownloadChannelWithHooks(ctx context.Context, channelDir string, hooks DownloadHooks) error
+
 	// DownloadVideoDir 下载指定视频目录的视频
 	// videoDir: 视频目录路径（例如：downloads/Comic-likerhythm/videoID）
 	DownloadVideoDir(ctx context.Context, videoDir string) error
@@ -68,6 +81,9 @@ type downloadService struct {
 	dailyDownloadDate  string // 格式: YYYY-MM-DD
 	// Bot detection 计数器
 	botDetectionCount int
+	// 并发下载：worker 之间共享的计数/休息协调，以及视频/字幕/缩略图的并发预算
+	gate    *downloadGate
+	limiter *resourceLimiter
 }
 
 // NewDownloadService 创建并返回一个新的 DownloadService 实例
@@ -85,6 +101,8 @@ func NewDownloadService(
 		subtitleManager: subtitleManager,
 		fileManager:     fileManager,
 		cfg:             cfg,
+		gate:            newDownloadGate(),
+		limiter:         newDownloadLimiter(cfg),
 	}
 
 	// 从文件加载 bot detection 计数
@@ -629,99 +647,126 @@ func (s *downloadService) downloadFromChannelInfo(ctx context.Context, channel *
 			Msg("待下载状态文件已生成/更新")
 	}
 
-	// 检查是否在休息期间
-	if inRest, restUntil, err := s.fileManager.IsInRestPeriod(); err == nil && inRest {
-		s.sleepUntilRestEnd(ctx, restUntil)
-		// 休息结束后，计数器已重置，继续下载
+	// 处于休息期间时不开始下载，由下一次运行（daemon 顺延到休息结束）继续
+	if restUntil, resting := s.downloadRestUntil(); resting {
+		logger.Info().
+			Time("rest_until", restUntil).
+			Dur("remaining", time.Until(restUntil)).
+			Msg("检测到正在休息期间，本次不下载")
+		return restingError(restUntil)
 	}
 
-	// 遍历每个视频，逐个下载（不使用并发）
-	for i, videoMap := range videoMaps {
-		// 检查是否达到下载限制（每N个视频后休息）
-		if s.isDownloadLimitReached() {
-			s.restAfterLimit(ctx)
-			// 休息结束后，计数器已重置，继续下载
-		}
+	// 按配置的 worker 数量处理视频（默认 1，即逐个下载）
+	workers := downloadWorkers(s.cfg)
+	if workers > 1 {
+		logger.Info().
+			Int("workers", workers).
+			Str("channel_id", channelID).
+			Msg("并发下载频道视频")
+	}
+	results := make([]videoJobResult, len(videoMaps))
+	utils.RunWorkers(ctx, workers, len(videoMaps), func(ctx context.Context, worker, i int) {
+		ctx = logger.WithContext(ctx, workerLogger(worker, i, len(videoMaps)))
+		results[i] = s.downloadChannelInfoVideo(ctx, channelID, languages, videoMaps[i])
+	})
+	logVideoJobResults(channelID, results)
 
-		// 从 map 中提取基本信息
-		videoID, _ := videoMap["id"].(string)
-		title, _ := videoMap["title"].(string)
-		url, _ := videoMap["url"].(string)
-		if url == "" {
-			// 如果没有 url，尝试从 id 构建
-			if videoID != "" {
-				url = fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID)
-			}
-		}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if restUntil, resting := s.downloadRestUntil(); resting {
+		return restingError(restUntil)
+	}
+	return nil
+}
 
-		if videoID == "" || title == "" {
-			logger.Warn().
-				Int("index", i+1).
-				Msg("视频信息不完整，跳过")
-			continue
+// downloadChannelInfoVideo 下载 channel_info.json 中的单个视频（downloadFromChannelInfo 的 worker 任务）
+func (s *downloadService) downloadChannelInfoVideo(ctx context.Context, channelID string, languages []string, videoMap map[string]interface{}) videoJobResult {
+	log := logger.Ctx(ctx)
+	// 从 map 中提取基本信息
+	videoID, _ := videoMap["id"].(string)
+	title, _ := videoMap["title"].(string)
+	url, _ := videoMap["url"].(string)
+	if url == "" {
+		// 如果没有 url，尝试从 id 构建
+		if videoID != "" {
+			url = fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID)
 		}
+	}
 
-		logger.Info().
-			Int("current", i+1).
-			Int("total", len(videoMaps)).
-			Str("title", title).
-			Str("video_id", videoID).
-			Msg("处理视频")
+	if videoID == "" || title == "" {
+		log.Warn().Msg("视频信息不完整，跳过")
+		return videoJobResult{}
+	}
+	result := videoJobResult{videoID: videoID}
 
-		// 查找视频目录
-		videoDir, _ := s.fileManager.FindVideoDirByID(channelID, videoID)
+	log.Info().
+		Str("title", title).
+		Str("video_id", videoID).
+		Msg("处理视频")
 
-		// 分别检查视频、字幕、缩略图的下载状态
-		videoDownloaded := s.fileManager.IsVideoDownloaded(videoDir)
-		subtitlesDownloaded := s.fileManager.IsSubtitlesDownloaded(videoDir, languages)
-		thumbnailDownloaded := s.fileManager.IsThumbnailDownloaded(videoDir)
+	// 查找视频目录
+	videoDir, _ := s.fileManager.FindVideoDirByID(channelID, videoID)
+
+	// 分别检查视频、字幕、缩略图的下载状态
+	videoDownloaded := s.fileManager.IsVideoDownloaded(videoDir)
+	subtitlesDownloaded := s.fileManager.IsSubtitlesDownloaded(videoDir, languages)
+	thumbnailDownloaded := s.fileManager.IsThumbnailDownloaded(videoDir)
+
+	// 记录检查状态
+	log.Debug().
+		Str("title", title).
+		Str("video_id", videoID).
+		Str("video_dir", videoDir).
+		Bool("video_downloaded", videoDownloaded).
+		Bool("subtitles_downloaded", subtitlesDownloaded).
+		Bool("thumbnail_downloaded", thumbnailDownloaded).
+		Msg("检查下载状态")
 
-		// 记录检查状态
-		logger.Debug().
+	// 不再在这里跳过，让 downloadVideoAndSaveInfo 内部处理每个步骤的独立检查
+	// 如果视频未下载或下载失败，会重新下载
+	if !videoDownloaded {
+		log.Info().
 			Str("title", title).
 			Str("video_id", videoID).
-			Str("video_dir", videoDir).
 			Bool("video_downloaded", videoDownloaded).
 			Bool("subtitles_downloaded", subtitlesDownloaded).
 			Bool("thumbnail_downloaded", thumbnailDownloaded).
-			Msg("检查下载状态")
-
-		// 不再在这里跳过，让 downloadVideoAndSaveInfo 内部处理每个步骤的独立检查
-		// 如果视频未下载或下载失败，会重新下载
-		if !videoDownloaded {
-			logger.Info().
-				Str("title", title).
-				Str("video_id", videoID).
-				Bool("video_downloaded", videoDownloaded).
-				Bool("subtitles_downloaded", subtitlesDownloaded).
-				Bool("thumbnail_downloaded", thumbnailDownloaded).
-				Msg("视频未下载或下载失败，将开始/重新下载")
-		} else {
-			logger.Info().
-				Str("title", title).
-				Str("video_id", videoID).
-				Bool("video_downloaded", videoDownloaded).
-				Bool("subtitles_downloaded", subtitlesDownloaded).
-				Bool("thumbnail_downloaded", thumbnailDownloaded).
-				Msg("检查视频资源状态")
-		}
-
-		// 调用公共的下载视频方法
-		downloadedBefore := videoDownloaded
-		if err := s.downloadVideoAndSaveInfo(ctx, channelID, videoID, title, url, languages, videoMap); err != nil {
-			logger.Error().Err(err).Str("title", title).Str("video_id", videoID).Msg("下载视频失败")
-			// 下载失败时，状态文件已经在 downloadVideoAndSaveInfo 中更新为 failed
-			continue
-		}
+			Msg("视频未下载或下载失败，将开始/重新下载")
+	} else {
+		log.Info().
+			Str("title", title).
+			Str("video_id", videoID).
+			Bool("video_downloaded", videoDownloaded).
+			Bool("subtitles_downloaded", subtitlesDownloaded).
+			Bool("thumbnail_downloaded", thumbnailDownloaded).
+			Msg("检查视频资源状态")
+	}
 
-		// 如果成功下载了新视频，增加计数器
-		downloadedAfter := s.fileManager.IsVideoDownloaded(videoDir)
-		if downloadedAfter && !downloadedBefore {
-			s.incrementDownloadCounter(ctx)
-		}
+	// 为新下载预留下载计数（每N个视频后休息），休息期间不再开始新的视频
+	if err := s.acquireDownloadTurn(ctx, !videoDownloaded); err != nil {
+		result.err = err
+		result.interrupted = ctx.Err() != nil
+		result.deferred = errors.Is(err, ErrDownloadResting)
+		return result
 	}
 
-	return nil
+	// 调用公共的下载视频方法
+	err := s.downloadVideoAndSaveInfo(ctx, channelID, videoID, title, url, languages, videoMap)
+	// 如果成功下载了新视频，增加计数器
+	s.releaseDownloadTurn(ctx, !videoDownloaded, err == nil && s.fileManager.IsVideoDownloaded(videoDir))
+	if err != nil && ctx.Err() != nil {
+		log.Warn().Str("video_id", videoID).Msg("下载被中断")
+		result.err = err
+		result.interrupted = true
+		return result
+	}
+	if err != nil {
+		log.Error().Err(err).Str("title", title).Str("video_id", videoID).Msg("下载视频失败")
+		// 下载失败时，状态文件已经在 downloadVideoAndSaveInfo 中更新为 failed
+		result.err = err
+	}
+	return result
 }
 
 // generatePendingDownloads 生成待下载资源状态文件
@@ -890,6 +935,13 @@ func (s *downloadService) DownloadChannels(ctx context.Context) error {
 		}
 
 		if err := s.downloadFromChannelInfo(ctx, &channel); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, ErrDownloadResting) {
+				logger.Info().Err(err).Msg("进入休息，剩余频道在休息结束后的下一次运行中继续")
+				return nil
+			}
 			logger.Error().Err(err).Msg("下载频道失败")
 			continue
 		}
@@ -937,8 +989,7 @@ func (s *downloadService) DownloadSingleVideo(ctx context.Context, videoURL stri
 	}
 	args = append(args, videoURL)
 
-	cmd := exec.CommandContext(ctx, "yt-dlp", args...)
-	output, err := cmd.CombinedOutput()
+	output, err := s.fetchVideoMetadata(ctx, args)
 	var rawVideoData map[string]interface{}
 	if err == nil {
 		if err := json.Unmarshal(output, &rawVideoData); err != nil {
@@ -980,6 +1031,7 @@ func (s *downloadService) downloadVideoAndSaveInfo(
 	languages []string,
 	rawData map[string]interface{},
 ) error {
+	log := logger.Ctx(ctx)
 	// 查找或创建视频目录（使用视频ID）
 	var videoDir string
 	var err error
@@ -995,7 +1047,7 @@ func (s *downloadService) downloadVideoAndSaveInfo(
 
 	// 如果已上传，直接跳过（不再重新下载）
 	if s.fileManager.IsVideoUploaded(videoDir) {
-		logger.Info().
+		log.Info().
 			Str("video_id", videoID).
 			Str("video_dir", videoDir).
 			Msg("视频已上传，跳过下载与处理")
@@ -1007,7 +1059,7 @@ func (s *downloadService) downloadVideoAndSaveInfo(
 	var videoPath string
 	videoDownloaded := s.fileManager.IsVideoDownloaded(videoDir)
 
-	logger.Debug().
+	log.Debug().
 		Str("video_id", videoID).
 		Str("video_dir", videoDir).
 		Bool("video_downloaded", videoDownloaded).
@@ -1020,7 +1072,7 @@ func (s *downloadService) downloadVideoAndSaveInfo(
 		subsDownloadedEarly := s.fileManager.IsSubtitlesDownloaded(videoDir, checkLangs)
 		thumbDownloadedEarly := s.fileManager.IsThumbnailDownloaded(videoDir)
 		if !subsDownloadedEarly || !thumbDownloadedEarly {
-			logger.Info().
+			log.Info().
 				Str("video_id", videoID).
 				Bool("video_downloaded", videoDownloaded).
 				Bool("subtitles_downloaded_en", subsDownloadedEarly).
@@ -1039,8 +1091,8 @@ func (s *downloadService) downloadVideoAndSaveInfo(
 				_ = s.fileManager.InitializeDownloadStatus(videoDir, videoURL, subtitleURLs, languages, thumbnailURL)
 			}
 			// 统一调用下载器（不强制修改视频状态）
-			if _, err := s.downloader.DownloadVideo(ctx, channelID, videoURL, languages, title); err != nil {
-				logger.Warn().Err(err).Msg("统一下载补齐资源失败，后续将按缺失资源继续处理")
+			if _, err := s.fetchVideo(ctx, channelID, videoURL, languages, title); err != nil {
+				log.Warn().Err(err).Msg("统一下载补齐资源失败，后续将按缺失资源继续处理")
 			}
 		}
 	}
@@ -1099,10 +1151,10 @@ func (s *downloadService) downloadVideoAndSaveInfo(
 			// 注意：如果之前失败过，InitializeDownloadStatus 不会覆盖失败状态
 			// 即使没有字幕URL，也保存需要下载的语言列表
 			if err := s.fileManager.InitializeDownloadStatus(videoDir, videoURL, subtitleURLs, languages, thumbnailURL); err != nil {
-				logger.Warn().Err(err).Str("video_dir", videoDir).Msg("初始化下载状态文件失败")
+				log.Warn().Err(err).Str("video_dir", videoDir).Msg("初始化下载状态文件失败")
 			} else {
 				statusFile := filepath.Join(videoDir, "download_status.json")
-				logger.Info().
+				log.Info().
 					Str("status_file", statusFile).
 					Str("video_dir", videoDir).
 					Str("video_url", videoURL).
@@ -1112,29 +1164,33 @@ func (s *downloadService) downloadVideoAndSaveInfo(
 			}
 		}
 
-		logger.Info().Str("video_id", videoID).Msg("开始统一下载资源（视频/字幕/封面/信息）")
+		log.Info().Str("video_id", videoID).Msg("开始统一下载资源（视频/字幕/封面/信息）")
 		// 标记视频为 downloading 状态（重置之前的失败状态）
 		if err := s.fileManager.MarkVideoDownloading(videoDir, videoURL); err != nil {
-			logger.Warn().Err(err).Str("video_dir", videoDir).Msg("标记视频下载状态失败")
+			log.Warn().Err(err).Str("video_dir", videoDir).Msg("标记视频下载状态失败")
 		}
 
-		result, err := s.downloader.DownloadVideo(ctx, channelID, videoURL, languages, title)
+		result, err := s.fetchVideo(ctx, channelID, videoURL, languages, title)
+		if err != nil && ctx.Err() != nil {
+			s.rollbackInterruptedDownload(videoDir)
+			return ctx.Err()
+		}
 		if err != nil {
 			// 下载失败，根据配置决定是否清理部分下载的文件（.part, .ytdl 等）
 			if s.cfg != nil && s.cfg.YouTube.CleanupPartialFilesOnFailure {
 				if cleanupErr := s.fileManager.CleanupPartialFiles(videoDir); cleanupErr != nil {
-					logger.Warn().Err(cleanupErr).Str("video_dir", videoDir).Msg("清理部分下载文件失败")
+					log.Warn().Err(cleanupErr).Str("video_dir", videoDir).Msg("清理部分下载文件失败")
 				} else {
-					logger.Info().Str("video_dir", videoDir).Msg("已清理部分下载的文件")
+					log.Info().Str("video_dir", videoDir).Msg("已清理部分下载的文件")
 				}
 			} else {
-				logger.Debug().Str("video_dir", videoDir).Msg("下载失败，但配置为不清理部分下载文件（cleanup_partial_files_on_failure=false）")
+				log.Debug().Str("video_dir", videoDir).Msg("下载失败，但配置为不清理部分下载文件（cleanup_partial_files_on_failure=false）")
 			}
 
 			// 下载失败，更新状态为 failed
 			errorMsg := err.Error()
 			if markErr := s.fileManager.MarkVideoFailed(videoDir, errorMsg); markErr != nil {
-				logger.Warn().Err(markErr).Msg("标记下载失败状态失败")
+				log.Warn().Err(markErr).Msg("标记下载失败状态失败")
 			}
 			// 如果是 bot detection 错误，直接返回，不要包装，以便上层能正确检测
 			if errors.Is(err, youtube.ErrBotDetection) || strings.Contains(strings.ToLower(err.Error()), "bot detection") {
@@ -1152,42 +1208,46 @@ func (s *downloadService) downloadVideoAndSaveInfo(
 
 		// 标记视频已下载完成
 		if err := s.fileManager.MarkVideoDownloadedWithPath(videoDir, videoPath); err != nil {
-			logger.Warn().Err(err).Msg("标记视频下载状态失败")
+			log.Warn().Err(err).Msg("标记视频下载状态失败")
 		} else {
-			logger.Info().Str("video_path", videoPath).Msg("视频下载完成")
+			log.Info().Str("video_path", videoPath).Msg("视频下载完成")
 			s.fileManager.UpdatePendingDownloadStatus(channelID, videoID, "video", "completed", videoPath)
 		}
 	} else {
 		// 视频已下载，查找视频文件路径
 		if videoFile, err := s.fileManager.FindVideoFile(videoDir); err == nil {
 			videoPath = videoFile
-			logger.Info().Str("video_path", videoPath).Msg("视频已存在，跳过视频下载（仍处理字幕/封面/信息）")
+			log.Info().Str("video_path", videoPath).Msg("视频已存在，跳过视频下载（仍处理字幕/封面/信息）")
 		} else {
-			logger.Warn().Str("video_dir", videoDir).Msg("视频标记为已下载，但未找到视频文件")
+			log.Warn().Str("video_dir", videoDir).Msg("视频标记为已下载，但未找到视频文件")
 			// 处理异常状态：状态标记为完成但实际文件缺失，触发重新下载
-			logger.Info().Str("video_id", videoID).Msg("检测到视频文件缺失，准备重新下载该视频")
+			log.Info().Str("video_id", videoID).Msg("检测到视频文件缺失，准备重新下载该视频")
 			// 标记为 downloading，清除失败痕迹，并写入 url
 			if err := s.fileManager.MarkVideoDownloading(videoDir, videoURL); err != nil {
-				logger.Warn().Err(err).Str("video_dir", videoDir).Msg("标记视频下载状态失败")
+				log.Warn().Err(err).Str("video_dir", videoDir).Msg("标记视频下载状态失败")
 			}
 			// 执行下载
-			result, err := s.downloader.DownloadVideo(ctx, channelID, videoURL, languages, title)
+			result, err := s.fetchVideo(ctx, channelID, videoURL, languages, title)
+			if err != nil && ctx.Err() != nil {
+				s.rollbackInterruptedDownload(videoDir)
+				return ctx.Err()
+			}
 			if err != nil {
 				// 下载失败，根据配置决定是否清理部分下载的文件（.part, .ytdl 等）
 				if s.cfg != nil && s.cfg.YouTube.CleanupPartialFilesOnFailure {
 					if cleanupErr := s.fileManager.CleanupPartialFiles(videoDir); cleanupErr != nil {
-						logger.Warn().Err(cleanupErr).Str("video_dir", videoDir).Msg("清理部分下载文件失败")
+						log.Warn().Err(cleanupErr).Str("video_dir", videoDir).Msg("清理部分下载文件失败")
 					} else {
-						logger.Info().Str("video_dir", videoDir).Msg("已清理部分下载的文件")
+						log.Info().Str("video_dir", videoDir).Msg("已清理部分下载的文件")
 					}
 				} else {
-					logger.Debug().Str("video_dir", videoDir).Msg("下载失败，但配置为不清理部分下载文件（cleanup_partial_files_on_failure=false）")
+					log.Debug().Str("video_dir", videoDir).Msg("下载失败，但配置为不清理部分下载文件（cleanup_partial_files_on_failure=false）")
 				}
 
 				// 下载失败，更新状态为 failed
 				errorMsg := err.Error()
 				if markErr := s.fileManager.MarkVideoFailed(videoDir, errorMsg); markErr != nil {
-					logger.Warn().Err(markErr).Msg("标记下载失败状态失败")
+					log.Warn().Err(markErr).Msg("标记下载失败状态失败")
 				}
 				return fmt.Errorf("下载视频失败: %w", err)
 			}
@@ -1199,9 +1259,9 @@ func (s *downloadService) downloadVideoAndSaveInfo(
 			}
 			// 标记视频已下载完成并更新 pending
 			if err := s.fileManager.MarkVideoDownloadedWithPath(videoDir, videoPath); err != nil {
-				logger.Warn().Err(err).Msg("标记视频下载状态失败")
+				log.Warn().Err(err).Msg("标记视频下载状态失败")
 			} else {
-				logger.Info().Str("video_path", videoPath).Msg("视频下载完成（文件缺失后重新下载）")
+				log.Info().Str("video_path", videoPath).Msg("视频下载完成（文件缺失后重新下载）")
 				s.fileManager.UpdatePendingDownloadStatus(channelID, videoID, "video", "completed", videoPath)
 			}
 		}
@@ -1223,14 +1283,13 @@ func (s *downloadService) downloadVideoAndSaveInfo(
 		}
 		args = append(args, videoURL)
 
-		cmd := exec.CommandContext(ctx, "yt-dlp", args...)
-		output, err := cmd.CombinedOutput()
+		output, err := s.fetchVideoMetadata(ctx, args)
 		if err == nil {
 			if err := json.Unmarshal(output, &rawData); err != nil {
-				logger.Warn().Err(err).Msg("解析完整视频信息失败")
+				log.Warn().Err(err).Msg("解析完整视频信息失败")
 			}
 		} else {
-			logger.Warn().Err(err).Msg("获取完整视频信息失败")
+			log.Warn().Err(err).Msg("获取完整视频信息失败")
 		}
 	}
 
@@ -1245,7 +1304,7 @@ func (s *downloadService) downloadVideoAndSaveInfo(
 	subtitlesDownloaded := s.fileManager.IsSubtitlesDownloaded(videoDir, languages)
 	subtitleMap := make(map[string]string) // 用于保存视频信息
 	if !subtitlesDownloaded {
-		logger.Info().Str("video_id", videoID).Strs("languages", languages).Msg("检查并整理字幕（已在统一下载中请求）")
+		log.Info().Str("video_id", videoID).Strs("languages", languages).Msg("检查并整理字幕（已在统一下载中请求）")
 
 		// 优先使用本地已下载的字幕文件，避免再次请求网络
 		if existingSubs, err := s.fileManager.FindSubtitleFiles(videoDir); err == nil && len(existingSubs) > 0 {
@@ -1284,9 +1343,9 @@ func (s *downloadService) downloadVideoAndSaveInfo(
 
 			if len(downloadedLanguages) > 0 {
 				if err := s.fileManager.MarkSubtitlesDownloadedWithPaths(videoDir, downloadedLanguages, subtitlePaths, subtitleMap); err != nil {
-					logger.Warn().Err(err).Msg("标记字幕下载状态失败（本地）")
+					log.Warn().Err(err).Msg("标记字幕下载状态失败（本地）")
 				} else {
-					logger.Info().Strs("languages", downloadedLanguages).Msg("已从本地文件整理字幕并保存状态")
+					log.Info().Strs("languages", downloadedLanguages).Msg("已从本地文件整理字幕并保存状态")
 					for _, lang := range downloadedLanguages {
 						subPath := subtitlePaths[lang]
 						s.fileManager.UpdatePendingDownloadStatus(channelID, videoID, lang, "completed", subPath)
@@ -1326,15 +1385,15 @@ func (s *downloadService) downloadVideoAndSaveInfo(
 			}
 			if !found {
 				if err := s.fileManager.MarkSubtitleFailed(videoDir, lang, "统一下载后仍未找到该语言的字幕文件"); err != nil {
-					logger.Warn().Str("lang", lang).Err(err).Msg("标记字幕失败状态失败")
+					log.Warn().Str("lang", lang).Err(err).Msg("标记字幕失败状态失败")
 				} else {
-					logger.Warn().Str("lang", lang).Msg("字幕缺失，已标记为失败")
+					log.Warn().Str("lang", lang).Msg("字幕缺失，已标记为失败")
 				}
 				s.fileManager.UpdatePendingDownloadStatus(channelID, videoID, lang, "failed", "")
 			}
 		}
 	} else {
-		logger.Info().Str("video_id", videoID).Msg("字幕已下载，跳过")
+		log.Info().Str("video_id", videoID).Msg("字幕已下载，跳过")
 		// 即使字幕已下载，也需要获取字幕信息用于保存 video_info.json
 		// 这里不强制网络请求，保留空的 subtitleMap（或后续载入 video_info.json 时补全）
 	}
@@ -1347,29 +1406,29 @@ SUBTITLES_DONE:
 	hasCover := false
 
 	if !thumbnailDownloaded {
-		logger.Info().Str("video_id", videoID).Msg("检查封面图（已在统一下载中请求）")
+		log.Info().Str("video_id", videoID).Msg("检查封面图（已在统一下载中请求）")
 
 		thumbnailURL := ""
 		if len(thumbnails) > 0 {
 			thumbnailURL = thumbnails[len(thumbnails)-1].URL // 使用最后一个缩略图
 		}
 
-		downloadedCoverPath, err := s.downloadThumbnails(ctx, videoDir, rawData)
+		downloadedCoverPath, err := s.fetchThumbnail(ctx, videoDir, rawData)
 		if err != nil {
-			logger.Warn().Err(err).Msg("下载缩略图失败")
+			log.Warn().Err(err).Msg("下载缩略图失败")
 			s.fileManager.UpdatePendingDownloadStatus(channelID, videoID, "thumbnail", "failed", "")
 		} else if downloadedCoverPath != "" {
 			coverPath = downloadedCoverPath
 			if err := s.fileManager.MarkThumbnailDownloadedWithPath(videoDir, coverPath, thumbnailURL); err != nil {
-				logger.Warn().Err(err).Msg("标记缩略图下载状态失败")
+				log.Warn().Err(err).Msg("标记缩略图下载状态失败")
 			} else {
-				logger.Info().Str("cover_path", coverPath).Msg("缩略图已下载为 cover.{ext} 格式")
+				log.Info().Str("cover_path", coverPath).Msg("缩略图已下载为 cover.{ext} 格式")
 				s.fileManager.UpdatePendingDownloadStatus(channelID, videoID, "thumbnail", "completed", coverPath)
 				hasCover = true
 			}
 		}
 	} else {
-		logger.Info().Str("video_id", videoID).Msg("缩略图已下载，检查封面图")
+		log.Info().Str("video_id", videoID).Msg("缩略图已下载，检查封面图")
 		// 检查是否存在 cover.{ext} 文件（可能是 .jpg, .png, .webp 等）
 		possibleExtensions := []string{".jpg", ".jpeg", ".png", ".webp", ".gif"}
 		for _, ext := range possibleExtensions {
@@ -1377,7 +1436,7 @@ SUBTITLES_DONE:
 			if _, err := os.Stat(potentialCoverPath); err == nil {
 				coverPath = potentialCoverPath
 				hasCover = true
-				logger.Info().Str("cover_path", coverPath).Msg("封面图已存在")
+				log.Info().Str("cover_path", coverPath).Msg("封面图已存在")
 				break
 			}
 		}
@@ -1388,10 +1447,10 @@ SUBTITLES_DONE:
 				// 将旧的 thumbnail.jpg 重命名为 cover.jpg
 				coverPath = filepath.Join(videoDir, "cover.jpg")
 				if err := os.Rename(thumbnailPath, coverPath); err != nil {
-					logger.Warn().Err(err).Msg("重命名旧缩略图失败")
+					log.Warn().Err(err).Msg("重命名旧缩略图失败")
 				} else {
 					hasCover = true
-					logger.Info().Str("cover_path", coverPath).Msg("已将旧缩略图重命名为 cover.jpg")
+					log.Info().Str("cover_path", coverPath).Msg("已将旧缩略图重命名为 cover.jpg")
 				}
 			}
 		}
@@ -1404,30 +1463,30 @@ SUBTITLES_DONE:
 			coverPath = filepath.Join(videoDir, "cover.jpg")
 		}
 		if _, err := os.Stat(coverPath); os.IsNotExist(err) {
-			logger.Info().Str("video_id", videoID).Str("video_path", videoPath).Msg("缩略图不存在，开始从视频首帧生成封面图")
+			log.Info().Str("video_id", videoID).Str("video_path", videoPath).Msg("缩略图不存在，开始从视频首帧生成封面图")
 			if err := s.generateCoverFromVideo(ctx, videoDir, videoPath); err != nil {
-				logger.Warn().Err(err).Str("video_path", videoPath).Msg("生成封面图失败，尝试使用默认封面图")
+				log.Warn().Err(err).Str("video_path", videoPath).Msg("生成封面图失败，尝试使用默认封面图")
 				// 生成失败，尝试使用默认封面图
-				if err := s.useDefaultCover(videoDir, coverPath); err != nil {
-					logger.Warn().Err(err).Str("video_path", videoPath).Msg("使用默认封面图失败，继续处理其他任务")
+				if err := s.useDefaultCover(ctx, videoDir, coverPath); err != nil {
+					log.Warn().Err(err).Str("video_path", videoPath).Msg("使用默认封面图失败，继续处理其他任务")
 				} else {
-					logger.Info().Str("cover_path", coverPath).Msg("已使用默认封面图")
+					log.Info().Str("cover_path", coverPath).Msg("已使用默认封面图")
 				}
 			} else {
 				// 验证封面图是否成功创建
 				if _, err := os.Stat(coverPath); err == nil {
-					logger.Info().Str("cover_path", coverPath).Msg("封面图已从视频首帧生成")
+					log.Info().Str("cover_path", coverPath).Msg("封面图已从视频首帧生成")
 				} else {
-					logger.Warn().Str("cover_path", coverPath).Msg("封面图生成后文件不存在，尝试使用默认封面图")
-					if err := s.useDefaultCover(videoDir, coverPath); err != nil {
-						logger.Warn().Err(err).Msg("使用默认封面图失败")
+					log.Warn().Str("cover_path", coverPath).Msg("封面图生成后文件不存在，尝试使用默认封面图")
+					if err := s.useDefaultCover(ctx, videoDir, coverPath); err != nil {
+						log.Warn().Err(err).Msg("使用默认封面图失败")
 					} else {
-						logger.Info().Str("cover_path", coverPath).Msg("已使用默认封面图")
+						log.Info().Str("cover_path", coverPath).Msg("已使用默认封面图")
 					}
 				}
 			}
 		} else {
-			logger.Info().Str("cover_path", coverPath).Msg("封面图已存在，跳过生成")
+			log.Info().Str("cover_path", coverPath).Msg("封面图已存在，跳过生成")
 		}
 	}
 
@@ -1445,14 +1504,14 @@ SUBTITLES_DONE:
 				// 使用 ffprobe 获取分辨率
 				if w, h, ok := s.detectImageResolution(p); ok {
 					if h < minHeight {
-						logger.Warn().
+						log.Warn().
 							Str("cover_path", p).
 							Int("width", w).
 							Int("height", h).
 							Int("min_height", minHeight).
 							Msg("封面分辨率低于阈值，改用视频首帧生成高清封面")
 						if err := s.generateCoverFromVideo(ctx, videoDir, videoPath); err != nil {
-							logger.Warn().Err(err).Msg("从视频生成高清封面失败，保留低分辨率封面")
+							log.Warn().Err(err).Msg("从视频生成高清封面失败，保留低分辨率封面")
 						}
 					}
 				}
@@ -1470,13 +1529,13 @@ SUBTITLES_DONE:
 
 		// 保存视频信息（videoDir 已经在上面获取了）
 		if err := s.fileManager.SaveVideoInfo(videoDir, videoInfo); err != nil {
-			logger.Warn().Err(err).Msg("保存视频信息失败")
+			log.Warn().Err(err).Msg("保存视频信息失败")
 			// 不返回错误，因为视频可能已经下载，只是保存信息失败
 		} else {
-			logger.Info().Str("info_file", filepath.Join(videoDir, "video_info.json")).Msg("视频信息已保存")
+			log.Info().Str("info_file", filepath.Join(videoDir, "video_info.json")).Msg("视频信息已保存")
 		}
 	} else {
-		logger.Debug().Str("video_id", videoID).Msg("视频未下载完成，跳过保存视频信息（避免误判为已下载）")
+		log.Debug().Str("video_id", videoID).Msg("视频未下载完成，跳过保存视频信息（避免误判为已下载）")
 	}
 
 	return nil
@@ -1569,6 +1628,7 @@ func (s *downloadService) urlExists(ctx context.Context, client *http.Client, ur
 
 // downloadThumbnails 下载视频缩略图，保存为 cover.{ext} 格式
 func (s *downloadService) downloadThumbnails(ctx context.Context, videoDir string, rawData map[string]interface{}) (string, error) {
+	log := logger.Ctx(ctx)
 	if rawData == nil {
 		return "", nil
 	}
@@ -1580,13 +1640,13 @@ func (s *downloadService) downloadThumbnails(ctx context.Context, videoDir strin
 		if info, err := os.Stat(jpgPath); err == nil {
 			// 检查文件大小，确保不为空
 			if info.Size() > 0 {
-				logger.Info().Str("thumbnail_path", jpgPath).Int64("size", info.Size()).Msg("检测到 yt-dlp 已下载的 JPG 缩略图，直接使用")
+				log.Info().Str("thumbnail_path", jpgPath).Int64("size", info.Size()).Msg("检测到 yt-dlp 已下载的 JPG 缩略图，直接使用")
 				if err := s.fileManager.MarkThumbnailDownloadedWithPath(videoDir, jpgPath, ""); err != nil {
-					logger.Warn().Err(err).Msg("标记缩略图下载状态失败")
+					log.Warn().Err(err).Msg("标记缩略图下载状态失败")
 				}
 				return jpgPath, nil
 			} else {
-				logger.Warn().Str("thumbnail_path", jpgPath).Msg("检测到 yt-dlp 缩略图文件存在但大小为0，将重新下载")
+				log.Warn().Str("thumbnail_path", jpgPath).Msg("检测到 yt-dlp 缩略图文件存在但大小为0，将重新下载")
 				// 删除空文件，继续后续下载流程
 				_ = os.Remove(jpgPath)
 			}
@@ -1645,7 +1705,7 @@ func (s *downloadService) downloadThumbnails(ctx context.Context, videoDir strin
 	}
 	thumbnail := chosen
 	if thumbnail.Width > 0 && thumbnail.Height > 0 {
-		logger.Info().
+		log.Info().
 			Int("width", thumbnail.Width).
 			Int("height", thumbnail.Height).
 			Str("url", thumbnail.URL).
@@ -1680,11 +1740,11 @@ func (s *downloadService) downloadThumbnails(ctx context.Context, videoDir strin
 		accept = "image/webp"
 	}
 	// 使用 curl 严格检查 HTTP 错误（-f: HTTP error fail, -L: 跟随重定向, -S: 显示错误）
-	cmd := exec.CommandContext(ctx, "curl", "-fsSL", "-H", "Accept: "+accept, "-o", tempPath, thumbnail.URL)
+	cmd := utils.CommandContext(ctx, "curl", "-fsSL", "-H", "Accept: "+accept, "-o", tempPath, thumbnail.URL)
 	if err := cmd.Run(); err != nil {
 		// 如果 curl 失败，尝试使用 wget
 		// wget 默认对 404 返回非0
-		cmd = exec.CommandContext(ctx, "wget", "--header=Accept: "+accept, "-O", tempPath, thumbnail.URL)
+		cmd = utils.CommandContext(ctx, "wget", "--header=Accept: "+accept, "-O", tempPath, thumbnail.URL)
 		if err := cmd.Run(); err != nil {
 			_ = os.Remove(tempPath)
 			return "", fmt.Errorf("下载缩略图失败: %w", err)
@@ -1719,9 +1779,9 @@ func (s *downloadService) downloadThumbnails(ctx context.Context, videoDir strin
 				args = append(args, "-q:v", "2")
 			}
 			args = append(args, targetPath)
-			if out, convErr := exec.CommandContext(ctx, "ffmpeg", args...).CombinedOutput(); convErr != nil {
+			if out, convErr := utils.CommandContext(ctx, "ffmpeg", args...).CombinedOutput(); convErr != nil {
 				// 转码失败则回退为直接重命名到实际扩展
-				logger.Warn().Err(convErr).Str("output", string(out)).Str("from", actualExt).Str("to", ext).Msg("封面转码失败，回退为实际格式")
+				log.Warn().Err(convErr).Str("output", string(out)).Str("from", actualExt).Str("to", ext).Msg("封面转码失败，回退为实际格式")
 				fallbackPath := filepath.Join(videoDir, "cover"+actualExt)
 				_ = os.Rename(tempPath, fallbackPath)
 				coverPath = fallbackPath
@@ -1732,7 +1792,7 @@ func (s *downloadService) downloadThumbnails(ctx context.Context, videoDir strin
 			}
 		} else {
 			// 无转码工具，直接按实际格式保存
-			logger.Warn().Str("desired_ext", ext).Str("actual_ext", actualExt).Msg("未检测到 ffmpeg，按实际格式保存封面图")
+			log.Warn().Str("desired_ext", ext).Str("actual_ext", actualExt).Msg("未检测到 ffmpeg，按实际格式保存封面图")
 			fallbackPath := filepath.Join(videoDir, "cover"+actualExt)
 			if err := os.Rename(tempPath, fallbackPath); err != nil {
 				os.Remove(tempPath)
@@ -1749,7 +1809,7 @@ func (s *downloadService) downloadThumbnails(ctx context.Context, videoDir strin
 		}
 	}
 
-	logger.Info().Str("cover_path", coverPath).Msg("缩略图已下载为 cover.{ext} 格式")
+	log.Info().Str("cover_path", coverPath).Msg("缩略图已下载为 cover.{ext} 格式")
 	return coverPath, nil
 }
 
@@ -1847,9 +1907,10 @@ func (s *downloadService) detectImageResolution(filePath string) (int, int, bool
 
 // generateCoverFromVideo 从视频第一帧生成封面图
 func (s *downloadService) generateCoverFromVideo(ctx context.Context, videoDir, videoPath string) error {
+	log := logger.Ctx(ctx)
 	// 检查是否有 ffmpeg
 	if _, err := exec.LookPath("ffmpeg"); err != nil {
-		logger.Debug().Msg("未检测到 ffmpeg，跳过生成封面图")
+		log.Debug().Msg("未检测到 ffmpeg，跳过生成封面图")
 		return nil
 	}
 
@@ -1867,7 +1928,7 @@ func (s *downloadService) generateCoverFromVideo(ctx context.Context, videoDir,
 	// -vframes 1: 只提取 1 帧
 	// -q:v 2: 高质量 JPEG（1-31，数字越小质量越高，2 是高质量）
 	// 如果快速定位失败，尝试不使用 -ss（让 ffmpeg 自动处理）
-	cmd := exec.CommandContext(ctx, "ffmpeg",
+	cmd := utils.CommandContext(ctx, "ffmpeg",
 		"-ss", "0",
 		"-i", videoPath,
 		"-vframes", "1",
@@ -1879,8 +1940,8 @@ func (s *downloadService) generateCoverFromVideo(ctx context.Context, videoDir,
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		// 如果快速定位失败，尝试不使用 -ss（某些格式可能不支持快速定位）
-		logger.Debug().Str("video_path", videoPath).Msg("快速定位失败，尝试不使用 -ss 参数")
-		cmd = exec.CommandContext(ctx, "ffmpeg",
+		log.Debug().Str("video_path", videoPath).Msg("快速定位失败，尝试不使用 -ss 参数")
+		cmd = utils.CommandContext(ctx, "ffmpeg",
 			"-i", videoPath,
 			"-vframes", "1",
 			"-q:v", "2",
@@ -1890,8 +1951,8 @@ func (s *downloadService) generateCoverFromVideo(ctx context.Context, videoDir,
 		output, err = cmd.CombinedOutput()
 		if err != nil {
 			// 如果还是失败，尝试更简单的方式（不指定质量）
-			logger.Debug().Str("video_path", videoPath).Str("error", err.Error()).Msg("标准方式失败，尝试简化参数")
-			cmd = exec.CommandContext(ctx, "ffmpeg",
+			log.Debug().Str("video_path", videoPath).Str("error", err.Error()).Msg("标准方式失败，尝试简化参数")
+			cmd = utils.CommandContext(ctx, "ffmpeg",
 				"-i", videoPath,
 				"-vframes", "1",
 				"-y",
@@ -1917,7 +1978,7 @@ func (s *downloadService) generateCoverFromVideo(ctx context.Context, videoDir,
 		return fmt.Errorf("封面图文件大小为0，生成失败")
 	}
 
-	logger.Info().
+	log.Info().
 		Str("cover_path", coverPath).
 		Str("video_path", videoPath).
 		Msg("已从视频第一帧生成封面图")
@@ -1926,7 +1987,8 @@ func (s *downloadService) generateCoverFromVideo(ctx context.Context, videoDir,
 }
 
 // useDefaultCover 使用默认封面图（从 assets/default_cover.jpg 复制到视频目录）
-func (s *downloadService) useDefaultCover(videoDir, targetPath string) error {
+func (s *downloadService) useDefaultCover(ctx context.Context, videoDir, targetPath string) error {
+	log := logger.Ctx(ctx)
 	// 尝试多个可能的 assets 目录位置
 	possiblePaths := []string{
 		"./assets/default_cover.jpg", // 当前工作目录
@@ -1972,7 +2034,7 @@ func (s *downloadService) useDefaultCover(videoDir, targetPath string) error {
 		return fmt.Errorf("复制默认封面图失败: %w", err)
 	}
 
-	logger.Info().
+	log.Info().
 		Str("default_cover", defaultCoverPath).
 		Str("target_path", targetPath).
 		Msg("已复制默认封面图到视频目录")
@@ -12,11 +12,17 @@ import (
 	"blueberry/internal/config"
 	"blueberry/internal/repository/youtube"
 	"blueberry/pkg/logger"
+	"blueberry/pkg/utils"
 )
 
 // DownloadChannel 下载指定频道的所有视频
 // channelDir: 频道目录路径（例如：downloads/Comic-likerhythm）
 func (s *downloadService) DownloadChannel(ctx context.Context, channelDir string) error {
+	return s.DownloadChannelWithHooks(ctx, channelDir, DownloadHooks{})
+}
+
+// DownloadChannelWithHooks 下载指定频道的所有视频，并在处理每个视频前后调用 hooks
+func (s *downloadService) DownloadChannelWithHooks(ctx context.Context, channelDir string, hooks DownloadHooks) error {
 	// 从目录路径中提取频道ID（目录名就是频道ID）
 	channelID := filepath.Base(channelDir)
 
@@ -61,35 +67,13 @@ func (s *downloadService) DownloadChannel(ctx context.Context, channelDir string
 	// 获取频道语言配置
 	languages := s.getChannelLanguages(channel)
 
-	// 检查是否在下载限制的休息期间
-	if inRest, restUntil, err := s.fileManager.IsInRestPeriod(); err == nil {
-		if inRest {
-			logger.Info().
-				Time("rest_until", restUntil).
-				Dur("remaining", time.Until(restUntil)).
-				Msg("检测到正在休息期间（下载限制），等待休息结束")
-			s.sleepUntilRestEnd(ctx, restUntil)
-			// 休息结束后，计数器已重置，继续下载
-			logger.Info().Msg("休息结束，继续下载")
-		}
-	}
-
-	// 检查是否在 bot detection 休息期间
-	restBase := 180 // 默认3小时 = 180分钟
-	if s.cfg != nil && s.cfg.YouTube.BotDetectionRestDuration > 0 {
-		restBase = s.cfg.YouTube.BotDetectionRestDuration
-	}
-	if inBotRest, botRestUntil, err := s.fileManager.IsInBotDetectionRestPeriod(restBase); err == nil {
-		if inBotRest {
-			logger.Info().
-				Time("rest_until", botRestUntil).
-				Dur("remaining", time.Until(botRestUntil)).
-				Int("rest_duration_minutes", restBase).
-				Msg("检测到正在休息期间（bot detection），等待休息结束")
-			s.sleepUntilRestEnd(ctx, botRestUntil)
-			// 休息结束后，继续下载
-			logger.Info().Msg("Bot detection 休息结束，继续下载")
-		}
+	// 处于下载限制 / bot detection 休息期间时不开始下载，由下一次运行（daemon 顺延到休息结束）继续
+	if restUntil, resting := s.downloadRestUntil(); resting {
+		logger.Info().
+			Time("rest_until", restUntil).
+			Dur("remaining", time.Until(restUntil)).
+			Msg("检测到正在休息期间，本次不下载")
+		return restingError(restUntil)
 	}
 
 	// 加载当前下载计数
@@ -101,122 +85,168 @@ func (s *downloadService) DownloadChannel(ctx context.Context, channelDir string
 			Msg("开始下载，当前下载计数")
 	}
 
-	// 遍历每个视频，逐个下载
-	for i, videoMap := range videoMaps {
-		videoID, _ := videoMap["id"].(string)
-		title, _ := videoMap["title"].(string)
-		url, _ := videoMap["url"].(string)
-		if url == "" {
-			if videoID != "" {
-				url = fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID)
-			}
-		}
+	// 按配置的 worker 数量处理视频（默认 1，即逐个下载）
+	workers := downloadWorkers(s.cfg)
+	if workers > 1 {
+		logger.Info().
+			Int("workers", workers).
+			Str("channel_id", channelID).
+			Msg("并发下载频道视频")
+	}
+	results := make([]videoJobResult, len(videoMaps))
+	utils.RunWorkers(ctx, workers, len(videoMaps), func(ctx context.Context, worker, i int) {
+		ctx = logger.WithContext(ctx, workerLogger(worker, i, len(videoMaps)))
+		results[i] = s.downloadChannelVideo(ctx, channelDir, channelID, languages, videoMaps[i], i == len(videoMaps)-1, hooks)
+	})
+	logVideoJobResults(channelID, results)
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if restUntil, resting := s.downloadRestUntil(); resting {
+		return restingError(restUntil)
+	}
+	return nil
+}
 
-		if videoID == "" {
-			logger.Warn().Int("index", i+1).Msg("视频ID为空，跳过")
-			continue
+// downloadChannelVideo 下载频道中的单个视频（DownloadChannel 的 worker 任务）
+func (s *downloadService) downloadChannelVideo(ctx context.Context, channelDir, channelID string, languages []string, videoMap map[string]interface{}, isLast bool, hooks DownloadHooks) videoJobResult {
+	log := logger.Ctx(ctx)
+	videoID, _ := videoMap["id"].(string)
+	title, _ := videoMap["title"].(string)
+	url, _ := videoMap["url"].(string)
+	if url == "" {
+		if videoID != "" {
+			url = fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID)
 		}
+	}
 
-		logger.Info().
-			Int("current", i+1).
-			Int("total", len(videoMaps)).
-			Str("video_id", videoID).
-			Str("title", title).
-			Msg("处理视频")
-
-		// 若之前被标记为“不可下载”，按配置决定是否跳过
-		videoDir := filepath.Join(channelDir, videoID)
-		if st, dl, errMsg, e := s.fileManager.GetDownloadVideoStatus(videoDir); e == nil {
-			if !s.cfg.YouTube.ForceDownloadUndownloadable && st == "failed" && !dl &&
-				(strings.Contains(errMsg, "不可下载") || strings.Contains(errMsg, "未找到可用格式")) {
-				logger.Warn().
-					Str("video_id", videoID).
-					Str("video_dir", videoDir).
-					Str("error", errMsg).
-					Msg("此前标记为不可下载，按配置跳过此视频（可开启 youtube.force_download_undownloadable 强制下载）")
-				continue
-			}
-		}
+	if videoID == "" {
+		log.Warn().Msg("视频ID为空，跳过")
+		return videoJobResult{}
+	}
+	result := videoJobResult{videoID: videoID}
 
-		// 检查是否达到下载限制（每N个视频后休息）
-		if s.isDownloadLimitReached() {
-			logger.Warn().
-				Int("current_count", s.dailyDownloadCount).
-				Int("limit", s.getDownloadLimit()).
-				Msg("达到下载限制，准备休息")
-			s.restAfterLimit(ctx)
-			// 休息结束后，计数器已重置，继续下载
-			logger.Info().Msg("休息结束，继续下载下一个视频")
-		}
+	log.Info().
+		Str("video_id", videoID).
+		Str("title", title).
+		Msg("处理视频")
 
-		// 使用统一的下载逻辑
-		// 判断调用前后是否真的触发了下载（用于决定是否添加间隔）
-		downloadedBefore := s.fileManager.IsVideoDownloaded(videoDir)
-		if err := s.downloadVideoAndSaveInfo(ctx, channelID, videoID, title, url, languages, videoMap); err != nil {
-			// 检查是否是 bot detection 错误
-			isBotErr := s.isBotDetectionError(err)
-			logger.Debug().
+	// 若之前被标记为“不可下载”，按配置决定是否跳过
+	videoDir := filepath.Join(channelDir, videoID)
+	if st, dl, errMsg, e := s.fileManager.GetDownloadVideoStatus(videoDir); e == nil {
+		if !s.cfg.YouTube.ForceDownloadUndownloadable && st == "failed" && !dl &&
+			(strings.Contains(errMsg, "不可下载") || strings.Contains(errMsg, "未找到可用格式")) {
+			log.Warn().
 				Str("video_id", videoID).
-				Bool("is_bot_detection_error", isBotErr).
-				Str("error_string", err.Error()).
-				Msg("检查下载错误类型")
-
-			if isBotErr {
-				logger.Info().
-					Str("video_id", videoID).
-					Str("title", title).
-					Err(err).
-					Msg("检测到 bot detection 错误，开始处理")
-				s.handleBotDetection(ctx)
-				logger.Info().
-					Str("video_id", videoID).
-					Msg("handleBotDetection 函数返回，继续处理下一个视频")
-			}
-			logger.Error().
+				Str("video_dir", videoDir).
+				Str("error", errMsg).
+				Msg("此前标记为不可下载，按配置跳过此视频（可开启 youtube.force_download_undownloadable 强制下载）")
+			result.skipped = true
+			return result
+		}
+	}
+
+	// 其他 worker 触发休息后，不再进入 BeforeVideo（可能阻塞等待上传队列）
+	if _, resting := s.downloadRestUntil(); resting {
+		result.deferred = true
+		return result
+	}
+
+	if hooks.BeforeVideo != nil && !hooks.BeforeVideo(ctx, videoDir) {
+		result.skipped = true
+		return result
+	}
+
+	// 为新下载预留下载计数（每N个视频后休息），休息期间不再开始新的视频
+	// 判断调用前后是否真的触发了下载（用于计数与决定是否添加间隔）
+	downloadedBefore := s.fileManager.IsVideoDownloaded(videoDir)
+	if err := s.acquireDownloadTurn(ctx, !downloadedBefore); err != nil {
+		result.err = err
+		result.interrupted = ctx.Err() != nil
+		result.deferred = errors.Is(err, ErrDownloadResting)
+		return result
+	}
+
+	// 使用统一的下载逻辑
+	err := s.downloadVideoAndSaveInfo(ctx, channelID, videoID, title, url, languages, videoMap)
+	downloadedAfter := err == nil && s.fileManager.IsVideoDownloaded(videoDir)
+	// 如果成功下载了新视频，增加计数器
+	s.releaseDownloadTurn(ctx, !downloadedBefore, downloadedAfter)
+	if err != nil && ctx.Err() != nil {
+		log.Warn().Str("video_id", videoID).Msg("下载被中断")
+		result.err = err
+		result.interrupted = true
+		return result
+	}
+	if err != nil {
+		// 检查是否是 bot detection 错误
+		isBotErr := s.isBotDetectionError(err)
+		log.Debug().
+			Str("video_id", videoID).
+			Bool("is_bot_detection_error", isBotErr).
+			Str("error_string", err.Error()).
+			Msg("检查下载错误类型")
+
+		if isBotErr {
+			log.Info().
 				Str("video_id", videoID).
 				Str("title", title).
-				Bool("is_bot_detection", isBotErr).
 				Err(err).
-				Msg("下载视频失败，继续处理下一个")
-			continue
+				Msg("检测到 bot detection 错误，开始处理")
+			s.onBotDetection(ctx)
+			log.Info().
+				Str("video_id", videoID).
+				Msg("handleBotDetection 函数返回，继续处理下一个视频")
 		}
+		log.Error().
+			Str("video_id", videoID).
+			Str("title", title).
+			Bool("is_bot_detection", isBotErr).
+			Err(err).
+			Msg("下载视频失败，继续处理下一个")
+		result.err = err
+		return result
+	}
 
-		// 如果成功下载了新视频，增加计数器
-		downloadedAfter := s.fileManager.IsVideoDownloaded(videoDir)
-		if downloadedAfter && !downloadedBefore {
-			s.incrementDownloadCounter(ctx)
-		}
+	if hooks.OnReady != nil && downloadedAfter {
+		hooks.OnReady(ctx, videoDir)
+	}
 
-		// 在下载每个视频后添加延迟，避免触发 429 错误
-		// 字幕下载已经通过 --sleep-subtitles 参数添加了延迟，这里再添加一个整体延迟
-		// 使用配置的 sleep_interval_seconds 作为基础值，加上 0%-50% 的随机变化
-		if i < len(videoMaps)-1 && downloadedAfter && !downloadedBefore {
-			baseDelay := 3 * time.Second // 默认值
-			if s.cfg != nil && s.cfg.YouTube.SleepIntervalSeconds > 0 {
-				baseDelay = time.Duration(s.cfg.YouTube.SleepIntervalSeconds) * time.Second
-			}
-			// 添加 0%-50% 的随机变化
-			rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-			randomFactor := 1.0 + rng.Float64()*0.5 // 1.0 到 1.5 之间的随机值
-			delay := time.Duration(float64(baseDelay) * randomFactor)
-			logger.Debug().Dur("delay", delay).Dur("base_delay", baseDelay).Float64("random_factor", randomFactor).Msg("下载完成，等待后继续下一个视频")
-			time.Sleep(delay)
+	// 在下载每个视频后添加延迟，避免触发 429 错误
+	// 字幕下载已经通过 --sleep-subtitles 参数添加了延迟，这里再添加一个整体延迟
+	// 使用配置的 sleep_interval_seconds 作为基础值，加上 0%-50% 的随机变化（每个 worker 各自等待）
+	if !isLast && downloadedAfter && !downloadedBefore {
+		baseDelay := 3 * time.Second // 默认值
+		if s.cfg != nil && s.cfg.YouTube.SleepIntervalSeconds > 0 {
+			baseDelay = time.Duration(s.cfg.YouTube.SleepIntervalSeconds) * time.Second
+		}
+		// 添加 0%-50% 的随机变化
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		randomFactor := 1.0 + rng.Float64()*0.5 // 1.0 到 1.5 之间的随机值
+		delay := time.Duration(float64(baseDelay) * randomFactor)
+		log.Debug().Dur("delay", delay).Dur("base_delay", baseDelay).Float64("random_factor", randomFactor).Msg("下载完成，等待后继续下一个视频")
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
 		}
 	}
 
-	return nil
+	return result
 }
 
 // incrementDownloadCounter 增加下载计数器（持久化到文件）
+// 达到限制后的休息由 acquireDownloadTurn 在领取下一个视频前处理；调用方需持有 gate.mu
 func (s *downloadService) incrementDownloadCounter(ctx context.Context) {
+	log := logger.Ctx(ctx)
 	// 先获取当前计数
 	oldCount, _ := s.fileManager.GetTodayDownloadCount()
 
 	err := s.fileManager.IncrementTodayDownloadCount()
 	if err != nil {
-		logger.Warn().Err(err).Msg("保存下载计数失败，使用内存计数器")
+		log.Warn().Err(err).Msg("保存下载计数失败，使用内存计数器")
 		s.dailyDownloadCount++
-		logger.Info().
+		log.Info().
 			Int("old_count", oldCount).
 			Int("new_count", s.dailyDownloadCount).
 			Int("limit", s.getDownloadLimit()).
@@ -226,7 +256,7 @@ func (s *downloadService) incrementDownloadCounter(ctx context.Context) {
 		if loadErr == nil {
 			s.dailyDownloadCount = count
 			remaining := s.getDownloadLimit() - s.dailyDownloadCount
-			logger.Info().
+			log.Info().
 				Int("old_count", oldCount).
 				Int("new_count", s.dailyDownloadCount).
 				Int("limit", s.getDownloadLimit()).
@@ -234,58 +264,14 @@ func (s *downloadService) incrementDownloadCounter(ctx context.Context) {
 				Msg("下载计数更新（已保存到文件）")
 		} else {
 			s.dailyDownloadCount++
-			logger.Warn().Err(loadErr).Msg("加载下载计数失败，使用内存计数器")
-			logger.Info().
+			log.Warn().Err(loadErr).Msg("加载下载计数失败，使用内存计数器")
+			log.Info().
 				Int("old_count", oldCount).
 				Int("new_count", s.dailyDownloadCount).
 				Int("limit", s.getDownloadLimit()).
 				Msg("下载计数更新（内存计数器）")
 		}
 	}
-
-	// 检查是否达到限制
-	if s.isDownloadLimitReached() {
-		s.restAfterLimit(ctx)
-	}
-}
-
-// isDownloadLimitReached 检查是否达到下载限制（每N个视频后休息）
-func (s *downloadService) isDownloadLimitReached() bool {
-	limit := s.getDownloadLimit()
-	if limit <= 0 {
-		logger.Debug().Int("limit", limit).Msg("下载限制已禁用")
-		return false // 0 或负数表示不限制
-	}
-	// 优先从文件加载，确保准确性
-	count, err := s.fileManager.GetTodayDownloadCount()
-	if err != nil {
-		logger.Warn().Err(err).Msg("加载下载计数失败，使用内存计数器")
-		// 如果加载失败，使用内存计数器
-		reached := s.dailyDownloadCount >= limit
-		if reached {
-			logger.Info().
-				Int("count", s.dailyDownloadCount).
-				Int("limit", limit).
-				Msg("达到下载限制（使用内存计数器）")
-		}
-		return reached
-	}
-	// 更新内存计数器
-	s.dailyDownloadCount = count
-	reached := count >= limit
-	if reached {
-		logger.Info().
-			Int("count", count).
-			Int("limit", limit).
-			Msg("达到下载限制（从文件加载）")
-	} else {
-		logger.Debug().
-			Int("count", count).
-			Int("limit", limit).
-			Int("remaining", limit-count).
-			Msg("检查下载限制：未达到")
-	}
-	return reached
 }
 
 // getDownloadLimit 获取下载限制（每N个视频后休息）
@@ -297,8 +283,9 @@ func (s *downloadService) getDownloadLimit() int {
 	return 40
 }
 
-// restAfterLimit 达到限制后休息
-func (s *downloadService) restAfterLimit(ctx context.Context) {
+// startLimitRest 达到限制后记录休息窗口（不在任务内等待），返回休息截止时间
+func (s *downloadService) startLimitRest(ctx context.Context) time.Time {
+	log := logger.Ctx(ctx)
 	// 获取休息时长配置
 	restBase := 120 // 默认2小时 = 120分钟
 	if s.cfg != nil && s.cfg.YouTube.VideoLimitRestDuration > 0 {
@@ -312,7 +299,7 @@ func (s *downloadService) restAfterLimit(ctx context.Context) {
 	restDuration := time.Duration(restMinutes) * time.Minute
 	restUntil := time.Now().Add(restDuration)
 
-	logger.Warn().
+	log.Warn().
 		Int("download_count", s.dailyDownloadCount).
 		Int("limit", s.getDownloadLimit()).
 		Int("rest_base_minutes", restBase).
@@ -323,81 +310,15 @@ func (s *downloadService) restAfterLimit(ctx context.Context) {
 
 	// 保存休息时间到文件
 	if err := s.fileManager.SetDownloadRestUntil(restUntil, restMinutes); err != nil {
-		logger.Warn().Err(err).Msg("保存休息时间失败")
+		log.Warn().Err(err).Msg("保存休息时间失败")
 	} else {
-		logger.Info().
+		log.Info().
 			Time("rest_until", restUntil).
 			Int("rest_minutes", restMinutes).
 			Msg("休息时间已保存到文件")
 	}
 
-	// 休息
-	timer := time.NewTimer(restDuration)
-	defer timer.Stop()
-
-	select {
-	case <-ctx.Done():
-		logger.Info().Msg("休息被取消")
-		return
-	case <-timer.C:
-		// 验证计数器已重置
-		count, countErr := s.fileManager.GetTodayDownloadCount()
-		if countErr == nil {
-			logger.Info().
-				Int("reset_count", count).
-				Msg("休息结束，下载计数器已重置，继续下载")
-		} else {
-			logger.Info().Err(countErr).Msg("休息结束，继续下载")
-		}
-		// 计数器已在 SetDownloadRestUntil 中重置
-		return
-	}
-}
-
-// sleepUntilRestEnd 休眠到休息结束时间
-func (s *downloadService) sleepUntilRestEnd(ctx context.Context, restUntil time.Time) {
-	duration := time.Until(restUntil)
-	if duration <= 0 {
-		logger.Info().
-			Time("rest_until", restUntil).
-			Msg("休息时间已过，继续下载")
-		// 确保计数器已重置
-		if err := s.fileManager.ResetTodayDownloadCount(); err != nil {
-			logger.Warn().Err(err).Msg("重置下载计数器失败")
-		} else {
-			logger.Info().Msg("已重置下载计数器")
-		}
-		return
-	}
-
-	logger.Info().
-		Dur("sleep_duration", duration).
-		Time("wake_up_time", restUntil).
-		Str("duration_hours", fmt.Sprintf("%.2f", duration.Hours())).
-		Msg("检测到休息期间，等待休息结束")
-
-	timer := time.NewTimer(duration)
-	defer timer.Stop()
-
-	select {
-	case <-ctx.Done():
-		logger.Info().Msg("休息被取消")
-		return
-	case <-timer.C:
-		// 验证计数器已重置
-		count, countErr := s.fileManager.GetTodayDownloadCount()
-		if countErr == nil {
-			logger.Info().
-				Int("reset_count", count).
-				Time("rest_until", restUntil).
-				Msg("休息结束，下载计数器已重置，继续下载")
-		} else {
-			logger.Info().
-				Time("rest_until", restUntil).
-				Msg("休息结束，继续下载")
-		}
-		return
-	}
+	return restUntil
 }
 
 // isBotDetectionError 检查错误是否是 bot detection
@@ -413,49 +334,51 @@ func (s *downloadService) isBotDetectionError(err error) bool {
 		errors.Is(err, youtube.ErrBotDetection)
 }
 
-// handleBotDetection 处理 bot detection，累计计数并在达到阈值时休息
+// handleBotDetection 处理 bot detection，累计计数并在达到阈值时记录休息开始时间
+// 休息结束时 IsInBotDetectionRestPeriod 会清零计数
 func (s *downloadService) handleBotDetection(ctx context.Context) {
-	logger.Info().Msg("开始处理 bot detection（handleBotDetection 函数被调用）")
+	log := logger.Ctx(ctx)
+	log.Info().Msg("开始处理 bot detection（handleBotDetection 函数被调用）")
 
 	// 获取触发阈值配置
 	threshold := 3 // 默认3次
 	if s.cfg != nil && s.cfg.YouTube.BotDetectionThreshold > 0 {
 		threshold = s.cfg.YouTube.BotDetectionThreshold
 	}
-	logger.Debug().Int("threshold", threshold).Msg("Bot detection 阈值配置")
+	log.Debug().Int("threshold", threshold).Msg("Bot detection 阈值配置")
 
 	// 从文件加载当前计数
 	currentCount, err := s.fileManager.GetBotDetectionCount()
-	logger.Debug().Int("current_count_before", currentCount).Err(err).Msg("加载 bot detection 计数")
+	log.Debug().Int("current_count_before", currentCount).Err(err).Msg("加载 bot detection 计数")
 	if err != nil {
-		logger.Warn().Err(err).Msg("加载 bot detection 计数失败，使用内存计数器")
+		log.Warn().Err(err).Msg("加载 bot detection 计数失败，使用内存计数器")
 		s.botDetectionCount++
 		currentCount = s.botDetectionCount
-		logger.Debug().Int("memory_count", currentCount).Msg("使用内存计数器")
+		log.Debug().Int("memory_count", currentCount).Msg("使用内存计数器")
 	} else {
 		// 增加计数并保存到文件
-		logger.Debug().Int("count_before_increment", currentCount).Msg("准备增加 bot detection 计数")
+		log.Debug().Int("count_before_increment", currentCount).Msg("准备增加 bot detection 计数")
 		if err := s.fileManager.IncrementBotDetectionCount(); err != nil {
-			logger.Warn().Err(err).Msg("保存 bot detection 计数失败，使用内存计数器")
+			log.Warn().Err(err).Msg("保存 bot detection 计数失败，使用内存计数器")
 			s.botDetectionCount++
 			currentCount = s.botDetectionCount
-			logger.Debug().Int("memory_count", currentCount).Msg("使用内存计数器（保存失败）")
+			log.Debug().Int("memory_count", currentCount).Msg("使用内存计数器（保存失败）")
 		} else {
 			// 重新加载以获取最新值
 			if newCount, loadErr := s.fileManager.GetBotDetectionCount(); loadErr == nil {
-				logger.Debug().Int("old_count", currentCount).Int("new_count", newCount).Msg("成功增加并重新加载计数")
+				log.Debug().Int("old_count", currentCount).Int("new_count", newCount).Msg("成功增加并重新加载计数")
 				currentCount = newCount
 				s.botDetectionCount = newCount
 			} else {
-				logger.Warn().Err(loadErr).Msg("重新加载 bot detection 计数失败，使用内存计数器")
+				log.Warn().Err(loadErr).Msg("重新加载 bot detection 计数失败，使用内存计数器")
 				s.botDetectionCount++
 				currentCount = s.botDetectionCount
-				logger.Debug().Int("memory_count", currentCount).Msg("使用内存计数器（重新加载失败）")
+				log.Debug().Int("memory_count", currentCount).Msg("使用内存计数器（重新加载失败）")
 			}
 		}
 	}
 
-	logger.Warn().
+	log.Warn().
 		Int("bot_detection_count", currentCount).
 		Int("threshold", threshold).
 		Int("remaining", threshold-currentCount).
@@ -464,10 +387,7 @@ func (s *downloadService) handleBotDetection(ctx context.Context) {
 	// 达到阈值时，休息
 	if currentCount >= threshold {
 		// 获取休息时长配置
-		restBase := 180 // 默认3小时 = 180分钟
-		if s.cfg != nil && s.cfg.YouTube.BotDetectionRestDuration > 0 {
-			restBase = s.cfg.YouTube.BotDetectionRestDuration
-		}
+		restBase := botDetectionRestMinutes(s.cfg)
 
 		// 生成随机休息时间：基础时间 + 0-10% 的随机变化
 		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
@@ -475,7 +395,7 @@ func (s *downloadService) handleBotDetection(ctx context.Context) {
 		restMinutes := int(float64(restBase) * randomFactor)
 		restDuration := time.Duration(restMinutes) * time.Minute
 
-		logger.Warn().
+		log.Warn().
 			Int("bot_detection_count", currentCount).
 			Int("threshold", threshold).
 			Int("rest_base_minutes", restBase).
@@ -488,59 +408,26 @@ func (s *downloadService) handleBotDetection(ctx context.Context) {
 		restStartTime := time.Now()
 		// 保存休息开始时间到文件
 		if err := s.fileManager.SetBotDetectionRestStart(restStartTime); err != nil {
-			logger.Warn().Err(err).Msg("保存 bot detection 休息开始时间失败")
+			log.Warn().Err(err).Msg("保存 bot detection 休息开始时间失败")
 		}
-		logger.Info().
+		log.Info().
 			Time("rest_start", restStartTime).
 			Int("rest_minutes", restMinutes).
 			Int("rest_hours", restMinutes/60).
 			Dur("rest_duration", restDuration).
-			Msg("开始休息，等待 bot detection 冷却期结束")
-
-		timer := time.NewTimer(restDuration)
-		defer timer.Stop()
-
-		// 添加心跳日志（每5分钟输出一次）
-		heartbeatTicker := time.NewTicker(5 * time.Minute)
-		defer heartbeatTicker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				logger.Info().Msg("休息被取消")
-				return
-			case <-heartbeatTicker.C:
-				elapsed := time.Since(restStartTime)
-				remaining := restDuration - elapsed
-				if remaining > 0 {
-					logger.Info().
-						Dur("elapsed", elapsed).
-						Dur("remaining", remaining).
-						Msg("Bot detection 休息中（心跳日志）")
-				}
-			case <-timer.C:
-				logger.Info().Msg("休息结束，重置 bot detection 计数器")
-				// 重置计数器（保存到文件）
-				if err := s.fileManager.ResetBotDetectionCount(); err != nil {
-					logger.Warn().Err(err).Msg("重置 bot detection 计数失败")
-				} else {
-					s.botDetectionCount = 0
-					logger.Info().Msg("Bot detection 计数器已重置")
-				}
-				return
-			}
-		}
+			Msg("开始休息，等待 bot detection 冷却期结束后的下一次运行")
+		return
 	}
 
 	// 未达到阈值，继续下载
-	logger.Info().
+	log.Info().
 		Int("current_count", currentCount).
 		Int("threshold", threshold).
 		Int("remaining", threshold-currentCount).
 		Msg("Bot detection 未达到阈值，继续下载")
 
 	// 未达到阈值，继续下载
-	logger.Info().
+	log.Info().
 		Int("current_count", currentCount).
 		Int("threshold", threshold).
 		Int("remaining", threshold-currentCount).
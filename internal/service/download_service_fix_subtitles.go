@@ -5,13 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"blueberry/internal/repository/file"
 	"blueberry/internal/repository/youtube"
 	"blueberry/pkg/logger"
+	"blueberry/pkg/utils"
 )
 
 // SubtitleStatus 字幕状态
@@ -1183,9 +1184,14 @@ func (s *downloadService) downloadSubtitlesOnly(ctx context.Context, videoDir, v
 		Str("command", cmdStr).
 		Msg("执行字幕下载命令")
 
-	// 执行命令
-	cmd := exec.CommandContext(ctx, "yt-dlp", args...)
+	// 执行命令（占用字幕并发预算）
+	release, err := s.limiter.acquire(ctx, file.ResourceTypeSubtitle)
+	if err != nil {
+		return nil, err
+	}
+	cmd := utils.CommandContext(ctx, youtube.YtDlpPath(s.cfg), args...)
 	output, err := cmd.CombinedOutput()
+	release()
 	if err != nil {
 		outputStr := string(output)
 		logger.Error().
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/internal/repository/youtube"
+	"blueberry/internal/repository/youtube/ytdlptest"
+)
+
+const testChannelURL = "https://www.youtube.com/@testchannel/videos"
+
+func TestMain(m *testing.M) {
+	// 由下载服务启动的替身 yt-dlp 进程在这里执行后退出
+	ytdlptest.Main()
+	os.Exit(m.Run())
+}
+
+// testEnv 使用替身 yt-dlp 的下载服务；PATH 中没有 ffmpeg
+type testEnv struct {
+	cfg      *config.Config
+	repo     file.Repository
+	download DownloadService
+	fake     *ytdlptest.Fake
+}
+
+// newTestEnv 加载测试配置：一个频道、一个 B 站账号、bot detection 阈值足够大（不触发休息）
+func newTestEnv(t *testing.T, channel config.YouTubeChannel, videos ...ytdlptest.Video) *testEnv {
+	t.Helper()
+	fixture := ytdlptest.Fixture{Channels: map[string][]string{}, Videos: videos}
+	for _, v := range videos {
+		fixture.Channels[testChannelURL] = append(fixture.Channels[testChannelURL], v.ID)
+	}
+	fake := ytdlptest.New(t, fixture)
+	t.Setenv("PATH", t.TempDir())
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	content := fmt.Sprintf(`output:
+  directory: %q
+youtube:
+  ytdlp_path: %q
+  bot_detection_threshold: 100
+youtube_channels:
+  - url: %q
+    limit: %d
+bilibili_accounts:
+  main:
+    username: "tester"
+    cookies_file: %q
+`, filepath.Join(dir, "downloads"), fake.Path, testChannelURL, channel.Limit, filepath.Join(dir, "main_cookies.json"))
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatalf("加载测试配置失败: %v", err)
+	}
+
+	repo := file.NewRepository(cfg.Output.Directory)
+	download := NewDownloadService(
+		youtube.NewDownloader(repo, "", ""),
+		youtube.NewParser("", ""),
+		youtube.NewSubtitleManager("", ""),
+		repo,
+		cfg,
+	)
+	return &testEnv{cfg: cfg, repo: repo, download: download, fake: fake}
+}
+
+// videoDir 创建已解析（只有 video_info.json）的视频目录
+func (e *testEnv) videoDir(t *testing.T, id, title string) string {
+	t.Helper()
+	dir, err := e.repo.EnsureVideoDir("testchannel", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &file.VideoInfo{ID: id, Title: title, URL: "https://www.youtube.com/watch?v=" + id}
+	if err := e.repo.SaveVideoInfo(dir, info); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func testContext(t *testing.T) context.Context {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func loadDownloadStatus(t *testing.T, repo file.Repository, videoDir string) *file.DownloadStatus {
+	t.Helper()
+	status, err := repo.LoadDownloadStatus(videoDir)
+	if err != nil {
+		t.Fatalf("读取下载状态失败: %v", err)
+	}
+	return status
+}
+
+func TestDownloadVideoDirSuccess(t *testing.T) {
+	env := newTestEnv(t, config.YouTubeChannel{}, ytdlptest.Video{
+		ID: "vid00000001", Title: "Hello World", Subtitles: []string{"en", "zh-Hans"},
+	})
+	dir := env.videoDir(t, "vid00000001", "Hello World")
+
+	if err := env.download.DownloadVideoDir(testContext(t), dir); err != nil {
+		t.Fatalf("DownloadVideoDir: %v", err)
+	}
+
+	status := loadDownloadStatus(t, env.repo, dir)
+	if !status.Video.Downloaded || filepath.Base(status.Video.FilePath) != "vid00000001_1080p.mp4" {
+		t.Fatalf("video status = %+v", status.Video)
+	}
+	if status.Video.Has1080p == nil || !*status.Video.Has1080p {
+		t.Fatal("应标记 has_1080p")
+	}
+	for _, lang := range []string{"en", "zh-Hans"} {
+		if sub := status.Subtitle(lang); !sub.Downloaded {
+			t.Fatalf("%s 字幕未标记为已下载: %+v", lang, sub)
+		}
+	}
+	// 视频没有的语言标记为失败，留给 fix-subtitles 处理
+	if sub := status.Subtitle("th"); sub.Downloaded || sub.Status != file.ResourceFailed {
+		t.Fatalf("th 字幕状态 = %+v", sub)
+	}
+	// 缩略图直接使用 yt-dlp 写出的 jpg，不访问网络
+	if status.Thumbnail == nil || !status.Thumbnail.Downloaded {
+		t.Fatalf("thumbnail status = %+v", status.Thumbnail)
+	}
+
+	// 已下载的视频不会再次调用 yt-dlp 下载
+	if err := env.download.DownloadVideoDir(testContext(t), dir); err != nil {
+		t.Fatalf("DownloadVideoDir（第二次）: %v", err)
+	}
+	if n := len(env.fake.Invocations(ytdlptest.ActionDownload)); n != 1 {
+		t.Fatalf("download invocations = %d, want 1", n)
+	}
+}
+
+func TestDownloadVideoDirHTTP403MarksFailed(t *testing.T) {
+	env := newTestEnv(t, config.YouTubeChannel{}, ytdlptest.Video{
+		ID: "vid00000403", Title: "Forbidden", Scenario: ytdlptest.ScenarioHTTP403,
+	})
+	dir := env.videoDir(t, "vid00000403", "Forbidden")
+
+	err := env.download.DownloadVideoDir(testContext(t), dir)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if errors.Is(err, youtube.ErrBotDetection) || !strings.Contains(err.Error(), "HTTP Error 403") {
+		t.Fatalf("err = %v, want 403", err)
+	}
+	status := loadDownloadStatus(t, env.repo, dir)
+	if status.Video.Downloaded || status.Video.Status != file.ResourceFailed || status.Video.Error == "" {
+		t.Fatalf("video status = %+v", status.Video)
+	}
+}
+
+func TestDownloadVideoDirBotCheck(t *testing.T) {
+	env := newTestEnv(t, config.YouTubeChannel{}, ytdlptest.Video{
+		ID: "vid00000bot", Title: "Bot", Scenario: ytdlptest.ScenarioBotCheck,
+	})
+	dir := env.videoDir(t, "vid00000bot", "Bot")
+
+	err := env.download.DownloadVideoDir(testContext(t), dir)
+	if !errors.Is(err, youtube.ErrBotDetection) {
+		t.Fatalf("err = %v, want ErrBotDetection", err)
+	}
+	if status := loadDownloadStatus(t, env.repo, dir); status.Video.Status != file.ResourceFailed {
+		t.Fatalf("video status = %+v", status.Video)
+	}
+}
+
+func TestFixSubtitlesForVideoDirDownloadsMissingLanguages(t *testing.T) {
+	env := newTestEnv(t, config.YouTubeChannel{}, ytdlptest.Video{
+		ID: "vid00000sub", Title: "Subtitles later", Subtitles: []string{"en"},
+	})
+	dir := env.videoDir(t, "vid00000sub", "Subtitles later")
+	if err := env.download.DownloadVideoDir(testContext(t), dir); err != nil {
+		t.Fatalf("DownloadVideoDir: %v", err)
+	}
+
+	// 之后 YouTube 上出现了泰语与简体中文字幕（仅有 VTT 也能被找到）
+	fake := ytdlptest.New(t, ytdlptest.Fixture{Videos: []ytdlptest.Video{{
+		ID: "vid00000sub", Title: "Subtitles later", Subtitles: []string{"en", "th", "zh-Hans"},
+	}}})
+	if err := env.download.FixSubtitlesForVideoDir(testContext(t), dir, false); err != nil {
+		t.Fatalf("FixSubtitlesForVideoDir: %v", err)
+	}
+
+	calls := fake.Invocations()
+	if len(calls) != 1 || calls[0].Action != ytdlptest.ActionSubtitles {
+		t.Fatalf("invocations = %+v, want one subtitles-only call", calls)
+	}
+	if langs := calls[0].Value("--sub-langs"); strings.Contains(langs, "en") || !strings.Contains(langs, "th") {
+		t.Fatalf("--sub-langs = %q，应只请求缺失的语言", langs)
+	}
+	for _, lang := range []string{"th", "zh-Hans"} {
+		if _, err := os.Stat(filepath.Join(dir, "vid00000sub."+lang+".srt")); err != nil {
+			t.Fatalf("%s 字幕未下载: %v", lang, err)
+		}
+	}
+
+	absOutputDir, _ := filepath.Abs(env.cfg.Output.Directory)
+	statusFile := newSubtitleStatusFile(absOutputDir)
+	if err := statusFile.load(); err != nil {
+		t.Fatal(err)
+	}
+	record := statusFile.getRecord(dir)
+	if record == nil {
+		t.Fatal("没有字幕状态记录")
+	}
+	want := map[string]SubtitleStatus{
+		"th":      SubtitleStatusDownloaded,
+		"zh-Hans": SubtitleStatusDownloaded,
+		"ms":      SubtitleStatusNotFound,
+		"zh-Hant": SubtitleStatusNotFound,
+	}
+	for lang, status := range want {
+		if got := record.Statuses[lang].Status; got != status {
+			t.Fatalf("%s status = %q, want %q", lang, got, status)
+		}
+	}
+
+	// 再次补充时 not_found 的语言不会重新请求
+	if err := env.download.FixSubtitlesForVideoDir(testContext(t), dir, false); err != nil {
+		t.Fatalf("FixSubtitlesForVideoDir（第二次）: %v", err)
+	}
+	if n := len(fake.Invocations()); n != 1 {
+		t.Fatalf("invocations = %d, want 1", n)
+	}
+}
+
+// 达到 video_limit_before_rest 时记录休息窗口并立即返回，而不是在任务内等待休息结束
+func TestAcquireDownloadTurnReturnsWhenLimitReached(t *testing.T) {
+	env := newTestEnv(t, config.YouTubeChannel{URL: testChannelURL})
+	env.cfg.YouTube.VideoLimitBeforeRest = 1
+	svc := env.download.(*downloadService)
+	if err := env.repo.IncrementTodayDownloadCount(); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	err := svc.acquireDownloadTurn(testContext(t), true)
+	if !errors.Is(err, ErrDownloadResting) {
+		t.Fatalf("err = %v, want ErrDownloadResting", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("acquireDownloadTurn 阻塞了 %s", elapsed)
+	}
+	restUntil, resting := DownloadRestUntil(env.cfg, env.repo)
+	if !resting || !restUntil.After(time.Now()) {
+		t.Fatalf("DownloadRestUntil = %v, %v，期望处于休息期间", restUntil, resting)
+	}
+	if err := svc.acquireDownloadTurn(testContext(t), false); !errors.Is(err, ErrDownloadResting) {
+		t.Fatalf("休息期间 err = %v, want ErrDownloadResting", err)
+	}
+}
+
+// bot detection 达到阈值后只记录休息开始时间，NotBefore 使用与配置默认值一致的休息时长
+func TestBotDetectionRestDoesNotBlock(t *testing.T) {
+	env := newTestEnv(t, config.YouTubeChannel{URL: testChannelURL})
+	env.cfg.YouTube.BotDetectionThreshold = 1
+	env.cfg.YouTube.BotDetectionRestDuration = 0
+	svc := env.download.(*downloadService)
+
+	svc.onBotDetection(testContext(t))
+	restUntil, resting := DownloadRestUntil(env.cfg, env.repo)
+	if !resting {
+		t.Fatal("bot detection 达到阈值后应处于休息期间")
+	}
+	if remaining := time.Until(restUntil); remaining < 359*time.Minute || remaining > 361*time.Minute {
+		t.Fatalf("休息剩余 %s，期望默认 360 分钟", remaining)
+	}
+	if err := svc.acquireDownloadTurn(testContext(t), true); !errors.Is(err, ErrDownloadResting) {
+		t.Fatalf("err = %v, want ErrDownloadResting", err)
+	}
+}
+
+// 等待进行中的下载时取消，acquireDownloadTurn 应立即返回
+func TestAcquireDownloadTurnObservesCancelWhileWaiting(t *testing.T) {
+	env := newTestEnv(t, config.YouTubeChannel{URL: testChannelURL})
+	env.cfg.YouTube.VideoLimitBeforeRest = 1
+	svc := env.download.(*downloadService)
+	svc.gate.inFlight = 1 // 另一个 worker 的下载进行中
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- svc.acquireDownloadTurn(ctx, true) }()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("取消后 acquireDownloadTurn 仍在等待")
+	}
+}
@@ -0,0 +1,750 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+)
+
+// fsck 问题代码（机器可读，用于 --fix 选择修复项）
+const (
+	FsckChannelInfoMissing    = "channel_info_missing"      // 频道目录缺少 channel_info.json
+	FsckChannelInfoInvalid    = "channel_info_invalid"      // channel_info.json 无法解析
+	FsckVideoInfoMissing      = "video_info_missing"        // 视频目录缺少 video_info.json
+	FsckVideoInfoInvalid      = "video_info_invalid"        // video_info.json 无法解析
+	FsckVideoNotInChannelInfo = "video_not_in_channel_info" // 视频不在 channel_info.json 中
+	FsckDownloadStatusMissing = "download_status_missing"   // 有视频文件但没有下载状态
+	FsckDownloadStatusInvalid = "download_status_invalid"   // 下载状态无法解析
+	FsckVideoFileMissing      = "video_file_missing"        // 下载状态为已完成但视频文件不存在（未上传）
+	FsckVideoFileUntracked    = "video_file_untracked"      // 视频文件已存在但下载状态未完成
+	FsckDownloadStuck         = "download_stuck"            // 下载状态长期停留在 downloading
+	FsckSubtitleFileMissing   = "subtitle_file_missing"     // 字幕状态为已完成但字幕文件不存在（未上传）
+	FsckSubtitleFileUntracked = "subtitle_file_untracked"   // 字幕文件已存在但字幕状态未完成
+	FsckCoverMissing          = "cover_missing"             // 已下载的视频找不到封面图
+	FsckTempFiles             = "temp_files"                // 残留的 .part / .temp / 原子写入临时文件
+	FsckUploadStatusInvalid   = "upload_status_invalid"     // 上传状态无法解析
+	FsckUploadStuck           = "upload_stuck"              // 上传状态长期停留在 uploading
+	FsckUploadWithoutDownload = "upload_without_download"   // 已上传但下载状态未完成
+	FsckUploadMissingAID      = "upload_missing_aid"        // 已上传但没有记录 B站 AID
+	FsckStatusOrphaned        = "status_orphaned"           // 状态后端中有记录但视频目录不存在
+)
+
+// fsck 问题级别
+const (
+	FsckSeverityError   = "error"
+	FsckSeverityWarning = "warning"
+)
+
+// FsckCodeInfo 问题代码说明
+type FsckCodeInfo struct {
+	Code        string
+	Description string
+	Fixable     bool
+}
+
+// FsckCodes 所有问题代码及其修复方式（按检查顺序）
+var FsckCodes = []FsckCodeInfo{
+	{FsckChannelInfoMissing, "频道目录缺少 channel_info.json", false},
+	{FsckChannelInfoInvalid, "channel_info.json 无法解析", false},
+	{FsckVideoInfoMissing, "视频目录缺少 video_info.json", false},
+	{FsckVideoInfoInvalid, "video_info.json 无法解析", false},
+	{FsckVideoNotInChannelInfo, "视频不在 channel_info.json 中", false},
+	{FsckDownloadStatusMissing, "有视频文件但没有下载状态；修复：根据现有文件重建下载状态", true},
+	{FsckDownloadStatusInvalid, "下载状态无法解析；修复：根据现有文件重建下载状态", true},
+	{FsckVideoFileMissing, "下载状态为已完成但视频文件不存在；修复：标记为下载失败以便重新下载", true},
+	{FsckVideoFileUntracked, "视频文件已存在但下载状态未完成；修复：标记为已下载", true},
+	{FsckDownloadStuck, "下载状态长期停留在 downloading；修复：标记为下载失败", true},
+	{FsckSubtitleFileMissing, "字幕状态为已完成但字幕文件不存在；修复：标记该语言字幕下载失败", true},
+	{FsckSubtitleFileUntracked, "字幕文件已存在但字幕状态未完成；修复：标记该语言字幕已下载", true},
+	{FsckCoverMissing, "已下载的视频找不到封面图", false},
+	{FsckTempFiles, "残留的 .part / .temp / 原子写入临时文件；修复：删除", true},
+	{FsckUploadStatusInvalid, "上传状态无法解析（为避免重复上传不自动修复）", false},
+	{FsckUploadStuck, "上传状态长期停留在 uploading；修复：标记为上传失败", true},
+	{FsckUploadWithoutDownload, "已上传但下载状态未完成；修复：标记为已下载", true},
+	{FsckUploadMissingAID, "已上传但没有记录 B站 AID", false},
+	{FsckStatusOrphaned, "状态后端中有记录但视频目录不存在", false},
+}
+
+// FsckIssue 一处不一致
+type FsckIssue struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Channel  string `json:"channel,omitempty"`
+	VideoDir string `json:"video_dir,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Lang     string `json:"lang,omitempty"`
+	Message  string `json:"message"`
+	Fixable  bool   `json:"fixable"`
+}
+
+// FsckFix 一次修复的结果
+type FsckFix struct {
+	Issue   FsckIssue `json:"issue"`
+	Applied bool      `json:"applied"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// FsckDiff 修复前后的状态差异（dry-run 时展示）
+type FsckDiff struct {
+	Target string   `json:"target"`
+	Lines  []string `json:"lines"`
+}
+
+// FsckReport 检查/修复报告
+type FsckReport struct {
+	OutputDir     string      `json:"output_dir"`
+	StateBackend  string      `json:"state_backend"`
+	ChannelCount  int         `json:"channel_count"`
+	VideoDirCount int         `json:"video_dir_count"`
+	Issues        []FsckIssue `json:"issues"`
+	DryRun        bool        `json:"dry_run,omitempty"`
+	Fixes         []FsckFix   `json:"fixes,omitempty"`
+	Removed       []string    `json:"removed,omitempty"`
+	Diffs         []FsckDiff  `json:"diffs,omitempty"`
+}
+
+// Remaining 返回修复后仍然存在的问题数量
+func (r *FsckReport) Remaining() int {
+	if r.DryRun {
+		return len(r.Issues)
+	}
+	fixed := 0
+	for _, fix := range r.Fixes {
+		if fix.Applied {
+			fixed++
+		}
+	}
+	return len(r.Issues) - fixed
+}
+
+// FsckService 检查并修复下载目录与状态之间的不一致
+type FsckService interface {
+	// Check 遍历输出目录，交叉核对 channel_info / video_info / 下载状态 / 上传状态与实际文件（只读）
+	Check(ctx context.Context) (*FsckReport, error)
+
+	// Repair 先执行检查，再修复 codes 中列出的可修复问题
+	// dryRun 为 true 时状态修改只保存在内存中，不删除文件，报告中给出修改前后的差异
+	Repair(ctx context.Context, codes []string, dryRun bool) (*FsckReport, error)
+}
+
+type fsckService struct {
+	outputDir  string
+	store      file.StateStore
+	staleAfter time.Duration
+}
+
+// NewFsckService 创建 FsckService
+// staleAfter: 临时文件、downloading/uploading 状态超过该时长未更新才视为残留，避免误伤正在运行的下载/上传
+func NewFsckService(outputDir string, store file.StateStore, staleAfter time.Duration) FsckService {
+	return &fsckService{
+		outputDir:  outputDir,
+		store:      store,
+		staleAfter: staleAfter,
+	}
+}
+
+func (s *fsckService) Check(ctx context.Context) (*FsckReport, error) {
+	report := &FsckReport{
+		OutputDir:    s.outputDir,
+		StateBackend: s.store.Backend(),
+		Issues:       []FsckIssue{},
+	}
+	repo := file.NewRepositoryWithStore(s.outputDir, s.store)
+
+	channelEntries, err := os.ReadDir(s.outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("读取输出目录失败: %w", err)
+	}
+
+	s.checkTempFiles(report, "", filepath.Join(s.outputDir, ".global"), "")
+
+	videoDirs := make(map[string]bool)
+	for _, channelEntry := range channelEntries {
+		if !channelEntry.IsDir() || strings.HasPrefix(channelEntry.Name(), ".") {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		channel := channelEntry.Name()
+		channelDir := filepath.Join(s.outputDir, channel)
+		report.ChannelCount++
+
+		channelVideoIDs, hasChannelInfo := s.checkChannelInfo(report, repo, channel)
+		s.checkTempFiles(report, channel, channelDir, "")
+
+		videoEntries, err := os.ReadDir(channelDir)
+		if err != nil {
+			return nil, fmt.Errorf("读取频道目录失败 (%s): %w", channelDir, err)
+		}
+		for _, videoEntry := range videoEntries {
+			if !videoEntry.IsDir() || strings.HasPrefix(videoEntry.Name(), ".") {
+				continue
+			}
+			videoDir := filepath.Join(channelDir, videoEntry.Name())
+			videoDirs[videoDir] = true
+			report.VideoDirCount++
+			s.checkVideoDir(report, repo, channel, videoDir, channelVideoIDs, hasChannelInfo)
+		}
+	}
+
+	// 状态后端中存在、但目录已经不存在的记录（bolt 后端可能出现）
+	statusDirs, err := s.store.ListVideoDirs()
+	if err != nil {
+		return nil, fmt.Errorf("列出视频状态失败: %w", err)
+	}
+	for _, videoDir := range statusDirs {
+		if videoDirs[videoDir] {
+			continue
+		}
+		if _, err := os.Stat(videoDir); os.IsNotExist(err) {
+			report.add(FsckIssue{
+				Code:     FsckStatusOrphaned,
+				Severity: FsckSeverityWarning,
+				Channel:  filepath.Base(filepath.Dir(videoDir)),
+				VideoDir: videoDir,
+				Message:  "状态后端中有记录，但视频目录不存在",
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// checkChannelInfo 检查 channel_info.json，返回其中的视频 ID 集合
+func (s *fsckService) checkChannelInfo(report *FsckReport, repo file.Repository, channel string) (map[string]bool, bool) {
+	infoPath := filepath.Join(s.outputDir, channel, "channel_info.json")
+	if _, err := os.Stat(infoPath); os.IsNotExist(err) {
+		report.add(FsckIssue{
+			Code:     FsckChannelInfoMissing,
+			Severity: FsckSeverityWarning,
+			Channel:  channel,
+			Path:     infoPath,
+			Message:  "频道目录缺少 channel_info.json",
+		})
+		return nil, false
+	}
+	videos, err := repo.LoadChannelInfo(channel)
+	if err != nil {
+		report.add(FsckIssue{
+			Code:     FsckChannelInfoInvalid,
+			Severity: FsckSeverityError,
+			Channel:  channel,
+			Path:     infoPath,
+			Message:  err.Error(),
+		})
+		return nil, false
+	}
+	ids := make(map[string]bool, len(videos))
+	for _, video := range videos {
+		if id, ok := video["id"].(string); ok && id != "" {
+			ids[id] = true
+		}
+	}
+	return ids, true
+}
+
+func (s *fsckService) checkVideoDir(report *FsckReport, repo file.Repository, channel, videoDir string, channelVideoIDs map[string]bool, hasChannelInfo bool) {
+	issue := func(code, severity, message string) FsckIssue {
+		return FsckIssue{Code: code, Severity: severity, Channel: channel, VideoDir: videoDir, Message: message}
+	}
+
+	// video_info.json
+	infoPath := filepath.Join(videoDir, "video_info.json")
+	if _, err := os.Stat(infoPath); os.IsNotExist(err) {
+		i := issue(FsckVideoInfoMissing, FsckSeverityWarning, "视频目录缺少 video_info.json")
+		i.Path = infoPath
+		report.add(i)
+	} else if info, err := repo.LoadVideoInfo(videoDir); err != nil {
+		i := issue(FsckVideoInfoInvalid, FsckSeverityError, err.Error())
+		i.Path = infoPath
+		report.add(i)
+	} else if hasChannelInfo && info.ID != "" && !channelVideoIDs[info.ID] {
+		report.add(issue(FsckVideoNotInChannelInfo, FsckSeverityWarning,
+			fmt.Sprintf("视频 %s 不在 channel_info.json 中", info.ID)))
+	}
+
+	activeTemp := s.checkTempFiles(report, channel, videoDir, videoDir)
+	videoPath, _ := repo.FindVideoFile(videoDir)
+
+	// 上传状态
+	uploaded := false
+	uploadStatus, err := repo.LoadUploadStatus(videoDir)
+	switch {
+	case err == nil:
+		uploaded = uploadStatus.Status == file.UploadCompleted
+		s.checkUploadStatus(report, issue, uploadStatus)
+	case !os.IsNotExist(err):
+		report.add(issue(FsckUploadStatusInvalid, FsckSeverityError, err.Error()))
+	}
+
+	// 下载状态
+	downloadStatus, err := repo.LoadDownloadStatus(videoDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			report.add(issue(FsckDownloadStatusInvalid, FsckSeverityError, err.Error()))
+		} else if videoPath != "" && !activeTemp {
+			i := issue(FsckDownloadStatusMissing, FsckSeverityError, "存在视频文件但没有下载状态")
+			i.Path = videoPath
+			report.add(i)
+		}
+		return
+	}
+
+	video := downloadStatus.Video
+	switch {
+	case video.Status == file.ResourceCompleted && videoPath == "" && !uploaded:
+		i := issue(FsckVideoFileMissing, FsckSeverityError, "下载状态为已完成，但视频文件不存在")
+		i.Path = video.FilePath
+		report.add(i)
+	case video.Status != file.ResourceCompleted && videoPath != "" && !activeTemp:
+		i := issue(FsckVideoFileUntracked, FsckSeverityError,
+			fmt.Sprintf("视频文件已存在，但下载状态为 %q", video.Status))
+		i.Path = videoPath
+		report.add(i)
+	case video.Status == file.ResourceDownloading && !activeTemp && s.isStale(latestModTime(videoDir)):
+		report.add(issue(FsckDownloadStuck, FsckSeverityError,
+			fmt.Sprintf("下载状态停留在 downloading 且超过 %s 没有进展", s.staleAfter)))
+	}
+
+	if uploaded && video.Status != file.ResourceCompleted {
+		i := issue(FsckUploadWithoutDownload, FsckSeverityError,
+			fmt.Sprintf("已上传，但下载状态为 %q", video.Status))
+		i.Path = videoPath
+		report.add(i)
+	}
+
+	s.checkSubtitles(report, issue, videoDir, downloadStatus, uploaded, activeTemp)
+
+	if (video.Status == file.ResourceCompleted || videoPath != "") && !uploaded {
+		if findCover(videoDir, videoPath) == "" {
+			report.add(issue(FsckCoverMissing, FsckSeverityWarning,
+				"找不到封面图（与视频同名 .jpg、cover.{ext} 或 thumbnail.jpg）"))
+		}
+	}
+}
+
+func (s *fsckService) checkUploadStatus(report *FsckReport, issue func(code, severity, message string) FsckIssue, status *file.UploadStatus) {
+	switch status.Status {
+	case file.UploadCompleted:
+		if status.BilibiliAID == "" {
+			report.add(issue(FsckUploadMissingAID, FsckSeverityWarning, "已上传，但没有记录 bilibili_aid"))
+		}
+	case file.UploadUploading:
+		last := status.UpdatedAt
+		if status.StartedAt > last {
+			last = status.StartedAt
+		}
+		if s.isStale(time.Unix(last, 0)) {
+			report.add(issue(FsckUploadStuck, FsckSeverityError,
+				fmt.Sprintf("上传状态停留在 uploading 且超过 %s 没有更新", s.staleAfter)))
+		}
+	}
+}
+
+func (s *fsckService) checkSubtitles(report *FsckReport, issue func(code, severity, message string) FsckIssue, videoDir string, status *file.DownloadStatus, uploaded, activeTemp bool) {
+	// 按语言归类实际存在的字幕文件
+	filesByLang := make(map[string]string)
+	entries, _ := os.ReadDir(videoDir)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if lang := subtitleLangFromFileName(entry.Name()); lang != "" {
+			if _, ok := filesByLang[lang]; !ok {
+				filesByLang[lang] = filepath.Join(videoDir, entry.Name())
+			}
+		}
+	}
+
+	for _, lang := range status.SubtitleLanguages() {
+		sub := status.Subtitles[lang]
+		if sub.Status != file.ResourceCompleted || uploaded {
+			// 上传后字幕会被重命名为 {aid}_{lang}.srt，也可能被 organize 归档，不再检查
+			continue
+		}
+		if sub.FilePath != "" {
+			if _, err := os.Stat(sub.FilePath); err == nil {
+				continue
+			}
+		}
+		if _, ok := filesByLang[lang]; ok {
+			continue
+		}
+		i := issue(FsckSubtitleFileMissing, FsckSeverityError,
+			fmt.Sprintf("字幕 %s 状态为已完成，但字幕文件不存在", lang))
+		i.Lang = lang
+		i.Path = sub.FilePath
+		report.add(i)
+	}
+
+	if activeTemp {
+		return
+	}
+	langs := make([]string, 0, len(filesByLang))
+	for lang := range filesByLang {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		if sub, ok := status.Subtitles[lang]; ok && sub.Status == file.ResourceCompleted {
+			continue
+		}
+		i := issue(FsckSubtitleFileUntracked, FsckSeverityWarning,
+			fmt.Sprintf("字幕 %s 文件已存在，但字幕状态未完成", lang))
+		i.Lang = lang
+		i.Path = filesByLang[lang]
+		report.add(i)
+	}
+}
+
+// checkTempFiles 报告目录中残留的临时文件，返回目录中是否存在仍在更新的临时文件（说明下载可能正在进行）
+func (s *fsckService) checkTempFiles(report *FsckReport, channel, dir, videoDir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	active := false
+	for _, entry := range entries {
+		if entry.IsDir() || !isTempFileName(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if !s.isStale(info.ModTime()) {
+			active = true
+			continue
+		}
+		report.add(FsckIssue{
+			Code:     FsckTempFiles,
+			Severity: FsckSeverityWarning,
+			Channel:  channel,
+			VideoDir: videoDir,
+			Path:     filepath.Join(dir, entry.Name()),
+			Message:  fmt.Sprintf("临时文件超过 %s 未更新", s.staleAfter),
+		})
+	}
+	return active
+}
+
+func (s *fsckService) isStale(t time.Time) bool {
+	return time.Since(t) > s.staleAfter
+}
+
+func (r *FsckReport) add(issue FsckIssue) {
+	issue.Fixable = fsckFixable(issue.Code)
+	r.Issues = append(r.Issues, issue)
+}
+
+func fsckFixable(code string) bool {
+	for _, info := range FsckCodes {
+		if info.Code == code {
+			return info.Fixable
+		}
+	}
+	return false
+}
+
+func (s *fsckService) Repair(ctx context.Context, codes []string, dryRun bool) (*FsckReport, error) {
+	report, err := s.Check(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.DryRun = dryRun
+
+	selected := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		selected[code] = true
+	}
+
+	// dry-run 时所有状态写入都落在内存叠加层上
+	store := s.store
+	if dryRun {
+		store = file.NewOverlayStateStore(s.store)
+	}
+	repo := file.NewRepositoryWithStore(s.outputDir, store)
+
+	touched := make(map[string]bool)
+	for _, issue := range report.Issues {
+		if !issue.Fixable || !selected[issue.Code] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		fix := FsckFix{Issue: issue}
+		if err := s.applyFix(repo, issue, dryRun, report); err != nil {
+			fix.Error = err.Error()
+			logger.Warn().Err(err).Str("code", issue.Code).Str("video_dir", issue.VideoDir).Msg("修复失败")
+		} else {
+			fix.Applied = true
+			if issue.VideoDir != "" && issue.Code != FsckTempFiles {
+				touched[issue.VideoDir] = true
+			}
+		}
+		report.Fixes = append(report.Fixes, fix)
+	}
+
+	if dryRun {
+		dirs := make([]string, 0, len(touched))
+		for dir := range touched {
+			dirs = append(dirs, dir)
+		}
+		sort.Strings(dirs)
+		for _, dir := range dirs {
+			report.Diffs = append(report.Diffs, statusDiffs(dir, s.store, store)...)
+		}
+	}
+	return report, nil
+}
+
+func (s *fsckService) applyFix(repo file.Repository, issue FsckIssue, dryRun bool, report *FsckReport) error {
+	const fixNote = "fsck: "
+	switch issue.Code {
+	case FsckDownloadStatusMissing, FsckDownloadStatusInvalid:
+		return rebuildDownloadStatus(repo, issue.VideoDir)
+	case FsckVideoFileMissing:
+		return repo.MarkVideoFailed(issue.VideoDir, fixNote+"视频文件缺失，需要重新下载")
+	case FsckVideoFileUntracked, FsckUploadWithoutDownload:
+		return repo.MarkVideoDownloadedWithPath(issue.VideoDir, issue.Path)
+	case FsckDownloadStuck:
+		return repo.MarkVideoFailed(issue.VideoDir, fixNote+"下载中断")
+	case FsckSubtitleFileMissing:
+		return repo.MarkSubtitleFailed(issue.VideoDir, issue.Lang, fixNote+"字幕文件缺失，需要重新下载")
+	case FsckSubtitleFileUntracked:
+		return repo.MarkSubtitlesDownloadedWithPaths(issue.VideoDir, []string{issue.Lang}, map[string]string{issue.Lang: issue.Path}, nil)
+	case FsckUploadStuck:
+		return repo.MarkVideoUploadFailed(issue.VideoDir, fixNote+"上传中断")
+	case FsckTempFiles:
+		report.Removed = append(report.Removed, issue.Path)
+		if dryRun {
+			return nil
+		}
+		if err := os.Remove(issue.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("删除临时文件失败: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("问题 %s 不支持自动修复", issue.Code)
+}
+
+// rebuildDownloadStatus 根据目录中实际存在的视频、字幕、封面文件重建下载状态
+func rebuildDownloadStatus(repo file.Repository, videoDir string) error {
+	if videoPath, err := repo.FindVideoFile(videoDir); err == nil {
+		if err := repo.MarkVideoDownloadedWithPath(videoDir, videoPath); err != nil {
+			return err
+		}
+	} else if err := repo.InitializeDownloadStatus(videoDir, "", nil, nil, ""); err != nil {
+		return err
+	}
+
+	subtitles, _ := repo.FindSubtitleFiles(videoDir)
+	var langs []string
+	paths := make(map[string]string)
+	for _, subPath := range subtitles {
+		lang := subtitleLangFromFileName(filepath.Base(subPath))
+		if lang == "" || paths[lang] != "" {
+			continue
+		}
+		langs = append(langs, lang)
+		paths[lang] = subPath
+	}
+	if len(langs) > 0 {
+		if err := repo.MarkSubtitlesDownloadedWithPaths(videoDir, langs, paths, nil); err != nil {
+			return err
+		}
+	}
+
+	videoPath, _ := repo.FindVideoFile(videoDir)
+	if coverPath := findCover(videoDir, videoPath); coverPath != "" {
+		if err := repo.MarkThumbnailDownloadedWithPath(videoDir, coverPath, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statusDiffs 对比修复前（base）与修复后（overlay）的状态
+func statusDiffs(videoDir string, before, after file.StateStore) []FsckDiff {
+	var diffs []FsckDiff
+	add := func(name string, oldValue, newValue interface{}) {
+		lines := diffLines(marshalForDiff(oldValue), marshalForDiff(newValue))
+		if len(lines) > 0 {
+			diffs = append(diffs, FsckDiff{Target: filepath.Join(videoDir, name), Lines: lines})
+		}
+	}
+
+	oldDownload, _ := before.LoadDownloadStatus(videoDir)
+	newDownload, _ := after.LoadDownloadStatus(videoDir)
+	add("download_status.json", oldDownload, newDownload)
+
+	oldUpload, _ := before.LoadUploadStatus(videoDir)
+	newUpload, _ := after.LoadUploadStatus(videoDir)
+	add("upload_status.json", oldUpload, newUpload)
+	return diffs
+}
+
+func marshalForDiff(v interface{}) []string {
+	switch t := v.(type) {
+	case *file.DownloadStatus:
+		if t == nil {
+			return nil
+		}
+	case *file.UploadStatus:
+		if t == nil {
+			return nil
+		}
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil
+	}
+	return strings.Split(string(data), "\n")
+}
+
+// diffLines 生成逐行差异（基于最长公共子序列），只保留变化行及其前后各 2 行上下文；无变化时返回 nil
+func diffLines(a, b []string) []string {
+	const contextLines = 2
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var all []string
+	changed := false
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			all = append(all, "  "+a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			all = append(all, "- "+a[i])
+			changed = true
+			i++
+		default:
+			all = append(all, "+ "+b[j])
+			changed = true
+			j++
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	keep := make([]bool, len(all))
+	for idx, line := range all {
+		if line[0] == ' ' {
+			continue
+		}
+		for k := idx - contextLines; k <= idx+contextLines; k++ {
+			if k >= 0 && k < len(all) {
+				keep[k] = true
+			}
+		}
+	}
+	var out []string
+	skipped := false
+	for idx, line := range all {
+		if !keep[idx] {
+			skipped = true
+			continue
+		}
+		if skipped && len(out) > 0 {
+			out = append(out, "  ...")
+		}
+		skipped = false
+		out = append(out, line)
+	}
+	return out
+}
+
+// findCover 按上传时的顺序查找封面：与视频同名 .jpg → cover.{ext} → thumbnail.jpg
+func findCover(videoDir, videoPath string) string {
+	var candidates []string
+	if videoPath != "" {
+		candidates = append(candidates, strings.TrimSuffix(videoPath, filepath.Ext(videoPath))+".jpg")
+	}
+	for _, ext := range []string{".jpg", ".jpeg", ".png", ".webp", ".gif"} {
+		candidates = append(candidates, filepath.Join(videoDir, "cover"+ext))
+	}
+	candidates = append(candidates, filepath.Join(videoDir, "thumbnail.jpg"))
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// subtitleLangPattern 字幕语言代码（en、zh-Hans、pt-BR 等）
+var subtitleLangPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// subtitleLangFromFileName 从字幕文件名中提取语言代码
+// 支持 {video_id}_{lang}.srt、{aid}_{lang}.srt、{title}.{lang}.vtt 等格式；无法识别时返回空字符串
+func subtitleLangFromFileName(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext != ".srt" && ext != ".vtt" && ext != ".ass" {
+		return ""
+	}
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	if strings.HasSuffix(stem, ".frame") {
+		return ""
+	}
+	for _, sep := range []string{".", "_"} {
+		if i := strings.LastIndex(stem, sep); i >= 0 {
+			if lang := stem[i+1:]; subtitleLangPattern.MatchString(lang) {
+				return lang
+			}
+		}
+	}
+	return ""
+}
+
+// isTempFileName 判断是否为临时文件：yt-dlp 的 .part / .ytdl / .temp，以及原子写入残留的 .{name}.tmp-*
+func isTempFileName(name string) bool {
+	return strings.Contains(name, ".part") ||
+		strings.Contains(name, ".ytdl") ||
+		strings.Contains(name, ".temp.") ||
+		strings.HasSuffix(name, ".temp") ||
+		(strings.HasPrefix(name, ".") && strings.Contains(name, ".tmp-"))
+}
+
+// latestModTime 返回目录及其中文件的最近修改时间
+func latestModTime(dir string) time.Time {
+	var latest time.Time
+	if info, err := os.Stat(dir); err == nil {
+		latest = info.ModTime()
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"sync"
+
+	"blueberry/pkg/logger"
+)
+
+// 被中断任务的类型
+const (
+	InterruptedDownload = "download"
+	InterruptedUpload   = "upload"
+)
+
+// InterruptedItem 收到 Ctrl+C / SIGTERM 时正在处理、已回滚为可恢复状态的视频
+type InterruptedItem struct {
+	Kind     string // download / upload
+	VideoDir string
+	Note     string // 回滚说明
+}
+
+var interrupted struct {
+	mu    sync.Mutex
+	items []InterruptedItem
+}
+
+// recordInterrupted 记录一条被中断的任务，供命令退出前打印汇总
+func recordInterrupted(kind, videoDir, note string) {
+	interrupted.mu.Lock()
+	defer interrupted.mu.Unlock()
+	interrupted.items = append(interrupted.items, InterruptedItem{Kind: kind, VideoDir: videoDir, Note: note})
+}
+
+// InterruptedItems 返回本进程内被中断并回滚的任务
+func InterruptedItems() []InterruptedItem {
+	interrupted.mu.Lock()
+	defer interrupted.mu.Unlock()
+	return append([]InterruptedItem(nil), interrupted.items...)
+}
+
+// rollbackInterruptedDownload 下载被取消：视频状态回滚为 pending，.part 等临时文件默认保留以便 yt-dlp 续传
+func (s *downloadService) rollbackInterruptedDownload(videoDir string) {
+	note := "已回滚为 pending，保留 .part 以便续传"
+	if s.cfg != nil && s.cfg.YouTube.CleanupPartialFilesOnFailure {
+		if err := s.fileManager.CleanupPartialFiles(videoDir); err != nil {
+			logger.Warn().Err(err).Str("video_dir", videoDir).Msg("清理部分下载文件失败")
+		}
+		note = "已回滚为 pending，已清理 .part（cleanup_partial_files_on_failure=true）"
+	}
+	if err := s.fileManager.MarkVideoInterrupted(videoDir); err != nil {
+		logger.Warn().Err(err).Str("video_dir", videoDir).Msg("回滚下载状态失败")
+		note = "回滚下载状态失败: " + err.Error()
+	}
+	logger.Warn().Str("video_dir", videoDir).Msg("下载被中断，" + note)
+	recordInterrupted(InterruptedDownload, videoDir, note)
+}
+
+// rollbackInterruptedUpload 上传被取消：上传状态回滚为 pending，下次运行重新上传，不计入失败
+func (s *uploadService) rollbackInterruptedUpload(videoDir string) {
+	note := "已回滚为 pending，下次运行重新上传"
+	if err := s.fileManager.MarkVideoUploadInterrupted(videoDir); err != nil {
+		logger.Warn().Err(err).Str("video_dir", videoDir).Msg("回滚上传状态失败")
+		note = "回滚上传状态失败: " + err.Error()
+	}
+	logger.Warn().Str("video_dir", videoDir).Msg("上传被中断，" + note)
+	recordInterrupted(InterruptedUpload, videoDir, note)
+}
@@ -0,0 +1,520 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+	"blueberry/pkg/utils"
+
+	"github.com/rs/zerolog"
+)
+
+// pipeline 运行阶段
+const (
+	// PipelineStageAll 同时运行下载（入队）与上传（消费队列）
+	PipelineStageAll = "all"
+	// PipelineStageDownload 只下载并入队，上传由其他进程消费
+	PipelineStageDownload = "download"
+	// PipelineStageUpload 只消费队列中已下载的视频
+	PipelineStageUpload = "upload"
+)
+
+// PipelineSummary pipeline 一次运行的统计
+type PipelineSummary struct {
+	Enqueued int `json:"enqueued"`
+	Uploaded int `json:"uploaded"`
+	// Retried 上传失败后重新入队（等待退避）的次数
+	Retried int `json:"retried"`
+	// Failed 超过最大尝试次数被移入 failed 的视频数
+	Failed int `json:"failed"`
+	// Recovered 启动时从上次中断中恢复的 inflight 条目数
+	Recovered int             `json:"recovered"`
+	Remaining file.QueueStats `json:"remaining"`
+}
+
+type PipelineService interface {
+	// Run 运行 pipeline
+	// 下载端按频道下载视频，每个视频就绪后写入持久化上传队列；上传端由 pipeline.upload_workers 个 worker 消费队列。
+	// 下载端在队列积压过多、磁盘空间不足或当日账号剩余额度不足时暂停（背压）。
+	// 队列保存在磁盘上，进程重启后未完成的条目会被恢复并继续上传。
+	// channelURLs 为空时处理配置中的所有频道；stage 见 PipelineStage* 常量。
+	Run(ctx context.Context, channelURLs []string, stage string) (*PipelineSummary, error)
+}
+
+type pipelineService struct {
+	downloadService DownloadService
+	uploadService   UploadService
+	fileManager     file.Repository
+	queue           file.UploadQueue
+	accounts        AccountSelector
+	cfg             *config.Config
+
+	mu sync.Mutex
+	// reserved 正在上传（尚未计入当日上传计数）的视频占用的账号额度
+	reserved map[string]int
+	summary  PipelineSummary
+	// quotaWarned 本次运行是否已提示过账号额度用尽（避免每个视频重复输出）
+	quotaWarned bool
+	// notify 上传端每处理完一个条目通知一次，唤醒等待背压的下载端
+	notify chan struct{}
+}
+
+// NewPipelineService 创建并返回一个新的 PipelineService 实例
+func NewPipelineService(
+	downloadService DownloadService,
+	uploadService UploadService,
+	fileManager file.Repository,
+	queue file.UploadQueue,
+	cfg *config.Config,
+) PipelineService {
+	return &pipelineService{
+		downloadService: downloadService,
+		uploadService:   uploadService,
+		fileManager:     fileManager,
+		queue:           queue,
+		accounts:        NewAccountSelector(fileManager, cfg),
+		cfg:             cfg,
+		reserved:        make(map[string]int),
+		notify:          make(chan struct{}, 1),
+	}
+}
+
+func (s *pipelineService) Run(ctx context.Context, channelURLs []string, stage string) (*PipelineSummary, error) {
+	switch stage {
+	case "":
+		stage = PipelineStageAll
+	case PipelineStageAll, PipelineStageDownload, PipelineStageUpload:
+	default:
+		return nil, fmt.Errorf("未知的 pipeline 阶段: %s（可选: all, download, upload）", stage)
+	}
+
+	s.mu.Lock()
+	s.summary = PipelineSummary{}
+	s.reserved = make(map[string]int)
+	s.quotaWarned = false
+	s.mu.Unlock()
+
+	if stage != PipelineStageDownload {
+		recovered, err := s.queue.Recover()
+		if err != nil {
+			return nil, fmt.Errorf("恢复上传队列失败: %w", err)
+		}
+		if recovered > 0 {
+			logger.Info().Int("recovered", recovered).Msg("已将上次中断的上传任务放回队列")
+		}
+		s.mu.Lock()
+		s.summary.Recovered = recovered
+		s.mu.Unlock()
+	}
+
+	stats, _ := s.queue.Stats()
+	logger.Info().
+		Str("stage", stage).
+		Str("queue_dir", s.queue.Dir()).
+		Int("pending", stats.Pending).
+		Int("inflight", stats.Inflight).
+		Int("failed", stats.Failed).
+		Int("upload_workers", s.uploadWorkers()).
+		Msg("pipeline 启动")
+
+	var (
+		wg           sync.WaitGroup
+		producerDone = make(chan struct{})
+		producerErr  error
+	)
+	if stage == PipelineStageUpload {
+		close(producerDone)
+	} else {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(producerDone)
+			producerErr = s.produce(ctx, channelURLs)
+		}()
+	}
+
+	if stage != PipelineStageDownload {
+		for w := 1; w <= s.uploadWorkers(); w++ {
+			wg.Add(1)
+			go func(worker int) {
+				defer wg.Done()
+				s.consume(ctx, worker, producerDone)
+			}(w)
+		}
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	summary := s.summary
+	s.mu.Unlock()
+	summary.Remaining, _ = s.queue.Stats()
+	logger.Info().
+		Int("enqueued", summary.Enqueued).
+		Int("uploaded", summary.Uploaded).
+		Int("retried", summary.Retried).
+		Int("failed", summary.Failed).
+		Int("remaining_pending", summary.Remaining.Pending).
+		Int("remaining_failed", summary.Remaining.Failed).
+		Msg("pipeline 结束")
+
+	if producerErr != nil {
+		return &summary, producerErr
+	}
+	return &summary, ctx.Err()
+}
+
+// produce 下载端：逐个频道下载视频，就绪的视频写入上传队列
+func (s *pipelineService) produce(ctx context.Context, channelURLs []string) error {
+	if len(channelURLs) == 0 {
+		for _, ch := range s.cfg.YouTubeChannels {
+			channelURLs = append(channelURLs, ch.URL)
+		}
+	}
+
+	// 缺少频道信息时先解析一次（与 sync 一致）
+	for _, url := range channelURLs {
+		if !s.fileManager.ChannelInfoExists(s.fileManager.ExtractChannelID(url)) {
+			logger.Info().Str("channel_url", url).Msg("未找到频道信息，先解析频道")
+			if err := s.downloadService.ParseChannels(ctx); err != nil {
+				return fmt.Errorf("解析频道失败: %w", err)
+			}
+			break
+		}
+	}
+
+	hooks := DownloadHooks{
+		BeforeVideo: s.waitForCapacity,
+		OnReady:     s.enqueue,
+	}
+	for _, url := range channelURLs {
+		if ctx.Err() != nil {
+			return nil
+		}
+		channelID := s.fileManager.ExtractChannelID(url)
+		channelDir := filepath.Join(s.cfg.Output.Directory, channelID)
+		if err := s.downloadService.DownloadChannelWithHooks(ctx, channelDir, hooks); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, ErrDownloadResting) {
+				logger.Info().Err(err).Msg("pipeline 下载进入休息，剩余频道在休息结束后的下一次运行中继续")
+				return nil
+			}
+			logger.Error().Err(err).Str("channel_url", url).Msg("pipeline 下载频道失败（继续下一个频道）")
+		}
+	}
+	return nil
+}
+
+// enqueue 视频就绪后加入上传队列
+func (s *pipelineService) enqueue(ctx context.Context, videoDir string) {
+	if s.fileManager.IsVideoUploaded(videoDir) || CheckUploadLedger(s.cfg, s.fileManager, videoDir, "") != nil {
+		return
+	}
+	item := file.QueueItem{
+		VideoID:   filepath.Base(videoDir),
+		ChannelID: filepath.Base(filepath.Dir(videoDir)),
+		VideoDir:  videoDir,
+	}
+	if info, err := s.fileManager.LoadVideoInfo(videoDir); err == nil && info != nil && info.ID != "" {
+		item.VideoID = info.ID
+	}
+	added, err := s.queue.Enqueue(item)
+	if err != nil {
+		logger.Error().Err(err).Str("video_dir", videoDir).Msg("加入上传队列失败")
+		return
+	}
+	if added {
+		s.mu.Lock()
+		s.summary.Enqueued++
+		s.mu.Unlock()
+		logger.Info().Str("video_id", item.VideoID).Str("video_dir", videoDir).Msg("视频已加入上传队列")
+	}
+}
+
+// waitForCapacity 下载端背压：队列积压、磁盘空间或账号额度不足时等待上传端消化
+// 返回 false 表示跳过该视频（已上传、已取消，或等待无法解除时停止继续下载）
+func (s *pipelineService) waitForCapacity(ctx context.Context, videoDir string) bool {
+	if s.fileManager.IsVideoUploaded(videoDir) {
+		return false
+	}
+
+	logged := ""
+	for {
+		if ctx.Err() != nil {
+			return false
+		}
+		stats, err := s.queue.Stats()
+		if err != nil {
+			logger.Warn().Err(err).Msg("读取上传队列失败，跳过背压检查")
+			return true
+		}
+		backlog := stats.Backlog()
+
+		reason := ""
+		remaining := s.remainingQuota()
+		switch {
+		case len(s.cfg.BilibiliAccounts) == 0:
+			// 仅下载的节点可能不配置账号，此时不按额度限流
+			if s.cfg.Pipeline.MaxQueued > 0 && backlog >= s.cfg.Pipeline.MaxQueued {
+				reason = "max_queued"
+			} else if s.lowDiskSpace() {
+				reason = "disk"
+			}
+		case remaining <= 0:
+			// 上传端今天无法再消费，继续下载只会占用磁盘；留给下一次运行
+			s.mu.Lock()
+			warned := s.quotaWarned
+			s.quotaWarned = true
+			s.mu.Unlock()
+			if !warned {
+				logger.Warn().Int("backlog", backlog).Msg("所有账号当日上传额度已用尽，停止下载新视频")
+			}
+			return false
+		case backlog >= remaining:
+			reason = "quota"
+		case s.cfg.Pipeline.MaxQueued > 0 && backlog >= s.cfg.Pipeline.MaxQueued:
+			reason = "max_queued"
+		case s.lowDiskSpace():
+			if backlog == 0 {
+				logger.Error().
+					Int("min_free_disk_mb", s.cfg.Pipeline.MinFreeDiskMB).
+					Msg("磁盘剩余空间不足且上传队列为空，停止下载新视频")
+				return false
+			}
+			reason = "disk"
+		}
+		if reason == "" {
+			return true
+		}
+		if reason != logged {
+			logger.Info().
+				Str("reason", reason).
+				Int("backlog", backlog).
+				Int("remaining_quota", remaining).
+				Int("max_queued", s.cfg.Pipeline.MaxQueued).
+				Msg("下载暂停，等待上传队列消化")
+			logged = reason
+		}
+		s.wait(ctx)
+	}
+}
+
+// consume 上传端 worker：从队列领取视频并上传
+func (s *pipelineService) consume(ctx context.Context, worker int, producerDone <-chan struct{}) {
+	log := logger.Logger().With().Int("upload_worker", worker).Logger()
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.mu.Lock()
+		ok, retryAt := s.accounts.Available(s.reserved)
+		s.mu.Unlock()
+		if !ok {
+			if isClosed(producerDone) && !retryAt.Before(nextDay(time.Now())) {
+				log.Warn().Msg("所有账号当日上传额度已用尽，剩余视频保留在队列中等待下次运行")
+				return
+			}
+			s.wait(ctx)
+			continue
+		}
+
+		item, err := s.queue.Claim()
+		if err != nil || item == nil {
+			if err != nil {
+				log.Error().Err(err).Msg("领取上传任务失败")
+			} else if isClosed(producerDone) {
+				stats, statErr := s.queue.Stats()
+				if statErr == nil && stats.Pending == 0 {
+					return
+				}
+			}
+			s.wait(ctx)
+			continue
+		}
+
+		account, retryAt, err := s.reserveAccount(item.VideoDir)
+		if err != nil {
+			// 该频道可选的账号暂时都不可用：放回队列并推迟到账号恢复时再领取，避免反复领取同一条目
+			if !retryAt.IsZero() {
+				item.NotBefore = retryAt.Unix()
+			}
+			log.Info().Err(err).Str("video_dir", item.VideoDir).Time("retry_at", retryAt).Msg("暂无可用账号，视频放回队列")
+			if relErr := s.queue.Release(item); relErr != nil {
+				log.Warn().Err(relErr).Msg("放回队列失败（下次启动时会自动恢复）")
+			}
+			s.wait(ctx)
+			continue
+		}
+
+		s.upload(ctx, log, item, account)
+		s.releaseAccount(account)
+		s.signal()
+	}
+}
+
+// upload 上传一个队列条目并根据结果确认、重试或放弃
+func (s *pipelineService) upload(ctx context.Context, log zerolog.Logger, item *file.QueueItem, account string) {
+	log = log.With().Str("video_id", item.VideoID).Str("video_dir", item.VideoDir).Logger()
+
+	if s.fileManager.IsVideoUploaded(item.VideoDir) {
+		log.Info().Msg("视频已上传，从队列移除")
+		if err := s.queue.Ack(item); err != nil {
+			log.Warn().Err(err).Msg("移除队列条目失败")
+		}
+		return
+	}
+
+	log.Info().Str("account", account).Int("attempt", item.Attempts+1).Msg("开始上传队列中的视频")
+	err := s.uploadService.UploadSingleVideo(ctx, item.VideoDir, account)
+	if s.fileManager.IsVideoUploaded(item.VideoDir) {
+		if ackErr := s.queue.Ack(item); ackErr != nil {
+			log.Warn().Err(ackErr).Msg("移除队列条目失败")
+		}
+		s.mu.Lock()
+		s.summary.Uploaded++
+		s.mu.Unlock()
+		return
+	}
+
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrAccountQuarantined) {
+		// 被取消（或选中的账号刚被其他 worker 隔离）的上传不计入尝试次数，重新领取时换账号上传
+		if relErr := s.queue.Release(item); relErr != nil {
+			log.Warn().Err(relErr).Msg("放回队列失败（下次启动时会自动恢复）")
+		}
+		return
+	}
+
+	errMsg := "视频尚未就绪（下载未完成或存在临时文件）"
+	if err != nil {
+		errMsg = err.Error()
+	}
+	if errors.Is(err, ErrDuplicateUpload) || item.Attempts+1 >= s.maxAttempts() {
+		// 已发布过的视频重试没有意义，直接移入 failed（确认需要重复上传时加 --allow-duplicate 后 requeue）
+		log.Error().Str("error", errMsg).Int("attempts", item.Attempts+1).Msg("上传失败，移入 failed")
+		if failErr := s.queue.Fail(item, errMsg); failErr != nil {
+			log.Warn().Err(failErr).Msg("移动队列条目失败")
+		}
+		s.mu.Lock()
+		s.summary.Failed++
+		s.mu.Unlock()
+		return
+	}
+
+	retryAfter := time.Duration(item.Attempts+1) * time.Duration(s.cfg.Pipeline.RetryDelayMinutes) * time.Minute
+	log.Warn().Str("error", errMsg).Dur("retry_after", retryAfter).Msg("上传失败，稍后重试")
+	if nackErr := s.queue.Nack(item, errMsg, retryAfter); nackErr != nil {
+		log.Warn().Err(nackErr).Msg("重新入队失败")
+	}
+	s.mu.Lock()
+	s.summary.Retried++
+	s.mu.Unlock()
+}
+
+// reserveAccount 按视频所属频道的账号选择策略选择账号并占用一个名额（含正在上传的视频）
+// 没有可用账号时返回最早可能恢复的时间
+func (s *pipelineService) reserveAccount(videoDir string) (string, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	selection, err := s.accounts.Select(videoDir, s.reserved)
+	if err != nil {
+		return "", selection.RetryAt, err
+	}
+	logger.Info().Str("video_dir", videoDir).Str("account", selection.Account).Str("strategy", selection.Strategy).Str("reason", selection.Reason).Msg("选择上传账号")
+	s.reserved[selection.Account]++
+	return selection.Account, time.Time{}, nil
+}
+
+func (s *pipelineService) releaseAccount(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reserved[name] > 0 {
+		s.reserved[name]--
+	}
+}
+
+// remainingQuota 所有账号当日剩余上传额度之和
+func (s *pipelineService) remainingQuota() int {
+	counts, err := s.fileManager.LoadTodayUploadCounts()
+	if err != nil {
+		counts = map[string]int{}
+	}
+	limit := s.dailyUploadLimit()
+	remaining := 0
+	for name := range s.cfg.BilibiliAccounts {
+		if left := limit - counts[name]; left > 0 {
+			remaining += left
+		}
+	}
+	return remaining
+}
+
+// lowDiskSpace 下载目录所在磁盘剩余空间是否低于 pipeline.min_free_disk_mb
+func (s *pipelineService) lowDiskSpace() bool {
+	if s.cfg.Pipeline.MinFreeDiskMB <= 0 {
+		return false
+	}
+	free, err := utils.FreeDiskSpace(s.cfg.Output.Directory)
+	if err != nil {
+		return false
+	}
+	return free < uint64(s.cfg.Pipeline.MinFreeDiskMB)*1024*1024
+}
+
+// wait 等待一个轮询间隔，或被上传端的进度提前唤醒
+func (s *pipelineService) wait(ctx context.Context) {
+	interval := time.Duration(s.cfg.Pipeline.PollIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	case <-s.notify:
+	}
+}
+
+func (s *pipelineService) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *pipelineService) uploadWorkers() int {
+	if s.cfg.Pipeline.UploadWorkers > 1 {
+		return s.cfg.Pipeline.UploadWorkers
+	}
+	return 1
+}
+
+func (s *pipelineService) maxAttempts() int {
+	if s.cfg.Pipeline.MaxAttempts > 0 {
+		return s.cfg.Pipeline.MaxAttempts
+	}
+	return 5
+}
+
+func (s *pipelineService) dailyUploadLimit() int {
+	return dailyUploadLimit(s.cfg)
+}
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"blueberry/internal/repository/bilibili"
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+)
+
+// PlaylistBackfillOptions 补加播放列表的范围
+type PlaylistBackfillOptions struct {
+	// Dir 只处理该目录（频道目录或视频目录）下的视频；为空处理所有已上传的视频
+	Dir string
+	// DryRun 只列出将要加入播放列表的视频，不实际修改（也不创建播放列表）
+	DryRun bool
+}
+
+// PlaylistBackfillItem 单个视频的补加结果
+type PlaylistBackfillItem struct {
+	VideoDir   string
+	AID        string
+	Account    string
+	PlaylistID string // DryRun 时播放列表尚未创建则为空
+	Error      string // 为空表示成功（DryRun 时表示待加入）
+}
+
+// resolvePlaylist 返回视频所属频道在账号下的播放列表 ID：优先使用 playlist.ids，其次 .global/playlists 中的记录，
+// 再按标题查找账号下的播放列表，找不到且 auto_create 为 true 时创建（create 为 false 时不创建，返回空 ID）。
+// 频道未配置播放列表时返回空 ID
+func (s *uploadService) resolvePlaylist(ctx context.Context, videoDir, accountName string, create bool) (string, error) {
+	ch, ok := s.videoChannel(videoDir)
+	if !ok || !ch.Playlist.Enabled() || accountName == "" {
+		return "", nil
+	}
+	playlist := ch.Playlist
+	if id := playlist.IDs[accountName]; id != "" {
+		return id, nil
+	}
+	if playlist.Title == "" {
+		return "", nil
+	}
+	if id, err := s.fileManager.LookupPlaylistID(accountName, playlist.Title); err != nil {
+		logger.Warn().Err(err).Msg("读取播放列表记录失败")
+	} else if id != "" {
+		return id, nil
+	}
+
+	account := s.cfg.BilibiliAccounts[accountName]
+	playlists, err := s.playlists.ListPlaylists(ctx, account)
+	if err != nil {
+		return "", err
+	}
+	id := ""
+	if p, ok := bilibili.FindPlaylist(playlists, playlist.Title); ok {
+		id = p.ID
+	} else if !playlist.AutoCreate {
+		return "", fmt.Errorf("账号 %s 下没有标题为 %q 的播放列表（可配置 playlist.ids 或开启 auto_create）", accountName, playlist.Title)
+	} else if !create {
+		return "", nil
+	} else if id, err = s.playlists.CreatePlaylist(ctx, playlist.Title, playlist.Description, account); err != nil {
+		return "", err
+	}
+	saved, err := s.fileManager.SavePlaylistID(accountName, playlist.Title, id)
+	if err != nil {
+		logger.Warn().Err(err).Msg("保存播放列表记录失败")
+		return id, nil
+	}
+	return saved, nil
+}
+
+// assignPlaylist 上传成功后确保稿件在播放列表中（上传器发布时未加入的补加），记录到上传状态并重新排列播放列表
+func (s *uploadService) assignPlaylist(ctx context.Context, videoDir, accountName string, meta bilibili.VideoMeta, result *bilibili.UploadResult) {
+	if meta.PlaylistID == "" || result == nil || result.AID == "" {
+		return
+	}
+	account := s.cfg.BilibiliAccounts[accountName]
+	if result.PlaylistID != meta.PlaylistID {
+		if err := s.playlists.AddToPlaylist(ctx, meta.PlaylistID, []string{result.AID}, account); err != nil {
+			logger.Warn().Err(err).Str("video_dir", videoDir).Msg("加入播放列表失败，可稍后用 playlist backfill 补加")
+			return
+		}
+	}
+	if err := s.fileManager.MarkVideoPlaylist(videoDir, meta.PlaylistID); err != nil {
+		logger.Warn().Err(err).Str("video_dir", videoDir).Msg("记录播放列表失败")
+		return
+	}
+	if err := s.sortPlaylist(ctx, filepath.Dir(videoDir), accountName, meta.PlaylistID); err != nil {
+		logger.Warn().Err(err).Str("playlist_id", meta.PlaylistID).Msg("调整播放列表顺序失败")
+	}
+}
+
+// playlistEntry 播放列表中一个稿件的排序依据
+type playlistEntry struct {
+	aid        string
+	index      int    // video_info.json 中的 playlist_index，0 表示未知
+	uploadDate string // YYYYMMDD
+	videoDir   string
+}
+
+// sortPlaylist 将频道目录下已加入该播放列表的稿件按原始 playlist_index 排列（缺失时按 YouTube 上传日期）
+func (s *uploadService) sortPlaylist(ctx context.Context, channelDir, accountName, playlistID string) error {
+	dirs, err := s.fileManager.ListVideoDirsByUploadStatus(string(file.UploadCompleted))
+	if err != nil {
+		return fmt.Errorf("列出已上传的视频失败: %w", err)
+	}
+	if dirs, err = filterDirsUnder(dirs, channelDir); err != nil {
+		return err
+	}
+
+	var entries []playlistEntry
+	for _, videoDir := range dirs {
+		status, err := s.fileManager.LoadUploadStatus(videoDir)
+		if err != nil || status.BilibiliAID == "" || status.PlaylistID != playlistID || status.BilibiliAccount != accountName {
+			continue
+		}
+		entry := playlistEntry{aid: status.BilibiliAID, videoDir: videoDir}
+		if info, err := s.fileManager.LoadVideoInfo(videoDir); err == nil && info != nil {
+			entry.index = info.PlaylistIndex
+			entry.uploadDate = info.UploadDate
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) < 2 {
+		return nil
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if (a.index > 0) != (b.index > 0) {
+			return a.index > 0
+		}
+		if a.index != b.index {
+			return a.index < b.index
+		}
+		if a.uploadDate != b.uploadDate {
+			return a.uploadDate < b.uploadDate
+		}
+		return a.videoDir < b.videoDir
+	})
+	aids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		aids = append(aids, e.aid)
+	}
+	return s.playlists.SortPlaylist(ctx, playlistID, aids, s.cfg.BilibiliAccounts[accountName])
+}
+
+// BackfillPlaylists 将已发布、upload_status.json 中没有播放列表记录的视频加入所属频道的播放列表，完成后重新排列涉及的播放列表
+func (s *uploadService) BackfillPlaylists(ctx context.Context, opts PlaylistBackfillOptions) ([]PlaylistBackfillItem, error) {
+	dirs, err := s.fileManager.ListVideoDirsByUploadStatus(string(file.UploadCompleted))
+	if err != nil {
+		return nil, fmt.Errorf("列出已上传的视频失败: %w", err)
+	}
+	if opts.Dir != "" {
+		if dirs, err = filterDirsUnder(dirs, opts.Dir); err != nil {
+			return nil, err
+		}
+	}
+
+	type playlistKey struct{ channelDir, account, playlistID string }
+	touched := make(map[playlistKey]bool)
+	var items []PlaylistBackfillItem
+	for _, videoDir := range dirs {
+		if ctx.Err() != nil {
+			return items, ctx.Err()
+		}
+		status, err := s.fileManager.LoadUploadStatus(videoDir)
+		if err != nil || status.BilibiliAID == "" || status.PlaylistID != "" {
+			continue
+		}
+		if ch, ok := s.videoChannel(videoDir); !ok || !ch.Playlist.Enabled() {
+			continue
+		}
+
+		item := PlaylistBackfillItem{VideoDir: videoDir, AID: status.BilibiliAID, Account: status.BilibiliAccount}
+		account, ok := s.cfg.BilibiliAccounts[status.BilibiliAccount]
+		if !ok {
+			item.Error = "账号不在 bilibili_accounts 中"
+			items = append(items, item)
+			continue
+		}
+		playlistID, err := s.resolvePlaylist(ctx, videoDir, status.BilibiliAccount, !opts.DryRun)
+		if err != nil {
+			item.Error = err.Error()
+			items = append(items, item)
+			continue
+		}
+		item.PlaylistID = playlistID
+		if opts.DryRun {
+			items = append(items, item)
+			continue
+		}
+		if playlistID == "" {
+			continue
+		}
+
+		if err := s.playlists.AddToPlaylist(ctx, playlistID, []string{status.BilibiliAID}, account); err != nil {
+			if ctx.Err() != nil {
+				return items, ctx.Err()
+			}
+			item.Error = err.Error()
+			items = append(items, item)
+			continue
+		}
+		if err := s.fileManager.MarkVideoPlaylist(videoDir, playlistID); err != nil {
+			logger.Warn().Err(err).Str("video_dir", videoDir).Msg("记录播放列表失败")
+		}
+		touched[playlistKey{filepath.Dir(videoDir), status.BilibiliAccount, playlistID}] = true
+		items = append(items, item)
+	}
+
+	for key := range touched {
+		if err := s.sortPlaylist(ctx, key.channelDir, key.account, key.playlistID); err != nil {
+			if ctx.Err() != nil {
+				return items, ctx.Err()
+			}
+			logger.Warn().Err(err).Str("playlist_id", key.playlistID).Msg("调整播放列表顺序失败")
+		}
+	}
+	return items, nil
+}
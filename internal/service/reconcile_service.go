@@ -0,0 +1,484 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/bilibili"
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+)
+
+// reconcile 问题代码（机器可读，用于 --fix 选择修复项）
+const (
+	ReconcileAIDMissing      = "aid_missing"       // 本地记录的 aid 在所有账号的稿件库中都不存在（或已删除）
+	ReconcileUnrecorded      = "upload_unrecorded" // 稿件已在 B站，但本地没有记录（未标记为已上传或记录了其他 aid）
+	ReconcileAccountMismatch = "account_mismatch"  // 本地记录的账号与稿件实际所在的账号不一致
+	ReconcileDuplicate       = "duplicate"         // 同一个本地视频在 B站有多个稿件
+	ReconcileRemoteUnmatched = "remote_unmatched"  // 稿件无法匹配到任何本地视频
+)
+
+// ReconcileCodes 所有问题代码及其修复方式
+var ReconcileCodes = []FsckCodeInfo{
+	{ReconcileAIDMissing, "本地记录的 aid 在稿件库中不存在；修复：清除 aid，上传状态回到 pending 以便重新上传", true},
+	{ReconcileUnrecorded, "稿件已在 B站但本地未记录；修复：将视频标记为已上传并记录 aid / 账号", true},
+	{ReconcileAccountMismatch, "本地记录的账号与稿件所在账号不一致；修复：更新本地记录的账号", true},
+	{ReconcileDuplicate, "同一视频在 B站有多个稿件；修复：删除多余的稿件（保留本地记录的或最早投稿的稿件）", true},
+	{ReconcileRemoteUnmatched, "稿件无法匹配到本地视频", false},
+}
+
+// ReconcileOptions reconcile 的范围与修复项
+type ReconcileOptions struct {
+	// Accounts 只拉取这些账号（bilibili_accounts 中的名称）；为空拉取全部账号
+	Accounts []string
+	// Fix 要修复的问题代码
+	Fix []string
+	// DryRun 只展示将要进行的修复
+	DryRun bool
+	// ConfirmDelete 删除多余稿件（duplicate）前调用，返回 false 时跳过删除；为 nil 时直接删除
+	ConfirmDelete func(issues []ReconcileIssue) bool
+}
+
+// ReconcileIssue 一处本地记录与 B站稿件不一致
+type ReconcileIssue struct {
+	Code     string   `json:"code"`
+	VideoDir string   `json:"video_dir,omitempty"`
+	VideoID  string   `json:"video_id,omitempty"`
+	AID      string   `json:"aid,omitempty"`
+	Account  string   `json:"account,omitempty"`
+	Title    string   `json:"title,omitempty"`
+	Extra    []string `json:"extra_aids,omitempty"` // duplicate：将被删除的多余稿件
+	Message  string   `json:"message"`
+	Fixable  bool     `json:"fixable"`
+
+	archive *bilibili.Archive // 修复时使用的稿件（upload_unrecorded / account_mismatch）
+}
+
+// ReconcileFix 一次修复的结果
+type ReconcileFix struct {
+	Issue   ReconcileIssue `json:"issue"`
+	Applied bool           `json:"applied"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// ReconcileAccount 单个账号的稿件拉取结果
+type ReconcileAccount struct {
+	Name     string `json:"name"`
+	Archives int    `json:"archives"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ReconcileReport reconcile 报告
+type ReconcileReport struct {
+	Accounts    []ReconcileAccount `json:"accounts"`
+	LocalVideos int                `json:"local_videos"`
+	Matched     int                `json:"matched"`
+	Issues      []ReconcileIssue   `json:"issues"`
+	DryRun      bool               `json:"dry_run,omitempty"`
+	Fixes       []ReconcileFix     `json:"fixes,omitempty"`
+}
+
+// Remaining 返回修复后仍然存在的问题数量（remote_unmatched 只作提示，不计入）
+func (r *ReconcileReport) Remaining() int {
+	remaining := 0
+	for _, issue := range r.Issues {
+		if issue.Code != ReconcileRemoteUnmatched {
+			remaining++
+		}
+	}
+	if !r.DryRun {
+		for _, fix := range r.Fixes {
+			if fix.Applied {
+				remaining--
+			}
+		}
+	}
+	return remaining
+}
+
+// ReconcileService 核对各账号的稿件库与本地上传记录
+type ReconcileService interface {
+	Reconcile(ctx context.Context, opts ReconcileOptions) (*ReconcileReport, error)
+}
+
+type reconcileService struct {
+	outputDir string
+	store     file.StateStore
+	library   bilibili.Library
+	cfg       *config.Config
+}
+
+// NewReconcileService 创建 ReconcileService
+func NewReconcileService(outputDir string, store file.StateStore, library bilibili.Library, cfg *config.Config) ReconcileService {
+	return &reconcileService{
+		outputDir: outputDir,
+		store:     store,
+		library:   library,
+		cfg:       cfg,
+	}
+}
+
+// localVideo 本地视频目录的匹配信息
+type localVideo struct {
+	dir     string
+	videoID string
+	title   string
+	status  *file.UploadStatus
+	matches []*bilibili.Archive
+}
+
+// resubmitSuffix 重新上传时追加到标题后的序号
+var resubmitSuffix = regexp.MustCompile(`\s*\(\d+\)$`)
+
+// youtubeIDInText 描述中的 YouTube 链接
+var youtubeIDInText = regexp.MustCompile(`(?:youtu\.be/|youtube\.com/(?:watch\?(?:[^\s]*&)?v=|shorts/|embed/|live/))([A-Za-z0-9_-]{11})`)
+
+// normalizeTitle 标题归一化：去掉重新上传的序号，忽略大小写与多余空白
+func normalizeTitle(title string) string {
+	title = resubmitSuffix.ReplaceAllString(strings.TrimSpace(title), "")
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+func (s *reconcileService) Reconcile(ctx context.Context, opts ReconcileOptions) (*ReconcileReport, error) {
+	report := &ReconcileReport{Issues: []ReconcileIssue{}, DryRun: opts.DryRun}
+	repo := file.NewRepositoryWithStore(s.outputDir, s.store)
+
+	locals, err := s.loadLocalVideos(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	report.LocalVideos = len(locals)
+
+	remote, complete, err := s.fetchArchives(ctx, report, opts.Accounts)
+	if err != nil {
+		return nil, err
+	}
+
+	// 建立索引：本地记录的 aid、视频 ID、归一化标题
+	byAID := make(map[string]*localVideo)
+	byKey := make(map[string][]*localVideo)
+	for _, lv := range locals {
+		if lv.status != nil && lv.status.BilibiliAID != "" {
+			byAID[lv.status.BilibiliAID] = lv
+		}
+		keys := map[string]bool{}
+		if lv.videoID != "" {
+			keys[normalizeTitle(lv.videoID)] = true
+		}
+		if lv.title != "" {
+			keys[normalizeTitle(lv.title)] = true
+		}
+		for key := range keys {
+			byKey[key] = append(byKey[key], lv)
+		}
+	}
+	byVideoID := make(map[string]*localVideo)
+	for _, lv := range locals {
+		if lv.videoID != "" {
+			byVideoID[lv.videoID] = lv
+		}
+	}
+
+	// 稿件匹配本地视频：aid > 描述中的 YouTube 链接 > 标题（唯一匹配）
+	remoteByAID := make(map[string]*bilibili.Archive, len(remote))
+	for _, a := range remote {
+		remoteByAID[a.AID] = a
+		if a.State == file.ReviewDeleted {
+			continue
+		}
+		lv := byAID[a.AID]
+		if lv == nil {
+			for _, m := range youtubeIDInText.FindAllStringSubmatch(a.Desc, -1) {
+				if lv = byVideoID[m[1]]; lv != nil {
+					break
+				}
+			}
+		}
+		if lv == nil {
+			if candidates := byKey[normalizeTitle(a.Title)]; len(candidates) == 1 {
+				lv = candidates[0]
+			}
+		}
+		if lv == nil {
+			report.Issues = append(report.Issues, ReconcileIssue{
+				Code:    ReconcileRemoteUnmatched,
+				AID:     a.AID,
+				Account: a.Account,
+				Title:   a.Title,
+				Message: "稿件无法匹配到本地视频",
+			})
+			continue
+		}
+		lv.matches = append(lv.matches, a)
+	}
+
+	for _, lv := range locals {
+		s.checkLocalVideo(report, lv, remoteByAID, complete)
+	}
+	for i := range report.Issues {
+		report.Issues[i].Fixable = reconcileFixable(report.Issues[i].Code)
+	}
+
+	if len(opts.Fix) > 0 {
+		s.applyFixes(ctx, report, repo, opts)
+	}
+	return report, nil
+}
+
+// checkLocalVideo 核对单个本地视频的上传记录与匹配到的稿件
+func (s *reconcileService) checkLocalVideo(report *ReconcileReport, lv *localVideo, remoteByAID map[string]*bilibili.Archive, complete bool) {
+	recordedAID := ""
+	recordedAccount := ""
+	completed := lv.status != nil && lv.status.Status == file.UploadCompleted
+	if lv.status != nil {
+		recordedAID = lv.status.BilibiliAID
+		recordedAccount = lv.status.BilibiliAccount
+	}
+
+	if len(lv.matches) == 0 {
+		if !completed || recordedAID == "" || !complete {
+			return
+		}
+		msg := "本地记录的 aid 在稿件库中不存在"
+		if a := remoteByAID[recordedAID]; a != nil {
+			msg = "本地记录的稿件已被删除"
+		}
+		report.Issues = append(report.Issues, ReconcileIssue{
+			Code:     ReconcileAIDMissing,
+			VideoDir: lv.dir,
+			VideoID:  lv.videoID,
+			AID:      recordedAID,
+			Account:  recordedAccount,
+			Message:  msg,
+		})
+		return
+	}
+	report.Matched++
+
+	// 保留本地记录的稿件，否则保留最早投稿的稿件
+	sort.SliceStable(lv.matches, func(i, j int) bool {
+		ai, aj := lv.matches[i], lv.matches[j]
+		if (ai.AID == recordedAID) != (aj.AID == recordedAID) {
+			return ai.AID == recordedAID
+		}
+		return ai.CreatedAt.Before(aj.CreatedAt)
+	})
+	keeper := lv.matches[0]
+	if len(lv.matches) > 1 {
+		issue := ReconcileIssue{
+			Code:     ReconcileDuplicate,
+			VideoDir: lv.dir,
+			VideoID:  lv.videoID,
+			AID:      keeper.AID,
+			Account:  keeper.Account,
+			Title:    keeper.Title,
+		}
+		for _, extra := range lv.matches[1:] {
+			issue.Extra = append(issue.Extra, extra.Account+":"+extra.AID)
+		}
+		issue.Message = fmt.Sprintf("在 B站有 %d 个稿件，保留 %s", len(lv.matches), keeper.AID)
+		report.Issues = append(report.Issues, issue)
+	}
+
+	switch {
+	case !completed || recordedAID != keeper.AID:
+		msg := "稿件已在 B站，但本地未标记为已上传"
+		if completed && recordedAID != "" {
+			msg = fmt.Sprintf("本地记录的 aid %s 与 B站稿件 %s 不一致", recordedAID, keeper.AID)
+		} else if completed {
+			msg = "本地已标记为已上传但没有记录 aid"
+		}
+		report.Issues = append(report.Issues, ReconcileIssue{
+			Code:     ReconcileUnrecorded,
+			VideoDir: lv.dir,
+			VideoID:  lv.videoID,
+			AID:      keeper.AID,
+			Account:  keeper.Account,
+			Title:    keeper.Title,
+			Message:  msg,
+			archive:  keeper,
+		})
+	case recordedAccount != keeper.Account:
+		report.Issues = append(report.Issues, ReconcileIssue{
+			Code:     ReconcileAccountMismatch,
+			VideoDir: lv.dir,
+			VideoID:  lv.videoID,
+			AID:      keeper.AID,
+			Account:  keeper.Account,
+			Title:    keeper.Title,
+			Message:  fmt.Sprintf("本地记录的账号为 %q，稿件实际在 %q", recordedAccount, keeper.Account),
+			archive:  keeper,
+		})
+	}
+}
+
+// fetchArchives 拉取各账号的全部稿件（同一 aid 只保留第一次出现的账号）；任一账号失败时 complete 为 false
+func (s *reconcileService) fetchArchives(ctx context.Context, report *ReconcileReport, only []string) ([]*bilibili.Archive, bool, error) {
+	names := make([]string, 0, len(s.cfg.BilibiliAccounts))
+	for name := range s.cfg.BilibiliAccounts {
+		if len(only) == 0 || containsFold(only, name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, false, fmt.Errorf("没有可用的 bilibili_accounts 账号")
+	}
+	sort.Strings(names)
+
+	var archives []*bilibili.Archive
+	seen := make(map[string]string)
+	complete := len(only) == 0
+	for _, name := range names {
+		list, err := s.library.ListArchives(ctx, s.cfg.BilibiliAccounts[name], bilibili.ArchiveFilter{})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, false, ctx.Err()
+			}
+			logger.Error().Err(err).Str("account", name).Msg("获取账号稿件列表失败")
+			report.Accounts = append(report.Accounts, ReconcileAccount{Name: name, Error: err.Error()})
+			complete = false
+			continue
+		}
+		report.Accounts = append(report.Accounts, ReconcileAccount{Name: name, Archives: len(list)})
+		for i := range list {
+			a := &list[i]
+			if prev, ok := seen[a.AID]; ok {
+				logger.Warn().Str("aid", a.AID).Str("account", name).Str("first_account", prev).Msg("同一稿件出现在多个账号中（cookies 可能相同），只保留第一个")
+				continue
+			}
+			seen[a.AID] = name
+			a.Account = name
+			archives = append(archives, a)
+		}
+	}
+	if !complete {
+		logger.Warn().Msg("未拉取全部账号的稿件，跳过 aid_missing 检查")
+	}
+	return archives, complete, nil
+}
+
+// loadLocalVideos 遍历输出目录中的视频目录，读取视频 ID、标题与上传状态
+func (s *reconcileService) loadLocalVideos(ctx context.Context, repo file.Repository) ([]*localVideo, error) {
+	channelEntries, err := os.ReadDir(s.outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("读取输出目录失败: %w", err)
+	}
+	var locals []*localVideo
+	for _, channelEntry := range channelEntries {
+		if !channelEntry.IsDir() || strings.HasPrefix(channelEntry.Name(), ".") {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		channelDir := filepath.Join(s.outputDir, channelEntry.Name())
+		videoEntries, err := os.ReadDir(channelDir)
+		if err != nil {
+			return nil, fmt.Errorf("读取频道目录失败 (%s): %w", channelDir, err)
+		}
+		for _, videoEntry := range videoEntries {
+			if !videoEntry.IsDir() || strings.HasPrefix(videoEntry.Name(), ".") {
+				continue
+			}
+			videoDir := filepath.Join(channelDir, videoEntry.Name())
+			lv := &localVideo{dir: videoDir}
+			if info, err := repo.LoadVideoInfo(videoDir); err == nil && info != nil {
+				lv.videoID = strings.TrimSpace(info.ID)
+				lv.title = info.Title
+			}
+			if status, err := repo.LoadUploadStatus(videoDir); err == nil {
+				lv.status = status
+			}
+			if lv.videoID == "" && lv.status == nil {
+				continue
+			}
+			locals = append(locals, lv)
+		}
+	}
+	return locals, nil
+}
+
+// applyFixes 修复 opts.Fix 中列出的问题；DryRun 时只记录将要进行的修复
+func (s *reconcileService) applyFixes(ctx context.Context, report *ReconcileReport, repo file.Repository, opts ReconcileOptions) {
+	var selected, deletions []ReconcileIssue
+	for _, issue := range report.Issues {
+		if issue.Fixable && containsFold(opts.Fix, issue.Code) {
+			selected = append(selected, issue)
+			if issue.Code == ReconcileDuplicate {
+				deletions = append(deletions, issue)
+			}
+		}
+	}
+	confirmed := true
+	if len(deletions) > 0 && !opts.DryRun && opts.ConfirmDelete != nil {
+		confirmed = opts.ConfirmDelete(deletions)
+	}
+
+	for _, issue := range selected {
+		if ctx.Err() != nil {
+			return
+		}
+		fix := ReconcileFix{Issue: issue, Applied: true}
+		if issue.Code == ReconcileDuplicate && !confirmed {
+			fix.Applied = false
+			fix.Error = "未确认删除"
+		} else if !opts.DryRun {
+			if err := s.fix(ctx, repo, issue); err != nil {
+				fix.Applied = false
+				fix.Error = err.Error()
+				logger.Error().Err(err).Str("code", issue.Code).Str("video_dir", issue.VideoDir).Msg("修复失败")
+			}
+		}
+		report.Fixes = append(report.Fixes, fix)
+	}
+}
+
+func (s *reconcileService) fix(ctx context.Context, repo file.Repository, issue ReconcileIssue) error {
+	switch issue.Code {
+	case ReconcileAIDMissing:
+		return repo.MarkVideoUploadLost(issue.VideoDir, fmt.Sprintf("reconcile: 稿件 %s 在 B站不存在", issue.AID))
+	case ReconcileUnrecorded, ReconcileAccountMismatch:
+		a := issue.archive
+		if err := repo.LinkUploadedArchive(issue.VideoDir, a.AID, a.Account, s.cfg.BilibiliAccounts[a.Account].UserID); err != nil {
+			return err
+		}
+		if !a.State.Valid() {
+			return nil
+		}
+		return repo.MarkVideoReviewStatus(issue.VideoDir, file.ReviewStatus{
+			State:        a.State,
+			Code:         a.StateCode,
+			Desc:         a.StateDesc,
+			RejectReason: a.RejectReason,
+		})
+	case ReconcileDuplicate:
+		var failed []string
+		for _, extra := range issue.Extra {
+			account, aid, _ := strings.Cut(extra, ":")
+			if err := s.library.DeleteArchive(ctx, aid, s.cfg.BilibiliAccounts[account]); err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", aid, err))
+			}
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("删除多余稿件失败: %s", strings.Join(failed, "; "))
+		}
+		return nil
+	}
+	return fmt.Errorf("问题代码 %s 不支持自动修复", issue.Code)
+}
+
+// reconcileFixable 问题代码是否可自动修复
+func reconcileFixable(code string) bool {
+	for _, info := range ReconcileCodes {
+		if info.Code == code {
+			return info.Fixable
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/bilibili"
+	"blueberry/pkg/logger"
+)
+
+// planPublishTime 决定视频的发布时间：upload --publish-at 指定时使用该时间；开启 bilibili.schedule 时
+// 预约账号下一个空闲的发布时段；否则返回零值（立即发布）
+func (s *uploadService) planPublishTime(videoDir, accountName string) (time.Time, error) {
+	if at := s.cfg.Bilibili.PublishAtOverride; !at.IsZero() {
+		if !at.After(time.Now()) {
+			return time.Time{}, fmt.Errorf("指定的发布时间 %s 已过去", at.Format("2006-01-02 15:04"))
+		}
+		return at, nil
+	}
+	schedule := s.cfg.Bilibili.Schedule
+	if !schedule.Enabled || accountName == "" {
+		return time.Time{}, nil
+	}
+
+	account := s.cfg.BilibiliAccounts[accountName]
+	loc, slots, err := AccountPublishSlots(schedule, account)
+	if err != nil {
+		return time.Time{}, err
+	}
+	unix, err := s.fileManager.ReservePublishSlot(accountName, videoDir, func(booked []int64) (int64, error) {
+		at, err := NextPublishSlot(time.Now(), loc, slots, schedule, booked)
+		if err != nil {
+			return 0, err
+		}
+		return at.Unix(), nil
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("预约发布时间失败: %w", err)
+	}
+	at := time.Unix(unix, 0).In(loc)
+	logger.Info().
+		Str("video_dir", videoDir).
+		Str("account", accountName).
+		Str("publish_at", at.Format("2006-01-02 15:04 MST")).
+		Msg("已预约定时发布")
+	return at, nil
+}
+
+// AccountPublishSlots 返回账号的时区与发布时段（账号未配置时使用 bilibili.schedule）
+func AccountPublishSlots(schedule config.ScheduleConfig, account config.Account) (*time.Location, []string, error) {
+	timezone := account.Timezone
+	if timezone == "" {
+		timezone = schedule.Timezone
+	}
+	loc := time.Local
+	if timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, nil, fmt.Errorf("未知时区 %s: %w", timezone, err)
+		}
+	}
+	slots := account.PublishSlots
+	if len(slots) == 0 {
+		slots = schedule.Slots
+	}
+	if len(slots) == 0 {
+		return nil, nil, fmt.Errorf("已开启定时发布，但未配置发布时段（bilibili.schedule.slots）")
+	}
+	return loc, slots, nil
+}
+
+// NextPublishSlot 从 now + min_lead_minutes 起按天遍历 loc 时区下的发布时段，返回第一个预约数未达到 per_slot 的时段；
+// 超过 max_days 仍未找到时返回错误
+func NextPublishSlot(now time.Time, loc *time.Location, slots []string, schedule config.ScheduleConfig, booked []int64) (time.Time, error) {
+	perSlot := schedule.PerSlot
+	if perSlot <= 0 {
+		perSlot = 1
+	}
+	maxDays := schedule.MaxDays
+	if maxDays <= 0 {
+		maxDays = 15
+	}
+
+	offsets := make([]time.Duration, 0, len(slots))
+	for _, slot := range slots {
+		t, err := time.Parse("15:04", slot)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("发布时段 %q 格式应为 HH:MM", slot)
+		}
+		offsets = append(offsets, time.Duration(t.Hour())*time.Hour+time.Duration(t.Minute())*time.Minute)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	counts := make(map[int64]int, len(booked))
+	for _, at := range booked {
+		counts[at]++
+	}
+
+	earliest := now.Add(time.Duration(schedule.MinLeadMinutes) * time.Minute)
+	latest := now.Add(time.Duration(maxDays) * 24 * time.Hour)
+	local := now.In(loc)
+	for day := 0; day <= maxDays; day++ {
+		y, m, d := local.AddDate(0, 0, day).Date()
+		for _, offset := range offsets {
+			at := time.Date(y, m, d, 0, 0, 0, 0, loc).Add(offset)
+			if at.Before(earliest) {
+				continue
+			}
+			if at.After(latest) {
+				return time.Time{}, fmt.Errorf("未来 %d 天的发布时段已排满", maxDays)
+			}
+			if counts[at.Unix()] < perSlot {
+				return at, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("未来 %d 天的发布时段已排满", maxDays)
+}
+
+// releasePublishSlot 上传失败或中断时释放预约的发布时间（--publish-at 指定的时间不占用排期）
+func (s *uploadService) releasePublishSlot(videoDir, accountName string, meta bilibili.VideoMeta) {
+	if meta.PublishAt.IsZero() || !s.cfg.Bilibili.PublishAtOverride.IsZero() {
+		return
+	}
+	if err := s.fileManager.ReleasePublishSlot(accountName, videoDir); err != nil {
+		logger.Warn().Err(err).Str("video_dir", videoDir).Msg("释放预约的发布时间失败")
+	}
+}
+
+// recordScheduledPublish 上传成功后将定时发布时间写入上传状态
+func (s *uploadService) recordScheduledPublish(videoDir string, meta bilibili.VideoMeta) {
+	if meta.PublishAt.IsZero() {
+		return
+	}
+	if err := s.fileManager.MarkVideoScheduledPublish(videoDir, meta.PublishAt.Unix()); err != nil {
+		logger.Warn().Err(err).Str("video_dir", videoDir).Msg("记录定时发布时间失败")
+	}
+}
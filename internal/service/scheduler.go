@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"blueberry/pkg/logger"
+	"blueberry/pkg/utils"
+
+	"github.com/robfig/cron/v3"
+)
+
+// 定时任务的运行结果
+const (
+	TaskStatusRunning = "running"
+	TaskStatusSuccess = "success"
+	TaskStatusFailed  = "failed"
+)
+
+// ScheduledTask daemon 中的一个定时任务
+type ScheduledTask struct {
+	Name string
+	// Schedule cron 表达式（分 时 日 月 周），支持 @every、@daily 等描述符
+	Schedule string
+	// IgnoreQuietHours 为 true 时静默时段内也照常启动
+	IgnoreQuietHours bool
+	// NotBefore 可选：返回该任务最早可以启动的时间（例如下载限制 / bot detection 休息结束），
+	// 到期时若该时间尚未到达，任务顺延到该时间而不是启动后在任务内阻塞休眠
+	NotBefore func() (time.Time, bool)
+	Run       func(ctx context.Context) error
+}
+
+// TaskState 定时任务的持久化状态
+type TaskState struct {
+	Schedule     string    `json:"schedule"`
+	NextRun      time.Time `json:"next_run"`
+	LastStart    time.Time `json:"last_start"`
+	LastEnd      time.Time `json:"last_end"`
+	LastStatus   string    `json:"last_status,omitempty"`
+	LastError    string    `json:"last_error,omitempty"`
+	LastDuration string    `json:"last_duration,omitempty"`
+	// Deferred 下次运行被顺延的原因（quiet_hours / not_before），为空表示按计划运行
+	Deferred string `json:"deferred,omitempty"`
+}
+
+// SchedulerState 调度器持久化状态（任务名 -> 状态）
+type SchedulerState struct {
+	UpdatedAt time.Time             `json:"updated_at"`
+	Tasks     map[string]*TaskState `json:"tasks"`
+}
+
+// Scheduler 按 cron 表达式运行定时任务
+// 下次运行时间与上次运行结果保存在状态文件中：重启后按保存的时间继续调度，
+// 停机期间错过的运行在启动时补跑一次，上次中断（状态为 running）的任务会立即重新运行。
+// 同一任务不会重叠运行；不同任务可以同时运行。
+type Scheduler interface {
+	// Run 运行调度循环直到 ctx 取消，返回前等待正在运行的任务结束并保存状态
+	Run(ctx context.Context) error
+	// State 返回当前的调度状态
+	State() SchedulerState
+}
+
+type scheduledEntry struct {
+	task     ScheduledTask
+	schedule cron.Schedule
+	running  bool
+}
+
+type scheduler struct {
+	entries    []*scheduledEntry
+	quietHours []quietRange
+	statePath  string
+
+	mu    sync.Mutex
+	state SchedulerState
+	now   func() time.Time
+}
+
+// NewScheduler 创建调度器；statePath 为调度状态文件路径，quietHours 为 HH:MM-HH:MM 格式的静默时段
+func NewScheduler(tasks []ScheduledTask, quietHours []string, statePath string) (Scheduler, error) {
+	s := &scheduler{
+		statePath: statePath,
+		state:     SchedulerState{Tasks: make(map[string]*TaskState)},
+		now:       time.Now,
+	}
+	for _, spec := range quietHours {
+		r, err := parseQuietRange(spec)
+		if err != nil {
+			return nil, err
+		}
+		s.quietHours = append(s.quietHours, r)
+	}
+	for _, task := range tasks {
+		sched, err := cron.ParseStandard(task.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("任务 %s 的调度表达式无效 (%s): %w", task.Name, task.Schedule, err)
+		}
+		s.entries = append(s.entries, &scheduledEntry{task: task, schedule: sched})
+	}
+	if err := s.loadState(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *scheduler) loadState() error {
+	data, err := os.ReadFile(s.statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("读取调度状态失败: %w", err)
+	}
+	var state SchedulerState
+	if err := json.Unmarshal(data, &state); err != nil {
+		logger.Warn().Err(err).Str("path", s.statePath).Msg("调度状态文件损坏，重新开始调度")
+		return nil
+	}
+	if state.Tasks != nil {
+		s.state = state
+	}
+	return nil
+}
+
+// saveState 保存调度状态，调用方需持有 mu
+func (s *scheduler) saveState() {
+	s.state.UpdatedAt = s.now()
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		logger.Warn().Err(err).Msg("序列化调度状态失败")
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(s.statePath), 0755); err != nil {
+		logger.Warn().Err(err).Msg("创建调度状态目录失败")
+		return
+	}
+	if err := utils.WriteFileAtomic(s.statePath, data, 0644); err != nil {
+		logger.Warn().Err(err).Str("path", s.statePath).Msg("保存调度状态失败")
+	}
+}
+
+func (s *scheduler) State() SchedulerState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := SchedulerState{UpdatedAt: s.state.UpdatedAt, Tasks: make(map[string]*TaskState, len(s.state.Tasks))}
+	for name, ts := range s.state.Tasks {
+		copied := *ts
+		state.Tasks[name] = &copied
+	}
+	return state
+}
+
+// initState 根据保存的状态确定每个任务的首次运行时间
+func (s *scheduler) initState(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active := make(map[string]bool, len(s.entries))
+	for _, e := range s.entries {
+		name := e.task.Name
+		active[name] = true
+		ts := s.state.Tasks[name]
+		switch {
+		case ts == nil || ts.Schedule != e.task.Schedule || ts.NextRun.IsZero():
+			// 新任务或调度表达式已修改：按新的表达式计算
+			if ts == nil {
+				ts = &TaskState{}
+				s.state.Tasks[name] = ts
+			}
+			ts.Schedule = e.task.Schedule
+			ts.NextRun = e.schedule.Next(now)
+			ts.Deferred = ""
+		case ts.LastStatus == TaskStatusRunning:
+			// 上次运行被中断（进程退出或崩溃），立即重新运行
+			logger.Info().Str("task", name).Time("last_start", ts.LastStart).Msg("任务上次运行被中断，立即重新运行")
+			ts.NextRun = now
+		case ts.NextRun.Before(now):
+			// 停机期间错过的运行只补跑一次
+			logger.Info().Str("task", name).Time("missed", ts.NextRun).Msg("任务在停机期间错过运行，立即补跑")
+			ts.NextRun = now
+		}
+	}
+	// 已从配置中移除的任务不再保留状态
+	for name := range s.state.Tasks {
+		if !active[name] {
+			delete(s.state.Tasks, name)
+		}
+	}
+	s.saveState()
+}
+
+func (s *scheduler) Run(ctx context.Context) error {
+	if len(s.entries) == 0 {
+		return fmt.Errorf("没有启用的定时任务")
+	}
+	s.initState(s.now())
+	s.logSchedule()
+
+	var wg sync.WaitGroup
+	done := make(chan string, len(s.entries))
+	defer func() {
+		wg.Wait()
+		s.mu.Lock()
+		s.saveState()
+		s.mu.Unlock()
+	}()
+
+	for {
+		wake := s.dispatch(ctx, &wg, done)
+
+		timer := time.NewTimer(time.Until(wake))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			logger.Info().Msg("daemon 收到退出信号，等待正在运行的任务结束")
+			return nil
+		case name := <-done:
+			timer.Stop()
+			logger.Debug().Str("task", name).Msg("任务结束，重新计算调度")
+		case <-timer.C:
+		}
+	}
+}
+
+// dispatch 启动所有到期的任务，返回下一次需要唤醒的时间
+func (s *scheduler) dispatch(ctx context.Context, wg *sync.WaitGroup, done chan<- string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	changed := false
+	var wake time.Time
+	for _, e := range s.entries {
+		ts := s.state.Tasks[e.task.Name]
+		if !ts.NextRun.After(now) {
+			changed = true
+			s.fire(ctx, e, ts, now, wg, done)
+		}
+		if wake.IsZero() || ts.NextRun.Before(wake) {
+			wake = ts.NextRun
+		}
+	}
+	if changed {
+		s.saveState()
+	}
+	return wake
+}
+
+// fire 处理一个到期的任务：静默时段或 NotBefore 未到时顺延，正在运行时跳过本次，否则启动；调用方需持有 mu
+func (s *scheduler) fire(ctx context.Context, e *scheduledEntry, ts *TaskState, now time.Time, wg *sync.WaitGroup, done chan<- string) {
+	name := e.task.Name
+	if e.running {
+		ts.NextRun = e.schedule.Next(now)
+		logger.Warn().Str("task", name).Time("next_run", ts.NextRun).Msg("任务上次运行尚未结束，跳过本次")
+		return
+	}
+	if !e.task.IgnoreQuietHours {
+		if until, quiet := s.quietUntil(now); quiet {
+			ts.NextRun = until
+			ts.Deferred = "quiet_hours"
+			logger.Info().Str("task", name).Time("next_run", until).Msg("处于静默时段，任务顺延")
+			return
+		}
+	}
+	if e.task.NotBefore != nil {
+		if notBefore, ok := e.task.NotBefore(); ok && notBefore.After(now) {
+			ts.NextRun = notBefore
+			ts.Deferred = "not_before"
+			logger.Info().Str("task", name).Time("next_run", notBefore).Msg("任务处于休息期，顺延到休息结束")
+			return
+		}
+	}
+
+	e.running = true
+	ts.Deferred = ""
+	ts.LastStart = now
+	ts.LastStatus = TaskStatusRunning
+	ts.LastError = ""
+	ts.NextRun = e.schedule.Next(now)
+	logger.Info().Str("task", name).Time("next_run", ts.NextRun).Msg("启动定时任务")
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err := e.task.Run(ctx)
+
+		s.mu.Lock()
+		end := s.now()
+		e.running = false
+		ts.LastEnd = end
+		ts.LastDuration = end.Sub(ts.LastStart).Round(time.Second).String()
+		switch {
+		case err != nil && ctx.Err() != nil:
+			// 因退出被中断：保持 running 状态，下次启动时立即重新运行
+			logger.Warn().Str("task", name).Err(err).Msg("任务被中断，下次启动时重新运行")
+		case err != nil:
+			ts.LastStatus = TaskStatusFailed
+			ts.LastError = err.Error()
+			logger.Error().Str("task", name).Err(err).Str("duration", ts.LastDuration).Msg("定时任务失败")
+		case ctx.Err() != nil:
+			logger.Warn().Str("task", name).Msg("任务被中断，下次启动时重新运行")
+		default:
+			ts.LastStatus = TaskStatusSuccess
+			logger.Info().Str("task", name).Str("duration", ts.LastDuration).Time("next_run", ts.NextRun).Msg("定时任务完成")
+		}
+		s.saveState()
+		s.mu.Unlock()
+
+		done <- name
+	}()
+}
+
+func (s *scheduler) logSchedule() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.state.Tasks))
+	for name := range s.state.Tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ts := s.state.Tasks[name]
+		logger.Info().
+			Str("task", name).
+			Str("schedule", ts.Schedule).
+			Time("next_run", ts.NextRun).
+			Msg("定时任务已加载")
+	}
+}
+
+// quietRange 静默时段（从当天 0 点起的分钟数，end <= start 表示跨午夜）
+type quietRange struct {
+	start, end int
+}
+
+func parseQuietRange(spec string) (quietRange, error) {
+	parts := strings.Split(strings.TrimSpace(spec), "-")
+	if len(parts) != 2 {
+		return quietRange{}, fmt.Errorf("静默时段格式错误（应为 HH:MM-HH:MM）: %s", spec)
+	}
+	var minutes [2]int
+	for i, part := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return quietRange{}, fmt.Errorf("静默时段格式错误（应为 HH:MM-HH:MM）: %s", spec)
+		}
+		minutes[i] = t.Hour()*60 + t.Minute()
+	}
+	if minutes[0] == minutes[1] {
+		return quietRange{}, fmt.Errorf("静默时段的开始与结束时间不能相同: %s", spec)
+	}
+	return quietRange{start: minutes[0], end: minutes[1]}, nil
+}
+
+// quietUntil 判断 t 是否处于静默时段，是则返回静默结束时间（相邻的时段会合并）
+func (s *scheduler) quietUntil(t time.Time) (time.Time, bool) {
+	until, quiet := t, false
+	// 结束时间可能落在另一个静默时段内，最多合并 len(quietHours) 次
+	for i := 0; i <= len(s.quietHours); i++ {
+		extended := false
+		for _, r := range s.quietHours {
+			if end, ok := r.contains(until); ok {
+				until, quiet, extended = end, true, true
+			}
+		}
+		if !extended {
+			break
+		}
+	}
+	return until, quiet
+}
+
+// contains 判断 t 是否在该时段内，是则返回本次时段的结束时间
+func (r quietRange) contains(t time.Time) (time.Time, bool) {
+	minute := t.Hour()*60 + t.Minute()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	at := func(day, m int) time.Time {
+		return midnight.AddDate(0, 0, day).Add(time.Duration(m) * time.Minute)
+	}
+	if r.start < r.end {
+		if minute >= r.start && minute < r.end {
+			return at(0, r.end), true
+		}
+		return time.Time{}, false
+	}
+	// 跨午夜：[start, 24:00) 或 [00:00, end)
+	if minute >= r.start {
+		return at(1, r.end), true
+	}
+	if minute < r.end {
+		return at(0, r.end), true
+	}
+	return time.Time{}, false
+}
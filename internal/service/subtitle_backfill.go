@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"blueberry/internal/repository/bilibili"
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+)
+
+// SubtitleBackfillOptions 补传字幕的范围
+type SubtitleBackfillOptions struct {
+	// Dir 只处理该目录（频道目录或视频目录）下的视频；为空处理所有已上传的视频
+	Dir string
+	// Languages 只补传这些语言（YouTube 语言代码，不区分大小写）；为空补传所有缺失的语言
+	Languages []string
+	// DryRun 只列出将要补传的语言，不实际上传
+	DryRun bool
+}
+
+// SubtitleBackfillItem 单个视频单个语言的补传结果
+type SubtitleBackfillItem struct {
+	VideoDir string
+	AID      string
+	Lang     string
+	LangID   int
+	Error    string // 为空表示成功（DryRun 时表示待补传）
+}
+
+// recordSubtitleResults 将上传器返回的各语言字幕结果写入 upload_status.json
+func (s *uploadService) recordSubtitleResults(videoDir string, results []bilibili.SubtitleResult) {
+	if len(results) == 0 {
+		return
+	}
+	records := make(map[string]file.SubtitleUpload, len(results))
+	var failed []string
+	for _, r := range results {
+		record := file.SubtitleUpload{LangID: r.LangID, Status: file.UploadCompleted, SubtitleURL: r.URL}
+		if r.Err != nil {
+			record.Status = file.UploadFailed
+			record.Error = r.Err.Error()
+			failed = append(failed, r.Lang)
+		}
+		records[r.Lang] = record
+	}
+	if err := s.fileManager.MarkSubtitlesUploaded(videoDir, records); err != nil {
+		logger.Warn().Err(err).Str("video_dir", videoDir).Msg("记录字幕上传结果失败")
+		return
+	}
+	logger.Info().
+		Str("video_dir", videoDir).
+		Int("languages", len(results)).
+		Strs("failed", failed).
+		Msg("字幕上传结果已记录")
+}
+
+// BackfillSubtitles 为已发布的视频补传缺失语言的字幕：upload_status.json 中没有成功记录、稿件上也还没有的语言
+// 用上传该稿件的账号追加到稿件，账号已不在配置中的视频记为失败
+func (s *uploadService) BackfillSubtitles(ctx context.Context, opts SubtitleBackfillOptions) ([]SubtitleBackfillItem, error) {
+	dirs, err := s.fileManager.ListVideoDirsByUploadStatus(string(file.UploadCompleted))
+	if err != nil {
+		return nil, fmt.Errorf("列出已上传的视频失败: %w", err)
+	}
+	if opts.Dir != "" {
+		if dirs, err = filterDirsUnder(dirs, opts.Dir); err != nil {
+			return nil, err
+		}
+	}
+
+	var items []SubtitleBackfillItem
+	for i, videoDir := range dirs {
+		if ctx.Err() != nil {
+			return items, ctx.Err()
+		}
+		status, err := s.fileManager.LoadUploadStatus(videoDir)
+		if err != nil || status.BilibiliAID == "" {
+			continue
+		}
+		allSubtitlePaths, _ := s.fileManager.FindSubtitleFiles(videoDir)
+		missing := missingSubtitlePaths(status, allSubtitlePaths, opts.Languages)
+		if len(missing) == 0 {
+			continue
+		}
+
+		log := logger.Info().
+			Int("current", i+1).
+			Int("total", len(dirs)).
+			Str("video_dir", videoDir).
+			Str("aid", status.BilibiliAID)
+		failAll := func(msg string) {
+			for _, lang := range sortedKeys(missing) {
+				items = append(items, SubtitleBackfillItem{VideoDir: videoDir, AID: status.BilibiliAID, Lang: lang, Error: msg})
+			}
+		}
+
+		// 只用上传该稿件的账号补传，不回退到全局 cookies（其他账号无权修改该稿件）
+		account, ok := s.cfg.BilibiliAccounts[status.BilibiliAccount]
+		if !ok {
+			logger.Warn().Str("video_dir", videoDir).Str("account", status.BilibiliAccount).Msg("上传账号不在 bilibili_accounts 中，跳过补传字幕")
+			failAll(fmt.Sprintf("上传账号 %q 不在 bilibili_accounts 中", status.BilibiliAccount))
+			continue
+		}
+
+		// 稿件上已有的语言（如发布时随请求提交、或在创作中心手动添加的字幕）不再重复追加
+		remote, err := s.uploader.ArchiveSubtitles(ctx, status.BilibiliAID, account)
+		if err != nil {
+			if ctx.Err() != nil {
+				return items, ctx.Err()
+			}
+			logger.Error().Err(err).Str("video_dir", videoDir).Msg("查询稿件已有字幕失败，跳过补传")
+			failAll(err.Error())
+			continue
+		}
+		existing := s.dropRemoteSubtitles(videoDir, missing, remote, opts.DryRun)
+		if len(missing) == 0 {
+			log.Strs("existing", existing).Msg("稿件上已有这些语言的字幕，无需补传")
+			continue
+		}
+
+		if opts.DryRun {
+			for _, lang := range sortedKeys(missing) {
+				item := SubtitleBackfillItem{VideoDir: videoDir, AID: status.BilibiliAID, Lang: lang}
+				id, ok := bilibili.SubtitleLangID(s.cfg, lang)
+				if !ok {
+					item.Error = "未在 bilibili.subtitle_languages 中配置语言 ID"
+				}
+				item.LangID = id
+				items = append(items, item)
+			}
+			log.Int("languages", len(missing)).Msg("待补传字幕（dry-run）")
+			continue
+		}
+		log.Int("languages", len(missing)).Msg("开始补传字幕")
+
+		paths := make([]string, 0, len(missing))
+		for _, lang := range sortedKeys(missing) {
+			paths = append(paths, missing[lang])
+		}
+		results, err := s.uploader.AddSubtitles(ctx, status.BilibiliAID, paths, account)
+		if err != nil {
+			if ctx.Err() != nil {
+				return items, ctx.Err()
+			}
+			logger.Error().Err(err).Str("video_dir", videoDir).Msg("补传字幕失败")
+			failAll(err.Error())
+			continue
+		}
+		s.recordSubtitleResults(videoDir, results)
+		for _, r := range results {
+			item := SubtitleBackfillItem{VideoDir: videoDir, AID: status.BilibiliAID, Lang: r.Lang, LangID: r.LangID}
+			if r.Err != nil {
+				item.Error = r.Err.Error()
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// dropRemoteSubtitles 从 missing 中去掉稿件上已有的语言并返回这些语言；非 dry-run 时在 upload_status.json 中记为已上传
+func (s *uploadService) dropRemoteSubtitles(videoDir string, missing map[string]string, remoteLangIDs []int, dryRun bool) []string {
+	remote := make(map[int]bool, len(remoteLangIDs))
+	for _, id := range remoteLangIDs {
+		remote[id] = true
+	}
+	var existing []string
+	records := make(map[string]file.SubtitleUpload)
+	for _, lang := range sortedKeys(missing) {
+		id, ok := bilibili.SubtitleLangID(s.cfg, lang)
+		if !ok || !remote[id] {
+			continue
+		}
+		existing = append(existing, lang)
+		records[lang] = file.SubtitleUpload{LangID: id, Status: file.UploadCompleted}
+		delete(missing, lang)
+	}
+	if !dryRun && len(records) > 0 {
+		if err := s.fileManager.MarkSubtitlesUploaded(videoDir, records); err != nil {
+			logger.Warn().Err(err).Str("video_dir", videoDir).Msg("记录稿件上已有的字幕失败")
+		}
+	}
+	return existing
+}
+
+// missingSubtitlePaths 返回本地已下载、但尚未成功上传到稿件的字幕（key 为语言代码）
+func missingSubtitlePaths(status *file.UploadStatus, subtitlePaths []string, languages []string) map[string]string {
+	missing := make(map[string]string)
+	for _, path := range subtitlePaths {
+		lang := bilibili.SubtitleLanguage(path)
+		if lang == "" || !strings.HasSuffix(strings.ToLower(path), ".srt") {
+			continue
+		}
+		if len(languages) > 0 && !containsFold(languages, lang) {
+			continue
+		}
+		if sub := status.Subtitles[lang]; sub != nil && sub.Status == file.UploadCompleted {
+			continue
+		}
+		if prev, ok := missing[lang]; !ok || strings.Contains(filepath.Base(prev), ".frame.") {
+			missing[lang] = path
+		}
+	}
+	return missing
+}
+
+// sortedKeys 返回排序后的 map 键
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// containsFold 不区分大小写判断 list 是否包含 s
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(strings.TrimSpace(v), s) {
+			return true
+		}
+	}
+	return false
+}
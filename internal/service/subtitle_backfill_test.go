@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/bilibili"
+	"blueberry/internal/repository/file"
+)
+
+// stubSubtitleUploader 记录补传请求，稿件上已有的字幕语言由 remote 给出
+type stubSubtitleUploader struct {
+	bilibili.Uploader
+	remote []int
+	added  [][]string
+}
+
+func (u *stubSubtitleUploader) ArchiveSubtitles(ctx context.Context, aid string, account config.Account) ([]int, error) {
+	return u.remote, nil
+}
+
+func (u *stubSubtitleUploader) AddSubtitles(ctx context.Context, aid string, subtitlePaths []string, account config.Account) ([]bilibili.SubtitleResult, error) {
+	u.added = append(u.added, subtitlePaths)
+	results := make([]bilibili.SubtitleResult, 0, len(subtitlePaths))
+	for _, path := range subtitlePaths {
+		lang := bilibili.SubtitleLanguage(path)
+		results = append(results, bilibili.SubtitleResult{Lang: lang, LangID: 2, URL: "https://example.com/" + lang})
+	}
+	return results, nil
+}
+
+// uploadedVideoDir 创建已发布到 aid、带英语与简体中文字幕的视频目录
+func uploadedVideoDir(t *testing.T, env *testEnv, id, aid, account string) string {
+	t.Helper()
+	dir := env.videoDir(t, id, "Video "+id)
+	for _, lang := range []string{"en", "zh-Hans"} {
+		if err := os.WriteFile(filepath.Join(dir, "Video "+id+"."+lang+".srt"), []byte("1\n00:00:00,000 --> 00:00:01,000\nhi\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := env.repo.LinkUploadedArchive(dir, aid, account, ""); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// 稿件上已有英语字幕时只补传简体中文，英语直接记为已上传
+func TestBackfillSubtitlesSkipsLanguagesOnArchive(t *testing.T) {
+	env := newTestEnv(t, config.YouTubeChannel{Limit: 1})
+	env.cfg.Bilibili.SubtitleLanguages = map[string]int{"en": 3, "zh-Hans": 2}
+	dir := uploadedVideoDir(t, env, "vid00000001", "1001", "main")
+
+	uploader := &stubSubtitleUploader{remote: []int{3}}
+	items, err := NewUploadService(uploader, nil, nil, nil, env.repo, env.cfg).BackfillSubtitles(testContext(t), SubtitleBackfillOptions{})
+	if err != nil {
+		t.Fatalf("BackfillSubtitles 失败: %v", err)
+	}
+	if len(uploader.added) != 1 || len(uploader.added[0]) != 1 || bilibili.SubtitleLanguage(uploader.added[0][0]) != "zh-Hans" {
+		t.Fatalf("补传的字幕 = %v，期望只有 zh-Hans", uploader.added)
+	}
+	if len(items) != 1 || items[0].Lang != "zh-Hans" || items[0].Error != "" {
+		t.Fatalf("补传结果 = %+v，期望 zh-Hans 成功", items)
+	}
+
+	status, err := env.repo.LoadUploadStatus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, lang := range []string{"en", "zh-Hans"} {
+		if sub := status.Subtitles[lang]; sub == nil || sub.Status != file.UploadCompleted {
+			t.Fatalf("%s 字幕记录 = %+v，期望已上传", lang, sub)
+		}
+	}
+}
+
+// 上传账号不在 bilibili_accounts 中时不补传，缺失的语言记为失败
+func TestBackfillSubtitlesRequiresUploadAccount(t *testing.T) {
+	env := newTestEnv(t, config.YouTubeChannel{Limit: 1})
+	env.cfg.Bilibili.SubtitleLanguages = map[string]int{"en": 3, "zh-Hans": 2}
+	uploadedVideoDir(t, env, "vid00000002", "1002", "removed")
+
+	uploader := &stubSubtitleUploader{}
+	items, err := NewUploadService(uploader, nil, nil, nil, env.repo, env.cfg).BackfillSubtitles(testContext(t), SubtitleBackfillOptions{})
+	if err != nil {
+		t.Fatalf("BackfillSubtitles 失败: %v", err)
+	}
+	if len(uploader.added) != 0 {
+		t.Fatalf("账号不在配置中时不应补传，实际补传 %v", uploader.added)
+	}
+	if len(items) != 2 {
+		t.Fatalf("补传结果 = %+v，期望两个语言都记为失败", items)
+	}
+	for _, item := range items {
+		if item.Error == "" {
+			t.Fatalf("补传结果 = %+v，期望记为失败", items)
+		}
+	}
+}
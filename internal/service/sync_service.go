@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/internal/repository/youtube"
+	"blueberry/pkg/logger"
+	"blueberry/pkg/utils"
+)
+
+// SyncService 顺序同步（sync 命令）：按视频顺序逐个下载完再上传，下载→上传为一个原子单元
+type SyncService interface {
+	// SyncChannel 同步一个频道：本地没有频道信息时先解析频道，按 offset/limit（命令行覆盖优先于频道配置）选出视频，
+	// 逐个执行“下载→上传”；单个视频失败时继续下一个，账号额度用尽或检测到 bot detection 时停止领取后续视频并返回原因
+	SyncChannel(ctx context.Context, channel config.YouTubeChannel) error
+}
+
+type syncService struct {
+	downloadService DownloadService
+	uploadService   UploadService
+	fileManager     file.Repository
+	accounts        AccountSelector
+	cfg             *config.Config
+}
+
+// NewSyncService 创建并返回一个新的 SyncService 实例
+func NewSyncService(
+	downloadService DownloadService,
+	uploadService UploadService,
+	fileManager file.Repository,
+	cfg *config.Config,
+) SyncService {
+	return &syncService{
+		downloadService: downloadService,
+		uploadService:   uploadService,
+		fileManager:     fileManager,
+		accounts:        NewAccountSelector(fileManager, cfg),
+		cfg:             cfg,
+	}
+}
+
+func (s *syncService) SyncChannel(ctx context.Context, ch config.YouTubeChannel) error {
+	channelID := s.fileManager.ExtractChannelID(ch.URL)
+	channelDir := filepath.Join(s.cfg.Output.Directory, channelID)
+
+	// 确保有频道信息
+	if !s.fileManager.ChannelInfoExists(channelID) {
+		logger.Info().Str("channel_url", ch.URL).Msg("未找到频道信息，先解析频道")
+		if err := s.downloadService.ParseChannels(ctx); err != nil {
+			return fmt.Errorf("解析频道失败: %w", err)
+		}
+	}
+
+	videos, err := s.fileManager.LoadChannelInfo(channelID)
+	if err != nil || len(videos) == 0 {
+		return fmt.Errorf("加载频道视频列表失败或为空: %w", err)
+	}
+
+	// 计算有效 offset/limit（命令行 > 配置）
+	offset := 0
+	limit := 0
+	if s.cfg.YouTube.OffsetOverride != 0 || s.cfg.YouTube.LimitOverride != 0 {
+		offset = s.cfg.YouTube.OffsetOverride
+		limit = s.cfg.YouTube.LimitOverride
+	} else {
+		offset = ch.Offset
+		limit = ch.Limit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	start := offset
+	end := len(videos)
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+	if start > len(videos) {
+		start = len(videos)
+	}
+	if start < end {
+		videos = videos[start:end]
+	} else {
+		videos = []map[string]interface{}{}
+	}
+
+	logger.Info().
+		Str("channel_id", channelID).
+		Int("count", len(videos)).
+		Int("offset", offset).
+		Int("limit", limit).
+		Int("workers", s.cfg.YouTube.DownloadWorkers).
+		Msg("开始顺序处理频道视频（已应用 offset/limit）")
+
+	// download_workers > 1 时多个视频并行下载；上传共用浏览器与账号额度，仍逐个执行
+	// 账号额度用尽或 bot detection 时以 cause 取消，停止领取后续视频
+	channelCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var uploadMu sync.Mutex
+	utils.RunWorkers(channelCtx, s.cfg.YouTube.DownloadWorkers, len(videos), func(ctx context.Context, worker, i int) {
+		v := videos[i]
+		videoID, _ := v["id"].(string)
+		title, _ := v["title"].(string)
+		if videoID == "" {
+			return
+		}
+		log := logger.Logger().With().
+			Int("worker", worker).
+			Str("seq", fmt.Sprintf("%d/%d", i+1, len(videos))).
+			Str("video_id", videoID).
+			Logger()
+
+		log.Info().
+			Str("title", title).
+			Msg("处理视频")
+
+		// 目录使用 videoID（与下载服务一致）
+		videoDir := filepath.Join(channelDir, videoID)
+
+		// 如果已上传，直接跳过（避免不必要的下载与上传）
+		if s.fileManager.IsVideoUploaded(videoDir) {
+			log.Info().
+				Str("video_dir", videoDir).
+				Msg("该视频已上传（upload_status.json=completed），跳过下载与上传")
+			return
+		}
+
+		// 已在其他账号 / 服务器发布过（全局上传账本），跳过下载与上传
+		if err := CheckUploadLedger(s.cfg, s.fileManager, videoDir, videoID); err != nil {
+			return
+		}
+
+		// 先下载该视频（包含字幕/缩略图等按需步骤）
+		if err := s.downloadService.DownloadVideoDir(ctx, videoDir); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if errors.Is(err, youtube.ErrBotDetection) || strings.Contains(err.Error(), "bot detection") {
+				log.Error().Err(err).Msg("检测到 bot detection，停止同步")
+				cancel(fmt.Errorf("检测到 bot detection: %w", err))
+				return
+			}
+			log.Error().Err(err).Str("video_dir", videoDir).Msg("下载该视频失败，继续下一个")
+			return
+		}
+
+		uploadMu.Lock()
+		defer uploadMu.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+
+		// 按频道的账号选择策略选择上传账号（过滤每日 / 每小时上限与失败冷却）
+		selection, err := s.accounts.Select(videoDir, nil)
+		if err != nil {
+			log.Error().Err(err).Time("retry_at", selection.RetryAt).Msg("没有可用的B站账号，终止后续处理")
+			cancel(err)
+			return
+		}
+		accountName := selection.Account
+		log.Info().Str("account", accountName).Str("strategy", selection.Strategy).Str("reason", selection.Reason).Msg("选择上传账号")
+		// 立即上传该视频
+		if err := s.uploadService.UploadSingleVideo(ctx, videoDir, accountName); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Error().Err(err).Str("video_dir", videoDir).Msg("上传该视频失败，继续下一个")
+			return
+		}
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if cause := context.Cause(channelCtx); cause != nil {
+		return cause
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/youtube"
+	"blueberry/internal/repository/youtube/ytdlptest"
+)
+
+// recordingUploadService 只记录上传调用的 UploadService
+type recordingUploadService struct {
+	UploadService
+	mu      sync.Mutex
+	uploads []upload
+}
+
+type upload struct {
+	videoDir string
+	account  string
+}
+
+func (s *recordingUploadService) UploadSingleVideo(ctx context.Context, videoDir string, accountName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.uploads = append(s.uploads, upload{videoDir: videoDir, account: accountName})
+	return nil
+}
+
+func (e *testEnv) syncService() (SyncService, *recordingUploadService) {
+	uploads := &recordingUploadService{}
+	return NewSyncService(e.download, uploads, e.repo, e.cfg), uploads
+}
+
+func TestSyncChannelParsesDownloadsAndUploads(t *testing.T) {
+	env := newTestEnv(t, config.YouTubeChannel{Limit: 2},
+		ytdlptest.Video{ID: "vid00000001", Title: "First"},
+		ytdlptest.Video{ID: "vid00000002", Title: "Second", Scenario: ytdlptest.ScenarioNo1080p},
+		ytdlptest.Video{ID: "vid00000003", Title: "Out of range"},
+	)
+	svc, uploads := env.syncService()
+
+	if err := svc.SyncChannel(testContext(t), env.cfg.YouTubeChannels[0]); err != nil {
+		t.Fatalf("SyncChannel: %v", err)
+	}
+
+	if n := len(env.fake.Invocations(ytdlptest.ActionFlatPlaylist)); n != 1 {
+		t.Fatalf("flat-playlist invocations = %d, want 1", n)
+	}
+	channelDir := filepath.Join(env.cfg.Output.Directory, "testchannel")
+	want := []upload{
+		{videoDir: filepath.Join(channelDir, "vid00000001"), account: "main"},
+		{videoDir: filepath.Join(channelDir, "vid00000002"), account: "main"},
+	}
+	if len(uploads.uploads) != len(want) {
+		t.Fatalf("uploads = %+v, want %+v", uploads.uploads, want)
+	}
+	for i, u := range want {
+		if uploads.uploads[i] != u {
+			t.Fatalf("upload[%d] = %+v, want %+v", i, uploads.uploads[i], u)
+		}
+		if !env.repo.IsVideoDownloaded(u.videoDir) {
+			t.Fatalf("%s 未下载", u.videoDir)
+		}
+	}
+	for _, inv := range env.fake.Invocations(ytdlptest.ActionDownload) {
+		if inv.VideoID == "vid00000003" {
+			t.Fatal("limit 之外的视频不应下载")
+		}
+	}
+}
+
+func TestSyncChannelStopsOnBotDetection(t *testing.T) {
+	env := newTestEnv(t, config.YouTubeChannel{},
+		ytdlptest.Video{ID: "vid00000bot", Title: "Bot", Scenario: ytdlptest.ScenarioBotCheck},
+		ytdlptest.Video{ID: "vid00000002", Title: "Never reached"},
+	)
+	svc, uploads := env.syncService()
+
+	err := svc.SyncChannel(testContext(t), env.cfg.YouTubeChannels[0])
+	if !errors.Is(err, youtube.ErrBotDetection) {
+		t.Fatalf("err = %v, want ErrBotDetection", err)
+	}
+	if len(uploads.uploads) != 0 {
+		t.Fatalf("uploads = %+v, want none", uploads.uploads)
+	}
+	for _, inv := range env.fake.Invocations(ytdlptest.ActionDownload) {
+		if inv.VideoID != "vid00000bot" {
+			t.Fatalf("bot detection 后不应继续下载 %s", inv.VideoID)
+		}
+	}
+}
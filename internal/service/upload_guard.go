@@ -0,0 +1,50 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+)
+
+// ErrDuplicateUpload 视频已在某个账号 / 服务器上发布（全局上传账本中有记录），为避免重复上传拒绝再次上传
+var ErrDuplicateUpload = errors.New("视频已发布过，拒绝重复上传（使用 --allow-duplicate 强制上传）")
+
+// CheckUploadLedger 上传前查询全局上传账本（按 YouTube 视频 ID）；videoID 为空时从 video_info.json 读取
+// bilibili.AllowDuplicateUpload（--allow-duplicate）为 true 时只记录日志不拦截
+func CheckUploadLedger(cfg *config.Config, repo file.Repository, videoDir, videoID string) error {
+	if videoID == "" {
+		if info, err := repo.LoadVideoInfo(videoDir); err == nil && info != nil {
+			videoID = strings.TrimSpace(info.ID)
+		}
+	}
+	if videoID == "" {
+		return nil
+	}
+	entry, err := repo.LookupUploadLedger(videoID)
+	if err != nil {
+		logger.Warn().Err(err).Str("video_id", videoID).Msg("查询上传账本失败，继续上传")
+		return nil
+	}
+	if entry == nil {
+		return nil
+	}
+
+	log := logger.Warn().
+		Str("video_id", videoID).
+		Str("video_dir", videoDir).
+		Str("aid", entry.AID).
+		Str("account", entry.Account).
+		Str("host", entry.Host).
+		Str("uploaded_at", time.Unix(entry.UploadedAt, 0).Format("2006-01-02 15:04:05"))
+	if cfg != nil && cfg.Bilibili.AllowDuplicateUpload {
+		log.Msg("视频已发布过，--allow-duplicate 已指定，继续上传")
+		return nil
+	}
+	log.Msg("视频已发布过（上传账本中有记录），跳过上传")
+	return fmt.Errorf("%w: video_id=%s, aid=%s, account=%s, host=%s", ErrDuplicateUpload, videoID, entry.AID, entry.Account, entry.Host)
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"blueberry/internal/config"
+	"blueberry/internal/repository/bilibili"
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+	"blueberry/pkg/metadata"
+	"blueberry/pkg/sanitize"
+)
+
+// buildVideoMeta 生成投稿的标题、简介与标签：未配置模板的字段使用默认值（标题为视频 ID，简介为 YouTube 描述），
+// 经清洗（bilibili.sanitize）并追加重新上传序号后按 B站限制校验，清洗修改记录写入上传状态。
+// videoID 为空时从 video_info.json 读取，仍为空则使用文件名。开启定时发布时同时为 accountName 预约发布时间，
+// 频道配置了播放列表时解析 accountName 下的播放列表
+func (s *uploadService) buildVideoMeta(ctx context.Context, videoDir, videoFile, videoID, accountName string) (bilibili.VideoMeta, error) {
+	info, _ := s.fileManager.LoadVideoInfo(videoDir)
+	if videoID == "" && info != nil {
+		videoID = strings.TrimSpace(info.ID)
+	}
+	if videoID == "" {
+		videoID = s.fileManager.ExtractVideoTitleFromFile(videoFile)
+	}
+
+	rawDesc := s.readVideoDescription(videoDir, videoFile)
+	result := metadata.Result{Title: videoID, Description: rawDesc}
+
+	templates := s.channelMetadataTemplates(videoDir)
+	if templates.Title != "" || templates.Description != "" || templates.Tags != "" {
+		tmpl, err := metadata.Parse(templates.Title, templates.Description, templates.Tags)
+		if err != nil {
+			return bilibili.VideoMeta{}, err
+		}
+		data := metadataData(info, videoID, rawDesc)
+		if result, err = tmpl.Render(data, result); err != nil {
+			return bilibili.VideoMeta{}, err
+		}
+	}
+
+	sanitizer := s.sanitizer()
+	clean, changes := sanitizer.Apply(sanitize.Metadata{Title: result.Title, Description: result.Description, Tags: result.Tags})
+	result = metadata.Result{Title: clean.Title, Description: clean.Description, Tags: clean.Tags}
+
+	// 重新上传追加的序号不能被裁剪，超长时先裁剪原标题
+	title := s.resubmitTitle(videoDir, result.Title)
+	if over := utf8.RuneCountInString(title) - sanitize.MaxTitleRunes; over > 0 {
+		limit := utf8.RuneCountInString(result.Title) - over
+		result.Title = sanitizer.Title(result.Title, limit, func(field, rule, detail string) {
+			changes = append(changes, sanitize.Change{Field: field, Rule: rule, Detail: detail})
+		})
+		title = s.resubmitTitle(videoDir, result.Title)
+	}
+	result.Title = title
+
+	if err := metadata.Validate(result); err != nil {
+		return bilibili.VideoMeta{}, fmt.Errorf("投稿信息不符合 B站限制: %w", err)
+	}
+
+	record := file.PublishedMetadata{
+		Title:             result.Title,
+		Tags:              result.Tags,
+		DescriptionLength: utf8.RuneCountInString(result.Description),
+	}
+	for _, c := range changes {
+		record.Changes = append(record.Changes, file.MetadataChange{Field: c.Field, Rule: c.Rule, Detail: c.Detail})
+	}
+	if len(changes) > 0 {
+		logger.Info().Str("video_dir", videoDir).Int("changes", len(changes)).Interface("details", changes).Msg("投稿信息已清洗")
+	}
+	if err := s.fileManager.RecordPublishedMetadata(videoDir, record); err != nil {
+		logger.Warn().Err(err).Str("video_dir", videoDir).Msg("记录投稿信息失败")
+	}
+	publishAt, err := s.planPublishTime(videoDir, accountName)
+	if err != nil {
+		return bilibili.VideoMeta{}, err
+	}
+	// 播放列表不可用时照常发布，之后可用 playlist backfill 补加
+	playlistID, err := s.resolvePlaylist(ctx, videoDir, accountName, true)
+	if err != nil {
+		logger.Warn().Err(err).Str("video_dir", videoDir).Str("account", accountName).Msg("解析播放列表失败，本次发布不加入播放列表")
+	}
+	return bilibili.VideoMeta{Title: result.Title, Desc: result.Description, Tags: result.Tags, PublishAt: publishAt, PlaylistID: playlistID}, nil
+}
+
+// sanitizer 按 bilibili.sanitize 创建投稿信息清洗器
+func (s *uploadService) sanitizer() *sanitize.Sanitizer {
+	rules := s.cfg.Bilibili.Sanitize
+	return sanitize.New(sanitize.Rules{
+		BannedPhrases:   rules.BannedPhrases,
+		BannedDomains:   rules.BannedDomains,
+		StripLinks:      rules.StripLinks,
+		StripTitleEmoji: rules.StripTitleEmoji,
+	})
+}
+
+// channelMetadataTemplates 返回视频所属频道的投稿模板，未匹配时使用 bilibili.metadata
+func (s *uploadService) channelMetadataTemplates(videoDir string) config.MetadataTemplates {
+	templates := s.cfg.Bilibili.Metadata
+	if ch, ok := s.videoChannel(videoDir); ok {
+		return templates.Merge(ch.Metadata)
+	}
+	return templates
+}
+
+// videoChannel 按频道目录名匹配视频所属的 youtube_channels 配置
+func (s *uploadService) videoChannel(videoDir string) (config.YouTubeChannel, bool) {
+	return findChannelByDir(s.cfg, s.fileManager, filepath.Base(filepath.Dir(videoDir)))
+}
+
+// findChannelByDir 按频道目录名查找 youtube_channels 配置
+func findChannelByDir(cfg *config.Config, fileManager file.Repository, channelID string) (config.YouTubeChannel, bool) {
+	for _, ch := range cfg.YouTubeChannels {
+		if fileManager.ExtractChannelID(ch.URL) == channelID {
+			return ch, true
+		}
+	}
+	return config.YouTubeChannel{}, false
+}
+
+// metadataData 将 video_info.json 转换为模板数据
+func metadataData(info *file.VideoInfo, videoID, description string) metadata.Data {
+	data := metadata.Data{ID: videoID, Title: videoID, Description: description}
+	if info == nil {
+		return data
+	}
+	data.Title = info.Title
+	data.Channel = info.Channel
+	data.ChannelID = info.ChannelID
+	data.ChannelURL = info.ChannelURL
+	data.Uploader = info.Uploader
+	if t, err := time.ParseInLocation("20060102", info.UploadDate, time.Local); err == nil {
+		data.UploadDate = t
+	}
+	data.Duration = time.Duration(info.Duration * float64(time.Second))
+	data.DurationString = info.DurationString
+	data.Playlist = info.PlaylistTitle
+	if data.Playlist == "" {
+		data.Playlist = info.Playlist
+	}
+	data.PlaylistIndex = info.PlaylistIndex
+	data.URL = info.WebpageURL
+	if data.URL == "" {
+		data.URL = info.OriginalURL
+	}
+	if data.URL == "" {
+		data.URL = info.URL
+	}
+	if tags, ok := info.RawData["tags"].([]interface{}); ok {
+		for _, tag := range tags {
+			if s, ok := tag.(string); ok && s != "" {
+				data.Tags = append(data.Tags, s)
+			}
+		}
+	}
+	return data
+}
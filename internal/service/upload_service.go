@@ -3,7 +3,6 @@ package service
 import (
 	"context"
 	"fmt"
-	"math/rand"
 	"os"
 	"path/filepath"
 	"strings"
@@ -30,7 +29,7 @@ type UploadService interface {
 	UploadChannel(ctx context.Context, channelURL string) error
 
 	// UploadChannelDir 直接根据本地频道目录上传该目录下的所有视频
-	// 不依赖配置中的频道 URL；账号从全局账号池中随机选择（受每日上限限制）
+	// 不依赖配置中的频道 URL；每个视频按 account_selection 策略选择账号（受每日 / 每小时上限与失败冷却限制）
 	UploadChannelDir(ctx context.Context, channelDir string) error
 
 	// UploadAllChannels 上传配置文件中所有频道下已下载的视频
@@ -43,10 +42,21 @@ type UploadService interface {
 	// aid: Bilibili视频ID，用于生成新的文件名
 	// 返回重命名后的字幕文件路径列表
 	RenameSubtitlesForAID(subtitlePaths []string, aid string) ([]string, error)
+
+	// BackfillSubtitles 为已发布的视频补传缺失语言的字幕，返回每个视频每个语言的结果
+	BackfillSubtitles(ctx context.Context, opts SubtitleBackfillOptions) ([]SubtitleBackfillItem, error)
+
+	// VerifyUploads 查询已上传视频的审核 / 转码状态并记录，按配置将退回的视频重新排队上传
+	VerifyUploads(ctx context.Context, opts VerifyOptions) ([]VerifyItem, error)
+
+	// BackfillPlaylists 将已发布、尚未加入频道播放列表的视频加入播放列表并按原始顺序排列
+	BackfillPlaylists(ctx context.Context, opts PlaylistBackfillOptions) ([]PlaylistBackfillItem, error)
 }
 
 type uploadService struct {
 	uploader        bilibili.Uploader
+	playlists       bilibili.Playlists
+	accounts        AccountSelector
 	parser          youtube.Parser
 	subtitleManager youtube.SubtitleManager
 	fileManager     file.Repository
@@ -57,6 +67,7 @@ type uploadService struct {
 // 直接接收 Repository 层依赖，不通过中间层
 func NewUploadService(
 	uploader bilibili.Uploader,
+	playlists bilibili.Playlists,
 	parser youtube.Parser,
 	subtitleManager youtube.SubtitleManager,
 	fileManager file.Repository,
@@ -64,6 +75,8 @@ func NewUploadService(
 ) UploadService {
 	return &uploadService{
 		uploader:        uploader,
+		playlists:       playlists,
+		accounts:        NewAccountSelector(fileManager, cfg),
 		parser:          parser,
 		subtitleManager: subtitleManager,
 		fileManager:     fileManager,
@@ -75,34 +88,24 @@ func (s *uploadService) RenameSubtitlesForAID(subtitlePaths []string, aid string
 	return s.subtitleManager.RenameSubtitlesForAID(subtitlePaths, aid, s.cfg.Output.Directory)
 }
 
-// selectAvailableAccount 随机选择当日未达上限的账号
-func (s *uploadService) selectAvailableAccount() (string, bool) {
-	names := make([]string, 0, len(s.cfg.BilibiliAccounts))
-	for name := range s.cfg.BilibiliAccounts {
-		names = append(names, name)
+// uploadVideo 调用上传器上传视频，并将结果计入账号的今日上传数与账号选择状态（成功计入每小时上传数，失败开始冷却）
+func (s *uploadService) uploadVideo(ctx context.Context, videoFile string, meta bilibili.VideoMeta, subtitlePaths []string, accountName string) (*bilibili.UploadResult, error) {
+	result, err := s.uploader.UploadVideo(ctx, videoFile, meta, subtitlePaths, s.cfg.BilibiliAccounts[accountName])
+	if ctx.Err() != nil {
+		return result, err
 	}
-	if len(names) == 0 {
-		return "", false
-	}
-	counts, err := s.fileManager.LoadTodayUploadCounts()
-	if err != nil {
-		counts = map[string]int{}
-	}
-	limit := s.cfg.Bilibili.DailyUploadLimit
-	if limit <= 0 {
-		limit = 160
-	}
-	available := make([]string, 0, len(names))
-	for _, n := range names {
-		if counts[n] < limit {
-			available = append(available, n)
+	switch {
+	case err != nil:
+		s.accounts.RecordResult(accountName, err)
+	case result != nil && result.Success:
+		if err := s.fileManager.IncrementTodayUploadCount(accountName); err != nil {
+			logger.Warn().Err(err).Str("account", accountName).Msg("更新账号当日上传计数失败")
 		}
+		s.accounts.RecordResult(accountName, nil)
+	case result != nil && result.Error != nil:
+		s.accounts.RecordResult(accountName, result.Error)
 	}
-	if len(available) == 0 {
-		return "", false
-	}
-	rand.Seed(time.Now().UnixNano())
-	return available[rand.Intn(len(available))], true
+	return result, err
 }
 
 func (s *uploadService) UploadSingleVideo(ctx context.Context, videoPath string, accountName string) error {
@@ -111,6 +114,17 @@ func (s *uploadService) UploadSingleVideo(ctx context.Context, videoPath string,
 		logger.Error().Str("account", accountName).Msg("账号不存在")
 		return nil
 	}
+	if quarantines, err := s.accounts.Quarantines(); err == nil {
+		if q, ok := quarantines[accountName]; ok {
+			until := time.Unix(q.Until, 0)
+			logger.Error().
+				Str("account", accountName).
+				Str("kind", q.Kind).
+				Time("until", until).
+				Msg("账号处于隔离期，跳过上传（确认账号恢复后可用 accounts release 解除）")
+			return fmt.Errorf("%w: %s（%s，至 %s）", ErrAccountQuarantined, accountName, q.Kind, until.Format("2006-01-02 15:04"))
+		}
+	}
 
 	// 允许传入“目录或文件”。目录时在目录中查找实际视频文件。
 	videoDir := videoPath
@@ -170,6 +184,9 @@ func (s *uploadService) UploadSingleVideo(ctx context.Context, videoPath string,
 			Msg("视频已上传（upload_status.json 已完成），跳过上传")
 		return nil
 	}
+	if err := CheckUploadLedger(s.cfg, s.fileManager, videoDir, ""); err != nil {
+		return err
+	}
 
 	// 在检查视频/图片等文件之前，检查下载状态是否完成
 	status, downloaded, _, err := s.fileManager.GetDownloadVideoStatus(videoDir)
@@ -190,12 +207,8 @@ func (s *uploadService) UploadSingleVideo(ctx context.Context, videoPath string,
 	}
 
 	allSubtitlePaths, _ := s.fileManager.FindSubtitleFiles(videoDir)
-	// 优先选择英文字幕
-	subtitlePaths := s.filterEnglishSubtitles(allSubtitlePaths)
-	if len(subtitlePaths) == 0 {
-		// 如果没有英文字幕，使用所有字幕
-		subtitlePaths = allSubtitlePaths
-	}
+	// 上传所有已下载的语言（上传器按 bilibili.subtitle_languages 映射语言 ID）
+	subtitlePaths := allSubtitlePaths
 	if !s.cfg.Bilibili.UploadSubtitles {
 		subtitlePaths = []string{}
 		logger.Info().Msg("已禁用字幕上传（bilibili.upload_subtitles=false）")
@@ -204,17 +217,16 @@ func (s *uploadService) UploadSingleVideo(ctx context.Context, videoPath string,
 		Int("total_subtitles", len(allSubtitlePaths)).
 		Int("selected_subtitles", len(subtitlePaths)).
 		Msg("字幕文件选择完成")
-	// 使用 video_id 作为标题；若无法获取则回退到文件名，同时获取描述（优先 .description）
-	videoTitle := ""
-	videoDesc := s.getVideoDescription(videoDir, videoFile)
-	if info, err := s.fileManager.LoadVideoInfo(videoDir); err == nil && info != nil {
-		if id := strings.TrimSpace(info.ID); id != "" {
-			videoTitle = id
+	// 按模板生成标题 / 简介 / 标签（默认使用 video_id 作为标题，若无法获取则回退到文件名；描述优先 .description）
+	meta, err := s.buildVideoMeta(ctx, videoDir, videoFile, "", accountName)
+	if err != nil {
+		logger.Error().Err(err).Str("video_dir", videoDir).Msg("生成投稿信息失败，跳过上传")
+		if markErr := s.fileManager.MarkVideoUploadFailed(videoDir, err.Error()); markErr != nil {
+			logger.Warn().Err(markErr).Msg("标记上传失败状态失败")
 		}
+		return err
 	}
-	if videoTitle == "" {
-		videoTitle = s.fileManager.ExtractVideoTitleFromFile(videoFile)
-	}
+	videoTitle := meta.Title
 
 	logger.Info().
 		Str("video_dir", videoDir).
@@ -227,9 +239,15 @@ func (s *uploadService) UploadSingleVideo(ctx context.Context, videoPath string,
 		logger.Warn().Err(err).Msg("标记上传状态失败")
 	}
 
-	result, err := s.uploader.UploadVideo(ctx, videoFile, videoTitle, videoDesc, subtitlePaths, account)
+	result, err := s.uploadVideo(ctx, videoFile, meta, subtitlePaths, accountName)
+	if err != nil && ctx.Err() != nil {
+		s.releasePublishSlot(videoDir, accountName, meta)
+		s.rollbackInterruptedUpload(videoDir)
+		return ctx.Err()
+	}
 	if err != nil {
 		logger.Error().Err(err).Msg("上传失败")
+		s.releasePublishSlot(videoDir, accountName, meta)
 		// 标记上传失败
 		if markErr := s.fileManager.MarkVideoUploadFailed(videoDir, err.Error()); markErr != nil {
 			logger.Warn().Err(markErr).Msg("标记上传失败状态失败")
@@ -249,10 +267,6 @@ func (s *uploadService) UploadSingleVideo(ctx context.Context, videoPath string,
 			Str("account", accountName).
 			Str("userid", account.UserID).
 			Msg("上传成功")
-		// 增加账号当日上传计数
-		if err := s.fileManager.IncrementTodayUploadCount(accountName); err != nil {
-			logger.Warn().Err(err).Str("account", accountName).Msg("更新账号当日上传计数失败")
-		}
 		// 标记上传完成（保存到 upload_status.json，下次运行时会跳过）
 		if err := s.fileManager.MarkVideoUploaded(videoDir, result.VideoID, accountName, account.UserID, fileSize); err != nil {
 			logger.Warn().Err(err).Msg("标记上传完成状态失败")
@@ -262,6 +276,9 @@ func (s *uploadService) UploadSingleVideo(ctx context.Context, videoPath string,
 				Str("video_dir", videoDir).
 				Msg("上传状态已保存到 upload_status.json，下次运行将自动跳过此视频")
 		}
+		s.recordSubtitleResults(videoDir, result.Subtitles)
+		s.recordScheduledPublish(videoDir, meta)
+		s.assignPlaylist(ctx, videoDir, accountName, meta, result)
 		// 按配置删除本地原视频文件
 		if s.cfg.Bilibili.DeleteOriginalAfterUpload {
 			if err := os.Remove(videoFile); err != nil {
@@ -314,15 +331,13 @@ func (s *uploadService) UploadChannel(ctx context.Context, channelURL string) er
 		return nil
 	}
 
-	// 选择当日未达上限的可用账号（随机）
-	accountName, ok := s.selectAvailableAccount()
-	if !ok {
-		logger.Error().Msg("没有可用的B站账号（当日额度已用尽）")
-		return fmt.Errorf("no available bilibili account today")
+	// 账号按频道的选择策略逐个视频选择，这里只确认仍有可用账号
+	if ok, retryAt := s.accounts.Available(nil); !ok {
+		logger.Error().Time("retry_at", retryAt).Msg("没有可用的B站账号（已达上传上限或处于失败冷却期）")
+		return ErrNoAccountAvailable
 	}
-	account := s.cfg.BilibiliAccounts[accountName]
 
-	logger.Info().Str("channel_url", channelURL).Str("account", accountName).Msg("开始处理频道上传")
+	logger.Info().Str("channel_url", channelURL).Msg("开始处理频道上传")
 
 	channelID := s.fileManager.ExtractChannelID(channelURL)
 
@@ -359,6 +374,9 @@ func (s *uploadService) UploadChannel(ctx context.Context, channelURL string) er
 	}
 
 	for i, videoMap := range videos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		videoID, _ := videoMap["id"].(string)
 		title, _ := videoMap["title"].(string)
 
@@ -393,6 +411,9 @@ func (s *uploadService) UploadChannel(ctx context.Context, channelURL string) er
 				Msg("视频已上传，跳过")
 			continue
 		}
+		if CheckUploadLedger(s.cfg, s.fileManager, videoDir, videoID) != nil {
+			continue
+		}
 
 		// 在检查视频/图片等文件之前，检查下载状态是否完成
 		status, downloaded, _, err := s.fileManager.GetDownloadVideoStatus(videoDir)
@@ -455,12 +476,8 @@ func (s *uploadService) UploadChannel(ctx context.Context, channelURL string) er
 		}
 
 		allSubtitlePaths, _ := s.fileManager.FindSubtitleFiles(videoDir)
-		// 优先选择英文字幕
-		subtitlePaths := s.filterEnglishSubtitles(allSubtitlePaths)
-		if len(subtitlePaths) == 0 {
-			// 如果没有英文字幕，使用所有字幕
-			subtitlePaths = allSubtitlePaths
-		}
+		// 上传所有已下载的语言（上传器按 bilibili.subtitle_languages 映射语言 ID）
+		subtitlePaths := allSubtitlePaths
 		if !s.cfg.Bilibili.UploadSubtitles {
 			subtitlePaths = []string{}
 			logger.Info().Msg("已禁用字幕上传（bilibili.upload_subtitles=false）")
@@ -470,9 +487,26 @@ func (s *uploadService) UploadChannel(ctx context.Context, channelURL string) er
 			Int("selected_subtitles", len(subtitlePaths)).
 			Msg("字幕文件选择完成")
 
-		// 使用 video_id 作为标题，加载描述
-		videoTitle := videoID
-		videoDesc := s.getVideoDescription(videoDir, videoFile)
+		// 按频道的账号选择策略为该视频选择上传账号
+		selection, err := s.accounts.Select(videoDir, nil)
+		if err != nil {
+			logger.Error().Err(err).Msg("没有可用的B站账号，停止处理该频道")
+			return err
+		}
+		accountName := selection.Account
+		account := s.cfg.BilibiliAccounts[accountName]
+		logger.Info().Str("account", accountName).Str("strategy", selection.Strategy).Str("reason", selection.Reason).Msg("选择上传账号")
+
+		// 按模板生成标题 / 简介 / 标签（默认使用 video_id 作为标题）
+		meta, err := s.buildVideoMeta(ctx, videoDir, videoFile, videoID, accountName)
+		if err != nil {
+			logger.Error().Err(err).Str("video_id", videoID).Msg("生成投稿信息失败，跳过该视频")
+			if markErr := s.fileManager.MarkVideoUploadFailed(videoDir, err.Error()); markErr != nil {
+				logger.Warn().Err(markErr).Msg("标记上传失败状态失败")
+			}
+			continue
+		}
+		videoTitle := meta.Title
 
 		// 检查封面图是否存在（必需，上传器缺失时会直接退出）
 		coverPath, _ := s.fileManager.FindCoverFile(videoDir)
@@ -493,10 +527,16 @@ func (s *uploadService) UploadChannel(ctx context.Context, channelURL string) er
 			logger.Warn().Err(err).Msg("标记上传状态失败")
 		}
 
-		result, err := s.uploader.UploadVideo(ctx, videoFile, videoTitle, videoDesc, subtitlePaths, account)
+		result, err := s.uploadVideo(ctx, videoFile, meta, subtitlePaths, accountName)
+		if err != nil && ctx.Err() != nil {
+			s.releasePublishSlot(videoDir, accountName, meta)
+			s.rollbackInterruptedUpload(videoDir)
+			return ctx.Err()
+		}
 		if err != nil {
 			errorMsg := err.Error()
 			logger.Error().Err(err).Str("title", videoTitle).Msg("上传失败，跳过该视频继续下一个")
+			s.releasePublishSlot(videoDir, accountName, meta)
 			// 标记上传失败
 			if markErr := s.fileManager.MarkVideoUploadFailed(videoDir, errorMsg); markErr != nil {
 				logger.Warn().Err(markErr).Msg("标记上传失败状态失败")
@@ -530,6 +570,9 @@ func (s *uploadService) UploadChannel(ctx context.Context, channelURL string) er
 					Str("video_dir", videoDir).
 					Msg("上传状态已保存到 upload_status.json，下次运行将自动跳过此视频")
 			}
+			s.recordSubtitleResults(videoDir, result.Subtitles)
+			s.recordScheduledPublish(videoDir, meta)
+			s.assignPlaylist(ctx, videoDir, accountName, meta, result)
 
 			// 按配置删除本地原视频文件
 			if s.cfg.Bilibili.DeleteOriginalAfterUpload {
@@ -581,14 +624,12 @@ func (s *uploadService) UploadChannel(ctx context.Context, channelURL string) er
 
 // UploadChannelDir 根据本地频道目录上传
 func (s *uploadService) UploadChannelDir(ctx context.Context, channelDir string) error {
-	accountName, ok := s.selectAvailableAccount()
-	if !ok {
-		logger.Error().Msg("没有可用的B站账号（当日额度已用尽）")
-		return fmt.Errorf("no available bilibili account today")
+	if ok, retryAt := s.accounts.Available(nil); !ok {
+		logger.Error().Time("retry_at", retryAt).Msg("没有可用的B站账号（已达上传上限或处于失败冷却期）")
+		return ErrNoAccountAvailable
 	}
-	account := s.cfg.BilibiliAccounts[accountName]
 
-	logger.Info().Str("channel_dir", channelDir).Str("account", accountName).Msg("开始处理频道目录上传")
+	logger.Info().Str("channel_dir", channelDir).Msg("开始处理频道目录上传")
 
 	// 推导 channelID（目录名）
 	channelID := filepath.Base(channelDir)
@@ -624,6 +665,9 @@ func (s *uploadService) UploadChannelDir(ctx context.Context, channelDir string)
 	}
 
 	for i, videoMap := range videos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		videoID, _ := videoMap["id"].(string)
 		title, _ := videoMap["title"].(string)
 		if videoID == "" {
@@ -648,6 +692,9 @@ func (s *uploadService) UploadChannelDir(ctx context.Context, channelDir string)
 			logger.Info().Str("video_id", videoID).Msg("视频已上传，跳过")
 			continue
 		}
+		if CheckUploadLedger(s.cfg, s.fileManager, videoDir, videoID) != nil {
+			continue
+		}
 
 		// 在检查视频/图片等文件之前，检查下载状态是否完成
 		status, downloaded, _, err := s.fileManager.GetDownloadVideoStatus(videoDir)
@@ -707,18 +754,32 @@ func (s *uploadService) UploadChannelDir(ctx context.Context, channelDir string)
 		}
 
 		allSubtitlePaths, _ := s.fileManager.FindSubtitleFiles(videoDir)
-		subtitlePaths := s.filterEnglishSubtitles(allSubtitlePaths)
-		if len(subtitlePaths) == 0 {
-			subtitlePaths = allSubtitlePaths
-		}
+		subtitlePaths := allSubtitlePaths
 		if !s.cfg.Bilibili.UploadSubtitles {
 			subtitlePaths = []string{}
 			logger.Info().Msg("已禁用字幕上传（bilibili.upload_subtitles=false）")
 		}
 
-		// 使用 video_id 作为标题，加载描述
-		videoTitle := videoID
-		videoDesc := s.getVideoDescription(videoDir, videoFile)
+		// 按频道的账号选择策略为该视频选择上传账号
+		selection, err := s.accounts.Select(videoDir, nil)
+		if err != nil {
+			logger.Error().Err(err).Msg("没有可用的B站账号，停止处理该频道")
+			return err
+		}
+		accountName := selection.Account
+		account := s.cfg.BilibiliAccounts[accountName]
+		logger.Info().Str("account", accountName).Str("strategy", selection.Strategy).Str("reason", selection.Reason).Msg("选择上传账号")
+
+		// 按模板生成标题 / 简介 / 标签（默认使用 video_id 作为标题）
+		meta, err := s.buildVideoMeta(ctx, videoDir, videoFile, videoID, accountName)
+		if err != nil {
+			logger.Error().Err(err).Str("video_id", videoID).Msg("生成投稿信息失败，跳过该视频")
+			if markErr := s.fileManager.MarkVideoUploadFailed(videoDir, err.Error()); markErr != nil {
+				logger.Warn().Err(markErr).Msg("标记上传失败状态失败")
+			}
+			continue
+		}
+		videoTitle := meta.Title
 
 		logger.Info().
 			Str("video_file", videoFile).
@@ -730,10 +791,16 @@ func (s *uploadService) UploadChannelDir(ctx context.Context, channelDir string)
 			logger.Warn().Err(err).Msg("标记上传状态失败")
 		}
 
-		result, err := s.uploader.UploadVideo(ctx, videoFile, videoTitle, videoDesc, subtitlePaths, account)
+		result, err := s.uploadVideo(ctx, videoFile, meta, subtitlePaths, accountName)
+		if err != nil && ctx.Err() != nil {
+			s.releasePublishSlot(videoDir, accountName, meta)
+			s.rollbackInterruptedUpload(videoDir)
+			return ctx.Err()
+		}
 		if err != nil {
 			errorMsg := err.Error()
 			logger.Error().Err(err).Str("title", videoTitle).Msg("上传失败")
+			s.releasePublishSlot(videoDir, accountName, meta)
 			if markErr := s.fileManager.MarkVideoUploadFailed(videoDir, errorMsg); markErr != nil {
 				logger.Warn().Err(markErr).Msg("标记上传失败状态失败")
 			}
@@ -757,6 +824,9 @@ func (s *uploadService) UploadChannelDir(ctx context.Context, channelDir string)
 			if err := s.fileManager.MarkVideoUploaded(videoDir, result.VideoID, accountName, account.UserID, fileSize); err != nil {
 				logger.Warn().Err(err).Msg("标记上传完成状态失败")
 			}
+			s.recordSubtitleResults(videoDir, result.Subtitles)
+			s.recordScheduledPublish(videoDir, meta)
+			s.assignPlaylist(ctx, videoDir, accountName, meta, result)
 			// 按配置删除本地原视频文件
 			if s.cfg.Bilibili.DeleteOriginalAfterUpload {
 				if err := os.Remove(videoFile); err != nil {
@@ -796,34 +866,14 @@ func (s *uploadService) UploadChannelDir(ctx context.Context, channelDir string)
 	return nil
 }
 
-// filterEnglishSubtitles 从字幕文件列表中筛选出英文字幕
-// 优先匹配 "en"、"en-US"、"en-GB" 等英语变体
-func (s *uploadService) filterEnglishSubtitles(subtitlePaths []string) []string {
-	var englishSubtitles []string
-	for _, path := range subtitlePaths {
-		base := filepath.Base(path)
-		ext := filepath.Ext(base)
-		name := strings.TrimSuffix(base, ext)
-
-		// 检查文件名中是否包含英语标识
-		// 匹配模式：.en.、.en-US.、.en-GB.、-en.、-en-US. 等
-		if strings.Contains(name, ".en.") ||
-			strings.Contains(name, ".en-") ||
-			strings.Contains(name, "-en.") ||
-			strings.Contains(name, "-en-") ||
-			strings.HasSuffix(name, ".en") ||
-			strings.HasSuffix(name, "-en") {
-			englishSubtitles = append(englishSubtitles, path)
-		}
-	}
-	return englishSubtitles
-}
-
 func (s *uploadService) UploadAllChannels(ctx context.Context) error {
 	for _, channel := range s.cfg.YouTubeChannels {
 		logger.Info().Str("channel_url", channel.URL).Msg("处理频道")
 
 		if err := s.UploadChannel(ctx, channel.URL); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
 			logger.Error().Err(err).Msg("处理频道失败")
 			continue
 		}
@@ -832,9 +882,8 @@ func (s *uploadService) UploadAllChannels(ctx context.Context) error {
 	return nil
 }
 
-// getVideoDescription 优先从与视频同名的 .description 文件读取描述，若不存在则回退到 video_info.json 的 Description
-// 如果描述超过1500字符，会在换行或空格处裁剪
-func (s *uploadService) getVideoDescription(videoDir string, videoFile string) string {
+// readVideoDescription 优先从与视频同名的 .description 文件读取描述，若不存在则回退到 video_info.json 的 Description
+func (s *uploadService) readVideoDescription(videoDir string, videoFile string) string {
 	base := strings.TrimSuffix(videoFile, filepath.Ext(videoFile))
 	descPath := base + ".description"
 	var desc string
@@ -851,53 +900,5 @@ func (s *uploadService) getVideoDescription(videoDir string, videoFile string) s
 			}
 		}
 	}
-
-	// 裁剪描述到1500字符以内，尽量在换行或空格处裁剪
-	return s.truncateDescription(desc)
-}
-
-// truncateDescription 裁剪描述到1500字符以内，尽量在换行或空格处裁剪
-func (s *uploadService) truncateDescription(desc string) string {
-	const maxLength = 1500
-	if len(desc) <= maxLength {
-		return desc
-	}
-
-	// 从maxLength位置向前查找最近的换行符或空格
-	// 优先查找换行符，其次查找空格
-	truncatePos := maxLength
-	foundNewline := false
-
-	// 先查找换行符（向前查找最多200个字符）
-	for i := maxLength; i > maxLength-200 && i > 0; i-- {
-		char := desc[i-1]
-		if char == '\n' || char == '\r' {
-			// 找到换行符，在换行符之后裁剪
-			truncatePos = i
-			foundNewline = true
-			break
-		}
-	}
-
-	// 如果没找到换行符，查找空格（向前查找最多100个字符）
-	if !foundNewline {
-		for i := maxLength; i > maxLength-100 && i > 0; i-- {
-			if desc[i-1] == ' ' {
-				// 找到空格，在空格之后裁剪
-				truncatePos = i
-				break
-			}
-		}
-	}
-
-	truncated := desc[:truncatePos]
-	// 移除末尾的空白字符
-	truncated = strings.TrimRight(truncated, " \n\r\t")
-
-	logger.Info().
-		Int("original_length", len(desc)).
-		Int("truncated_length", len(truncated)).
-		Msg("描述已裁剪")
-
-	return truncated
+	return desc
 }
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"blueberry/internal/repository/file"
+	"blueberry/pkg/logger"
+	"blueberry/pkg/utils"
+)
+
+// VerifyOptions verify-uploads 的检查范围
+type VerifyOptions struct {
+	// Dir 只检查该目录（频道目录或视频目录）下的视频；为空检查所有已上传的视频
+	Dir string
+	// All 同时重新检查已开放浏览 / 已删除的稿件（默认跳过）
+	All bool
+	// Requeue 本次将退回的视频重新排队上传（不受 verify.auto_requeue_rejected 影响）
+	Requeue bool
+	// Queue 可选：重新排队的视频同时加入 pipeline 上传队列
+	Queue file.UploadQueue
+}
+
+// VerifyItem 单个稿件的检查结果
+type VerifyItem struct {
+	VideoDir     string
+	AID          string
+	State        file.ReviewState
+	Code         int
+	Desc         string
+	RejectReason string
+	Changed      bool   // 与上次检查相比状态是否变化
+	Requeued     bool   // 已重新排队上传
+	Error        string // 查询或重新排队失败的原因
+}
+
+// VerifyUploads 查询每个已上传视频的审核 / 转码状态并记录到上传状态；
+// 审核退回或转码失败的视频按配置重新排队上传（使用修改后的标题与封面）
+func (s *uploadService) VerifyUploads(ctx context.Context, opts VerifyOptions) ([]VerifyItem, error) {
+	dirs, err := s.fileManager.ListVideoDirsByUploadStatus(string(file.UploadCompleted))
+	if err != nil {
+		return nil, fmt.Errorf("列出已上传的视频失败: %w", err)
+	}
+	if opts.Dir != "" {
+		if dirs, err = filterDirsUnder(dirs, opts.Dir); err != nil {
+			return nil, err
+		}
+	}
+	requeue := opts.Requeue || s.cfg.Verify.AutoRequeueRejected
+	interval := time.Duration(s.cfg.Verify.RequestIntervalMs) * time.Millisecond
+
+	var items []VerifyItem
+	queried := 0
+	for _, videoDir := range dirs {
+		if ctx.Err() != nil {
+			return items, ctx.Err()
+		}
+		status, err := s.fileManager.LoadUploadStatus(videoDir)
+		if err != nil || status.BilibiliAID == "" {
+			continue
+		}
+		if !opts.All && status.Review != nil && status.Review.State.Final() {
+			continue
+		}
+
+		if queried > 0 && interval > 0 {
+			select {
+			case <-ctx.Done():
+				return items, ctx.Err()
+			case <-time.After(interval):
+			}
+		}
+		queried++
+
+		item := VerifyItem{VideoDir: videoDir, AID: status.BilibiliAID}
+		account := s.cfg.BilibiliAccounts[status.BilibiliAccount]
+		review, err := s.uploader.GetArchiveStatus(ctx, status.BilibiliAID, account)
+		if err != nil {
+			if ctx.Err() != nil {
+				return items, ctx.Err()
+			}
+			logger.Warn().Err(err).Str("video_dir", videoDir).Str("aid", status.BilibiliAID).Msg("查询稿件状态失败")
+			item.Error = err.Error()
+			items = append(items, item)
+			continue
+		}
+		item.State = review.State
+		item.Code = review.Code
+		item.Desc = review.Desc
+		item.RejectReason = review.RejectReason
+		item.Changed = status.Review == nil || status.Review.State != review.State
+		if err := s.fileManager.MarkVideoReviewStatus(videoDir, *review); err != nil {
+			logger.Warn().Err(err).Str("video_dir", videoDir).Msg("记录审核状态失败")
+		}
+
+		log := logger.Info()
+		if review.State.NeedsResubmit() {
+			log = logger.Warn()
+		}
+		log.Str("video_dir", videoDir).
+			Str("aid", status.BilibiliAID).
+			Str("state", string(review.State)).
+			Int("code", review.Code).
+			Str("desc", review.Desc).
+			Str("reject_reason", review.RejectReason).
+			Bool("changed", item.Changed).
+			Msg("稿件状态")
+
+		if review.State.NeedsResubmit() && requeue {
+			if err := s.resubmitRejected(ctx, videoDir, status, opts.Queue); err != nil {
+				logger.Warn().Err(err).Str("video_dir", videoDir).Msg("重新排队上传失败")
+				item.Error = err.Error()
+			} else {
+				item.Requeued = true
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// resubmitRejected 将退回的视频重新排队上传：从视频中截取另一帧作为新封面，上传状态回到 pending（标题在上传时追加序号）
+func (s *uploadService) resubmitRejected(ctx context.Context, videoDir string, status *file.UploadStatus, queue file.UploadQueue) error {
+	maxResubmissions := s.cfg.Verify.MaxResubmissions
+	if status.Resubmissions >= maxResubmissions {
+		return fmt.Errorf("已重新上传 %d 次，达到 verify.max_resubmissions 上限", status.Resubmissions)
+	}
+	videoFile, err := s.fileManager.FindVideoFile(videoDir)
+	if err != nil || videoFile == "" {
+		return fmt.Errorf("本地视频文件不存在（可能已按 delete_original_after_upload 删除），无法重新上传")
+	}
+
+	if err := generateResubmitCover(ctx, videoFile, status.Resubmissions+1); err != nil {
+		logger.Warn().Err(err).Str("video_dir", videoDir).Msg("生成重新上传封面失败，沿用原封面")
+	}
+	if err := s.fileManager.MarkVideoResubmit(videoDir); err != nil {
+		return fmt.Errorf("重置上传状态失败: %w", err)
+	}
+
+	if queue != nil {
+		item := file.QueueItem{
+			VideoID:   filepath.Base(videoDir),
+			ChannelID: filepath.Base(filepath.Dir(videoDir)),
+			VideoDir:  videoDir,
+		}
+		if info, err := s.fileManager.LoadVideoInfo(videoDir); err == nil && info != nil && info.ID != "" {
+			item.VideoID = info.ID
+		}
+		if _, err := queue.Enqueue(item); err != nil {
+			logger.Warn().Err(err).Str("video_dir", videoDir).Msg("加入上传队列失败，将由 upload 命令重新上传")
+		}
+	}
+	logger.Info().
+		Str("video_dir", videoDir).
+		Str("rejected_aid", status.BilibiliAID).
+		Int("resubmission", status.Resubmissions+1).
+		Msg("退回的视频已重新排队上传")
+	return nil
+}
+
+// resubmitTitle 重新上传的视频在标题后追加序号，避免与被退回的稿件完全相同
+func (s *uploadService) resubmitTitle(videoDir, title string) string {
+	status, err := s.fileManager.LoadUploadStatus(videoDir)
+	if err != nil || status.Resubmissions == 0 {
+		return title
+	}
+	return fmt.Sprintf("%s (%d)", title, status.Resubmissions+1)
+}
+
+// generateResubmitCover 从视频中截取一帧（第 n 次重新上传取时长的 n*20% 处，最多 80%）生成 1280x720 封面
+func generateResubmitCover(ctx context.Context, videoFile string, n int) error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("未检测到 ffmpeg")
+	}
+	offset := 0.0
+	if duration, err := probeDuration(ctx, videoFile); err == nil {
+		fraction := 0.2 * float64(n)
+		if fraction > 0.8 {
+			fraction = 0.8
+		}
+		offset = duration * fraction
+	}
+	outPath := filepath.Join(filepath.Dir(videoFile), file.ResubmitCoverFile)
+	vf := "scale='if(gt(a,16/9),1280,-1)':'if(gt(a,16/9),-1,720)',pad=1280:720:(ow-iw)/2:(oh-ih)/2:color=black,format=yuv420p"
+	cmd := utils.CommandContext(ctx, "ffmpeg", "-y", "-ss", strconv.FormatFloat(offset, 'f', 2, 64), "-i", videoFile, "-vf", vf, "-frames:v", "1", "-q:v", "2", outPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(outPath)
+		return fmt.Errorf("ffmpeg 截帧失败: %w, output=%s", err, string(out))
+	}
+	return nil
+}
+
+// probeDuration 使用 ffprobe 获取视频时长（秒）
+func probeDuration(ctx context.Context, videoFile string) (float64, error) {
+	out, err := utils.CommandContext(ctx, "ffprobe", "-v", "error", "-show_entries", "format=duration", "-of", "csv=p=0", videoFile).Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+}
+
+// filterDirsUnder 只保留位于 root（频道目录或视频目录）下的视频目录
+func filterDirsUnder(dirs []string, root string) ([]string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("解析目录路径失败: %w", err)
+	}
+	filtered := dirs[:0]
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		if abs == absRoot || strings.HasPrefix(abs, absRoot+string(filepath.Separator)) {
+			filtered = append(filtered, dir)
+		}
+	}
+	return filtered, nil
+}
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"blueberry/internal/config"
+	"context"
 	"io"
 	"os"
 	"time"
@@ -121,3 +122,20 @@ func Logger() zerolog.Logger {
 func Printf(format string, v ...interface{}) {
 	base.Info().Msgf(format, v...)
 }
+
+type ctxKey struct{}
+
+// WithContext 将 l 附加到 ctx，之后 Ctx(ctx) 返回 l（用于给并发 worker 的日志加上 worker / seq 字段）
+func WithContext(ctx context.Context, l zerolog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// Ctx 返回 ctx 中附加的日志器，没有时返回全局日志器
+func Ctx(ctx context.Context) *zerolog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxKey{}).(zerolog.Logger); ok {
+			return &l
+		}
+	}
+	return &base
+}
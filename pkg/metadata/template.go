@@ -0,0 +1,228 @@
+package metadata
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"blueberry/pkg/sanitize"
+)
+
+// Data 模板中可用的视频字段
+type Data struct {
+	ID             string
+	Title          string
+	Description    string // .description 文件或 video_info.json 中的完整描述（未裁剪）
+	Channel        string
+	ChannelID      string
+	ChannelURL     string
+	Uploader       string
+	UploadDate     time.Time // 由 YYYYMMDD 解析，未知时为零值
+	Duration       time.Duration
+	DurationString string
+	Playlist       string
+	PlaylistIndex  int
+	URL            string   // 原视频链接
+	Tags           []string // YouTube 标签
+}
+
+// Result 渲染后的投稿信息
+type Result struct {
+	Title       string
+	Description string
+	Tags        []string
+}
+
+// Templates 标题、简介与标签模板，未配置的字段保持默认值
+type Templates struct {
+	title       *template.Template
+	description *template.Template
+	tags        *template.Template
+}
+
+var (
+	urlPattern     = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+	hashtagPattern = regexp.MustCompile(`#([\p{L}\p{N}_]+)`)
+	blankLines     = regexp.MustCompile(`\n{3,}`)
+)
+
+// Funcs 模板辅助函数
+//
+//	truncate N S    按字符数裁剪（超出时以 … 结尾）
+//	stripURLs S     去除链接
+//	hashtags S      提取 #话题 （去掉 #，去重）
+//	first N LIST    取前 N 个
+//	join SEP LIST   连接字符串列表
+//	default D S     S 为空时使用 D
+//	date LAYOUT T   格式化时间（零值时为空）
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"truncate":  Truncate,
+		"stripURLs": StripURLs,
+		"hashtags":  Hashtags,
+		"first": func(n int, list []string) []string {
+			if n >= 0 && len(list) > n {
+				return list[:n]
+			}
+			return list
+		},
+		"join": func(sep string, list []string) string { return strings.Join(list, sep) },
+		"default": func(def, s string) string {
+			if strings.TrimSpace(s) == "" {
+				return def
+			}
+			return s
+		},
+		"date": func(layout string, t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.Format(layout)
+		},
+		"trim":  strings.TrimSpace,
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+	}
+}
+
+// Parse 解析模板，空字符串表示该字段不使用模板
+func Parse(title, description, tags string) (*Templates, error) {
+	t := &Templates{}
+	var err error
+	if t.title, err = parseField("title", title); err != nil {
+		return nil, err
+	}
+	if t.description, err = parseField("description", description); err != nil {
+		return nil, err
+	}
+	if t.tags, err = parseField("tags", tags); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func parseField(name, text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New(name).Funcs(Funcs()).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 模板失败: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// Empty 是否没有配置任何模板
+func (t *Templates) Empty() bool {
+	return t == nil || (t.title == nil && t.description == nil && t.tags == nil)
+}
+
+// Render 渲染模板；未配置模板的字段使用 defaults 中的值。结果不做长度校验（见 Validate）
+func (t *Templates) Render(data Data, defaults Result) (Result, error) {
+	result := defaults
+	if t == nil {
+		return result, nil
+	}
+	if t.title != nil {
+		out, err := execute(t.title, data)
+		if err != nil {
+			return result, err
+		}
+		result.Title = strings.Join(strings.Fields(out), " ")
+	}
+	if t.description != nil {
+		out, err := execute(t.description, data)
+		if err != nil {
+			return result, err
+		}
+		result.Description = strings.TrimSpace(blankLines.ReplaceAllString(out, "\n\n"))
+	}
+	if t.tags != nil {
+		out, err := execute(t.tags, data)
+		if err != nil {
+			return result, err
+		}
+		result.Tags = SplitTags(out)
+	}
+	return result, nil
+}
+
+func execute(tmpl *template.Template, data Data) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("渲染 %s 模板失败: %w", tmpl.Name(), err)
+	}
+	return buf.String(), nil
+}
+
+// Validate 按 B站限制校验投稿信息
+func Validate(r Result) error {
+	if strings.TrimSpace(r.Title) == "" {
+		return fmt.Errorf("标题不能为空")
+	}
+	if n := utf8.RuneCountInString(r.Title); n > sanitize.MaxTitleRunes {
+		return fmt.Errorf("标题长度 %d 超过限制 %d 个字符", n, sanitize.MaxTitleRunes)
+	}
+	if n := utf8.RuneCountInString(r.Description); n > sanitize.MaxDescriptionRunes {
+		return fmt.Errorf("简介长度 %d 超过限制 %d 个字符", n, sanitize.MaxDescriptionRunes)
+	}
+	if len(r.Tags) > sanitize.MaxTags {
+		return fmt.Errorf("标签数量 %d 超过限制 %d 个", len(r.Tags), sanitize.MaxTags)
+	}
+	for _, tag := range r.Tags {
+		if n := utf8.RuneCountInString(tag); n > sanitize.MaxTagRunes {
+			return fmt.Errorf("标签 %q 长度 %d 超过限制 %d 个字符", tag, n, sanitize.MaxTagRunes)
+		}
+	}
+	return nil
+}
+
+// Truncate 按字符数裁剪，超出时保留 n-1 个字符并以 … 结尾
+func Truncate(n int, s string) string {
+	return sanitize.TruncateRunes(strings.TrimRight(s, " \t\r\n"), n)
+}
+
+// StripURLs 去除文本中的链接（http(s)://、www.）
+func StripURLs(s string) string {
+	s = urlPattern.ReplaceAllString(s, "")
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// Hashtags 提取文本中的 #话题（不含 #，按出现顺序去重）
+func Hashtags(s string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, m := range hashtagPattern.FindAllStringSubmatch(s, -1) {
+		key := strings.ToLower(m[1])
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		tags = append(tags, m[1])
+	}
+	return tags
+}
+
+// SplitTags 将标签模板的输出按逗号或换行拆分，去掉 #、空白与重复项
+func SplitTags(s string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '，' || r == '\n' }) {
+		tag = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(tag), "#"))
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
@@ -0,0 +1,305 @@
+package sanitize
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// B站海外版投稿的长度限制（按字符数，即 rune 计）
+const (
+	MaxTitleRunes       = 80
+	MaxDescriptionRunes = 1500
+	MaxTags             = 10
+	MaxTagRunes         = 20
+)
+
+// 投稿信息字段
+const (
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldTags        = "tags"
+)
+
+// 修改规则
+const (
+	RuleNormalize = "normalize"     // Unicode NFC 归一化
+	RuleControl   = "control_chars" // 去除控制字符与不可见格式字符
+	RuleEmoji     = "emoji"         // 去除标题 / 标签中的 emoji
+	RuleBanned    = "banned_phrase" // 去除禁用词
+	RuleLink      = "link"          // 去除链接
+	RuleTruncate  = "truncate"      // 超出长度限制被裁剪
+	RuleDropped   = "dropped"       // 标签被丢弃（为空、重复或超出数量）
+)
+
+// Rules 清洗规则
+type Rules struct {
+	BannedPhrases   []string // 禁用词（不区分大小写），从标题、简介与标签中去除
+	BannedDomains   []string // 禁止出现的链接域名（含子域名），对应链接从简介与标题中去除
+	StripLinks      bool     // 去除简介中的所有链接
+	StripTitleEmoji bool     // 去除标题与标签中的 emoji
+}
+
+// Metadata 待发布的投稿信息
+type Metadata struct {
+	Title       string
+	Description string
+	Tags        []string
+}
+
+// Change 一次修改记录（用于审计）
+type Change struct {
+	Field  string `json:"field"`
+	Rule   string `json:"rule"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Sanitizer 投稿信息清洗器
+type Sanitizer struct {
+	rules   Rules
+	banned  []*regexp.Regexp
+	domains []string
+}
+
+var (
+	urlPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"'）)】]+`)
+	spaces     = regexp.MustCompile(`[ \t\p{Zs}]+`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// New 创建清洗器
+func New(rules Rules) *Sanitizer {
+	s := &Sanitizer{rules: rules}
+	for _, phrase := range rules.BannedPhrases {
+		if phrase = strings.TrimSpace(phrase); phrase != "" {
+			s.banned = append(s.banned, regexp.MustCompile(`(?i)`+regexp.QuoteMeta(phrase)))
+		}
+	}
+	for _, domain := range rules.BannedDomains {
+		if domain = strings.ToLower(strings.Trim(strings.TrimSpace(domain), ".")); domain != "" {
+			s.domains = append(s.domains, domain)
+		}
+	}
+	return s
+}
+
+// Apply 清洗标题、简介与标签并按长度限制裁剪，返回结果与全部修改记录
+func (s *Sanitizer) Apply(m Metadata) (Metadata, []Change) {
+	var changes []Change
+	record := func(field, rule, detail string) {
+		changes = append(changes, Change{Field: field, Rule: rule, Detail: detail})
+	}
+
+	out := Metadata{
+		Title:       s.Title(m.Title, MaxTitleRunes, record),
+		Description: s.description(m.Description, record),
+	}
+	out.Tags = s.tags(m.Tags, record)
+	return out, changes
+}
+
+// Title 清洗标题并裁剪到 limit 个字符；record 可为 nil
+func (s *Sanitizer) Title(title string, limit int, record func(field, rule, detail string)) string {
+	if record == nil {
+		record = func(string, string, string) {}
+	}
+	title = s.common(FieldTitle, title, false, record)
+	title = s.removeLinks(FieldTitle, title, true, record)
+	if s.rules.StripTitleEmoji {
+		title = stripEmoji(FieldTitle, title, record)
+	}
+	title = strings.TrimSpace(spaces.ReplaceAllString(title, " "))
+	if n := utf8.RuneCountInString(title); n > limit {
+		title = TruncateRunes(title, limit)
+		record(FieldTitle, RuleTruncate, fmt.Sprintf("%d → %d 个字符", n, utf8.RuneCountInString(title)))
+	}
+	return title
+}
+
+func (s *Sanitizer) description(desc string, record func(field, rule, detail string)) string {
+	desc = s.common(FieldDescription, desc, true, record)
+	desc = s.removeLinks(FieldDescription, desc, s.rules.StripLinks, record)
+
+	lines := strings.Split(desc, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(spaces.ReplaceAllString(line, " "), " ")
+	}
+	desc = strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+
+	if n := utf8.RuneCountInString(desc); n > MaxDescriptionRunes {
+		desc = TruncateText(desc, MaxDescriptionRunes)
+		record(FieldDescription, RuleTruncate, fmt.Sprintf("%d → %d 个字符", n, utf8.RuneCountInString(desc)))
+	}
+	return desc
+}
+
+func (s *Sanitizer) tags(tags []string, record func(field, rule, detail string)) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		original := tag
+		tag = s.common(FieldTags, tag, false, record)
+		tag = strings.TrimLeft(strings.TrimSpace(tag), "#")
+		if s.rules.StripTitleEmoji {
+			tag = stripEmoji(FieldTags, tag, record)
+		}
+		tag = strings.TrimSpace(spaces.ReplaceAllString(tag, " "))
+		key := strings.ToLower(tag)
+		switch {
+		case tag == "" || seen[key]:
+			if strings.TrimSpace(original) != "" {
+				record(FieldTags, RuleDropped, original)
+			}
+			continue
+		case len(out) >= MaxTags:
+			record(FieldTags, RuleDropped, fmt.Sprintf("%s（超过 %d 个）", original, MaxTags))
+			continue
+		}
+		if n := utf8.RuneCountInString(tag); n > MaxTagRunes {
+			tag = string([]rune(tag)[:MaxTagRunes])
+			record(FieldTags, RuleTruncate, original)
+		}
+		seen[key] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// common 归一化、去除控制字符与禁用词；multiline 为 false 时换行替换为空格
+func (s *Sanitizer) common(field, text string, multiline bool, record func(field, rule, detail string)) string {
+	if normalized := norm.NFC.String(text); normalized != text {
+		text = normalized
+		record(field, RuleNormalize, "")
+	}
+
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	removed := 0
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			if multiline {
+				return r
+			}
+			return ' '
+		case r == utf8.RuneError, unicode.IsControl(r), isInvisible(r):
+			removed++
+			return -1
+		}
+		return r
+	}, text)
+	if removed > 0 {
+		record(field, RuleControl, fmt.Sprintf("%d 个字符", removed))
+	}
+
+	for _, re := range s.banned {
+		if matches := re.FindAllString(text, -1); len(matches) > 0 {
+			text = re.ReplaceAllString(text, "")
+			record(field, RuleBanned, matches[0])
+		}
+	}
+	return text
+}
+
+// removeLinks 去除链接：all 为 true 时去除全部链接，否则只去除禁止域名的链接
+func (s *Sanitizer) removeLinks(field, text string, all bool, record func(field, rule, detail string)) string {
+	if !all && len(s.domains) == 0 {
+		return text
+	}
+	return urlPattern.ReplaceAllStringFunc(text, func(link string) string {
+		if all || s.bannedLink(link) {
+			record(field, RuleLink, link)
+			return ""
+		}
+		return link
+	})
+}
+
+func (s *Sanitizer) bannedLink(link string) bool {
+	host := strings.ToLower(link)
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+	if i := strings.IndexAny(host, "/?#:"); i >= 0 {
+		host = host[:i]
+	}
+	for _, domain := range s.domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// isInvisible 零宽字符、BOM 与双向文本控制符
+func isInvisible(r rune) bool {
+	switch {
+	case r == 0x200B, r == 0x2060, r == 0xFEFF, r == 0x00AD:
+		return true
+	case r >= 0x202A && r <= 0x202E, r >= 0x2066 && r <= 0x2069:
+		return true
+	}
+	return false
+}
+
+// isEmoji emoji 及其修饰符（变体选择符、肤色、零宽连接符、旗帜）
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF, // 麻将牌、扑克、旗帜、表情、符号与象形文字
+		r >= 0x2600 && r <= 0x27BF,   // 杂项符号、装饰符号
+		r >= 0x2B00 && r <= 0x2BFF,   // ⭐ ⬆ 等
+		r >= 0xFE00 && r <= 0xFE0F,   // 变体选择符
+		r >= 0xE0020 && r <= 0xE007F, // 标签字符（区旗）
+		r == 0x200D, r == 0x20E3, r == 0x3030, r == 0x303D:
+		return true
+	}
+	return false
+}
+
+func stripEmoji(field, text string, record func(field, rule, detail string)) string {
+	removed := 0
+	text = strings.Map(func(r rune) rune {
+		if isEmoji(r) {
+			removed++
+			return -1
+		}
+		return r
+	}, text)
+	if removed > 0 {
+		record(field, RuleEmoji, fmt.Sprintf("%d 个字符", removed))
+	}
+	return text
+}
+
+// TruncateRunes 按字符数裁剪单行文本，超出时保留 n-1 个字符并以 … 结尾
+func TruncateRunes(s string, n int) string {
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return strings.TrimRight(string([]rune(s)[:n-1]), " ") + "…"
+}
+
+// TruncateText 按字符数裁剪多行文本，尽量在最后 200 个字符内的换行处（其次 100 个字符内的空格处）裁剪
+func TruncateText(s string, n int) string {
+	runes := []rune(s)
+	if n <= 0 || len(runes) <= n {
+		return s
+	}
+	pos := n
+	for i := n; i > n-200 && i > 0; i-- {
+		if runes[i-1] == '\n' {
+			pos = i
+			break
+		}
+	}
+	if pos == n {
+		for i := n; i > n-100 && i > 0; i-- {
+			if runes[i-1] == ' ' {
+				pos = i
+				break
+			}
+		}
+	}
+	return strings.TrimRight(string(runes[:pos]), " \t\n")
+}
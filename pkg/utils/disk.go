@@ -0,0 +1,21 @@
+package utils
+
+import "errors"
+
+// ErrNotSupported 当前平台不支持该操作
+var ErrNotSupported = errors.New("当前平台不支持")
+
+// FreeDiskSpace 返回 path 所在文件系统对当前用户可用的剩余空间（字节）
+// 非 Unix 平台返回 ErrNotSupported，调用方应跳过基于磁盘空间的判断
+func FreeDiskSpace(path string) (uint64, error) {
+	return freeDiskSpace(path)
+}
+
+// ProcessAlive 判断本机上 pid 对应的进程是否仍在运行
+// 无法判断时（非 Unix 平台）保守地返回 true
+func ProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	return processAlive(pid)
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package utils
+
+func freeDiskSpace(path string) (uint64, error) {
+	return 0, ErrNotSupported
+}
+
+func processAlive(pid int) bool {
+	return true
+}
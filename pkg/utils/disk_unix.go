@@ -0,0 +1,22 @@
+//go:build unix
+
+package utils
+
+import (
+	"errors"
+	"syscall"
+)
+
+func freeDiskSpace(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
+
+func processAlive(pid int) bool {
+	// 信号 0 只做存在性与权限检查；EPERM 说明进程存在但属于其他用户
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
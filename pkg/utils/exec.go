@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// SubprocessWaitDelay ctx 取消后等待子进程自行退出的时间，超时后强制 kill
+const SubprocessWaitDelay = 15 * time.Second
+
+// CommandContext 与 exec.CommandContext 相同，但 ctx 取消时先向子进程发送中断信号，
+// 让 yt-dlp / ffmpeg 有机会落盘 .part 与分片、清理临时文件后自行退出，
+// 超过 SubprocessWaitDelay 仍未退出才强制 kill
+func CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Cancel = func() error {
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			// 不支持中断信号的平台（Windows）直接 kill
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+	cmd.WaitDelay = SubprocessWaitDelay
+	return cmd
+}
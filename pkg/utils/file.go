@@ -26,3 +26,47 @@ func JoinPath(elem ...string) string {
 	return filepath.Join(elem...)
 }
 
+// WriteFileAtomic 原子写文件：先写入同目录下的临时文件并 fsync，再 rename 覆盖目标文件，
+// 最后 fsync 所在目录，保证进程崩溃或断电时目标文件要么是旧内容、要么是完整的新内容
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	// 任何一步失败都清理临时文件
+	success := false
+	defer func() {
+		if !success {
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	success = true
+
+	// rename 之后 fsync 目录，确保目录项落盘（部分平台不支持对目录 fsync，忽略错误）
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileLock 跨进程的建议性文件锁（advisory lock）
+// 锁加在独立的 .lock 文件上而不是数据文件本身，因为数据文件会被原子 rename 替换，
+// 锁住旧 inode 对后续打开的进程没有意义。
+type FileLock struct {
+	path string
+	file *os.File
+}
+
+// LockFile 获取 path 对应的排他锁（阻塞直到获得），返回的锁需调用 Unlock 释放
+// 同一进程内的多个 goroutine 分别调用 LockFile 也会互斥
+func LockFile(path string) (*FileLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建锁文件目录失败: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开锁文件失败: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("获取文件锁失败 (%s): %w", path, err)
+	}
+	return &FileLock{path: path, file: f}, nil
+}
+
+// Unlock 释放锁（锁文件保留，避免删除与其他进程加锁之间的竞态）
+func (l *FileLock) Unlock() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	err := unlockFile(l.file)
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	l.file = nil
+	return err
+}
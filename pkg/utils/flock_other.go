@@ -0,0 +1,14 @@
+//go:build !unix
+
+package utils
+
+import "os"
+
+// 非 Unix 平台不提供跨进程锁，仅保证调用方代码可以编译运行
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"context"
+	"sync"
+)
+
+// RunWorkers 启动 workers 个 worker 处理 total 个任务
+// 任务按下标从小到大依次分发（worker 空闲后领取下一个），fn 的 worker 参数从 1 开始；
+// ctx 取消后不再分发新任务，已开始的任务由 fn 自行响应取消。所有已分发的任务结束后返回。
+func RunWorkers(ctx context.Context, workers, total int, fn func(ctx context.Context, worker, index int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > total {
+		workers = total
+	}
+
+	// 单 worker 时直接在当前 goroutine 中执行，行为与顺序处理完全一致
+	if workers <= 1 {
+		for i := 0; i < total; i++ {
+			if ctx.Err() != nil {
+				return
+			}
+			fn(ctx, 1, i)
+		}
+		return
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 1; w <= workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for index := range jobs {
+				fn(ctx, worker, index)
+			}
+		}(w)
+	}
+
+dispatch:
+	for i := 0; i < total; i++ {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}